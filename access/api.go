@@ -2,11 +2,15 @@ package access
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	"github.com/onflow/flow/protobuf/go/flow/access"
 	"github.com/onflow/flow/protobuf/go/flow/entities"
 
+	"github.com/onflow/flow-go/crypto"
 	"github.com/onflow/flow-go/engine/common/rpc/convert"
+	"github.com/onflow/flow-go/model/encodable"
 	"github.com/onflow/flow-go/model/flow"
 )
 
@@ -28,6 +32,19 @@ type API interface {
 	SendTransaction(ctx context.Context, tx *flow.TransactionBody) error
 	GetTransaction(ctx context.Context, id flow.Identifier) (*flow.TransactionBody, error)
 	GetTransactionResult(ctx context.Context, id flow.Identifier) (*TransactionResult, error)
+	// GetTransactionResultsByBlockID returns the results of all transactions of the block, in the order
+	// of their execution, i.e. ordered by collection and ending with the result of the system transaction.
+	GetTransactionResultsByBlockID(ctx context.Context, blockID flow.Identifier) ([]*TransactionResult, error)
+	// GetTransactionResultByIndex returns the result of the transaction with the given index in the block,
+	// using the same order as GetTransactionResultsByBlockID.
+	GetTransactionResultByIndex(ctx context.Context, blockID flow.Identifier, index uint32) (*TransactionResult, error)
+	// GetTransactionTimeline returns the status transitions of the transaction so far, with the time and
+	// block height of each transition, and the collection cluster responsible for the transaction.
+	GetTransactionTimeline(ctx context.Context, id flow.Identifier) (*TransactionTimeline, error)
+	// SimulateTransaction executes the transaction against the execution state of the block without
+	// submitting it, or against the latest sealed block if the block ID is flow.ZeroID. The signatures
+	// of the transaction are not verified if skipSignatureCheck is set.
+	SimulateTransaction(ctx context.Context, tx *flow.TransactionBody, blockID flow.Identifier, skipSignatureCheck bool) (*flow.TransactionSimulation, error)
 
 	GetAccount(ctx context.Context, address flow.Address) (*flow.Account, error)
 	GetAccountAtLatestBlock(ctx context.Context, address flow.Address) (*flow.Account, error)
@@ -39,16 +56,126 @@ type API interface {
 
 	GetEventsForHeightRange(ctx context.Context, eventType string, startHeight, endHeight uint64) ([]flow.BlockEvents, error)
 	GetEventsForBlockIDs(ctx context.Context, eventType string, blockIDs []flow.Identifier) ([]flow.BlockEvents, error)
+
+	// GetExecutionResultForBlockID returns the sealed execution result of the block.
+	GetExecutionResultForBlockID(ctx context.Context, blockID flow.Identifier) (*flow.ExecutionResult, error)
+	// GetSealForBlockID returns the seal of the block, which was included in a finalized block.
+	GetSealForBlockID(ctx context.Context, blockID flow.Identifier) (*flow.Seal, error)
+
+	// The subscriptions are only served by the REST API: the Access API protobuf definitions have no
+	// streaming methods, so they cannot be served over gRPC until they are added there.
+
+	// SubscribeBlocks streams the finalized (or sealed) blocks as *flow.Block, starting at the given
+	// height, or at the latest block if the height is 0.
+	SubscribeBlocks(ctx context.Context, startHeight uint64, sealed bool) (Subscription, error)
+	// SubscribeEvents streams the events matching the filter as *flow.BlockEvents, one per sealed block,
+	// starting at the given height, or at the latest sealed block if the height is 0.
+	SubscribeEvents(ctx context.Context, startHeight uint64, filter EventFilter) (Subscription, error)
+	// SubscribeTransactionStatus streams the result of the transaction as *TransactionResult
+	// whenever its status changes, until the transaction is sealed or expired.
+	SubscribeTransactionStatus(ctx context.Context, txID flow.Identifier) (Subscription, error)
+
+	// GetCurrentEpoch returns the epoch of the latest finalized block.
+	GetCurrentEpoch(ctx context.Context) (*Epoch, error)
+	// GetEpochByCounter returns the epoch with the given counter. The epochs of the spork up to the next
+	// epoch of the latest finalized block are available.
+	GetEpochByCounter(ctx context.Context, counter uint64) (*Epoch, error)
+	// GetNodeIdentities returns the identity table of the network at the given block.
+	GetNodeIdentities(ctx context.Context, blockID flow.Identifier) (flow.IdentityList, error)
+	// GetProtocolStateSnapshot returns the JSON encoded root snapshot of the protocol state, from which
+	// another node can be bootstrapped.
+	GetProtocolStateSnapshot(ctx context.Context) ([]byte, error)
 }
 
 // TODO: Combine this with flow.TransactionResult?
 type TransactionResult struct {
-	Status       flow.TransactionStatus
+	Status flow.TransactionStatus
+	// StatusCode is 0 if the transaction succeeded, and otherwise the code of its error in the
+	// catalogue of FVM error codes (see fvm.ErrorCodesVersion).
 	StatusCode   uint
 	Events       []flow.Event
 	ErrorMessage string
 }
 
+// TransactionTimeline describes the progress of a transaction through the network.
+type TransactionTimeline struct {
+	TransactionID flow.Identifier
+	ClusterIndex  uint              // index of the collection cluster responsible for the transaction
+	Cluster       []flow.Identifier // node IDs of the collection cluster responsible for the transaction
+	Transitions   []TransactionStatusTransition
+}
+
+// TransactionStatusTransition is the transition of a transaction into a status.
+type TransactionStatusTransition struct {
+	Status      flow.TransactionStatus
+	Time        time.Time // time at which the access node observed the transition, or the timestamp of the block; zero if unknown
+	BlockHeight uint64    // height of the block which caused the transition
+}
+
+// Epoch describes an epoch of the protocol.
+type Epoch struct {
+	Counter     uint64
+	FirstView   uint64
+	FinalView   uint64
+	Identities  flow.IdentityList   // initial identity table of the epoch
+	Clusters    [][]flow.Identifier // node IDs of the collection clusters, by cluster index
+	DKGGroupKey crypto.PublicKey    // group key of the random beacon; nil if the epoch is not committed yet
+	// Phase is the epoch phase of the finalized block the epoch was read at: the latest finalized block
+	// for the current and next epoch, and the last finalized block of the epoch for past epochs.
+	Phase flow.EpochPhase
+}
+
+// epochJSON is the JSON encoding of an Epoch, with the DKG group key encoded as a random beacon key
+type epochJSON struct {
+	Counter     uint64
+	FirstView   uint64
+	FinalView   uint64
+	Identities  flow.IdentityList
+	Clusters    [][]flow.Identifier
+	DKGGroupKey encodable.RandomBeaconPubKey
+	Phase       flow.EpochPhase
+}
+
+func (e Epoch) MarshalJSON() ([]byte, error) {
+	return json.Marshal(epochJSON{
+		Counter:     e.Counter,
+		FirstView:   e.FirstView,
+		FinalView:   e.FinalView,
+		Identities:  e.Identities,
+		Clusters:    e.Clusters,
+		DKGGroupKey: encodable.RandomBeaconPubKey{PublicKey: e.DKGGroupKey},
+		Phase:       e.Phase,
+	})
+}
+
+func (e *Epoch) UnmarshalJSON(b []byte) error {
+	var encoded epochJSON
+	err := json.Unmarshal(b, &encoded)
+	if err != nil {
+		return err
+	}
+	*e = Epoch{
+		Counter:     encoded.Counter,
+		FirstView:   encoded.FirstView,
+		FinalView:   encoded.FinalView,
+		Identities:  encoded.Identities,
+		Clusters:    encoded.Clusters,
+		DKGGroupKey: encoded.DKGGroupKey.PublicKey,
+		Phase:       encoded.Phase,
+	}
+	return nil
+}
+
+// ProtocolStateSnapshot is a snapshot of the protocol state, from which a node can be bootstrapped.
+// It contains the same data as the root bootstrap files.
+type ProtocolStateSnapshot struct {
+	Block          *flow.Block
+	QC             *flow.QuorumCertificate
+	Result         *flow.ExecutionResult
+	Seal           *flow.Seal
+	EpochFirstView uint64
+}
+
 func TransactionResultToMessage(result *TransactionResult) *access.TransactionResultResponse {
 	return &access.TransactionResultResponse{
 		Status:       entities.TransactionStatus(result.Status),
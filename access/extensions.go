@@ -0,0 +1,256 @@
+package access
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// The Extensions service serves the methods of the Access API which the protobuf definitions of the Access
+// API contain no messages for. The service is declared by hand: requests and responses are JSON encoded,
+// wrapped into BytesValue messages.
+const extensionsServiceName = "flow.access.AccessAPIExtensions"
+
+// TransactionResultsRequest is a request for the results of all transactions of a block.
+type TransactionResultsRequest struct {
+	BlockID flow.Identifier
+}
+
+// TransactionResultByIndexRequest is a request for the result of the transaction with the given index in
+// a block.
+type TransactionResultByIndexRequest struct {
+	BlockID flow.Identifier
+	Index   uint32
+}
+
+// CurrentEpochRequest is a request for the epoch of the latest finalized block.
+type CurrentEpochRequest struct{}
+
+// EpochRequest is a request for the epoch with the given counter.
+type EpochRequest struct {
+	Counter uint64
+}
+
+// NodeIdentitiesRequest is a request for the identity table of the network at a block.
+type NodeIdentitiesRequest struct {
+	BlockID flow.Identifier
+}
+
+// ProtocolStateSnapshotRequest is a request for the JSON encoded root snapshot of the protocol state.
+type ProtocolStateSnapshotRequest struct{}
+
+// Extensions is the part of the Access API served by the Extensions service. It is implemented by the
+// Handler, and by the client of the service.
+type Extensions interface {
+	GetTransactionResultsByBlockID(ctx context.Context, req *TransactionResultsRequest) ([]*TransactionResult, error)
+	GetTransactionResultByIndex(ctx context.Context, req *TransactionResultByIndexRequest) (*TransactionResult, error)
+	GetCurrentEpoch(ctx context.Context, req *CurrentEpochRequest) (*Epoch, error)
+	GetEpochByCounter(ctx context.Context, req *EpochRequest) (*Epoch, error)
+	GetNodeIdentities(ctx context.Context, req *NodeIdentitiesRequest) (flow.IdentityList, error)
+	GetProtocolStateSnapshot(ctx context.Context, req *ProtocolStateSnapshotRequest) ([]byte, error)
+}
+
+// RegisterExtensionsServer registers the Extensions service on the gRPC server.
+func RegisterExtensionsServer(s *grpc.Server, srv Extensions) {
+	s.RegisterService(&extensionsServiceDesc, srv)
+}
+
+var extensionsServiceDesc = grpc.ServiceDesc{
+	ServiceName: extensionsServiceName,
+	HandlerType: (*Extensions)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetTransactionResultsByBlockID",
+			Handler: extensionHandler("GetTransactionResultsByBlockID",
+				func() interface{} { return new(TransactionResultsRequest) },
+				func(ctx context.Context, srv Extensions, req interface{}) (interface{}, error) {
+					return srv.GetTransactionResultsByBlockID(ctx, req.(*TransactionResultsRequest))
+				}),
+		},
+		{
+			MethodName: "GetTransactionResultByIndex",
+			Handler: extensionHandler("GetTransactionResultByIndex",
+				func() interface{} { return new(TransactionResultByIndexRequest) },
+				func(ctx context.Context, srv Extensions, req interface{}) (interface{}, error) {
+					return srv.GetTransactionResultByIndex(ctx, req.(*TransactionResultByIndexRequest))
+				}),
+		},
+		{
+			MethodName: "GetCurrentEpoch",
+			Handler: extensionHandler("GetCurrentEpoch",
+				func() interface{} { return new(CurrentEpochRequest) },
+				func(ctx context.Context, srv Extensions, req interface{}) (interface{}, error) {
+					return srv.GetCurrentEpoch(ctx, req.(*CurrentEpochRequest))
+				}),
+		},
+		{
+			MethodName: "GetEpochByCounter",
+			Handler: extensionHandler("GetEpochByCounter",
+				func() interface{} { return new(EpochRequest) },
+				func(ctx context.Context, srv Extensions, req interface{}) (interface{}, error) {
+					return srv.GetEpochByCounter(ctx, req.(*EpochRequest))
+				}),
+		},
+		{
+			MethodName: "GetNodeIdentities",
+			Handler: extensionHandler("GetNodeIdentities",
+				func() interface{} { return new(NodeIdentitiesRequest) },
+				func(ctx context.Context, srv Extensions, req interface{}) (interface{}, error) {
+					return srv.GetNodeIdentities(ctx, req.(*NodeIdentitiesRequest))
+				}),
+		},
+		{
+			MethodName: "GetProtocolStateSnapshot",
+			Handler: extensionHandler("GetProtocolStateSnapshot",
+				func() interface{} { return new(ProtocolStateSnapshotRequest) },
+				func(ctx context.Context, srv Extensions, req interface{}) (interface{}, error) {
+					return srv.GetProtocolStateSnapshot(ctx, req.(*ProtocolStateSnapshotRequest))
+				}),
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "extensions.go",
+}
+
+// extensionHandler returns the gRPC handler of the method of the Extensions service, which decodes the
+// request created by newReq, calls the method and encodes its response.
+func extensionHandler(
+	method string,
+	newReq func() interface{},
+	call func(ctx context.Context, srv Extensions, req interface{}) (interface{}, error),
+) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+
+	return func(
+		srv interface{},
+		ctx context.Context,
+		dec func(interface{}) error,
+		interceptor grpc.UnaryServerInterceptor,
+	) (interface{}, error) {
+
+		in := new(wrappers.BytesValue)
+		err := dec(in)
+		if err != nil {
+			return nil, err
+		}
+
+		handle := func(ctx context.Context, in interface{}) (interface{}, error) {
+			req := newReq()
+			err := json.Unmarshal(in.(*wrappers.BytesValue).GetValue(), req)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+			}
+
+			res, err := call(ctx, srv.(Extensions), req)
+			if err != nil {
+				return nil, err
+			}
+
+			data, err := json.Marshal(res)
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to encode response: %v", err)
+			}
+			return &wrappers.BytesValue{Value: data}, nil
+		}
+
+		if interceptor == nil {
+			return handle(ctx, in)
+		}
+		info := &grpc.UnaryServerInfo{
+			Server:     srv,
+			FullMethod: "/" + extensionsServiceName + "/" + method,
+		}
+		return interceptor(ctx, in, info, handle)
+	}
+}
+
+type extensionsClient struct {
+	conn grpc.ClientConnInterface
+}
+
+// NewExtensionsClient returns a client of the Extensions service served on the given connection.
+func NewExtensionsClient(conn grpc.ClientConnInterface) Extensions {
+	return &extensionsClient{conn: conn}
+}
+
+func (c *extensionsClient) GetTransactionResultsByBlockID(ctx context.Context, req *TransactionResultsRequest) ([]*TransactionResult, error) {
+	var results []*TransactionResult
+	err := c.invoke(ctx, "GetTransactionResultsByBlockID", req, &results)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (c *extensionsClient) GetTransactionResultByIndex(ctx context.Context, req *TransactionResultByIndexRequest) (*TransactionResult, error) {
+	var result TransactionResult
+	err := c.invoke(ctx, "GetTransactionResultByIndex", req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *extensionsClient) GetCurrentEpoch(ctx context.Context, req *CurrentEpochRequest) (*Epoch, error) {
+	var epoch Epoch
+	err := c.invoke(ctx, "GetCurrentEpoch", req, &epoch)
+	if err != nil {
+		return nil, err
+	}
+	return &epoch, nil
+}
+
+func (c *extensionsClient) GetEpochByCounter(ctx context.Context, req *EpochRequest) (*Epoch, error) {
+	var epoch Epoch
+	err := c.invoke(ctx, "GetEpochByCounter", req, &epoch)
+	if err != nil {
+		return nil, err
+	}
+	return &epoch, nil
+}
+
+func (c *extensionsClient) GetNodeIdentities(ctx context.Context, req *NodeIdentitiesRequest) (flow.IdentityList, error) {
+	var identities flow.IdentityList
+	err := c.invoke(ctx, "GetNodeIdentities", req, &identities)
+	if err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+func (c *extensionsClient) GetProtocolStateSnapshot(ctx context.Context, req *ProtocolStateSnapshotRequest) ([]byte, error) {
+	var snapshot []byte
+	err := c.invoke(ctx, "GetProtocolStateSnapshot", req, &snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// invoke calls the method of the Extensions service with the request, and decodes its response into res
+func (c *extensionsClient) invoke(ctx context.Context, method string, req interface{}, res interface{}) error {
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("could not encode request: %w", err)
+	}
+
+	out := new(wrappers.BytesValue)
+	err = c.conn.Invoke(ctx, "/"+extensionsServiceName+"/"+method, &wrappers.BytesValue{Value: data}, out)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(out.GetValue(), res)
+	if err != nil {
+		return fmt.Errorf("could not decode response: %w", err)
+	}
+
+	return nil
+}
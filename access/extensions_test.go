@@ -0,0 +1,147 @@
+package access_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/onflow/flow-go/access"
+	accessmock "github.com/onflow/flow-go/access/mock"
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/utils/unittest"
+)
+
+// serveExtensions serves the Extensions service of the handler on an in-memory connection, and returns a
+// client connected to it
+func serveExtensions(t *testing.T, api access.API) access.Extensions {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	access.RegisterExtensionsServer(server, access.NewHandler(api, flow.Testnet.Chain()))
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}),
+		grpc.WithInsecure(),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return access.NewExtensionsClient(conn)
+}
+
+func TestExtensionsTransactionResults(t *testing.T) {
+	blockID := unittest.IdentifierFixture()
+	results := []*access.TransactionResult{
+		{
+			Status: flow.TransactionStatusSealed,
+			Events: []flow.Event{unittest.EventFixture(flow.EventAccountCreated, 0, 0, unittest.IdentifierFixture())},
+		},
+		{
+			Status:       flow.TransactionStatusSealed,
+			StatusCode:   100,
+			ErrorMessage: "execution error",
+		},
+	}
+
+	t.Run("results by block ID", func(t *testing.T) {
+		api := new(accessmock.API)
+		api.On("GetTransactionResultsByBlockID", mock.Anything, blockID).Return(results, nil)
+
+		actual, err := serveExtensions(t, api).GetTransactionResultsByBlockID(context.Background(), &access.TransactionResultsRequest{BlockID: blockID})
+		require.NoError(t, err)
+		require.Equal(t, results, actual)
+		api.AssertExpectations(t)
+	})
+
+	t.Run("result by index", func(t *testing.T) {
+		api := new(accessmock.API)
+		api.On("GetTransactionResultByIndex", mock.Anything, blockID, uint32(1)).Return(results[1], nil)
+
+		actual, err := serveExtensions(t, api).GetTransactionResultByIndex(context.Background(), &access.TransactionResultByIndexRequest{BlockID: blockID, Index: 1})
+		require.NoError(t, err)
+		require.Equal(t, results[1], actual)
+		api.AssertExpectations(t)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		api := new(accessmock.API)
+		api.On("GetTransactionResultByIndex", mock.Anything, blockID, uint32(2)).Return(nil, status.Error(codes.NotFound, "no transaction with index 2"))
+
+		_, err := serveExtensions(t, api).GetTransactionResultByIndex(context.Background(), &access.TransactionResultByIndexRequest{BlockID: blockID, Index: 2})
+		require.Error(t, err)
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+func TestExtensionsEpochs(t *testing.T) {
+	identities := flow.IdentityList{
+		{NodeID: unittest.IdentifierFixture(), Address: "collection-1:3569", Role: flow.RoleCollection, Stake: 1000},
+		{NodeID: unittest.IdentifierFixture(), Address: "collection-2:3569", Role: flow.RoleCollection, Stake: 1000},
+	}
+	epoch := &access.Epoch{
+		Counter:    2,
+		FirstView:  1000,
+		FinalView:  1999,
+		Identities: identities,
+		Clusters:   [][]flow.Identifier{identities.NodeIDs()},
+		Phase:      flow.EpochPhaseSetup,
+	}
+
+	t.Run("current epoch", func(t *testing.T) {
+		api := new(accessmock.API)
+		api.On("GetCurrentEpoch", mock.Anything).Return(epoch, nil)
+
+		actual, err := serveExtensions(t, api).GetCurrentEpoch(context.Background(), &access.CurrentEpochRequest{})
+		require.NoError(t, err)
+		require.Equal(t, epoch, actual)
+	})
+
+	t.Run("epoch by counter", func(t *testing.T) {
+		api := new(accessmock.API)
+		api.On("GetEpochByCounter", mock.Anything, uint64(2)).Return(epoch, nil)
+		api.On("GetEpochByCounter", mock.Anything, uint64(5)).Return(nil, status.Error(codes.NotFound, "epoch is not available"))
+
+		extensions := serveExtensions(t, api)
+		actual, err := extensions.GetEpochByCounter(context.Background(), &access.EpochRequest{Counter: 2})
+		require.NoError(t, err)
+		require.Equal(t, epoch, actual)
+
+		_, err = extensions.GetEpochByCounter(context.Background(), &access.EpochRequest{Counter: 5})
+		require.Error(t, err)
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("node identities", func(t *testing.T) {
+		blockID := unittest.IdentifierFixture()
+		api := new(accessmock.API)
+		api.On("GetNodeIdentities", mock.Anything, blockID).Return(identities, nil)
+
+		actual, err := serveExtensions(t, api).GetNodeIdentities(context.Background(), &access.NodeIdentitiesRequest{BlockID: blockID})
+		require.NoError(t, err)
+		require.Equal(t, identities, actual)
+	})
+
+	t.Run("protocol state snapshot", func(t *testing.T) {
+		snapshot := []byte(`{"EpochFirstView":42}`)
+		api := new(accessmock.API)
+		api.On("GetProtocolStateSnapshot", mock.Anything).Return(snapshot, nil)
+
+		actual, err := serveExtensions(t, api).GetProtocolStateSnapshot(context.Background(), &access.ProtocolStateSnapshotRequest{})
+		require.NoError(t, err)
+		require.Equal(t, snapshot, actual)
+	})
+}
@@ -18,6 +18,8 @@ type Handler struct {
 	chain flow.Chain
 }
 
+var _ Extensions = &Handler{}
+
 func NewHandler(api API, chain flow.Chain) *Handler {
 	return &Handler{
 		api:   api,
@@ -221,6 +223,46 @@ func (h *Handler) GetTransactionResult(
 	return TransactionResultToMessage(result), nil
 }
 
+// GetTransactionResultsByBlockID gets the results of all transactions of a block, in the order of their
+// execution. It is served by the Extensions service.
+func (h *Handler) GetTransactionResultsByBlockID(
+	ctx context.Context,
+	req *TransactionResultsRequest,
+) ([]*TransactionResult, error) {
+	return h.api.GetTransactionResultsByBlockID(ctx, req.BlockID)
+}
+
+// GetTransactionResultByIndex gets the result of the transaction with the given index in a block. It is
+// served by the Extensions service.
+func (h *Handler) GetTransactionResultByIndex(
+	ctx context.Context,
+	req *TransactionResultByIndexRequest,
+) (*TransactionResult, error) {
+	return h.api.GetTransactionResultByIndex(ctx, req.BlockID, req.Index)
+}
+
+// GetCurrentEpoch gets the epoch of the latest finalized block. It is served by the Extensions service.
+func (h *Handler) GetCurrentEpoch(ctx context.Context, _ *CurrentEpochRequest) (*Epoch, error) {
+	return h.api.GetCurrentEpoch(ctx)
+}
+
+// GetEpochByCounter gets the epoch with the given counter. It is served by the Extensions service.
+func (h *Handler) GetEpochByCounter(ctx context.Context, req *EpochRequest) (*Epoch, error) {
+	return h.api.GetEpochByCounter(ctx, req.Counter)
+}
+
+// GetNodeIdentities gets the identity table of the network at a block. It is served by the Extensions
+// service.
+func (h *Handler) GetNodeIdentities(ctx context.Context, req *NodeIdentitiesRequest) (flow.IdentityList, error) {
+	return h.api.GetNodeIdentities(ctx, req.BlockID)
+}
+
+// GetProtocolStateSnapshot gets the JSON encoded root snapshot of the protocol state. It is served by the
+// Extensions service.
+func (h *Handler) GetProtocolStateSnapshot(ctx context.Context, _ *ProtocolStateSnapshotRequest) ([]byte, error) {
+	return h.api.GetProtocolStateSnapshot(ctx)
+}
+
 // GetAccount returns an account by address at the latest sealed block.
 func (h *Handler) GetAccount(
 	ctx context.Context,
@@ -0,0 +1,70 @@
+package access
+
+import (
+	"github.com/onflow/cadence"
+	jsoncdc "github.com/onflow/cadence/encoding/json"
+
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// Subscription streams the responses of a subscription of the Access API.
+type Subscription interface {
+	// Channel returns the channel streaming the responses. It is closed when the subscription ends.
+	Channel() <-chan interface{}
+
+	// Err returns the error which ended the subscription, once the channel is closed. It is the
+	// context's error if the subscription was canceled, and nil if all responses were sent.
+	Err() error
+}
+
+// EventFilter selects the events streamed by an events subscription.
+type EventFilter struct {
+	// EventTypes are the types of the streamed events, at least one type is required.
+	EventTypes []flow.EventType
+
+	// Addresses restricts the streamed events, if not empty, to the events with
+	// a field (or an optional field) holding one of the addresses.
+	Addresses []flow.Address
+}
+
+// Match returns whether the event is of one of the filter's types, and holds one of the
+// filter's addresses. Events, whose payload cannot be decoded, do not match any addresses.
+func (f EventFilter) Match(event flow.Event) bool {
+	matched := false
+	for _, eventType := range f.EventTypes {
+		if event.Type == eventType {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return false
+	}
+	if len(f.Addresses) == 0 {
+		return true
+	}
+
+	value, err := jsoncdc.Decode(event.Payload)
+	if err != nil {
+		return false
+	}
+	cadenceEvent, ok := value.(cadence.Event)
+	if !ok {
+		return false
+	}
+	for _, field := range cadenceEvent.Fields {
+		if optional, ok := field.(cadence.Optional); ok {
+			field = optional.Value
+		}
+		address, ok := field.(cadence.Address)
+		if !ok {
+			continue
+		}
+		for _, filtered := range f.Addresses {
+			if flow.Address(address) == filtered {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package access
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/utils/unittest"
+)
+
+func TestEventFilter(t *testing.T) {
+	chain := flow.Testnet.Chain()
+	address1 := chain.ServiceAddress()
+	address2, err := chain.AddressAtIndex(10)
+	require.NoError(t, err)
+
+	// eventWithField returns an event with a single field holding the given JSON-Cadence value
+	eventWithField := func(eventType flow.EventType, value string) flow.Event {
+		event := unittest.EventFixture(eventType, 0, 0, unittest.IdentifierFixture())
+		event.Payload = []byte(fmt.Sprintf(
+			`{"type":"Event","value":{"id":"%s","fields":[{"name":"field","value":%s}]}}`,
+			eventType, value,
+		))
+		return event
+	}
+	addressValue := func(address flow.Address) string {
+		return fmt.Sprintf(`{"type":"Address","value":"%s"}`, address.HexWithPrefix())
+	}
+
+	created := eventWithField(flow.EventAccountCreated, addressValue(address1))
+	updated := eventWithField(flow.EventAccountUpdated, addressValue(address1))
+	optional := eventWithField(flow.EventAccountCreated, fmt.Sprintf(`{"type":"Optional","value":%s}`, addressValue(address2)))
+	noAddress := eventWithField(flow.EventAccountCreated, `{"type":"String","value":"abc"}`)
+
+	t.Run("event types", func(t *testing.T) {
+		filter := EventFilter{EventTypes: []flow.EventType{flow.EventAccountCreated}}
+		require.True(t, filter.Match(created))
+		require.True(t, filter.Match(noAddress))
+		require.False(t, filter.Match(updated))
+	})
+
+	t.Run("addresses", func(t *testing.T) {
+		filter := EventFilter{
+			EventTypes: []flow.EventType{flow.EventAccountCreated, flow.EventAccountUpdated},
+			Addresses:  []flow.Address{address2},
+		}
+		require.False(t, filter.Match(created))
+		require.True(t, filter.Match(optional))
+		require.False(t, filter.Match(noAddress))
+
+		filter.Addresses = append(filter.Addresses, address1)
+		require.True(t, filter.Match(created))
+		require.True(t, filter.Match(updated))
+	})
+
+	t.Run("undecodable payload", func(t *testing.T) {
+		filter := EventFilter{
+			EventTypes: []flow.EventType{flow.EventAccountCreated},
+			Addresses:  []flow.Address{address1},
+		}
+		event := unittest.EventFixture(flow.EventAccountCreated, 0, 0, unittest.IdentifierFixture())
+		event.Payload = []byte("not json-cadence")
+		require.False(t, filter.Match(event))
+	})
+}
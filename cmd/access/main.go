@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
 	"google.golang.org/grpc"
@@ -10,17 +11,22 @@ import (
 	"github.com/onflow/flow/protobuf/go/flow/access"
 	"github.com/onflow/flow/protobuf/go/flow/execution"
 
+	accessapi "github.com/onflow/flow-go/access"
 	"github.com/onflow/flow-go/cmd"
 	"github.com/onflow/flow-go/consensus"
 	"github.com/onflow/flow-go/consensus/hotstuff/committees"
 	"github.com/onflow/flow-go/consensus/hotstuff/verification"
 	recovery "github.com/onflow/flow-go/consensus/recovery/protocol"
 	"github.com/onflow/flow-go/engine"
+	"github.com/onflow/flow-go/engine/access/indexer"
 	"github.com/onflow/flow-go/engine/access/ingestion"
 	pingeng "github.com/onflow/flow-go/engine/access/ping"
 	"github.com/onflow/flow-go/engine/access/rpc"
+	"github.com/onflow/flow-go/engine/access/rpc/backend"
+	"github.com/onflow/flow-go/engine/access/rpc/ratelimit"
 	followereng "github.com/onflow/flow-go/engine/common/follower"
 	"github.com/onflow/flow-go/engine/common/requester"
+	"github.com/onflow/flow-go/engine/common/rpc/simulation"
 	synceng "github.com/onflow/flow-go/engine/common/synchronization"
 	"github.com/onflow/flow-go/model/encoding"
 	"github.com/onflow/flow-go/model/flow"
@@ -34,6 +40,7 @@ import (
 	"github.com/onflow/flow-go/module/synchronization"
 	"github.com/onflow/flow-go/state/protocol"
 	badgerState "github.com/onflow/flow-go/state/protocol/badger"
+	storageapi "github.com/onflow/flow-go/storage"
 	storage "github.com/onflow/flow-go/storage/badger"
 	grpcutils "github.com/onflow/flow-go/utils/grpc"
 )
@@ -56,6 +63,7 @@ func main() {
 		rpcEng                       *rpc.Engine
 		collectionRPC                access.AccessAPIClient
 		executionRPC                 execution.ExecutionAPIClient
+		simulationRPC                simulation.API
 		historicalAccessRPCs         []access.AccessAPIClient
 		err                          error
 		conCache                     *buffer.PendingBlocks // pending block cache for follower
@@ -64,12 +72,19 @@ func main() {
 		collectionsToMarkExecuted    *stdmap.Times
 		blocksToMarkExecuted         *stdmap.Times
 		transactionMetrics           module.TransactionMetrics
+		executionNodeQueryMetrics    module.ExecutionNodeQueryMetrics
+		rateLimitMetrics             module.RateLimitMetrics
+		rateLimitMethods             string
+		scriptComputeBurst           time.Duration
 		pingMetrics                  module.PingMetrics
 		logTxTimeToFinalized         bool
 		logTxTimeToExecuted          bool
 		logTxTimeToFinalizedExecuted bool
 		retryEnabled                 bool
 		rpcMetricsEnabled            bool
+		indexingEnabled              bool
+		events                       *storage.Events
+		transactionResults           *storage.TransactionResults
 	)
 
 	cmd.FlowNode(flow.RoleAccess.String()).
@@ -80,15 +95,27 @@ func main() {
 			flags.UintVar(&collectionGRPCPort, "collection-ingress-port", 9000, "the grpc ingress port for all collection nodes")
 			flags.StringVarP(&rpcConf.GRPCListenAddr, "rpc-addr", "r", "localhost:9000", "the address the gRPC server listens on")
 			flags.StringVarP(&rpcConf.HTTPListenAddr, "http-addr", "h", "localhost:8000", "the address the http proxy server listens on")
+			flags.StringVar(&rpcConf.RESTListenAddr, "rest-addr", "", "the address the REST API server listens on (disabled if empty)")
 			flags.StringVarP(&rpcConf.CollectionAddr, "static-collection-ingress-addr", "", "", "the address (of the collection node) to send transactions to")
 			flags.StringVarP(&rpcConf.ExecutionAddr, "script-addr", "s", "localhost:9000", "the address (of the execution node) forward the script to")
+			flags.UintVar(&rpcConf.ExecutionGRPCPort, "execution-api-port", 9000, "the grpc port of the execution API of all execution nodes")
+			flags.UintVar(&rpcConf.ExecutionQueryCount, "execution-query-count", 2, "number of execution nodes, which produced a receipt for the block, that need to return the same response to a request (0 forwards all requests to --script-addr)")
 			flags.StringVarP(&rpcConf.HistoricalAccessAddrs, "historical-access-addr", "", "", "comma separated rpc addresses for historical access nodes")
+			flags.UintVar(&rpcConf.ResponseCache.Size, "response-cache-size", 0, "maximum number of cached responses for script results, events and accounts at sealed blocks (0 to disable)")
+			flags.DurationVar(&rpcConf.ResponseCache.TTL, "response-cache-ttl", 10*time.Minute, "time after which cached responses expire (0 to never expire)")
+			flags.Float64Var(&rpcConf.RateLimits.Default.Rate, "rate-limit", 0, "number of calls per second each client may make to each gRPC or REST API method (0 to disable)")
+			flags.Float64Var(&rpcConf.RateLimits.Default.Burst, "rate-limit-burst", 0, "number of calls each client may make to each gRPC or REST API method in a burst (0 defaults to --rate-limit, but at least 1)")
+			flags.StringVar(&rateLimitMethods, "rate-limit-methods", "", "comma separated rate limits of individual Access API methods, overriding --rate-limit, in the format method=rate[:burst]")
+			flags.Float64Var(&rpcConf.RateLimits.ScriptCompute.Rate, "script-compute-limit", 0, "seconds of script execution per second each client may use (0 to disable)")
+			flags.DurationVar(&scriptComputeBurst, "script-compute-burst", 10*time.Second, "script execution time each client may use in a burst")
+			flags.StringSliceVar(&rpcConf.RateLimits.APIKeys, "rate-limit-api-keys", nil, "comma separated API keys, which clients send in the x-api-key header to be rate limited by key instead of by IP address")
 			flags.BoolVar(&logTxTimeToFinalized, "log-tx-time-to-finalized", false, "log transaction time to finalized")
 			flags.BoolVar(&logTxTimeToExecuted, "log-tx-time-to-executed", false, "log transaction time to executed")
 			flags.BoolVar(&logTxTimeToFinalizedExecuted, "log-tx-time-to-finalized-executed", false, "log transaction time to finalized and executed")
 			flags.BoolVar(&pingEnabled, "ping-enabled", false, "whether to enable the ping process that pings all other peers and report the connectivity to metrics")
 			flags.BoolVar(&retryEnabled, "retry-enabled", false, "whether to enable the retry mechanism at the access node level")
 			flags.BoolVar(&rpcMetricsEnabled, "rpc-metrics-enabled", false, "whether to enable the rpc metrics")
+			flags.BoolVar(&indexingEnabled, "index-execution-results", false, "whether to index the events and transaction results of sealed blocks, to serve them without requesting them from execution nodes")
 			flags.StringVarP(&nodeInfoFile, "node-info-file", "", "", "full path to a json file which provides more details about nodes when reporting its reachability metrics")
 		}).
 		Module("mutable follower state", func(node *cmd.FlowNodeBuilder) error {
@@ -134,7 +161,8 @@ func main() {
 			if err != nil {
 				return err
 			}
-			executionRPC = execution.NewExecutionAPIClient(executionRPCConn)
+			executionRPC = backend.NewExecutionClient(executionRPCConn)
+			simulationRPC = simulation.NewClient(executionRPCConn)
 			return nil
 		}).
 		Module("historical access node clients", func(node *cmd.FlowNodeBuilder) error {
@@ -156,6 +184,19 @@ func main() {
 			}
 			return nil
 		}).
+		Module("execution results storage", func(node *cmd.FlowNodeBuilder) error {
+			events = storage.NewEvents(node.DB)
+			transactionResults = storage.NewTransactionResults(node.DB)
+			return nil
+		}).
+		Module("execution receipt executors index", func(node *cmd.FlowNodeBuilder) error {
+			// receipts stored before their executors were indexed by the executed block are indexed once
+			receipts, ok := node.Storage.Receipts.(*storage.ExecutionReceipts)
+			if !ok {
+				return fmt.Errorf("only implementations of type badger.ExecutionReceipts are currently supported but receipts storage has type %T", node.Storage.Receipts)
+			}
+			return receipts.IndexExecutors()
+		}).
 		Module("block cache", func(node *cmd.FlowNodeBuilder) error {
 			conCache = buffer.NewPendingBlocks()
 			return nil
@@ -188,26 +229,72 @@ func main() {
 				logTxTimeToExecuted, logTxTimeToFinalizedExecuted)
 			return nil
 		}).
+		Module("execution node query metrics", func(node *cmd.FlowNodeBuilder) error {
+			executionNodeQueryMetrics = metrics.NewExecutionNodeQueryCollector()
+			return nil
+		}).
+		Module("rate limit metrics", func(node *cmd.FlowNodeBuilder) error {
+			rateLimitMetrics = metrics.NewRateLimitCollector()
+			return nil
+		}).
+		Module("rate limits", func(node *cmd.FlowNodeBuilder) error {
+			rpcConf.RateLimits.Methods, err = ratelimit.ParseMethodLimits(rateLimitMethods)
+			if err != nil {
+				return fmt.Errorf("invalid rate limits: %w", err)
+			}
+			rpcConf.RateLimits.ScriptCompute.Burst = scriptComputeBurst.Seconds()
+			err = rpcConf.RateLimits.Validate()
+			if err != nil {
+				return fmt.Errorf("invalid rate limits: %w", err)
+			}
+			return nil
+		}).
 		Module("ping metrics", func(node *cmd.FlowNodeBuilder) error {
 			pingMetrics = metrics.NewPingCollector()
 			return nil
 		}).
 		Component("RPC engine", func(node *cmd.FlowNodeBuilder) (module.ReadyDoneAware, error) {
+			// events and transaction results are only served locally, if they are indexed
+			var indexedEvents storageapi.Events
+			var indexedTransactionResults storageapi.TransactionResults
+			if indexingEnabled {
+				indexedEvents = events
+				indexedTransactionResults = transactionResults
+			}
 			rpcEng = rpc.New(
 				node.Logger,
-				node.State,
 				rpcConf,
-				executionRPC,
-				collectionRPC,
-				historicalAccessRPCs,
-				node.Storage.Blocks,
-				node.Storage.Headers,
-				node.Storage.Collections,
-				node.Storage.Transactions,
-				node.RootChainID,
-				transactionMetrics,
-				collectionGRPCPort,
-				retryEnabled,
+				backend.Params{
+					State:                 node.State,
+					ExecutionRPC:          executionRPC,
+					SimulationRPC:         simulationRPC,
+					CollectionRPC:         collectionRPC,
+					HistoricalAccessNodes: historicalAccessRPCs,
+					Blocks:                node.Storage.Blocks,
+					Headers:               node.Storage.Headers,
+					Collections:           node.Storage.Collections,
+					Transactions:          node.Storage.Transactions,
+					Events:                indexedEvents,
+					TransactionResults:    indexedTransactionResults,
+					Seals:                 node.Storage.Seals,
+					Results:               node.Storage.Results,
+					Receipts:              node.Storage.Receipts,
+					ChainID:               node.RootChainID,
+					RootSnapshot: &accessapi.ProtocolStateSnapshot{
+						Block:          node.RootBlock,
+						QC:             node.RootQC,
+						Result:         node.RootResult,
+						Seal:           node.RootSeal,
+						EpochFirstView: node.RootBlock.Header.View,
+					},
+					TransactionMetrics:        transactionMetrics,
+					TransactionTimings:        transactionTimings,
+					ExecutionNodeQueryMetrics: executionNodeQueryMetrics,
+					CacheMetrics:              node.Metrics.Cache,
+					CollectionGRPCPort:        collectionGRPCPort,
+					RetryEnabled:              retryEnabled,
+				},
+				rateLimitMetrics,
 				rpcMetricsEnabled,
 			)
 			return rpcEng, nil
@@ -231,6 +318,19 @@ func main() {
 			requestEng.WithHandle(ingestEng.OnCollection)
 			return ingestEng, err
 		}).
+		Component("indexer engine", func(node *cmd.FlowNodeBuilder) (module.ReadyDoneAware, error) {
+			return indexer.New(
+				node.Logger,
+				node.State,
+				executionRPC,
+				node.Storage.Blocks,
+				node.Storage.Collections,
+				events,
+				transactionResults,
+				node.RootChainID,
+				indexingEnabled,
+			), nil
+		}).
 		Component("requester engine", func(node *cmd.FlowNodeBuilder) (module.ReadyDoneAware, error) {
 			// We initialize the requester engine inside the ingestion engine due to the mutual dependency. However, in
 			// order for it to properly start and shut down, we should still return it as its own engine here, so it can
@@ -29,6 +29,7 @@ import (
 	"github.com/onflow/flow-go/engine/execution/state/bootstrap"
 	"github.com/onflow/flow-go/fvm"
 	ledger "github.com/onflow/flow-go/ledger/complete"
+	"github.com/onflow/flow-go/ledger/complete/mtrie"
 	wal "github.com/onflow/flow-go/ledger/complete/wal"
 	bootstrapFilenames "github.com/onflow/flow-go/model/bootstrap"
 	"github.com/onflow/flow-go/model/encoding"
@@ -67,9 +68,14 @@ func main() {
 		err                   error
 		executionState        state.ExecutionState
 		triedir               string
+		trieArchiveDir        string
+		compactProofs         bool
+		registerHistory       bool
 		collector             module.ExecutionMetrics
 		mTrieCacheSize        uint32
+		mTrieMemoryBudget     uint64
 		checkpointDistance    uint
+		checkpointDeltaChain  uint
 		stateDeltasLimit      uint
 		requestInterval       time.Duration
 		preferredExeNodeIDStr string
@@ -77,6 +83,9 @@ func main() {
 		syncFast              bool
 		syncThreshold         int
 		extensiveLog          bool
+		executionParallelism  uint
+		programCacheSize      int
+		programCacheDir       string
 	)
 
 	cmd.FlowNode(flow.RoleExecution.String()).
@@ -86,8 +95,13 @@ func main() {
 
 			flags.StringVarP(&rpcConf.ListenAddr, "rpc-addr", "i", "localhost:9000", "the address the gRPC server listens on")
 			flags.StringVar(&triedir, "triedir", datadir, "directory to store the execution State")
+			flags.StringVar(&trieArchiveDir, "trie-archive-dir", "", "directory to archive tries evicted from the MTrie cache, enables answering queries for any past state (disabled if empty)")
+			flags.BoolVar(&compactProofs, "compact-proofs", false, "encode the register proofs of chunk data packs compactly, only enable once all verification nodes decode compact proofs")
+			flags.BoolVar(&registerHistory, "register-history", false, "index the heights of the blocks writing each register, enables querying the history of registers")
 			flags.Uint32Var(&mTrieCacheSize, "mtrie-cache-size", 1000, "cache size for MTrie")
+			flags.Uint64Var(&mTrieMemoryBudget, "mtrie-memory-budget", 0, "approximate memory budget in bytes for MTrie, least recently used tries are evicted when exceeded (0 means no budget)")
 			flags.UintVar(&checkpointDistance, "checkpoint-distance", 10, "number of WAL segments between checkpoints")
+			flags.UintVar(&checkpointDeltaChain, "checkpoint-delta-chain", 0, "maximum number of consecutive delta checkpoints between full checkpoints (0 disables delta checkpoints)")
 			flags.UintVar(&stateDeltasLimit, "state-deltas-limit", 1000, "maximum number of state deltas in the memory pool")
 			flags.DurationVar(&requestInterval, "request-interval", 60*time.Second, "the interval between requests for the requester engine")
 			flags.StringVar(&preferredExeNodeIDStr, "preferred-exe-node-id", "", "node ID for preferred execution node used for state sync")
@@ -95,6 +109,9 @@ func main() {
 			flags.BoolVar(&syncFast, "sync-fast", false, "fast sync allows execution node to skip fetching collection during state syncing, and rely on state syncing to catch up")
 			flags.IntVar(&syncThreshold, "sync-threshold", 100, "the maximum number of sealed and unexecuted blocks before triggering state syncing")
 			flags.BoolVar(&extensiveLog, "extensive-logging", false, "extensive logging logs tx contents and block headers")
+			flags.UintVar(&executionParallelism, "execution-parallelism", 1, "number of transactions of a collection to execute optimistically in parallel (1 executes them serially)")
+			flags.IntVar(&programCacheSize, "program-cache-size", 0, "maximum number of parsed contract programs cached across blocks, programs are still checked on every import (disabled if 0)")
+			flags.StringVar(&programCacheDir, "program-cache-dir", "", "directory to store the cached contract programs in, so that they are loaded on restart (disabled if empty)")
 		}).
 		Module("mutable follower state", func(node *cmd.FlowNodeBuilder) error {
 			// For now, we only support state implementations from package badger.
@@ -116,7 +133,17 @@ func main() {
 			rt := runtime.NewInterpreterRuntime()
 
 			vm := fvm.New(rt)
-			vmCtx := fvm.NewContext(node.Logger, node.FvmOptions...)
+
+			vmOptions := append([]fvm.Option{}, node.FvmOptions...)
+			if programCacheSize > 0 {
+				programCache, err := fvm.NewProgramCache(programCacheSize, programCacheDir)
+				if err != nil {
+					return fmt.Errorf("could not create program cache: %w", err)
+				}
+				vmOptions = append(vmOptions, fvm.WithProgramCache(programCache))
+			}
+
+			vmCtx := fvm.NewContext(node.Logger, vmOptions...)
 
 			manager, err := computation.New(
 				node.Logger,
@@ -126,6 +153,7 @@ func main() {
 				node.State,
 				vm,
 				vmCtx,
+				executionParallelism,
 			)
 			computationManager = manager
 
@@ -187,8 +215,19 @@ func main() {
 				}
 			}
 
-			ledgerStorage, err = ledger.NewLedger(triedir, int(mTrieCacheSize), collector, node.Logger.With().Str("subcomponent", "ledger").Logger(), node.MetricsRegisterer, ledger.DefaultPathFinderVersion)
-			return ledgerStorage, err
+			ledgerLogger := node.Logger.With().Str("subcomponent", "ledger").Logger()
+			if trieArchiveDir != "" {
+				ledgerStorage, err = ledger.NewArchivalLedger(triedir, trieArchiveDir, int(mTrieCacheSize), collector, ledgerLogger, node.MetricsRegisterer, ledger.DefaultPathFinderVersion, mtrie.WithMemoryBudget(mTrieMemoryBudget))
+			} else {
+				ledgerStorage, err = ledger.NewLedger(triedir, int(mTrieCacheSize), collector, ledgerLogger, node.MetricsRegisterer, ledger.DefaultPathFinderVersion, mtrie.WithMemoryBudget(mTrieMemoryBudget))
+			}
+			if err != nil {
+				return nil, err
+			}
+			if compactProofs {
+				ledgerStorage.EnableCompactProofs()
+			}
+			return ledgerStorage, nil
 		}).
 		Component("execution state ledger WAL compactor", func(node *cmd.FlowNodeBuilder) (module.ReadyDoneAware, error) {
 
@@ -196,6 +235,7 @@ func main() {
 			if err != nil {
 				return nil, fmt.Errorf("cannot create checkpointer: %w", err)
 			}
+			checkpointer.SetMaxDeltaChain(checkpointDeltaChain)
 			compactor := wal.NewCompactor(checkpointer, 10*time.Second, checkpointDistance)
 
 			return compactor, nil
@@ -204,6 +244,11 @@ func main() {
 			chunkDataPacks := storage.NewChunkDataPacks(node.DB)
 			stateCommitments := storage.NewCommits(node.Metrics.Cache, node.DB)
 
+			var stateOpts []state.OptionFunc
+			if registerHistory {
+				stateOpts = append(stateOpts, state.WithRegisterHistory())
+			}
+
 			executionState = state.NewExecutionState(
 				ledgerStorage,
 				stateCommitments,
@@ -214,6 +259,7 @@ func main() {
 				receipts,
 				node.DB,
 				node.Tracer,
+				stateOpts...,
 			)
 
 			providerEngine, err = exeprovider.New(
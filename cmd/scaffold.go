@@ -58,6 +58,8 @@ type BaseConfig struct {
 	profilerDir      string
 	profilerInterval time.Duration
 	profilerDuration time.Duration
+
+	scheduledTransactionsEnabled bool
 }
 
 type Metrics struct {
@@ -157,6 +159,8 @@ func (fnb *FlowNodeBuilder) baseFlags() {
 		"the interval between auto-profiler runs")
 	fnb.flags.DurationVar(&fnb.BaseConfig.profilerDuration, "profiler-duration", 10*time.Second,
 		"the duration to run the auto-profile for")
+	fnb.flags.BoolVar(&fnb.BaseConfig.scheduledTransactionsEnabled, "scheduled-transactions-enabled", false,
+		"whether transactions can schedule transactions, must be set on all execution and verification nodes alike")
 }
 
 func (fnb *FlowNodeBuilder) enqueueNetworkInit() {
@@ -566,6 +570,9 @@ func (fnb *FlowNodeBuilder) initFvmOptions() {
 			fvm.WithRestrictedDeployment(false),
 		)
 	}
+	if fnb.BaseConfig.scheduledTransactionsEnabled {
+		vmOpts = append(vmOpts, fvm.WithScheduledTransactions(true))
+	}
 	fnb.FvmOptions = vmOpts
 }
 
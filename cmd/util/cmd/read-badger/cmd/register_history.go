@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"math"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/onflow/flow-go/cmd/util/cmd/common"
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/storage/badger/operation"
+)
+
+var flagOwner string
+var flagController string
+var flagKey string
+var flagFromHeight uint64
+var flagToHeight uint64
+
+func init() {
+	rootCmd.AddCommand(registerHistoryCmd)
+
+	registerHistoryCmd.Flags().StringVar(&flagOwner, "owner", "", "the owner of the register (hex encoded)")
+	registerHistoryCmd.Flags().StringVar(&flagController, "controller", "", "the controller of the register (hex encoded)")
+	registerHistoryCmd.Flags().StringVar(&flagKey, "key", "", "the key of the register (hex encoded)")
+	_ = registerHistoryCmd.MarkFlagRequired("key")
+
+	registerHistoryCmd.Flags().Uint64Var(&flagFromHeight, "from-height", 0, "the lowest height of the blocks to list")
+	registerHistoryCmd.Flags().Uint64Var(&flagToHeight, "to-height", math.MaxUint64, "the highest height of the blocks to list")
+}
+
+var registerHistoryCmd = &cobra.Command{
+	Use:   "register-history",
+	Short: "get the blocks which wrote a register, requires the register history index of the execution node",
+	Run: func(cmd *cobra.Command, args []string) {
+		db := common.InitStorage(flagDatadir)
+
+		var parts [3]string
+		for i, flag := range []string{flagOwner, flagController, flagKey} {
+			part, err := hex.DecodeString(flag)
+			if err != nil {
+				log.Fatal().Err(err).Msgf("malformed register id part: %s", flag)
+			}
+			parts[i] = string(part)
+		}
+		registerID := flow.NewRegisterID(parts[0], parts[1], parts[2])
+
+		log.Info().Msgf("getting register history between heights %d and %d", flagFromHeight, flagToHeight)
+		var writes []flow.RegisterWrite
+		err := db.View(operation.LookupRegisterHistory(registerID, flagFromHeight, flagToHeight, &writes))
+		if err != nil {
+			log.Fatal().Err(err).Msg("could not get register history")
+		}
+
+		log.Info().Msgf("found %d blocks writing the register", len(writes))
+		for _, write := range writes {
+			common.PrettyPrint(write)
+		}
+	},
+}
@@ -6,7 +6,9 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
+	diff_tries "github.com/onflow/flow-go/cmd/util/cmd/read-execution-state/diff-tries"
 	list_accounts "github.com/onflow/flow-go/cmd/util/cmd/read-execution-state/list-accounts"
+	list_registers "github.com/onflow/flow-go/cmd/util/cmd/read-execution-state/list-registers"
 	list_tries "github.com/onflow/flow-go/cmd/util/cmd/read-execution-state/list-tries"
 	list_wals "github.com/onflow/flow-go/cmd/util/cmd/read-execution-state/list-wals"
 
@@ -39,7 +41,9 @@ func init() {
 func addSubcommands() {
 	Cmd.AddCommand(list_tries.Init(loadExecutionState))
 	Cmd.AddCommand(list_accounts.Init(loadExecutionState))
+	Cmd.AddCommand(list_registers.Init(loadExecutionState))
 	Cmd.AddCommand(list_wals.Init())
+	Cmd.AddCommand(diff_tries.Init(loadExecutionState))
 }
 
 func loadExecutionState() *mtrie.Forest {
@@ -0,0 +1,125 @@
+package diff_tries
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/complete/mtrie"
+)
+
+var cmd = &cobra.Command{
+	Use:   "diff-tries",
+	Short: "Lists registers added, removed and modified between two state commitments (as json lines)",
+	Run:   run,
+}
+
+var stateLoader func() *mtrie.Forest = nil
+var flagFrom string
+var flagTo string
+var flagOutputFile string
+
+func Init(f func() *mtrie.Forest) *cobra.Command {
+	stateLoader = f
+
+	cmd.Flags().StringVar(&flagFrom, "from", "",
+		"State commitment to compare from (64 chars, hex-encoded)")
+	_ = cmd.MarkFlagRequired("from")
+
+	cmd.Flags().StringVar(&flagTo, "to", "",
+		"State commitment to compare to (64 chars, hex-encoded)")
+	_ = cmd.MarkFlagRequired("to")
+
+	cmd.Flags().StringVar(&flagOutputFile, "output-file", "",
+		"file to write the changes to, stdout if empty")
+
+	return cmd
+}
+
+// payloadChange is the json representation of a ledger.PayloadChange
+type payloadChange struct {
+	Path   string
+	Change string
+	Old    *ledger.Payload `json:",omitempty"`
+	New    *ledger.Payload `json:",omitempty"`
+}
+
+func decodeStateCommitment(flag string, value string) ledger.RootHash {
+	stateCommitment, err := hex.DecodeString(value)
+	if err != nil {
+		log.Fatal().Err(err).Str("flag", flag).Msg("invalid flag, cannot decode")
+	}
+
+	if len(stateCommitment) != 32 {
+		log.Fatal().Str("flag", flag).Msgf("invalid number of bytes, got %d expected %d", len(stateCommitment), 32)
+	}
+	return stateCommitment
+}
+
+func run(*cobra.Command, []string) {
+	startTime := time.Now()
+
+	from := decodeStateCommitment("from", flagFrom)
+	to := decodeStateCommitment("to", flagTo)
+
+	var output io.Writer = os.Stdout
+	if flagOutputFile != "" {
+		file, err := os.Create(flagOutputFile)
+		if err != nil {
+			log.Fatal().Err(err).Msg("cannot create output file")
+		}
+		defer file.Close()
+		output = file
+	}
+	writer := bufio.NewWriter(output)
+	defer writer.Flush()
+
+	forest := stateLoader()
+
+	changes, err := forest.Diff(from, to)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot compare tries")
+	}
+
+	encoder := json.NewEncoder(writer)
+	added, removed, modified := 0, 0, 0
+	for _, change := range changes {
+		kind := "modified"
+		switch {
+		case change.IsAdded():
+			kind = "added"
+			added++
+		case change.IsRemoved():
+			kind = "removed"
+			removed++
+		default:
+			modified++
+		}
+
+		err := encoder.Encode(&payloadChange{
+			Path:   hex.EncodeToString(change.Path),
+			Change: kind,
+			Old:    change.Old,
+			New:    change.New,
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("error while writing change")
+		}
+	}
+
+	duration := time.Since(startTime)
+
+	log.Info().
+		Int("added", added).
+		Int("removed", removed).
+		Int("modified", modified).
+		Float64("total_time_s", duration.Seconds()).
+		Msg("finished")
+}
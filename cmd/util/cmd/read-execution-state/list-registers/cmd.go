@@ -0,0 +1,102 @@
+package list_registers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	executionState "github.com/onflow/flow-go/engine/execution/state"
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/complete/mtrie"
+	"github.com/onflow/flow-go/model/flow"
+)
+
+var cmd = &cobra.Command{
+	Use:   "list-registers",
+	Short: "Lists registers (in path order) at given state commitment, page by page",
+	Run:   run,
+}
+
+var stateLoader func() *mtrie.Forest = nil
+var flagStateCommitment string
+var flagOwner string
+var flagStart string
+var flagLimit int
+
+func Init(f func() *mtrie.Forest) *cobra.Command {
+	stateLoader = f
+
+	cmd.Flags().StringVar(&flagStateCommitment, "state-commitment", "",
+		"State commitment (64 chars, hex-encoded)")
+	_ = cmd.MarkFlagRequired("state-commitment")
+
+	cmd.Flags().StringVar(&flagOwner, "owner", "",
+		"only list registers owned by this account address (hex-encoded), all registers if empty")
+
+	cmd.Flags().StringVar(&flagStart, "start", "",
+		"path (hex-encoded) to start listing at, as printed after the previous page")
+
+	cmd.Flags().IntVar(&flagLimit, "limit", 100,
+		"maximum number of registers to list, 0 to list all registers")
+
+	return cmd
+}
+
+func run(*cobra.Command, []string) {
+	startTime := time.Now()
+
+	stateCommitment, err := hex.DecodeString(flagStateCommitment)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid flag, cannot decode")
+	}
+
+	if len(stateCommitment) != 32 {
+		log.Fatal().Err(err).Msgf("invalid number of bytes, got %d expected %d", len(stateCommitment), 32)
+	}
+
+	var start ledger.Path
+	if flagStart != "" {
+		start, err = hex.DecodeString(flagStart)
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid start path, cannot decode")
+		}
+	}
+
+	var keyParts []ledger.KeyPart
+	if flagOwner != "" {
+		address := flow.HexToAddress(flagOwner)
+		keyParts = append(keyParts, ledger.NewKeyPart(executionState.KeyPartOwner, address.Bytes()))
+	}
+
+	forest := stateLoader()
+
+	result, err := forest.Iterate(&ledger.TrieIteration{
+		RootHash: stateCommitment,
+		Start:    start,
+		Limit:    flagLimit,
+		KeyParts: keyParts,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot iterate over registers")
+	}
+
+	for _, payload := range result.Payloads {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			log.Fatal().Err(err).Msg("error while marshalling payload")
+		}
+		fmt.Println(string(b))
+	}
+
+	if result.Next != nil {
+		log.Info().Str("next", hex.EncodeToString(result.Next)).Msg("more registers available, use --start to list the next page")
+	}
+
+	duration := time.Since(startTime)
+
+	log.Info().Int("registers", len(result.Payloads)).Float64("total_time_s", duration.Seconds()).Msg("finished")
+}
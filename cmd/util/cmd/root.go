@@ -12,7 +12,9 @@ import (
 	checkpoint_list_tries "github.com/onflow/flow-go/cmd/util/cmd/checkpoint-list-tries"
 	export "github.com/onflow/flow-go/cmd/util/cmd/exec-data-json-export"
 	extract "github.com/onflow/flow-go/cmd/util/cmd/execution-state-extract"
+	read_execution_state "github.com/onflow/flow-go/cmd/util/cmd/read-execution-state"
 	truncate_database "github.com/onflow/flow-go/cmd/util/cmd/truncate-database"
+	verify_execution_state "github.com/onflow/flow-go/cmd/util/cmd/verify-execution-state"
 )
 
 var (
@@ -49,7 +51,9 @@ func addCommands() {
 	rootCmd.AddCommand(extract.Cmd)
 	rootCmd.AddCommand(export.Cmd)
 	rootCmd.AddCommand(checkpoint_list_tries.Cmd)
+	rootCmd.AddCommand(read_execution_state.Cmd)
 	rootCmd.AddCommand(truncate_database.Cmd)
+	rootCmd.AddCommand(verify_execution_state.Cmd)
 }
 
 func initConfig() {
@@ -0,0 +1,48 @@
+package verify_execution_state
+
+import (
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/onflow/flow-go/ledger/complete"
+)
+
+var (
+	flagExecutionStateDir string
+	flagCheckpoint        string
+	flagDatadir           string
+	flagTruncateWAL       bool
+	flagPathFinderVersion uint8
+)
+
+var Cmd = &cobra.Command{
+	Use:   "verify-execution-state",
+	Short: "Verifies the integrity of the checkpoints and the WAL of the execution state",
+	Run:   run,
+}
+
+func init() {
+
+	Cmd.Flags().StringVar(&flagExecutionStateDir, "execution-state-dir", "",
+		"Execution Node state dir (where WAL logs are written")
+	_ = Cmd.MarkFlagRequired("execution-state-dir")
+
+	Cmd.Flags().StringVar(&flagCheckpoint, "checkpoint", "",
+		"full checkpoint file to verify instead of the latest checkpoint in the execution state dir")
+
+	Cmd.Flags().StringVar(&flagDatadir, "datadir", "",
+		"protocol state directory, to check the checkpointed tries against the state commitments of finalized blocks")
+
+	Cmd.Flags().BoolVar(&flagTruncateWAL, "truncate-wal", false,
+		"truncate the WAL to the last valid record, if corruption is found")
+
+	Cmd.Flags().Uint8Var(&flagPathFinderVersion, "path-finder-version", complete.DefaultPathFinderVersion,
+		"path finder version of the execution state, which determines the hasher of the tries")
+}
+
+func run(*cobra.Command, []string) {
+	err := verifyExecutionState(flagExecutionStateDir, flagCheckpoint, flagDatadir, flagTruncateWAL, flagPathFinderVersion, log.Logger)
+	if err != nil {
+		log.Fatal().Err(err).Msg("execution state verification failed")
+	}
+}
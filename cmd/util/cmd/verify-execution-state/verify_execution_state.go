@@ -0,0 +1,215 @@
+package verify_execution_state
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog"
+
+	"github.com/onflow/flow-go/cmd/util/cmd/common"
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common/pathfinder"
+	"github.com/onflow/flow-go/ledger/complete"
+	"github.com/onflow/flow-go/ledger/complete/wal"
+	"github.com/onflow/flow-go/storage"
+)
+
+// verifyExecutionState verifies the checkpoint and the WAL of the execution state in dir.
+// The given full checkpoint file is verified instead of the latest checkpoint, if set. The
+// checkpointed tries are checked against the protocol state in datadir, if set.
+func verifyExecutionState(dir string, checkpoint string, datadir string, truncateWAL bool, pathFinderVersion uint8, log zerolog.Logger) error {
+
+	w, err := wal.NewWAL(
+		nil,
+		nil,
+		dir,
+		complete.DefaultCacheSize,
+		pathfinder.PathByteSize,
+		wal.SegmentSize,
+	)
+	if err != nil {
+		return fmt.Errorf("error while creating WAL: %w", err)
+	}
+	defer func() {
+		err := w.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("error while closing WAL")
+		}
+	}()
+
+	rootHashes, err := verifyCheckpoint(w, checkpoint, pathFinderVersion, log)
+	if err != nil {
+		return err
+	}
+
+	if datadir != "" {
+		err = verifyStateCommitments(datadir, rootHashes, log)
+		if err != nil {
+			return err
+		}
+	}
+
+	return verifyWAL(w, truncateWAL, log)
+}
+
+// verifyCheckpoint verifies the given checkpoint file, or the latest checkpoint
+// (falling back to the root checkpoint) and returns the root hashes of its tries
+func verifyCheckpoint(w *wal.LedgerWAL, checkpoint string, pathFinderVersion uint8, log zerolog.Logger) ([]ledger.RootHash, error) {
+
+	if checkpoint != "" {
+		rootHashes, err := wal.VerifyCheckpointFile(checkpoint)
+		if err != nil {
+			return nil, fmt.Errorf("verification of checkpoint %s failed: %w", checkpoint, err)
+		}
+		logRootHashes(log, checkpoint, rootHashes)
+		return rootHashes, nil
+	}
+
+	checkpointer, err := w.NewCheckpointer()
+	if err != nil {
+		return nil, fmt.Errorf("cannot create checkpointer: %w", err)
+	}
+	hasher, err := pathfinder.TrieHasher(pathFinderVersion)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get trie hasher: %w", err)
+	}
+	checkpointer.SetTrieHasher(hasher)
+
+	latest, err := checkpointer.LatestCheckpoint()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get latest checkpoint: %w", err)
+	}
+
+	if latest != -1 {
+		rootHashes, err := checkpointer.VerifyCheckpoint(latest)
+		if err != nil {
+			return nil, fmt.Errorf("verification of checkpoint %d failed: %w", latest, err)
+		}
+		logRootHashes(log, wal.NumberToFilename(latest), rootHashes)
+		return rootHashes, nil
+	}
+
+	hasRootCheckpoint, err := checkpointer.HasRootCheckpoint()
+	if err != nil {
+		return nil, fmt.Errorf("cannot check for root checkpoint: %w", err)
+	}
+	if !hasRootCheckpoint {
+		log.Warn().Msg("no checkpoint found")
+		return nil, nil
+	}
+
+	rootHashes, err := checkpointer.VerifyRootCheckpoint()
+	if err != nil {
+		return nil, fmt.Errorf("verification of root checkpoint failed: %w", err)
+	}
+	logRootHashes(log, wal.RootCheckpointFilename, rootHashes)
+	return rootHashes, nil
+}
+
+func logRootHashes(log zerolog.Logger, checkpoint string, rootHashes []ledger.RootHash) {
+	log.Info().Str("checkpoint", checkpoint).Int("tries", len(rootHashes)).Msg("checkpoint verified")
+	for _, rootHash := range rootHashes {
+		log.Debug().Hex("root_hash", rootHash).Msg("checkpointed trie")
+	}
+}
+
+// verifyStateCommitments checks that the checkpointed tries correspond to state commitments
+// (executed or sealed) of finalized blocks in the protocol state
+func verifyStateCommitments(datadir string, rootHashes []ledger.RootHash, log zerolog.Logger) error {
+	if len(rootHashes) == 0 {
+		return nil
+	}
+
+	db := common.InitStorage(datadir)
+	defer db.Close()
+
+	storages := common.InitStorages(db)
+	state, err := common.InitProtocolState(db, storages)
+	if err != nil {
+		return fmt.Errorf("could not init protocol state: %w", err)
+	}
+
+	root, err := state.Params().Root()
+	if err != nil {
+		return fmt.Errorf("could not get root block: %w", err)
+	}
+	final, err := state.Final().Head()
+	if err != nil {
+		return fmt.Errorf("could not get finalized block: %w", err)
+	}
+
+	// state commitment -> heights of the blocks with this commitment
+	heights := make(map[string][]uint64)
+	for height := root.Height; height <= final.Height; height++ {
+		header, err := state.AtHeight(height).Head()
+		if err != nil {
+			return fmt.Errorf("could not get finalized block at height %d: %w", height, err)
+		}
+		blockID := header.ID()
+
+		commit, err := storages.Commits.ByBlockID(blockID)
+		if err == nil {
+			key := hex.EncodeToString(commit)
+			heights[key] = append(heights[key], height)
+		} else if !errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("could not get state commitment at height %d: %w", height, err)
+		}
+
+		seal, err := storages.Seals.ByBlockID(blockID)
+		if err == nil {
+			key := hex.EncodeToString(seal.FinalState)
+			heights[key] = append(heights[key], height)
+		} else if !errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("could not get seal at height %d: %w", height, err)
+		}
+	}
+
+	matched := 0
+	for _, rootHash := range rootHashes {
+		found, ok := heights[hex.EncodeToString(rootHash)]
+		if !ok {
+			log.Warn().Hex("root_hash", rootHash).Msg("checkpointed trie does not match any state commitment of a finalized block")
+			continue
+		}
+		matched++
+		log.Info().Hex("root_hash", rootHash).Uints64("heights", found).Msg("checkpointed trie matches state commitment")
+	}
+
+	if matched == 0 {
+		return fmt.Errorf("no checkpointed trie matches a state commitment of a finalized block (root height %d, finalized height %d)",
+			root.Height, final.Height)
+	}
+	log.Info().Int("matched", matched).Int("tries", len(rootHashes)).Msg("state commitments verified")
+	return nil
+}
+
+// verifyWAL verifies all records of the WAL and truncates it if requested
+func verifyWAL(w *wal.LedgerWAL, truncate bool, log zerolog.Logger) error {
+	records, corruption, err := w.Verify()
+	if err != nil {
+		return fmt.Errorf("WAL verification failed: %w", err)
+	}
+	if corruption == nil {
+		log.Info().Int("records", records).Msg("WAL verified")
+		return nil
+	}
+
+	log.Error().
+		Err(corruption.Err).
+		Int("valid_records", records).
+		Int("segment", corruption.Segment).
+		Int64("offset", corruption.Offset).
+		Msg("WAL is corrupted")
+
+	if !truncate {
+		return fmt.Errorf("WAL is corrupted, use --truncate-wal to truncate it to the last valid record: %w", corruption)
+	}
+
+	err = w.Truncate(corruption)
+	if err != nil {
+		return fmt.Errorf("could not truncate WAL: %w", err)
+	}
+	log.Info().Int("records", records).Msg("WAL truncated to last valid record")
+	return nil
+}
@@ -0,0 +1,111 @@
+package verify_execution_state
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common/pathfinder"
+	"github.com/onflow/flow-go/ledger/common/utils"
+	"github.com/onflow/flow-go/ledger/complete"
+	"github.com/onflow/flow-go/ledger/complete/wal"
+	"github.com/onflow/flow-go/module/metrics"
+	"github.com/onflow/flow-go/utils/unittest"
+)
+
+func TestVerifyExecutionState(t *testing.T) {
+
+	t.Run("valid checkpoint", func(t *testing.T) {
+		withExecutionState(t, func(dir string, checkpoint string) {
+			err := verifyExecutionState(dir, "", "", false, complete.DefaultPathFinderVersion, zerolog.Nop())
+			require.NoError(t, err)
+
+			err = verifyExecutionState(dir, checkpoint, "", false, complete.DefaultPathFinderVersion, zerolog.Nop())
+			require.NoError(t, err)
+		})
+	})
+
+	t.Run("corrupted checksum", func(t *testing.T) {
+		withExecutionState(t, func(dir string, checkpoint string) {
+			// the checksum is stored in the last bytes of the checkpoint
+			data, err := ioutil.ReadFile(checkpoint)
+			require.NoError(t, err)
+			data[len(data)-1] ^= 0x01
+			err = ioutil.WriteFile(checkpoint, data, 0644)
+			require.NoError(t, err)
+
+			err = verifyExecutionState(dir, "", "", false, complete.DefaultPathFinderVersion, zerolog.Nop())
+			var cerr *wal.CheckpointCorruptionErr
+			require.True(t, errors.As(err, &cerr))
+
+			err = verifyExecutionState(dir, checkpoint, "", false, complete.DefaultPathFinderVersion, zerolog.Nop())
+			require.True(t, errors.As(err, &cerr))
+		})
+	})
+
+	t.Run("truncated WAL segment", func(t *testing.T) {
+		withExecutionState(t, func(dir string, checkpoint string) {
+			// cut the segment in the middle of the last record, skipping the zero padding of the page
+			segment := path.Join(dir, "00000000")
+			data, err := ioutil.ReadFile(segment)
+			require.NoError(t, err)
+			end := len(data)
+			for end > 0 && data[end-1] == 0 {
+				end--
+			}
+			err = os.Truncate(segment, int64(end-5))
+			require.NoError(t, err)
+
+			err = verifyExecutionState(dir, "", "", false, complete.DefaultPathFinderVersion, zerolog.Nop())
+			require.Error(t, err)
+
+			// truncating the WAL to the last valid record repairs it
+			err = verifyExecutionState(dir, "", "", true, complete.DefaultPathFinderVersion, zerolog.Nop())
+			require.NoError(t, err)
+
+			err = verifyExecutionState(dir, "", "", false, complete.DefaultPathFinderVersion, zerolog.Nop())
+			require.NoError(t, err)
+		})
+	})
+}
+
+// withExecutionState runs f with an execution state dir, holding a WAL with a few
+// updates and a checkpoint of all of them
+func withExecutionState(t *testing.T, f func(dir string, checkpoint string)) {
+	unittest.RunWithTempDir(t, func(dir string) {
+		led, err := complete.NewLedger(dir, 100, &metrics.NoopCollector{}, zerolog.Nop(), nil, complete.DefaultPathFinderVersion)
+		require.NoError(t, err)
+
+		state := led.InitialState()
+		for i := 0; i < 5; i++ {
+			keys := utils.RandomUniqueKeys(10, 2, 1, 10)
+			values := utils.RandomValues(10, 1, 32)
+			update, err := ledger.NewUpdate(state, keys, values)
+			require.NoError(t, err)
+			state, err = led.Set(update)
+			require.NoError(t, err)
+		}
+		<-led.Done()
+
+		w, err := wal.NewWAL(nil, nil, dir, 100, pathfinder.PathByteSize, wal.SegmentSize)
+		require.NoError(t, err)
+		checkpointer, err := w.NewCheckpointer()
+		require.NoError(t, err)
+		_, to, err := checkpointer.NotCheckpointedSegments()
+		require.NoError(t, err)
+		err = checkpointer.Checkpoint(to, func() (io.WriteCloser, error) {
+			return checkpointer.CheckpointWriter(to)
+		})
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		f(dir, path.Join(dir, wal.NumberToFilename(to)))
+	})
+}
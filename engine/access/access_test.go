@@ -96,21 +96,20 @@ func (suite *Suite) RunTest(
 		transactions := storage.NewTransactions(suite.metrics, db)
 		collections := storage.NewCollections(db, transactions)
 
-		suite.backend = backend.New(
-			suite.state,
-			suite.execClient,
-			suite.collClient,
-			nil,
-			blocks,
-			headers,
-			collections,
-			transactions,
-			suite.chainID,
-			suite.metrics,
-			uint(9000),
-			nil,
-			false,
-		)
+		suite.backend = backend.New(backend.Params{
+			State:                     suite.state,
+			ExecutionRPC:              suite.execClient,
+			CollectionRPC:             suite.collClient,
+			Blocks:                    blocks,
+			Headers:                   headers,
+			Collections:               collections,
+			Transactions:              transactions,
+			ChainID:                   suite.chainID,
+			TransactionMetrics:        suite.metrics,
+			ExecutionNodeQueryMetrics: suite.metrics,
+			CacheMetrics:              suite.metrics,
+			CollectionGRPCPort:        uint(9000),
+		})
 
 		handler := access.NewHandler(suite.backend, suite.chainID.Chain())
 
@@ -275,21 +274,18 @@ func (suite *Suite) TestSendTransactionToRandomCollectionNode() {
 		connFactory.On("GetAccessAPIClient", grpcAddr(collNode1)).Return(col1ApiClient, &mockCloser{}, nil)
 		connFactory.On("GetAccessAPIClient", grpcAddr(collNode2)).Return(col2ApiClient, &mockCloser{}, nil)
 
-		backend := backend.New(
-			suite.state,
-			nil,
-			nil, // setting collectionRPC to nil to choose a random collection node for each send tx request
-			nil,
-			nil,
-			nil,
-			collections,
-			transactions,
-			suite.chainID,
-			metrics,
-			collectionGrpcPort,
-			connFactory, // passing in the connection factory
-			false,
-		)
+		// the collection RPC is not set, to choose a random collection node for each send tx request
+		backend := backend.New(backend.Params{
+			State:                     suite.state,
+			Collections:               collections,
+			Transactions:              transactions,
+			ChainID:                   suite.chainID,
+			TransactionMetrics:        metrics,
+			ExecutionNodeQueryMetrics: metrics,
+			CacheMetrics:              metrics,
+			CollectionGRPCPort:        collectionGrpcPort,
+			ConnFactory:               connFactory, // passing in the connection factory
+		})
 
 		handler := access.NewHandler(backend, suite.chainID.Chain())
 
@@ -446,8 +442,17 @@ func (suite *Suite) TestGetSealedTransaction() {
 		blocksToMarkExecuted, err := stdmap.NewTimes(100)
 		require.NoError(suite.T(), err)
 
-		rpcEng := rpc.New(suite.log, suite.state, rpc.Config{}, nil, nil, nil, blocks, headers, collections, transactions,
-			suite.chainID, metrics, 0, false, false)
+		rpcEng := rpc.New(suite.log, rpc.Config{}, backend.Params{
+			State:                     suite.state,
+			Blocks:                    blocks,
+			Headers:                   headers,
+			Collections:               collections,
+			Transactions:              transactions,
+			ChainID:                   suite.chainID,
+			TransactionMetrics:        metrics,
+			ExecutionNodeQueryMetrics: metrics,
+			CacheMetrics:              metrics,
+		}, metrics, false)
 
 		// create the ingest engine
 		ingestEng, err := ingestion.New(suite.log, suite.net, suite.state, suite.me, suite.request, blocks, headers, collections,
@@ -523,7 +528,7 @@ func (suite *Suite) TestExecuteScript() {
 				Value: []byte{9, 10, 11},
 			}
 
-			suite.execClient.On("ExecuteScriptAtBlockID", ctx, &executionReq).Return(&executionResp, nil).Once()
+			suite.execClient.On("ExecuteScriptAtBlockID", mock.Anything, &executionReq).Return(&executionResp, nil).Once()
 
 			expectedResp := accessproto.ExecuteScriptResponse{
 				Value: executionResp.GetValue(),
@@ -0,0 +1,238 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	execproto "github.com/onflow/flow/protobuf/go/flow/execution"
+	"github.com/rs/zerolog"
+
+	"github.com/onflow/flow-go/engine"
+	"github.com/onflow/flow-go/engine/common/rpc/convert"
+	"github.com/onflow/flow-go/fvm"
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/state/protocol"
+	"github.com/onflow/flow-go/storage"
+)
+
+// time to wait between checks for newly sealed blocks to index
+const indexInterval = 10 * time.Second
+
+var defaultIndexInterval = indexInterval
+
+// Engine indexes the events and transaction results of sealed blocks in the local storage of the
+// access node, so that the access API can serve them without requesting them from an execution node.
+//
+// Blocks are indexed in order of their height, once they are sealed and all their collections have
+// been received (see the FullBlockHeight index). The height of the last indexed block is stored in
+// the IndexedBlockHeight index.
+type Engine struct {
+	unit               *engine.Unit
+	log                zerolog.Logger
+	state              protocol.State
+	executionRPC       execproto.ExecutionAPIClient
+	blocks             storage.Blocks
+	collections        storage.Collections
+	events             storage.Events
+	transactionResults storage.TransactionResults
+	systemTxID         flow.Identifier // ID of the system chunk transaction, executed at the end of every block
+	indexingEnabled    bool
+}
+
+// New creates a new access indexer engine
+func New(
+	log zerolog.Logger,
+	state protocol.State,
+	executionRPC execproto.ExecutionAPIClient,
+	blocks storage.Blocks,
+	collections storage.Collections,
+	events storage.Events,
+	transactionResults storage.TransactionResults,
+	chainID flow.ChainID,
+	indexingEnabled bool,
+) *Engine {
+	return &Engine{
+		unit:               engine.NewUnit(),
+		log:                log.With().Str("engine", "indexer").Logger(),
+		state:              state,
+		executionRPC:       executionRPC,
+		blocks:             blocks,
+		collections:        collections,
+		events:             events,
+		transactionResults: transactionResults,
+		systemTxID:         fvm.SystemChunkTransaction(chainID.Chain().ServiceAddress()).ID(),
+		indexingEnabled:    indexingEnabled,
+	}
+}
+
+// Ready returns a ready channel that is closed once the engine has fully started.
+func (e *Engine) Ready() <-chan struct{} {
+	// only launch when indexing is enabled
+	if e.indexingEnabled {
+		e.unit.LaunchPeriodically(e.indexSealedBlocks, defaultIndexInterval, time.Duration(0))
+	}
+	e.log.Info().Bool("indexing enabled", e.indexingEnabled).Msg("indexing enabled")
+	return e.unit.Ready()
+}
+
+// Done returns a done channel that is closed once the engine has fully stopped.
+func (e *Engine) Done() <-chan struct{} {
+	return e.unit.Done()
+}
+
+// indexSealedBlocks indexes all blocks following the last indexed block, which are sealed
+// and for which all collections have been received.
+func (e *Engine) indexSealedBlocks() {
+
+	logError := func(err error) {
+		e.log.Error().Err(err).Msg("failed to index sealed blocks")
+	}
+
+	lastIndexedHeight, err := e.blocks.GetLastIndexedBlockHeight()
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			logError(err)
+			return
+		}
+		// the root block is not executed, start indexing at the next block
+		root, err := e.state.Params().Root()
+		if err != nil {
+			logError(err)
+			return
+		}
+		lastIndexedHeight = root.Height
+	}
+
+	sealed, err := e.state.Sealed().Head()
+	if err != nil {
+		logError(err)
+		return
+	}
+
+	// the transactions of a block are only known once all its collections are received
+	lastFullHeight, err := e.blocks.GetLastFullBlockHeight()
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return
+		}
+		logError(err)
+		return
+	}
+
+	maxHeight := sealed.Height
+	if lastFullHeight < maxHeight {
+		maxHeight = lastFullHeight
+	}
+
+	ctx := e.unit.Ctx()
+	for height := lastIndexedHeight + 1; height <= maxHeight && ctx.Err() == nil; height++ {
+		err = e.indexBlock(ctx, height)
+		if err != nil {
+			logError(fmt.Errorf("could not index block at height %d: %w", height, err))
+			return
+		}
+
+		err = e.blocks.UpdateLastIndexedBlockHeight(height)
+		if err != nil {
+			logError(err)
+			return
+		}
+		lastIndexedHeight = height
+	}
+
+	e.log.Debug().Uint64("last_indexed_height", lastIndexedHeight).Msg("indexed sealed blocks")
+}
+
+// indexBlock requests the results of all transactions of the finalized block at the given height
+// from the execution node, and stores the results and their events.
+func (e *Engine) indexBlock(ctx context.Context, height uint64) error {
+
+	block, err := e.blocks.ByHeight(height)
+	if err != nil {
+		return fmt.Errorf("could not get block: %w", err)
+	}
+	blockID := block.ID()
+
+	var txIDs []flow.Identifier
+	for _, guarantee := range block.Payload.Guarantees {
+		collection, err := e.collections.LightByID(guarantee.CollectionID)
+		if err != nil {
+			return fmt.Errorf("could not get collection %x: %w", guarantee.CollectionID, err)
+		}
+		txIDs = append(txIDs, collection.Transactions...)
+	}
+
+	scheduledTxIDs, err := e.scheduledTransactions(ctx, blockID)
+	if err != nil {
+		return fmt.Errorf("could not get scheduled transactions: %w", err)
+	}
+	txIDs = append(txIDs, scheduledTxIDs...)
+	txIDs = append(txIDs, e.systemTxID)
+
+	var events []flow.Event
+	results := make([]flow.TransactionResult, 0, len(txIDs))
+	for _, txID := range txIDs {
+		req := execproto.GetTransactionResultRequest{
+			BlockId:       blockID[:],
+			TransactionId: txID[:],
+		}
+
+		resp, err := e.executionRPC.GetTransactionResult(ctx, &req)
+		if err != nil {
+			return fmt.Errorf("could not get result of transaction %x from execution node: %w", txID, err)
+		}
+
+		events = append(events, convert.MessagesToEvents(resp.GetEvents())...)
+		results = append(results, flow.TransactionResult{
+			TransactionID: txID,
+			ErrorMessage:  resp.GetErrorMessage(),
+			ErrorCode:     resp.GetStatusCode(),
+		})
+	}
+
+	err = e.events.Store(blockID, events)
+	if err != nil {
+		return fmt.Errorf("could not store events: %w", err)
+	}
+
+	err = e.transactionResults.BatchStore(blockID, results)
+	if err != nil {
+		return fmt.Errorf("could not store transaction results: %w", err)
+	}
+
+	return nil
+}
+
+// scheduledTransactions returns the IDs of the scheduled transactions executed in the system chunk of the
+// block, in the order of their execution, which are found by the events emitted when they are executed.
+func (e *Engine) scheduledTransactions(ctx context.Context, blockID flow.Identifier) ([]flow.Identifier, error) {
+
+	req := execproto.GetEventsForBlockIDsRequest{
+		Type:     string(flow.EventScheduledTransactionExecuted),
+		BlockIds: [][]byte{blockID[:]},
+	}
+
+	resp, err := e.executionRPC.GetEventsForBlockIDs(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("could not get events from execution node: %w", err)
+	}
+
+	var events []flow.Event
+	for _, result := range resp.GetResults() {
+		events = append(events, convert.MessagesToEvents(result.GetEvents())...)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].TransactionIndex < events[j].TransactionIndex
+	})
+
+	txIDs := make([]flow.Identifier, 0, len(events))
+	for _, event := range events {
+		txIDs = append(txIDs, event.TransactionID)
+	}
+
+	return txIDs, nil
+}
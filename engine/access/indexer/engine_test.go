@@ -0,0 +1,205 @@
+package indexer
+
+import (
+	"fmt"
+	"testing"
+
+	execproto "github.com/onflow/flow/protobuf/go/flow/execution"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	access "github.com/onflow/flow-go/engine/access/mock"
+	"github.com/onflow/flow-go/engine/common/rpc/convert"
+	"github.com/onflow/flow-go/fvm"
+	"github.com/onflow/flow-go/model/flow"
+	protocol "github.com/onflow/flow-go/state/protocol/mock"
+	storerr "github.com/onflow/flow-go/storage"
+	storage "github.com/onflow/flow-go/storage/mock"
+	"github.com/onflow/flow-go/utils/unittest"
+)
+
+type Suite struct {
+	suite.Suite
+
+	state              *protocol.State
+	snapshot           *protocol.Snapshot
+	params             *protocol.Params
+	execClient         *access.ExecutionAPIClient
+	blocks             *storage.Blocks
+	collections        *storage.Collections
+	events             *storage.Events
+	transactionResults *storage.TransactionResults
+
+	root   *flow.Header
+	chain  []*flow.Block // finalized blocks following the root block
+	sealed *flow.Header
+
+	eng *Engine
+}
+
+func TestIndexerEngine(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+func (suite *Suite) SetupTest() {
+	suite.state = new(protocol.State)
+	suite.snapshot = new(protocol.Snapshot)
+	suite.params = new(protocol.Params)
+	suite.state.On("Sealed").Return(suite.snapshot)
+	suite.state.On("Params").Return(suite.params)
+
+	suite.execClient = new(access.ExecutionAPIClient)
+	suite.blocks = new(storage.Blocks)
+	suite.collections = new(storage.Collections)
+	suite.events = new(storage.Events)
+	suite.transactionResults = new(storage.TransactionResults)
+
+	root := unittest.BlockHeaderFixture()
+	suite.root = &root
+	suite.params.On("Root").Return(suite.root, nil)
+
+	parent := suite.root
+	suite.chain = nil
+	for i := 0; i < 3; i++ {
+		block := unittest.BlockWithParentFixture(parent)
+		block.SetPayload(flow.Payload{Guarantees: []*flow.CollectionGuarantee{unittest.CollectionGuaranteeFixture()}})
+		suite.chain = append(suite.chain, &block)
+		suite.blocks.On("ByHeight", block.Header.Height).Return(&block, nil).Maybe()
+
+		collection := unittest.CollectionFixture(2)
+		light := collection.Light()
+		suite.collections.On("LightByID", block.Payload.Guarantees[0].CollectionID).Return(&light, nil).Maybe()
+
+		parent = block.Header
+	}
+	suite.sealed = suite.chain[len(suite.chain)-1].Header
+	suite.snapshot.On("Head").Return(suite.sealed, nil)
+
+	suite.eng = New(
+		zerolog.Nop(),
+		suite.state,
+		suite.execClient,
+		suite.blocks,
+		suite.collections,
+		suite.events,
+		suite.transactionResults,
+		flow.Testnet,
+		true,
+	)
+}
+
+// expectIndexing expects the results of all transactions of the given block, including a scheduled
+// transaction, to be requested from the execution node and stored.
+func (suite *Suite) expectIndexing(block *flow.Block) {
+	blockID := block.ID()
+
+	light, err := suite.collections.LightByID(block.Payload.Guarantees[0].CollectionID)
+	suite.Require().NoError(err)
+
+	scheduledTxID := unittest.IdentifierFixture()
+	scheduledEvent := unittest.EventFixture(flow.EventScheduledTransactionExecuted, uint32(len(light.Transactions)), 0, scheduledTxID)
+	suite.execClient.
+		On("GetEventsForBlockIDs", mock.Anything, &execproto.GetEventsForBlockIDsRequest{
+			Type:     string(flow.EventScheduledTransactionExecuted),
+			BlockIds: [][]byte{blockID[:]},
+		}).
+		Return(&execproto.GetEventsForBlockIDsResponse{
+			Results: []*execproto.GetEventsForBlockIDsResponse_Result{{
+				BlockId:     blockID[:],
+				BlockHeight: block.Header.Height,
+				Events:      convert.EventsToMessages([]flow.Event{scheduledEvent}),
+			}},
+		}, nil).
+		Once()
+
+	txIDs := append(append([]flow.Identifier{}, light.Transactions...), scheduledTxID, suite.eng.systemTxID)
+
+	var events []flow.Event
+	var results []flow.TransactionResult
+	for i, txID := range txIDs {
+		txID := txID
+		event := unittest.EventFixture(flow.EventAccountCreated, uint32(i), 0, txID)
+		events = append(events, event)
+
+		errorMessage := ""
+		var errorCode uint32
+		if i == 0 {
+			errorMessage = "failed"
+			errorCode = fvm.ErrCodeConditionFailed
+		}
+		results = append(results, flow.TransactionResult{TransactionID: txID, ErrorMessage: errorMessage, ErrorCode: errorCode})
+
+		resp := &execproto.GetTransactionResultResponse{
+			Events:       convert.EventsToMessages([]flow.Event{event}),
+			StatusCode:   errorCode,
+			ErrorMessage: errorMessage,
+		}
+		suite.execClient.
+			On("GetTransactionResult", mock.Anything, &execproto.GetTransactionResultRequest{
+				BlockId:       blockID[:],
+				TransactionId: txID[:],
+			}).
+			Return(resp, nil).
+			Once()
+	}
+
+	suite.events.On("Store", blockID, events).Return(nil).Once()
+	suite.transactionResults.On("BatchStore", blockID, results).Return(nil).Once()
+	suite.blocks.On("UpdateLastIndexedBlockHeight", block.Header.Height).Return(nil).Once()
+}
+
+// TestIndexSealedBlocks tests that all sealed blocks, for which all collections have
+// been received, are indexed following the root block.
+func (suite *Suite) TestIndexSealedBlocks() {
+	suite.blocks.On("GetLastIndexedBlockHeight").Return(uint64(0), storerr.ErrNotFound)
+	suite.blocks.On("GetLastFullBlockHeight").Return(suite.chain[1].Header.Height, nil)
+
+	suite.expectIndexing(suite.chain[0])
+	suite.expectIndexing(suite.chain[1])
+
+	suite.eng.indexSealedBlocks()
+
+	suite.execClient.AssertExpectations(suite.T())
+	suite.events.AssertExpectations(suite.T())
+	suite.transactionResults.AssertExpectations(suite.T())
+	suite.blocks.AssertExpectations(suite.T())
+	suite.blocks.AssertNotCalled(suite.T(), "ByHeight", suite.chain[2].Header.Height)
+}
+
+// TestIndexSealedBlocksFromLastIndexed tests that indexing continues with the block following
+// the last indexed block, and stops at the first block which is not executed yet.
+func (suite *Suite) TestIndexSealedBlocksFromLastIndexed() {
+	suite.blocks.On("GetLastIndexedBlockHeight").Return(suite.chain[0].Header.Height, nil)
+	suite.blocks.On("GetLastFullBlockHeight").Return(suite.sealed.Height, nil)
+
+	suite.expectIndexing(suite.chain[1])
+
+	// the last block is not executed yet
+	suite.execClient.
+		On("GetEventsForBlockIDs", mock.Anything, mock.Anything).
+		Return(nil, status.Error(codes.NotFound, "block not found"))
+
+	suite.eng.indexSealedBlocks()
+
+	suite.events.AssertExpectations(suite.T())
+	suite.transactionResults.AssertExpectations(suite.T())
+	suite.blocks.AssertExpectations(suite.T())
+	suite.events.AssertNumberOfCalls(suite.T(), "Store", 1)
+	suite.blocks.AssertNotCalled(suite.T(), "UpdateLastIndexedBlockHeight", suite.sealed.Height)
+}
+
+// TestIndexSealedBlocksWithoutFullBlocks tests that no block is indexed,
+// as long as no block is known to have all its collections.
+func (suite *Suite) TestIndexSealedBlocksWithoutFullBlocks() {
+	suite.blocks.On("GetLastIndexedBlockHeight").Return(uint64(0), storerr.ErrNotFound)
+	suite.blocks.On("GetLastFullBlockHeight").Return(uint64(0), fmt.Errorf("failed to retrieve LastFullBlockHeight: %w", storerr.ErrNotFound))
+
+	suite.eng.indexSealedBlocks()
+
+	suite.execClient.AssertNotCalled(suite.T(), "GetEventsForBlockIDs", mock.Anything, mock.Anything)
+	suite.execClient.AssertNotCalled(suite.T(), "GetTransactionResult", mock.Anything, mock.Anything)
+	suite.blocks.AssertNotCalled(suite.T(), "UpdateLastIndexedBlockHeight", mock.Anything)
+}
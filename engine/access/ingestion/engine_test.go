@@ -16,6 +16,7 @@ import (
 	"github.com/onflow/flow-go/consensus/hotstuff/model"
 	"github.com/onflow/flow-go/engine"
 	"github.com/onflow/flow-go/engine/access/rpc"
+	"github.com/onflow/flow-go/engine/access/rpc/backend"
 	"github.com/onflow/flow-go/model/flow"
 	"github.com/onflow/flow-go/network/mocknetwork"
 
@@ -88,8 +89,17 @@ func (suite *Suite) SetupTest() {
 	blocksToMarkExecuted, err := stdmap.NewTimes(100)
 	require.NoError(suite.T(), err)
 
-	rpcEng := rpc.New(log, suite.proto.state, rpc.Config{}, nil, nil, nil, suite.blocks, suite.headers, suite.collections,
-		suite.transactions, flow.Testnet, metrics.NewNoopCollector(), 0, false, false)
+	rpcEng := rpc.New(log, rpc.Config{}, backend.Params{
+		State:                     suite.proto.state,
+		Blocks:                    suite.blocks,
+		Headers:                   suite.headers,
+		Collections:               suite.collections,
+		Transactions:              suite.transactions,
+		ChainID:                   flow.Testnet,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	}, metrics.NewNoopCollector(), false)
 
 	eng, err := New(log, net, suite.proto.state, suite.me, suite.request, suite.blocks, suite.headers, suite.collections,
 		suite.transactions, metrics.NewNoopCollector(), collectionsToMarkFinalized, collectionsToMarkExecuted,
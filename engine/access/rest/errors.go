@@ -0,0 +1,72 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StatusError is an error with the HTTP status code of the response
+type StatusError struct {
+	Code int
+	Err  error
+}
+
+func (e *StatusError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// newBadRequestError returns an error resulting in a 400 Bad Request response
+func newBadRequestError(format string, args ...interface{}) error {
+	return &StatusError{Code: http.StatusBadRequest, Err: fmt.Errorf(format, args...)}
+}
+
+// ErrorResponse is the body of all responses with a status code other than 200 OK
+type ErrorResponse struct {
+	Code    int
+	Message string
+}
+
+// errorResponse returns the response for the given error. Errors of the Access API
+// are gRPC status errors, whose codes are mapped to the corresponding HTTP status codes.
+func errorResponse(err error) ErrorResponse {
+	var serr *StatusError
+	if errors.As(err, &serr) {
+		return ErrorResponse{Code: serr.Code, Message: serr.Error()}
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return ErrorResponse{Code: http.StatusInternalServerError, Message: err.Error()}
+	}
+
+	code := http.StatusInternalServerError
+	switch st.Code() {
+	case codes.InvalidArgument, codes.OutOfRange:
+		code = http.StatusBadRequest
+	case codes.NotFound:
+		code = http.StatusNotFound
+	case codes.AlreadyExists:
+		code = http.StatusConflict
+	case codes.FailedPrecondition:
+		code = http.StatusPreconditionFailed
+	case codes.ResourceExhausted:
+		code = http.StatusTooManyRequests
+	case codes.Unimplemented:
+		code = http.StatusNotImplemented
+	case codes.Unavailable:
+		code = http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		code = http.StatusGatewayTimeout
+	case codes.Canceled:
+		code = http.StatusRequestTimeout
+	}
+	return ErrorResponse{Code: code, Message: st.Message()}
+}
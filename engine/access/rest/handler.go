@@ -0,0 +1,594 @@
+package rest
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/onflow/flow-go/access"
+	"github.com/onflow/flow-go/engine/access/rpc/ratelimit"
+	"github.com/onflow/flow-go/model/encoding/json"
+	"github.com/onflow/flow-go/model/flow"
+	grpcutils "github.com/onflow/flow-go/utils/grpc"
+)
+
+// Handler serves the REST API, backed by the Access API. Flow entities are encoded
+// with the JSON encoding of the model, see openapi.yaml for the description of the API.
+//
+// If the handler has a limiter, requests are rate limited like the gRPC API, under the name of
+// the Access API method they call.
+type Handler struct {
+	api     access.API
+	chain   flow.Chain
+	limiter *ratelimit.Limiter
+	log     zerolog.Logger
+	encoder *json.Encoder
+	routes  []route
+
+	streams      context.Context // canceled to end the streamed subscriptions
+	closeStreams context.CancelFunc
+}
+
+// NewHandler returns a new REST API handler. The limiter is optional.
+func NewHandler(api access.API, chain flow.Chain, limiter *ratelimit.Limiter, log zerolog.Logger) *Handler {
+	h := &Handler{
+		api:     api,
+		chain:   chain,
+		limiter: limiter,
+		log:     log.With().Str("component", "rest_api").Logger(),
+		encoder: json.NewEncoder(),
+	}
+	h.streams, h.closeStreams = context.WithCancel(context.Background())
+
+	h.routes = []route{
+		newRoute(http.MethodGet, "/v1/network/parameters", "GetNetworkParameters", h.getNetworkParameters),
+
+		newRoute(http.MethodGet, "/v1/blocks/latest", "GetLatestBlock", h.getLatestBlock),
+		newRoute(http.MethodGet, "/v1/blocks/{id}", "GetBlockByID", h.getBlockByID),
+		newRoute(http.MethodGet, "/v1/blocks", "GetBlockByHeight", h.getBlockByHeight),
+		newRoute(http.MethodGet, "/v1/blocks/{id}/transaction_results", "GetTransactionResultsByBlockID", h.getTransactionResultsByBlockID),
+		newRoute(http.MethodGet, "/v1/blocks/{id}/transaction_results/{index}", "GetTransactionResultByIndex", h.getTransactionResultByIndex),
+		newRoute(http.MethodGet, "/v1/blocks/{id}/execution_result", "GetExecutionResultForBlockID", h.getExecutionResultForBlockID),
+		newRoute(http.MethodGet, "/v1/blocks/{id}/seal", "GetSealForBlockID", h.getSealForBlockID),
+		newRoute(http.MethodGet, "/v1/blocks/{id}/identities", "GetNodeIdentities", h.getNodeIdentities),
+
+		newRoute(http.MethodGet, "/v1/headers/latest", "GetLatestBlockHeader", h.getLatestBlockHeader),
+		newRoute(http.MethodGet, "/v1/headers/{id}", "GetBlockHeaderByID", h.getBlockHeaderByID),
+		newRoute(http.MethodGet, "/v1/headers", "GetBlockHeaderByHeight", h.getBlockHeaderByHeight),
+
+		newRoute(http.MethodGet, "/v1/collections/{id}", "GetCollectionByID", h.getCollectionByID),
+
+		newRoute(http.MethodPost, "/v1/transactions", "SendTransaction", h.sendTransaction),
+		newRoute(http.MethodPost, "/v1/transactions/simulate", "SimulateTransaction", h.simulateTransaction),
+		newRoute(http.MethodGet, "/v1/transactions/{id}", "GetTransaction", h.getTransaction),
+		newRoute(http.MethodGet, "/v1/transactions/{id}/result", "GetTransactionResult", h.getTransactionResult),
+		newRoute(http.MethodGet, "/v1/transactions/{id}/timeline", "GetTransactionTimeline", h.getTransactionTimeline),
+
+		newRoute(http.MethodGet, "/v1/accounts/{address}", "GetAccountAtLatestBlock", h.getAccount).
+			withAPIMethod(accountAPIMethod),
+
+		newRoute(http.MethodGet, "/v1/events", "GetEventsForHeightRange", h.getEvents).
+			withAPIMethod(eventsAPIMethod),
+
+		newRoute(http.MethodPost, "/v1/scripts", "ExecuteScriptAtLatestBlock", h.executeScript).
+			withAPIMethod(scriptAPIMethod),
+
+		newRoute(http.MethodGet, "/v1/epochs/current", "GetCurrentEpoch", h.getCurrentEpoch),
+		newRoute(http.MethodGet, "/v1/epochs/{counter}", "GetEpochByCounter", h.getEpochByCounter),
+		newRoute(http.MethodGet, "/v1/protocol_state/snapshot", "GetProtocolStateSnapshot", h.getProtocolStateSnapshot),
+
+		newStreamRoute(http.MethodGet, "/v1/subscribe/blocks", "SubscribeBlocks", h.subscribeBlocks),
+		newStreamRoute(http.MethodGet, "/v1/subscribe/events", "SubscribeEvents", h.subscribeEvents),
+		newStreamRoute(http.MethodGet, "/v1/subscribe/transactions/{id}", "SubscribeTransactionStatus", h.subscribeTransactionStatus),
+	}
+
+	return h
+}
+
+// ServeHTTP dispatches the request to the handler of the matching route, and writes its JSON encoded response
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "*")
+	if req.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	path := splitPath(req.URL.Path)
+	allowed := false
+	for _, rt := range h.routes {
+		params, ok := rt.match(path)
+		if !ok {
+			continue
+		}
+		if rt.method != req.Method {
+			allowed = true
+			continue
+		}
+
+		req.Body = http.MaxBytesReader(w, req.Body, grpcutils.DefaultMaxMsgSize)
+		h.serveRoute(w, &request{Request: req, params: params}, rt)
+		return
+	}
+
+	if allowed {
+		h.writeResponse(w, http.StatusMethodNotAllowed, ErrorResponse{Code: http.StatusMethodNotAllowed, Message: "method not allowed"})
+		return
+	}
+	h.writeResponse(w, http.StatusNotFound, ErrorResponse{Code: http.StatusNotFound, Message: "not found"})
+}
+
+// serveRoute serves the request matched to the route, unless it exceeds the rate limits of the client
+func (h *Handler) serveRoute(w http.ResponseWriter, r *request, rt route) {
+	var response interface{}
+	serve := func() error {
+		if rt.stream != nil {
+			h.serveStream(w, r, rt.stream)
+			return nil
+		}
+		var err error
+		response, err = rt.handler(r)
+		return err
+	}
+
+	var err error
+	if h.limiter != nil {
+		err = h.limiter.Call(h.limiter.HTTPClientID(r.Request), rt.apiMethod(r), serve)
+	} else {
+		err = serve()
+	}
+	if err != nil {
+		h.writeError(w, r.Request, err)
+		return
+	}
+
+	if rt.stream == nil {
+		h.writeResponse(w, http.StatusOK, response)
+	}
+}
+
+// accountAPIMethod returns the Access API method called by getAccount
+func accountAPIMethod(r *request) string {
+	if r.URL.Query().Get("height") != "" {
+		return "GetAccountAtBlockHeight"
+	}
+	return "GetAccountAtLatestBlock"
+}
+
+// eventsAPIMethod returns the Access API method called by getEvents
+func eventsAPIMethod(r *request) string {
+	if r.URL.Query().Get("block_ids") != "" {
+		return "GetEventsForBlockIDs"
+	}
+	return "GetEventsForHeightRange"
+}
+
+// scriptAPIMethod returns the Access API method called by executeScript
+func scriptAPIMethod(r *request) string {
+	switch {
+	case r.URL.Query().Get("block_id") != "":
+		return "ExecuteScriptAtBlockID"
+	case r.URL.Query().Get("block_height") != "":
+		return "ExecuteScriptAtBlockHeight"
+	default:
+		return "ExecuteScriptAtLatestBlock"
+	}
+}
+
+// CloseStreams ends the subscriptions being streamed, and the subscriptions started afterwards.
+// Streams do not end on their own, so they must be closed for the server to shut down gracefully.
+func (h *Handler) CloseStreams() {
+	h.closeStreams()
+}
+
+// serveStream starts the subscription and streams its responses as newline delimited JSON,
+// until the subscription ends, the client disconnects or the streams are closed. If the
+// subscription fails, the last line is the error response.
+func (h *Handler) serveStream(w http.ResponseWriter, r *request, stream streamFunc) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go func() {
+		select {
+		case <-h.streams.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	sub, err := stream(&request{Request: r.WithContext(ctx), params: r.params})
+	if err != nil {
+		h.writeError(w, r.Request, err)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	writeLine := func(response interface{}) bool {
+		body, err := h.encoder.Encode(response)
+		if err != nil {
+			h.log.Error().Err(err).Msg("could not encode response")
+			return false
+		}
+		_, err = w.Write(append(body, '\n'))
+		if err != nil {
+			h.log.Debug().Err(err).Msg("could not write response")
+			return false
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return true
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	for response := range sub.Channel() {
+		if !writeLine(response) {
+			// the subscription is canceled with the request's context when returning
+			return
+		}
+	}
+
+	err = sub.Err()
+	if err != nil && !errors.Is(err, context.Canceled) {
+		writeLine(errorResponse(err))
+	}
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, req *http.Request, err error) {
+	response := errorResponse(err)
+	if response.Code >= http.StatusInternalServerError {
+		h.log.Error().Err(err).Str("method", req.Method).Str("path", req.URL.Path).Msg("request failed")
+	}
+	h.writeResponse(w, response.Code, response)
+}
+
+func (h *Handler) writeResponse(w http.ResponseWriter, code int, response interface{}) {
+	body, err := h.encoder.Encode(response)
+	if err != nil {
+		h.log.Error().Err(err).Msg("could not encode response")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, err = w.Write(body)
+	if err != nil {
+		h.log.Debug().Err(err).Msg("could not write response")
+	}
+}
+
+func (h *Handler) getNetworkParameters(r *request) (interface{}, error) {
+	params := h.api.GetNetworkParameters(r.Context())
+	return NetworkParametersResponse{ChainID: params.ChainID}, nil
+}
+
+func (h *Handler) getLatestBlock(r *request) (interface{}, error) {
+	sealed, err := r.queryBool("sealed")
+	if err != nil {
+		return nil, err
+	}
+	return h.api.GetLatestBlock(r.Context(), sealed)
+}
+
+func (h *Handler) getBlockByID(r *request) (interface{}, error) {
+	id, err := r.id("id")
+	if err != nil {
+		return nil, err
+	}
+	return h.api.GetBlockByID(r.Context(), id)
+}
+
+func (h *Handler) getBlockByHeight(r *request) (interface{}, error) {
+	height, err := r.requireUint64("height")
+	if err != nil {
+		return nil, err
+	}
+	return h.api.GetBlockByHeight(r.Context(), height)
+}
+
+func (h *Handler) getLatestBlockHeader(r *request) (interface{}, error) {
+	sealed, err := r.queryBool("sealed")
+	if err != nil {
+		return nil, err
+	}
+	return h.api.GetLatestBlockHeader(r.Context(), sealed)
+}
+
+func (h *Handler) getBlockHeaderByID(r *request) (interface{}, error) {
+	id, err := r.id("id")
+	if err != nil {
+		return nil, err
+	}
+	return h.api.GetBlockHeaderByID(r.Context(), id)
+}
+
+func (h *Handler) getBlockHeaderByHeight(r *request) (interface{}, error) {
+	height, err := r.requireUint64("height")
+	if err != nil {
+		return nil, err
+	}
+	return h.api.GetBlockHeaderByHeight(r.Context(), height)
+}
+
+func (h *Handler) getCollectionByID(r *request) (interface{}, error) {
+	id, err := r.id("id")
+	if err != nil {
+		return nil, err
+	}
+	return h.api.GetCollectionByID(r.Context(), id)
+}
+
+func (h *Handler) sendTransaction(r *request) (interface{}, error) {
+	var tx flow.TransactionBody
+	err := r.decodeBody(&tx)
+	if err != nil {
+		return nil, err
+	}
+	err = h.api.SendTransaction(r.Context(), &tx)
+	if err != nil {
+		return nil, err
+	}
+	return SendTransactionResponse{ID: tx.ID()}, nil
+}
+
+func (h *Handler) simulateTransaction(r *request) (interface{}, error) {
+	var tx flow.TransactionBody
+	err := r.decodeBody(&tx)
+	if err != nil {
+		return nil, err
+	}
+	// the latest sealed block is used if no block is given
+	blockID := flow.ZeroID
+	if value := r.URL.Query().Get("block_id"); value != "" {
+		blockID, err = decodeID("block_id", value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	skipSignatureCheck, err := r.queryBool("skip_signature_check")
+	if err != nil {
+		return nil, err
+	}
+	return h.api.SimulateTransaction(r.Context(), &tx, blockID, skipSignatureCheck)
+}
+
+func (h *Handler) getTransaction(r *request) (interface{}, error) {
+	id, err := r.id("id")
+	if err != nil {
+		return nil, err
+	}
+	return h.api.GetTransaction(r.Context(), id)
+}
+
+func (h *Handler) getTransactionResult(r *request) (interface{}, error) {
+	id, err := r.id("id")
+	if err != nil {
+		return nil, err
+	}
+	return h.api.GetTransactionResult(r.Context(), id)
+}
+
+func (h *Handler) getTransactionTimeline(r *request) (interface{}, error) {
+	id, err := r.id("id")
+	if err != nil {
+		return nil, err
+	}
+	return h.api.GetTransactionTimeline(r.Context(), id)
+}
+
+func (h *Handler) getTransactionResultsByBlockID(r *request) (interface{}, error) {
+	id, err := r.id("id")
+	if err != nil {
+		return nil, err
+	}
+	return h.api.GetTransactionResultsByBlockID(r.Context(), id)
+}
+
+func (h *Handler) getTransactionResultByIndex(r *request) (interface{}, error) {
+	id, err := r.id("id")
+	if err != nil {
+		return nil, err
+	}
+	index, err := r.uint32("index")
+	if err != nil {
+		return nil, err
+	}
+	return h.api.GetTransactionResultByIndex(r.Context(), id, index)
+}
+
+func (h *Handler) getExecutionResultForBlockID(r *request) (interface{}, error) {
+	id, err := r.id("id")
+	if err != nil {
+		return nil, err
+	}
+	return h.api.GetExecutionResultForBlockID(r.Context(), id)
+}
+
+func (h *Handler) getSealForBlockID(r *request) (interface{}, error) {
+	id, err := r.id("id")
+	if err != nil {
+		return nil, err
+	}
+	return h.api.GetSealForBlockID(r.Context(), id)
+}
+
+func (h *Handler) getNodeIdentities(r *request) (interface{}, error) {
+	id, err := r.id("id")
+	if err != nil {
+		return nil, err
+	}
+	return h.api.GetNodeIdentities(r.Context(), id)
+}
+
+func (h *Handler) getAccount(r *request) (interface{}, error) {
+	address, err := r.address("address", h.chain)
+	if err != nil {
+		return nil, err
+	}
+	height, ok, err := r.queryUint64("height")
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return h.api.GetAccountAtBlockHeight(r.Context(), address, height)
+	}
+	return h.api.GetAccountAtLatestBlock(r.Context(), address)
+}
+
+// getEvents returns the events of the given type, either for the blocks with the given IDs,
+// or for the blocks in the given height range
+func (h *Handler) getEvents(r *request) (interface{}, error) {
+	eventType := r.URL.Query().Get("type")
+	if eventType == "" {
+		return nil, newBadRequestError("missing query parameter type")
+	}
+
+	blockIDs, err := r.queryIDs("block_ids")
+	if err != nil {
+		return nil, err
+	}
+	if len(blockIDs) > 0 {
+		return h.api.GetEventsForBlockIDs(r.Context(), eventType, blockIDs)
+	}
+
+	startHeight, err := r.requireUint64("start_height")
+	if err != nil {
+		return nil, err
+	}
+	endHeight, err := r.requireUint64("end_height")
+	if err != nil {
+		return nil, err
+	}
+	return h.api.GetEventsForHeightRange(r.Context(), eventType, startHeight, endHeight)
+}
+
+// executeScript executes the script at the given block (by ID or height), or at the latest sealed block
+func (h *Handler) executeScript(r *request) (interface{}, error) {
+	var script ScriptRequest
+	err := r.decodeBody(&script)
+	if err != nil {
+		return nil, err
+	}
+
+	height, hasHeight, err := r.queryUint64("block_height")
+	if err != nil {
+		return nil, err
+	}
+	blockID := r.URL.Query().Get("block_id")
+	if hasHeight && blockID != "" {
+		return nil, newBadRequestError("provide either block_id or block_height, not both")
+	}
+
+	var value []byte
+	switch {
+	case blockID != "":
+		id, err := decodeID("block_id", blockID)
+		if err != nil {
+			return nil, err
+		}
+		value, err = h.api.ExecuteScriptAtBlockID(r.Context(), id, script.Script, script.Arguments)
+		if err != nil {
+			return nil, err
+		}
+	case hasHeight:
+		value, err = h.api.ExecuteScriptAtBlockHeight(r.Context(), height, script.Script, script.Arguments)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		value, err = h.api.ExecuteScriptAtLatestBlock(r.Context(), script.Script, script.Arguments)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ScriptResponse{Value: value}, nil
+}
+
+func (h *Handler) getCurrentEpoch(r *request) (interface{}, error) {
+	epoch, err := h.api.GetCurrentEpoch(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	return newEpochResponse(epoch), nil
+}
+
+func (h *Handler) getEpochByCounter(r *request) (interface{}, error) {
+	counter, err := r.uint64("counter")
+	if err != nil {
+		return nil, err
+	}
+	epoch, err := h.api.GetEpochByCounter(r.Context(), counter)
+	if err != nil {
+		return nil, err
+	}
+	return newEpochResponse(epoch), nil
+}
+
+func (h *Handler) getProtocolStateSnapshot(r *request) (interface{}, error) {
+	snapshot, err := h.api.GetProtocolStateSnapshot(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	// the snapshot is already JSON encoded
+	return stdjson.RawMessage(snapshot), nil
+}
+
+func (h *Handler) subscribeBlocks(r *request) (access.Subscription, error) {
+	startHeight, _, err := r.queryUint64("start_height")
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := r.queryBool("sealed")
+	if err != nil {
+		return nil, err
+	}
+	return h.api.SubscribeBlocks(r.Context(), startHeight, sealed)
+}
+
+// subscribeEvents streams the events of the given comma separated types, optionally restricted
+// to the events holding one of the given comma separated addresses
+func (h *Handler) subscribeEvents(r *request) (access.Subscription, error) {
+	startHeight, _, err := r.queryUint64("start_height")
+	if err != nil {
+		return nil, err
+	}
+
+	var filter access.EventFilter
+	for _, eventType := range strings.Split(r.URL.Query().Get("type"), ",") {
+		if eventType != "" {
+			filter.EventTypes = append(filter.EventTypes, flow.EventType(eventType))
+		}
+	}
+	if len(filter.EventTypes) == 0 {
+		return nil, newBadRequestError("missing query parameter type")
+	}
+	for _, address := range strings.Split(r.URL.Query().Get("address"), ",") {
+		if address == "" {
+			continue
+		}
+		decoded, err := decodeAddress("address", address, h.chain)
+		if err != nil {
+			return nil, err
+		}
+		filter.Addresses = append(filter.Addresses, decoded)
+	}
+
+	return h.api.SubscribeEvents(r.Context(), startHeight, filter)
+}
+
+func (h *Handler) subscribeTransactionStatus(r *request) (access.Subscription, error) {
+	id, err := r.id("id")
+	if err != nil {
+		return nil, err
+	}
+	return h.api.SubscribeTransactionStatus(r.Context(), id)
+}
@@ -0,0 +1,609 @@
+package rest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onflow/flow-go/access"
+	accessmock "github.com/onflow/flow-go/access/mock"
+	"github.com/onflow/flow-go/engine/access/rpc/ratelimit"
+	"github.com/onflow/flow-go/model/encoding/json"
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/module/metrics"
+	"github.com/onflow/flow-go/utils/unittest"
+)
+
+var encoder = json.NewEncoder()
+
+// serve sends the request to a handler backed by the given API and returns the recorded response
+func serve(t *testing.T, api *accessmock.API, method string, url string, body interface{}) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if body != nil {
+		reader = bytes.NewReader(encoder.MustEncode(body))
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, url, reader)
+	rec := httptest.NewRecorder()
+	NewHandler(api, flow.Testnet.Chain(), nil, zerolog.Nop()).ServeHTTP(rec, req)
+	return rec
+}
+
+// requireResponse checks the status code and that the body is the JSON encoding of the expected value
+func requireResponse(t *testing.T, rec *httptest.ResponseRecorder, code int, expected interface{}) {
+	require.Equal(t, code, rec.Code, rec.Body.String())
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.JSONEq(t, string(encoder.MustEncode(expected)), rec.Body.String())
+}
+
+// requireError checks the status code of an error response
+func requireError(t *testing.T, rec *httptest.ResponseRecorder, code int) {
+	require.Equal(t, code, rec.Code, rec.Body.String())
+	var response ErrorResponse
+	encoder.MustDecode(rec.Body.Bytes(), &response)
+	require.Equal(t, code, response.Code)
+	require.NotEmpty(t, response.Message)
+}
+
+func TestBlocks(t *testing.T) {
+	block := unittest.BlockFixture()
+	blockID := block.ID()
+
+	t.Run("latest sealed", func(t *testing.T) {
+		api := new(accessmock.API)
+		api.On("GetLatestBlock", mock.Anything, true).Return(&block, nil)
+
+		rec := serve(t, api, http.MethodGet, "/v1/blocks/latest?sealed=true", nil)
+		requireResponse(t, rec, http.StatusOK, &block)
+		api.AssertExpectations(t)
+	})
+
+	t.Run("by ID", func(t *testing.T) {
+		api := new(accessmock.API)
+		api.On("GetBlockByID", mock.Anything, blockID).Return(&block, nil)
+
+		rec := serve(t, api, http.MethodGet, "/v1/blocks/"+blockID.String(), nil)
+		requireResponse(t, rec, http.StatusOK, &block)
+	})
+
+	t.Run("by height", func(t *testing.T) {
+		api := new(accessmock.API)
+		api.On("GetBlockByHeight", mock.Anything, block.Header.Height).Return(&block, nil)
+
+		rec := serve(t, api, http.MethodGet, fmt.Sprintf("/v1/blocks?height=%d", block.Header.Height), nil)
+		requireResponse(t, rec, http.StatusOK, &block)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		api := new(accessmock.API)
+		api.On("GetBlockByID", mock.Anything, blockID).Return(nil, status.Error(codes.NotFound, "not found"))
+
+		rec := serve(t, api, http.MethodGet, "/v1/blocks/"+blockID.String(), nil)
+		requireError(t, rec, http.StatusNotFound)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		api := new(accessmock.API)
+		requireError(t, serve(t, api, http.MethodGet, "/v1/blocks/xyz", nil), http.StatusBadRequest)
+		requireError(t, serve(t, api, http.MethodGet, "/v1/blocks/abcd", nil), http.StatusBadRequest)
+		requireError(t, serve(t, api, http.MethodGet, "/v1/blocks", nil), http.StatusBadRequest)
+		requireError(t, serve(t, api, http.MethodGet, "/v1/blocks?height=-1", nil), http.StatusBadRequest)
+		requireError(t, serve(t, api, http.MethodGet, "/v1/blocks/latest?sealed=maybe", nil), http.StatusBadRequest)
+		api.AssertNotCalled(t, "GetBlockByID", mock.Anything, mock.Anything)
+	})
+}
+
+func TestHeaders(t *testing.T) {
+	header := unittest.BlockHeaderFixture()
+	headerID := header.ID()
+
+	api := new(accessmock.API)
+	api.On("GetLatestBlockHeader", mock.Anything, false).Return(&header, nil)
+	api.On("GetBlockHeaderByID", mock.Anything, headerID).Return(&header, nil)
+	api.On("GetBlockHeaderByHeight", mock.Anything, header.Height).Return(&header, nil)
+
+	requireResponse(t, serve(t, api, http.MethodGet, "/v1/headers/latest", nil), http.StatusOK, &header)
+	requireResponse(t, serve(t, api, http.MethodGet, "/v1/headers/0x"+headerID.String(), nil), http.StatusOK, &header)
+	requireResponse(t, serve(t, api, http.MethodGet, fmt.Sprintf("/v1/headers?height=%d", header.Height), nil), http.StatusOK, &header)
+	api.AssertExpectations(t)
+}
+
+func TestCollections(t *testing.T) {
+	collection := unittest.CollectionFixture(3).Light()
+	collectionID := collection.ID()
+
+	api := new(accessmock.API)
+	api.On("GetCollectionByID", mock.Anything, collectionID).Return(&collection, nil)
+
+	rec := serve(t, api, http.MethodGet, "/v1/collections/"+collectionID.String(), nil)
+	requireResponse(t, rec, http.StatusOK, &collection)
+}
+
+func TestTransactions(t *testing.T) {
+	tx := unittest.TransactionBodyFixture()
+	txID := tx.ID()
+
+	t.Run("send", func(t *testing.T) {
+		api := new(accessmock.API)
+		api.On("SendTransaction", mock.Anything, &tx).Return(nil)
+
+		rec := serve(t, api, http.MethodPost, "/v1/transactions", &tx)
+		requireResponse(t, rec, http.StatusOK, SendTransactionResponse{ID: txID})
+		api.AssertExpectations(t)
+	})
+
+	t.Run("send invalid", func(t *testing.T) {
+		api := new(accessmock.API)
+		api.On("SendTransaction", mock.Anything, mock.Anything).Return(status.Error(codes.InvalidArgument, "invalid transaction"))
+
+		requireError(t, serve(t, api, http.MethodPost, "/v1/transactions", &tx), http.StatusBadRequest)
+		requireError(t, serve(t, api, http.MethodPost, "/v1/transactions", "not a transaction"), http.StatusBadRequest)
+		api.AssertNumberOfCalls(t, "SendTransaction", 1)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		api := new(accessmock.API)
+		api.On("GetTransaction", mock.Anything, txID).Return(&tx, nil)
+
+		rec := serve(t, api, http.MethodGet, "/v1/transactions/"+txID.String(), nil)
+		requireResponse(t, rec, http.StatusOK, &tx)
+	})
+
+	t.Run("result", func(t *testing.T) {
+		result := &access.TransactionResult{
+			Status:     flow.TransactionStatusSealed,
+			StatusCode: 0,
+			Events: []flow.Event{
+				unittest.EventFixture(flow.EventAccountCreated, 0, 0, txID),
+			},
+		}
+		api := new(accessmock.API)
+		api.On("GetTransactionResult", mock.Anything, txID).Return(result, nil)
+
+		rec := serve(t, api, http.MethodGet, "/v1/transactions/"+txID.String()+"/result", nil)
+		requireResponse(t, rec, http.StatusOK, result)
+	})
+
+	t.Run("timeline", func(t *testing.T) {
+		timeline := &access.TransactionTimeline{
+			TransactionID: txID,
+			ClusterIndex:  1,
+			Cluster:       unittest.IdentifierListFixture(3),
+			Transitions: []access.TransactionStatusTransition{
+				{Status: flow.TransactionStatusPending, Time: time.Now().UTC(), BlockHeight: 10},
+				{Status: flow.TransactionStatusFinalized, Time: time.Now().UTC(), BlockHeight: 12},
+			},
+		}
+		api := new(accessmock.API)
+		api.On("GetTransactionTimeline", mock.Anything, txID).Return(timeline, nil)
+
+		rec := serve(t, api, http.MethodGet, "/v1/transactions/"+txID.String()+"/timeline", nil)
+		requireResponse(t, rec, http.StatusOK, timeline)
+	})
+
+	t.Run("simulate", func(t *testing.T) {
+		blockID := unittest.IdentifierFixture()
+		simulation := &flow.TransactionSimulation{
+			TransactionID:   txID,
+			BlockID:         blockID,
+			Logs:            []string{"log"},
+			ComputationUsed: 10,
+		}
+		api := new(accessmock.API)
+		api.On("SimulateTransaction", mock.Anything, &tx, blockID, true).Return(simulation, nil)
+		api.On("SimulateTransaction", mock.Anything, &tx, flow.ZeroID, false).Return(simulation, nil)
+
+		rec := serve(t, api, http.MethodPost, "/v1/transactions/simulate?block_id="+blockID.String()+"&skip_signature_check=true", &tx)
+		requireResponse(t, rec, http.StatusOK, simulation)
+
+		// the latest sealed block is used if no block is given
+		rec = serve(t, api, http.MethodPost, "/v1/transactions/simulate", &tx)
+		requireResponse(t, rec, http.StatusOK, simulation)
+
+		requireError(t, serve(t, api, http.MethodPost, "/v1/transactions/simulate?block_id=invalid", &tx), http.StatusBadRequest)
+		api.AssertNumberOfCalls(t, "SimulateTransaction", 2)
+	})
+
+	t.Run("internal error", func(t *testing.T) {
+		api := new(accessmock.API)
+		api.On("GetTransaction", mock.Anything, txID).Return(nil, fmt.Errorf("storage failure"))
+
+		rec := serve(t, api, http.MethodGet, "/v1/transactions/"+txID.String(), nil)
+		requireError(t, rec, http.StatusInternalServerError)
+	})
+}
+
+func TestBlockResults(t *testing.T) {
+	block := unittest.BlockFixture()
+	blockID := block.ID()
+
+	t.Run("transaction results", func(t *testing.T) {
+		results := []*access.TransactionResult{
+			{Status: flow.TransactionStatusSealed, StatusCode: 1, ErrorMessage: "failed"},
+			{Status: flow.TransactionStatusSealed},
+		}
+		api := new(accessmock.API)
+		api.On("GetTransactionResultsByBlockID", mock.Anything, blockID).Return(results, nil)
+
+		rec := serve(t, api, http.MethodGet, "/v1/blocks/"+blockID.String()+"/transaction_results", nil)
+		requireResponse(t, rec, http.StatusOK, results)
+	})
+
+	t.Run("transaction result by index", func(t *testing.T) {
+		result := &access.TransactionResult{Status: flow.TransactionStatusExecuted}
+		api := new(accessmock.API)
+		api.On("GetTransactionResultByIndex", mock.Anything, blockID, uint32(1)).Return(result, nil)
+
+		rec := serve(t, api, http.MethodGet, "/v1/blocks/"+blockID.String()+"/transaction_results/1", nil)
+		requireResponse(t, rec, http.StatusOK, result)
+
+		requireError(t, serve(t, api, http.MethodGet, "/v1/blocks/"+blockID.String()+"/transaction_results/-1", nil), http.StatusBadRequest)
+		api.AssertNumberOfCalls(t, "GetTransactionResultByIndex", 1)
+	})
+
+	t.Run("execution result", func(t *testing.T) {
+		result := unittest.ExecutionResultFixture()
+		api := new(accessmock.API)
+		api.On("GetExecutionResultForBlockID", mock.Anything, blockID).Return(result, nil)
+
+		rec := serve(t, api, http.MethodGet, "/v1/blocks/"+blockID.String()+"/execution_result", nil)
+		requireResponse(t, rec, http.StatusOK, result)
+	})
+
+	t.Run("seal", func(t *testing.T) {
+		seal := unittest.Seal.Fixture(unittest.Seal.WithBlockID(blockID))
+		api := new(accessmock.API)
+		api.On("GetSealForBlockID", mock.Anything, blockID).Return(seal, nil)
+
+		rec := serve(t, api, http.MethodGet, "/v1/blocks/"+blockID.String()+"/seal", nil)
+		requireResponse(t, rec, http.StatusOK, seal)
+	})
+
+	t.Run("not sealed", func(t *testing.T) {
+		api := new(accessmock.API)
+		api.On("GetSealForBlockID", mock.Anything, blockID).Return(nil, status.Error(codes.NotFound, "block is not sealed"))
+
+		rec := serve(t, api, http.MethodGet, "/v1/blocks/"+blockID.String()+"/seal", nil)
+		requireError(t, rec, http.StatusNotFound)
+	})
+}
+
+func TestEpochs(t *testing.T) {
+	identities := unittest.IdentityListFixture(4, unittest.WithRole(flow.RoleCollection))
+	epoch := &access.Epoch{
+		Counter:    2,
+		FirstView:  1000,
+		FinalView:  1999,
+		Identities: identities,
+		Clusters:   [][]flow.Identifier{identities[:2].NodeIDs(), identities[2:].NodeIDs()},
+		Phase:      flow.EpochPhaseSetup,
+	}
+	expected := &EpochResponse{
+		Counter:    epoch.Counter,
+		FirstView:  epoch.FirstView,
+		FinalView:  epoch.FinalView,
+		Identities: epoch.Identities,
+		Clusters:   epoch.Clusters,
+		Phase:      flow.EpochPhaseSetup,
+	}
+
+	t.Run("current epoch", func(t *testing.T) {
+		api := new(accessmock.API)
+		api.On("GetCurrentEpoch", mock.Anything).Return(epoch, nil)
+
+		rec := serve(t, api, http.MethodGet, "/v1/epochs/current", nil)
+		requireResponse(t, rec, http.StatusOK, expected)
+		api.AssertNotCalled(t, "GetEpochByCounter", mock.Anything, mock.Anything)
+	})
+
+	t.Run("epoch by counter", func(t *testing.T) {
+		api := new(accessmock.API)
+		api.On("GetEpochByCounter", mock.Anything, uint64(2)).Return(epoch, nil)
+		api.On("GetEpochByCounter", mock.Anything, uint64(5)).Return(nil, status.Error(codes.NotFound, "epoch is not available"))
+
+		rec := serve(t, api, http.MethodGet, "/v1/epochs/2", nil)
+		requireResponse(t, rec, http.StatusOK, expected)
+
+		requireError(t, serve(t, api, http.MethodGet, "/v1/epochs/5", nil), http.StatusNotFound)
+		requireError(t, serve(t, api, http.MethodGet, "/v1/epochs/next", nil), http.StatusBadRequest)
+	})
+
+	t.Run("node identities", func(t *testing.T) {
+		blockID := unittest.IdentifierFixture()
+		api := new(accessmock.API)
+		api.On("GetNodeIdentities", mock.Anything, blockID).Return(identities, nil)
+
+		rec := serve(t, api, http.MethodGet, "/v1/blocks/"+blockID.String()+"/identities", nil)
+		requireResponse(t, rec, http.StatusOK, identities)
+	})
+
+	t.Run("protocol state snapshot", func(t *testing.T) {
+		snapshot := []byte(`{"EpochFirstView":42}`)
+		api := new(accessmock.API)
+		api.On("GetProtocolStateSnapshot", mock.Anything).Return(snapshot, nil)
+
+		rec := serve(t, api, http.MethodGet, "/v1/protocol_state/snapshot", nil)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		require.JSONEq(t, string(snapshot), rec.Body.String())
+	})
+}
+
+func TestAccounts(t *testing.T) {
+	address := unittest.AddressFixture()
+	account := &flow.Account{
+		Address:   address,
+		Balance:   100,
+		Contracts: map[string][]byte{"Contract": []byte("pub contract Contract {}")},
+	}
+
+	api := new(accessmock.API)
+	api.On("GetAccountAtLatestBlock", mock.Anything, address).Return(account, nil)
+	api.On("GetAccountAtBlockHeight", mock.Anything, address, uint64(42)).Return(account, nil)
+
+	requireResponse(t, serve(t, api, http.MethodGet, "/v1/accounts/"+address.Hex(), nil), http.StatusOK, account)
+	requireResponse(t, serve(t, api, http.MethodGet, "/v1/accounts/"+address.HexWithPrefix()+"?height=42", nil), http.StatusOK, account)
+	api.AssertExpectations(t)
+
+	// addresses must be valid for the chain
+	requireError(t, serve(t, api, http.MethodGet, "/v1/accounts/0000000000000001", nil), http.StatusBadRequest)
+	requireError(t, serve(t, api, http.MethodGet, "/v1/accounts/000000000000000000", nil), http.StatusBadRequest)
+}
+
+func TestEvents(t *testing.T) {
+	eventType := string(flow.EventAccountCreated)
+	blockIDs := []flow.Identifier{unittest.IdentifierFixture(), unittest.IdentifierFixture()}
+	events := []flow.BlockEvents{
+		{
+			BlockID:     blockIDs[0],
+			BlockHeight: 5,
+			Events:      []flow.Event{unittest.EventFixture(flow.EventAccountCreated, 0, 0, unittest.IdentifierFixture())},
+		},
+	}
+
+	t.Run("height range", func(t *testing.T) {
+		api := new(accessmock.API)
+		api.On("GetEventsForHeightRange", mock.Anything, eventType, uint64(5), uint64(10)).Return(events, nil)
+
+		rec := serve(t, api, http.MethodGet, "/v1/events?type="+eventType+"&start_height=5&end_height=10", nil)
+		requireResponse(t, rec, http.StatusOK, events)
+	})
+
+	t.Run("block IDs", func(t *testing.T) {
+		api := new(accessmock.API)
+		api.On("GetEventsForBlockIDs", mock.Anything, eventType, blockIDs).Return(events, nil)
+
+		url := fmt.Sprintf("/v1/events?type=%s&block_ids=%s,%s", eventType, blockIDs[0], blockIDs[1])
+		rec := serve(t, api, http.MethodGet, url, nil)
+		requireResponse(t, rec, http.StatusOK, events)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		api := new(accessmock.API)
+		requireError(t, serve(t, api, http.MethodGet, "/v1/events?start_height=5&end_height=10", nil), http.StatusBadRequest)
+		requireError(t, serve(t, api, http.MethodGet, "/v1/events?type="+eventType+"&start_height=5", nil), http.StatusBadRequest)
+		requireError(t, serve(t, api, http.MethodGet, "/v1/events?type="+eventType+"&block_ids=abc", nil), http.StatusBadRequest)
+	})
+}
+
+func TestScripts(t *testing.T) {
+	script := ScriptRequest{
+		Script:    []byte("pub fun main(a: Int): Int { return a }"),
+		Arguments: [][]byte{[]byte(`{"type":"Int","value":"1"}`)},
+	}
+	value := []byte(`{"type":"Int","value":"1"}`)
+	blockID := unittest.IdentifierFixture()
+
+	api := new(accessmock.API)
+	api.On("ExecuteScriptAtLatestBlock", mock.Anything, script.Script, script.Arguments).Return(value, nil)
+	api.On("ExecuteScriptAtBlockHeight", mock.Anything, uint64(7), script.Script, script.Arguments).Return(value, nil)
+	api.On("ExecuteScriptAtBlockID", mock.Anything, blockID, script.Script, script.Arguments).Return(value, nil)
+
+	expected := ScriptResponse{Value: value}
+	requireResponse(t, serve(t, api, http.MethodPost, "/v1/scripts", script), http.StatusOK, expected)
+	requireResponse(t, serve(t, api, http.MethodPost, "/v1/scripts?block_height=7", script), http.StatusOK, expected)
+	requireResponse(t, serve(t, api, http.MethodPost, "/v1/scripts?block_id="+blockID.String(), script), http.StatusOK, expected)
+	api.AssertExpectations(t)
+
+	url := fmt.Sprintf("/v1/scripts?block_height=7&block_id=%s", blockID)
+	requireError(t, serve(t, api, http.MethodPost, url, script), http.StatusBadRequest)
+}
+
+func TestRouting(t *testing.T) {
+	api := new(accessmock.API)
+	api.On("GetNetworkParameters", mock.Anything).Return(access.NetworkParameters{ChainID: flow.Testnet})
+
+	requireResponse(t, serve(t, api, http.MethodGet, "/v1/network/parameters", nil), http.StatusOK, NetworkParametersResponse{ChainID: flow.Testnet})
+	requireError(t, serve(t, api, http.MethodGet, "/v1/unknown", nil), http.StatusNotFound)
+	requireError(t, serve(t, api, http.MethodGet, "/v1/transactions", nil), http.StatusMethodNotAllowed)
+	requireError(t, serve(t, api, http.MethodDelete, "/v1/blocks/latest", nil), http.StatusMethodNotAllowed)
+
+	rec := serve(t, api, http.MethodOptions, "/v1/transactions", nil)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+}
+
+// testSubscription streams the given responses, and then ends with the given error
+type testSubscription struct {
+	ch  chan interface{}
+	err error
+}
+
+func newTestSubscription(err error, responses ...interface{}) *testSubscription {
+	ch := make(chan interface{}, len(responses))
+	for _, response := range responses {
+		ch <- response
+	}
+	close(ch)
+	return &testSubscription{ch: ch, err: err}
+}
+
+func (s *testSubscription) Channel() <-chan interface{} {
+	return s.ch
+}
+
+func (s *testSubscription) Err() error {
+	return s.err
+}
+
+// requireStream checks that the body consists of the JSON encodings of the expected values, one per line
+func requireStream(t *testing.T, rec *httptest.ResponseRecorder, expected ...interface{}) {
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	lines := bytes.Split(bytes.TrimSuffix(rec.Body.Bytes(), []byte("\n")), []byte("\n"))
+	require.Len(t, lines, len(expected))
+	for i, line := range lines {
+		require.JSONEq(t, string(encoder.MustEncode(expected[i])), string(line))
+	}
+}
+
+func TestRateLimits(t *testing.T) {
+	address := unittest.AddressFixture()
+	account := &flow.Account{Address: address}
+	header := unittest.BlockHeaderFixture()
+
+	api := new(accessmock.API)
+	api.On("GetLatestBlockHeader", mock.Anything, false).Return(&header, nil)
+	api.On("GetAccountAtLatestBlock", mock.Anything, address).Return(account, nil)
+	api.On("GetAccountAtBlockHeight", mock.Anything, address, uint64(42)).Return(account, nil)
+
+	limiter := ratelimit.NewLimiter(ratelimit.Config{
+		Default: ratelimit.Limit{Rate: 1, Burst: 1},
+		APIKeys: []string{"key"},
+	}, metrics.NewNoopCollector())
+	handler := NewHandler(api, flow.Testnet.Chain(), limiter, zerolog.Nop())
+
+	serveAs := func(apiKey string, url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if apiKey != "" {
+			req.Header.Set(ratelimit.APIKeyHeader, apiKey)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("requests exceeding the limit are rejected", func(t *testing.T) {
+		requireResponse(t, serveAs("", "/v1/headers/latest"), http.StatusOK, &header)
+		requireError(t, serveAs("", "/v1/headers/latest"), http.StatusTooManyRequests)
+
+		// clients with a configured API key have their own limits
+		requireResponse(t, serveAs("key", "/v1/headers/latest"), http.StatusOK, &header)
+
+		// clients with an unknown API key are limited by their IP address
+		requireError(t, serveAs("random", "/v1/headers/latest"), http.StatusTooManyRequests)
+	})
+
+	t.Run("requests are limited by the Access API method they call", func(t *testing.T) {
+		requireResponse(t, serveAs("", "/v1/accounts/"+address.Hex()), http.StatusOK, account)
+		requireResponse(t, serveAs("", "/v1/accounts/"+address.Hex()+"?height=42"), http.StatusOK, account)
+		requireError(t, serveAs("", "/v1/accounts/"+address.Hex()+"?height=42"), http.StatusTooManyRequests)
+	})
+}
+
+func TestSubscriptions(t *testing.T) {
+	block1 := unittest.BlockFixture()
+	block2 := unittest.BlockFixture()
+
+	t.Run("blocks", func(t *testing.T) {
+		api := new(accessmock.API)
+		api.On("SubscribeBlocks", mock.Anything, uint64(5), true).Return(newTestSubscription(nil, &block1, &block2), nil)
+
+		rec := serve(t, api, http.MethodGet, "/v1/subscribe/blocks?start_height=5&sealed=true", nil)
+		requireStream(t, rec, &block1, &block2)
+	})
+
+	t.Run("events", func(t *testing.T) {
+		address := unittest.AddressFixture()
+		filter := access.EventFilter{
+			EventTypes: []flow.EventType{flow.EventAccountCreated, flow.EventAccountUpdated},
+			Addresses:  []flow.Address{address},
+		}
+		events := &flow.BlockEvents{BlockID: block1.ID(), BlockHeight: 5, Events: []flow.Event{}}
+		api := new(accessmock.API)
+		api.On("SubscribeEvents", mock.Anything, uint64(0), filter).Return(newTestSubscription(nil, events), nil)
+
+		url := fmt.Sprintf("/v1/subscribe/events?type=%s,%s&address=%s", flow.EventAccountCreated, flow.EventAccountUpdated, address.HexWithPrefix())
+		rec := serve(t, api, http.MethodGet, url, nil)
+		requireStream(t, rec, events)
+
+		requireError(t, serve(t, api, http.MethodGet, "/v1/subscribe/events", nil), http.StatusBadRequest)
+		requireError(t, serve(t, api, http.MethodGet, "/v1/subscribe/events?type=flow.AccountCreated&address=0000000000000001", nil), http.StatusBadRequest)
+	})
+
+	t.Run("transaction status", func(t *testing.T) {
+		txID := unittest.IdentifierFixture()
+		finalized := &access.TransactionResult{Status: flow.TransactionStatusFinalized}
+		api := new(accessmock.API)
+		api.On("SubscribeTransactionStatus", mock.Anything, txID).
+			Return(newTestSubscription(status.Error(codes.Unavailable, "execution node unavailable"), finalized), nil)
+
+		// the error ending the subscription is the last line of the stream
+		rec := serve(t, api, http.MethodGet, "/v1/subscribe/transactions/"+txID.String(), nil)
+		requireStream(t, rec, finalized, ErrorResponse{Code: http.StatusServiceUnavailable, Message: "execution node unavailable"})
+	})
+
+	t.Run("invalid subscription", func(t *testing.T) {
+		api := new(accessmock.API)
+		api.On("SubscribeBlocks", mock.Anything, uint64(1), false).Return(nil, status.Error(codes.InvalidArgument, "start height below root"))
+
+		requireError(t, serve(t, api, http.MethodGet, "/v1/subscribe/blocks?start_height=1", nil), http.StatusBadRequest)
+	})
+}
+
+func TestShutdownEndsSubscriptions(t *testing.T) {
+	block := unittest.BlockFixture()
+
+	// the subscription streams one block, and then waits until it is canceled
+	api := new(accessmock.API)
+	api.On("SubscribeBlocks", mock.Anything, uint64(5), false).Return(
+		func(ctx context.Context, _ uint64, _ bool) access.Subscription {
+			sub := &testSubscription{ch: make(chan interface{}, 1)}
+			sub.ch <- &block
+			go func() {
+				<-ctx.Done()
+				sub.err = ctx.Err()
+				close(sub.ch)
+			}()
+			return sub
+		},
+		nil,
+	)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := NewServer(api, flow.Testnet.Chain(), "", nil, zerolog.Nop())
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	res, err := http.Get(fmt.Sprintf("http://%s/v1/subscribe/blocks?start_height=5", listener.Addr()))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	reader := bufio.NewReader(res.Body)
+	line, err := reader.ReadBytes('\n')
+	require.NoError(t, err)
+	require.JSONEq(t, string(encoder.MustEncode(&block)), string(line))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, server.Shutdown(ctx))
+
+	// the stream ends without an error line
+	_, err = reader.ReadBytes('\n')
+	require.Equal(t, io.EOF, err)
+}
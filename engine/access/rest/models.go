@@ -0,0 +1,54 @@
+package rest
+
+import (
+	"github.com/onflow/flow-go/access"
+	"github.com/onflow/flow-go/model/encodable"
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// Flow entities are encoded with the JSON encoding of the model (see model/encoding/json),
+// the types below only define the requests and responses without a model counterpart.
+
+// NetworkParametersResponse is the response of GET /v1/network/parameters
+type NetworkParametersResponse struct {
+	ChainID flow.ChainID
+}
+
+// SendTransactionResponse is the response of POST /v1/transactions
+type SendTransactionResponse struct {
+	ID flow.Identifier
+}
+
+// ScriptRequest is the body of POST /v1/scripts
+type ScriptRequest struct {
+	Script    []byte
+	Arguments [][]byte
+}
+
+// ScriptResponse is the response of POST /v1/scripts
+type ScriptResponse struct {
+	Value []byte
+}
+
+// EpochResponse is the response of GET /v1/epochs/current and GET /v1/epochs/{counter}
+type EpochResponse struct {
+	Counter     uint64
+	FirstView   uint64
+	FinalView   uint64
+	Identities  flow.IdentityList
+	Clusters    [][]flow.Identifier
+	DKGGroupKey encodable.RandomBeaconPubKey
+	Phase       flow.EpochPhase
+}
+
+func newEpochResponse(epoch *access.Epoch) *EpochResponse {
+	return &EpochResponse{
+		Counter:     epoch.Counter,
+		FirstView:   epoch.FirstView,
+		FinalView:   epoch.FinalView,
+		Identities:  epoch.Identities,
+		Clusters:    epoch.Clusters,
+		DKGGroupKey: encodable.RandomBeaconPubKey{PublicKey: epoch.DKGGroupKey},
+		Phase:       epoch.Phase,
+	}
+}
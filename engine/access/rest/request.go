@@ -0,0 +1,144 @@
+package rest
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/onflow/flow-go/engine/common/rpc/convert"
+	"github.com/onflow/flow-go/model/encoding/json"
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// decodeHex decodes a hex string, optionally prefixed with 0x
+func decodeHex(name string, value string) ([]byte, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	if err != nil {
+		return nil, newBadRequestError("invalid %s %q: %w", name, value, err)
+	}
+	return b, nil
+}
+
+// decodeID decodes a hex encoded identifier
+func decodeID(name string, value string) (flow.Identifier, error) {
+	b, err := decodeHex(name, value)
+	if err != nil {
+		return flow.ZeroID, err
+	}
+	if len(b) != len(flow.ZeroID) {
+		return flow.ZeroID, newBadRequestError("invalid %s %q: expected %d bytes", name, value, len(flow.ZeroID))
+	}
+	return flow.HashToID(b), nil
+}
+
+// id returns the identifier given by the path parameter
+func (r *request) id(name string) (flow.Identifier, error) {
+	return decodeID(name, r.params[name])
+}
+
+// decodeAddress decodes a hex encoded address, which must be valid for the chain
+func decodeAddress(name string, value string, chain flow.Chain) (flow.Address, error) {
+	b, err := decodeHex(name, value)
+	if err != nil {
+		return flow.EmptyAddress, err
+	}
+	if len(b) > flow.AddressLength {
+		return flow.EmptyAddress, newBadRequestError("invalid %s %q: expected at most %d bytes", name, value, flow.AddressLength)
+	}
+	return convert.Address(b, chain)
+}
+
+// address returns the address given by the path parameter
+func (r *request) address(name string, chain flow.Chain) (flow.Address, error) {
+	return decodeAddress(name, r.params[name], chain)
+}
+
+// uint32 returns the unsigned integer given by the path parameter
+func (r *request) uint32(name string) (uint32, error) {
+	value := r.params[name]
+	v, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, newBadRequestError("invalid %s %q: %w", name, value, err)
+	}
+	return uint32(v), nil
+}
+
+// uint64 returns the unsigned integer given by the path parameter
+func (r *request) uint64(name string) (uint64, error) {
+	value := r.params[name]
+	v, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, newBadRequestError("invalid %s %q: %w", name, value, err)
+	}
+	return v, nil
+}
+
+// queryUint64 returns the unsigned integer given by the query parameter, and whether it is set
+func (r *request) queryUint64(name string) (uint64, bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, false, nil
+	}
+	v, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, false, newBadRequestError("invalid %s %q: %w", name, value, err)
+	}
+	return v, true, nil
+}
+
+// requireUint64 returns the unsigned integer given by the query parameter, which must be set
+func (r *request) requireUint64(name string) (uint64, error) {
+	v, ok, err := r.queryUint64(name)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, newBadRequestError("missing query parameter %s", name)
+	}
+	return v, nil
+}
+
+// queryBool returns the boolean given by the query parameter, false if not set
+func (r *request) queryBool(name string) (bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return false, nil
+	}
+	v, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, newBadRequestError("invalid %s %q: %w", name, value, err)
+	}
+	return v, nil
+}
+
+// queryIDs returns the comma separated identifiers given by the query parameter
+func (r *request) queryIDs(name string) ([]flow.Identifier, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	parts := strings.Split(value, ",")
+	ids := make([]flow.Identifier, 0, len(parts))
+	for _, part := range parts {
+		id, err := decodeID(name, part)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// decodeBody decodes the JSON body of the request
+func (r *request) decodeBody(val interface{}) error {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return newBadRequestError("could not read request body: %w", err)
+	}
+	err = json.NewEncoder().Decode(body, val)
+	if err != nil {
+		return newBadRequestError("could not decode request body: %w", err)
+	}
+	return nil
+}
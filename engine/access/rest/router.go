@@ -0,0 +1,90 @@
+package rest
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/onflow/flow-go/access"
+)
+
+// request is an HTTP request matched to a route
+type request struct {
+	*http.Request
+	params map[string]string // path parameters, by name
+}
+
+// handlerFunc handles a request and returns the response to be encoded as JSON
+type handlerFunc func(r *request) (interface{}, error)
+
+// streamFunc handles a request by starting a subscription, whose responses are streamed
+type streamFunc func(r *request) (access.Subscription, error)
+
+// apiMethodFunc returns the name of the Access API method a request calls, under which it is rate limited
+type apiMethodFunc func(r *request) string
+
+// route maps a method and a path pattern to a handler, or to a stream for subscriptions.
+// Segments of the pattern of the form {name} match any non-empty segment, which is passed
+// to the handler as the path parameter with the given name.
+type route struct {
+	method    string
+	pattern   []string
+	apiMethod apiMethodFunc
+	handler   handlerFunc
+	stream    streamFunc
+}
+
+func newRoute(method string, pattern string, apiMethod string, handler handlerFunc) route {
+	return route{
+		method:    method,
+		pattern:   splitPath(pattern),
+		apiMethod: constAPIMethod(apiMethod),
+		handler:   handler,
+	}
+}
+
+func newStreamRoute(method string, pattern string, apiMethod string, stream streamFunc) route {
+	return route{
+		method:    method,
+		pattern:   splitPath(pattern),
+		apiMethod: constAPIMethod(apiMethod),
+		stream:    stream,
+	}
+}
+
+// withAPIMethod returns the route for a handler which calls one of several Access API methods,
+// depending on the request
+func (r route) withAPIMethod(apiMethod apiMethodFunc) route {
+	r.apiMethod = apiMethod
+	return r
+}
+
+func constAPIMethod(name string) apiMethodFunc {
+	return func(*request) string {
+		return name
+	}
+}
+
+// match returns the path parameters if the path matches the route's pattern
+func (r route) match(path []string) (map[string]string, bool) {
+	if len(path) != len(r.pattern) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, segment := range r.pattern {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if path[i] == "" {
+				return nil, false
+			}
+			params[segment[1:len(segment)-1]] = path[i]
+			continue
+		}
+		if segment != path[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
@@ -0,0 +1,30 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/rs/zerolog"
+
+	"github.com/onflow/flow-go/access"
+	"github.com/onflow/flow-go/engine/access/rpc/ratelimit"
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// NewServer creates and initializes a new HTTP server serving the REST API.
+// Requests are rate limited by the limiter, if not nil.
+func NewServer(
+	api access.API,
+	chain flow.Chain,
+	address string,
+	limiter *ratelimit.Limiter,
+	log zerolog.Logger,
+) *http.Server {
+	handler := NewHandler(api, chain, limiter, log)
+	server := &http.Server{
+		Addr:    address,
+		Handler: handler,
+	}
+	// shutting down waits for the active requests, so end the streamed subscriptions
+	server.RegisterOnShutdown(handler.CloseStreams)
+	return server
+}
@@ -11,8 +11,11 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/onflow/flow-go/access"
+	"github.com/onflow/flow-go/engine/common/rpc/simulation"
+	"github.com/onflow/flow-go/fvm"
 	"github.com/onflow/flow-go/model/flow"
 	"github.com/onflow/flow-go/module"
+	"github.com/onflow/flow-go/module/mempool"
 	"github.com/onflow/flow-go/state/protocol"
 	"github.com/onflow/flow-go/storage"
 )
@@ -27,6 +30,11 @@ import (
 // Block details related calls are handled by backendBlockDetails.
 // Event related calls are handled by backendEvents.
 // Account related calls are handled by backendAccounts.
+// Execution result and seal related calls are handled by backendExecutionResults.
+// Transaction timeline related calls are handled by backendTransactionTimelines.
+// Epoch and protocol state related calls are handled by backendEpochs.
+// Transaction simulations are handled by backendSimulations.
+// Subscriptions are handled by backendSubscriptions.
 //
 // All remaining calls are handled by the base Backend in this file.
 type Backend struct {
@@ -36,84 +44,169 @@ type Backend struct {
 	backendBlockHeaders
 	backendBlockDetails
 	backendAccounts
+	backendExecutionResults
+	backendTransactionTimelines
+	backendEpochs
+	backendSimulations
+	backendSubscriptions
 
-	executionRPC execproto.ExecutionAPIClient
-	state        protocol.State
-	chainID      flow.ChainID
-	collections  storage.Collections
+	executionRPC   execproto.ExecutionAPIClient
+	executionNodes *executionNodes
+	state          protocol.State
+	chainID        flow.ChainID
+	collections    storage.Collections
 }
 
-func New(
-	state protocol.State,
-	executionRPC execproto.ExecutionAPIClient,
-	collectionRPC accessproto.AccessAPIClient,
-	historicalAccessNodes []accessproto.AccessAPIClient,
-	blocks storage.Blocks,
-	headers storage.Headers,
-	collections storage.Collections,
-	transactions storage.Transactions,
-	chainID flow.ChainID,
-	transactionMetrics module.TransactionMetrics,
-	collectionGRPCPort uint,
-	connFactory ConnectionFactory,
-	retryEnabled bool,
-) *Backend {
+// Params are the dependencies and the configuration of the backend. Storages, clients and metrics
+// which are not used by the served calls may be left empty.
+type Params struct {
+	State                     protocol.State
+	ExecutionRPC              execproto.ExecutionAPIClient  // the statically configured execution node
+	SimulationRPC             simulation.API                // transaction simulations, disabled if nil
+	CollectionRPC             accessproto.AccessAPIClient   // the statically configured collection node
+	HistoricalAccessNodes     []accessproto.AccessAPIClient // access nodes of previous sporks
+	Blocks                    storage.Blocks
+	Headers                   storage.Headers
+	Collections               storage.Collections
+	Transactions              storage.Transactions
+	Events                    storage.Events             // indexed events, not served locally if nil
+	TransactionResults        storage.TransactionResults // indexed transaction results, not served locally if nil
+	Seals                     storage.Seals
+	Results                   storage.ExecutionResults
+	Receipts                  storage.ExecutionReceipts // receipts of the execution nodes to cross-check responses with
+	ChainID                   flow.ChainID
+	RootSnapshot              *access.ProtocolStateSnapshot
+	TransactionMetrics        module.TransactionMetrics
+	TransactionTimings        mempool.TransactionTimings
+	ExecutionNodeQueryMetrics module.ExecutionNodeQueryMetrics
+	ResponseCache             ResponseCacheConfig
+	CacheMetrics              module.CacheMetrics
+	CollectionGRPCPort        uint // gRPC port of all collection nodes
+	ExecutionGRPCPort         uint // gRPC port of all execution nodes
+	ExecutionQueryCount       uint // number of execution nodes to cross-check responses with, 0 to only use ExecutionRPC
+	ConnFactory               ConnectionFactory
+	RetryEnabled              bool
+}
+
+// New creates the backend of the Access API with the given dependencies and configuration.
+func New(params Params) *Backend {
 	retry := newRetry()
-	if retryEnabled {
+	if params.RetryEnabled {
 		retry.Activate()
 	}
 
+	// events and transaction results of sealed blocks are served locally, if they are indexed
+	index := newResultsIndex(params.Blocks, params.Headers, params.Events, params.TransactionResults)
+
+	// requests for execution data are forwarded to the execution nodes which executed the block
+	executionNodes := newExecutionNodes(
+		params.State,
+		params.Receipts,
+		params.ExecutionRPC,
+		params.ConnFactory,
+		params.ExecutionGRPCPort,
+		params.ExecutionQueryCount,
+		params.ExecutionNodeQueryMetrics,
+	)
+
+	// responses for immutable data of sealed blocks are cached
+	cache := newResponseCache(params.ResponseCache, params.State, params.Headers, params.CacheMetrics)
+
 	b := &Backend{
-		executionRPC: executionRPC,
-		state:        state,
+		executionRPC:   params.ExecutionRPC,
+		executionNodes: executionNodes,
+		state:          params.State,
 		// create the sub-backends
 		backendScripts: backendScripts{
-			headers:      headers,
-			executionRPC: executionRPC,
-			state:        state,
+			headers:        params.Headers,
+			executionNodes: executionNodes,
+			state:          params.State,
+			cache:          cache,
 		},
 		backendTransactions: backendTransactions{
-			staticCollectionRPC:  collectionRPC,
-			executionRPC:         executionRPC,
-			state:                state,
-			chainID:              chainID,
-			collections:          collections,
-			blocks:               blocks,
-			transactions:         transactions,
-			transactionValidator: configureTransactionValidator(state, chainID),
-			transactionMetrics:   transactionMetrics,
+			staticCollectionRPC:  params.CollectionRPC,
+			executionNodes:       executionNodes,
+			state:                params.State,
+			chainID:              params.ChainID,
+			collections:          params.Collections,
+			blocks:               params.Blocks,
+			transactions:         params.Transactions,
+			transactionValidator: configureTransactionValidator(params.State, params.ChainID),
+			transactionMetrics:   params.TransactionMetrics,
 			retry:                retry,
-			collectionGRPCPort:   collectionGRPCPort,
-			connFactory:          connFactory,
-			previousAccessNodes:  historicalAccessNodes,
+			collectionGRPCPort:   params.CollectionGRPCPort,
+			connFactory:          params.ConnFactory,
+			previousAccessNodes:  params.HistoricalAccessNodes,
+			index:                index,
+			systemTxID:           fvm.SystemChunkTransaction(params.ChainID.Chain().ServiceAddress()).ID(),
 		},
 		backendEvents: backendEvents{
-			executionRPC: executionRPC,
-			state:        state,
-			blocks:       blocks,
+			executionNodes: executionNodes,
+			state:          params.State,
+			blocks:         params.Blocks,
+			index:          index,
+			cache:          cache,
 		},
 		backendBlockHeaders: backendBlockHeaders{
-			headers: headers,
-			state:   state,
+			headers: params.Headers,
+			state:   params.State,
 		},
 		backendBlockDetails: backendBlockDetails{
-			blocks: blocks,
-			state:  state,
+			blocks: params.Blocks,
+			state:  params.State,
 		},
 		backendAccounts: backendAccounts{
-			executionRPC: executionRPC,
-			state:        state,
-			headers:      headers,
+			executionNodes: executionNodes,
+			state:          params.State,
+			headers:        params.Headers,
+			cache:          cache,
+		},
+		backendExecutionResults: backendExecutionResults{
+			state:   params.State,
+			blocks:  params.Blocks,
+			seals:   params.Seals,
+			results: params.Results,
+		},
+		backendTransactionTimelines: backendTransactionTimelines{
+			state:        params.State,
+			transactions: params.Transactions,
+			timings:      params.TransactionTimings,
 		},
-		collections: collections,
-		chainID:     chainID,
+		backendEpochs: backendEpochs{
+			state:        params.State,
+			rootSnapshot: params.RootSnapshot,
+		},
+		backendSimulations: backendSimulations{
+			state:         params.State,
+			simulationRPC: params.SimulationRPC,
+		},
+		backendSubscriptions: backendSubscriptions{
+			state:    params.State,
+			blocks:   params.Blocks,
+			notifier: newBlockNotifier(),
+		},
+		collections: params.Collections,
+		chainID:     params.ChainID,
 	}
 
+	// subscriptions use the other sub-backends to retrieve their responses
+	b.backendSubscriptions.events = &b.backendEvents
+	b.backendSubscriptions.transactions = &b.backendTransactions
+
+	// transaction timelines are derived from the transactions and their seals
+	b.backendTransactionTimelines.transactionsBackend = &b.backendTransactions
+	b.backendTransactionTimelines.executionResults = &b.backendExecutionResults
+
 	retry.SetBackend(b)
 
 	return b
 }
 
+// Close closes the connections to the execution nodes, which were opened to cross-check their responses.
+func (b *Backend) Close() error {
+	return b.executionNodes.close()
+}
+
 func configureTransactionValidator(state protocol.State, chainID flow.ChainID) *access.TransactionValidator {
 	return access.NewTransactionValidator(
 		access.NewProtocolStateBlocks(state),
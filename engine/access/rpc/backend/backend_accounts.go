@@ -2,21 +2,25 @@ package backend
 
 import (
 	"context"
+	"encoding/binary"
 
+	"github.com/golang/protobuf/proto"
 	execproto "github.com/onflow/flow/protobuf/go/flow/execution"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	"github.com/onflow/flow-go/engine/common/rpc/convert"
 	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/module/metrics"
 	"github.com/onflow/flow-go/state/protocol"
 	"github.com/onflow/flow-go/storage"
 )
 
 type backendAccounts struct {
-	state        protocol.State
-	executionRPC execproto.ExecutionAPIClient
-	headers      storage.Headers
+	state          protocol.State
+	executionNodes *executionNodes
+	headers        storage.Headers
+	cache          *responseCache
 }
 
 func (b *backendAccounts) GetAccount(ctx context.Context, address flow.Address) (*flow.Account, error) {
@@ -47,6 +51,16 @@ func (b *backendAccounts) GetAccountAtBlockHeight(
 	address flow.Address,
 	height uint64,
 ) (*flow.Account, error) {
+
+	// the accounts at sealed heights never change
+	var encodedHeight [8]byte
+	binary.BigEndian.PutUint64(encodedHeight[:], height)
+	key := newResponseKey(metrics.ResourceAccount, address.Bytes(), encodedHeight[:])
+	cached, ok := b.cache.get(key)
+	if ok {
+		return cached.(*flow.Account), nil
+	}
+
 	// get header at given height
 	header, err := b.headers.ByHeight(height)
 	if err != nil {
@@ -62,6 +76,11 @@ func (b *backendAccounts) GetAccountAtBlockHeight(
 		return nil, err
 	}
 
+	err = b.cache.put(key, height, account)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to cache account: %v", err)
+	}
+
 	return account, nil
 }
 
@@ -76,7 +95,10 @@ func (b *backendAccounts) getAccountAtBlockID(
 		BlockId: blockID[:],
 	}
 
-	exeRes, err := b.executionRPC.GetAccountAtBlockID(ctx, &exeReq)
+	exeRes, err := b.executionNodes.query(ctx, "get_account", []flow.Identifier{blockID},
+		func(ctx context.Context, client execproto.ExecutionAPIClient) (proto.Message, error) {
+			return client.GetAccountAtBlockID(ctx, &exeReq)
+		})
 	if err != nil {
 		errStatus, _ := status.FromError(err)
 		if errStatus.Code() == codes.NotFound {
@@ -86,7 +108,7 @@ func (b *backendAccounts) getAccountAtBlockID(
 		return nil, status.Errorf(codes.Internal, "failed to get account from the execution node: %v", err)
 	}
 
-	account, err := convert.MessageToAccount(exeRes.GetAccount())
+	account, err := convert.MessageToAccount(exeRes.(*execproto.GetAccountAtBlockIDResponse).GetAccount())
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to convert account message: %v", err)
 	}
@@ -0,0 +1,212 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onflow/flow-go/access"
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/model/flow/filter"
+	"github.com/onflow/flow-go/state/protocol"
+)
+
+type backendEpochs struct {
+	state        protocol.State
+	rootSnapshot *access.ProtocolStateSnapshot // nil if the root snapshot is not available
+}
+
+// GetCurrentEpoch returns the epoch of the latest finalized block.
+func (b *backendEpochs) GetCurrentEpoch(_ context.Context) (*access.Epoch, error) {
+	// the current epoch is always committed
+	final := b.state.Final()
+	epoch, err := convertEpoch(final, final.Epochs().Current(), true)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get current epoch: %v", err)
+	}
+	return epoch, nil
+}
+
+// GetEpochByCounter returns the epoch with the given counter. The current and next epoch are read from
+// the latest finalized block, and past epochs of the spork from the last finalized block of the epoch.
+func (b *backendEpochs) GetEpochByCounter(_ context.Context, counter uint64) (*access.Epoch, error) {
+
+	final := b.state.Final()
+
+	current, err := final.Epochs().Current().Counter()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get current epoch: %v", err)
+	}
+
+	snapshot := final
+	var epoch protocol.Epoch
+	committed := true
+	switch {
+	case counter == current:
+		epoch = final.Epochs().Current()
+	case counter == current+1:
+		epoch = final.Epochs().Next()
+		// the next epoch is only committed once the epoch commit event was sealed
+		phase, err := final.Phase()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get epoch phase: %v", err)
+		}
+		committed = phase == flow.EpochPhaseCommitted
+	case counter < current:
+		snapshot, err = b.lastSnapshotOfEpoch(final, current, counter)
+		if err != nil {
+			return nil, err
+		}
+		epoch = snapshot.Epochs().Current()
+	default:
+		return nil, status.Errorf(codes.NotFound, "epoch %d is not available (current epoch: %d)", counter, current)
+	}
+
+	result, err := convertEpoch(snapshot, epoch, committed)
+	if errors.Is(err, protocol.ErrNextEpochNotSetup) {
+		return nil, status.Errorf(codes.NotFound, "epoch %d is not available: %v", counter, err)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get epoch %d: %v", counter, err)
+	}
+
+	return result, nil
+}
+
+// lastSnapshotOfEpoch returns the snapshot of the protocol state at the last finalized block of the past
+// epoch with the given counter. Starting at the latest finalized block in the current epoch, it steps back
+// one epoch at a time, to the last finalized block within the final view of the previous epoch.
+func (b *backendEpochs) lastSnapshotOfEpoch(final protocol.Snapshot, current uint64, counter uint64) (protocol.Snapshot, error) {
+
+	root, err := b.state.Params().Root()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get root block: %v", err)
+	}
+
+	snapshot := final
+	for epochCounter := current; epochCounter > counter; epochCounter-- {
+		head, err := snapshot.Head()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get block of epoch %d: %v", epochCounter, err)
+		}
+		finalView, err := snapshot.Epochs().Previous().FinalView()
+		if errors.Is(err, protocol.ErrNoPreviousEpoch) {
+			return nil, status.Errorf(codes.NotFound, "epoch %d is not available: %v", counter, err)
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get epoch %d: %v", epochCounter-1, err)
+		}
+
+		// finalized views increase with the height, so the last block of the previous epoch is found
+		// by a binary search of the heights since the root block
+		low, high := root.Height, head.Height
+		for low < high {
+			height := high - (high-low)/2
+			header, err := b.state.AtHeight(height).Head()
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to get block at height %d: %v", height, err)
+			}
+			if header.View <= finalView {
+				low = height
+			} else {
+				high = height - 1
+			}
+		}
+		snapshot = b.state.AtHeight(low)
+	}
+
+	// the epoch has no finalized block if its last block is the root block of a later epoch
+	epochCounter, err := snapshot.Epochs().Current().Counter()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get epoch %d: %v", counter, err)
+	}
+	if epochCounter != counter {
+		return nil, status.Errorf(codes.NotFound, "epoch %d is not available (first epoch: %d)", counter, epochCounter)
+	}
+
+	return snapshot, nil
+}
+
+// GetNodeIdentities returns the identity table of the network at the given block.
+func (b *backendEpochs) GetNodeIdentities(_ context.Context, blockID flow.Identifier) (flow.IdentityList, error) {
+	identities, err := b.state.AtBlockID(blockID).Identities(filter.Any)
+	if err != nil {
+		return nil, convertStorageError(err)
+	}
+	return identities, nil
+}
+
+// GetProtocolStateSnapshot returns the JSON encoded root snapshot of the protocol state.
+//
+// As the protocol state can only be bootstrapped from the root block of a spork, the root snapshot
+// this node was bootstrapped from is returned.
+func (b *backendEpochs) GetProtocolStateSnapshot(_ context.Context) ([]byte, error) {
+	if b.rootSnapshot == nil {
+		return nil, status.Errorf(codes.Unavailable, "protocol state snapshot is not available")
+	}
+
+	data, err := json.Marshal(b.rootSnapshot)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode protocol state snapshot: %v", err)
+	}
+
+	return data, nil
+}
+
+// convertEpoch converts the epoch of the protocol state, read at the given snapshot, into an epoch of the
+// access API. The DKG of the epoch is only available if it is committed.
+func convertEpoch(snapshot protocol.Snapshot, epoch protocol.Epoch, committed bool) (*access.Epoch, error) {
+
+	counter, err := epoch.Counter()
+	if err != nil {
+		return nil, fmt.Errorf("could not get counter: %w", err)
+	}
+	firstView, err := epoch.FirstView()
+	if err != nil {
+		return nil, fmt.Errorf("could not get first view: %w", err)
+	}
+	finalView, err := epoch.FinalView()
+	if err != nil {
+		return nil, fmt.Errorf("could not get final view: %w", err)
+	}
+	identities, err := epoch.InitialIdentities()
+	if err != nil {
+		return nil, fmt.Errorf("could not get initial identities: %w", err)
+	}
+	clustering, err := epoch.Clustering()
+	if err != nil {
+		return nil, fmt.Errorf("could not get clustering: %w", err)
+	}
+	phase, err := snapshot.Phase()
+	if err != nil {
+		return nil, fmt.Errorf("could not get epoch phase: %w", err)
+	}
+
+	result := &access.Epoch{
+		Counter:    counter,
+		FirstView:  firstView,
+		FinalView:  finalView,
+		Identities: identities,
+		Clusters:   make([][]flow.Identifier, 0, len(clustering)),
+		Phase:      phase,
+	}
+	for _, cluster := range clustering {
+		result.Clusters = append(result.Clusters, cluster.NodeIDs())
+	}
+
+	if !committed {
+		return result, nil
+	}
+
+	dkg, err := epoch.DKG()
+	if err != nil {
+		return nil, fmt.Errorf("could not get DKG: %w", err)
+	}
+	result.DKGGroupKey = dkg.GroupKey()
+
+	return result, nil
+}
@@ -6,20 +6,24 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/golang/protobuf/proto"
 	execproto "github.com/onflow/flow/protobuf/go/flow/execution"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	"github.com/onflow/flow-go/engine/common/rpc/convert"
 	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/module/metrics"
 	"github.com/onflow/flow-go/state/protocol"
 	"github.com/onflow/flow-go/storage"
 )
 
 type backendEvents struct {
-	executionRPC execproto.ExecutionAPIClient
-	blocks       storage.Blocks
-	state        protocol.State
+	executionNodes *executionNodes
+	blocks         storage.Blocks
+	state          protocol.State
+	index          *resultsIndex
+	cache          *responseCache
 }
 
 // GetEventsForHeightRange retrieves events for all sealed blocks between the start block height and
@@ -57,7 +61,7 @@ func (b *backendEvents) GetEventsForHeightRange(
 		blockHeaders = append(blockHeaders, block.Header)
 	}
 
-	return b.getBlockEventsFromExecutionNode(ctx, blockHeaders, eventType)
+	return b.getBlockEvents(ctx, blockHeaders, eventType)
 }
 
 // GetEventsForBlockIDs retrieves events for all the specified block IDs that have the given type
@@ -67,8 +71,16 @@ func (b *backendEvents) GetEventsForBlockIDs(
 	blockIDs []flow.Identifier,
 ) ([]flow.BlockEvents, error) {
 
+	// the events of sealed blocks never change
+	key := blockEventsKey(eventType, blockIDs)
+	cached, ok := b.cache.get(key)
+	if ok {
+		return cached.([]flow.BlockEvents), nil
+	}
+
 	// find the block headers for all the block IDs
 	blockHeaders := make([]*flow.Header, 0)
+	maxHeight := uint64(0)
 	for _, blockID := range blockIDs {
 		block, err := b.blocks.ByID(blockID)
 		if err != nil {
@@ -76,10 +88,141 @@ func (b *backendEvents) GetEventsForBlockIDs(
 		}
 
 		blockHeaders = append(blockHeaders, block.Header)
+		if block.Header.Height > maxHeight {
+			maxHeight = block.Header.Height
+		}
+	}
+
+	blockEvents, err := b.getBlockEvents(ctx, blockHeaders, eventType)
+	if err != nil {
+		return nil, err
+	}
+
+	// the events are only cached if all of the blocks are sealed
+	err = b.cache.put(key, maxHeight, blockEvents)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to cache events: %v", err)
+	}
+
+	return blockEvents, nil
+}
+
+// getBlockEventsOfTypes returns the events of any of the given types emitted in the block. The events of
+// an indexed block are read from the index with a single lookup. The execution API only filters events by
+// a single type, so the events of other blocks are requested from the execution node for each type, and
+// cached like the responses of GetEventsForBlockIDs.
+func (b *backendEvents) getBlockEventsOfTypes(
+	ctx context.Context,
+	header *flow.Header,
+	eventTypes []flow.EventType,
+) ([]flow.Event, error) {
+
+	blockID := header.ID()
+
+	indexed, _, err := b.index.partition([]*flow.Header{header})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get events: %v", err)
+	}
+
+	if len(indexed) > 0 {
+		blockEvents, err := b.index.events.ByBlockID(blockID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get events: %v", err)
+		}
+		events := make([]flow.Event, 0, len(blockEvents))
+		for _, event := range blockEvents {
+			for _, eventType := range eventTypes {
+				if event.Type == eventType {
+					events = append(events, event)
+					break
+				}
+			}
+		}
+		return events, nil
+	}
+
+	var events []flow.Event
+	for _, eventType := range eventTypes {
+		key := blockEventsKey(string(eventType), []flow.Identifier{blockID})
+		cached, ok := b.cache.get(key)
+		if ok {
+			events = append(events, cached.([]flow.BlockEvents)[0].Events...)
+			continue
+		}
+
+		results, err := b.getBlockEventsFromExecutionNode(ctx, []*flow.Header{header}, string(eventType))
+		if err != nil {
+			return nil, err
+		}
+		err = b.cache.put(key, header.Height, results)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to cache events: %v", err)
+		}
+		events = append(events, results[0].Events...)
 	}
 
-	// forward the request to the execution node
-	return b.getBlockEventsFromExecutionNode(ctx, blockHeaders, eventType)
+	return events, nil
+}
+
+// blockEventsKey returns the key of the cached events of the given type emitted in the given blocks.
+func blockEventsKey(eventType string, blockIDs []flow.Identifier) responseKey {
+	parts := [][]byte{[]byte(eventType)}
+	for _, blockID := range blockIDs {
+		parts = append(parts, blockID[:])
+	}
+	return newResponseKey(metrics.ResourceBlockEvents, parts...)
+}
+
+// getBlockEvents retrieves the events of the given blocks from the local index, and forwards
+// the request to the execution node for all blocks that are not indexed (yet).
+func (b *backendEvents) getBlockEvents(
+	ctx context.Context,
+	blockHeaders []*flow.Header,
+	eventType string,
+) ([]flow.BlockEvents, error) {
+
+	indexed, remaining, err := b.index.partition(blockHeaders)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get events: %v", err)
+	}
+
+	if len(indexed) == 0 {
+		return b.getBlockEventsFromExecutionNode(ctx, blockHeaders, eventType)
+	}
+
+	results := make(map[flow.Identifier]flow.BlockEvents, len(blockHeaders))
+	for _, header := range indexed {
+		blockID := header.ID()
+		events, err := b.index.events.ByBlockIDEventType(blockID, flow.EventType(eventType))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get events: %v", err)
+		}
+		results[blockID] = flow.BlockEvents{
+			BlockID:        blockID,
+			BlockHeight:    header.Height,
+			BlockTimestamp: header.Timestamp,
+			Events:         events,
+		}
+	}
+
+	if len(remaining) > 0 {
+		// forward the request to the execution node
+		remoteResults, err := b.getBlockEventsFromExecutionNode(ctx, remaining, eventType)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range remoteResults {
+			results[result.BlockID] = result
+		}
+	}
+
+	// return the results in the order of the requested blocks
+	blockEvents := make([]flow.BlockEvents, 0, len(blockHeaders))
+	for _, header := range blockHeaders {
+		blockEvents = append(blockEvents, results[header.ID()])
+	}
+
+	return blockEvents, nil
 }
 
 func (b *backendEvents) getBlockEventsFromExecutionNode(
@@ -99,14 +242,16 @@ func (b *backendEvents) getBlockEventsFromExecutionNode(
 	}
 
 	// call the execution node gRPC
-	resp, err := b.executionRPC.GetEventsForBlockIDs(ctx, &req)
-
+	resp, err := b.executionNodes.query(ctx, "get_events", blockIDs,
+		func(ctx context.Context, client execproto.ExecutionAPIClient) (proto.Message, error) {
+			return client.GetEventsForBlockIDs(ctx, &req)
+		})
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to retrieve events from execution node: %v", err)
 	}
 
 	// convert execution node api result to access node api result
-	results, err := verifyAndConvertToAccessEvents(resp.GetResults(), blockHeaders)
+	results, err := verifyAndConvertToAccessEvents(resp.(*execproto.GetEventsForBlockIDsResponse).GetResults(), blockHeaders)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to verify retrieved events from execution node: %v", err)
 	}
@@ -0,0 +1,100 @@
+package backend
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/state/protocol"
+	"github.com/onflow/flow-go/storage"
+)
+
+type backendExecutionResults struct {
+	state   protocol.State
+	blocks  storage.Blocks
+	seals   storage.Seals
+	results storage.ExecutionResults
+}
+
+// GetSealForBlockID returns the seal of the given block, which was included in a finalized block
+func (b *backendExecutionResults) GetSealForBlockID(_ context.Context, blockID flow.Identifier) (*flow.Seal, error) {
+	return b.lookupSeal(blockID)
+}
+
+// GetExecutionResultForBlockID returns the sealed execution result of the given block
+func (b *backendExecutionResults) GetExecutionResultForBlockID(_ context.Context, blockID flow.Identifier) (*flow.ExecutionResult, error) {
+	seal, err := b.lookupSeal(blockID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := b.results.ByID(seal.ResultID)
+	if err != nil {
+		return nil, convertStorageError(err)
+	}
+
+	return result, nil
+}
+
+// lookupSeal finds the seal of the given finalized block in the payloads of the finalized blocks following it
+func (b *backendExecutionResults) lookupSeal(blockID flow.Identifier) (*flow.Seal, error) {
+	seal, _, err := b.lookupSealingBlock(blockID)
+	return seal, err
+}
+
+// lookupSealingBlock finds the seal of the given finalized block, and the finalized block whose payload
+// includes it. The seal of the root block is not included in a payload, so the root block itself is returned.
+func (b *backendExecutionResults) lookupSealingBlock(blockID flow.Identifier) (*flow.Seal, *flow.Header, error) {
+
+	header, err := b.state.AtBlockID(blockID).Head()
+	if err != nil {
+		return nil, nil, convertStorageError(err)
+	}
+
+	sealed, err := b.state.Sealed().Head()
+	if err != nil {
+		return nil, nil, convertStorageError(err)
+	}
+	if header.Height > sealed.Height {
+		return nil, nil, status.Errorf(codes.NotFound, "block %v is not sealed", blockID)
+	}
+
+	finalized, err := b.state.AtHeight(header.Height).Head()
+	if err != nil {
+		return nil, nil, convertStorageError(err)
+	}
+	if finalized.ID() != blockID {
+		return nil, nil, status.Errorf(codes.NotFound, "block %v is not finalized", blockID)
+	}
+
+	// the seal of the root block is not included in a payload, but it is the last seal of the root block
+	last, err := b.seals.ByBlockID(blockID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, convertStorageError(err)
+	}
+	if err == nil && last.BlockID == blockID {
+		return last, header, nil
+	}
+
+	// a sealed block is sealed by a seal in one of the finalized blocks up to the latest finalized block
+	final, err := b.state.Final().Head()
+	if err != nil {
+		return nil, nil, convertStorageError(err)
+	}
+	for height := header.Height + 1; height <= final.Height; height++ {
+		block, err := b.blocks.ByHeight(height)
+		if err != nil {
+			return nil, nil, convertStorageError(err)
+		}
+		for _, seal := range block.Payload.Seals {
+			if seal.BlockID == blockID {
+				return seal, block.Header, nil
+			}
+		}
+	}
+
+	return nil, nil, status.Errorf(codes.NotFound, "no seal found for block %v", blockID)
+}
@@ -3,19 +3,22 @@ package backend
 import (
 	"context"
 
+	"github.com/golang/protobuf/proto"
 	execproto "github.com/onflow/flow/protobuf/go/flow/execution"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/module/metrics"
 	"github.com/onflow/flow-go/state/protocol"
 	"github.com/onflow/flow-go/storage"
 )
 
 type backendScripts struct {
-	headers      storage.Headers
-	state        protocol.State
-	executionRPC execproto.ExecutionAPIClient
+	headers        storage.Headers
+	state          protocol.State
+	executionNodes *executionNodes
+	cache          *responseCache
 }
 
 func (b *backendScripts) ExecuteScriptAtLatestBlock(
@@ -43,8 +46,26 @@ func (b *backendScripts) ExecuteScriptAtBlockID(
 	script []byte,
 	arguments [][]byte,
 ) ([]byte, error) {
+
+	// the results of scripts executed at sealed blocks never change
+	key := newResponseKey(metrics.ResourceScriptResult, append([][]byte{blockID[:], script}, arguments...)...)
+	cached, ok := b.cache.get(key)
+	if ok {
+		return cached.([]byte), nil
+	}
+
 	// execute script on the execution node at that block id
-	return b.executeScriptOnExecutionNode(ctx, blockID, script, arguments)
+	result, err := b.executeScriptOnExecutionNode(ctx, blockID, script, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.cache.putAtBlockID(key, blockID, result)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to cache script result: %v", err)
+	}
+
+	return result, nil
 }
 
 func (b *backendScripts) ExecuteScriptAtBlockHeight(
@@ -81,10 +102,13 @@ func (b *backendScripts) executeScriptOnExecutionNode(
 		Arguments: arguments,
 	}
 
-	execResp, err := b.executionRPC.ExecuteScriptAtBlockID(ctx, &execReq)
+	execResp, err := b.executionNodes.query(ctx, "execute_script", []flow.Identifier{blockID},
+		func(ctx context.Context, client execproto.ExecutionAPIClient) (proto.Message, error) {
+			return client.ExecuteScriptAtBlockID(ctx, &execReq)
+		})
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to execute the script on the execution node: %v", err)
 	}
 
-	return execResp.GetValue(), nil
+	return execResp.(*execproto.ExecuteScriptAtBlockIDResponse).GetValue(), nil
 }
@@ -0,0 +1,61 @@
+package backend
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onflow/flow-go/engine/common/rpc/simulation"
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/state/protocol"
+)
+
+type backendSimulations struct {
+	state         protocol.State
+	simulationRPC simulation.API // the simulation service of the statically configured execution node
+}
+
+// SimulateTransaction forwards the transaction to the execution node, which executes it against the
+// execution state of the block without committing its changes.
+func (b *backendSimulations) SimulateTransaction(
+	ctx context.Context,
+	tx *flow.TransactionBody,
+	blockID flow.Identifier,
+	skipSignatureCheck bool,
+) (*flow.TransactionSimulation, error) {
+
+	if b.simulationRPC == nil {
+		return nil, status.Errorf(codes.Unavailable, "transaction simulation is not available")
+	}
+
+	// simulate against the latest sealed block, which is known to be executed, if no block is given
+	if blockID == flow.ZeroID {
+		header, err := b.state.Sealed().Head()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get latest sealed block: %v", err)
+		}
+		blockID = header.ID()
+	} else {
+		_, err := b.state.AtBlockID(blockID).Head()
+		if err != nil {
+			return nil, convertStorageError(err)
+		}
+	}
+
+	req := &simulation.Request{
+		BlockID:            blockID,
+		Transaction:        tx,
+		SkipSignatureCheck: skipSignatureCheck,
+	}
+
+	result, err := b.simulationRPC.SimulateTransaction(ctx, req)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, err
+		}
+		return nil, status.Errorf(codes.Internal, "failed to simulate transaction on execution node: %v", err)
+	}
+
+	return result, nil
+}
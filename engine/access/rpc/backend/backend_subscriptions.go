@@ -0,0 +1,273 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onflow/flow-go/access"
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/state/protocol"
+	"github.com/onflow/flow-go/storage"
+)
+
+// subscriptionBufferSize is the number of responses buffered by a subscription,
+// before it waits for the subscriber to receive them
+const subscriptionBufferSize = 10
+
+var (
+	// errNotReady is returned by the next function of a subscription,
+	// if the next response is not available until another block is finalized
+	errNotReady = errors.New("next response not ready")
+
+	// errSubscriptionDone is returned by the next function of a subscription,
+	// if all responses were sent
+	errSubscriptionDone = errors.New("subscription done")
+)
+
+type backendSubscriptions struct {
+	state        protocol.State
+	blocks       storage.Blocks
+	events       *backendEvents
+	transactions *backendTransactions
+	notifier     *blockNotifier
+}
+
+// NotifyFinalizedBlock notifies the subscriptions of a newly finalized block
+func (b *backendSubscriptions) NotifyFinalizedBlock() {
+	b.notifier.Notify()
+}
+
+// SubscribeBlocks streams the finalized (or sealed) blocks, starting at the given height,
+// or at the latest block if the height is 0.
+func (b *backendSubscriptions) SubscribeBlocks(ctx context.Context, startHeight uint64, sealed bool) (access.Subscription, error) {
+	height, err := b.startHeight(startHeight, sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	next := func(ctx context.Context) (interface{}, error) {
+		head, err := b.latestHeader(sealed)
+		if err != nil {
+			return nil, err
+		}
+		if height > head.Height {
+			return nil, errNotReady
+		}
+
+		block, err := b.blocks.ByHeight(height)
+		if err != nil {
+			return nil, convertStorageError(err)
+		}
+		height++
+		return block, nil
+	}
+
+	return b.subscribe(ctx, next), nil
+}
+
+// SubscribeEvents streams the events matching the filter, one flow.BlockEvents per sealed block
+// (including blocks without matching events), starting at the given height, or at the latest
+// sealed block if the height is 0.
+func (b *backendSubscriptions) SubscribeEvents(ctx context.Context, startHeight uint64, filter access.EventFilter) (access.Subscription, error) {
+	if len(filter.EventTypes) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one event type is required")
+	}
+	height, err := b.startHeight(startHeight, true)
+	if err != nil {
+		return nil, err
+	}
+
+	next := func(ctx context.Context) (interface{}, error) {
+		head, err := b.latestHeader(true)
+		if err != nil {
+			return nil, err
+		}
+		if height > head.Height {
+			return nil, errNotReady
+		}
+
+		block, err := b.blocks.ByHeight(height)
+		if err != nil {
+			return nil, convertStorageError(err)
+		}
+
+		events, err := b.events.getBlockEventsOfTypes(ctx, block.Header, filter.EventTypes)
+		if err != nil {
+			return nil, err
+		}
+
+		blockEvents := &flow.BlockEvents{
+			BlockID:        block.ID(),
+			BlockHeight:    block.Header.Height,
+			BlockTimestamp: block.Header.Timestamp,
+			Events:         []flow.Event{},
+		}
+		for _, event := range events {
+			if filter.Match(event) {
+				blockEvents.Events = append(blockEvents.Events, event)
+			}
+		}
+		sort.Slice(blockEvents.Events, func(i, j int) bool {
+			a, b := blockEvents.Events[i], blockEvents.Events[j]
+			if a.TransactionIndex != b.TransactionIndex {
+				return a.TransactionIndex < b.TransactionIndex
+			}
+			return a.EventIndex < b.EventIndex
+		})
+
+		height++
+		return blockEvents, nil
+	}
+
+	return b.subscribe(ctx, next), nil
+}
+
+// SubscribeTransactionStatus streams the result of the transaction whenever its status changes,
+// until the transaction is sealed or expired. While the transaction is not known, no results are sent.
+func (b *backendSubscriptions) SubscribeTransactionStatus(ctx context.Context, txID flow.Identifier) (access.Subscription, error) {
+	lastStatus := flow.TransactionStatusUnknown
+
+	next := func(ctx context.Context) (interface{}, error) {
+		if lastStatus == flow.TransactionStatusSealed || lastStatus == flow.TransactionStatusExpired {
+			return nil, errSubscriptionDone
+		}
+
+		result, err := b.transactions.GetTransactionResult(ctx, txID)
+		if status.Code(err) == codes.NotFound {
+			return nil, errNotReady
+		}
+		if err != nil {
+			return nil, err
+		}
+		if result.Status == lastStatus {
+			return nil, errNotReady
+		}
+
+		lastStatus = result.Status
+		return result, nil
+	}
+
+	return b.subscribe(ctx, next), nil
+}
+
+// startHeight returns the height of the first block of a subscription, which must not be below the root block
+func (b *backendSubscriptions) startHeight(startHeight uint64, sealed bool) (uint64, error) {
+	if startHeight == 0 {
+		head, err := b.latestHeader(sealed)
+		if err != nil {
+			return 0, err
+		}
+		return head.Height, nil
+	}
+
+	root, err := b.state.Params().Root()
+	if err != nil {
+		return 0, status.Errorf(codes.Internal, "could not get root block: %v", err)
+	}
+	if startHeight < root.Height {
+		return 0, status.Errorf(codes.InvalidArgument, "start height %d is below the root height %d", startHeight, root.Height)
+	}
+	return startHeight, nil
+}
+
+func (b *backendSubscriptions) latestHeader(sealed bool) (*flow.Header, error) {
+	snapshot := b.state.Final()
+	if sealed {
+		snapshot = b.state.Sealed()
+	}
+	head, err := snapshot.Head()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not get latest block: %v", err)
+	}
+	return head, nil
+}
+
+// subscribe starts a subscription streaming the responses returned by next. While next returns
+// errNotReady, the subscription waits for the next block to be finalized, before calling it again.
+func (b *backendSubscriptions) subscribe(ctx context.Context, next func(context.Context) (interface{}, error)) access.Subscription {
+	sub := &subscription{
+		ch: make(chan interface{}, subscriptionBufferSize),
+	}
+
+	go func() {
+		defer close(sub.ch)
+		for {
+			// get the notification channel before checking for the next response,
+			// so that no block is missed in between
+			notified := b.notifier.Channel()
+
+			response, err := next(ctx)
+			if errors.Is(err, errNotReady) {
+				select {
+				case <-ctx.Done():
+					sub.err = ctx.Err()
+					return
+				case <-notified:
+					continue
+				}
+			}
+			if errors.Is(err, errSubscriptionDone) {
+				return
+			}
+			if err != nil {
+				sub.err = err
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				sub.err = ctx.Err()
+				return
+			case sub.ch <- response:
+			}
+		}
+	}()
+
+	return sub
+}
+
+// subscription implements access.Subscription
+type subscription struct {
+	ch  chan interface{}
+	err error // set before the channel is closed
+}
+
+func (s *subscription) Channel() <-chan interface{} {
+	return s.ch
+}
+
+func (s *subscription) Err() error {
+	return s.err
+}
+
+// blockNotifier notifies subscriptions of newly finalized blocks
+type blockNotifier struct {
+	mu     sync.Mutex
+	notify chan struct{}
+}
+
+func newBlockNotifier() *blockNotifier {
+	return &blockNotifier{
+		notify: make(chan struct{}),
+	}
+}
+
+// Channel returns a channel, which is closed when the next block is finalized
+func (n *blockNotifier) Channel() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.notify
+}
+
+// Notify notifies all subscriptions waiting for a block to be finalized
+func (n *blockNotifier) Notify() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	close(n.notify)
+	n.notify = make(chan struct{})
+}
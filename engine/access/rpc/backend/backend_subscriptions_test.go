@@ -0,0 +1,232 @@
+package backend
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	execproto "github.com/onflow/flow/protobuf/go/flow/execution"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/onflow/flow-go/access"
+	"github.com/onflow/flow-go/engine/common/rpc/convert"
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/module/metrics"
+	protocol "github.com/onflow/flow-go/state/protocol/mock"
+	"github.com/onflow/flow-go/utils/unittest"
+)
+
+// subscriptionTimeout is the maximum time to wait for a response of a subscription
+const subscriptionTimeout = 5 * time.Second
+
+// setupChain mocks a chain of blocks starting at the given root height, of which the blocks
+// up to the returned latest height are finalized and sealed
+func (suite *Suite) setupChain(rootHeight uint64, count int) ([]*flow.Block, *uint64) {
+	blocks := make([]*flow.Block, count)
+	for i := range blocks {
+		block := unittest.BlockFixture()
+		block.Header.Height = rootHeight + uint64(i)
+		blocks[i] = &block
+		suite.blocks.On("ByHeight", block.Header.Height).Return(&block, nil).Maybe()
+	}
+
+	latest := rootHeight
+	suite.snapshot.On("Head").Return(func() *flow.Header {
+		return blocks[atomic.LoadUint64(&latest)-rootHeight].Header
+	}, nil)
+
+	params := new(protocol.Params)
+	params.On("Root").Return(blocks[0].Header, nil)
+	suite.state.On("Params").Return(params).Maybe()
+
+	return blocks, &latest
+}
+
+func (suite *Suite) newSubscriptionBackend() *Backend {
+	return New(Params{
+		State:                     suite.state,
+		ExecutionRPC:              suite.execClient,
+		Blocks:                    suite.blocks,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
+}
+
+// receive returns the next response of the subscription, or nil if the subscription ended
+func (suite *Suite) receive(sub access.Subscription) interface{} {
+	select {
+	case response := <-sub.Channel():
+		return response
+	case <-time.After(subscriptionTimeout):
+		suite.FailNow("timed out waiting for subscription")
+		return nil
+	}
+}
+
+func (suite *Suite) TestSubscribeBlocks() {
+	blocks, latest := suite.setupChain(10, 5)
+	atomic.StoreUint64(latest, 12)
+	backend := suite.newSubscriptionBackend()
+
+	suite.Run("resume from height", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sub, err := backend.SubscribeBlocks(ctx, 11, false)
+		suite.Require().NoError(err)
+		suite.Require().Equal(blocks[1], suite.receive(sub))
+		suite.Require().Equal(blocks[2], suite.receive(sub))
+
+		// the next block is streamed once it is finalized
+		select {
+		case <-sub.Channel():
+			suite.FailNow("received block before it was finalized")
+		case <-time.After(10 * time.Millisecond):
+		}
+		atomic.StoreUint64(latest, 13)
+		backend.NotifyFinalizedBlock()
+		suite.Require().Equal(blocks[3], suite.receive(sub))
+
+		cancel()
+		_, ok := <-sub.Channel()
+		suite.Require().False(ok)
+		suite.Require().Equal(context.Canceled, sub.Err())
+	})
+
+	suite.Run("start at latest block", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sub, err := backend.SubscribeBlocks(ctx, 0, true)
+		suite.Require().NoError(err)
+		suite.Require().Equal(blocks[3], suite.receive(sub))
+	})
+
+	suite.Run("start below root height", func() {
+		_, err := backend.SubscribeBlocks(context.Background(), 9, false)
+		suite.Require().Error(err)
+	})
+}
+
+func (suite *Suite) TestSubscribeEvents() {
+	blocks, latest := suite.setupChain(10, 2)
+	backend := suite.newSubscriptionBackend()
+
+	created := flow.Event{Type: flow.EventAccountCreated, TransactionIndex: 1}
+	updated := flow.Event{Type: flow.EventAccountUpdated, TransactionIndex: 0}
+	for i, block := range blocks {
+		for _, event := range []flow.Event{created, updated} {
+			events := []flow.Event{event}
+			if i > 0 {
+				events = []flow.Event{}
+			}
+			suite.execClient.
+				On("GetEventsForBlockIDs", mock.Anything, &execproto.GetEventsForBlockIDsRequest{
+					Type:     string(event.Type),
+					BlockIds: convert.IdentifiersToMessages([]flow.Identifier{block.ID()}),
+				}).
+				Return(&execproto.GetEventsForBlockIDsResponse{
+					Results: []*execproto.GetEventsForBlockIDsResponse_Result{{
+						BlockId:     convert.IdentifierToMessage(block.ID()),
+						BlockHeight: block.Header.Height,
+						Events:      convert.EventsToMessages(events),
+					}},
+				}, nil).
+				Once()
+		}
+	}
+
+	suite.Run("missing event types", func() {
+		_, err := backend.SubscribeEvents(context.Background(), 0, access.EventFilter{})
+		suite.Require().Error(err)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	filter := access.EventFilter{EventTypes: []flow.EventType{flow.EventAccountCreated, flow.EventAccountUpdated}}
+	sub, err := backend.SubscribeEvents(ctx, 0, filter)
+	suite.Require().NoError(err)
+
+	// events of all types are streamed in the order of the transactions
+	blockEvents := suite.receive(sub).(*flow.BlockEvents)
+	suite.Require().Equal(blocks[0].ID(), blockEvents.BlockID)
+	suite.Require().Len(blockEvents.Events, 2)
+	suite.Require().Equal(flow.EventAccountUpdated, blockEvents.Events[0].Type)
+	suite.Require().Equal(flow.EventAccountCreated, blockEvents.Events[1].Type)
+
+	// blocks without events are streamed as well
+	atomic.StoreUint64(latest, 11)
+	backend.NotifyFinalizedBlock()
+	blockEvents = suite.receive(sub).(*flow.BlockEvents)
+	suite.Require().Equal(blocks[1].ID(), blockEvents.BlockID)
+	suite.Require().Equal(blocks[1].Header.Height, blockEvents.BlockHeight)
+	suite.Require().Empty(blockEvents.Events)
+
+	suite.execClient.AssertExpectations(suite.T())
+}
+
+// TestSubscribeEventsFromIndex tests that the events of all types of an indexed block are read
+// from the index with a single lookup, without requesting them from the execution node.
+func (suite *Suite) TestSubscribeEventsFromIndex() {
+	blocks, _ := suite.setupChain(10, 1)
+	block := blocks[0]
+
+	suite.blocks.On("GetLastIndexedBlockHeight").Return(block.Header.Height, nil)
+	suite.headers.On("ByHeight", block.Header.Height).Return(block.Header, nil)
+
+	created := flow.Event{Type: flow.EventAccountCreated, TransactionIndex: 1}
+	updated := flow.Event{Type: flow.EventAccountUpdated, TransactionIndex: 0}
+	other := flow.Event{Type: "flow.Other", TransactionIndex: 0}
+	suite.events.
+		On("ByBlockID", block.ID()).
+		Return([]flow.Event{created, other, updated}, nil).
+		Once()
+
+	backend := New(Params{
+		State:                     suite.state,
+		ExecutionRPC:              suite.execClient,
+		Blocks:                    suite.blocks,
+		Headers:                   suite.headers,
+		Events:                    suite.events,
+		TransactionResults:        suite.transactionResults,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	filter := access.EventFilter{EventTypes: []flow.EventType{flow.EventAccountCreated, flow.EventAccountUpdated}}
+	sub, err := backend.SubscribeEvents(ctx, 0, filter)
+	suite.Require().NoError(err)
+
+	blockEvents := suite.receive(sub).(*flow.BlockEvents)
+	suite.Require().Equal(block.ID(), blockEvents.BlockID)
+	suite.Require().Equal([]flow.Event{updated, created}, blockEvents.Events)
+
+	suite.events.AssertExpectations(suite.T())
+	suite.execClient.AssertNotCalled(suite.T(), "GetEventsForBlockIDs", mock.Anything, mock.Anything)
+}
+
+func (suite *Suite) TestSubscriptionEndsOnError() {
+	suite.setupChain(10, 1)
+	backend := suite.newSubscriptionBackend()
+
+	// the execution node fails to return the events
+	suite.execClient.
+		On("GetEventsForBlockIDs", mock.Anything, mock.Anything).
+		Return(nil, context.DeadlineExceeded).
+		Once()
+
+	filter := access.EventFilter{EventTypes: []flow.EventType{flow.EventAccountCreated}}
+	sub, err := backend.SubscribeEvents(context.Background(), 0, filter)
+	suite.Require().NoError(err)
+
+	suite.Require().Nil(suite.receive(sub))
+	suite.Require().Error(sub.Err())
+}
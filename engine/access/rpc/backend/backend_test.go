@@ -2,6 +2,7 @@ package backend
 
 import (
 	"context"
+	"encoding/json"
 	"math/rand"
 	"testing"
 	"time"
@@ -15,10 +16,18 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	accessapi "github.com/onflow/flow-go/access"
+	"github.com/onflow/flow-go/crypto"
 	access "github.com/onflow/flow-go/engine/access/mock"
 	"github.com/onflow/flow-go/engine/common/rpc/convert"
+	rpcresults "github.com/onflow/flow-go/engine/common/rpc/results"
+	resultsmock "github.com/onflow/flow-go/engine/common/rpc/results/mock"
+	"github.com/onflow/flow-go/engine/common/rpc/simulation"
+	simulationmock "github.com/onflow/flow-go/engine/common/rpc/simulation/mock"
 	"github.com/onflow/flow-go/model/flow"
+	mempool "github.com/onflow/flow-go/module/mempool/mock"
 	"github.com/onflow/flow-go/module/metrics"
+	realprotocol "github.com/onflow/flow-go/state/protocol"
 	protocol "github.com/onflow/flow-go/state/protocol/mock"
 	"github.com/onflow/flow-go/storage"
 	storagemock "github.com/onflow/flow-go/storage/mock"
@@ -36,6 +45,10 @@ type Suite struct {
 	headers                *storagemock.Headers
 	collections            *storagemock.Collections
 	transactions           *storagemock.Transactions
+	events                 *storagemock.Events
+	transactionResults     *storagemock.TransactionResults
+	seals                  *storagemock.Seals
+	results                *storagemock.ExecutionResults
 	colClient              *access.AccessAPIClient
 	execClient             *access.ExecutionAPIClient
 	historicalAccessClient *access.AccessAPIClient
@@ -57,6 +70,10 @@ func (suite *Suite) SetupTest() {
 	suite.headers = new(storagemock.Headers)
 	suite.transactions = new(storagemock.Transactions)
 	suite.collections = new(storagemock.Collections)
+	suite.events = new(storagemock.Events)
+	suite.transactionResults = new(storagemock.TransactionResults)
+	suite.seals = new(storagemock.Seals)
+	suite.results = new(storagemock.ExecutionResults)
 	suite.colClient = new(access.AccessAPIClient)
 	suite.execClient = new(access.ExecutionAPIClient)
 	suite.chainID = flow.Testnet
@@ -72,17 +89,15 @@ func (suite *Suite) TestPing() {
 		On("Ping", mock.Anything, &execproto.PingRequest{}).
 		Return(&execproto.PingResponse{}, nil)
 
-	backend := New(
-		suite.state,
-		suite.execClient,
-		suite.colClient,
-		nil, nil, nil, nil, nil,
-		suite.chainID,
-		metrics.NewNoopCollector(),
-		0,
-		nil,
-		false,
-	)
+	backend := New(Params{
+		State:                     suite.state,
+		ExecutionRPC:              suite.execClient,
+		CollectionRPC:             suite.colClient,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
 
 	err := backend.Ping(context.Background())
 
@@ -95,16 +110,14 @@ func (suite *Suite) TestGetLatestFinalizedBlockHeader() {
 
 	suite.snapshot.On("Head").Return(&block, nil).Once()
 
-	backend := New(
-		suite.state,
-		suite.execClient,
-		nil, nil, nil, nil, nil, nil,
-		suite.chainID,
-		metrics.NewNoopCollector(),
-		0,
-		nil,
-		false,
-	)
+	backend := New(Params{
+		State:                     suite.state,
+		ExecutionRPC:              suite.execClient,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
 
 	// query the handler for the latest finalized block
 	header, err := backend.GetLatestBlockHeader(context.Background(), false)
@@ -123,16 +136,14 @@ func (suite *Suite) TestGetLatestSealedBlockHeader() {
 	block := unittest.BlockHeaderFixture()
 	suite.snapshot.On("Head").Return(&block, nil).Once()
 
-	backend := New(
-		suite.state,
-		nil, nil, nil, nil,
-		suite.headers, nil, nil,
-		suite.chainID,
-		metrics.NewNoopCollector(),
-		0,
-		nil,
-		false,
-	)
+	backend := New(Params{
+		State:                     suite.state,
+		Headers:                   suite.headers,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
 
 	// query the handler for the latest sealed block
 	header, err := backend.GetLatestBlockHeader(context.Background(), true)
@@ -155,16 +166,14 @@ func (suite *Suite) TestGetTransaction() {
 		Return(&expected, nil).
 		Once()
 
-	backend := New(
-		suite.state,
-		nil, nil, nil, nil, nil, nil,
-		suite.transactions,
-		suite.chainID,
-		metrics.NewNoopCollector(),
-		0,
-		nil,
-		false,
-	)
+	backend := New(Params{
+		State:                     suite.state,
+		Transactions:              suite.transactions,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
 
 	actual, err := backend.GetTransaction(context.Background(), transaction.ID())
 	suite.checkResponse(actual, err)
@@ -182,17 +191,15 @@ func (suite *Suite) TestGetCollection() {
 		Return(&expected, nil).
 		Once()
 
-	backend := New(
-		suite.state,
-		nil, nil, nil, nil, nil,
-		suite.collections,
-		suite.transactions,
-		suite.chainID,
-		metrics.NewNoopCollector(),
-		0,
-		nil,
-		false,
-	)
+	backend := New(Params{
+		State:                     suite.state,
+		Collections:               suite.collections,
+		Transactions:              suite.transactions,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
 
 	actual, err := backend.GetCollectionByID(context.Background(), expected.ID())
 	suite.transactions.AssertExpectations(suite.T())
@@ -247,25 +254,22 @@ func (suite *Suite) TestTransactionStatusTransition() {
 		Events: nil,
 	}
 
-	backend := New(
-		suite.state,
-		suite.execClient,
-		nil,
-		nil,
-		suite.blocks,
-		suite.headers,
-		suite.collections,
-		suite.transactions,
-		suite.chainID,
-		metrics.NewNoopCollector(),
-		0,
-		nil,
-		false,
-	)
+	backend := New(Params{
+		State:                     suite.state,
+		ExecutionRPC:              suite.execClient,
+		Blocks:                    suite.blocks,
+		Headers:                   suite.headers,
+		Collections:               suite.collections,
+		Transactions:              suite.transactions,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
 
 	// Successfully return empty event list
 	suite.execClient.
-		On("GetTransactionResult", ctx, &exeEventReq).
+		On("GetTransactionResult", mock.Anything, &exeEventReq).
 		Return(&exeEventResp, status.Errorf(codes.NotFound, "not found")).
 		Once()
 
@@ -278,7 +282,7 @@ func (suite *Suite) TestTransactionStatusTransition() {
 
 	// Successfully return empty event list from here on
 	suite.execClient.
-		On("GetTransactionResult", ctx, &exeEventReq).
+		On("GetTransactionResult", mock.Anything, &exeEventReq).
 		Return(&exeEventResp, nil)
 
 	// second call - when block under test's height is greater height than the sealed head
@@ -349,21 +353,18 @@ func (suite *Suite) TestTransactionExpiredStatusTransition() {
 
 	txID := transactionBody.ID()
 
-	backend := New(
-		suite.state,
-		suite.execClient,
-		nil,
-		nil,
-		suite.blocks,
-		suite.headers,
-		suite.collections,
-		suite.transactions,
-		suite.chainID,
-		metrics.NewNoopCollector(),
-		0,
-		nil,
-		false,
-	)
+	backend := New(Params{
+		State:                     suite.state,
+		ExecutionRPC:              suite.execClient,
+		Blocks:                    suite.blocks,
+		Headers:                   suite.headers,
+		Collections:               suite.collections,
+		Transactions:              suite.transactions,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
 
 	// first call - referenced block isn't known yet, so should return pending status
 	result, err := backend.GetTransactionResult(ctx, txID)
@@ -398,17 +399,14 @@ func (suite *Suite) TestGetLatestFinalizedBlock() {
 		Return(&expected, nil).
 		Once()
 
-	backend := New(
-		suite.state,
-		nil, nil, nil,
-		suite.blocks,
-		nil, nil, nil,
-		suite.chainID,
-		metrics.NewNoopCollector(),
-		0,
-		nil,
-		false,
-	)
+	backend := New(Params{
+		State:                     suite.state,
+		Blocks:                    suite.blocks,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
 
 	// query the handler for the latest finalized header
 	actual, err := backend.GetLatestBlock(context.Background(), false)
@@ -476,23 +474,20 @@ func (suite *Suite) TestGetEventsForBlockIDs() {
 
 	// expect one call to the executor api client
 	suite.execClient.
-		On("GetEventsForBlockIDs", ctx, exeReq).
+		On("GetEventsForBlockIDs", mock.Anything, exeReq).
 		Return(&exeResp, nil).
 		Once()
 
 	// create the handler
-	backend := New(
-		suite.state,
-		suite.execClient,
-		nil, nil,
-		suite.blocks,
-		nil, nil, nil,
-		suite.chainID,
-		metrics.NewNoopCollector(),
-		0,
-		nil,
-		false,
-	)
+	backend := New(Params{
+		State:                     suite.state,
+		ExecutionRPC:              suite.execClient,
+		Blocks:                    suite.blocks,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
 
 	// execute request
 	actual, err := backend.GetEventsForBlockIDs(ctx, string(flow.EventAccountCreated), blockIDs)
@@ -567,7 +562,7 @@ func (suite *Suite) TestGetEventsForHeightRange() {
 		}
 
 		suite.execClient.
-			On("GetEventsForBlockIDs", ctx, execReq).
+			On("GetEventsForBlockIDs", mock.Anything, execReq).
 			Return(exeResp, nil).
 			Once()
 
@@ -575,15 +570,13 @@ func (suite *Suite) TestGetEventsForHeightRange() {
 	}
 
 	suite.Run("invalid request max height < min height", func() {
-		backend := New(
-			suite.state,
-			nil, nil, nil, nil, nil, nil, nil,
-			suite.chainID,
-			metrics.NewNoopCollector(),
-			0,
-			nil,
-			false,
-		)
+		backend := New(Params{
+			State:                     suite.state,
+			ChainID:                   suite.chainID,
+			TransactionMetrics:        metrics.NewNoopCollector(),
+			ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+			CacheMetrics:              metrics.NewNoopCollector(),
+		})
 
 		_, err := backend.GetEventsForHeightRange(ctx, string(flow.EventAccountCreated), maxHeight, minHeight)
 		suite.Require().Error(err)
@@ -601,19 +594,16 @@ func (suite *Suite) TestGetEventsForHeightRange() {
 		expectedResp := setupExecClient()
 
 		// create handler
-		backend := New(
-			suite.state,
-			suite.execClient,
-			nil, nil,
-			suite.blocks,
-			suite.headers,
-			nil, nil,
-			suite.chainID,
-			metrics.NewNoopCollector(),
-			0,
-			nil,
-			false,
-		)
+		backend := New(Params{
+			State:                     suite.state,
+			ExecutionRPC:              suite.execClient,
+			Blocks:                    suite.blocks,
+			Headers:                   suite.headers,
+			ChainID:                   suite.chainID,
+			TransactionMetrics:        metrics.NewNoopCollector(),
+			ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+			CacheMetrics:              metrics.NewNoopCollector(),
+		})
 
 		// execute request
 		actualResp, err := backend.GetEventsForHeightRange(ctx, string(flow.EventAccountCreated), minHeight, maxHeight)
@@ -630,19 +620,16 @@ func (suite *Suite) TestGetEventsForHeightRange() {
 		blockHeaders = setupStorage(minHeight, headHeight)
 		expectedResp := setupExecClient()
 
-		backend := New(
-			suite.state,
-			suite.execClient,
-			nil, nil,
-			suite.blocks,
-			suite.headers,
-			nil, nil,
-			suite.chainID,
-			metrics.NewNoopCollector(),
-			0,
-			nil,
-			false,
-		)
+		backend := New(Params{
+			State:                     suite.state,
+			ExecutionRPC:              suite.execClient,
+			Blocks:                    suite.blocks,
+			Headers:                   suite.headers,
+			ChainID:                   suite.chainID,
+			TransactionMetrics:        metrics.NewNoopCollector(),
+			ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+			CacheMetrics:              metrics.NewNoopCollector(),
+		})
 
 		actualResp, err := backend.GetEventsForHeightRange(ctx, string(flow.EventAccountCreated), minHeight, maxHeight)
 		suite.checkResponse(actualResp, err)
@@ -653,6 +640,757 @@ func (suite *Suite) TestGetEventsForHeightRange() {
 
 }
 
+// TestGetEventsForBlockIDsFromIndex tests that the events of indexed blocks are served from the local index,
+// and only the events of the remaining blocks are requested from the execution node.
+func (suite *Suite) TestGetEventsForBlockIDsFromIndex() {
+	events := getEvents(10)
+	ctx := context.Background()
+
+	// the blocks at height 1 and 2 are indexed, the blocks at height 3 and 4 are not
+	blocks := make([]*flow.Block, 4)
+	for i := range blocks {
+		block := unittest.BlockFixture()
+		block.Header.Height = uint64(i + 1)
+		blocks[i] = &block
+		suite.blocks.
+			On("ByID", block.ID()).
+			Return(&block, nil).Once()
+	}
+	suite.blocks.
+		On("GetLastIndexedBlockHeight").
+		Return(uint64(2), nil)
+
+	// a block of a different fork at an indexed height is not indexed
+	fork := unittest.BlockFixture()
+	fork.Header.Height = 2
+	suite.blocks.
+		On("ByID", fork.ID()).
+		Return(&fork, nil).Once()
+
+	for _, block := range blocks[:2] {
+		suite.headers.
+			On("ByHeight", block.Header.Height).
+			Return(block.Header, nil)
+		suite.events.
+			On("ByBlockIDEventType", block.ID(), flow.EventAccountCreated).
+			Return(events, nil).Once()
+	}
+
+	remaining := []*flow.Header{blocks[2].Header, fork.Header, blocks[3].Header}
+	exeResults := make([]*execproto.GetEventsForBlockIDsResponse_Result, len(remaining))
+	remainingIDs := make([]flow.Identifier, len(remaining))
+	for i, header := range remaining {
+		remainingIDs[i] = header.ID()
+		exeResults[i] = &execproto.GetEventsForBlockIDsResponse_Result{
+			BlockId:     convert.IdentifierToMessage(header.ID()),
+			BlockHeight: header.Height,
+			Events:      convert.EventsToMessages(events),
+		}
+	}
+	exeReq := &execproto.GetEventsForBlockIDsRequest{
+		BlockIds: convert.IdentifiersToMessages(remainingIDs),
+		Type:     string(flow.EventAccountCreated),
+	}
+	suite.execClient.
+		On("GetEventsForBlockIDs", mock.Anything, exeReq).
+		Return(&execproto.GetEventsForBlockIDsResponse{Results: exeResults}, nil).
+		Once()
+
+	backend := New(Params{
+		State:                     suite.state,
+		ExecutionRPC:              suite.execClient,
+		Blocks:                    suite.blocks,
+		Headers:                   suite.headers,
+		Events:                    suite.events,
+		TransactionResults:        suite.transactionResults,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
+
+	// the results are returned in the order of the requested blocks
+	requested := []*flow.Header{blocks[2].Header, blocks[0].Header, fork.Header, blocks[1].Header, blocks[3].Header}
+	blockIDs := make([]flow.Identifier, len(requested))
+	expected := make([]flow.BlockEvents, len(requested))
+	for i, header := range requested {
+		blockIDs[i] = header.ID()
+		expected[i] = flow.BlockEvents{
+			BlockID:        header.ID(),
+			BlockHeight:    header.Height,
+			BlockTimestamp: header.Timestamp,
+			Events:         events,
+		}
+	}
+
+	actual, err := backend.GetEventsForBlockIDs(ctx, string(flow.EventAccountCreated), blockIDs)
+	suite.checkResponse(actual, err)
+
+	suite.Require().Equal(expected, actual)
+	suite.assertAllExpectations()
+}
+
+// TestGetTransactionResultFromIndex tests that the result of a transaction in an indexed block
+// is served from the local index, without requesting it from the execution node.
+func (suite *Suite) TestGetTransactionResultFromIndex() {
+	ctx := context.Background()
+	collection := unittest.CollectionFixture(1)
+	transactionBody := collection.Transactions[0]
+	light := collection.Light()
+	block := unittest.BlockFixture()
+	block.Header.Height = 2
+	headBlock := unittest.BlockFixture()
+	headBlock.Header.Height = block.Header.Height + 1
+
+	txID := transactionBody.ID()
+	blockID := block.ID()
+	events := getEvents(2)
+
+	suite.snapshot.
+		On("Head").
+		Return(headBlock.Header, nil)
+	suite.transactions.
+		On("ByID", txID).
+		Return(transactionBody, nil)
+	suite.collections.
+		On("LightByTransactionID", txID).
+		Return(&light, nil)
+	suite.blocks.
+		On("ByCollectionID", collection.ID()).
+		Return(&block, nil)
+	suite.blocks.
+		On("GetLastIndexedBlockHeight").
+		Return(block.Header.Height, nil)
+	suite.headers.
+		On("ByHeight", block.Header.Height).
+		Return(block.Header, nil)
+	suite.transactionResults.
+		On("ByBlockIDTransactionID", blockID, txID).
+		Return(&flow.TransactionResult{TransactionID: txID, ErrorMessage: "failed"}, nil)
+	suite.events.
+		On("ByBlockIDTransactionID", blockID, txID).
+		Return(events, nil)
+
+	backend := New(Params{
+		State:                     suite.state,
+		ExecutionRPC:              suite.execClient,
+		Blocks:                    suite.blocks,
+		Headers:                   suite.headers,
+		Collections:               suite.collections,
+		Transactions:              suite.transactions,
+		Events:                    suite.events,
+		TransactionResults:        suite.transactionResults,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
+
+	result, err := backend.GetTransactionResult(ctx, txID)
+	suite.checkResponse(result, err)
+
+	suite.Assert().Equal(flow.TransactionStatusSealed, result.Status)
+	suite.Assert().Equal(uint(1), result.StatusCode)
+	suite.Assert().Equal("failed", result.ErrorMessage)
+	suite.Assert().Equal(events, result.Events)
+
+	suite.execClient.AssertNotCalled(suite.T(), "GetTransactionResult", mock.Anything, mock.Anything)
+	suite.assertAllExpectations()
+}
+
+// TestGetTransactionResultsByBlockID tests that the results of all transactions of a block are returned
+// in the order of their execution, followed by the scheduled transactions and ending with the system transaction.
+func (suite *Suite) TestGetTransactionResultsByBlockID() {
+	ctx := context.Background()
+	collection := unittest.CollectionFixture(2)
+	light := collection.Light()
+	guarantee := unittest.CollectionGuaranteeFixture()
+	guarantee.CollectionID = light.ID()
+	block := unittest.BlockFixture()
+	block.SetPayload(flow.Payload{Guarantees: []*flow.CollectionGuarantee{guarantee}})
+	block.Header.Height = 2
+	headBlock := unittest.BlockFixture()
+	headBlock.Header.Height = block.Header.Height + 1
+	blockID := block.ID()
+
+	suite.snapshot.
+		On("Head").
+		Return(headBlock.Header, nil)
+	suite.blocks.
+		On("ByID", blockID).
+		Return(&block, nil)
+	suite.collections.
+		On("LightByID", light.ID()).
+		Return(&light, nil)
+
+	finalSnapshot := new(protocol.Snapshot)
+	finalSnapshot.On("Head").Return(block.Header, nil)
+	suite.state.
+		On("AtHeight", block.Header.Height).
+		Return(finalSnapshot)
+
+	backend := New(Params{
+		State:                     suite.state,
+		ExecutionRPC:              suite.execClient,
+		Blocks:                    suite.blocks,
+		Collections:               suite.collections,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
+
+	// the scheduled transactions are found by the events emitted when they are executed
+	scheduledTxIDs := []flow.Identifier{unittest.IdentifierFixture(), unittest.IdentifierFixture()}
+	scheduledEvents := make([]flow.Event, len(scheduledTxIDs))
+	for i, txID := range scheduledTxIDs {
+		scheduledEvents[i] = unittest.EventFixture(flow.EventScheduledTransactionExecuted, uint32(len(light.Transactions)+i), 0, txID)
+	}
+	suite.execClient.
+		On("GetEventsForBlockIDs", mock.Anything, &execproto.GetEventsForBlockIDsRequest{
+			Type:     string(flow.EventScheduledTransactionExecuted),
+			BlockIds: convert.IdentifiersToMessages([]flow.Identifier{blockID}),
+		}).
+		Return(&execproto.GetEventsForBlockIDsResponse{
+			Results: []*execproto.GetEventsForBlockIDsResponse_Result{{
+				BlockId:     blockID[:],
+				BlockHeight: block.Header.Height,
+				// events are not necessarily returned in the order of execution
+				Events: convert.EventsToMessages([]flow.Event{scheduledEvents[1], scheduledEvents[0]}),
+			}},
+		}, nil)
+
+	txIDs := append(append([]flow.Identifier{}, light.Transactions...), scheduledTxIDs...)
+	txIDs = append(txIDs, backend.systemTxID)
+	events := getEvents(1)
+	for i, txID := range txIDs {
+		txID := txID
+		resp := &execproto.GetTransactionResultResponse{
+			Events: convert.EventsToMessages(events),
+		}
+		if i == 0 {
+			resp.StatusCode = 1
+			resp.ErrorMessage = "failed"
+		}
+		suite.execClient.
+			On("GetTransactionResult", mock.Anything, &execproto.GetTransactionResultRequest{
+				BlockId:       blockID[:],
+				TransactionId: txID[:],
+			}).
+			Return(resp, nil)
+	}
+
+	results, err := backend.GetTransactionResultsByBlockID(ctx, blockID)
+	suite.checkResponse(results, err)
+
+	suite.Require().Len(results, len(txIDs))
+	for i, result := range results {
+		suite.Assert().Equal(flow.TransactionStatusSealed, result.Status)
+		suite.Assert().Equal(events, result.Events)
+		if i == 0 {
+			suite.Assert().Equal(uint(1), result.StatusCode)
+			suite.Assert().Equal("failed", result.ErrorMessage)
+		} else {
+			suite.Assert().Equal(uint(0), result.StatusCode)
+		}
+	}
+
+	// the result of a single transaction is returned by its index
+	result, err := backend.GetTransactionResultByIndex(ctx, blockID, 0)
+	suite.checkResponse(result, err)
+	suite.Assert().Equal(results[0], result)
+
+	result, err = backend.GetTransactionResultByIndex(ctx, blockID, uint32(len(txIDs)-1))
+	suite.checkResponse(result, err)
+	suite.Assert().Equal(results[len(txIDs)-1], result)
+
+	_, err = backend.GetTransactionResultByIndex(ctx, blockID, uint32(len(txIDs)))
+	suite.Require().Error(err)
+	suite.Assert().Equal(codes.NotFound, status.Code(err))
+
+	suite.assertAllExpectations()
+}
+
+// TestGetTransactionResultsByBlockIDInBatch tests that the results of all transactions of a block which is
+// not indexed are requested from the execution node in one batch.
+func (suite *Suite) TestGetTransactionResultsByBlockIDInBatch() {
+	ctx := context.Background()
+	collection := unittest.CollectionFixture(2)
+	light := collection.Light()
+	guarantee := unittest.CollectionGuaranteeFixture()
+	guarantee.CollectionID = light.ID()
+	block := unittest.BlockFixture()
+	block.SetPayload(flow.Payload{Guarantees: []*flow.CollectionGuarantee{guarantee}})
+	block.Header.Height = 2
+	headBlock := unittest.BlockFixture()
+	headBlock.Header.Height = block.Header.Height - 1
+	blockID := block.ID()
+
+	suite.snapshot.
+		On("Head").
+		Return(headBlock.Header, nil)
+	suite.blocks.
+		On("ByID", blockID).
+		Return(&block, nil)
+	suite.collections.
+		On("LightByID", light.ID()).
+		Return(&light, nil)
+
+	finalSnapshot := new(protocol.Snapshot)
+	finalSnapshot.On("Head").Return(block.Header, nil)
+	suite.state.
+		On("AtHeight", block.Header.Height).
+		Return(finalSnapshot)
+
+	resultsClient := new(resultsmock.API)
+	execClient := &batchExecutionClient{
+		ExecutionAPIClient: suite.execClient,
+		API:                resultsClient,
+	}
+
+	backend := New(Params{
+		State:                     suite.state,
+		ExecutionRPC:              execClient,
+		Blocks:                    suite.blocks,
+		Collections:               suite.collections,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
+
+	// no scheduled transactions were executed in the block
+	suite.execClient.
+		On("GetEventsForBlockIDs", mock.Anything, mock.Anything).
+		Return(&execproto.GetEventsForBlockIDsResponse{
+			Results: []*execproto.GetEventsForBlockIDsResponse_Result{{
+				BlockId:     blockID[:],
+				BlockHeight: block.Header.Height,
+			}},
+		}, nil)
+
+	txIDs := append(append([]flow.Identifier{}, light.Transactions...), backend.systemTxID)
+	events := getEvents(1)
+	execResults := make([]rpcresults.Result, 0, len(txIDs))
+	for _, txID := range txIDs {
+		execResults = append(execResults, rpcresults.Result{
+			TransactionID: txID,
+			Events:        events,
+		})
+	}
+	execResults[0].StatusCode = 1
+	execResults[0].ErrorMessage = "failed"
+	resultsClient.
+		On("GetTransactionResults", mock.Anything, &rpcresults.Request{BlockID: blockID, TransactionIDs: txIDs}).
+		Return(execResults, nil).
+		Once()
+
+	results, err := backend.GetTransactionResultsByBlockID(ctx, blockID)
+	suite.checkResponse(results, err)
+
+	suite.Require().Len(results, len(txIDs))
+	for i, result := range results {
+		suite.Assert().Equal(flow.TransactionStatusExecuted, result.Status)
+		suite.Assert().Equal(events, result.Events)
+		suite.Assert().Equal(uint(execResults[i].StatusCode), result.StatusCode)
+		suite.Assert().Equal(execResults[i].ErrorMessage, result.ErrorMessage)
+	}
+
+	resultsClient.AssertExpectations(suite.T())
+	suite.execClient.AssertNotCalled(suite.T(), "GetTransactionResult", mock.Anything, mock.Anything)
+	suite.assertAllExpectations()
+}
+
+// batchExecutionClient is an execution node client which requests transaction results in batches
+type batchExecutionClient struct {
+	*access.ExecutionAPIClient
+	*resultsmock.API
+}
+
+// TestGetSealForBlockID tests that the seal and the sealed execution result of a block are found
+// in the payloads of the finalized blocks following the block.
+func (suite *Suite) TestGetSealForBlockID() {
+	ctx := context.Background()
+
+	block := unittest.BlockFixture()
+	block.Header.Height = 2
+	blockID := block.ID()
+
+	result := unittest.ExecutionResultFixture()
+	seal := unittest.Seal.Fixture(unittest.Seal.WithResult(result), unittest.Seal.WithBlockID(blockID))
+
+	// the seal is included in the second block following the block
+	child := unittest.BlockWithParentFixture(block.Header)
+	grandchild := unittest.BlockWithParentFixture(child.Header)
+	grandchild.SetPayload(flow.Payload{Seals: []*flow.Seal{seal}})
+	suite.blocks.
+		On("ByHeight", child.Header.Height).
+		Return(&child, nil)
+	suite.blocks.
+		On("ByHeight", grandchild.Header.Height).
+		Return(&grandchild, nil)
+
+	blockSnapshot := new(protocol.Snapshot)
+	blockSnapshot.On("Head").Return(block.Header, nil)
+	suite.state.On("AtBlockID", blockID).Return(blockSnapshot)
+	suite.state.On("AtHeight", block.Header.Height).Return(blockSnapshot)
+	suite.snapshot.
+		On("Head").
+		Return(grandchild.Header, nil)
+
+	// the last seal of the block is the seal of an ancestor
+	suite.seals.
+		On("ByBlockID", blockID).
+		Return(unittest.Seal.Fixture(), nil)
+	suite.results.
+		On("ByID", result.ID()).
+		Return(result, nil)
+
+	backend := New(Params{
+		State:                     suite.state,
+		Blocks:                    suite.blocks,
+		Seals:                     suite.seals,
+		Results:                   suite.results,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
+
+	actualSeal, err := backend.GetSealForBlockID(ctx, blockID)
+	suite.checkResponse(actualSeal, err)
+	suite.Assert().Equal(seal, actualSeal)
+
+	actualResult, err := backend.GetExecutionResultForBlockID(ctx, blockID)
+	suite.checkResponse(actualResult, err)
+	suite.Assert().Equal(result, actualResult)
+
+	// a block which is not sealed yet has no seal
+	unsealed := unittest.BlockWithParentFixture(grandchild.Header)
+	unsealedSnapshot := new(protocol.Snapshot)
+	unsealedSnapshot.On("Head").Return(unsealed.Header, nil)
+	suite.state.On("AtBlockID", unsealed.ID()).Return(unsealedSnapshot)
+
+	_, err = backend.GetSealForBlockID(ctx, unsealed.ID())
+	suite.Require().Error(err)
+	suite.Assert().Equal(codes.NotFound, status.Code(err))
+
+	suite.assertAllExpectations()
+}
+
+// TestGetTransactionTimeline tests that the timeline of a sealed transaction contains all its
+// status transitions, using the tracked timings of the transaction where available.
+func (suite *Suite) TestGetTransactionTimeline() {
+	ctx := context.Background()
+
+	referenceBlock := unittest.BlockHeaderFixture()
+	referenceBlock.Height = 1
+	tx := unittest.TransactionBodyFixture()
+	tx.ReferenceBlockID = referenceBlock.ID()
+	txID := tx.ID()
+
+	// the transaction is included in the block, which is sealed by the second block following it
+	block := unittest.BlockWithParentFixture(&referenceBlock)
+	blockID := block.ID()
+	child := unittest.BlockWithParentFixture(block.Header)
+	grandchild := unittest.BlockWithParentFixture(child.Header)
+	grandchild.SetPayload(flow.Payload{Seals: []*flow.Seal{unittest.Seal.Fixture(unittest.Seal.WithBlockID(blockID))}})
+
+	light := flow.LightCollection{Transactions: []flow.Identifier{txID}}
+	suite.transactions.On("ByID", txID).Return(&tx, nil)
+	suite.collections.On("LightByTransactionID", txID).Return(&light, nil)
+	suite.blocks.On("ByCollectionID", light.ID()).Return(&block, nil)
+	suite.blocks.On("ByHeight", child.Header.Height).Return(&child, nil)
+	suite.blocks.On("ByHeight", grandchild.Header.Height).Return(&grandchild, nil)
+	suite.seals.On("ByBlockID", blockID).Return(nil, storage.ErrNotFound)
+
+	// the collection cluster is assigned by the epoch of the reference block
+	collectors := unittest.IdentityListFixture(4, unittest.WithRole(flow.RoleCollection))
+	clusters, err := flow.NewClusterList(unittest.ClusterAssignment(2, collectors), collectors)
+	suite.Require().NoError(err)
+	cluster, ok := clusters.ByTxID(txID)
+	suite.Require().True(ok)
+	clusterIndex, ok := clusters.IndexOf(cluster)
+	suite.Require().True(ok)
+
+	epoch := new(protocol.Epoch)
+	epoch.On("Clustering").Return(clusters, nil)
+	epochs := new(protocol.EpochQuery)
+	epochs.On("Current").Return(epoch)
+	referenceSnapshot := new(protocol.Snapshot)
+	referenceSnapshot.On("Head").Return(&referenceBlock, nil)
+	referenceSnapshot.On("Epochs").Return(epochs)
+	suite.state.On("AtBlockID", referenceBlock.ID()).Return(referenceSnapshot)
+
+	blockSnapshot := new(protocol.Snapshot)
+	blockSnapshot.On("Head").Return(block.Header, nil)
+	suite.state.On("AtBlockID", blockID).Return(blockSnapshot)
+	suite.state.On("AtHeight", block.Header.Height).Return(blockSnapshot)
+	suite.snapshot.On("Head").Return(grandchild.Header, nil)
+
+	// the transaction was received by the access node, which observed its finalization and execution
+	timing := &flow.TransactionTiming{
+		TransactionID: txID,
+		Received:      time.Now().Add(-3 * time.Second),
+		Finalized:     time.Now().Add(-2 * time.Second),
+		Executed:      time.Now().Add(-time.Second),
+	}
+	timings := new(mempool.TransactionTimings)
+	timings.On("ByID", txID).Return(timing, true)
+
+	backend := New(Params{
+		State:                     suite.state,
+		Blocks:                    suite.blocks,
+		Collections:               suite.collections,
+		Transactions:              suite.transactions,
+		Seals:                     suite.seals,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		TransactionTimings:        timings,
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
+
+	timeline, err := backend.GetTransactionTimeline(ctx, txID)
+	suite.checkResponse(timeline, err)
+
+	suite.Assert().Equal(txID, timeline.TransactionID)
+	suite.Assert().Equal(clusterIndex, timeline.ClusterIndex)
+	suite.Assert().Equal(cluster.NodeIDs(), timeline.Cluster)
+	suite.Assert().Equal([]accessapi.TransactionStatusTransition{
+		{Status: flow.TransactionStatusPending, Time: timing.Received, BlockHeight: referenceBlock.Height},
+		{Status: flow.TransactionStatusFinalized, Time: timing.Finalized, BlockHeight: block.Header.Height},
+		{Status: flow.TransactionStatusExecuted, Time: timing.Executed, BlockHeight: block.Header.Height},
+		{Status: flow.TransactionStatusSealed, Time: grandchild.Header.Timestamp, BlockHeight: grandchild.Header.Height},
+	}, timeline.Transitions)
+
+	suite.assertAllExpectations()
+}
+
+// epochFixture returns a mocked epoch with the given counter, and the access API epoch it is converted into
+func (suite *Suite) epochFixture(counter uint64, committed bool) (*protocol.Epoch, *accessapi.Epoch) {
+	identities := unittest.IdentityListFixture(4, unittest.WithRole(flow.RoleCollection))
+	clusters, err := flow.NewClusterList(unittest.ClusterAssignment(2, identities), identities)
+	suite.Require().NoError(err)
+
+	expected := &accessapi.Epoch{
+		Counter:    counter,
+		FirstView:  counter * 1000,
+		FinalView:  counter*1000 + 999,
+		Identities: identities,
+		Clusters:   [][]flow.Identifier{clusters[0].NodeIDs(), clusters[1].NodeIDs()},
+	}
+
+	epoch := new(protocol.Epoch)
+	epoch.On("Counter").Return(expected.Counter, nil)
+	epoch.On("FirstView").Return(expected.FirstView, nil)
+	epoch.On("FinalView").Return(expected.FinalView, nil)
+	epoch.On("InitialIdentities").Return(identities, nil)
+	epoch.On("Clustering").Return(clusters, nil)
+	if committed {
+		expected.DKGGroupKey = unittest.KeyFixture(crypto.ECDSAP256).PublicKey()
+		dkg := new(protocol.DKG)
+		dkg.On("GroupKey").Return(expected.DKGGroupKey)
+		epoch.On("DKG").Return(dkg, nil)
+	}
+
+	return epoch, expected
+}
+
+func (suite *Suite) TestGetEpochs() {
+	ctx := context.Background()
+
+	previous, expectedPrevious := suite.epochFixture(1, true)
+	current, expectedCurrent := suite.epochFixture(2, true)
+	next, expectedNext := suite.epochFixture(3, false)
+
+	// the next epoch is set up, but not committed yet
+	expectedCurrent.Phase = flow.EpochPhaseSetup
+	expectedNext.Phase = flow.EpochPhaseSetup
+	// the previous epoch is read at its last finalized block, once it was committed
+	expectedPrevious.Phase = flow.EpochPhaseCommitted
+
+	epochs := new(protocol.EpochQuery)
+	epochs.On("Previous").Return(previous)
+	epochs.On("Current").Return(current)
+	epochs.On("Next").Return(next)
+	suite.snapshot.On("Epochs").Return(epochs)
+	suite.snapshot.On("Phase").Return(flow.EpochPhaseSetup, nil)
+
+	// the finalized blocks since the root block, with views increasing by 150 from the first view of
+	// the previous epoch, so that the block at height 16 is the last block of the previous epoch
+	root := unittest.BlockHeaderFixture()
+	root.Height = 10
+	params := new(protocol.Params)
+	params.On("Root").Return(&root, nil)
+	suite.state.On("Params").Return(params)
+
+	// the previous epoch is the first epoch of the spork
+	first := new(protocol.Epoch)
+	first.On("FinalView").Return(uint64(0), realprotocol.ErrNoPreviousEpoch)
+	previousEpochs := new(protocol.EpochQuery)
+	previousEpochs.On("Current").Return(previous)
+	previousEpochs.On("Previous").Return(first)
+
+	for height := uint64(10); height <= 20; height++ {
+		header := unittest.BlockHeaderFixture()
+		header.Height = height
+		header.View = expectedPrevious.FirstView + (height-10)*150
+		snapshot := new(protocol.Snapshot)
+		snapshot.On("Head").Return(&header, nil)
+		if height == 16 {
+			snapshot.On("Epochs").Return(previousEpochs)
+			snapshot.On("Phase").Return(flow.EpochPhaseCommitted, nil)
+		}
+		suite.state.On("AtHeight", height).Return(snapshot)
+		if height == 20 {
+			suite.snapshot.On("Head").Return(&header, nil)
+		}
+	}
+
+	backend := New(Params{
+		State:                     suite.state,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
+
+	suite.Run("current epoch", func() {
+		epoch, err := backend.GetCurrentEpoch(ctx)
+		suite.checkResponse(epoch, err)
+		suite.Assert().Equal(expectedCurrent, epoch)
+	})
+
+	suite.Run("epochs by counter", func() {
+		for _, expected := range []*accessapi.Epoch{expectedPrevious, expectedCurrent, expectedNext} {
+			epoch, err := backend.GetEpochByCounter(ctx, expected.Counter)
+			suite.checkResponse(epoch, err)
+			suite.Assert().Equal(expected, epoch)
+		}
+	})
+
+	suite.Run("unknown epoch", func() {
+		_, err := backend.GetEpochByCounter(ctx, 4)
+		suite.Require().Error(err)
+		suite.Assert().Equal(codes.NotFound, status.Code(err))
+	})
+
+	suite.Run("epoch before the spork", func() {
+		_, err := backend.GetEpochByCounter(ctx, 0)
+		suite.Require().Error(err)
+		suite.Assert().Equal(codes.NotFound, status.Code(err))
+	})
+
+	// the DKG of the next epoch is not requested before the epoch is committed
+	next.AssertNotCalled(suite.T(), "DKG")
+}
+
+func (suite *Suite) TestGetNodeIdentities() {
+	ctx := context.Background()
+
+	block := unittest.BlockHeaderFixture()
+	identities := unittest.IdentityListFixture(5)
+	snapshot := new(protocol.Snapshot)
+	snapshot.On("Identities", mock.Anything).Return(identities, nil)
+	suite.state.On("AtBlockID", block.ID()).Return(snapshot)
+
+	backend := New(Params{
+		State:                     suite.state,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
+
+	actual, err := backend.GetNodeIdentities(ctx, block.ID())
+	suite.checkResponse(actual, err)
+	suite.Assert().Equal(identities, actual)
+
+	suite.assertAllExpectations()
+}
+
+func (suite *Suite) TestGetProtocolStateSnapshot() {
+	ctx := context.Background()
+
+	participants := unittest.IdentityListFixture(5, unittest.WithAllRoles())
+	block := unittest.GenesisFixture(participants)
+	result := unittest.BootstrapExecutionResultFixture(block, unittest.GenesisStateCommitment)
+	setup := unittest.EpochSetupFixture(unittest.WithParticipants(participants))
+	seal := unittest.Seal.Fixture(unittest.Seal.WithResult(result), unittest.Seal.WithServiceEvents(setup.ServiceEvent()))
+	qc := unittest.QuorumCertificateFixture()
+	qc.BlockID = block.ID()
+	root := &accessapi.ProtocolStateSnapshot{
+		Block:          block,
+		QC:             qc,
+		Result:         result,
+		Seal:           seal,
+		EpochFirstView: block.Header.View,
+	}
+
+	backend := New(Params{
+		State:                     suite.state,
+		ChainID:                   suite.chainID,
+		RootSnapshot:              root,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
+
+	data, err := backend.GetProtocolStateSnapshot(ctx)
+	suite.checkResponse(data, err)
+
+	expected, err := json.Marshal(root)
+	suite.Require().NoError(err)
+	suite.Assert().JSONEq(string(expected), string(data))
+}
+
+func (suite *Suite) TestSimulateTransaction() {
+	ctx := context.Background()
+
+	tx := unittest.TransactionBodyFixture()
+	sealed := unittest.BlockHeaderFixture()
+	block := unittest.BlockHeaderFixture()
+
+	suite.snapshot.On("Head").Return(&sealed, nil)
+	blockSnapshot := new(protocol.Snapshot)
+	blockSnapshot.On("Head").Return(&block, nil)
+	suite.state.On("AtBlockID", block.ID()).Return(blockSnapshot)
+
+	simulationRPC := new(simulationmock.API)
+	simulationRPC.
+		On("SimulateTransaction", mock.Anything, &simulation.Request{BlockID: block.ID(), Transaction: &tx, SkipSignatureCheck: true}).
+		Return(&flow.TransactionSimulation{TransactionID: tx.ID(), BlockID: block.ID()}, nil).
+		Once()
+	simulationRPC.
+		On("SimulateTransaction", mock.Anything, &simulation.Request{BlockID: sealed.ID(), Transaction: &tx}).
+		Return(&flow.TransactionSimulation{TransactionID: tx.ID(), BlockID: sealed.ID()}, nil).
+		Once()
+
+	backend := New(Params{
+		State:                     suite.state,
+		SimulationRPC:             simulationRPC,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
+
+	suite.Run("at block", func() {
+		result, err := backend.SimulateTransaction(ctx, &tx, block.ID(), true)
+		suite.checkResponse(result, err)
+		suite.Assert().Equal(block.ID(), result.BlockID)
+	})
+
+	suite.Run("at latest sealed block", func() {
+		result, err := backend.SimulateTransaction(ctx, &tx, flow.ZeroID, false)
+		suite.checkResponse(result, err)
+		suite.Assert().Equal(sealed.ID(), result.BlockID)
+	})
+
+	simulationRPC.AssertExpectations(suite.T())
+}
+
 func (suite *Suite) TestGetAccount() {
 
 	address, err := suite.chainID.Chain().NewAddressGenerator().NextAddress()
@@ -687,23 +1425,20 @@ func (suite *Suite) TestGetAccount() {
 
 	// setup the execution client mock
 	suite.execClient.
-		On("GetAccountAtBlockID", ctx, exeReq).
+		On("GetAccountAtBlockID", mock.Anything, exeReq).
 		Return(exeResp, nil).
 		Once()
 
 	// create the handler with the mock
-	backend := New(
-		suite.state,
-		suite.execClient,
-		nil, nil, nil,
-		suite.headers,
-		nil, nil,
-		suite.chainID,
-		metrics.NewNoopCollector(),
-		0,
-		nil,
-		false,
-	)
+	backend := New(Params{
+		State:                     suite.state,
+		ExecutionRPC:              suite.execClient,
+		Headers:                   suite.headers,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
 
 	suite.Run("happy path - valid request and valid response", func() {
 		account, err := backend.GetAccountAtLatestBlock(ctx, address)
@@ -746,23 +1481,20 @@ func (suite *Suite) TestGetAccountAtBlockHeight() {
 
 	// setup the execution client mock
 	suite.execClient.
-		On("GetAccountAtBlockID", ctx, exeReq).
+		On("GetAccountAtBlockID", mock.Anything, exeReq).
 		Return(exeResp, nil).
 		Once()
 
 	// create the handler with the mock
-	backend := New(
-		suite.state,
-		suite.execClient,
-		nil, nil, nil,
-		suite.headers,
-		nil, nil,
-		flow.Testnet,
-		metrics.NewNoopCollector(),
-		0,
-		nil,
-		false,
-	)
+	backend := New(Params{
+		State:                     suite.state,
+		ExecutionRPC:              suite.execClient,
+		Headers:                   suite.headers,
+		ChainID:                   flow.Testnet,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
 
 	suite.Run("happy path - valid request and valid response", func() {
 		account, err := backend.GetAccountAtBlockHeight(ctx, address, height)
@@ -774,17 +1506,84 @@ func (suite *Suite) TestGetAccountAtBlockHeight() {
 	})
 }
 
+func (suite *Suite) TestExecuteScriptAtBlockIDCached() {
+	ctx := context.Background()
+	script := []byte("pub fun main(): Int { return 1 }")
+	arguments := [][]byte{[]byte("argument")}
+
+	// setup the latest sealed block
+	sealed := unittest.BlockHeaderFixture()
+	suite.snapshot.
+		On("Head").
+		Return(&sealed, nil)
+
+	// a sealed and an unsealed block to execute the script at
+	sealedHeader := unittest.BlockHeaderWithParentFixture(&sealed)
+	sealedHeader.Height = sealed.Height
+	unsealedHeader := unittest.BlockHeaderWithParentFixture(&sealed)
+
+	backend := New(Params{
+		State:                     suite.state,
+		ExecutionRPC:              suite.execClient,
+		Headers:                   suite.headers,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		ResponseCache:             ResponseCacheConfig{Size: 10},
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
+
+	for _, header := range []flow.Header{sealedHeader, unsealedHeader} {
+		header := header
+		blockID := header.ID()
+		suite.headers.
+			On("ByBlockID", blockID).
+			Return(&header, nil)
+
+		exeReq := &execproto.ExecuteScriptAtBlockIDRequest{
+			BlockId:   blockID[:],
+			Script:    script,
+			Arguments: arguments,
+		}
+		suite.execClient.
+			On("ExecuteScriptAtBlockID", mock.Anything, exeReq).
+			Return(&execproto.ExecuteScriptAtBlockIDResponse{Value: blockID[:]}, nil)
+	}
+
+	suite.Run("sealed block", func() {
+		blockID := sealedHeader.ID()
+		for i := 0; i < 2; i++ {
+			result, err := backend.ExecuteScriptAtBlockID(ctx, blockID, script, arguments)
+			suite.checkResponse(result, err)
+			suite.Require().Equal(blockID[:], result)
+		}
+
+		// the second result is served from the cache
+		suite.execClient.AssertNumberOfCalls(suite.T(), "ExecuteScriptAtBlockID", 1)
+	})
+
+	suite.Run("unsealed block", func() {
+		blockID := unsealedHeader.ID()
+		for i := 0; i < 2; i++ {
+			result, err := backend.ExecuteScriptAtBlockID(ctx, blockID, script, arguments)
+			suite.checkResponse(result, err)
+			suite.Require().Equal(blockID[:], result)
+		}
+
+		// the results at unsealed blocks are never cached
+		suite.execClient.AssertNumberOfCalls(suite.T(), "ExecuteScriptAtBlockID", 3)
+	})
+}
+
 func (suite *Suite) TestGetNetworkParameters() {
 	expectedChainID := flow.Mainnet
 
-	backend := New(
-		nil, nil, nil, nil, nil, nil, nil, nil,
-		flow.Mainnet,
-		metrics.NewNoopCollector(),
-		0,
-		nil,
-		false,
-	)
+	backend := New(Params{
+		ChainID:                   flow.Mainnet,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
 
 	params := backend.GetNetworkParameters(context.Background())
 
@@ -798,6 +1597,10 @@ func (suite *Suite) assertAllExpectations() {
 	suite.headers.AssertExpectations(suite.T())
 	suite.collections.AssertExpectations(suite.T())
 	suite.transactions.AssertExpectations(suite.T())
+	suite.events.AssertExpectations(suite.T())
+	suite.transactionResults.AssertExpectations(suite.T())
+	suite.seals.AssertExpectations(suite.T())
+	suite.results.AssertExpectations(suite.T())
 	suite.execClient.AssertExpectations(suite.T())
 }
 
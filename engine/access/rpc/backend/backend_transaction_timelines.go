@@ -0,0 +1,168 @@
+package backend
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onflow/flow-go/access"
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/module/mempool"
+	"github.com/onflow/flow-go/state/protocol"
+	"github.com/onflow/flow-go/storage"
+)
+
+type backendTransactionTimelines struct {
+	state        protocol.State
+	transactions storage.Transactions
+	timings      mempool.TransactionTimings // nil if transaction timings are not tracked
+
+	// the timelines are derived from the other sub-backends
+	transactionsBackend *backendTransactions
+	executionResults    *backendExecutionResults
+}
+
+// GetTransactionTimeline returns the status transitions of the transaction so far.
+//
+// The times at which the access node observed the transitions are only known for transactions it is
+// tracking the timings of. Otherwise, the timestamps of the blocks which caused the transitions are used.
+func (b *backendTransactionTimelines) GetTransactionTimeline(ctx context.Context, txID flow.Identifier) (*access.TransactionTimeline, error) {
+
+	tx, err := b.transactions.ByID(txID)
+	if err != nil {
+		return nil, convertStorageError(err)
+	}
+
+	referenceBlock, err := b.state.AtBlockID(tx.ReferenceBlockID).Head()
+	if err != nil {
+		return nil, convertStorageError(err)
+	}
+
+	timeline := &access.TransactionTimeline{
+		TransactionID: txID,
+	}
+
+	// the collection cluster responsible for the transaction is assigned by the epoch of its reference block
+	clusters, err := b.state.AtBlockID(tx.ReferenceBlockID).Epochs().Current().Clustering()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get collection clusters: %v", err)
+	}
+	cluster, ok := clusters.ByTxID(txID)
+	if ok {
+		timeline.ClusterIndex, _ = clusters.IndexOf(cluster)
+		timeline.Cluster = cluster.NodeIDs()
+	}
+
+	timing := b.timing(txID)
+
+	timeline.Transitions = append(timeline.Transitions, access.TransactionStatusTransition{
+		Status:      flow.TransactionStatusPending,
+		Time:        timing.Received,
+		BlockHeight: referenceBlock.Height,
+	})
+
+	block, err := b.transactionsBackend.lookupBlock(txID)
+	if errors.Is(err, storage.ErrNotFound) {
+		expired, err := b.expiredTransition(referenceBlock)
+		if err != nil {
+			return nil, err
+		}
+		if expired != nil {
+			timeline.Transitions = append(timeline.Transitions, *expired)
+		}
+		return timeline, nil
+	}
+	if err != nil {
+		return nil, convertStorageError(err)
+	}
+
+	finalizedAt := timing.Finalized
+	if finalizedAt.IsZero() {
+		finalizedAt = block.Header.Timestamp
+	}
+	timeline.Transitions = append(timeline.Transitions, access.TransactionStatusTransition{
+		Status:      flow.TransactionStatusFinalized,
+		Time:        finalizedAt,
+		BlockHeight: block.Header.Height,
+	})
+
+	sealed, err := b.state.Sealed().Head()
+	if err != nil {
+		return nil, convertStorageError(err)
+	}
+
+	// a sealed block is known to be executed
+	executed := block.Header.Height <= sealed.Height
+	if !executed {
+		executed, _, _, _, err = b.transactionsBackend.lookupTransactionResultInBlock(ctx, block.Header, txID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !executed {
+		return timeline, nil
+	}
+
+	timeline.Transitions = append(timeline.Transitions, access.TransactionStatusTransition{
+		Status:      flow.TransactionStatusExecuted,
+		Time:        timing.Executed,
+		BlockHeight: block.Header.Height,
+	})
+
+	if block.Header.Height > sealed.Height {
+		return timeline, nil
+	}
+
+	_, sealingBlock, err := b.executionResults.lookupSealingBlock(block.ID())
+	if err != nil {
+		return nil, err
+	}
+	timeline.Transitions = append(timeline.Transitions, access.TransactionStatusTransition{
+		Status:      flow.TransactionStatusSealed,
+		Time:        sealingBlock.Timestamp,
+		BlockHeight: sealingBlock.Height,
+	})
+
+	return timeline, nil
+}
+
+// timing returns the tracked timing of the transaction, or an empty timing if it is not tracked
+func (b *backendTransactionTimelines) timing(txID flow.Identifier) flow.TransactionTiming {
+	if b.timings == nil {
+		return flow.TransactionTiming{TransactionID: txID}
+	}
+	timing, ok := b.timings.ByID(txID)
+	if !ok {
+		return flow.TransactionTiming{TransactionID: txID}
+	}
+	return *timing
+}
+
+// expiredTransition returns the transition of a transaction with the given reference block, which is
+// not included in a finalized block, into the expired status, or nil if it has not expired yet.
+func (b *backendTransactionTimelines) expiredTransition(referenceBlock *flow.Header) (*access.TransactionStatusTransition, error) {
+
+	final, err := b.state.Final().Head()
+	if err != nil {
+		return nil, convertStorageError(err)
+	}
+
+	// the transaction expires with the first finalized block beyond the expiry of its reference block
+	expiryHeight := referenceBlock.Height + flow.DefaultTransactionExpiry + 1
+	if final.Height < expiryHeight {
+		return nil, nil
+	}
+
+	expiryBlock, err := b.state.AtHeight(expiryHeight).Head()
+	if err != nil {
+		return nil, convertStorageError(err)
+	}
+
+	return &access.TransactionStatusTransition{
+		Status:      flow.TransactionStatusExpired,
+		Time:        expiryBlock.Timestamp,
+		BlockHeight: expiryHeight,
+	}, nil
+}
@@ -2,11 +2,14 @@ package backend
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"net"
+	"sort"
 	"time"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/hashicorp/go-multierror"
 	accessproto "github.com/onflow/flow/protobuf/go/flow/access"
 	"github.com/onflow/flow/protobuf/go/flow/entities"
@@ -16,6 +19,7 @@ import (
 
 	"github.com/onflow/flow-go/access"
 	"github.com/onflow/flow-go/engine/common/rpc/convert"
+	rpcresults "github.com/onflow/flow-go/engine/common/rpc/results"
 	"github.com/onflow/flow-go/model/flow"
 	"github.com/onflow/flow-go/module"
 	"github.com/onflow/flow-go/state/protocol"
@@ -26,7 +30,7 @@ const collectionNodesToTry uint = 3
 
 type backendTransactions struct {
 	staticCollectionRPC  accessproto.AccessAPIClient // rpc client tied to a fixed collection node
-	executionRPC         execproto.ExecutionAPIClient
+	executionNodes       *executionNodes
 	transactions         storage.Transactions
 	collections          storage.Collections
 	blocks               storage.Blocks
@@ -37,6 +41,8 @@ type backendTransactions struct {
 	retry                *Retry
 	collectionGRPCPort   uint
 	connFactory          ConnectionFactory
+	index                *resultsIndex
+	systemTxID           flow.Identifier // ID of the system chunk transaction, executed at the end of every block
 
 	previousAccessNodes []accessproto.AccessAPIClient
 }
@@ -126,14 +132,11 @@ func (b *backendTransactions) chooseCollectionNodes(tx *flow.TransactionBody, sa
 	// (identity list does not directly provide collection nodes gRPC address)
 	var targetAddrs = make([]string, len(targetNodes))
 	for i, id := range targetNodes {
-		// split hostname and port
-		hostnameOrIP, _, err := net.SplitHostPort(id.Address)
+		addr, err := grpcAddress(id.Address, b.collectionGRPCPort)
 		if err != nil {
 			return nil, err
 		}
-		// use the hostname from identity list and port number as the one passed in as argument
-		grpcAddress := fmt.Sprintf("%s:%d", hostnameOrIP, b.collectionGRPCPort)
-		targetAddrs[i] = grpcAddress
+		targetAddrs[i] = addr
 	}
 
 	return targetAddrs, nil
@@ -218,7 +221,65 @@ func (b *backendTransactions) GetTransactionResult(
 		return nil, convertStorageError(err)
 	}
 
-	// TODO: Set correct values for StatusCode and ErrorMessage
+	return &access.TransactionResult{
+		Status:       status,
+		StatusCode:   uint(statusCode),
+		Events:       events,
+		ErrorMessage: txError,
+	}, nil
+}
+
+// GetTransactionResultsByBlockID returns the results of all transactions of the given finalized block,
+// in the order of their execution
+func (b *backendTransactions) GetTransactionResultsByBlockID(
+	ctx context.Context,
+	blockID flow.Identifier,
+) ([]*access.TransactionResult, error) {
+
+	block, txIDs, err := b.lookupBlockTransactions(ctx, blockID)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.getTransactionResultsInBlock(ctx, block.Header, txIDs)
+}
+
+// GetTransactionResultByIndex returns the result of the transaction with the given index
+// in the given finalized block
+func (b *backendTransactions) GetTransactionResultByIndex(
+	ctx context.Context,
+	blockID flow.Identifier,
+	index uint32,
+) (*access.TransactionResult, error) {
+
+	block, txIDs, err := b.lookupBlockTransactions(ctx, blockID)
+	if err != nil {
+		return nil, err
+	}
+
+	if index >= uint32(len(txIDs)) {
+		return nil, status.Errorf(codes.NotFound, "block %v has no transaction with index %d", blockID, index)
+	}
+
+	return b.getTransactionResultInBlock(ctx, block.Header, txIDs[index])
+}
+
+// getTransactionResultInBlock returns the result of the transaction in the given finalized block
+func (b *backendTransactions) getTransactionResultInBlock(
+	ctx context.Context,
+	header *flow.Header,
+	txID flow.Identifier,
+) (*access.TransactionResult, error) {
+
+	executed, events, statusCode, txError, err := b.lookupTransactionResultInBlock(ctx, header, txID)
+	if err != nil {
+		return nil, convertStorageError(err)
+	}
+
+	status, err := b.deriveTransactionStatusInBlock(header, executed)
+	if err != nil {
+		return nil, convertStorageError(err)
+	}
 
 	return &access.TransactionResult{
 		Status:       status,
@@ -228,6 +289,208 @@ func (b *backendTransactions) GetTransactionResult(
 	}, nil
 }
 
+// getTransactionResultsInBlock returns the results of the transactions in the given finalized block. The
+// results of a block which is not indexed yet are requested from the execution nodes in one batch, unless
+// they don't serve the batch request yet.
+func (b *backendTransactions) getTransactionResultsInBlock(
+	ctx context.Context,
+	header *flow.Header,
+	txIDs []flow.Identifier,
+) ([]*access.TransactionResult, error) {
+
+	indexed, _, err := b.index.partition([]*flow.Header{header})
+	if err != nil {
+		return nil, convertStorageError(err)
+	}
+
+	if len(indexed) == 0 {
+		results, err := b.getTransactionResultsFromExecutionNode(ctx, header, txIDs)
+		if status.Code(err) != codes.Unimplemented {
+			return results, err
+		}
+	}
+
+	results := make([]*access.TransactionResult, 0, len(txIDs))
+	for _, txID := range txIDs {
+		result, err := b.getTransactionResultInBlock(ctx, header, txID)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// getTransactionResultsFromExecutionNode requests the results of the transactions in the given finalized
+// block from the execution nodes in one batch. It fails with codes.Unimplemented if they don't serve it.
+func (b *backendTransactions) getTransactionResultsFromExecutionNode(
+	ctx context.Context,
+	header *flow.Header,
+	txIDs []flow.Identifier,
+) ([]*access.TransactionResult, error) {
+
+	blockID := header.ID()
+	req := &rpcresults.Request{
+		BlockID:        blockID,
+		TransactionIDs: txIDs,
+	}
+
+	resp, err := b.executionNodes.query(ctx, "get_transaction_results", []flow.Identifier{blockID},
+		func(ctx context.Context, client execproto.ExecutionAPIClient) (proto.Message, error) {
+			resultsClient, ok := client.(rpcresults.API)
+			if !ok {
+				return nil, status.Error(codes.Unimplemented, "execution node client does not request transaction results")
+			}
+			results, err := resultsClient.GetTransactionResults(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			// the responses of the execution nodes are cross-checked in their encoded form
+			data, err := json.Marshal(results)
+			if err != nil {
+				return nil, fmt.Errorf("could not encode transaction results: %w", err)
+			}
+			return &wrappers.BytesValue{Value: data}, nil
+		})
+	if status.Code(err) == codes.NotFound {
+		// no results yet, the block has not been executed
+		txStatus, err := b.deriveTransactionStatusInBlock(header, false)
+		if err != nil {
+			return nil, convertStorageError(err)
+		}
+		results := make([]*access.TransactionResult, 0, len(txIDs))
+		for range txIDs {
+			results = append(results, &access.TransactionResult{Status: txStatus})
+		}
+		return results, nil
+	}
+	if status.Code(err) == codes.Unimplemented {
+		return nil, err
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to retrieve results from execution node: %v", err)
+	}
+
+	var execResults []rpcresults.Result
+	err = json.Unmarshal(resp.(*wrappers.BytesValue).GetValue(), &execResults)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to decode transaction results: %v", err)
+	}
+	if len(execResults) != len(txIDs) {
+		return nil, status.Errorf(codes.Internal, "execution node returned %d results for %d transactions", len(execResults), len(txIDs))
+	}
+
+	txStatus, err := b.deriveTransactionStatusInBlock(header, true)
+	if err != nil {
+		return nil, convertStorageError(err)
+	}
+
+	results := make([]*access.TransactionResult, 0, len(execResults))
+	for _, execResult := range execResults {
+		results = append(results, &access.TransactionResult{
+			Status:       txStatus,
+			StatusCode:   uint(execResult.StatusCode),
+			Events:       execResult.Events,
+			ErrorMessage: execResult.ErrorMessage,
+		})
+	}
+
+	return results, nil
+}
+
+// lookupBlockTransactions returns the finalized block with the given ID and the IDs of its transactions
+// in the order of their execution, i.e. ordered by collection, followed by the scheduled transactions
+// once the block is executed, and ending with the system transaction
+func (b *backendTransactions) lookupBlockTransactions(ctx context.Context, blockID flow.Identifier) (*flow.Block, []flow.Identifier, error) {
+
+	block, err := b.blocks.ByID(blockID)
+	if err != nil {
+		return nil, nil, convertStorageError(err)
+	}
+
+	// only finalized blocks are considered, as the transactions of other blocks are never executed
+	finalized, err := b.state.AtHeight(block.Header.Height).Head()
+	if err != nil {
+		return nil, nil, convertStorageError(err)
+	}
+	if finalized.ID() != blockID {
+		return nil, nil, status.Errorf(codes.NotFound, "block %v is not finalized", blockID)
+	}
+
+	var txIDs []flow.Identifier
+	for _, guarantee := range block.Payload.Guarantees {
+		collection, err := b.collections.LightByID(guarantee.CollectionID)
+		if err != nil {
+			return nil, nil, convertStorageError(err)
+		}
+		txIDs = append(txIDs, collection.Transactions...)
+	}
+
+	scheduledTxIDs, err := b.lookupScheduledTransactions(ctx, block.Header)
+	if err != nil {
+		return nil, nil, err
+	}
+	txIDs = append(txIDs, scheduledTxIDs...)
+	txIDs = append(txIDs, b.systemTxID)
+
+	return block, txIDs, nil
+}
+
+// lookupScheduledTransactions returns the IDs of the scheduled transactions executed in the system chunk
+// of the given finalized block, in the order of their execution. Scheduled transactions are found by
+// their ScheduledTransactionExecuted events, so none are returned if the block is not executed yet.
+func (b *backendTransactions) lookupScheduledTransactions(ctx context.Context, header *flow.Header) ([]flow.Identifier, error) {
+
+	blockID := header.ID()
+	eventType := flow.EventScheduledTransactionExecuted
+
+	indexed, _, err := b.index.partition([]*flow.Header{header})
+	if err != nil {
+		return nil, convertStorageError(err)
+	}
+
+	var events []flow.Event
+	if len(indexed) > 0 {
+		events, err = b.index.events.ByBlockIDEventType(blockID, eventType)
+		if err != nil {
+			return nil, convertStorageError(err)
+		}
+	} else {
+		req := execproto.GetEventsForBlockIDsRequest{
+			Type:     string(eventType),
+			BlockIds: convert.IdentifiersToMessages([]flow.Identifier{blockID}),
+		}
+
+		resp, err := b.executionNodes.query(ctx, "get_events", []flow.Identifier{blockID},
+			func(ctx context.Context, client execproto.ExecutionAPIClient) (proto.Message, error) {
+				return client.GetEventsForBlockIDs(ctx, &req)
+			})
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				// the block is not executed yet
+				return nil, nil
+			}
+			return nil, status.Errorf(codes.Internal, "failed to retrieve scheduled transactions from execution node: %v", err)
+		}
+
+		for _, result := range resp.(*execproto.GetEventsForBlockIDsResponse).GetResults() {
+			events = append(events, convert.MessagesToEvents(result.GetEvents())...)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].TransactionIndex < events[j].TransactionIndex
+	})
+
+	txIDs := make([]flow.Identifier, 0, len(events))
+	for _, event := range events {
+		txIDs = append(txIDs, event.TransactionID)
+	}
+
+	return txIDs, nil
+}
+
 // DeriveTransactionStatus derives the transaction status based on current protocol state
 func (b *backendTransactions) DeriveTransactionStatus(
 	tx *flow.TransactionBody,
@@ -262,6 +525,15 @@ func (b *backendTransactions) DeriveTransactionStatus(
 		return flow.TransactionStatusUnknown, err
 	}
 
+	return b.deriveTransactionStatusInBlock(block.Header, executed)
+}
+
+// deriveTransactionStatusInBlock derives the status of a transaction included in the given finalized block
+func (b *backendTransactions) deriveTransactionStatusInBlock(
+	header *flow.Header,
+	executed bool,
+) (flow.TransactionStatus, error) {
+
 	if !executed {
 		// If we've gotten here, but the block has not yet been executed, report it as only been finalized
 		return flow.TransactionStatusFinalized, nil
@@ -275,7 +547,7 @@ func (b *backendTransactions) DeriveTransactionStatus(
 		return flow.TransactionStatusUnknown, err
 	}
 
-	if block.Header.Height > sealed.Height {
+	if header.Height > sealed.Height {
 		// The block is not yet sealed, so we'll report it as only executed
 		return flow.TransactionStatusExecuted, nil
 	}
@@ -314,7 +586,27 @@ func (b *backendTransactions) lookupTransactionResult(
 		return false, nil, 0, "", convertStorageError(err)
 	}
 
-	blockID := block.ID()
+	return b.lookupTransactionResultInBlock(ctx, block.Header, txID)
+}
+
+// lookupTransactionResultInBlock looks up the result of the transaction in the given finalized block,
+// from the local index if the block is indexed, or otherwise from the execution node
+func (b *backendTransactions) lookupTransactionResultInBlock(
+	ctx context.Context,
+	header *flow.Header,
+	txID flow.Identifier,
+) (bool, []flow.Event, uint32, string, error) {
+
+	blockID := header.ID()
+
+	// sealed blocks are served from the local index, if they were indexed already
+	result, events, indexed, err := b.index.transactionResult(header, txID)
+	if err != nil {
+		return false, nil, 0, "", convertStorageError(err)
+	}
+	if indexed {
+		return true, events, result.StatusCode(), result.ErrorMessage, nil
+	}
 
 	events, txStatus, message, err := b.getTransactionResultFromExecutionNode(ctx, blockID[:], txID[:])
 	if err != nil {
@@ -396,7 +688,10 @@ func (b *backendTransactions) getTransactionResultFromExecutionNode(
 	}
 
 	// call the execution node gRPC
-	resp, err := b.executionRPC.GetTransactionResult(ctx, &req)
+	result, err := b.executionNodes.query(ctx, "get_transaction_result", []flow.Identifier{flow.HashToID(blockID)},
+		func(ctx context.Context, client execproto.ExecutionAPIClient) (proto.Message, error) {
+			return client.GetTransactionResult(ctx, &req)
+		})
 	if err != nil {
 		if status.Code(err) == codes.NotFound {
 			return nil, 0, "", err
@@ -405,6 +700,7 @@ func (b *backendTransactions) getTransactionResultFromExecutionNode(
 		return nil, 0, "", status.Errorf(codes.Internal, "failed to retrieve result from execution node: %v", err)
 	}
 
+	resp := result.(*execproto.GetTransactionResultResponse)
 	events := convert.MessagesToEvents(resp.GetEvents())
 
 	return events, resp.GetStatusCode(), resp.GetErrorMessage(), nil
@@ -5,14 +5,17 @@ import (
 	"io"
 
 	"github.com/onflow/flow/protobuf/go/flow/access"
+	"github.com/onflow/flow/protobuf/go/flow/execution"
 	"google.golang.org/grpc"
 
+	"github.com/onflow/flow-go/engine/common/rpc/results"
 	grpcutils "github.com/onflow/flow-go/utils/grpc"
 )
 
-// ConnectionFactory is used to create an access api client
+// ConnectionFactory is used to create access and execution api clients
 type ConnectionFactory interface {
 	GetAccessAPIClient(address string) (access.AccessAPIClient, io.Closer, error)
+	GetExecutionAPIClient(address string) (execution.ExecutionAPIClient, io.Closer, error)
 }
 
 type ConnectionFactoryImpl struct {
@@ -39,3 +42,29 @@ func (cf *ConnectionFactoryImpl) GetAccessAPIClient(address string) (access.Acce
 	closer := io.Closer(conn)
 	return accessAPIClient, closer, nil
 }
+
+func (cf *ConnectionFactoryImpl) GetExecutionAPIClient(address string) (execution.ExecutionAPIClient, io.Closer, error) {
+	conn, err := cf.createConnection(address)
+	if err != nil {
+		return nil, nil, err
+	}
+	executionAPIClient := NewExecutionClient(conn)
+	closer := io.Closer(conn)
+	return executionAPIClient, closer, nil
+}
+
+// executionClient is a client of the Execution API which also requests transaction results in batches,
+// through the transaction results service of the execution node.
+type executionClient struct {
+	execution.ExecutionAPIClient
+	results.API
+}
+
+// NewExecutionClient returns a client of the Execution API served on the given connection, which the
+// backend also uses to request the transaction results of blocks in batches.
+func NewExecutionClient(conn *grpc.ClientConn) execution.ExecutionAPIClient {
+	return &executionClient{
+		ExecutionAPIClient: execution.NewExecutionAPIClient(conn),
+		API:                results.NewClient(conn),
+	}
+}
@@ -0,0 +1,308 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hashicorp/go-multierror"
+	execproto "github.com/onflow/flow/protobuf/go/flow/execution"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/model/flow/filter"
+	"github.com/onflow/flow-go/module"
+	"github.com/onflow/flow-go/state/protocol"
+	"github.com/onflow/flow-go/storage"
+)
+
+// executionNodeQueryTimeout is the time an execution node has to respond to a request, before the
+// request is forwarded to the next execution node
+const executionNodeQueryTimeout = 10 * time.Second
+
+// executionNodes forwards requests about blocks to the execution nodes which produced an execution
+// receipt for the blocks, and cross-checks their responses.
+//
+// A request is sent to queryCount execution nodes in parallel, each of which has executionNodeQueryTimeout
+// to respond. Whenever a request fails, or the responses differ, the request is also sent to the next
+// execution node, until queryCount of them returned the same response. If the execution nodes run out
+// before enough of them agree, the response is only returned if no two responses differed.
+//
+// If cross-checking is disabled (queryCount is 0), or no receipt of the blocks is known, all requests
+// are forwarded to the statically configured execution node.
+type executionNodes struct {
+	state       protocol.State
+	receipts    storage.ExecutionReceipts
+	staticRPC   execproto.ExecutionAPIClient // the statically configured execution node
+	connFactory ConnectionFactory
+	grpcPort    uint
+	queryCount  uint
+	timeout     time.Duration // the time each execution node has to respond
+	metrics     module.ExecutionNodeQueryMetrics
+
+	mu      sync.Mutex
+	clients map[string]execproto.ExecutionAPIClient // connection pool, by gRPC address
+	closers map[string]io.Closer                    // connections of the pooled clients, by gRPC address
+}
+
+func newExecutionNodes(
+	state protocol.State,
+	receipts storage.ExecutionReceipts,
+	staticRPC execproto.ExecutionAPIClient,
+	connFactory ConnectionFactory,
+	grpcPort uint,
+	queryCount uint,
+	metrics module.ExecutionNodeQueryMetrics,
+) *executionNodes {
+	return &executionNodes{
+		state:       state,
+		receipts:    receipts,
+		staticRPC:   staticRPC,
+		connFactory: connFactory,
+		grpcPort:    grpcPort,
+		queryCount:  queryCount,
+		timeout:     executionNodeQueryTimeout,
+		metrics:     metrics,
+		clients:     make(map[string]execproto.ExecutionAPIClient),
+		closers:     make(map[string]io.Closer),
+	}
+}
+
+// executionQuery sends a request to the given execution node and returns its response. The request
+// must be canceled with the given context.
+type executionQuery func(ctx context.Context, client execproto.ExecutionAPIClient) (proto.Message, error)
+
+// query sends the request to the execution nodes which executed all given blocks, and returns the
+// cross-checked response. The name of the query is used to label the metrics.
+func (e *executionNodes) query(ctx context.Context, name string, blockIDs []flow.Identifier, query executionQuery) (proto.Message, error) {
+
+	clients, err := e.clientsFor(blockIDs)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to choose execution nodes: %v", err)
+	}
+
+	// the requests still running are canceled once a response is returned
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		resp proto.Message
+		err  error
+	}
+	results := make(chan result, len(clients))
+	next := 0
+	send := func() {
+		client := clients[next]
+		next++
+		go func() {
+			queryCtx, cancel := context.WithTimeout(ctx, e.timeout)
+			defer cancel()
+			resp, err := query(queryCtx, client)
+			results <- result{resp: resp, err: err}
+		}()
+	}
+
+	required := e.queryCount
+	if required == 0 {
+		required = 1
+	}
+
+	// distinct responses, and the number of execution nodes which returned them
+	var responses []proto.Message
+	var counts []uint
+	var maxCount uint
+	var lastErr error
+
+	pending := uint(0)
+	for {
+		// send the request to as many execution nodes as are missing for the most common response to be
+		// returned by enough of them, assuming they all return it
+		for ctx.Err() == nil && next < len(clients) && pending < required-maxCount {
+			send()
+			pending++
+		}
+		if pending == 0 {
+			break
+		}
+
+		res := <-results
+		pending--
+		if res.err != nil {
+			// fail over to the next execution node
+			e.metrics.ExecutionNodeQueryFailed(name)
+			lastErr = res.err
+			continue
+		}
+
+		matched := -1
+		for i := range responses {
+			if proto.Equal(responses[i], res.resp) {
+				matched = i
+				break
+			}
+		}
+		if matched < 0 {
+			if len(responses) > 0 {
+				e.metrics.ExecutionNodeResponseMismatch(name)
+			}
+			responses = append(responses, res.resp)
+			counts = append(counts, 0)
+			matched = len(responses) - 1
+		}
+		counts[matched]++
+		if counts[matched] >= required {
+			return responses[matched], nil
+		}
+		if counts[matched] > maxCount {
+			maxCount = counts[matched]
+		}
+	}
+
+	switch len(responses) {
+	case 0:
+		if lastErr == nil {
+			return nil, ctx.Err()
+		}
+		return nil, lastErr
+	case 1:
+		// not enough execution nodes to cross-check the response, but none of them disagreed
+		return responses[0], nil
+	default:
+		return nil, status.Errorf(codes.Internal, "execution nodes returned %d different responses", len(responses))
+	}
+}
+
+// clientsFor returns the clients of the execution nodes which produced an execution receipt for all given
+// blocks, in random order. It falls back to the statically configured execution node if none is known.
+func (e *executionNodes) clientsFor(blockIDs []flow.Identifier) ([]execproto.ExecutionAPIClient, error) {
+	if e.queryCount == 0 {
+		return []execproto.ExecutionAPIClient{e.staticRPC}, nil
+	}
+
+	// the number of the given blocks each execution node produced a receipt for
+	executed := make(map[flow.Identifier]int)
+	for _, blockID := range blockIDs {
+		executors, err := e.executorsOf(blockID)
+		if err != nil {
+			return nil, err
+		}
+		for executorID := range executors {
+			executed[executorID]++
+		}
+	}
+
+	var executorIDs []flow.Identifier
+	for executorID, count := range executed {
+		if count == len(blockIDs) {
+			executorIDs = append(executorIDs, executorID)
+		}
+	}
+
+	if len(executorIDs) == 0 {
+		return []execproto.ExecutionAPIClient{e.staticRPC}, nil
+	}
+
+	identities, err := e.state.Final().Identities(filter.And(
+		filter.HasRole(flow.RoleExecution),
+		filter.HasNodeID(executorIDs...),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("could not get execution node identities: %w", err)
+	}
+	if len(identities) == 0 {
+		return []execproto.ExecutionAPIClient{e.staticRPC}, nil
+	}
+
+	identities = identities.Sample(uint(len(identities)))
+	clients := make([]execproto.ExecutionAPIClient, 0, len(identities))
+	for _, identity := range identities {
+		client, err := e.client(identity.Address)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+
+	return clients, nil
+}
+
+// executorsOf returns the IDs of the execution nodes which produced an execution receipt for the given
+// block, which was included in a block stored by the access node.
+func (e *executionNodes) executorsOf(blockID flow.Identifier) (map[flow.Identifier]struct{}, error) {
+
+	ids, err := e.receipts.ExecutorsByBlockID(blockID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get executors of block %x: %w", blockID, err)
+	}
+
+	executorIDs := make(map[flow.Identifier]struct{}, len(ids))
+	for _, executorID := range ids {
+		executorIDs[executorID] = struct{}{}
+	}
+
+	return executorIDs, nil
+}
+
+// client returns the pooled client of the execution node with the given network address,
+// connecting to it if necessary.
+func (e *executionNodes) client(address string) (execproto.ExecutionAPIClient, error) {
+	addr, err := grpcAddress(address, e.grpcPort)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	client, ok := e.clients[addr]
+	if ok {
+		return client, nil
+	}
+
+	// the connection is kept open until the execution nodes are closed
+	client, closer, err := e.connFactory.GetExecutionAPIClient(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to execution node at %s: %w", addr, err)
+	}
+	e.clients[addr] = client
+	if closer != nil {
+		e.closers[addr] = closer
+	}
+
+	return client, nil
+}
+
+// close closes the connections of all pooled clients. Clients requested afterwards reconnect.
+func (e *executionNodes) close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var result *multierror.Error
+	for addr, closer := range e.closers {
+		err := closer.Close()
+		if err != nil {
+			result = multierror.Append(result, fmt.Errorf("failed to close connection to execution node at %s: %w", addr, err))
+		}
+	}
+	e.clients = make(map[string]execproto.ExecutionAPIClient)
+	e.closers = make(map[string]io.Closer)
+
+	return result.ErrorOrNil()
+}
+
+// grpcAddress converts the network address of a node from the identity list into the address of
+// its gRPC server listening on the given port
+func grpcAddress(address string, port uint) (string, error) {
+	// split hostname and port
+	hostnameOrIP, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", err
+	}
+	// use the hostname from identity list and the given port number
+	return fmt.Sprintf("%s:%d", hostnameOrIP, port), nil
+}
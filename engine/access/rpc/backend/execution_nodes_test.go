@@ -0,0 +1,278 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	execproto "github.com/onflow/flow/protobuf/go/flow/execution"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	access "github.com/onflow/flow-go/engine/access/mock"
+	connmock "github.com/onflow/flow-go/engine/access/rpc/backend/mock"
+	"github.com/onflow/flow-go/model/flow"
+	modulemock "github.com/onflow/flow-go/module/mock"
+	protocol "github.com/onflow/flow-go/state/protocol/mock"
+	storagemock "github.com/onflow/flow-go/storage/mock"
+	"github.com/onflow/flow-go/utils/unittest"
+)
+
+type ExecutionNodesSuite struct {
+	suite.Suite
+
+	state       *protocol.State
+	receipts    *storagemock.ExecutionReceipts
+	staticRPC   *access.ExecutionAPIClient
+	connFactory *connmock.ConnectionFactory
+	metrics     *modulemock.ExecutionNodeQueryMetrics
+
+	header     *flow.Header
+	executors  flow.IdentityList
+	execClient map[flow.Identifier]*access.ExecutionAPIClient
+}
+
+func TestExecutionNodes(t *testing.T) {
+	suite.Run(t, new(ExecutionNodesSuite))
+}
+
+func (suite *ExecutionNodesSuite) SetupTest() {
+	suite.state = new(protocol.State)
+	suite.receipts = new(storagemock.ExecutionReceipts)
+	suite.staticRPC = new(access.ExecutionAPIClient)
+	suite.connFactory = new(connmock.ConnectionFactory)
+	suite.metrics = new(modulemock.ExecutionNodeQueryMetrics)
+
+	header := unittest.BlockHeaderFixture()
+	suite.header = &header
+
+	// all execution nodes produced a receipt for the block
+	suite.executors = unittest.IdentityListFixture(3, unittest.WithRole(flow.RoleExecution))
+	suite.receipts.On("ExecutorsByBlockID", header.ID()).Return(suite.executors.NodeIDs(), nil).Maybe()
+	suite.execClient = make(map[flow.Identifier]*access.ExecutionAPIClient)
+	for i, executor := range suite.executors {
+		executor.Address = fmt.Sprintf("execution-%d:3569", i)
+
+		client := new(access.ExecutionAPIClient)
+		suite.execClient[executor.NodeID] = client
+		suite.connFactory.
+			On("GetExecutionAPIClient", fmt.Sprintf("execution-%d:9000", i)).
+			Return(client, nil, nil).
+			Maybe()
+	}
+
+	finalSnapshot := new(protocol.Snapshot)
+	finalSnapshot.On("Identities", mock.Anything).Return(
+		func(selector flow.IdentityFilter) flow.IdentityList {
+			return suite.executors.Filter(selector)
+		},
+		nil,
+	)
+	suite.state.On("Final").Return(finalSnapshot)
+}
+
+func (suite *ExecutionNodesSuite) executionNodes(queryCount uint) *executionNodes {
+	return newExecutionNodes(
+		suite.state,
+		suite.receipts,
+		suite.staticRPC,
+		suite.connFactory,
+		9000,
+		queryCount,
+		suite.metrics,
+	)
+}
+
+// expectScriptResult expects the script to be executed on the given execution node, returning the given value
+func (suite *ExecutionNodesSuite) expectScriptResult(executor *flow.Identity, value []byte) {
+	suite.execClient[executor.NodeID].
+		On("ExecuteScriptAtBlockID", mock.Anything, mock.Anything).
+		Return(&execproto.ExecuteScriptAtBlockIDResponse{Value: value}, nil).
+		Maybe()
+}
+
+func (suite *ExecutionNodesSuite) executeScript(nodes *executionNodes) ([]byte, error) {
+	ctx := context.Background()
+	resp, err := nodes.query(ctx, "execute_script", []flow.Identifier{suite.header.ID()},
+		func(ctx context.Context, client execproto.ExecutionAPIClient) (proto.Message, error) {
+			return client.ExecuteScriptAtBlockID(ctx, &execproto.ExecuteScriptAtBlockIDRequest{})
+		})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*execproto.ExecuteScriptAtBlockIDResponse).GetValue(), nil
+}
+
+// TestQueryCrossChecked tests that the request is sent to the execution nodes which produced a receipt,
+// until enough of them returned the same response.
+func (suite *ExecutionNodesSuite) TestQueryCrossChecked() {
+	for _, executor := range suite.executors {
+		suite.expectScriptResult(executor, []byte("value"))
+	}
+
+	value, err := suite.executeScript(suite.executionNodes(2))
+	suite.Require().NoError(err)
+	suite.Assert().Equal([]byte("value"), value)
+
+	calls := 0
+	for _, client := range suite.execClient {
+		calls += len(client.Calls)
+	}
+	suite.Assert().Equal(2, calls)
+	suite.staticRPC.AssertNotCalled(suite.T(), "ExecuteScriptAtBlockID", mock.Anything, mock.Anything)
+	suite.metrics.AssertNotCalled(suite.T(), "ExecutionNodeResponseMismatch", mock.Anything)
+}
+
+// TestQueryMismatch tests that a response differing from the responses of the other execution nodes is
+// reported, and the response of the majority is returned.
+func (suite *ExecutionNodesSuite) TestQueryMismatch() {
+	suite.expectScriptResult(suite.executors[0], []byte("value"))
+	suite.expectScriptResult(suite.executors[1], []byte("wrong value"))
+	suite.expectScriptResult(suite.executors[2], []byte("value"))
+	suite.metrics.On("ExecutionNodeResponseMismatch", "execute_script").Maybe()
+
+	value, err := suite.executeScript(suite.executionNodes(2))
+	suite.Require().NoError(err)
+	suite.Assert().Equal([]byte("value"), value)
+
+}
+
+// TestQueryDisagreement tests that an error is returned, if the execution nodes run out before
+// enough of them returned the same response.
+func (suite *ExecutionNodesSuite) TestQueryDisagreement() {
+	suite.executors = suite.executors[:2]
+	suite.expectScriptResult(suite.executors[0], []byte("value"))
+	suite.expectScriptResult(suite.executors[1], []byte("wrong value"))
+	suite.metrics.On("ExecutionNodeResponseMismatch", "execute_script").Once()
+
+	_, err := suite.executeScript(suite.executionNodes(2))
+	suite.Require().Error(err)
+	suite.Assert().Equal(codes.Internal, status.Code(err))
+	suite.metrics.AssertExpectations(suite.T())
+}
+
+// TestQueryFailover tests that a failed request is forwarded to the next execution node.
+func (suite *ExecutionNodesSuite) TestQueryFailover() {
+	suite.executors = suite.executors[:2]
+	suite.execClient[suite.executors[0].NodeID].
+		On("ExecuteScriptAtBlockID", mock.Anything, mock.Anything).
+		Return(nil, status.Error(codes.Unavailable, "unavailable"))
+	suite.expectScriptResult(suite.executors[1], []byte("value"))
+	suite.metrics.On("ExecutionNodeQueryFailed", "execute_script").Once()
+
+	value, err := suite.executeScript(suite.executionNodes(2))
+	suite.Require().NoError(err)
+	suite.Assert().Equal([]byte("value"), value)
+	suite.metrics.AssertExpectations(suite.T())
+}
+
+// TestQueryParallel tests that the request is sent to the execution nodes in parallel.
+func (suite *ExecutionNodesSuite) TestQueryParallel() {
+	suite.executors = suite.executors[:2]
+
+	// each execution node only responds once both received the request
+	var received sync.WaitGroup
+	received.Add(len(suite.executors))
+	for _, executor := range suite.executors {
+		suite.execClient[executor.NodeID].
+			On("ExecuteScriptAtBlockID", mock.Anything, mock.Anything).
+			Run(func(mock.Arguments) {
+				received.Done()
+				received.Wait()
+			}).
+			Return(&execproto.ExecuteScriptAtBlockIDResponse{Value: []byte("value")}, nil)
+	}
+
+	value, err := suite.executeScript(suite.executionNodes(2))
+	suite.Require().NoError(err)
+	suite.Assert().Equal([]byte("value"), value)
+}
+
+// TestQueryTimeout tests that a request, which an execution node does not respond to in time, is forwarded
+// to the next execution node.
+func (suite *ExecutionNodesSuite) TestQueryTimeout() {
+	suite.execClient[suite.executors[0].NodeID].
+		On("ExecuteScriptAtBlockID", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			<-args.Get(0).(context.Context).Done()
+		}).
+		Return(nil, status.Error(codes.DeadlineExceeded, "deadline exceeded")).
+		Maybe()
+	suite.expectScriptResult(suite.executors[1], []byte("value"))
+	suite.expectScriptResult(suite.executors[2], []byte("value"))
+	suite.metrics.On("ExecutionNodeQueryFailed", "execute_script").Maybe()
+
+	nodes := suite.executionNodes(2)
+	nodes.timeout = 10 * time.Millisecond
+
+	value, err := suite.executeScript(nodes)
+	suite.Require().NoError(err)
+	suite.Assert().Equal([]byte("value"), value)
+}
+
+// TestQueryWithoutReceipts tests that requests are forwarded to the statically configured execution node,
+// if no receipt for the block is known, or cross-checking is disabled.
+func (suite *ExecutionNodesSuite) TestQueryWithoutReceipts() {
+	suite.staticRPC.
+		On("ExecuteScriptAtBlockID", mock.Anything, mock.Anything).
+		Return(&execproto.ExecuteScriptAtBlockIDResponse{Value: []byte("value")}, nil)
+
+	suite.Run("cross-checking disabled", func() {
+		value, err := suite.executeScript(suite.executionNodes(0))
+		suite.Require().NoError(err)
+		suite.Assert().Equal([]byte("value"), value)
+	})
+
+	suite.Run("no receipts", func() {
+		suite.receipts = new(storagemock.ExecutionReceipts)
+		suite.receipts.On("ExecutorsByBlockID", suite.header.ID()).Return(nil, nil)
+
+		value, err := suite.executeScript(suite.executionNodes(2))
+		suite.Require().NoError(err)
+		suite.Assert().Equal([]byte("value"), value)
+	})
+
+	suite.staticRPC.AssertNumberOfCalls(suite.T(), "ExecuteScriptAtBlockID", 2)
+}
+
+// TestClose tests that the connections of all pooled clients are closed, and that clients reconnect afterwards.
+func (suite *ExecutionNodesSuite) TestClose() {
+	closers := make(map[flow.Identifier]*connCloser)
+	suite.connFactory = new(connmock.ConnectionFactory)
+	for i, executor := range suite.executors {
+		closers[executor.NodeID] = &connCloser{}
+		suite.connFactory.
+			On("GetExecutionAPIClient", fmt.Sprintf("execution-%d:9000", i)).
+			Return(suite.execClient[executor.NodeID], closers[executor.NodeID], nil)
+		suite.expectScriptResult(executor, []byte("value"))
+	}
+
+	nodes := suite.executionNodes(uint(len(suite.executors)))
+	_, err := suite.executeScript(nodes)
+	suite.Require().NoError(err)
+
+	err = nodes.close()
+	suite.Require().NoError(err)
+	for _, closer := range closers {
+		suite.Assert().Equal(1, closer.closed)
+	}
+
+	_, err = suite.executeScript(nodes)
+	suite.Require().NoError(err)
+	suite.connFactory.AssertNumberOfCalls(suite.T(), "GetExecutionAPIClient", 2*len(suite.executors))
+}
+
+// connCloser counts how often a connection was closed
+type connCloser struct {
+	closed int
+}
+
+func (c *connCloser) Close() error {
+	c.closed++
+	return nil
+}
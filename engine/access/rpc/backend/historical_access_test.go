@@ -37,21 +37,19 @@ func (suite *Suite) TestHistoricalTransactionResult() {
 		Events: nil,
 	}
 
-	backend := New(
-		suite.state,
-		suite.execClient,
-		nil,
-		[]accessproto.AccessAPIClient{suite.historicalAccessClient},
-		suite.blocks,
-		suite.headers,
-		suite.collections,
-		suite.transactions,
-		suite.chainID,
-		metrics.NewNoopCollector(),
-		0,
-		nil,
-		false,
-	)
+	backend := New(Params{
+		State:                     suite.state,
+		ExecutionRPC:              suite.execClient,
+		HistoricalAccessNodes:     []accessproto.AccessAPIClient{suite.historicalAccessClient},
+		Blocks:                    suite.blocks,
+		Headers:                   suite.headers,
+		Collections:               suite.collections,
+		Transactions:              suite.transactions,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
 
 	// Successfully return the transaction from the historical node
 	suite.historicalAccessClient.
@@ -90,21 +88,19 @@ func (suite *Suite) TestHistoricalTransaction() {
 		Transaction: convert.TransactionToMessage(*transactionBody),
 	}
 
-	backend := New(
-		suite.state,
-		suite.execClient,
-		nil,
-		[]accessproto.AccessAPIClient{suite.historicalAccessClient},
-		suite.blocks,
-		suite.headers,
-		suite.collections,
-		suite.transactions,
-		suite.chainID,
-		metrics.NewNoopCollector(),
-		0,
-		nil,
-		false,
-	)
+	backend := New(Params{
+		State:                     suite.state,
+		ExecutionRPC:              suite.execClient,
+		HistoricalAccessNodes:     []accessproto.AccessAPIClient{suite.historicalAccessClient},
+		Blocks:                    suite.blocks,
+		Headers:                   suite.headers,
+		Collections:               suite.collections,
+		Transactions:              suite.transactions,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
 
 	// Successfully return the transaction from the historical node
 	suite.historicalAccessClient.
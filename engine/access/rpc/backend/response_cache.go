@@ -0,0 +1,161 @@
+package backend
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/simplelru"
+
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/module"
+	"github.com/onflow/flow-go/state/protocol"
+	"github.com/onflow/flow-go/storage"
+)
+
+// ResponseCacheConfig configures the cache of responses for immutable data.
+type ResponseCacheConfig struct {
+	Size uint          // maximum number of cached responses, 0 disables the cache
+	TTL  time.Duration // time after which cached responses expire, 0 if they never expire
+}
+
+// responseKey identifies a response by the method and the request it was returned for.
+type responseKey struct {
+	resource string
+	request  [sha256.Size]byte
+}
+
+// newResponseKey returns the key of the response of the method (reported as resource to the metrics)
+// to the request consisting of the given parts.
+func newResponseKey(resource string, parts ...[]byte) responseKey {
+	hasher := sha256.New()
+	for _, part := range parts {
+		// prefix every part with its length, so that the boundaries between parts are unambiguous
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(part)))
+		_, _ = hasher.Write(length[:])
+		_, _ = hasher.Write(part)
+	}
+
+	key := responseKey{resource: resource}
+	copy(key.request[:], hasher.Sum(nil))
+	return key
+}
+
+type cachedResponse struct {
+	response interface{}
+	expiry   time.Time
+}
+
+// responseCache is an LRU cache of responses to requests for data that can no longer change, such as
+// the results of scripts executed at sealed blocks. Only the responses for sealed heights are cached,
+// as the execution state of blocks that are not sealed yet may still turn out to be different.
+type responseCache struct {
+	state   protocol.State
+	headers storage.Headers
+	metrics module.CacheMetrics
+	ttl     time.Duration
+	now     func() time.Time
+
+	mu      sync.Mutex
+	cache   *simplelru.LRU // nil if the cache is disabled
+	entries map[string]uint
+}
+
+func newResponseCache(
+	config ResponseCacheConfig,
+	state protocol.State,
+	headers storage.Headers,
+	metrics module.CacheMetrics,
+) *responseCache {
+
+	c := &responseCache{
+		state:   state,
+		headers: headers,
+		metrics: metrics,
+		ttl:     config.TTL,
+		now:     time.Now,
+		entries: make(map[string]uint),
+	}
+	if config.Size == 0 {
+		return c
+	}
+
+	// the entries are counted per resource, to report the number of cached responses of each method
+	c.cache, _ = simplelru.NewLRU(int(config.Size), func(key interface{}, _ interface{}) {
+		resource := key.(responseKey).resource
+		c.entries[resource]--
+		c.metrics.CacheEntries(resource, c.entries[resource])
+	})
+	return c
+}
+
+// get returns the cached response for the key, if there is one which has not expired yet.
+func (c *responseCache) get(key responseKey) (interface{}, bool) {
+	if c.cache == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, ok := c.cache.Get(key)
+	if ok && c.ttl > 0 && c.now().After(value.(cachedResponse).expiry) {
+		c.cache.Remove(key)
+		ok = false
+	}
+	if !ok {
+		c.metrics.CacheMiss(key.resource)
+		return nil, false
+	}
+
+	c.metrics.CacheHit(key.resource)
+	return value.(cachedResponse).response, true
+}
+
+// put caches the response for the key, if the block at the given height is sealed.
+func (c *responseCache) put(key responseKey, height uint64, response interface{}) error {
+	if c.cache == nil {
+		return nil
+	}
+
+	sealed, err := c.state.Sealed().Head()
+	if err != nil {
+		return fmt.Errorf("could not get latest sealed header: %w", err)
+	}
+	if height > sealed.Height {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value := cachedResponse{
+		response: response,
+		expiry:   c.now().Add(c.ttl),
+	}
+	exists := c.cache.Contains(key)
+	c.cache.Add(key, value)
+	if !exists {
+		c.entries[key.resource]++
+		c.metrics.CacheEntries(key.resource, c.entries[key.resource])
+	}
+
+	return nil
+}
+
+// putAtBlockID caches the response for the key, if the block with the given ID is sealed.
+func (c *responseCache) putAtBlockID(key responseKey, blockID flow.Identifier, response interface{}) error {
+	if c.cache == nil {
+		return nil
+	}
+
+	header, err := c.headers.ByBlockID(blockID)
+	if err != nil {
+		return fmt.Errorf("could not get header: %w", err)
+	}
+
+	return c.put(key, header.Height, response)
+}
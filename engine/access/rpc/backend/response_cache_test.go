@@ -0,0 +1,125 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/module/metrics"
+	modulemock "github.com/onflow/flow-go/module/mock"
+	protocol "github.com/onflow/flow-go/state/protocol/mock"
+	storagemock "github.com/onflow/flow-go/storage/mock"
+	"github.com/onflow/flow-go/utils/unittest"
+)
+
+// newSealedState returns a protocol state whose latest sealed block is at the given height
+func newSealedState(height uint64) *protocol.State {
+	header := unittest.BlockHeaderFixture()
+	header.Height = height
+
+	snapshot := new(protocol.Snapshot)
+	snapshot.On("Head").Return(&header, nil)
+	state := new(protocol.State)
+	state.On("Sealed").Return(snapshot)
+	return state
+}
+
+func TestResponseKey(t *testing.T) {
+	// the boundaries between the parts of a request are part of the key
+	assert.NotEqual(t,
+		newResponseKey(metrics.ResourceScriptResult, []byte("ab"), []byte("c")),
+		newResponseKey(metrics.ResourceScriptResult, []byte("a"), []byte("bc")),
+	)
+	// responses of different methods never collide
+	assert.NotEqual(t,
+		newResponseKey(metrics.ResourceScriptResult, []byte("a")),
+		newResponseKey(metrics.ResourceAccount, []byte("a")),
+	)
+	assert.Equal(t,
+		newResponseKey(metrics.ResourceScriptResult, []byte("a")),
+		newResponseKey(metrics.ResourceScriptResult, []byte("a")),
+	)
+}
+
+func TestResponseCache(t *testing.T) {
+	key := newResponseKey(metrics.ResourceScriptResult, []byte("script"))
+
+	t.Run("only responses for sealed heights are cached", func(t *testing.T) {
+		cache := newResponseCache(ResponseCacheConfig{Size: 10}, newSealedState(10), nil, metrics.NewNoopCollector())
+
+		require.NoError(t, cache.put(key, 11, []byte("unsealed")))
+		_, ok := cache.get(key)
+		assert.False(t, ok)
+
+		require.NoError(t, cache.put(key, 10, []byte("sealed")))
+		response, ok := cache.get(key)
+		require.True(t, ok)
+		assert.Equal(t, []byte("sealed"), response)
+	})
+
+	t.Run("responses at block IDs", func(t *testing.T) {
+		header := unittest.BlockHeaderFixture()
+		header.Height = 11
+		headers := new(storagemock.Headers)
+		headers.On("ByBlockID", header.ID()).Return(&header, nil)
+
+		cache := newResponseCache(ResponseCacheConfig{Size: 10}, newSealedState(10), headers, metrics.NewNoopCollector())
+
+		require.NoError(t, cache.putAtBlockID(key, header.ID(), []byte("unsealed")))
+		_, ok := cache.get(key)
+		assert.False(t, ok)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		// neither the protocol state nor the metrics are used by a disabled cache
+		cache := newResponseCache(ResponseCacheConfig{}, nil, nil, nil)
+
+		require.NoError(t, cache.put(key, 0, []byte("response")))
+		require.NoError(t, cache.putAtBlockID(key, flow.ZeroID, []byte("response")))
+		_, ok := cache.get(key)
+		assert.False(t, ok)
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		now := time.Now()
+		cache := newResponseCache(ResponseCacheConfig{Size: 10, TTL: time.Minute}, newSealedState(10), nil, metrics.NewNoopCollector())
+		cache.now = func() time.Time { return now }
+
+		require.NoError(t, cache.put(key, 10, []byte("response")))
+
+		now = now.Add(time.Minute)
+		_, ok := cache.get(key)
+		assert.True(t, ok)
+
+		now = now.Add(time.Second)
+		_, ok = cache.get(key)
+		assert.False(t, ok)
+		assert.Equal(t, 0, cache.cache.Len())
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		accountKey := newResponseKey(metrics.ResourceAccount, []byte("address"))
+
+		collector := new(modulemock.CacheMetrics)
+		collector.On("CacheMiss", metrics.ResourceScriptResult).Once()
+		collector.On("CacheEntries", metrics.ResourceScriptResult, uint(1)).Once()
+		collector.On("CacheHit", metrics.ResourceScriptResult).Once()
+		// adding the account evicts the least recently used script result
+		collector.On("CacheEntries", metrics.ResourceScriptResult, uint(0)).Once()
+		collector.On("CacheEntries", metrics.ResourceAccount, uint(1)).Once()
+
+		cache := newResponseCache(ResponseCacheConfig{Size: 1}, newSealedState(10), nil, collector)
+
+		_, ok := cache.get(key)
+		assert.False(t, ok)
+		require.NoError(t, cache.put(key, 10, []byte("response")))
+		_, ok = cache.get(key)
+		assert.True(t, ok)
+		require.NoError(t, cache.put(accountKey, 10, &flow.Account{}))
+
+		collector.AssertExpectations(t)
+	})
+}
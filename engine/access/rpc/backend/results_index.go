@@ -0,0 +1,109 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/storage"
+)
+
+// resultsIndex provides the events and transaction results of sealed blocks, which were indexed
+// locally by the access node, so that they don't need to be requested from an execution node.
+// A nil resultsIndex is valid and contains no blocks.
+type resultsIndex struct {
+	blocks             storage.Blocks
+	headers            storage.Headers
+	events             storage.Events
+	transactionResults storage.TransactionResults
+}
+
+// newResultsIndex returns the index of the given storages, or nil if events or transaction results are not stored.
+func newResultsIndex(
+	blocks storage.Blocks,
+	headers storage.Headers,
+	events storage.Events,
+	transactionResults storage.TransactionResults,
+) *resultsIndex {
+	if events == nil || transactionResults == nil {
+		return nil
+	}
+	return &resultsIndex{
+		blocks:             blocks,
+		headers:            headers,
+		events:             events,
+		transactionResults: transactionResults,
+	}
+}
+
+// partition splits the given blocks into the blocks which are indexed and the blocks which are not,
+// preserving their order.
+func (r *resultsIndex) partition(headers []*flow.Header) ([]*flow.Header, []*flow.Header, error) {
+	if r == nil {
+		return nil, headers, nil
+	}
+
+	lastHeight, err := r.blocks.GetLastIndexedBlockHeight()
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, headers, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var indexed, remaining []*flow.Header
+	for _, header := range headers {
+		ok, err := r.contains(header, lastHeight)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			indexed = append(indexed, header)
+		} else {
+			remaining = append(remaining, header)
+		}
+	}
+
+	return indexed, remaining, nil
+}
+
+// contains returns whether the given block is indexed, given the height of the last indexed block.
+func (r *resultsIndex) contains(header *flow.Header, lastHeight uint64) (bool, error) {
+	if header.Height > lastHeight {
+		return false, nil
+	}
+
+	// only finalized blocks are indexed, a block of a different fork is never executed
+	finalized, err := r.headers.ByHeight(header.Height)
+	if err != nil {
+		return false, fmt.Errorf("could not get finalized block at height %d: %w", header.Height, err)
+	}
+
+	return finalized.ID() == header.ID(), nil
+}
+
+// transactionResult returns the indexed result and events of the transaction in the given block.
+// It returns false if the block is not indexed, or the result of the transaction is missing in the index.
+func (r *resultsIndex) transactionResult(header *flow.Header, txID flow.Identifier) (*flow.TransactionResult, []flow.Event, bool, error) {
+	indexed, _, err := r.partition([]*flow.Header{header})
+	if err != nil || len(indexed) == 0 {
+		return nil, nil, false, err
+	}
+
+	blockID := header.ID()
+
+	result, err := r.transactionResults.ByBlockIDTransactionID(blockID, txID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, false, nil
+	}
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("could not get transaction result: %w", err)
+	}
+
+	events, err := r.events.ByBlockIDTransactionID(blockID, txID)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("could not get transaction events: %w", err)
+	}
+
+	return result, events, true, nil
+}
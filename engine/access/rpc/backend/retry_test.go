@@ -40,8 +40,19 @@ func (suite *Suite) TestTransactionRetry() {
 	// txID := transactionBody.ID()
 	// blockID := block.ID()
 	// Setup Handler + Retry
-	backend := New(suite.state, suite.execClient, suite.colClient, nil, suite.blocks, suite.headers,
-		suite.collections, suite.transactions, suite.chainID, metrics.NewNoopCollector(), 0, nil, false)
+	backend := New(Params{
+		State:                     suite.state,
+		ExecutionRPC:              suite.execClient,
+		CollectionRPC:             suite.colClient,
+		Blocks:                    suite.blocks,
+		Headers:                   suite.headers,
+		Collections:               suite.collections,
+		Transactions:              suite.transactions,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
 	retry := newRetry().SetBackend(backend).Activate()
 	backend.retry = retry
 
@@ -98,8 +109,19 @@ func (suite *Suite) TestSuccessfulTransactionsDontRetry() {
 	}
 
 	// Setup Handler + Retry
-	backend := New(suite.state, suite.execClient, suite.colClient, nil, suite.blocks, suite.headers,
-		suite.collections, suite.transactions, suite.chainID, metrics.NewNoopCollector(), 0, nil, false)
+	backend := New(Params{
+		State:                     suite.state,
+		ExecutionRPC:              suite.execClient,
+		CollectionRPC:             suite.colClient,
+		Blocks:                    suite.blocks,
+		Headers:                   suite.headers,
+		Collections:               suite.collections,
+		Transactions:              suite.transactions,
+		ChainID:                   suite.chainID,
+		TransactionMetrics:        metrics.NewNoopCollector(),
+		ExecutionNodeQueryMetrics: metrics.NewNoopCollector(),
+		CacheMetrics:              metrics.NewNoopCollector(),
+	})
 	retry := newRetry().SetBackend(backend).Activate()
 	backend.retry = retry
 
@@ -108,7 +130,7 @@ func (suite *Suite) TestSuccessfulTransactionsDontRetry() {
 	suite.colClient.On("SendTransaction", mock.Anything, mock.Anything).Return(&access.SendTransactionResponse{}, nil)
 
 	// return not found to return finalized status
-	suite.execClient.On("GetTransactionResult", ctx, &exeEventReq).Return(&exeEventResp, status.Errorf(codes.NotFound, "not found")).Once()
+	suite.execClient.On("GetTransactionResult", mock.Anything, &exeEventReq).Return(&exeEventResp, status.Errorf(codes.NotFound, "not found")).Once()
 	// first call - when block under test is greater height than the sealed head, but execution node does not know about Tx
 	result, err := backend.GetTransactionResult(ctx, txID)
 	suite.checkResponse(result, err)
@@ -6,34 +6,46 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"time"
 
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/rs/zerolog"
 	"google.golang.org/grpc"
 
 	accessproto "github.com/onflow/flow/protobuf/go/flow/access"
-	execproto "github.com/onflow/flow/protobuf/go/flow/execution"
 	legacyaccessproto "github.com/onflow/flow/protobuf/go/flow/legacy/access"
 
 	"github.com/onflow/flow-go/access"
 	legacyaccess "github.com/onflow/flow-go/access/legacy"
 	"github.com/onflow/flow-go/engine"
+	"github.com/onflow/flow-go/engine/access/rest"
 	"github.com/onflow/flow-go/engine/access/rpc/backend"
+	"github.com/onflow/flow-go/engine/access/rpc/ratelimit"
 	"github.com/onflow/flow-go/model/flow"
 	"github.com/onflow/flow-go/module"
-	"github.com/onflow/flow-go/state/protocol"
-	"github.com/onflow/flow-go/storage"
 	grpcutils "github.com/onflow/flow-go/utils/grpc"
 )
 
+// restShutdownTimeout is how long the REST server waits for active requests to complete when
+// shutting down, before closing their connections.
+const restShutdownTimeout = 5 * time.Second
+
 // Config defines the configurable options for the access node server
 type Config struct {
 	GRPCListenAddr        string
 	HTTPListenAddr        string
+	RESTListenAddr        string // address of the REST API server, disabled if empty
 	ExecutionAddr         string
 	CollectionAddr        string
 	HistoricalAccessAddrs string
-	MaxMsgSize            int // In bytes
+	MaxMsgSize            int  // In bytes
+	ExecutionGRPCPort     uint // gRPC port of all execution nodes
+	ExecutionQueryCount   uint // number of execution nodes to cross-check responses with, 0 to only use ExecutionAddr
+
+	// RateLimits are the per-client rate limits of the gRPC and REST APIs
+	RateLimits ratelimit.Config
+	// ResponseCache configures the cache of responses for immutable data
+	ResponseCache backend.ResponseCacheConfig
 }
 
 // Engine implements a gRPC server with a simplified version of the Observation API.
@@ -43,24 +55,16 @@ type Engine struct {
 	backend    *backend.Backend // the gRPC service implementation
 	grpcServer *grpc.Server     // the gRPC server
 	httpServer *http.Server
+	restServer *http.Server // the REST API server, nil if disabled
 	config     Config
 }
 
-// New returns a new RPC engine.
+// New returns a new RPC engine serving the backend with the given params. The execution node options and
+// the response cache of the backend are configured by the config.
 func New(log zerolog.Logger,
-	state protocol.State,
 	config Config,
-	executionRPC execproto.ExecutionAPIClient,
-	collectionRPC accessproto.AccessAPIClient,
-	historicalAccessNodes []accessproto.AccessAPIClient,
-	blocks storage.Blocks,
-	headers storage.Headers,
-	collections storage.Collections,
-	transactions storage.Transactions,
-	chainID flow.ChainID,
-	transactionMetrics module.TransactionMetrics,
-	collectionGRPCPort uint,
-	retryEnabled bool,
+	backendParams backend.Params,
+	rateLimitMetrics module.RateLimitMetrics,
 	rpcMetricsEnabled bool,
 ) *Engine {
 
@@ -75,34 +79,37 @@ func New(log zerolog.Logger,
 		grpc.MaxRecvMsgSize(config.MaxMsgSize),
 		grpc.MaxSendMsgSize(config.MaxMsgSize),
 	}
+	var unaryInterceptors []grpc.UnaryServerInterceptor
+	var streamInterceptors []grpc.StreamServerInterceptor
 	if rpcMetricsEnabled {
-		grpcOpts = append(
-			grpcOpts,
-			grpc.StreamInterceptor(grpc_prometheus.StreamServerInterceptor),
-			grpc.UnaryInterceptor(grpc_prometheus.UnaryServerInterceptor),
-		)
+		unaryInterceptors = append(unaryInterceptors, grpc_prometheus.UnaryServerInterceptor)
+		streamInterceptors = append(streamInterceptors, grpc_prometheus.StreamServerInterceptor)
 	}
+	// rate limit after the metrics interceptors, so that rejected calls are counted as well.
+	// The REST API shares the limiter, so that clients have the same limits on both APIs.
+	var limiter *ratelimit.Limiter
+	if config.RateLimits.Enabled() {
+		limiter = ratelimit.NewLimiter(config.RateLimits, rateLimitMetrics)
+		unaryInterceptors = append(unaryInterceptors, limiter.UnaryServerInterceptor)
+		streamInterceptors = append(streamInterceptors, limiter.StreamServerInterceptor)
+	}
+	grpcOpts = append(
+		grpcOpts,
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+	)
 
 	grpcServer := grpc.NewServer(grpcOpts...)
 
 	// wrap the GRPC server with an HTTP proxy server to serve HTTP clients
 	httpServer := NewHTTPServer(grpcServer, config.HTTPListenAddr)
 
-	backend := backend.New(
-		state,
-		executionRPC,
-		collectionRPC,
-		historicalAccessNodes,
-		blocks,
-		headers,
-		collections,
-		transactions,
-		chainID,
-		transactionMetrics,
-		collectionGRPCPort,
-		&backend.ConnectionFactoryImpl{},
-		retryEnabled,
-	)
+	// the backend connects to the execution nodes with the configured options
+	backendParams.ExecutionGRPCPort = config.ExecutionGRPCPort
+	backendParams.ExecutionQueryCount = config.ExecutionQueryCount
+	backendParams.ResponseCache = config.ResponseCache
+	backendParams.ConnFactory = &backend.ConnectionFactoryImpl{}
+	backend := backend.New(backendParams)
 
 	eng := &Engine{
 		log:        log,
@@ -113,10 +120,13 @@ func New(log zerolog.Logger,
 		config:     config,
 	}
 
-	accessproto.RegisterAccessAPIServer(
-		eng.grpcServer,
-		access.NewHandler(backend, chainID.Chain()),
-	)
+	handler := access.NewHandler(backend, backendParams.ChainID.Chain())
+	accessproto.RegisterAccessAPIServer(eng.grpcServer, handler)
+	access.RegisterExtensionsServer(eng.grpcServer, handler)
+
+	if config.RESTListenAddr != "" {
+		eng.restServer = rest.NewServer(backend, backendParams.ChainID.Chain(), config.RESTListenAddr, limiter, log)
+	}
 
 	if rpcMetricsEnabled {
 		// Not interested in legacy metrics, so initialize here
@@ -126,7 +136,7 @@ func New(log zerolog.Logger,
 	// Register legacy gRPC handlers for backwards compatibility, to be removed at a later date
 	legacyaccessproto.RegisterAccessAPIServer(
 		eng.grpcServer,
-		legacyaccess.NewHandler(backend, chainID.Chain()),
+		legacyaccess.NewHandler(backend, backendParams.ChainID.Chain()),
 	)
 
 	return eng
@@ -138,11 +148,15 @@ func New(log zerolog.Logger,
 func (e *Engine) Ready() <-chan struct{} {
 	e.unit.Launch(e.serveGRPC)
 	e.unit.Launch(e.serveGRPCWebProxy)
+	if e.restServer != nil {
+		e.unit.Launch(e.serveREST)
+	}
 	return e.unit.Ready()
 }
 
 // Done returns a done channel that is closed once the engine has fully stopped.
-// It sends a signal to stop the gRPC server, then closes the channel.
+// It sends a signal to stop the gRPC server, closes the connections to the execution nodes,
+// then closes the channel.
 func (e *Engine) Done() <-chan struct{} {
 	return e.unit.Done(
 		e.grpcServer.GracefulStop,
@@ -151,6 +165,27 @@ func (e *Engine) Done() <-chan struct{} {
 			if err != nil {
 				e.log.Error().Err(err).Msg("error stopping http server")
 			}
+		},
+		func() {
+			if e.restServer == nil {
+				return
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), restShutdownTimeout)
+			defer cancel()
+			err := e.restServer.Shutdown(ctx)
+			if err != nil {
+				e.log.Warn().Err(err).Msg("could not stop rest server gracefully, closing connections")
+				err = e.restServer.Close()
+			}
+			if err != nil {
+				e.log.Error().Err(err).Msg("error stopping rest server")
+			}
+		},
+		func() {
+			err := e.backend.Close()
+			if err != nil {
+				e.log.Error().Err(err).Msg("error closing execution node connections")
+			}
 		})
 }
 
@@ -171,6 +206,7 @@ func (e *Engine) process(event interface{}) error {
 	switch entity := event.(type) {
 	case *flow.Block:
 		e.backend.NotifyFinalizedBlockHeight(entity.Header.Height)
+		e.backend.NotifyFinalizedBlock()
 		return nil
 	default:
 		return fmt.Errorf("invalid event type (%T)", event)
@@ -210,3 +246,18 @@ func (e *Engine) serveGRPCWebProxy() {
 		e.log.Err(err).Msg("failed to start the http proxy server")
 	}
 }
+
+// serveREST starts the REST API server
+func (e *Engine) serveREST() {
+	log := e.log.With().Str("rest_api_address", e.config.RESTListenAddr).Logger()
+
+	log.Info().Msg("starting rest api server on address")
+
+	err := e.restServer.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return
+	}
+	if err != nil {
+		e.log.Err(err).Msg("failed to start the rest api server")
+	}
+}
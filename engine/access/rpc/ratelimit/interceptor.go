@@ -0,0 +1,121 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// APIKeyHeader is the metadata key, or HTTP header, of the API key identifying a client. Clients without
+// a configured API key are identified by their IP address.
+const APIKeyHeader = "x-api-key"
+
+// UnaryServerInterceptor rejects unary calls exceeding the limits of the client with ResourceExhausted,
+// and charges the execution time of scripts to the compute budget of the client.
+func (l *Limiter) UnaryServerInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+
+	var resp interface{}
+	err := l.Call(l.clientID(ctx), methodName(info.FullMethod), func() error {
+		var err error
+		resp, err = handler(ctx, req)
+		return err
+	})
+
+	return resp, err
+}
+
+// StreamServerInterceptor rejects streams exceeding the limits of the client with ResourceExhausted.
+// Only opening a stream is limited, not the messages sent on it.
+func (l *Limiter) StreamServerInterceptor(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+
+	return l.Call(l.clientID(stream.Context()), methodName(info.FullMethod), func() error {
+		return handler(srv, stream)
+	})
+}
+
+// Call calls the method with the given function, unless the call exceeds the limits of the client,
+// in which case a ResourceExhausted status error is returned. The execution time of scripts is charged
+// to the compute budget of the client.
+func (l *Limiter) Call(client string, method string, call func() error) error {
+	allowed, limit := l.Allow(client, method)
+	if !allowed {
+		return status.Errorf(codes.ResourceExhausted, "%s rate limit of %s exceeded", limit, method)
+	}
+
+	if !isScriptMethod(method) {
+		return call()
+	}
+
+	start := l.now()
+	err := call()
+	l.ChargeCompute(client, l.now().Sub(start))
+
+	return err
+}
+
+// clientID returns the identity of the calling client: its API key if it sent a configured one, its IP
+// address otherwise.
+func (l *Limiter) clientID(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok {
+		keys := md.Get(APIKeyHeader)
+		if len(keys) > 0 && l.knownKey(keys[0]) {
+			return "key:" + keys[0]
+		}
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+	addr := p.Addr.String()
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "ip:" + addr
+	}
+	return "ip:" + host
+}
+
+// HTTPClientID returns the identity of the client of an HTTP request: its API key if it sent a configured
+// one in the x-api-key header, its IP address otherwise.
+func (l *Limiter) HTTPClientID(req *http.Request) string {
+	key := req.Header.Get(APIKeyHeader)
+	if l.knownKey(key) {
+		return "key:" + key
+	}
+
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return "ip:" + req.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// knownKey returns whether the API key is configured.
+func (l *Limiter) knownKey(key string) bool {
+	_, ok := l.apiKeys[key]
+	return ok
+}
+
+// methodName returns the name of the method of a full gRPC method name, e.g. "GetAccount" for
+// "/flow.access.AccessAPI/GetAccount".
+func methodName(fullMethod string) string {
+	return fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+}
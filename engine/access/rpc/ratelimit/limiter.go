@@ -0,0 +1,287 @@
+// Package ratelimit implements per-client rate limits of the gRPC and REST APIs of access nodes.
+//
+// Every client, identified by its API key if the key is configured or else by its IP address, has a
+// token bucket for every method it calls. In addition, scripts are charged the time it took to execute
+// them against a separate compute budget of the client, as the cost of a script varies by orders of
+// magnitude.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/simplelru"
+
+	"github.com/onflow/flow-go/module"
+)
+
+// names of the limits reported to the metrics
+const (
+	limitRequests = "requests"
+	limitCompute  = "compute"
+)
+
+// maxBuckets is the number of request buckets, and of compute buckets, after which the least recently
+// used bucket is evicted. An evicted bucket was most likely refilled, which is equivalent to a bucket
+// which does not exist.
+const maxBuckets = 10000
+
+// scriptReservation is the execution time reserved from the compute budget of a client when a script
+// is admitted, so that concurrent scripts can't overspend the budget. The reservation is settled with
+// the actual execution time once the script finished.
+const scriptReservation = time.Second
+
+// Limit is the limit of a token bucket, which refills with Rate tokens per second up to Burst tokens.
+// A limit with a zero rate is disabled, and a zero burst defaults to the rate, but at least one token.
+type Limit struct {
+	Rate  float64
+	Burst float64
+}
+
+func (l Limit) enabled() bool {
+	return l.Rate > 0
+}
+
+// burst returns the maximum number of tokens of the bucket.
+func (l Limit) burst() float64 {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return math.Max(1, l.Rate)
+}
+
+// validateRequests returns an error if the bucket of an enabled request limit can never hold a token.
+func (l Limit) validateRequests() error {
+	if l.enabled() && l.Burst != 0 && l.Burst < 1 {
+		return fmt.Errorf("burst %v is less than one call", l.Burst)
+	}
+	return nil
+}
+
+// Config defines the rate limits of each client.
+type Config struct {
+	// Default is the limit of calls per second of each method, unless configured in Methods.
+	Default Limit
+	// Methods are the limits of calls per second of individual methods, by method name (e.g. "GetAccount").
+	Methods map[string]Limit
+	// ScriptCompute is the limit of seconds of script execution per second, shared by all ExecuteScript* methods.
+	ScriptCompute Limit
+	// APIKeys are the API keys identifying clients. Clients sending any other key are identified by their
+	// IP address, so that they can't get new buckets by sending new keys.
+	APIKeys []string
+}
+
+// Enabled returns whether any limit is configured.
+func (c Config) Enabled() bool {
+	if c.Default.enabled() || c.ScriptCompute.enabled() {
+		return true
+	}
+	for _, limit := range c.Methods {
+		if limit.enabled() {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns an error if a limit is invalid.
+func (c Config) Validate() error {
+	err := c.Default.validateRequests()
+	if err != nil {
+		return fmt.Errorf("invalid default limit: %w", err)
+	}
+	for method, limit := range c.Methods {
+		err := limit.validateRequests()
+		if err != nil {
+			return fmt.Errorf("invalid limit of method %s: %w", method, err)
+		}
+	}
+	if c.ScriptCompute.enabled() && c.ScriptCompute.Burst < 0 {
+		return fmt.Errorf("invalid script compute limit: negative burst %v", c.ScriptCompute.Burst)
+	}
+	for _, key := range c.APIKeys {
+		if key == "" {
+			return fmt.Errorf("invalid empty API key")
+		}
+	}
+	return nil
+}
+
+func (c Config) methodLimit(method string) Limit {
+	limit, ok := c.Methods[method]
+	if !ok {
+		return c.Default
+	}
+	return limit
+}
+
+// ParseMethodLimits parses a comma separated list of method limits in the format method=rate[:burst],
+// e.g. "ExecuteScriptAtLatestBlock=10:20,GetAccount=50". The burst defaults to the rate, but at least one.
+// A burst of less than one call is rejected, as the method could never be called.
+func ParseMethodLimits(value string) (map[string]Limit, error) {
+	limits := make(map[string]Limit)
+	if strings.TrimSpace(value) == "" {
+		return limits, nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), "=")
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid method limit %q, expected method=rate[:burst]", entry)
+		}
+		values := strings.Split(parts[1], ":")
+		if len(values) > 2 {
+			return nil, fmt.Errorf("invalid method limit %q, expected method=rate[:burst]", entry)
+		}
+		rate, err := strconv.ParseFloat(values[0], 64)
+		if err != nil || rate < 0 {
+			return nil, fmt.Errorf("invalid rate of method limit %q", entry)
+		}
+		limit := Limit{Rate: rate}
+		if len(values) == 2 {
+			limit.Burst, err = strconv.ParseFloat(values[1], 64)
+			if err != nil || limit.Burst < 0 {
+				return nil, fmt.Errorf("invalid burst of method limit %q", entry)
+			}
+			err = limit.validateRequests()
+			if err != nil {
+				return nil, fmt.Errorf("invalid burst of method limit %q: %w", entry, err)
+			}
+		}
+		limits[parts[0]] = limit
+	}
+
+	return limits, nil
+}
+
+// bucket is a token bucket. Its tokens may become negative, when a client is charged after a call.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens accumulated since the last refill.
+func (b *bucket) refill(limit Limit, now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(limit.burst(), b.tokens+elapsed*limit.Rate)
+	}
+	b.last = now
+}
+
+type methodKey struct {
+	client string
+	method string
+}
+
+// Limiter tracks the token buckets of all clients.
+type Limiter struct {
+	config  Config
+	metrics module.RateLimitMetrics
+	now     func() time.Time
+	apiKeys map[string]struct{}
+
+	mu       sync.Mutex
+	requests *simplelru.LRU // request buckets by client and method
+	compute  *simplelru.LRU // script compute buckets by client
+}
+
+// NewLimiter returns a new limiter enforcing the given limits.
+func NewLimiter(config Config, metrics module.RateLimitMetrics) *Limiter {
+	apiKeys := make(map[string]struct{}, len(config.APIKeys))
+	for _, key := range config.APIKeys {
+		apiKeys[key] = struct{}{}
+	}
+
+	// the size is positive, so creating the caches can't fail
+	requests, _ := simplelru.NewLRU(maxBuckets, nil)
+	compute, _ := simplelru.NewLRU(maxBuckets, nil)
+
+	return &Limiter{
+		config:   config,
+		metrics:  metrics,
+		now:      time.Now,
+		apiKeys:  apiKeys,
+		requests: requests,
+		compute:  compute,
+	}
+}
+
+// Allow returns whether the client may call the method now, and takes a token from its request bucket
+// if so. Scripts are only allowed while the compute budget of the client is not exhausted, and reserve
+// part of it, which must be settled with ChargeCompute once the script finished.
+// The name of the exceeded limit is returned if the call is rejected.
+func (l *Limiter) Allow(client string, method string) (bool, string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	var compute *bucket
+	if isScriptMethod(method) && l.config.ScriptCompute.enabled() {
+		compute = l.bucket(l.compute, client, l.config.ScriptCompute, now)
+		if compute.tokens <= 0 {
+			l.metrics.CallRateLimited(method, limitCompute)
+			return false, limitCompute
+		}
+	}
+
+	limit := l.config.methodLimit(method)
+	if limit.enabled() {
+		b := l.bucket(l.requests, methodKey{client: client, method: method}, limit, now)
+		if b.tokens < 1 {
+			l.metrics.CallRateLimited(method, limitRequests)
+			return false, limitRequests
+		}
+		b.tokens--
+	}
+
+	if compute != nil {
+		compute.tokens -= l.scriptReservation()
+	}
+
+	return true, ""
+}
+
+// ChargeCompute charges the execution time of a script, which was allowed, to the compute budget of the
+// client, and releases the reservation made when the script was allowed.
+func (l *Limiter) ChargeCompute(client string, duration time.Duration) {
+	if !l.config.ScriptCompute.enabled() {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.bucket(l.compute, client, l.config.ScriptCompute, l.now())
+	b.tokens -= duration.Seconds() - l.scriptReservation()
+}
+
+// scriptReservation returns the tokens reserved from the compute budget when a script is allowed,
+// which are at most the burst of the budget.
+func (l *Limiter) scriptReservation() float64 {
+	return math.Min(scriptReservation.Seconds(), l.config.ScriptCompute.burst())
+}
+
+// bucket returns the refilled bucket with the given key and limit of the given buckets, and creates it
+// if it does not exist. The caller must hold the lock.
+func (l *Limiter) bucket(buckets *simplelru.LRU, key interface{}, limit Limit, now time.Time) *bucket {
+	value, ok := buckets.Get(key)
+	if !ok {
+		b := &bucket{tokens: limit.burst(), last: now}
+		buckets.Add(key, b)
+		return b
+	}
+	b := value.(*bucket)
+	b.refill(limit, now)
+	return b
+}
+
+// isScriptMethod returns whether the method executes a script.
+func isScriptMethod(method string) bool {
+	return strings.HasPrefix(method, "ExecuteScript")
+}
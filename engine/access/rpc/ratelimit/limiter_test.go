@@ -0,0 +1,315 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/onflow/flow-go/module/metrics"
+	mockmodule "github.com/onflow/flow-go/module/mock"
+)
+
+// clock is a manually advanced clock
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newLimiter(config Config) (*Limiter, *clock) {
+	c := &clock{now: time.Now()}
+	limiter := NewLimiter(config, metrics.NewNoopCollector())
+	limiter.now = c.Now
+	return limiter, c
+}
+
+func TestRequestLimit(t *testing.T) {
+	limiter, clock := newLimiter(Config{
+		Default: Limit{Rate: 1, Burst: 2},
+		Methods: map[string]Limit{
+			"GetAccount": {Rate: 10, Burst: 10},
+			"Ping":       {},
+		},
+	})
+
+	t.Run("burst and refill", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			allowed, _ := limiter.Allow("a", "GetBlockByID")
+			assert.True(t, allowed)
+		}
+		allowed, limit := limiter.Allow("a", "GetBlockByID")
+		assert.False(t, allowed)
+		assert.Equal(t, limitRequests, limit)
+
+		clock.Advance(time.Second)
+		allowed, _ = limiter.Allow("a", "GetBlockByID")
+		assert.True(t, allowed)
+		allowed, _ = limiter.Allow("a", "GetBlockByID")
+		assert.False(t, allowed)
+	})
+
+	t.Run("limits are per client and method", func(t *testing.T) {
+		allowed, _ := limiter.Allow("b", "GetBlockByID")
+		assert.True(t, allowed)
+		allowed, _ = limiter.Allow("a", "GetCollectionByID")
+		assert.True(t, allowed)
+	})
+
+	t.Run("method limits", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			allowed, _ := limiter.Allow("a", "GetAccount")
+			assert.True(t, allowed)
+		}
+		allowed, _ := limiter.Allow("a", "GetAccount")
+		assert.False(t, allowed)
+
+		// a zero limit disables the limit of the method
+		for i := 0; i < 100; i++ {
+			allowed, _ := limiter.Allow("a", "Ping")
+			assert.True(t, allowed)
+		}
+	})
+}
+
+func TestRateOnlyLimit(t *testing.T) {
+	config := Config{
+		Default: Limit{Rate: 2},
+		Methods: map[string]Limit{
+			"GetAccount": {Rate: 0.5},
+		},
+	}
+	require.NoError(t, config.Validate())
+
+	limiter, clock := newLimiter(config)
+
+	t.Run("burst defaults to the rate", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			allowed, _ := limiter.Allow("a", "GetBlockByID")
+			assert.True(t, allowed)
+		}
+		allowed, _ := limiter.Allow("a", "GetBlockByID")
+		assert.False(t, allowed)
+
+		clock.Advance(time.Second)
+		for i := 0; i < 2; i++ {
+			allowed, _ := limiter.Allow("a", "GetBlockByID")
+			assert.True(t, allowed)
+		}
+	})
+
+	t.Run("burst is at least one call", func(t *testing.T) {
+		allowed, _ := limiter.Allow("a", "GetAccount")
+		assert.True(t, allowed)
+		allowed, _ = limiter.Allow("a", "GetAccount")
+		assert.False(t, allowed)
+
+		clock.Advance(2 * time.Second)
+		allowed, _ = limiter.Allow("a", "GetAccount")
+		assert.True(t, allowed)
+	})
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, Config{}.Validate())
+	assert.NoError(t, Config{Default: Limit{Rate: 1, Burst: 1}}.Validate())
+	assert.Error(t, Config{Default: Limit{Rate: 1, Burst: 0.5}}.Validate())
+	assert.Error(t, Config{Methods: map[string]Limit{"GetAccount": {Rate: 1, Burst: -1}}}.Validate())
+	assert.Error(t, Config{ScriptCompute: Limit{Rate: 1, Burst: -1}}.Validate())
+	assert.Error(t, Config{APIKeys: []string{"key", ""}}.Validate())
+}
+
+func TestScriptComputeLimit(t *testing.T) {
+	limiter, clock := newLimiter(Config{
+		ScriptCompute: Limit{Rate: 0.5, Burst: 2},
+	})
+
+	allowed, _ := limiter.Allow("a", "ExecuteScriptAtLatestBlock")
+	assert.True(t, allowed)
+
+	// a single expensive script may exceed the budget, but blocks further scripts until it is paid off
+	limiter.ChargeCompute("a", 3*time.Second)
+	allowed, limit := limiter.Allow("a", "ExecuteScriptAtBlockID")
+	assert.False(t, allowed)
+	assert.Equal(t, limitCompute, limit)
+
+	// other methods and other clients are not affected
+	allowed, _ = limiter.Allow("a", "GetAccount")
+	assert.True(t, allowed)
+	allowed, _ = limiter.Allow("b", "ExecuteScriptAtLatestBlock")
+	assert.True(t, allowed)
+
+	clock.Advance(2 * time.Second)
+	allowed, _ = limiter.Allow("a", "ExecuteScriptAtLatestBlock")
+	assert.False(t, allowed)
+
+	clock.Advance(time.Second)
+	allowed, _ = limiter.Allow("a", "ExecuteScriptAtLatestBlock")
+	assert.True(t, allowed)
+}
+
+func TestScriptComputeReservation(t *testing.T) {
+	limiter, _ := newLimiter(Config{
+		ScriptCompute: Limit{Rate: 0.5, Burst: 2},
+	})
+
+	// concurrent scripts reserve the budget before they are executed
+	for i := 0; i < 2; i++ {
+		allowed, _ := limiter.Allow("a", "ExecuteScriptAtLatestBlock")
+		assert.True(t, allowed)
+	}
+	allowed, limit := limiter.Allow("a", "ExecuteScriptAtLatestBlock")
+	assert.False(t, allowed)
+	assert.Equal(t, limitCompute, limit)
+
+	// the reservations are settled with the execution time of the scripts
+	limiter.ChargeCompute("a", 100*time.Millisecond)
+	limiter.ChargeCompute("a", 100*time.Millisecond)
+	value, ok := limiter.compute.Get("a")
+	require.True(t, ok)
+	assert.InDelta(t, 1.8, value.(*bucket).tokens, 1e-9)
+
+	// the reservation is at most the burst of the budget
+	limiter, _ = newLimiter(Config{
+		ScriptCompute: Limit{Rate: 0.1, Burst: 0.5},
+	})
+	allowed, _ = limiter.Allow("a", "ExecuteScriptAtLatestBlock")
+	assert.True(t, allowed)
+	allowed, _ = limiter.Allow("a", "ExecuteScriptAtLatestBlock")
+	assert.False(t, allowed)
+}
+
+func TestBucketEviction(t *testing.T) {
+	limiter, _ := newLimiter(Config{
+		Default: Limit{Rate: 1, Burst: 1},
+	})
+
+	limiter.Allow("idle", "GetAccount")
+	for i := 0; i < maxBuckets; i++ {
+		limiter.Allow(strconv.Itoa(i), "GetAccount")
+	}
+
+	// the least recently used bucket was evicted, the buckets of the other clients were not
+	assert.Equal(t, maxBuckets, limiter.requests.Len())
+	assert.False(t, limiter.requests.Contains(methodKey{client: "idle", method: "GetAccount"}))
+	allowed, _ := limiter.Allow("0", "GetAccount")
+	assert.False(t, allowed)
+}
+
+func TestParseMethodLimits(t *testing.T) {
+	limits, err := ParseMethodLimits("ExecuteScriptAtLatestBlock=10:20, GetAccount=0.5:1, GetBlockByID=5")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Limit{
+		"ExecuteScriptAtLatestBlock": {Rate: 10, Burst: 20},
+		"GetAccount":                 {Rate: 0.5, Burst: 1},
+		"GetBlockByID":               {Rate: 5},
+	}, limits)
+
+	limits, err = ParseMethodLimits("")
+	require.NoError(t, err)
+	assert.Empty(t, limits)
+
+	for _, value := range []string{"GetAccount", "GetAccount=1:2:3", "=1:1", "GetAccount=a:1", "GetAccount=1:-1", "GetAccount=1:0.5"} {
+		_, err := ParseMethodLimits(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	collector := new(mockmodule.RateLimitMetrics)
+	collector.On("CallRateLimited", "GetAccount", limitRequests).Once()
+	collector.On("CallRateLimited", "ExecuteScriptAtLatestBlock", limitCompute).Once()
+
+	c := &clock{now: time.Now()}
+	limiter := NewLimiter(Config{
+		Default:       Limit{Rate: 1, Burst: 1},
+		ScriptCompute: Limit{Rate: 1, Burst: 1},
+		APIKeys:       []string{"key"},
+	}, collector)
+	limiter.now = c.Now
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 3569},
+	})
+	info := func(method string) *grpc.UnaryServerInfo {
+		return &grpc.UnaryServerInfo{FullMethod: "/flow.access.AccessAPI/" + method}
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "response", nil
+	}
+	scriptHandler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		c.Advance(2 * time.Second)
+		return "result", nil
+	}
+
+	resp, err := limiter.UnaryServerInterceptor(ctx, "request", info("GetAccount"), handler)
+	require.NoError(t, err)
+	assert.Equal(t, "response", resp)
+
+	_, err = limiter.UnaryServerInterceptor(ctx, "request", info("GetAccount"), handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// the execution time of the script exhausts the compute budget
+	_, err = limiter.UnaryServerInterceptor(ctx, "request", info("ExecuteScriptAtLatestBlock"), scriptHandler)
+	require.NoError(t, err)
+	_, err = limiter.UnaryServerInterceptor(ctx, "request", info("ExecuteScriptAtLatestBlock"), scriptHandler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// clients sending a configured API key are limited separately from their IP address
+	keyCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(APIKeyHeader, "key"))
+	_, err = limiter.UnaryServerInterceptor(keyCtx, "request", info("ExecuteScriptAtLatestBlock"), scriptHandler)
+	require.NoError(t, err)
+
+	// clients sending an unknown API key are limited by their IP address
+	unknownCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(APIKeyHeader, "unknown"))
+	collector.On("CallRateLimited", "GetAccount", limitRequests).Once()
+	_, err = limiter.UnaryServerInterceptor(ctx, "request", info("GetAccount"), handler)
+	require.NoError(t, err)
+	_, err = limiter.UnaryServerInterceptor(unknownCtx, "request", info("GetAccount"), handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	collector.AssertExpectations(t)
+}
+
+func TestClientID(t *testing.T) {
+	limiter := NewLimiter(Config{APIKeys: []string{"secret"}}, metrics.NewNoopCollector())
+
+	assert.Equal(t, "unknown", limiter.clientID(context.Background()))
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 3569},
+	})
+	assert.Equal(t, "ip:10.0.0.1", limiter.clientID(ctx))
+
+	keyCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(APIKeyHeader, "secret"))
+	assert.Equal(t, "key:secret", limiter.clientID(keyCtx))
+
+	// keys which are not configured are not trusted
+	keyCtx = metadata.NewIncomingContext(ctx, metadata.Pairs(APIKeyHeader, "random"))
+	assert.Equal(t, "ip:10.0.0.1", limiter.clientID(keyCtx))
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/blocks", nil)
+	req.RemoteAddr = "10.0.0.2:1234"
+	assert.Equal(t, "ip:10.0.0.2", limiter.HTTPClientID(req))
+	req.Header.Set(APIKeyHeader, "random")
+	assert.Equal(t, "ip:10.0.0.2", limiter.HTTPClientID(req))
+	req.Header.Set(APIKeyHeader, "secret")
+	assert.Equal(t, "key:secret", limiter.HTTPClientID(req))
+}
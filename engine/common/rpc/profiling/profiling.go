@@ -0,0 +1,138 @@
+// Package profiling implements the gRPC service through which execution nodes execute scripts with the
+// execution profiler of the FVM, and return where their computation and register accesses went.
+//
+// The protobuf definitions of the Flow APIs contain no messages for profiles, so the service is declared
+// by hand: requests and responses are JSON encoded, wrapped into BytesValue messages.
+package profiling
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onflow/flow-go/fvm"
+	"github.com/onflow/flow-go/model/flow"
+)
+
+const (
+	serviceName                  = "flow.execution.ProfilingAPI"
+	profileScriptAtBlockIDMethod = "/" + serviceName + "/ProfileScriptAtBlockID"
+)
+
+// Request is a request to profile a script executed against the execution state of a block.
+type Request struct {
+	BlockID   flow.Identifier
+	Script    []byte
+	Arguments [][]byte
+}
+
+// Response contains the result of a profiled script, and the profile of its execution.
+type Response struct {
+	// Value is the JSON-CDC encoded value returned by the script.
+	Value   []byte
+	Profile *fvm.ExecutionProfile
+}
+
+// API profiles scripts. It is implemented by the execution node, and by the client of the service.
+type API interface {
+	ProfileScriptAtBlockID(ctx context.Context, req *Request) (*Response, error)
+}
+
+// RegisterServer registers the profiling service on the gRPC server.
+func RegisterServer(s *grpc.Server, srv API) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*API)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ProfileScriptAtBlockID",
+			Handler:    profileScriptAtBlockIDHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "profiling.go",
+}
+
+func profileScriptAtBlockIDHandler(
+	srv interface{},
+	ctx context.Context,
+	dec func(interface{}) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (interface{}, error) {
+
+	in := new(wrappers.BytesValue)
+	err := dec(in)
+	if err != nil {
+		return nil, err
+	}
+
+	handle := func(ctx context.Context, in interface{}) (interface{}, error) {
+		var req Request
+		err := json.Unmarshal(in.(*wrappers.BytesValue).GetValue(), &req)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid profiling request: %v", err)
+		}
+		if len(req.Script) == 0 {
+			return nil, status.Error(codes.InvalidArgument, "missing script")
+		}
+
+		res, err := srv.(API).ProfileScriptAtBlockID(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := json.Marshal(res)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to encode profile: %v", err)
+		}
+		return &wrappers.BytesValue{Value: data}, nil
+	}
+
+	if interceptor == nil {
+		return handle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: profileScriptAtBlockIDMethod,
+	}
+	return interceptor(ctx, in, info, handle)
+}
+
+type client struct {
+	conn grpc.ClientConnInterface
+}
+
+// NewClient returns a client of the profiling service served on the given connection.
+func NewClient(conn grpc.ClientConnInterface) API {
+	return &client{conn: conn}
+}
+
+func (c *client) ProfileScriptAtBlockID(ctx context.Context, req *Request) (*Response, error) {
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode profiling request: %w", err)
+	}
+
+	out := new(wrappers.BytesValue)
+	err = c.conn.Invoke(ctx, profileScriptAtBlockIDMethod, &wrappers.BytesValue{Value: data}, out)
+	if err != nil {
+		return nil, err
+	}
+
+	var res Response
+	err = json.Unmarshal(out.GetValue(), &res)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode profile: %w", err)
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,95 @@
+package profiling_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/onflow/flow-go/engine/common/rpc/profiling"
+	profilingmock "github.com/onflow/flow-go/engine/common/rpc/profiling/mock"
+	"github.com/onflow/flow-go/fvm"
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/utils/unittest"
+)
+
+// serve serves the profiling API on an in-memory connection, and returns a client connected to it
+func serve(t *testing.T, api profiling.API) profiling.API {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	profiling.RegisterServer(server, api)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}),
+		grpc.WithInsecure(),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return profiling.NewClient(conn)
+}
+
+func TestProfileScriptAtBlockID(t *testing.T) {
+	req := &profiling.Request{
+		BlockID:   unittest.IdentifierFixture(),
+		Script:    []byte("pub fun main(): Int { return 1 }"),
+		Arguments: [][]byte{[]byte("argument")},
+	}
+
+	t.Run("profile", func(t *testing.T) {
+		res := &profiling.Response{
+			Value: []byte("value"),
+			Profile: &fvm.ExecutionProfile{
+				Computation:  1,
+				BytesRead:    10,
+				BytesWritten: 0,
+				Functions: []fvm.FunctionProfile{
+					{Location: "s.0123", Function: "main", Line: 1, Computation: 1},
+				},
+				Registers: []fvm.RegisterProfile{
+					{Owner: flow.HexToAddress("01"), Key: "key", Reads: 1, BytesRead: 10},
+				},
+			},
+		}
+
+		api := new(profilingmock.API)
+		api.On("ProfileScriptAtBlockID", mock.Anything, req).Return(res, nil)
+
+		actual, err := serve(t, api).ProfileScriptAtBlockID(context.Background(), req)
+		require.NoError(t, err)
+		require.Equal(t, res, actual)
+		api.AssertExpectations(t)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		api := new(profilingmock.API)
+		api.On("ProfileScriptAtBlockID", mock.Anything, req).Return(nil, status.Error(codes.NotFound, "block not executed"))
+
+		_, err := serve(t, api).ProfileScriptAtBlockID(context.Background(), req)
+		require.Error(t, err)
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("missing script", func(t *testing.T) {
+		api := new(profilingmock.API)
+
+		_, err := serve(t, api).ProfileScriptAtBlockID(context.Background(), &profiling.Request{BlockID: req.BlockID})
+		require.Error(t, err)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		api.AssertNotCalled(t, "ProfileScriptAtBlockID", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,138 @@
+// Package results implements the gRPC service through which access nodes request the results of many
+// transactions of a block from execution nodes at once.
+//
+// The protobuf definitions of the Flow APIs only contain a request for the result of a single transaction,
+// so the service is declared by hand: requests and responses are JSON encoded, wrapped into BytesValue
+// messages.
+package results
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onflow/flow-go/model/flow"
+)
+
+const (
+	serviceName                 = "flow.execution.TransactionResultsAPI"
+	getTransactionResultsMethod = "/" + serviceName + "/GetTransactionResults"
+)
+
+// Request is a request for the results of the given transactions, executed in the given block.
+type Request struct {
+	BlockID        flow.Identifier
+	TransactionIDs []flow.Identifier
+}
+
+// Result is the result of a transaction executed in a block.
+type Result struct {
+	TransactionID flow.Identifier
+	StatusCode    uint32
+	ErrorMessage  string
+	Events        []flow.Event
+}
+
+// API returns transaction results. It is implemented by the execution node, and by the client access
+// nodes use to request results from it.
+type API interface {
+	// GetTransactionResults returns the results of the requested transactions, in the order of the
+	// request. It fails with codes.NotFound if the result of any of the transactions is not known.
+	GetTransactionResults(ctx context.Context, req *Request) ([]Result, error)
+}
+
+// RegisterServer registers the transaction results service on the gRPC server.
+func RegisterServer(s *grpc.Server, srv API) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*API)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetTransactionResults",
+			Handler:    getTransactionResultsHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "results.go",
+}
+
+func getTransactionResultsHandler(
+	srv interface{},
+	ctx context.Context,
+	dec func(interface{}) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (interface{}, error) {
+
+	in := new(wrappers.BytesValue)
+	err := dec(in)
+	if err != nil {
+		return nil, err
+	}
+
+	handle := func(ctx context.Context, in interface{}) (interface{}, error) {
+		var req Request
+		err := json.Unmarshal(in.(*wrappers.BytesValue).GetValue(), &req)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid transaction results request: %v", err)
+		}
+
+		results, err := srv.(API).GetTransactionResults(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := json.Marshal(results)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to encode transaction results: %v", err)
+		}
+		return &wrappers.BytesValue{Value: data}, nil
+	}
+
+	if interceptor == nil {
+		return handle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: getTransactionResultsMethod,
+	}
+	return interceptor(ctx, in, info, handle)
+}
+
+type client struct {
+	conn grpc.ClientConnInterface
+}
+
+// NewClient returns a client of the transaction results service served on the given connection.
+func NewClient(conn grpc.ClientConnInterface) API {
+	return &client{conn: conn}
+}
+
+func (c *client) GetTransactionResults(ctx context.Context, req *Request) ([]Result, error) {
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode transaction results request: %w", err)
+	}
+
+	out := new(wrappers.BytesValue)
+	err = c.conn.Invoke(ctx, getTransactionResultsMethod, &wrappers.BytesValue{Value: data}, out)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	err = json.Unmarshal(out.GetValue(), &results)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode transaction results: %w", err)
+	}
+
+	return results, nil
+}
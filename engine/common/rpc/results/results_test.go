@@ -0,0 +1,82 @@
+package results_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/onflow/flow-go/engine/common/rpc/results"
+	resultsmock "github.com/onflow/flow-go/engine/common/rpc/results/mock"
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/utils/unittest"
+)
+
+// serve serves the transaction results API on an in-memory connection, and returns a client connected to it
+func serve(t *testing.T, api results.API) results.API {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	results.RegisterServer(server, api)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}),
+		grpc.WithInsecure(),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return results.NewClient(conn)
+}
+
+func TestGetTransactionResults(t *testing.T) {
+	txIDs := unittest.IdentifierListFixture(2)
+	req := &results.Request{
+		BlockID:        unittest.IdentifierFixture(),
+		TransactionIDs: txIDs,
+	}
+
+	t.Run("results", func(t *testing.T) {
+		expected := []results.Result{
+			{
+				TransactionID: txIDs[0],
+				Events:        []flow.Event{unittest.EventFixture(flow.EventAccountCreated, 0, 0, txIDs[0])},
+			},
+			{
+				TransactionID: txIDs[1],
+				StatusCode:    100,
+				ErrorMessage:  "execution error",
+			},
+		}
+
+		api := new(resultsmock.API)
+		api.On("GetTransactionResults", mock.Anything, req).Return(expected, nil)
+
+		actual, err := serve(t, api).GetTransactionResults(context.Background(), req)
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+		api.AssertExpectations(t)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		api := new(resultsmock.API)
+		api.On("GetTransactionResults", mock.Anything, req).Return(nil, status.Error(codes.NotFound, "transaction result not found"))
+
+		_, err := serve(t, api).GetTransactionResults(context.Background(), req)
+		require.Error(t, err)
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
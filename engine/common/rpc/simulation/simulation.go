@@ -0,0 +1,131 @@
+// Package simulation implements the gRPC service through which access nodes forward transaction
+// simulations to execution nodes.
+//
+// The protobuf definitions of the Flow APIs contain no messages for simulations, so the service is
+// declared by hand: requests and responses are JSON encoded flow models, wrapped into BytesValue messages.
+package simulation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onflow/flow-go/model/flow"
+)
+
+const (
+	serviceName               = "flow.execution.SimulationAPI"
+	simulateTransactionMethod = "/" + serviceName + "/SimulateTransaction"
+)
+
+// Request is a request to simulate a transaction against the execution state of a block.
+type Request struct {
+	BlockID            flow.Identifier
+	Transaction        *flow.TransactionBody
+	SkipSignatureCheck bool
+}
+
+// API simulates transactions. It is implemented by the execution node, and by the client access nodes
+// use to forward simulations to it.
+type API interface {
+	SimulateTransaction(ctx context.Context, req *Request) (*flow.TransactionSimulation, error)
+}
+
+// RegisterServer registers the simulation service on the gRPC server.
+func RegisterServer(s *grpc.Server, srv API) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*API)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SimulateTransaction",
+			Handler:    simulateTransactionHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "simulation.go",
+}
+
+func simulateTransactionHandler(
+	srv interface{},
+	ctx context.Context,
+	dec func(interface{}) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (interface{}, error) {
+
+	in := new(wrappers.BytesValue)
+	err := dec(in)
+	if err != nil {
+		return nil, err
+	}
+
+	handle := func(ctx context.Context, in interface{}) (interface{}, error) {
+		var req Request
+		err := json.Unmarshal(in.(*wrappers.BytesValue).GetValue(), &req)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid simulation request: %v", err)
+		}
+		if req.Transaction == nil {
+			return nil, status.Error(codes.InvalidArgument, "missing transaction")
+		}
+
+		simulation, err := srv.(API).SimulateTransaction(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := json.Marshal(simulation)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to encode simulation: %v", err)
+		}
+		return &wrappers.BytesValue{Value: data}, nil
+	}
+
+	if interceptor == nil {
+		return handle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: simulateTransactionMethod,
+	}
+	return interceptor(ctx, in, info, handle)
+}
+
+type client struct {
+	conn grpc.ClientConnInterface
+}
+
+// NewClient returns a client of the simulation service served on the given connection.
+func NewClient(conn grpc.ClientConnInterface) API {
+	return &client{conn: conn}
+}
+
+func (c *client) SimulateTransaction(ctx context.Context, req *Request) (*flow.TransactionSimulation, error) {
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode simulation request: %w", err)
+	}
+
+	out := new(wrappers.BytesValue)
+	err = c.conn.Invoke(ctx, simulateTransactionMethod, &wrappers.BytesValue{Value: data}, out)
+	if err != nil {
+		return nil, err
+	}
+
+	var simulation flow.TransactionSimulation
+	err = json.Unmarshal(out.GetValue(), &simulation)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode simulation: %w", err)
+	}
+
+	return &simulation, nil
+}
@@ -0,0 +1,93 @@
+package simulation_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/onflow/flow-go/engine/common/rpc/simulation"
+	simulationmock "github.com/onflow/flow-go/engine/common/rpc/simulation/mock"
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/utils/unittest"
+)
+
+// serve serves the simulation API on an in-memory connection, and returns a client connected to it
+func serve(t *testing.T, api simulation.API) simulation.API {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	simulation.RegisterServer(server, api)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}),
+		grpc.WithInsecure(),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return simulation.NewClient(conn)
+}
+
+func TestSimulateTransaction(t *testing.T) {
+	tx := unittest.TransactionBodyFixture()
+	req := &simulation.Request{
+		BlockID:            unittest.IdentifierFixture(),
+		Transaction:        &tx,
+		SkipSignatureCheck: true,
+	}
+
+	t.Run("simulation", func(t *testing.T) {
+		result := &flow.TransactionSimulation{
+			TransactionID:   tx.ID(),
+			BlockID:         req.BlockID,
+			Events:          []flow.Event{unittest.EventFixture(flow.EventAccountCreated, 0, 0, tx.ID())},
+			Logs:            []string{"log"},
+			ComputationUsed: 42,
+			RegisterUpdates: []flow.RegisterEntry{
+				{Key: flow.RegisterID{Owner: "owner", Controller: "controller", Key: "key"}, Value: []byte("value")},
+			},
+			ErrorCode:    100,
+			ErrorMessage: "execution error",
+		}
+
+		api := new(simulationmock.API)
+		api.On("SimulateTransaction", mock.Anything, req).Return(result, nil)
+
+		actual, err := serve(t, api).SimulateTransaction(context.Background(), req)
+		require.NoError(t, err)
+		require.Equal(t, result, actual)
+		api.AssertExpectations(t)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		api := new(simulationmock.API)
+		api.On("SimulateTransaction", mock.Anything, req).Return(nil, status.Error(codes.NotFound, "block not executed"))
+
+		_, err := serve(t, api).SimulateTransaction(context.Background(), req)
+		require.Error(t, err)
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("missing transaction", func(t *testing.T) {
+		api := new(simulationmock.API)
+
+		_, err := serve(t, api).SimulateTransaction(context.Background(), &simulation.Request{BlockID: req.BlockID})
+		require.Error(t, err)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		api.AssertNotCalled(t, "SimulateTransaction", mock.Anything, mock.Anything)
+	})
+}
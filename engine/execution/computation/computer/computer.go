@@ -37,15 +37,21 @@ type blockComputer struct {
 	tracer         module.Tracer
 	log            zerolog.Logger
 	systemChunkCtx fvm.Context
+	parallelism    uint
 }
 
 // NewBlockComputer creates a new block executor.
+//
+// The transactions of a collection are executed by up to parallelism goroutines concurrently, if
+// parallelism is greater than one, which requires the read function of the views the blocks are
+// executed on to be safe for concurrent use. See executeCollectionParallel for details.
 func NewBlockComputer(
 	vm VirtualMachine,
 	vmCtx fvm.Context,
 	metrics module.ExecutionMetrics,
 	tracer module.Tracer,
 	logger zerolog.Logger,
+	parallelism uint,
 ) (BlockComputer, error) {
 	systemChunkASTCache, err := fvm.NewLRUASTCache(SystemChunkASTCacheSize)
 	if err != nil {
@@ -53,11 +59,8 @@ func NewBlockComputer(
 	}
 
 	systemChunkCtx := fvm.NewContextFromParent(
-		vmCtx,
+		fvm.SystemChunkContext(vmCtx),
 		fvm.WithASTCache(systemChunkASTCache),
-		fvm.WithRestrictedAccountCreation(false),
-		fvm.WithRestrictedDeployment(false),
-		fvm.WithTransactionProcessors(fvm.NewTransactionInvocator(logger)),
 	)
 
 	return &blockComputer{
@@ -67,6 +70,7 @@ func NewBlockComputer(
 		tracer:         tracer,
 		log:            logger,
 		systemChunkCtx: systemChunkCtx,
+		parallelism:    parallelism,
 	}, nil
 }
 
@@ -145,12 +149,25 @@ func (e *blockComputer) executeBlock(
 		defer colSpan.Finish()
 	}
 
+	txMetrics := fvm.NewMetricsCollector()
+
+	// the transactions scheduled for the block are executed before the system chunk transaction
+	scheduledEvents, scheduledResults, nextIndex, scheduledGas, err := e.executeScheduledTransactions(
+		colSpan, txMetrics, txIndex, blockCtx, systemChunkView, block.Height(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute scheduled transactions: %w", err)
+	}
+
+	txIndex = nextIndex
+	events = append(events, scheduledEvents...)
+	blockTxResults = append(blockTxResults, scheduledResults...)
+	gasUsed += scheduledGas
+
 	serviceAddress := e.vmCtx.Chain.ServiceAddress()
 
 	tx := fvm.SystemChunkTransaction(serviceAddress)
 
-	txMetrics := fvm.NewMetricsCollector()
-
 	txEvents, txResult, txGas, err := e.executeTransaction(tx, colSpan, txMetrics, systemChunkView, e.systemChunkCtx, txIndex)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute system chunk transaction: %w", err)
@@ -186,6 +203,10 @@ func (e *blockComputer) executeCollection(
 		defer colSpan.Finish()
 	}
 
+	if e.parallelism > 1 && len(collection.Transactions) > 1 {
+		return e.executeCollectionParallel(colSpan, txIndex, blockCtx, collectionView, collection)
+	}
+
 	var (
 		events    []flow.Event
 		txResults []flow.TransactionResult
@@ -212,6 +233,58 @@ func (e *blockComputer) executeCollection(
 	return events, txResults, txIndex, gasUsed, nil
 }
 
+// executeScheduledTransactions takes the transactions scheduled for the given height from the
+// system chunk view, and executes them on it.
+//
+// The transactions are executed in the block context rather than the system chunk context, so that
+// they have no privileges the transactions of the collections don't have.
+func (e *blockComputer) executeScheduledTransactions(
+	colSpan opentracing.Span,
+	txMetrics *fvm.MetricsCollector,
+	txIndex uint32,
+	blockCtx fvm.Context,
+	systemChunkView *delta.View,
+	height uint64,
+) ([]flow.Event, []flow.TransactionResult, uint32, uint64, error) {
+
+	if !blockCtx.ScheduledTransactionsEnabled {
+		return nil, nil, txIndex, 0, nil
+	}
+
+	scheduled := fvm.ScheduledTransactions(height)
+
+	err := e.vm.Run(blockCtx, scheduled, systemChunkView)
+	if err != nil {
+		return nil, nil, txIndex, 0, err
+	}
+
+	var (
+		events    []flow.Event
+		txResults []flow.TransactionResult
+		gasUsed   uint64
+	)
+
+	txCtx := fvm.NewContextFromParent(
+		fvm.ScheduledTransactionContext(blockCtx),
+		fvm.WithMetricsCollector(txMetrics),
+	)
+
+	for _, txBody := range scheduled.Transactions {
+		txEvents, txResult, txGasUsed, err := e.executeTransaction(txBody, colSpan, txMetrics, systemChunkView, txCtx, txIndex)
+
+		txIndex++
+		events = append(events, txEvents...)
+		txResults = append(txResults, txResult)
+		gasUsed += txGasUsed
+
+		if err != nil {
+			return nil, nil, txIndex, 0, err
+		}
+	}
+
+	return events, txResults, txIndex, gasUsed, nil
+}
+
 func (e *blockComputer) executeTransaction(
 	txBody *flow.TransactionBody,
 	colSpan opentracing.Span,
@@ -220,6 +293,26 @@ func (e *blockComputer) executeTransaction(
 	ctx fvm.Context,
 	txIndex uint32,
 ) ([]flow.Event, flow.TransactionResult, uint64, error) {
+
+	txView := collectionView.NewChild()
+
+	tx, err := e.runTransaction(txBody, colSpan, txMetrics, txView, ctx, txIndex)
+	if err != nil {
+		return nil, flow.TransactionResult{}, 0, err
+	}
+
+	return e.commitTransaction(txBody, tx, txView, collectionView)
+}
+
+// runTransaction runs the transaction on the given view.
+func (e *blockComputer) runTransaction(
+	txBody *flow.TransactionBody,
+	colSpan opentracing.Span,
+	txMetrics *fvm.MetricsCollector,
+	txView *delta.View,
+	ctx fvm.Context,
+	txIndex uint32,
+) (*fvm.TransactionProcedure, error) {
 	if e.tracer != nil {
 		txSpan := e.tracer.StartSpanFromParent(colSpan, trace.EXEComputeTransaction)
 
@@ -234,13 +327,13 @@ func (e *blockComputer) executeTransaction(
 				log.Int64(trace.EXEParseDurationTag, int64(txMetrics.Parsed())),
 				log.Int64(trace.EXECheckDurationTag, int64(txMetrics.Checked())),
 				log.Int64(trace.EXEInterpretDurationTag, int64(txMetrics.Interpreted())),
+				log.Uint64(trace.EXEProgramCacheHitsTag, txMetrics.ProgramCacheHits()),
+				log.Uint64(trace.EXEProgramCacheMissesTag, txMetrics.ProgramCacheMisses()),
 			)
 			txSpan.Finish()
 		}()
 	}
 
-	txView := collectionView.NewChild()
-
 	tx := fvm.Transaction(txBody, txIndex)
 
 	err := e.vm.Run(ctx, tx, txView)
@@ -252,15 +345,28 @@ func (e *blockComputer) executeTransaction(
 	}
 
 	if err != nil {
-		return nil, flow.TransactionResult{}, 0, fmt.Errorf("failed to execute transaction: %w", err)
+		return nil, fmt.Errorf("failed to execute transaction: %w", err)
 	}
 
+	return tx, nil
+}
+
+// commitTransaction merges the changes of the transaction, run on the given view, into the
+// collection view, unless it failed.
+func (e *blockComputer) commitTransaction(
+	txBody *flow.TransactionBody,
+	tx *fvm.TransactionProcedure,
+	txView *delta.View,
+	collectionView *delta.View,
+) ([]flow.Event, flow.TransactionResult, uint64, error) {
+
 	txResult := flow.TransactionResult{
 		TransactionID: tx.ID,
 	}
 
 	if tx.Err != nil {
 		txResult.ErrorMessage = tx.Err.Error()
+		txResult.ErrorCode = tx.Err.Code()
 		e.log.Debug().
 			Hex("tx_id", logging.Entity(txBody)).
 			Str("error_message", tx.Err.Error()).
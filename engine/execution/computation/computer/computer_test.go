@@ -27,7 +27,7 @@ func TestBlockExecutor_ExecuteBlock(t *testing.T) {
 
 		vm := new(computermock.VirtualMachine)
 
-		exe, err := computer.NewBlockComputer(vm, execCtx, nil, nil, zerolog.Nop())
+		exe, err := computer.NewBlockComputer(vm, execCtx, nil, nil, zerolog.Nop(), 1)
 		require.NoError(t, err)
 
 		// create a block with 1 collection with 2 transactions
@@ -54,7 +54,7 @@ func TestBlockExecutor_ExecuteBlock(t *testing.T) {
 
 		vm := new(computermock.VirtualMachine)
 
-		exe, err := computer.NewBlockComputer(vm, execCtx, nil, nil, zerolog.Nop())
+		exe, err := computer.NewBlockComputer(vm, execCtx, nil, nil, zerolog.Nop(), 1)
 		require.NoError(t, err)
 
 		// create an empty block
@@ -76,12 +76,52 @@ func TestBlockExecutor_ExecuteBlock(t *testing.T) {
 		vm.AssertExpectations(t)
 	})
 
+	t.Run("scheduled transactions are executed in system chunk", func(t *testing.T) {
+
+		execCtx := fvm.NewContext(zerolog.Nop(), fvm.WithScheduledTransactions(true))
+
+		vm := new(computermock.VirtualMachine)
+
+		exe, err := computer.NewBlockComputer(vm, execCtx, nil, nil, zerolog.Nop(), 1)
+		require.NoError(t, err)
+
+		// create a block with 1 collection with 2 transactions
+		block := generateBlock(1, 2)
+
+		scheduledTx := &flow.TransactionBody{Script: []byte("transaction {}")}
+
+		vm.On("Run", mock.Anything, mock.AnythingOfType("*fvm.ScheduledTransactionsProcedure"), mock.Anything).
+			Run(func(args mock.Arguments) {
+				proc := args.Get(1).(*fvm.ScheduledTransactionsProcedure)
+				assert.Equal(t, block.Height(), proc.Height)
+				proc.Transactions = []*flow.TransactionBody{scheduledTx}
+			}).
+			Return(nil).
+			Once()
+
+		vm.On("Run", mock.Anything, mock.AnythingOfType("*fvm.TransactionProcedure"), mock.Anything).
+			Return(nil).
+			Times(2 + 1 + 1) // 2 txs in collection + scheduled tx + system chunk
+
+		view := delta.NewView(func(owner, controller, key string) (flow.RegisterValue, error) {
+			return nil, nil
+		})
+
+		result, err := exe.ExecuteBlock(context.Background(), block, view)
+		assert.NoError(t, err)
+		assert.Len(t, result.StateSnapshots, 1+1) // +1 system chunk
+		require.Len(t, result.TransactionResult, 2+1+1)
+		assert.Equal(t, scheduledTx.ID(), result.TransactionResult[2].TransactionID)
+
+		vm.AssertExpectations(t)
+	})
+
 	t.Run("multiple collections", func(t *testing.T) {
 		execCtx := fvm.NewContext(zerolog.Nop())
 
 		vm := new(computermock.VirtualMachine)
 
-		exe, err := computer.NewBlockComputer(vm, execCtx, nil, nil, zerolog.Nop())
+		exe, err := computer.NewBlockComputer(vm, execCtx, nil, nil, zerolog.Nop(), 1)
 		require.NoError(t, err)
 
 		collectionCount := 2
@@ -134,6 +174,7 @@ func TestBlockExecutor_ExecuteBlock(t *testing.T) {
 				txResult := flow.TransactionResult{
 					TransactionID: t.ID(),
 					ErrorMessage:  "no payer address provided",
+					ErrorCode:     fvm.ErrCodeMissingPayer,
 				}
 				expectedResults = append(expectedResults, txResult)
 			}
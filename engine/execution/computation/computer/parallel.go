@@ -0,0 +1,175 @@
+package computer
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/onflow/flow-go/engine/execution/state/delta"
+	"github.com/onflow/flow-go/fvm"
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/module/mempool/entity"
+	"github.com/onflow/flow-go/utils/logging"
+)
+
+// registerRead is a read of a register from the state a transaction was executed against.
+type registerRead struct {
+	owner      string
+	controller string
+	key        string
+}
+
+// speculativeExecution is an execution of a transaction against the state at the start of its
+// collection, i.e. without the changes of the transactions preceding it in the collection.
+type speculativeExecution struct {
+	collectionView *delta.View
+	view           *delta.View
+	reads          []registerRead // the registers read from the state at the start of the collection, in order
+	tx             *fvm.TransactionProcedure
+	err            error
+}
+
+func newSpeculativeExecution(collectionView *delta.View) *speculativeExecution {
+	s := &speculativeExecution{
+		collectionView: collectionView,
+	}
+	s.view = delta.NewView(s.get)
+	return s
+}
+
+// get reads a register from the state at the start of the collection, and records the read.
+func (s *speculativeExecution) get(owner, controller, key string) (flow.RegisterValue, error) {
+	value, err := s.collectionView.Peek(owner, controller, key)
+	if err != nil {
+		return nil, err
+	}
+	s.reads = append(s.reads, registerRead{owner: owner, controller: controller, key: key})
+	return value, nil
+}
+
+// conflicts returns whether the transaction read any register written by the transactions committed
+// to the collection view since the speculative execution started, in which case it could have
+// behaved differently when executed after them.
+func (s *speculativeExecution) conflicts() bool {
+	written := s.collectionView.Delta()
+	for _, read := range s.reads {
+		_, ok := written.Get(read.owner, read.controller, read.key)
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// replay reads the registers the transaction read from the collection view, in the same order. The
+// collection view records the reads just as it would have if the transaction was executed on a child
+// view of it, which keeps its touched registers, reads count and SPoCK secret identical to serial execution.
+func (s *speculativeExecution) replay() error {
+	for _, read := range s.reads {
+		_, err := s.collectionView.Get(read.owner, read.controller, read.key)
+		if err != nil {
+			return fmt.Errorf("failed to replay read of register: %w", err)
+		}
+	}
+	return nil
+}
+
+// executeCollectionParallel executes the transactions of a collection optimistically in parallel.
+//
+// First, all transactions are executed concurrently against the state at the start of the collection,
+// each on a separate view that records the registers it reads. Then, the transactions are validated
+// and committed in order: a transaction which read a register written by a transaction preceding it
+// conflicts with it, and is re-executed on top of the committed transactions. As only conflicting
+// transactions could have behaved differently, the resulting state, events and SPoCK secrets are
+// identical to serial execution.
+func (e *blockComputer) executeCollectionParallel(
+	colSpan opentracing.Span,
+	txIndex uint32,
+	blockCtx fvm.Context,
+	collectionView *delta.View,
+	collection *entity.CompleteCollection,
+) ([]flow.Event, []flow.TransactionResult, uint32, uint64, error) {
+
+	executions := make([]*speculativeExecution, len(collection.Transactions))
+
+	// the collection view is not modified until all speculative executions finished, so that they
+	// can all read from it concurrently
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := uint(0); w < e.parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				execution := newSpeculativeExecution(collectionView)
+
+				// metrics collectors are not safe for concurrent use
+				txMetrics := fvm.NewMetricsCollector()
+				txCtx := fvm.NewContextFromParent(blockCtx, fvm.WithMetricsCollector(txMetrics))
+
+				execution.tx, execution.err = e.runTransaction(
+					collection.Transactions[i], colSpan, txMetrics, execution.view, txCtx, txIndex+uint32(i),
+				)
+				executions[i] = execution
+			}
+		}()
+	}
+	for i := range collection.Transactions {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	var (
+		events      []flow.Event
+		txResults   []flow.TransactionResult
+		gasUsed     uint64
+		conflicting int
+	)
+
+	txMetrics := fvm.NewMetricsCollector()
+	txCtx := fvm.NewContextFromParent(blockCtx, fvm.WithMetricsCollector(txMetrics))
+
+	for i, txBody := range collection.Transactions {
+		execution := executions[i]
+
+		var (
+			txEvents  []flow.Event
+			txResult  flow.TransactionResult
+			txGasUsed uint64
+			err       error
+		)
+
+		// transactions which failed to execute are re-executed as well, as the failure could have been
+		// caused by a conflict, and otherwise occurs again
+		if execution.err != nil || execution.conflicts() {
+			conflicting++
+			txEvents, txResult, txGasUsed, err = e.executeTransaction(
+				txBody, colSpan, txMetrics, collectionView, txCtx, txIndex,
+			)
+		} else {
+			err = execution.replay()
+			if err == nil {
+				txEvents, txResult, txGasUsed, err = e.commitTransaction(txBody, execution.tx, execution.view, collectionView)
+			}
+		}
+
+		txIndex++
+		events = append(events, txEvents...)
+		txResults = append(txResults, txResult)
+		gasUsed += txGasUsed
+
+		if err != nil {
+			return nil, nil, txIndex, 0, err
+		}
+	}
+
+	e.log.Debug().
+		Hex("collection_id", logging.Entity(collection.Guarantee)).
+		Int("transactions", len(collection.Transactions)).
+		Int("re_executed", conflicting).
+		Msg("executed collection in parallel")
+
+	return events, txResults, txIndex, gasUsed, nil
+}
@@ -0,0 +1,202 @@
+package computer_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/onflow/cadence/runtime"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/engine/execution"
+	"github.com/onflow/flow-go/engine/execution/computation/computer"
+	"github.com/onflow/flow-go/engine/execution/state/delta"
+	"github.com/onflow/flow-go/engine/execution/testutil"
+	"github.com/onflow/flow-go/fvm"
+	"github.com/onflow/flow-go/fvm/state"
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/module/mempool/entity"
+	"github.com/onflow/flow-go/utils/unittest"
+)
+
+// countingVM counts the procedures it runs
+type countingVM struct {
+	vm   *fvm.VirtualMachine
+	runs int64
+}
+
+func (c *countingVM) Run(ctx fvm.Context, proc fvm.Procedure, ledger state.Ledger) error {
+	atomic.AddInt64(&c.runs, 1)
+	return c.vm.Run(ctx, proc, ledger)
+}
+
+const incrementTransaction = `
+	transaction {
+		prepare(signer: AuthAccount) {
+			let count = signer.load<Int>(from: /storage/count) ?? 0
+			signer.save(count + 1, to: /storage/count)
+		}
+	}
+`
+
+const panicTransaction = `
+	transaction {
+		prepare(signer: AuthAccount) {
+			signer.save(1, to: /storage/other)
+			panic("failed")
+		}
+	}
+`
+
+// TestParallelExecutionDeterminism executes the same block serially and with different degrees of
+// parallelism, and checks that the results are identical.
+func TestParallelExecutionDeterminism(t *testing.T) {
+	chain := flow.Mainnet.Chain()
+	vm := fvm.New(runtime.NewInterpreterRuntime())
+	execCtx := fvm.NewContext(zerolog.Nop(), fvm.WithChain(chain))
+
+	ledger := testutil.RootBootstrappedLedger(vm, execCtx)
+	privateKeys, err := testutil.GenerateAccountPrivateKeys(4)
+	require.NoError(t, err)
+	accounts, err := testutil.CreateAccounts(vm, ledger, privateKeys, chain)
+	require.NoError(t, err)
+
+	// the ledger records reads, so the registers are read from a copy of it, which is safe for concurrent use
+	registers := make(map[flow.RegisterID]flow.RegisterValue, len(ledger.Registers))
+	for _, entry := range ledger.Registers {
+		registers[entry.Key] = entry.Value
+	}
+	readRegister := func(owner, controller, key string) (flow.RegisterValue, error) {
+		return registers[flow.NewRegisterID(owner, controller, key)], nil
+	}
+
+	sequenceNumbers := make(map[flow.Address]uint64)
+	sign := func(tx *flow.TransactionBody, address flow.Address, privateKey flow.AccountPrivateKey) *flow.TransactionBody {
+		err := testutil.SignTransaction(tx, address, privateKey, sequenceNumbers[address])
+		require.NoError(t, err)
+		sequenceNumbers[address]++
+		return tx
+	}
+	increment := func(account int) *flow.TransactionBody {
+		tx := flow.NewTransactionBody().
+			SetScript([]byte(incrementTransaction)).
+			AddAuthorizer(accounts[account])
+		return sign(tx, accounts[account], privateKeys[account])
+	}
+	createAccount := func() *flow.TransactionBody {
+		_, tx := testutil.CreateAccountCreationTransaction(t, chain)
+		return sign(tx, chain.ServiceAddress(), unittest.ServiceAccountPrivateKey)
+	}
+	fail := func(account int) *flow.TransactionBody {
+		tx := flow.NewTransactionBody().
+			SetScript([]byte(panicTransaction)).
+			AddAuthorizer(accounts[account])
+		tx = sign(tx, accounts[account], privateKeys[account])
+		// failed transactions do not increment the sequence number
+		sequenceNumbers[accounts[account]]--
+		return tx
+	}
+
+	// transactions of the same account and account creations conflict with each other
+	block := blockFixture(
+		[]*flow.TransactionBody{
+			createAccount(),
+			increment(0),
+			increment(1),
+			increment(0),
+			createAccount(),
+			fail(2),
+			increment(0),
+		},
+		[]*flow.TransactionBody{
+			increment(1),
+			increment(3),
+			fail(2),
+			increment(2),
+			createAccount(),
+		},
+	)
+	transactionCount := 7 + 5 + 1 // including the system chunk transaction
+
+	execute := func(parallelism uint) (*execution.ComputationResult, *delta.View, int64) {
+		countingVM := &countingVM{vm: vm}
+		exe, err := computer.NewBlockComputer(countingVM, execCtx, nil, nil, zerolog.Nop(), parallelism)
+		require.NoError(t, err)
+
+		view := delta.NewView(readRegister)
+		result, err := exe.ExecuteBlock(context.Background(), block, view)
+		require.NoError(t, err)
+
+		return result, view, countingVM.runs
+	}
+
+	expected, expectedView, runs := execute(1)
+	require.Equal(t, int64(transactionCount), runs)
+
+	// the block contains both successful and failed transactions
+	failed := 0
+	for _, result := range expected.TransactionResult {
+		if result.ErrorMessage != "" {
+			failed++
+		}
+	}
+	require.Equal(t, 2, failed)
+
+	for _, parallelism := range []uint{2, 4, 8} {
+		t.Run(fmt.Sprintf("parallelism %d", parallelism), func(t *testing.T) {
+			// repeat the execution, as the order in which transactions are executed differs every time
+			for i := 0; i < 3; i++ {
+				actual, actualView, runs := execute(parallelism)
+
+				// the conflicting transactions were executed again
+				assert.Greater(t, runs, int64(transactionCount))
+
+				// SPoCK secrets are not compared, as Cadence writes the registers of a transaction in
+				// random order, so that they differ between serial executions as well
+
+				assert.Equal(t, expectedView.Delta(), actualView.Delta())
+				assert.Equal(t, expectedView.ReadsCount(), actualView.ReadsCount())
+				assert.ElementsMatch(t, expectedView.Interactions().Reads, actualView.Interactions().Reads)
+
+				assert.Equal(t, expected.Events, actual.Events)
+				assert.Equal(t, expected.TransactionResult, actual.TransactionResult)
+				assert.Equal(t, expected.GasUsed, actual.GasUsed)
+				assert.Equal(t, expected.StateReads, actual.StateReads)
+
+				require.Len(t, actual.StateSnapshots, len(expected.StateSnapshots))
+				for j, snapshot := range expected.StateSnapshots {
+					assert.Equal(t, snapshot.Delta, actual.StateSnapshots[j].Delta)
+					assert.ElementsMatch(t, snapshot.Reads, actual.StateSnapshots[j].Reads)
+				}
+			}
+		})
+	}
+}
+
+// blockFixture returns an executable block with a collection of each of the given transaction lists
+func blockFixture(collections ...[]*flow.TransactionBody) *entity.ExecutableBlock {
+	completeCollections := make(map[flow.Identifier]*entity.CompleteCollection, len(collections))
+	guarantees := make([]*flow.CollectionGuarantee, 0, len(collections))
+
+	for _, transactions := range collections {
+		collection := flow.Collection{Transactions: transactions}
+		guarantee := &flow.CollectionGuarantee{CollectionID: collection.ID()}
+		guarantees = append(guarantees, guarantee)
+		completeCollections[guarantee.ID()] = &entity.CompleteCollection{
+			Guarantee:    guarantee,
+			Transactions: transactions,
+		}
+	}
+
+	header := unittest.BlockHeaderFixture()
+	return &entity.ExecutableBlock{
+		Block: &flow.Block{
+			Header:  &header,
+			Payload: &flow.Payload{Guarantees: guarantees},
+		},
+		CompleteCollections: completeCollections,
+	}
+}
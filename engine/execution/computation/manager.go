@@ -26,12 +26,19 @@ type VirtualMachine interface {
 
 type ComputationManager interface {
 	ExecuteScript([]byte, [][]byte, *flow.Header, *delta.View) ([]byte, error)
+	ProfileScript([]byte, [][]byte, *flow.Header, *delta.View) ([]byte, *fvm.ExecutionProfile, error)
 	ComputeBlock(
 		ctx context.Context,
 		block *entity.ExecutableBlock,
 		view *delta.View,
 	) (*execution.ComputationResult, error)
 	GetAccount(addr flow.Address, header *flow.Header, view *delta.View) (*flow.Account, error)
+	SimulateTransaction(
+		tx *flow.TransactionBody,
+		header *flow.Header,
+		view *delta.View,
+		skipSignatureCheck bool,
+	) (*flow.TransactionSimulation, error)
 }
 
 // Manager manages computation and execution
@@ -52,6 +59,7 @@ func New(
 	protoState protocol.State,
 	vm VirtualMachine,
 	vmCtx fvm.Context,
+	parallelism uint,
 ) (*Manager, error) {
 	log := logger.With().Str("engine", "computation").Logger()
 
@@ -61,6 +69,7 @@ func New(
 		metrics,
 		tracer,
 		log.With().Str("component", "block_computer").Logger(),
+		parallelism,
 	)
 
 	if err != nil {
@@ -101,6 +110,40 @@ func (e *Manager) ExecuteScript(code []byte, arguments [][]byte, blockHeader *fl
 	return encodedValue, nil
 }
 
+// ProfileScript executes the script like ExecuteScript, and returns the profile of its execution.
+func (e *Manager) ProfileScript(
+	code []byte,
+	arguments [][]byte,
+	blockHeader *flow.Header,
+	view *delta.View,
+) ([]byte, *fvm.ExecutionProfile, error) {
+
+	profiler := fvm.NewExecutionProfiler()
+	blockCtx := fvm.NewContextFromParent(
+		e.vmCtx,
+		fvm.WithBlockHeader(blockHeader),
+		fvm.WithExecutionProfiler(profiler),
+	)
+
+	script := fvm.Script(code).WithArguments(arguments...)
+
+	err := e.vm.Run(blockCtx, script, view)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to execute script (internal error): %w", err)
+	}
+
+	if script.Err != nil {
+		return nil, nil, fmt.Errorf("failed to execute script at block (%s): %s", blockHeader.ID(), script.Err.Error())
+	}
+
+	encodedValue, err := jsoncdc.Encode(script.Value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode runtime value: %w", err)
+	}
+
+	return encodedValue, profiler.Profile(), nil
+}
+
 func (e *Manager) ComputeBlock(
 	ctx context.Context,
 	block *entity.ExecutableBlock,
@@ -137,3 +180,62 @@ func (e *Manager) GetAccount(address flow.Address, blockHeader *flow.Header, vie
 
 	return account, nil
 }
+
+// SimulateTransaction executes the transaction against the given view of the execution state of the block,
+// and returns its artifacts. The changes the transaction makes are only applied to a child of the view.
+func (e *Manager) SimulateTransaction(
+	tx *flow.TransactionBody,
+	blockHeader *flow.Header,
+	view *delta.View,
+	skipSignatureCheck bool,
+) (*flow.TransactionSimulation, error) {
+
+	processors := e.vmCtx.TransactionProcessors
+	if skipSignatureCheck {
+		processors = make([]fvm.TransactionProcessor, 0, len(e.vmCtx.TransactionProcessors))
+		for _, processor := range e.vmCtx.TransactionProcessors {
+			if _, ok := processor.(*fvm.TransactionSignatureVerifier); ok {
+				continue
+			}
+			processors = append(processors, processor)
+		}
+	}
+
+	blockCtx := fvm.NewContextFromParent(
+		e.vmCtx,
+		fvm.WithBlockHeader(blockHeader),
+		fvm.WithCadenceLogging(true),
+		fvm.WithTransactionProcessors(processors...),
+	)
+
+	txView := view.NewChild()
+	proc := fvm.Transaction(tx, 0)
+
+	err := e.vm.Run(blockCtx, proc, txView)
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate transaction (internal error): %w", err)
+	}
+
+	simulation := &flow.TransactionSimulation{
+		TransactionID:   proc.ID,
+		BlockID:         blockHeader.ID(),
+		Events:          proc.Events,
+		Logs:            proc.Logs,
+		ComputationUsed: proc.GasUsed,
+	}
+
+	ids, values := txView.Delta().RegisterUpdates()
+	for i, id := range ids {
+		simulation.RegisterUpdates = append(simulation.RegisterUpdates, flow.RegisterEntry{
+			Key:   id,
+			Value: values[i],
+		})
+	}
+
+	if proc.Err != nil {
+		simulation.ErrorCode = proc.Err.Code()
+		simulation.ErrorMessage = proc.Err.Error()
+	}
+
+	return simulation, nil
+}
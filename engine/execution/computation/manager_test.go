@@ -85,7 +85,7 @@ func TestComputeBlockWithStorage(t *testing.T) {
 	me := new(module.Local)
 	me.On("NodeID").Return(flow.ZeroID)
 
-	blockComputer, err := computer.NewBlockComputer(vm, execCtx, nil, nil, zerolog.Nop())
+	blockComputer, err := computer.NewBlockComputer(vm, execCtx, nil, nil, zerolog.Nop(), 1)
 	require.NoError(t, err)
 
 	engine := &Manager{
@@ -103,3 +103,98 @@ func TestComputeBlockWithStorage(t *testing.T) {
 	require.Len(t, returnedComputationResult.StateSnapshots, 1+1) // 1 coll + 1 system chunk
 	assert.NotEmpty(t, returnedComputationResult.StateSnapshots[0].Delta)
 }
+
+func TestSimulateTransaction(t *testing.T) {
+	rt := runtime.NewInterpreterRuntime()
+
+	chain := flow.Mainnet.Chain()
+
+	vm := fvm.New(rt)
+	execCtx := fvm.NewContext(zerolog.Nop(), fvm.WithChain(chain))
+
+	ledger := testutil.RootBootstrappedLedger(vm, execCtx)
+
+	// the transaction is not signed
+	tx := flow.NewTransactionBody().
+		SetScript([]byte(`transaction { prepare(signer: AuthAccount) { log("simulated") } }`)).
+		SetProposalKey(chain.ServiceAddress(), 0, 0).
+		SetPayer(chain.ServiceAddress()).
+		AddAuthorizer(chain.ServiceAddress())
+
+	header := unittest.BlockHeaderFixture()
+
+	engine := &Manager{
+		vm:    vm,
+		vmCtx: execCtx,
+	}
+
+	t.Run("signature check skipped", func(t *testing.T) {
+		view := delta.NewView(ledger.Get)
+
+		simulation, err := engine.SimulateTransaction(tx, &header, view, true)
+		require.NoError(t, err)
+
+		assert.Equal(t, tx.ID(), simulation.TransactionID)
+		assert.Equal(t, header.ID(), simulation.BlockID)
+		assert.Equal(t, uint32(0), simulation.ErrorCode)
+		assert.Empty(t, simulation.ErrorMessage)
+		assert.Equal(t, []string{`"simulated"`}, simulation.Logs)
+		assert.NotEmpty(t, simulation.RegisterUpdates)
+
+		// the changes of the transaction are not applied to the view
+		assert.Empty(t, view.Delta().Data)
+	})
+
+	t.Run("signature checked", func(t *testing.T) {
+		view := delta.NewView(ledger.Get)
+
+		simulation, err := engine.SimulateTransaction(tx, &header, view, false)
+		require.NoError(t, err)
+
+		assert.NotEqual(t, uint32(0), simulation.ErrorCode)
+		assert.NotEmpty(t, simulation.ErrorMessage)
+		assert.Empty(t, simulation.Logs)
+	})
+}
+
+func TestProfileScript(t *testing.T) {
+	rt := runtime.NewInterpreterRuntime()
+
+	chain := flow.Mainnet.Chain()
+
+	vm := fvm.New(rt)
+	execCtx := fvm.NewContext(zerolog.Nop(), fvm.WithChain(chain))
+
+	ledger := testutil.RootBootstrappedLedger(vm, execCtx)
+
+	header := unittest.BlockHeaderFixture()
+
+	engine := &Manager{
+		vm:    vm,
+		vmCtx: execCtx,
+	}
+
+	script := []byte(`
+		pub fun main(): Int {
+			var i = 0
+			while i < 3 {
+				i = i + 1
+			}
+			return i
+		}
+	`)
+
+	view := delta.NewView(ledger.Get)
+
+	value, profile, err := engine.ProfileScript(script, nil, &header, view)
+	require.NoError(t, err)
+
+	expected, err := engine.ExecuteScript(script, nil, &header, view)
+	require.NoError(t, err)
+	assert.Equal(t, expected, value)
+
+	require.Len(t, profile.Functions, 1)
+	assert.Equal(t, "main", profile.Functions[0].Function)
+	// the declaration, the loop and its body, and the return statement
+	assert.Equal(t, uint64(1+1+3+1), profile.Computation)
+}
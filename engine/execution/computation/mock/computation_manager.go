@@ -12,6 +12,8 @@ import (
 
 	flow "github.com/onflow/flow-go/model/flow"
 
+	fvm "github.com/onflow/flow-go/fvm"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -88,3 +90,58 @@ func (_m *ComputationManager) GetAccount(addr flow.Address, header *flow.Header,
 
 	return r0, r1
 }
+
+// ProfileScript provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *ComputationManager) ProfileScript(_a0 []byte, _a1 [][]byte, _a2 *flow.Header, _a3 *delta.View) ([]byte, *fvm.ExecutionProfile, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func([]byte, [][]byte, *flow.Header, *delta.View) []byte); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 *fvm.ExecutionProfile
+	if rf, ok := ret.Get(1).(func([]byte, [][]byte, *flow.Header, *delta.View) *fvm.ExecutionProfile); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*fvm.ExecutionProfile)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func([]byte, [][]byte, *flow.Header, *delta.View) error); ok {
+		r2 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// SimulateTransaction provides a mock function with given fields: tx, header, view, skipSignatureCheck
+func (_m *ComputationManager) SimulateTransaction(tx *flow.TransactionBody, header *flow.Header, view *delta.View, skipSignatureCheck bool) (*flow.TransactionSimulation, error) {
+	ret := _m.Called(tx, header, view, skipSignatureCheck)
+
+	var r0 *flow.TransactionSimulation
+	if rf, ok := ret.Get(0).(func(*flow.TransactionBody, *flow.Header, *delta.View, bool) *flow.TransactionSimulation); ok {
+		r0 = rf(tx, header, view, skipSignatureCheck)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*flow.TransactionSimulation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*flow.TransactionBody, *flow.Header, *delta.View, bool) error); ok {
+		r1 = rf(tx, header, view, skipSignatureCheck)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
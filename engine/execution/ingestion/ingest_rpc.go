@@ -3,6 +3,7 @@ package ingestion
 import (
 	"context"
 
+	"github.com/onflow/flow-go/fvm"
 	"github.com/onflow/flow-go/model/flow"
 )
 
@@ -12,6 +13,12 @@ type IngestRPC interface {
 	// ExecuteScriptAtBlockID executes a script at the given Block id
 	ExecuteScriptAtBlockID(ctx context.Context, script []byte, arguments [][]byte, blockID flow.Identifier) ([]byte, error)
 
+	// ProfileScriptAtBlockID executes a script at the given Block id, and returns the profile of its execution
+	ProfileScriptAtBlockID(ctx context.Context, script []byte, arguments [][]byte, blockID flow.Identifier) ([]byte, *fvm.ExecutionProfile, error)
+
 	// GetAccount returns the Account details at the given Block id
 	GetAccount(ctx context.Context, address flow.Address, blockID flow.Identifier) (*flow.Account, error)
+
+	// SimulateTransaction executes a transaction at the given Block id, without committing its changes
+	SimulateTransaction(ctx context.Context, tx *flow.TransactionBody, blockID flow.Identifier, skipSignatureCheck bool) (*flow.TransactionSimulation, error)
 }
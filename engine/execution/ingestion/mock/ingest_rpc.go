@@ -7,6 +7,8 @@ import (
 
 	flow "github.com/onflow/flow-go/model/flow"
 
+	fvm "github.com/onflow/flow-go/fvm"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -60,3 +62,58 @@ func (_m *IngestRPC) GetAccount(ctx context.Context, address flow.Address, block
 
 	return r0, r1
 }
+
+// ProfileScriptAtBlockID provides a mock function with given fields: ctx, script, arguments, blockID
+func (_m *IngestRPC) ProfileScriptAtBlockID(ctx context.Context, script []byte, arguments [][]byte, blockID flow.Identifier) ([]byte, *fvm.ExecutionProfile, error) {
+	ret := _m.Called(ctx, script, arguments, blockID)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, [][]byte, flow.Identifier) []byte); ok {
+		r0 = rf(ctx, script, arguments, blockID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 *fvm.ExecutionProfile
+	if rf, ok := ret.Get(1).(func(context.Context, []byte, [][]byte, flow.Identifier) *fvm.ExecutionProfile); ok {
+		r1 = rf(ctx, script, arguments, blockID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*fvm.ExecutionProfile)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, []byte, [][]byte, flow.Identifier) error); ok {
+		r2 = rf(ctx, script, arguments, blockID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// SimulateTransaction provides a mock function with given fields: ctx, tx, blockID, skipSignatureCheck
+func (_m *IngestRPC) SimulateTransaction(ctx context.Context, tx *flow.TransactionBody, blockID flow.Identifier, skipSignatureCheck bool) (*flow.TransactionSimulation, error) {
+	ret := _m.Called(ctx, tx, blockID, skipSignatureCheck)
+
+	var r0 *flow.TransactionSimulation
+	if rf, ok := ret.Get(0).(func(context.Context, *flow.TransactionBody, flow.Identifier, bool) *flow.TransactionSimulation); ok {
+		r0 = rf(ctx, tx, blockID, skipSignatureCheck)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*flow.TransactionSimulation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *flow.TransactionBody, flow.Identifier, bool) error); ok {
+		r1 = rf(ctx, tx, blockID, skipSignatureCheck)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -14,6 +14,9 @@ import (
 
 	"github.com/onflow/flow-go/engine"
 	"github.com/onflow/flow-go/engine/common/rpc/convert"
+	"github.com/onflow/flow-go/engine/common/rpc/profiling"
+	"github.com/onflow/flow-go/engine/common/rpc/results"
+	"github.com/onflow/flow-go/engine/common/rpc/simulation"
 	"github.com/onflow/flow-go/engine/execution/ingestion"
 	"github.com/onflow/flow-go/model/flow"
 	"github.com/onflow/flow-go/storage"
@@ -70,6 +73,9 @@ func New(
 	}
 
 	execution.RegisterExecutionAPIServer(eng.server, eng.handler)
+	simulation.RegisterServer(eng.server, eng.handler)
+	profiling.RegisterServer(eng.server, eng.handler)
+	results.RegisterServer(eng.server, eng.handler)
 
 	return eng
 }
@@ -117,6 +123,9 @@ type handler struct {
 }
 
 var _ execution.ExecutionAPIServer = &handler{}
+var _ simulation.API = &handler{}
+var _ profiling.API = &handler{}
+var _ results.API = &handler{}
 
 // Ping responds to requests when the server is up.
 func (h *handler) Ping(ctx context.Context, req *execution.PingRequest) (*execution.PingResponse, error) {
@@ -145,6 +154,53 @@ func (h *handler) ExecuteScriptAtBlockID(
 	return res, nil
 }
 
+// ProfileScriptAtBlockID executes the script at the given block, and returns the profile of its execution.
+func (h *handler) ProfileScriptAtBlockID(
+	ctx context.Context,
+	req *profiling.Request,
+) (*profiling.Response, error) {
+
+	if _, err := h.exeResults.ByBlockID(req.BlockID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "block %s has not been executed", req.BlockID)
+		}
+		return nil, status.Errorf(codes.Internal, "results for block ID %s could not be retrieved", req.BlockID)
+	}
+
+	value, profile, err := h.engine.ProfileScriptAtBlockID(ctx, req.Script, req.Arguments, req.BlockID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to execute script: %v", err)
+	}
+
+	res := &profiling.Response{
+		Value:   value,
+		Profile: profile,
+	}
+
+	return res, nil
+}
+
+// SimulateTransaction executes the transaction at the given block, without committing its changes.
+func (h *handler) SimulateTransaction(
+	ctx context.Context,
+	req *simulation.Request,
+) (*flow.TransactionSimulation, error) {
+
+	if _, err := h.exeResults.ByBlockID(req.BlockID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "block %s has not been executed", req.BlockID)
+		}
+		return nil, status.Errorf(codes.Internal, "results for block ID %s could not be retrieved", req.BlockID)
+	}
+
+	result, err := h.engine.SimulateTransaction(ctx, req.Transaction, req.BlockID, req.SkipSignatureCheck)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to simulate transaction: %v", err)
+	}
+
+	return result, nil
+}
+
 func (h *handler) GetEventsForBlockIDs(_ context.Context,
 	req *execution.GetEventsForBlockIDsRequest) (*execution.GetEventsForBlockIDsResponse, error) {
 
@@ -208,9 +264,6 @@ func (h *handler) GetTransactionResult(
 		return nil, err
 	}
 
-	var statusCode uint32 = 0
-	errMsg := ""
-
 	// lookup any transaction error that might have occurred
 	txResult, err := h.transactionResults.ByBlockIDTransactionID(blockID, txID)
 	if err != nil {
@@ -220,11 +273,6 @@ func (h *handler) GetTransactionResult(
 
 		return nil, status.Errorf(codes.Internal, "failed to get transaction result: %v", err)
 	}
-	if txResult.ErrorMessage != "" {
-		statusCode = 1 // for now a statusCode of 1 indicates an error and 0 indicates no error
-		errMsg = txResult.ErrorMessage
-	}
-
 	// lookup events by block id and transaction ID
 	blockEvents, err := h.events.ByBlockIDTransactionID(blockID, txID)
 	if err != nil {
@@ -235,12 +283,54 @@ func (h *handler) GetTransactionResult(
 
 	// compose a response with the events and the transaction error
 	return &execution.GetTransactionResultResponse{
-		StatusCode:   statusCode,
-		ErrorMessage: errMsg,
+		StatusCode:   txResult.StatusCode(),
+		ErrorMessage: txResult.ErrorMessage,
 		Events:       events,
 	}, nil
 }
 
+// GetTransactionResults returns the results of the given transactions of the block, with the events of
+// the block looked up once for all of them.
+func (h *handler) GetTransactionResults(
+	_ context.Context,
+	req *results.Request,
+) ([]results.Result, error) {
+
+	txResults := make([]results.Result, 0, len(req.TransactionIDs))
+	for _, txID := range req.TransactionIDs {
+		txResult, err := h.transactionResults.ByBlockIDTransactionID(req.BlockID, txID)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return nil, status.Errorf(codes.NotFound, "result of transaction %s not found", txID)
+			}
+			return nil, status.Errorf(codes.Internal, "failed to get transaction result: %v", err)
+		}
+		txResults = append(txResults, results.Result{
+			TransactionID: txID,
+			StatusCode:    txResult.StatusCode(),
+			ErrorMessage:  txResult.ErrorMessage,
+		})
+	}
+
+	blockEvents, err := h.events.ByBlockID(req.BlockID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get events for block: %v", err)
+	}
+
+	positions := make(map[flow.Identifier]int, len(txResults))
+	for i, txResult := range txResults {
+		positions[txResult.TransactionID] = i
+	}
+	for _, event := range blockEvents {
+		i, ok := positions[event.TransactionID]
+		if ok {
+			txResults[i].Events = append(txResults[i].Events, event)
+		}
+	}
+
+	return txResults, nil
+}
+
 // eventResult creates EventsResponse_Result from flow.Event for the given blockID
 func (h *handler) eventResult(blockID flow.Identifier,
 	flowEvents []flow.Event) (*execution.GetEventsForBlockIDsResponse_Result, error) {
@@ -15,7 +15,11 @@ import (
 	"github.com/onflow/flow/protobuf/go/flow/execution"
 
 	"github.com/onflow/flow-go/engine/common/rpc/convert"
+	rpcprofiling "github.com/onflow/flow-go/engine/common/rpc/profiling"
+	rpcresults "github.com/onflow/flow-go/engine/common/rpc/results"
+	rpcsimulation "github.com/onflow/flow-go/engine/common/rpc/simulation"
 	ingestion "github.com/onflow/flow-go/engine/execution/ingestion/mock"
+	"github.com/onflow/flow-go/fvm"
 	"github.com/onflow/flow-go/model/flow"
 	realstorage "github.com/onflow/flow-go/storage"
 	storage "github.com/onflow/flow-go/storage/mock"
@@ -232,6 +236,123 @@ func (suite *Suite) TestGetAccountAtBlockID() {
 	})
 }
 
+// TestProfileScriptAtBlockID tests the ProfileScriptAtBlockID API call
+func (suite *Suite) TestProfileScriptAtBlockID() {
+
+	block := unittest.BlockFixture()
+	blockID := block.ID()
+	script := []byte("pub fun main(): Int { return 1 }")
+	arguments := [][]byte{[]byte("argument")}
+
+	value := []byte("value")
+	profile := &fvm.ExecutionProfile{
+		Computation: 1,
+		Functions: []fvm.FunctionProfile{
+			{Location: "s.0123", Function: "main", Line: 1, Computation: 1},
+		},
+	}
+
+	mockEngine := new(ingestion.IngestRPC)
+
+	// create the handler
+	handler := &handler{
+		engine:     mockEngine,
+		chain:      flow.Mainnet,
+		exeResults: suite.exeResults,
+	}
+
+	req := &rpcprofiling.Request{
+		BlockID:   blockID,
+		Script:    script,
+		Arguments: arguments,
+	}
+
+	suite.Run("happy path with executed block", func() {
+
+		// setup mock expectations
+		suite.exeResults.On("ByBlockID", blockID).Return(nil, nil).Once()
+		mockEngine.On("ProfileScriptAtBlockID", mock.Anything, script, arguments, blockID).Return(value, profile, nil).Once()
+
+		res, err := handler.ProfileScriptAtBlockID(context.Background(), req)
+
+		suite.Require().NoError(err)
+		suite.Require().Equal(value, res.Value)
+		suite.Require().Equal(profile, res.Profile)
+		mockEngine.AssertExpectations(suite.T())
+	})
+
+	suite.Run("block not executed", func() {
+
+		// setup mock expectations
+		suite.exeResults.On("ByBlockID", blockID).Return(nil, realstorage.ErrNotFound).Once()
+
+		_, err := handler.ProfileScriptAtBlockID(context.Background(), req)
+
+		suite.Require().Error(err)
+		suite.Require().Equal(codes.NotFound, status.Code(err))
+		mockEngine.AssertNumberOfCalls(suite.T(), "ProfileScriptAtBlockID", 1)
+	})
+}
+
+// TestSimulateTransaction tests the SimulateTransaction API call
+func (suite *Suite) TestSimulateTransaction() {
+
+	block := unittest.BlockFixture()
+	blockID := block.ID()
+	tx := unittest.TransactionBodyFixture()
+
+	simulation := &flow.TransactionSimulation{
+		TransactionID:   tx.ID(),
+		BlockID:         blockID,
+		ComputationUsed: 10,
+	}
+
+	mockEngine := new(ingestion.IngestRPC)
+
+	// create the handler
+	handler := &handler{
+		engine:     mockEngine,
+		chain:      flow.Mainnet,
+		exeResults: suite.exeResults,
+	}
+
+	suite.Run("happy path with executed block", func() {
+
+		// setup mock expectations
+		suite.exeResults.On("ByBlockID", blockID).Return(nil, nil).Once()
+		mockEngine.On("SimulateTransaction", mock.Anything, &tx, blockID, true).Return(simulation, nil).Once()
+
+		req := &rpcsimulation.Request{
+			BlockID:            blockID,
+			Transaction:        &tx,
+			SkipSignatureCheck: true,
+		}
+
+		actual, err := handler.SimulateTransaction(context.Background(), req)
+
+		suite.Require().NoError(err)
+		suite.Require().Equal(simulation, actual)
+		mockEngine.AssertExpectations(suite.T())
+	})
+
+	suite.Run("block not executed", func() {
+
+		// setup mock expectations
+		suite.exeResults.On("ByBlockID", blockID).Return(nil, realstorage.ErrNotFound).Once()
+
+		req := &rpcsimulation.Request{
+			BlockID:     blockID,
+			Transaction: &tx,
+		}
+
+		_, err := handler.SimulateTransaction(context.Background(), req)
+
+		suite.Require().Error(err)
+		suite.Require().Equal(codes.NotFound, status.Code(err))
+		mockEngine.AssertNumberOfCalls(suite.T(), "SimulateTransaction", 1)
+	})
+}
+
 // TestGetTransactionResult tests the GetTransactionResult API call
 func (suite *Suite) TestGetTransactionResult() {
 
@@ -355,6 +476,44 @@ func (suite *Suite) TestGetTransactionResult() {
 		txResults.AssertExpectations(suite.T())
 	})
 
+	// happy path - the error code of the transaction error is returned as the status code
+	suite.Run("happy path with a transaction error code", func() {
+
+		// create the expected result
+		expectedResult := &execution.GetTransactionResultResponse{
+			StatusCode:   fvm.ErrCodeConditionFailed,
+			ErrorMessage: "post-condition failed",
+			Events:       eventMessages,
+		}
+
+		// setup the storage to return a transaction error with a code
+		txResults := new(storage.TransactionResults)
+		txResult := flow.TransactionResult{
+			TransactionID: txID,
+			ErrorMessage:  "post-condition failed",
+			ErrorCode:     fvm.ErrCodeConditionFailed,
+		}
+		txResults.On("ByBlockIDTransactionID", bID, txID).Return(&txResult, nil).Once()
+
+		handler := createHandler(txResults)
+
+		// create a valid API request
+		req := concoctReq(bID[:], txID[:])
+
+		// execute the GetTransactionResult call
+		actualResult, err := handler.GetTransactionResult(context.Background(), req)
+
+		// check that a successful response is received
+		suite.Require().NoError(err)
+
+		// check that all fields in response are as expected
+		assertEqual(expectedResult, actualResult)
+
+		// check that appropriate storage calls were made
+		suite.events.AssertExpectations(suite.T())
+		txResults.AssertExpectations(suite.T())
+	})
+
 	// failure path - nil transaction ID in the request results in an error
 	suite.Run("request with nil tx ID", func() {
 
@@ -424,3 +583,79 @@ func (suite *Suite) TestGetTransactionResult() {
 		suite.events.AssertExpectations(suite.T())
 	})
 }
+
+// TestGetTransactionResults tests the GetTransactionResults API call
+func (suite *Suite) TestGetTransactionResults() {
+
+	block := unittest.BlockFixture()
+	bID := block.ID()
+	txIDs := unittest.IdentifierListFixture(2)
+
+	// the events of the block, including those of a transaction which is not requested
+	event1 := unittest.EventFixture(flow.EventAccountCreated, 0, 0, txIDs[0])
+	event2 := unittest.EventFixture(flow.EventAccountCreated, 1, 0, unittest.IdentifierFixture())
+	event3 := unittest.EventFixture(flow.EventAccountCreated, 2, 0, txIDs[1])
+	event4 := unittest.EventFixture(flow.EventAccountCreated, 2, 1, txIDs[1])
+
+	req := &rpcresults.Request{
+		BlockID:        bID,
+		TransactionIDs: txIDs,
+	}
+
+	suite.Run("happy path with the events of each transaction", func() {
+
+		events := new(storage.Events)
+		events.On("ByBlockID", bID).Return([]flow.Event{event1, event2, event3, event4}, nil).Once()
+
+		txResults := new(storage.TransactionResults)
+		txResults.On("ByBlockIDTransactionID", bID, txIDs[0]).Return(&flow.TransactionResult{TransactionID: txIDs[0]}, nil).Once()
+		txResults.On("ByBlockIDTransactionID", bID, txIDs[1]).Return(&flow.TransactionResult{
+			TransactionID: txIDs[1],
+			ErrorMessage:  "post-condition failed",
+			ErrorCode:     fvm.ErrCodeConditionFailed,
+		}, nil).Once()
+
+		handler := &handler{
+			events:             events,
+			transactionResults: txResults,
+			chain:              flow.Mainnet,
+		}
+
+		actual, err := handler.GetTransactionResults(context.Background(), req)
+		suite.Require().NoError(err)
+
+		expected := []rpcresults.Result{
+			{
+				TransactionID: txIDs[0],
+				Events:        []flow.Event{event1},
+			},
+			{
+				TransactionID: txIDs[1],
+				StatusCode:    fvm.ErrCodeConditionFailed,
+				ErrorMessage:  "post-condition failed",
+				Events:        []flow.Event{event3, event4},
+			},
+		}
+		suite.Require().Equal(expected, actual)
+
+		// the events of the block are looked up once for all transactions
+		events.AssertExpectations(suite.T())
+		txResults.AssertExpectations(suite.T())
+	})
+
+	suite.Run("transaction result not found", func() {
+
+		txResults := new(storage.TransactionResults)
+		txResults.On("ByBlockIDTransactionID", bID, txIDs[0]).Return(nil, realstorage.ErrNotFound).Once()
+
+		handler := &handler{
+			events:             new(storage.Events),
+			transactionResults: txResults,
+			chain:              flow.Mainnet,
+		}
+
+		_, err := handler.GetTransactionResults(context.Background(), req)
+		suite.Require().Error(err)
+		suite.Require().Equal(codes.NotFound, status.Code(err))
+	})
+}
@@ -23,6 +23,7 @@ type View struct {
 	// for views other than collection views to improve performance
 	spockSecretHasher hash.Hasher
 	readFunc          GetRegisterFunc
+	parent            *View // the view this view was created from with NewChild, nil for root views
 }
 
 type Snapshot struct {
@@ -94,7 +95,9 @@ func (r *Snapshot) AllRegisters() []flow.RegisterID {
 
 // NewChild generates a new child view, with the current view as the base, sharing the Get function
 func (v *View) NewChild() *View {
-	return NewView(v.Get)
+	child := NewView(v.Get)
+	child.parent = v
+	return child
 }
 
 // Get gets a register value from this view.
@@ -130,6 +133,25 @@ func (v *View) Get(owner, controller, key string) (flow.RegisterValue, error) {
 	return value, err
 }
 
+// Peek gets a register value from this view without recording the read, i.e. without touching
+// the register, increasing the reads count or updating the SPoCK secret of this view or any of
+// its parents.
+//
+// Registers that are neither in the delta of this view nor of any of its parents are read with
+// the read function of the root view, which therefore must not have side effects either.
+func (v *View) Peek(owner, controller, key string) (flow.RegisterValue, error) {
+	value, exists := v.delta.Get(owner, controller, key)
+	if exists {
+		return value, nil
+	}
+
+	if v.parent != nil {
+		return v.parent.Peek(owner, controller, key)
+	}
+
+	return v.readFunc(owner, controller, key)
+}
+
 // Set sets a register value in this view.
 func (v *View) Set(owner, controller, key string, value flow.RegisterValue) error {
 	// every time we write something to delta (order preserving) we update spock
@@ -55,6 +55,59 @@ func TestView_Get(t *testing.T) {
 	})
 }
 
+func TestView_Peek(t *testing.T) {
+	registerID := "fruit"
+
+	reads := 0
+	v := delta.NewView(func(owner, controller, key string) (flow.RegisterValue, error) {
+		reads++
+		if owner == registerID {
+			return flow.RegisterValue("orange"), nil
+		}
+		return nil, nil
+	})
+	child := v.NewChild()
+	spockSecret := child.SpockSecret()
+
+	t.Run("ValueNotSet", func(t *testing.T) {
+		b, err := child.Peek(registerID, "", "")
+		assert.NoError(t, err)
+		assert.Equal(t, flow.RegisterValue("orange"), b)
+	})
+
+	t.Run("ValueInParent", func(t *testing.T) {
+		err := v.Set(registerID, "", "", flow.RegisterValue("apple"))
+		assert.NoError(t, err)
+
+		b, err := child.Peek(registerID, "", "")
+		assert.NoError(t, err)
+		assert.Equal(t, flow.RegisterValue("apple"), b)
+	})
+
+	t.Run("ValueInChild", func(t *testing.T) {
+		err := child.Set(registerID, "", "", flow.RegisterValue("banana"))
+		assert.NoError(t, err)
+
+		b, err := child.Peek(registerID, "", "")
+		assert.NoError(t, err)
+		assert.Equal(t, flow.RegisterValue("banana"), b)
+
+		spockSecret = child.SpockSecret()
+	})
+
+	t.Run("ReadsNotRecorded", func(t *testing.T) {
+		_, err := child.Peek("vegetable", "", "")
+		assert.NoError(t, err)
+
+		assert.Equal(t, 2, reads)
+		assert.Equal(t, uint64(0), child.ReadsCount())
+		assert.Equal(t, uint64(0), v.ReadsCount())
+		assert.Len(t, child.Interactions().Reads, 1) // the register set in the child
+		assert.Len(t, v.Interactions().Reads, 1)     // the register set in the parent
+		assert.Equal(t, spockSecret, child.SpockSecret())
+	})
+}
+
 func TestView_Set(t *testing.T) {
 	registerID := "fruit"
 
@@ -179,6 +179,29 @@ func (_m *ReadOnlyExecutionState) NewView(_a0 []byte) *delta.View {
 	return r0
 }
 
+// RegisterHistory provides a mock function with given fields: ctx, registerID, fromHeight, toHeight
+func (_m *ReadOnlyExecutionState) RegisterHistory(ctx context.Context, registerID flow.RegisterID, fromHeight uint64, toHeight uint64) ([]flow.RegisterWrite, error) {
+	ret := _m.Called(ctx, registerID, fromHeight, toHeight)
+
+	var r0 []flow.RegisterWrite
+	if rf, ok := ret.Get(0).(func(context.Context, flow.RegisterID, uint64, uint64) []flow.RegisterWrite); ok {
+		r0 = rf(ctx, registerID, fromHeight, toHeight)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]flow.RegisterWrite)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, flow.RegisterID, uint64, uint64) error); ok {
+		r1 = rf(ctx, registerID, fromHeight, toHeight)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // RetrieveStateDelta provides a mock function with given fields: _a0, _a1
 func (_m *ReadOnlyExecutionState) RetrieveStateDelta(_a0 context.Context, _a1 flow.Identifier) (*messages.ExecutionStateDelta, error) {
 	ret := _m.Called(_a0, _a1)
@@ -49,8 +49,16 @@ type ReadOnlyExecutionState interface {
 	GetHighestExecutedBlockID(context.Context) (uint64, flow.Identifier, error)
 
 	GetCollection(identifier flow.Identifier) (*flow.Collection, error)
+
+	// RegisterHistory returns the executed blocks with heights in [fromHeight, toHeight], which
+	// wrote the given register, ordered by height. It requires the register history index.
+	RegisterHistory(ctx context.Context, registerID flow.RegisterID, fromHeight uint64, toHeight uint64) ([]flow.RegisterWrite, error)
 }
 
+// ErrRegisterHistoryDisabled is returned when querying the register history,
+// if the execution state does not maintain the register history index.
+var ErrRegisterHistoryDisabled = errors.New("register history index is disabled")
+
 // IsBlockExecuted returns whether the block has been executed.
 // it checks whether the state commitment exists in execution state.
 func IsBlockExecuted(ctx context.Context, state ReadOnlyExecutionState, block flow.Identifier) (bool, error) {
@@ -111,6 +119,21 @@ type state struct {
 	results        storage.ExecutionResults
 	receipts       storage.ExecutionReceipts
 	db             *badger.DB
+
+	// registerHistory enables the index mapping registers to the blocks which wrote them
+	registerHistory bool
+}
+
+// OptionFunc is a functional option for the execution state
+type OptionFunc func(*state)
+
+// WithRegisterHistory enables the register history index. For every block, whose state
+// interactions are persisted, the registers updated by the block's delta (the delta committed
+// with CommitDelta) are indexed with the block's height, see RegisterHistory.
+func WithRegisterHistory() OptionFunc {
+	return func(s *state) {
+		s.registerHistory = true
+	}
 }
 
 func (s *state) PersistExecutionResult(ctx context.Context, executionResult *flow.ExecutionResult) error {
@@ -146,8 +169,9 @@ func NewExecutionState(
 	receipts storage.ExecutionReceipts,
 	db *badger.DB,
 	tracer module.Tracer,
+	opts ...OptionFunc,
 ) ExecutionState {
-	return &state{
+	s := &state{
 		tracer:         tracer,
 		ls:             ls,
 		commits:        commits,
@@ -158,7 +182,10 @@ func NewExecutionState(
 		receipts:       receipts,
 		db:             db,
 	}
-
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func makeSingleValueQuery(commitment flow.StateCommitment, owner, controller, key string) (*ledger.Query, error) {
@@ -376,7 +403,60 @@ func (s *state) PersistStateInteractions(ctx context.Context, blockID flow.Ident
 		defer span.Finish()
 	}
 
-	return operation.RetryOnConflict(s.db.Update, operation.InsertExecutionStateInteractions(blockID, views))
+	if !s.registerHistory {
+		return operation.RetryOnConflict(s.db.Update, operation.InsertExecutionStateInteractions(blockID, views))
+	}
+
+	return operation.RetryOnConflict(s.db.Update, func(txn *badger.Txn) error {
+		err := operation.InsertExecutionStateInteractions(blockID, views)(txn)
+		if err != nil {
+			return fmt.Errorf("cannot insert state interactions: %w", err)
+		}
+
+		var header flow.Header
+		err = operation.RetrieveHeader(blockID, &header)(txn)
+		if err != nil {
+			return fmt.Errorf("cannot retrieve block header: %w", err)
+		}
+
+		write := flow.RegisterWrite{Height: header.Height, BlockID: blockID}
+		indexed := make(map[flow.RegisterID]struct{})
+		for _, view := range views {
+			for _, registerID := range view.Delta.RegisterIDs() {
+				if _, ok := indexed[registerID]; ok {
+					continue
+				}
+				indexed[registerID] = struct{}{}
+
+				err = operation.IndexRegisterWrite(registerID, write)(txn)
+				if err != nil {
+					return fmt.Errorf("cannot index register write: %w", err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (s *state) RegisterHistory(ctx context.Context, registerID flow.RegisterID, fromHeight uint64, toHeight uint64) ([]flow.RegisterWrite, error) {
+	if s.tracer != nil {
+		span, _ := s.tracer.StartSpanFromContext(ctx, trace.EXERegisterHistory)
+		defer span.Finish()
+	}
+
+	if !s.registerHistory {
+		return nil, ErrRegisterHistoryDisabled
+	}
+	if fromHeight > toHeight {
+		return nil, fmt.Errorf("invalid height range [%d, %d]", fromHeight, toHeight)
+	}
+
+	var writes []flow.RegisterWrite
+	err := s.db.View(operation.LookupRegisterHistory(registerID, fromHeight, toHeight, &writes))
+	if err != nil {
+		return nil, fmt.Errorf("cannot lookup register history: %w", err)
+	}
+	return writes, nil
 }
 
 func (s *state) RetrieveStateDelta(ctx context.Context, blockID flow.Identifier) (*messages.ExecutionStateDelta, error) {
@@ -2,6 +2,7 @@ package state_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/dgraph-io/badger/v2"
@@ -11,9 +12,11 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/onflow/flow-go/engine/execution/state"
+	"github.com/onflow/flow-go/engine/execution/state/delta"
 	ledger "github.com/onflow/flow-go/ledger/complete"
 	"github.com/onflow/flow-go/model/flow"
 	"github.com/onflow/flow-go/module/metrics"
+	"github.com/onflow/flow-go/storage/badger/operation"
 	storage "github.com/onflow/flow-go/storage/mock"
 	"github.com/onflow/flow-go/storage/mocks"
 	"github.com/onflow/flow-go/utils/unittest"
@@ -185,3 +188,77 @@ func TestExecutionStateWithTrieStorage(t *testing.T) {
 	}))
 
 }
+
+func TestRegisterHistory(t *testing.T) {
+
+	register1 := flow.NewRegisterID("fruit", "", "")
+	register2 := flow.NewRegisterID("vegetable", "", "")
+
+	newState := func(t *testing.T, db *badger.DB, dbDir string, opts ...state.OptionFunc) state.ExecutionState {
+		ls, err := ledger.NewLedger(dbDir, 100, &metrics.NoopCollector{}, zerolog.Nop(), nil, ledger.DefaultPathFinderVersion)
+		require.NoError(t, err)
+		return state.NewExecutionState(
+			ls, nil, nil, nil, nil, nil, nil, db, nil, opts...,
+		)
+	}
+
+	// persistBlock stores a header with the given height and persists the state interactions of its delta
+	persistBlock := func(t *testing.T, db *badger.DB, es state.ExecutionState, height uint64, registers ...flow.RegisterID) flow.Identifier {
+		header := unittest.BlockHeaderFixture()
+		header.Height = height
+		blockID := header.ID()
+		err := db.Update(operation.InsertHeader(blockID, &header))
+		require.NoError(t, err)
+
+		d := delta.NewDelta()
+		for _, register := range registers {
+			d.Set(register.Owner, register.Controller, register.Key, flow.RegisterValue("apple"))
+		}
+		// a register updated by several collections is indexed once
+		views := []*delta.Snapshot{{Delta: d}, {Delta: d}}
+		err = es.PersistStateInteractions(context.Background(), blockID, views)
+		require.NoError(t, err)
+		return blockID
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		unittest.RunWithBadgerDB(t, func(db *badger.DB) {
+			unittest.RunWithTempDir(t, func(dbDir string) {
+				es := newState(t, db, dbDir)
+				persistBlock(t, db, es, 10, register1)
+
+				_, err := es.RegisterHistory(context.Background(), register1, 0, 100)
+				require.True(t, errors.Is(err, state.ErrRegisterHistoryDisabled))
+			})
+		})
+	})
+
+	t.Run("blocks writing a register are indexed", func(t *testing.T) {
+		unittest.RunWithBadgerDB(t, func(db *badger.DB) {
+			unittest.RunWithTempDir(t, func(dbDir string) {
+				es := newState(t, db, dbDir, state.WithRegisterHistory())
+				block10 := persistBlock(t, db, es, 10, register1, register2)
+				persistBlock(t, db, es, 11, register2)
+				block12 := persistBlock(t, db, es, 12, register1)
+
+				writes, err := es.RegisterHistory(context.Background(), register1, 0, 100)
+				require.NoError(t, err)
+				require.Equal(t, []flow.RegisterWrite{
+					{Height: 10, BlockID: block10},
+					{Height: 12, BlockID: block12},
+				}, writes)
+
+				writes, err = es.RegisterHistory(context.Background(), register1, 11, 12)
+				require.NoError(t, err)
+				require.Equal(t, []flow.RegisterWrite{{Height: 12, BlockID: block12}}, writes)
+
+				writes, err = es.RegisterHistory(context.Background(), register2, 12, 20)
+				require.NoError(t, err)
+				require.Empty(t, writes)
+
+				_, err = es.RegisterHistory(context.Background(), register1, 12, 11)
+				require.Error(t, err)
+			})
+		})
+	})
+}
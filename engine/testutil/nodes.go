@@ -377,6 +377,7 @@ func ExecutionNode(t *testing.T, hub *stub.Hub, identity *flow.Identity, identit
 		node.State,
 		vm,
 		vmCtx,
+		1,
 	)
 	require.NoError(t, err)
 
@@ -12,6 +12,7 @@ import (
 type Context struct {
 	Chain                            flow.Chain
 	ASTCache                         ASTCache
+	ProgramCache                     *ProgramCache
 	Blocks                           Blocks
 	Metrics                          *MetricsCollector
 	GasLimit                         uint64
@@ -25,11 +26,16 @@ type Context struct {
 	RestrictedDeploymentEnabled      bool
 	LimitAccountStorage              bool
 	CadenceLoggingEnabled            bool
+	ScheduledTransactionsEnabled     bool
 	SetValueHandler                  SetValueHandler
 	SignatureVerifier                SignatureVerifier
 	TransactionProcessors            []TransactionProcessor
 	ScriptProcessors                 []ScriptProcessor
+	ExecutionProfiler                *ExecutionProfiler
 	Logger                           zerolog.Logger
+
+	// scheduledTransaction is set in the context of scheduled transactions, see ScheduledTransactionContext.
+	scheduledTransaction bool
 }
 
 // SetValueHandler receives a value written by the Cadence runtime.
@@ -64,6 +70,7 @@ func defaultContext(logger zerolog.Logger) Context {
 	return Context{
 		Chain:                            flow.Mainnet.Chain(),
 		ASTCache:                         nil,
+		ProgramCache:                     nil,
 		Blocks:                           nil,
 		Metrics:                          nil,
 		GasLimit:                         DefaultGasLimit,
@@ -76,6 +83,7 @@ func defaultContext(logger zerolog.Logger) Context {
 		RestrictedAccountCreationEnabled: true,
 		RestrictedDeploymentEnabled:      true,
 		CadenceLoggingEnabled:            false,
+		ScheduledTransactionsEnabled:     false,
 		SetValueHandler:                  nil,
 		SignatureVerifier:                NewDefaultSignatureVerifier(),
 		TransactionProcessors: []TransactionProcessor{
@@ -88,7 +96,8 @@ func defaultContext(logger zerolog.Logger) Context {
 		ScriptProcessors: []ScriptProcessor{
 			NewScriptInvocator(),
 		},
-		Logger: logger,
+		ExecutionProfiler: nil,
+		Logger:            logger,
 	}
 }
 
@@ -111,6 +120,15 @@ func WithASTCache(cache ASTCache) Option {
 	}
 }
 
+// WithProgramCache sets the program cache for a virtual machine context, which takes precedence over
+// the AST cache for the programs of account contracts.
+func WithProgramCache(cache *ProgramCache) Option {
+	return func(ctx Context) Context {
+		ctx.ProgramCache = cache
+		return ctx
+	}
+}
+
 // WithGasLimit sets the gas limit for a virtual machine context.
 func WithGasLimit(limit uint64) Option {
 	return func(ctx Context) Context {
@@ -219,6 +237,17 @@ func WithCadenceLogging(enabled bool) Option {
 	}
 }
 
+// WithScheduledTransactions enables or disables scheduled transactions for a virtual machine context.
+//
+// If enabled, transactions can schedule transactions for future blocks with the scheduleTransaction
+// function, which are executed in the system chunk of the block at the scheduled height.
+func WithScheduledTransactions(enabled bool) Option {
+	return func(ctx Context) Context {
+		ctx.ScheduledTransactionsEnabled = enabled
+		return ctx
+	}
+}
+
 // WithRestrictedAccountCreation enables or disables restricted account creation for a
 // virtual machine context
 func WithRestrictedAccountCreation(enabled bool) Option {
@@ -245,3 +274,14 @@ func WithAccountStorageLimit(enabled bool) Option {
 		return ctx
 	}
 }
+
+// WithExecutionProfiler sets the execution profiler for a virtual machine context.
+//
+// The profiler records the computation of scripts per function, and the registers read and written
+// by scripts and transactions. Scripts are executed twice when profiled, see ScriptInvocator.
+func WithExecutionProfiler(profiler *ExecutionProfiler) Option {
+	return func(ctx Context) Context {
+		ctx.ExecutionProfiler = profiler
+		return ctx
+	}
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/onflow/flow-go/fvm/state"
 	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/model/hash"
 	"github.com/onflow/flow-go/storage"
 
 	"github.com/onflow/flow-go-sdk/crypto"
@@ -36,6 +37,17 @@ type hostEnv struct {
 	totalGasUsed       uint64
 	transactionEnv     *transactionEnv
 	rng                *rand.Rand
+	meteringDisabled   bool
+	uncachedCodes      map[common.LocationID][]byte
+	pendingPrograms    []pendingProgram
+}
+
+// pendingProgram is a program parsed by a procedure, which is added to the program cache
+// once the procedure succeeded.
+type pendingProgram struct {
+	location common.AddressLocation
+	code     []byte
+	program  *ast.Program
 }
 
 func (e *hostEnv) Hash(data []byte, hashAlgorithm string) ([]byte, error) {
@@ -112,10 +124,16 @@ func (e *hostEnv) GetValue(owner, key []byte) ([]byte, error) {
 		flow.BytesToAddress(owner),
 		string(key),
 	)
+	if e.ctx.ExecutionProfiler != nil {
+		e.ctx.ExecutionProfiler.recordRead(flow.BytesToAddress(owner), string(key), v)
+	}
 	return v, nil
 }
 
 func (e *hostEnv) SetValue(owner, key, value []byte) error {
+	if e.ctx.ExecutionProfiler != nil {
+		e.ctx.ExecutionProfiler.recordWrite(flow.BytesToAddress(owner), string(key), value)
+	}
 	return e.accounts.SetValue(
 		flow.BytesToAddress(owner),
 		string(key),
@@ -256,22 +274,41 @@ func (e *hostEnv) GetCode(location runtime.Location) ([]byte, error) {
 		return nil, fmt.Errorf("can only get code for an account contract (an AddressLocation)")
 	}
 
-	address := flow.BytesToAddress(contractLocation.Address.Bytes())
+	// the runtime gets the code of a contract whose program isn't cached right after
+	// getCachedContractProgram read it, so it is not read again
+	code, ok := e.uncachedCodes[contractLocation.ID()]
+	if !ok {
+		var err error
+		code, err = e.getContractCode(contractLocation)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	code, err := e.accounts.GetContract(contractLocation.Name, address)
-	if err != nil {
-		return nil, err
+	if e.ctx.ExecutionProfiler != nil {
+		e.ctx.ExecutionProfiler.recordCode(location, code)
 	}
 
 	return code, nil
 }
 
+func (e *hostEnv) getContractCode(location common.AddressLocation) ([]byte, error) {
+	return e.accounts.GetContract(location.Name, flow.BytesToAddress(location.Address.Bytes()))
+}
+
 func (e *hostEnv) GetCachedProgram(location common.Location) (*ast.Program, error) {
+	if addressLocation, ok := location.(common.AddressLocation); ok && e.ctx.ProgramCache != nil {
+		return e.getCachedContractProgram(addressLocation)
+	}
+
 	if e.ctx.ASTCache == nil {
 		return nil, nil
 	}
 
 	program, err := e.ctx.ASTCache.GetProgram(location)
+	if program != nil && e.ctx.ExecutionProfiler != nil {
+		e.ctx.ExecutionProfiler.recordProgram(location, program)
+	}
 	if program != nil {
 		// Program was found within cache, do an explicit ledger register touch
 		// to ensure consistent reads during chunk verification.
@@ -284,7 +321,58 @@ func (e *hostEnv) GetCachedProgram(location common.Location) (*ast.Program, erro
 	return program, err
 }
 
+// getCachedContractProgram returns the program of the contract from the program cache, if it was
+// parsed from the current code of the contract.
+//
+// The code is read exactly once per import, like it would be if there was no cache, which ensures
+// consistent reads during chunk verification. If the program isn't cached, the code is remembered:
+// GetCode returns it to the runtime without reading it again, and CacheProgram caches the program
+// the runtime parses from it.
+func (e *hostEnv) getCachedContractProgram(location common.AddressLocation) (*ast.Program, error) {
+	code, err := e.getContractCode(location)
+	if err != nil {
+		return nil, err
+	}
+
+	program := e.ctx.ProgramCache.GetProgram(location, hash.DefaultHasher.ComputeHash(code))
+	if program != nil {
+		if e.ctx.Metrics != nil {
+			e.ctx.Metrics.programCacheHits++
+		}
+		if e.ctx.ExecutionProfiler != nil {
+			e.ctx.ExecutionProfiler.recordProgram(location, program)
+		}
+		return program, nil
+	}
+
+	if e.ctx.Metrics != nil {
+		e.ctx.Metrics.programCacheMisses++
+	}
+
+	if e.uncachedCodes == nil {
+		e.uncachedCodes = make(map[common.LocationID][]byte)
+	}
+	e.uncachedCodes[location.ID()] = code
+
+	return nil, nil
+}
+
 func (e *hostEnv) CacheProgram(location common.Location, program *ast.Program) error {
+	if addressLocation, ok := location.(common.AddressLocation); ok && e.ctx.ProgramCache != nil {
+		// only programs parsed from code read by getCachedContractProgram are cached: the runtime
+		// also caches the programs of deployed contracts, before their code is updated
+		code, ok := e.uncachedCodes[location.ID()]
+		if ok {
+			delete(e.uncachedCodes, location.ID())
+			e.pendingPrograms = append(e.pendingPrograms, pendingProgram{
+				location: addressLocation,
+				code:     code,
+				program:  program,
+			})
+		}
+		return nil
+	}
+
 	if e.ctx.ASTCache == nil {
 		return nil
 	}
@@ -293,6 +381,39 @@ func (e *hostEnv) CacheProgram(location common.Location, program *ast.Program) e
 	return e.ctx.ASTCache.SetProgram(location, program)
 }
 
+// invalidateProgram discards the programs of the contract, which the procedure is about to update
+// or remove.
+func (e *hostEnv) invalidateProgram(location common.AddressLocation) {
+	if e.ctx.ProgramCache == nil {
+		return
+	}
+
+	delete(e.uncachedCodes, location.ID())
+
+	pending := e.pendingPrograms[:0]
+	for _, p := range e.pendingPrograms {
+		if p.location != location {
+			pending = append(pending, p)
+		}
+	}
+	e.pendingPrograms = pending
+
+	e.ctx.ProgramCache.InvalidateProgram(location)
+}
+
+// cachePrograms adds the programs parsed by the procedure to the program cache. It must only be
+// called once the procedure succeeded.
+func (e *hostEnv) cachePrograms() {
+	for _, p := range e.pendingPrograms {
+		err := e.ctx.ProgramCache.SetProgram(p.location, p.code, p.program)
+		if err != nil {
+			// the program is still cached in memory
+			e.ctx.Logger.Warn().Err(err).Str("location", p.location.String()).Msg("failed to store program")
+		}
+	}
+	e.pendingPrograms = nil
+}
+
 func (e *hostEnv) Log(message string) error {
 	if e.ctx.CadenceLoggingEnabled {
 		e.logs = append(e.logs, message)
@@ -342,6 +463,11 @@ func (e *hostEnv) GenerateUUID() (uint64, error) {
 }
 
 func (e *hostEnv) GetComputationLimit() uint64 {
+	// a limit of 0 disables metering
+	if e.meteringDisabled {
+		return 0
+	}
+
 	if e.transactionEnv != nil {
 		return e.transactionEnv.GetComputationLimit()
 	}
@@ -486,6 +612,8 @@ func (e *hostEnv) UpdateAccountContractCode(address runtime.Address, name string
 		return errors.New("updating account contract code is not supported")
 	}
 
+	e.invalidateProgram(common.AddressLocation{Address: address, Name: name})
+
 	// TODO: improve error passing https://github.com/onflow/cadence/issues/202
 	return e.transactionEnv.UpdateAccountContractCode(address, name, code)
 }
@@ -502,6 +630,8 @@ func (e *hostEnv) RemoveAccountContractCode(address runtime.Address, name string
 		return errors.New("removing account contracts is not supported")
 	}
 
+	e.invalidateProgram(common.AddressLocation{Address: address, Name: name})
+
 	// TODO: improve error passing https://github.com/onflow/cadence/issues/202
 	return e.transactionEnv.RemoveAccountContractCode(address, name)
 }
@@ -6,30 +6,54 @@ import (
 
 	"github.com/onflow/cadence/runtime"
 	"github.com/onflow/cadence/runtime/interpreter"
+	"github.com/onflow/cadence/runtime/stdlib"
 
 	"github.com/onflow/flow-go/crypto/hash"
 	"github.com/onflow/flow-go/model/flow"
 )
 
-const (
-	errCodeMissingSignature                      = 1
-	errCodeMissingPayer                          = 2
-	errCodeInvalidSignaturePublicKeyDoesNotExist = 3
-	errCodeInvalidSignaturePublicKeyRevoked      = 4
-	errCodeInvalidSignatureVerification          = 5
-
-	errCodeInvalidProposalKeyPublicKeyDoesNotExist = 6
-	errCodeInvalidProposalKeyPublicKeyRevoked      = 7
-	errCodeInvalidProposalKeySequenceNumber        = 8
-	errCodeInvalidProposalKeyMissingSignature      = 9
-
-	errCodeInvalidHashAlgorithm = 10
-
-	errCodeStorageCapacityExceeded = 11
-
-	errCodeEventLimitExceededError = 20
+// ErrorCodesVersion is the version of the catalogue of error codes below. The codes are returned to
+// clients, which may branch on them, so the code of an error never changes within a version.
+//
+// Transactions fail with one of the codes below. Failures of the ledger are not transaction failures,
+// they abort the execution of the block, and have no codes.
+const ErrorCodesVersion = 1
 
-	errCodeExecution = 100
+const (
+	// the transaction is invalid (TransactionSignatureVerifier, TransactionSequenceNumberChecker)
+	ErrCodeMissingSignature                      = 1
+	ErrCodeMissingPayer                          = 2
+	ErrCodeInvalidSignaturePublicKeyDoesNotExist = 3
+	ErrCodeInvalidSignaturePublicKeyRevoked      = 4
+	ErrCodeInvalidSignatureVerification          = 5
+
+	ErrCodeInvalidProposalKeyPublicKeyDoesNotExist = 6
+	ErrCodeInvalidProposalKeyPublicKeyRevoked      = 7
+	ErrCodeInvalidProposalKeySequenceNumber        = 8
+	ErrCodeInvalidProposalKeyMissingSignature      = 9
+
+	ErrCodeInvalidHashAlgorithm = 10
+
+	// the transaction exceeded the storage limits (TransactionStorageLimiter)
+	ErrCodeStorageCapacityExceeded = 11
+
+	ErrCodeEventLimitExceeded = 20
+
+	// the transaction fees could not be deducted (TransactionFeeDeductor)
+	ErrCodeFeeDeductionFailed = 30
+
+	// the transaction could not schedule a transaction (scheduleTransaction)
+	ErrCodeInvalidScheduledTransaction = 40
+
+	// the execution of the transaction failed (TransactionInvocator)
+	ErrCodeExecution                 = 100 // any failure of the Cadence runtime not covered by a more specific code
+	ErrCodeParsingChecking           = 101
+	ErrCodeComputationLimitExceeded  = 102
+	ErrCodeInvalidArgument           = 103
+	ErrCodeInvalidAuthorizerCount    = 104
+	ErrCodeConditionFailed           = 105 // a pre- or post-condition failed
+	ErrCodeAborted                   = 106 // the transaction panicked or an assertion failed
+	ErrCodeInvalidContractDeployment = 107
 )
 
 var ErrAccountNotFound = errors.New("account not found")
@@ -56,7 +80,7 @@ func (e *MissingSignatureError) Error() string {
 }
 
 func (e *MissingSignatureError) Code() uint32 {
-	return errCodeMissingSignature
+	return ErrCodeMissingSignature
 }
 
 // A MissingPayerError indicates that a transaction is missing a payer.
@@ -67,7 +91,7 @@ func (e *MissingPayerError) Error() string {
 }
 
 func (e *MissingPayerError) Code() uint32 {
-	return errCodeMissingPayer
+	return ErrCodeMissingPayer
 }
 
 // An InvalidSignaturePublicKeyDoesNotExistError indicates that a signature specifies a public key that
@@ -86,7 +110,7 @@ func (e *InvalidSignaturePublicKeyDoesNotExistError) Error() string {
 }
 
 func (e *InvalidSignaturePublicKeyDoesNotExistError) Code() uint32 {
-	return errCodeInvalidSignaturePublicKeyDoesNotExist
+	return ErrCodeInvalidSignaturePublicKeyDoesNotExist
 }
 
 // An InvalidSignaturePublicKeyRevokedError indicates that a signature specifies a public key that has been revoked.
@@ -104,7 +128,7 @@ func (e *InvalidSignaturePublicKeyRevokedError) Error() string {
 }
 
 func (e *InvalidSignaturePublicKeyRevokedError) Code() uint32 {
-	return errCodeInvalidSignaturePublicKeyRevoked
+	return ErrCodeInvalidSignaturePublicKeyRevoked
 }
 
 // An InvalidSignatureVerificationError indicates that a signature could not be verified using its specified
@@ -123,7 +147,7 @@ func (e *InvalidSignatureVerificationError) Error() string {
 }
 
 func (e *InvalidSignatureVerificationError) Code() uint32 {
-	return errCodeInvalidSignatureVerification
+	return ErrCodeInvalidSignatureVerification
 }
 
 // A InvalidProposalKeyPublicKeyDoesNotExistError indicates that proposal key specifies a nonexistent public key.
@@ -141,7 +165,7 @@ func (e *InvalidProposalKeyPublicKeyDoesNotExistError) Error() string {
 }
 
 func (e *InvalidProposalKeyPublicKeyDoesNotExistError) Code() uint32 {
-	return errCodeInvalidProposalKeyPublicKeyDoesNotExist
+	return ErrCodeInvalidProposalKeyPublicKeyDoesNotExist
 }
 
 // An InvalidProposalKeyPublicKeyRevokedError indicates that proposal key sequence number does not match the on-chain value.
@@ -159,7 +183,7 @@ func (e *InvalidProposalKeyPublicKeyRevokedError) Error() string {
 }
 
 func (e *InvalidProposalKeyPublicKeyRevokedError) Code() uint32 {
-	return errCodeInvalidProposalKeyPublicKeyRevoked
+	return ErrCodeInvalidProposalKeyPublicKeyRevoked
 }
 
 // An InvalidProposalKeySequenceNumberError indicates that proposal key sequence number does not match the on-chain value.
@@ -181,7 +205,7 @@ func (e *InvalidProposalKeySequenceNumberError) Error() string {
 }
 
 func (e *InvalidProposalKeySequenceNumberError) Code() uint32 {
-	return errCodeInvalidProposalKeySequenceNumber
+	return ErrCodeInvalidProposalKeySequenceNumber
 }
 
 // A InvalidProposalKeyMissingSignatureError indicates that a proposal key does not have a valid signature.
@@ -199,7 +223,7 @@ func (e *InvalidProposalKeyMissingSignatureError) Error() string {
 }
 
 func (e *InvalidProposalKeyMissingSignatureError) Code() uint32 {
-	return errCodeInvalidProposalKeyMissingSignature
+	return ErrCodeInvalidProposalKeyMissingSignature
 }
 
 // EventLimitExceededError indicates that the transaction has produced events with size more than limit.
@@ -218,7 +242,7 @@ func (e *EventLimitExceededError) Error() string {
 
 // Code returns the error code for this error
 func (e *EventLimitExceededError) Code() uint32 {
-	return errCodeEventLimitExceededError
+	return ErrCodeEventLimitExceeded
 }
 
 // An InvalidHashAlgorithmError indicates that a given key has an invalid hash algorithm.
@@ -233,7 +257,7 @@ func (e *InvalidHashAlgorithmError) Error() string {
 }
 
 func (e *InvalidHashAlgorithmError) Code() uint32 {
-	return errCodeInvalidHashAlgorithm
+	return ErrCodeInvalidHashAlgorithm
 }
 
 // An StorageCapacityExceededError indicates that an account used more storage than it has storage capacity.
@@ -248,9 +272,40 @@ func (e *StorageCapacityExceededError) Error() string {
 }
 
 func (e *StorageCapacityExceededError) Code() uint32 {
-	return errCodeStorageCapacityExceeded
+	return ErrCodeStorageCapacityExceeded
+}
+
+// A FeeDeductionFailedError indicates that the transaction fees could not be deducted from the payer.
+type FeeDeductionFailedError struct {
+	Err Error
+}
+
+func (e *FeeDeductionFailedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FeeDeductionFailedError) Code() uint32 {
+	return ErrCodeFeeDeductionFailed
+}
+
+func (e *FeeDeductionFailedError) Unwrap() error {
+	return e.Err
+}
+
+// An InvalidScheduledTransactionError indicates that a transaction tried to schedule an invalid transaction.
+type InvalidScheduledTransactionError struct {
+	Reason string
+}
+
+func (e *InvalidScheduledTransactionError) Error() string {
+	return e.Reason
 }
 
+func (e *InvalidScheduledTransactionError) Code() uint32 {
+	return ErrCodeInvalidScheduledTransaction
+}
+
+// An ExecutionError indicates that the Cadence runtime failed to execute a procedure.
 type ExecutionError struct {
 	Err runtime.Error
 }
@@ -259,8 +314,40 @@ func (e *ExecutionError) Error() string {
 	return e.Err.Error()
 }
 
+// Code returns the code of the most specific category of the runtime error.
 func (e *ExecutionError) Code() uint32 {
-	return errCodeExecution
+	var (
+		deploymentErr      *runtime.InvalidContractDeploymentError
+		parsingCheckingErr *runtime.ParsingCheckingError
+		argumentErr        *runtime.InvalidEntryPointArgumentError
+		scheduleErr        *InvalidScheduledTransactionError
+	)
+
+	switch {
+	// errors of host functions are reported with their own code
+	case errors.As(e.Err, &scheduleErr):
+		return scheduleErr.Code()
+	// deployment errors wrap the parsing and checking errors of the deployed contract
+	case errors.As(e.Err, &deploymentErr):
+		return ErrCodeInvalidContractDeployment
+	case errors.As(e.Err, &parsingCheckingErr),
+		errors.As(e.Err, &runtime.InvalidTransactionCountError{}):
+		return ErrCodeParsingChecking
+	case errors.As(e.Err, &runtime.ComputationLimitExceededError{}):
+		return ErrCodeComputationLimitExceeded
+	case errors.As(e.Err, &argumentErr),
+		errors.As(e.Err, &runtime.InvalidEntryPointParameterCountError{}):
+		return ErrCodeInvalidArgument
+	case errors.As(e.Err, &runtime.InvalidTransactionAuthorizerCountError{}):
+		return ErrCodeInvalidAuthorizerCount
+	case errors.As(e.Err, &interpreter.ConditionError{}):
+		return ErrCodeConditionFailed
+	case errors.As(e.Err, &stdlib.PanicError{}),
+		errors.As(e.Err, &stdlib.AssertionError{}):
+		return ErrCodeAborted
+	default:
+		return ErrCodeExecution
+	}
 }
 
 func handleError(err error) (vmErr Error, fatalErr error) {
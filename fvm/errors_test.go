@@ -0,0 +1,127 @@
+package fvm_test
+
+import (
+	"testing"
+
+	"github.com/onflow/cadence/runtime"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/engine/execution/testutil"
+	"github.com/onflow/flow-go/fvm"
+	"github.com/onflow/flow-go/model/flow"
+)
+
+func TestExecutionErrorCodes(t *testing.T) {
+	rt := runtime.NewInterpreterRuntime()
+
+	chain := flow.Mainnet.Chain()
+
+	vm := fvm.New(rt)
+
+	ctx := fvm.NewContext(zerolog.Nop(), fvm.WithChain(chain))
+
+	var tests = []struct {
+		label       string
+		script      string
+		arguments   [][]byte
+		authorizers int
+		gasLimit    uint64
+		code        uint32
+	}{
+		{
+			label:  "parsing error",
+			script: `transaction { prepare(signer: AuthAccount) {`,
+			code:   fvm.ErrCodeParsingChecking,
+		},
+		{
+			label:  "checking error",
+			script: `transaction { execute { let x: Int = "a" } }`,
+			code:   fvm.ErrCodeParsingChecking,
+		},
+		{
+			label:  "no transaction",
+			script: `pub fun main() {}`,
+			code:   fvm.ErrCodeParsingChecking,
+		},
+		{
+			label:    "computation limit exceeded",
+			script:   `transaction { execute { while true {} } }`,
+			gasLimit: 10,
+			code:     fvm.ErrCodeComputationLimitExceeded,
+		},
+		{
+			label:     "invalid argument count",
+			script:    `transaction(x: Int) { execute {} }`,
+			arguments: nil,
+			code:      fvm.ErrCodeInvalidArgument,
+		},
+		{
+			label:     "invalid argument",
+			script:    `transaction(x: Int) { execute {} }`,
+			arguments: [][]byte{[]byte(`{"type": "String", "value": "a"}`)},
+			code:      fvm.ErrCodeInvalidArgument,
+		},
+		{
+			label:       "invalid authorizer count",
+			script:      `transaction { prepare(a: AuthAccount, b: AuthAccount) {} }`,
+			authorizers: 1,
+			code:        fvm.ErrCodeInvalidAuthorizerCount,
+		},
+		{
+			label:  "condition failed",
+			script: `transaction { execute {} post { false } }`,
+			code:   fvm.ErrCodeConditionFailed,
+		},
+		{
+			label:  "panic",
+			script: `transaction { execute { panic("failed") } }`,
+			code:   fvm.ErrCodeAborted,
+		},
+		{
+			label:  "assertion failed",
+			script: `transaction { execute { assert(false) } }`,
+			code:   fvm.ErrCodeAborted,
+		},
+		{
+			label:  "other runtime errors",
+			script: `transaction { execute { let x = [1][2] } }`,
+			code:   fvm.ErrCodeExecution,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			txBody := flow.NewTransactionBody().
+				SetScript([]byte(tt.script)).
+				SetGasLimit(tt.gasLimit)
+			for _, argument := range tt.arguments {
+				txBody.AddArgument(argument)
+			}
+			for i := 0; i < tt.authorizers; i++ {
+				txBody.AddAuthorizer(chain.ServiceAddress())
+			}
+
+			ledger := testutil.RootBootstrappedLedger(vm, ctx)
+
+			err := testutil.SignTransactionAsServiceAccount(txBody, 0, chain)
+			require.NoError(t, err)
+
+			tx := fvm.Transaction(txBody, 0)
+
+			err = vm.Run(ctx, tx, ledger)
+			require.NoError(t, err)
+
+			require.Error(t, tx.Err)
+			assert.Equal(t, tt.code, tx.Err.Code(), tx.Err.Error())
+		})
+	}
+}
+
+func TestFeeDeductionFailedError(t *testing.T) {
+	err := &fvm.FeeDeductionFailedError{Err: &fvm.MissingPayerError{}}
+
+	assert.Equal(t, uint32(fvm.ErrCodeFeeDeductionFailed), err.Code())
+	assert.Equal(t, "no payer address provided", err.Error())
+}
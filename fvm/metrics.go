@@ -11,9 +11,11 @@ import (
 // A single collector instance will sum all reported values. For example, the "parsed" field will be
 // incremented each time a program is parsed.
 type MetricsCollector struct {
-	parsed      time.Duration
-	checked     time.Duration
-	interpreted time.Duration
+	parsed             time.Duration
+	checked            time.Duration
+	interpreted        time.Duration
+	programCacheHits   uint64
+	programCacheMisses uint64
 }
 
 // NewMetricsCollectors returns a new runtime metrics collector.
@@ -25,6 +27,12 @@ func (m *MetricsCollector) Parsed() time.Duration      { return m.parsed }
 func (m *MetricsCollector) Checked() time.Duration     { return m.checked }
 func (m *MetricsCollector) Interpreted() time.Duration { return m.interpreted }
 
+// ProgramCacheHits returns the number of account contract programs found in the program cache.
+func (m *MetricsCollector) ProgramCacheHits() uint64 { return m.programCacheHits }
+
+// ProgramCacheMisses returns the number of account contract programs not found in the program cache.
+func (m *MetricsCollector) ProgramCacheMisses() uint64 { return m.programCacheMisses }
+
 type metricsCollector struct {
 	*MetricsCollector
 }
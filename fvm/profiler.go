@@ -0,0 +1,251 @@
+package fvm
+
+import (
+	"sort"
+
+	"github.com/onflow/cadence/runtime"
+	"github.com/onflow/cadence/runtime/ast"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/parser2"
+
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// An ExecutionProfiler records where the computation of scripts goes, and which registers the
+// Cadence runtime reads and writes.
+//
+// The computation is measured in executed statements, which are attributed to the innermost function
+// declaration containing them. Only the register accesses of transactions are recorded, as Cadence
+// does not report the executed statements while it meters computation, which it always does for
+// transactions.
+//
+// A profiler is not safe for concurrent use.
+type ExecutionProfiler struct {
+	coverage  *runtime.CoverageReport
+	programs  map[common.LocationID]*ast.Program
+	codes     map[common.LocationID][]byte
+	registers map[registerKey]*RegisterProfile
+}
+
+type registerKey struct {
+	owner flow.Address
+	key   string
+}
+
+// NewExecutionProfiler returns a new execution profiler.
+func NewExecutionProfiler() *ExecutionProfiler {
+	return &ExecutionProfiler{
+		coverage:  runtime.NewCoverageReport(),
+		programs:  make(map[common.LocationID]*ast.Program),
+		codes:     make(map[common.LocationID][]byte),
+		registers: make(map[registerKey]*RegisterProfile),
+	}
+}
+
+// An ExecutionProfile is the report of an execution profiler.
+type ExecutionProfile struct {
+	// Computation is the number of statements executed.
+	Computation uint64
+	// BytesRead is the number of bytes read from registers.
+	BytesRead uint64
+	// BytesWritten is the number of bytes written to registers.
+	BytesWritten uint64
+	// Functions are the functions which executed statements, by descending computation.
+	Functions []FunctionProfile
+	// Registers are the registers read or written, ordered by owner and key.
+	Registers []RegisterProfile
+}
+
+// A FunctionProfile records the computation of a function.
+type FunctionProfile struct {
+	// Location is the ID of the location of the program declaring the function.
+	Location string
+	// Function is the name of the function, qualified by the names of the composite types declaring it.
+	// It is empty for statements which are not part of a function.
+	Function string
+	// Line is the line of the function declaration.
+	Line int
+	// Computation is the number of statements executed in the function, excluding the ones executed by
+	// the functions it called.
+	Computation uint64
+}
+
+// A RegisterProfile records the accesses of a register.
+type RegisterProfile struct {
+	Owner        flow.Address
+	Key          string
+	Reads        uint64
+	Writes       uint64
+	BytesRead    uint64
+	BytesWritten uint64
+}
+
+func (p *ExecutionProfiler) register(owner flow.Address, key string) *RegisterProfile {
+	k := registerKey{owner: owner, key: key}
+	register, ok := p.registers[k]
+	if !ok {
+		register = &RegisterProfile{Owner: owner, Key: key}
+		p.registers[k] = register
+	}
+	return register
+}
+
+func (p *ExecutionProfiler) recordRead(owner flow.Address, key string, value []byte) {
+	register := p.register(owner, key)
+	register.Reads++
+	register.BytesRead += uint64(len(value))
+}
+
+func (p *ExecutionProfiler) recordWrite(owner flow.Address, key string, value []byte) {
+	register := p.register(owner, key)
+	register.Writes++
+	register.BytesWritten += uint64(len(value))
+}
+
+// recordCode records the code of a location, which is parsed when the profile is built to attribute
+// the executed statements of the location to its functions.
+func (p *ExecutionProfiler) recordCode(location common.Location, code []byte) {
+	p.codes[location.ID()] = code
+}
+
+// recordProgram records the already parsed program of a location.
+func (p *ExecutionProfiler) recordProgram(location common.Location, program *ast.Program) {
+	p.programs[location.ID()] = program
+}
+
+// Profile returns the profile of the executions recorded so far.
+func (p *ExecutionProfiler) Profile() *ExecutionProfile {
+	profile := &ExecutionProfile{}
+
+	for locationID, coverage := range p.coverage.Coverage {
+		functions := p.functions(locationID)
+
+		computation := make(map[*functionSpan]uint64)
+		for line, hits := range coverage.LineHits {
+			computation[functions.innermost(line)] += uint64(hits)
+		}
+
+		for function, hits := range computation {
+			profile.Computation += hits
+			profile.Functions = append(profile.Functions, FunctionProfile{
+				Location:    string(locationID),
+				Function:    function.name,
+				Line:        function.start,
+				Computation: hits,
+			})
+		}
+	}
+
+	sort.Slice(profile.Functions, func(i, j int) bool {
+		a, b := profile.Functions[i], profile.Functions[j]
+		if a.Computation != b.Computation {
+			return a.Computation > b.Computation
+		}
+		if a.Location != b.Location {
+			return a.Location < b.Location
+		}
+		return a.Line < b.Line
+	})
+
+	for _, register := range p.registers {
+		profile.BytesRead += register.BytesRead
+		profile.BytesWritten += register.BytesWritten
+		profile.Registers = append(profile.Registers, *register)
+	}
+
+	sort.Slice(profile.Registers, func(i, j int) bool {
+		a, b := profile.Registers[i], profile.Registers[j]
+		if a.Owner != b.Owner {
+			return a.Owner.Hex() < b.Owner.Hex()
+		}
+		return a.Key < b.Key
+	})
+
+	return profile
+}
+
+// functionSpan is the range of lines of a function declaration.
+type functionSpan struct {
+	name  string
+	start int
+	end   int
+}
+
+type functionSpans []*functionSpan
+
+// topLevel is the span of the statements outside of any function.
+var topLevel = &functionSpan{}
+
+// innermost returns the innermost function containing the line. As nested functions start after the
+// functions containing them, it is the one with the latest start.
+func (s functionSpans) innermost(line int) *functionSpan {
+	innermost := topLevel
+	for _, span := range s {
+		if span.start <= line && line <= span.end && span.start >= innermost.start {
+			innermost = span
+		}
+	}
+	return innermost
+}
+
+// functions returns the function declarations of the program of the location. If the program is unknown
+// or can't be parsed, all statements of the location are attributed to the top level.
+func (p *ExecutionProfiler) functions(locationID common.LocationID) functionSpans {
+	program, ok := p.programs[locationID]
+	if !ok {
+		code, ok := p.codes[locationID]
+		if !ok {
+			return nil
+		}
+		var err error
+		program, err = parser2.ParseProgram(string(code))
+		if err != nil {
+			return nil
+		}
+	}
+
+	var spans functionSpans
+
+	addFunction := func(prefix string, name string, function *ast.FunctionDeclaration) {
+		spans = append(spans, &functionSpan{
+			name:  prefix + name,
+			start: function.StartPosition().Line,
+			end:   function.EndPosition().Line,
+		})
+	}
+
+	var addMembers func(prefix string, members *ast.Members)
+	addMembers = func(prefix string, members *ast.Members) {
+		for _, function := range members.Functions() {
+			addFunction(prefix, function.Identifier.Identifier, function)
+		}
+		for _, function := range members.SpecialFunctions() {
+			addFunction(prefix, function.Kind.Keywords(), function.FunctionDeclaration)
+		}
+		for _, composite := range members.Composites() {
+			addMembers(prefix+composite.Identifier.Identifier+".", composite.Members)
+		}
+		for _, intf := range members.Interfaces() {
+			addMembers(prefix+intf.Identifier.Identifier+".", intf.Members)
+		}
+	}
+
+	for _, function := range program.FunctionDeclarations() {
+		addFunction("", function.Identifier.Identifier, function)
+	}
+	for _, composite := range program.CompositeDeclarations() {
+		addMembers(composite.Identifier.Identifier+".", composite.Members)
+	}
+	for _, intf := range program.InterfaceDeclarations() {
+		addMembers(intf.Identifier.Identifier+".", intf.Members)
+	}
+	for _, transaction := range program.TransactionDeclarations() {
+		for _, function := range []*ast.SpecialFunctionDeclaration{transaction.Prepare, transaction.Execute} {
+			if function != nil {
+				addFunction("", function.Kind.Keywords(), function.FunctionDeclaration)
+			}
+		}
+	}
+
+	return spans
+}
@@ -0,0 +1,141 @@
+package fvm_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/engine/execution/testutil"
+	"github.com/onflow/flow-go/fvm"
+	"github.com/onflow/flow-go/fvm/state"
+	"github.com/onflow/flow-go/model/flow"
+)
+
+func TestExecutionProfiler(t *testing.T) {
+
+	t.Run("script", newVMTest().run(
+		func(t *testing.T, vm *fvm.VirtualMachine, chain flow.Chain, ctx fvm.Context, ledger state.Ledger) {
+			code := []byte(fmt.Sprintf(`
+				import FlowServiceAccount from 0x%s
+
+				pub fun double(_ x: UFix64): UFix64 {
+					return x * 2.0
+				}
+
+				pub fun main(): UFix64 {
+					var total = 0.0
+					var i = 0
+					while i < 10 {
+						total = total + double(1.0)
+						i = i + 1
+					}
+					return total + FlowServiceAccount.defaultTokenBalance(getAccount(0x%s))
+				}
+			`, chain.ServiceAddress(), chain.ServiceAddress()))
+
+			profiler := fvm.NewExecutionProfiler()
+			script := fvm.Script(code)
+
+			err := vm.Run(fvm.NewContextFromParent(ctx, fvm.WithExecutionProfiler(profiler)), script, ledger)
+			require.NoError(t, err)
+			require.NoError(t, script.Err)
+
+			// the result is the same as without the profiler
+			unprofiled := fvm.Script(code)
+			err = vm.Run(ctx, unprofiled, ledger)
+			require.NoError(t, err)
+			assert.Equal(t, unprofiled.Value, script.Value)
+
+			profile := profiler.Profile()
+
+			functions := make(map[string]fvm.FunctionProfile)
+			var computation uint64
+			for _, function := range profile.Functions {
+				functions[function.Location+" "+function.Function] = function
+				computation += function.Computation
+			}
+			assert.Equal(t, profile.Computation, computation)
+
+			scriptLocation := fmt.Sprintf("s.%s", script.ID)
+			double, ok := functions[scriptLocation+" double"]
+			require.True(t, ok)
+			assert.Equal(t, uint64(10), double.Computation)
+			assert.Equal(t, 4, double.Line)
+
+			main, ok := functions[scriptLocation+" main"]
+			require.True(t, ok)
+			// the declarations, the loop and its body, and the return statement
+			assert.Equal(t, uint64(2+1+10*2+1), main.Computation)
+
+			contract, ok := functions[fmt.Sprintf("A.%s.FlowServiceAccount FlowServiceAccount.defaultTokenBalance", chain.ServiceAddress())]
+			require.True(t, ok)
+			assert.Greater(t, contract.Computation, uint64(0))
+
+			// registers were read, but not written
+			require.NotEmpty(t, profile.Registers)
+			assert.Greater(t, profile.BytesRead, uint64(0))
+			assert.Equal(t, uint64(0), profile.BytesWritten)
+			for _, register := range profile.Registers {
+				assert.Greater(t, register.Reads, uint64(0))
+				assert.Equal(t, uint64(0), register.Writes)
+			}
+		},
+	))
+
+	t.Run("computation limit", newVMTest().withContextOptions(fvm.WithGasLimit(100)).run(
+		func(t *testing.T, vm *fvm.VirtualMachine, chain flow.Chain, ctx fvm.Context, ledger state.Ledger) {
+			profiler := fvm.NewExecutionProfiler()
+			script := fvm.Script([]byte(`
+				pub fun main() {
+					while true {}
+				}
+			`))
+
+			// the script is not executed without metering, which would never terminate
+			err := vm.Run(fvm.NewContextFromParent(ctx, fvm.WithExecutionProfiler(profiler)), script, ledger)
+			require.NoError(t, err)
+			require.Error(t, script.Err)
+
+			assert.Empty(t, profiler.Profile().Functions)
+		},
+	))
+
+	t.Run("transaction", newVMTest().run(
+		func(t *testing.T, vm *fvm.VirtualMachine, chain flow.Chain, ctx fvm.Context, ledger state.Ledger) {
+			txBody := flow.NewTransactionBody().
+				SetScript([]byte(`
+					transaction {
+						prepare(signer: AuthAccount) {
+							signer.save(42, to: /storage/answer)
+						}
+					}
+				`)).
+				AddAuthorizer(chain.ServiceAddress())
+
+			err := testutil.SignTransactionAsServiceAccount(txBody, 0, chain)
+			require.NoError(t, err)
+
+			profiler := fvm.NewExecutionProfiler()
+			tx := fvm.Transaction(txBody, 0)
+
+			err = vm.Run(fvm.NewContextFromParent(ctx, fvm.WithExecutionProfiler(profiler)), tx, ledger)
+			require.NoError(t, err)
+			require.NoError(t, tx.Err)
+
+			// only the register accesses of transactions are profiled
+			profile := profiler.Profile()
+			assert.Equal(t, uint64(0), profile.Computation)
+			assert.Greater(t, profile.BytesWritten, uint64(0))
+
+			written := false
+			for _, register := range profile.Registers {
+				if register.Owner == chain.ServiceAddress() && register.Writes > 0 {
+					written = true
+				}
+			}
+			assert.True(t, written)
+		},
+	))
+}
@@ -0,0 +1,254 @@
+package fvm
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/onflow/cadence/runtime/ast"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/parser2"
+
+	"github.com/onflow/flow-go/model/hash"
+)
+
+// ProgramCache is a cache for the parsed programs of account contracts, which can be shared by
+// the procedures of all blocks.
+//
+// Programs are keyed by the location of the contract and the hash of the code they were parsed
+// from, and are only returned for that code. The program of a location is invalidated when a
+// procedure updates or removes the code of the contract.
+//
+// Cadence only lets the host cache parsed programs, so cached programs are still checked by every
+// procedure importing them.
+//
+// If the cache has a directory, the code of the cached programs is also stored in the directory,
+// and the programs stored by a previous process are parsed when the cache is created, rather than
+// while executing the first blocks after a restart.
+type ProgramCache struct {
+	lru *lru.Cache
+	dir string
+}
+
+type programCacheEntry struct {
+	codeHash []byte
+	program  *ast.Program
+}
+
+// storedProgram is the representation of a cached program in the directory of the cache.
+type storedProgram struct {
+	Address  string
+	Name     string
+	CodeHash string
+	Code     string
+}
+
+// NewProgramCache creates a new program cache holding up to size programs. If dir is not empty,
+// the code of cached programs is stored in it, and the programs already stored in it are loaded.
+func NewProgramCache(size int, dir string) (*ProgramCache, error) {
+	cache := &ProgramCache{dir: dir}
+
+	var onEvict func(key interface{}, value interface{})
+	if dir != "" {
+		err := os.MkdirAll(dir, 0700)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create program cache directory: %w", err)
+		}
+
+		onEvict = func(key interface{}, _ interface{}) {
+			cache.removeStored(key.(common.LocationID))
+		}
+	}
+
+	var err error
+	cache.lru, err = lru.NewWithEvict(size, onEvict)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lru cache, %w", err)
+	}
+
+	if dir != "" {
+		err = cache.load()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load program cache: %w", err)
+		}
+	}
+
+	return cache, nil
+}
+
+// GetProgram returns the cached program of the location, if it was parsed from the code with the
+// given hash.
+func (cache *ProgramCache) GetProgram(location common.AddressLocation, codeHash []byte) *ast.Program {
+	value, found := cache.lru.Get(location.ID())
+	if !found {
+		return nil
+	}
+
+	entry := value.(*programCacheEntry)
+	if !bytes.Equal(entry.codeHash, codeHash) {
+		return nil
+	}
+
+	// Return a new program to clear importedPrograms.
+	// This will avoid a concurrent map write when attempting to
+	// resolveImports.
+	return &ast.Program{Declarations: entry.program.Declarations}
+}
+
+// SetProgram adds the program parsed from the code of the location to the cache.
+//
+// The program is cached even if it can't be stored in the directory of the cache, in which case
+// an error is returned.
+func (cache *ProgramCache) SetProgram(location common.AddressLocation, code []byte, program *ast.Program) error {
+	codeHash := hash.DefaultHasher.ComputeHash(code)
+
+	_ = cache.lru.Add(location.ID(), &programCacheEntry{
+		codeHash: codeHash,
+		program:  program,
+	})
+
+	if cache.dir == "" {
+		return nil
+	}
+
+	return cache.store(location, codeHash, code)
+}
+
+// InvalidateProgram removes the program of the location from the cache.
+func (cache *ProgramCache) InvalidateProgram(location common.AddressLocation) {
+	// the stored program is removed by the eviction callback
+	cache.lru.Remove(location.ID())
+}
+
+// Len returns the number of cached programs.
+func (cache *ProgramCache) Len() int {
+	return cache.lru.Len()
+}
+
+func (cache *ProgramCache) path(locationID common.LocationID) string {
+	name := hash.DefaultHasher.ComputeHash([]byte(locationID))
+	return filepath.Join(cache.dir, hex.EncodeToString(name)+".json")
+}
+
+func (cache *ProgramCache) store(location common.AddressLocation, codeHash []byte, code []byte) error {
+	data, err := json.Marshal(storedProgram{
+		Address:  location.Address.Hex(),
+		Name:     location.Name,
+		CodeHash: hex.EncodeToString(codeHash),
+		Code:     string(code),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode program: %w", err)
+	}
+
+	// the file is renamed into place, so that a file is never read while it is written
+	tmp, err := ioutil.TempFile(cache.dir, "program-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create program file: %w", err)
+	}
+
+	_, err = tmp.Write(data)
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), cache.path(location.ID()))
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write program file: %w", err)
+	}
+
+	return nil
+}
+
+func (cache *ProgramCache) removeStored(locationID common.LocationID) {
+	// a program which fails to be removed is ignored when loaded,
+	// as its code hash does not match the code of the location anymore
+	_ = os.Remove(cache.path(locationID))
+}
+
+// load parses and caches the programs stored in the directory of the cache. Files which can't be
+// decoded, and programs which can't be parsed, are removed.
+func (cache *ProgramCache) load() error {
+	files, err := ioutil.ReadDir(cache.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		path := filepath.Join(cache.dir, file.Name())
+
+		if strings.HasSuffix(file.Name(), ".tmp") {
+			_ = os.Remove(path)
+			continue
+		}
+
+		if !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+
+		location, code, ok := cache.loadStored(path)
+		if !ok {
+			_ = os.Remove(path)
+			continue
+		}
+
+		program, err := parser2.ParseProgram(string(code))
+		if err != nil {
+			_ = os.Remove(path)
+			continue
+		}
+
+		// the files are already in place, so they are only added to the memory
+		_ = cache.lru.Add(location.ID(), &programCacheEntry{
+			codeHash: hash.DefaultHasher.ComputeHash(code),
+			program:  program,
+		})
+	}
+
+	return nil
+}
+
+func (cache *ProgramCache) loadStored(path string) (common.AddressLocation, []byte, bool) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return common.AddressLocation{}, nil, false
+	}
+
+	var stored storedProgram
+	err = json.Unmarshal(data, &stored)
+	if err != nil {
+		return common.AddressLocation{}, nil, false
+	}
+
+	address, err := hex.DecodeString(stored.Address)
+	if err != nil {
+		return common.AddressLocation{}, nil, false
+	}
+
+	location := common.AddressLocation{
+		Address: common.BytesToAddress(address),
+		Name:    stored.Name,
+	}
+
+	codeHash, err := hex.DecodeString(stored.CodeHash)
+	if err != nil {
+		return common.AddressLocation{}, nil, false
+	}
+
+	// discard files which were corrupted, or which don't belong to their location
+	code := []byte(stored.Code)
+	if !bytes.Equal(hash.DefaultHasher.ComputeHash(code), codeHash) || cache.path(location.ID()) != path {
+		return common.AddressLocation{}, nil, false
+	}
+
+	return location, code, true
+}
@@ -0,0 +1,282 @@
+package fvm_test
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/parser2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/engine/execution/state/delta"
+	"github.com/onflow/flow-go/engine/execution/testutil"
+	"github.com/onflow/flow-go/fvm"
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/model/hash"
+	"github.com/onflow/flow-go/utils/unittest"
+)
+
+func TestProgramCache(t *testing.T) {
+	rt := runtime.NewInterpreterRuntime()
+
+	chain := flow.Testnet.Chain()
+
+	vm := fvm.New(rt)
+
+	flowTokenLocation := common.AddressLocation{
+		Address: common.BytesToAddress(fvm.FlowTokenAddress(chain).Bytes()),
+		Name:    "FlowToken",
+	}
+
+	balanceScript := []byte(fmt.Sprintf(`
+		import FlowToken from 0x%s
+
+		pub fun main(): UFix64 {
+			let vault <- FlowToken.createEmptyVault()
+			let balance = vault.balance
+			destroy vault
+			return balance
+		}
+	`, fvm.FlowTokenAddress(chain)))
+
+	t.Run("imported contracts are cached", func(t *testing.T) {
+		cache, err := fvm.NewProgramCache(CacheSize, "")
+		require.NoError(t, err)
+
+		ctx := fvm.NewContext(zerolog.Nop(), fvm.WithChain(chain))
+
+		ledger := testutil.RootBootstrappedLedger(vm, ctx)
+
+		metrics := fvm.NewMetricsCollector()
+		ctx = fvm.NewContextFromParent(ctx, fvm.WithProgramCache(cache), fvm.WithMetricsCollector(metrics))
+
+		script := fvm.Script(balanceScript)
+		err = vm.Run(ctx, script, ledger)
+		require.NoError(t, err)
+		require.NoError(t, script.Err)
+
+		// FlowToken and FungibleToken
+		assert.Equal(t, uint64(0), metrics.ProgramCacheHits())
+		assert.Equal(t, uint64(2), metrics.ProgramCacheMisses())
+		assert.Equal(t, 2, cache.Len())
+
+		script = fvm.Script(balanceScript)
+		err = vm.Run(ctx, script, ledger)
+		require.NoError(t, err)
+		require.NoError(t, script.Err)
+
+		assert.Equal(t, uint64(2), metrics.ProgramCacheHits())
+		assert.Equal(t, uint64(2), metrics.ProgramCacheMisses())
+	})
+
+	t.Run("cached programs are only returned for their code", func(t *testing.T) {
+		cache, err := fvm.NewProgramCache(CacheSize, "")
+		require.NoError(t, err)
+
+		code := []byte(`pub contract FlowToken {}`)
+		program, err := parser2.ParseProgram(string(code))
+		require.NoError(t, err)
+
+		err = cache.SetProgram(flowTokenLocation, code, program)
+		require.NoError(t, err)
+
+		assert.NotNil(t, cache.GetProgram(flowTokenLocation, hash.DefaultHasher.ComputeHash(code)))
+		assert.Nil(t, cache.GetProgram(flowTokenLocation, hash.DefaultHasher.ComputeHash([]byte(`pub contract FlowToken {} `))))
+
+		cache.InvalidateProgram(flowTokenLocation)
+		assert.Nil(t, cache.GetProgram(flowTokenLocation, hash.DefaultHasher.ComputeHash(code)))
+	})
+
+	t.Run("register touches are the same with and without the cache", func(t *testing.T) {
+		cache, err := fvm.NewProgramCache(CacheSize, "")
+		require.NoError(t, err)
+
+		touches := func(ctx fvm.Context) map[string]bool {
+			ledger := testutil.RootBootstrappedLedger(vm, fvm.NewContext(zerolog.Nop(), fvm.WithChain(chain)))
+
+			script := fvm.Script(balanceScript)
+			err := vm.Run(ctx, script, ledger)
+			require.NoError(t, err)
+			require.NoError(t, script.Err)
+
+			return ledger.RegisterTouches
+		}
+
+		ctx := fvm.NewContext(zerolog.Nop(), fvm.WithChain(chain), fvm.WithProgramCache(cache))
+
+		// populate the cache
+		touches(ctx)
+		require.Equal(t, 2, cache.Len())
+
+		assert.Equal(t,
+			touches(ctx),
+			touches(fvm.NewContext(zerolog.Nop(), fvm.WithChain(chain))),
+		)
+	})
+
+	t.Run("register reads are the same with and without the cache", func(t *testing.T) {
+		cache, err := fvm.NewProgramCache(CacheSize, "")
+		require.NoError(t, err)
+
+		ledger := testutil.RootBootstrappedLedger(vm, fvm.NewContext(zerolog.Nop(), fvm.WithChain(chain)))
+
+		serviceAccountScript := []byte(fmt.Sprintf(`
+			import FlowServiceAccount from 0x%s
+
+			pub fun main(): UFix64 {
+				let acct = getAccount(0x%s)
+				return FlowServiceAccount.defaultTokenBalance(acct)
+			}
+		`, chain.ServiceAddress(), chain.ServiceAddress()))
+
+		run := func(ctx fvm.Context) *delta.View {
+			view := delta.NewView(ledger.Get)
+
+			script := fvm.Script(serviceAccountScript)
+			err := vm.Run(ctx, script, view)
+			require.NoError(t, err)
+			require.NoError(t, script.Err)
+
+			return view
+		}
+
+		withoutCache := run(fvm.NewContext(zerolog.Nop(), fvm.WithChain(chain)))
+
+		ctx := fvm.NewContext(zerolog.Nop(), fvm.WithChain(chain), fvm.WithProgramCache(cache))
+
+		coldCache := run(ctx)
+		require.NotZero(t, cache.Len())
+
+		warmCache := run(ctx)
+
+		for _, view := range []*delta.View{coldCache, warmCache} {
+			assert.Equal(t, withoutCache.ReadsCount(), view.ReadsCount())
+			assert.Equal(t, withoutCache.SpockSecret(), view.SpockSecret())
+		}
+	})
+
+	t.Run("updated contracts are invalidated", func(t *testing.T) {
+		cache, err := fvm.NewProgramCache(CacheSize, "")
+		require.NoError(t, err)
+
+		ctx := fvm.NewContext(
+			zerolog.Nop(),
+			fvm.WithChain(chain),
+			fvm.WithProgramCache(cache),
+			fvm.WithRestrictedDeployment(false),
+		)
+
+		ledger := testutil.RootBootstrappedLedger(vm, ctx)
+
+		contract := func(value int) string {
+			return fmt.Sprintf(`
+				pub contract Test {
+					pub fun value(): Int {
+						return %d
+					}
+				}
+			`, value)
+		}
+
+		seqNum := uint64(0)
+		deploy := func(function string, code string) {
+			txBody := flow.NewTransactionBody().
+				SetScript([]byte(fmt.Sprintf(`
+					transaction {
+						prepare(signer: AuthAccount) {
+							signer.contracts.%s(name: "Test", code: "%s".decodeHex())
+						}
+					}
+				`, function, hex.EncodeToString([]byte(code))))).
+				AddAuthorizer(chain.ServiceAddress())
+
+			err := testutil.SignTransactionAsServiceAccount(txBody, seqNum, chain)
+			require.NoError(t, err)
+			seqNum++
+
+			tx := fvm.Transaction(txBody, 0)
+			err = vm.Run(ctx, tx, ledger)
+			require.NoError(t, err)
+			require.NoError(t, tx.Err)
+		}
+
+		value := func() cadence.Value {
+			script := fvm.Script([]byte(fmt.Sprintf(`
+				import Test from 0x%s
+
+				pub fun main(): Int {
+					return Test.value()
+				}
+			`, chain.ServiceAddress())))
+
+			err := vm.Run(ctx, script, ledger)
+			require.NoError(t, err)
+			require.NoError(t, script.Err)
+
+			return script.Value
+		}
+
+		testLocation := common.AddressLocation{
+			Address: common.BytesToAddress(chain.ServiceAddress().Bytes()),
+			Name:    "Test",
+		}
+
+		// the programs of deployed contracts are not cached
+		deploy("add", contract(1))
+		assert.Nil(t, cache.GetProgram(testLocation, hash.DefaultHasher.ComputeHash([]byte(contract(2)))))
+
+		assert.Equal(t, cadence.NewInt(1), value())
+		assert.NotNil(t, cache.GetProgram(testLocation, hash.DefaultHasher.ComputeHash([]byte(contract(1)))))
+
+		deploy("update__experimental", contract(2))
+		assert.Nil(t, cache.GetProgram(testLocation, hash.DefaultHasher.ComputeHash([]byte(contract(1)))))
+		assert.Nil(t, cache.GetProgram(testLocation, hash.DefaultHasher.ComputeHash([]byte(contract(2)))))
+
+		assert.Equal(t, cadence.NewInt(2), value())
+		assert.NotNil(t, cache.GetProgram(testLocation, hash.DefaultHasher.ComputeHash([]byte(contract(2)))))
+	})
+
+	t.Run("cached programs are persisted", func(t *testing.T) {
+		unittest.RunWithTempDir(t, func(dir string) {
+			cache, err := fvm.NewProgramCache(CacheSize, dir)
+			require.NoError(t, err)
+
+			ctx := fvm.NewContext(zerolog.Nop(), fvm.WithChain(chain))
+
+			ledger := testutil.RootBootstrappedLedger(vm, ctx)
+
+			script := fvm.Script(balanceScript)
+			err = vm.Run(fvm.NewContextFromParent(ctx, fvm.WithProgramCache(cache)), script, ledger)
+			require.NoError(t, err)
+			require.NoError(t, script.Err)
+
+			// a new cache loads the programs stored by the previous one
+			cache, err = fvm.NewProgramCache(CacheSize, dir)
+			require.NoError(t, err)
+			assert.Equal(t, 2, cache.Len())
+
+			metrics := fvm.NewMetricsCollector()
+			ctx = fvm.NewContextFromParent(ctx, fvm.WithProgramCache(cache), fvm.WithMetricsCollector(metrics))
+
+			script = fvm.Script(balanceScript)
+			err = vm.Run(ctx, script, ledger)
+			require.NoError(t, err)
+			require.NoError(t, script.Err)
+
+			assert.Equal(t, uint64(2), metrics.ProgramCacheHits())
+			assert.Equal(t, uint64(0), metrics.ProgramCacheMisses())
+
+			// invalidated programs are removed from the directory
+			cache.InvalidateProgram(flowTokenLocation)
+
+			cache, err = fvm.NewProgramCache(CacheSize, dir)
+			require.NoError(t, err)
+			assert.Equal(t, 1, cache.Len())
+		})
+	})
+}
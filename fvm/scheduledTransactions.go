@@ -0,0 +1,304 @@
+package fvm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/onflow/cadence"
+	jsoncdc "github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/cadence/runtime"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/interpreter"
+	"github.com/onflow/cadence/runtime/sema"
+	"github.com/onflow/cadence/runtime/stdlib"
+	"github.com/onflow/cadence/runtime/trampoline"
+
+	"github.com/onflow/flow-go/fvm/state"
+	"github.com/onflow/flow-go/model/flow"
+)
+
+const (
+	// MaxScheduledTransactionsPerBlock is the maximum number of transactions which can be scheduled
+	// for the same height.
+	MaxScheduledTransactionsPerBlock = 100
+	// MaxScheduledTransactionScriptSize is the maximum size of the script of a scheduled transaction.
+	MaxScheduledTransactionScriptSize = 64000
+	// MaxScheduledTransactionHeightWindow is the maximum number of blocks a transaction can be
+	// scheduled ahead of the current height.
+	MaxScheduledTransactionHeightWindow = 100000
+)
+
+const scheduleTransactionFunctionName = "scheduleTransaction"
+
+// scheduleTransactionFunctionType is the type of the function transactions schedule transactions with:
+//
+//	fun scheduleTransaction(
+//	    at height: UInt64,
+//	    script: String,
+//	    arguments: [String],
+//	    payer: AuthAccount,
+//	    gasLimit: UInt64
+//	): UInt64
+//
+// The arguments are encoded with JSON-Cadence, and the function returns the schedule ID. Requiring the
+// AuthAccount of the payer ensures that the payer authorized the scheduling transaction.
+var scheduleTransactionFunctionType = &sema.FunctionType{
+	Parameters: []*sema.Parameter{
+		{
+			Label:          "at",
+			Identifier:     "height",
+			TypeAnnotation: sema.NewTypeAnnotation(&sema.UInt64Type{}),
+		},
+		{
+			Identifier:     "script",
+			TypeAnnotation: sema.NewTypeAnnotation(&sema.StringType{}),
+		},
+		{
+			Identifier: "arguments",
+			TypeAnnotation: sema.NewTypeAnnotation(&sema.VariableSizedType{
+				Type: &sema.StringType{},
+			}),
+		},
+		{
+			Identifier:     "payer",
+			TypeAnnotation: sema.NewTypeAnnotation(&sema.AuthAccountType{}),
+		},
+		{
+			Identifier:     "gasLimit",
+			TypeAnnotation: sema.NewTypeAnnotation(&sema.UInt64Type{}),
+		},
+	},
+	ReturnTypeAnnotation: sema.NewTypeAnnotation(&sema.UInt64Type{}),
+}
+
+// transactionScheduledEventType is the type of the event emitted by transactions which schedule a transaction:
+//
+//	event TransactionScheduled(scheduleID: UInt64, height: UInt64, transactionID: String)
+//
+// The transaction ID is the hex-encoded ID of the scheduled transaction, which it is executed with.
+var transactionScheduledEventType = &cadence.EventType{
+	Location:            stdlib.FlowLocation{},
+	QualifiedIdentifier: "TransactionScheduled",
+	Fields: []cadence.Field{
+		{Identifier: "scheduleID", Type: cadence.UInt64Type{}},
+		{Identifier: "height", Type: cadence.UInt64Type{}},
+		{Identifier: "transactionID", Type: cadence.StringType{}},
+	},
+}
+
+// scheduledTransactionExecutedEventType is the type of the event emitted by every executed scheduled
+// transaction, whether it succeeded or not:
+//
+//	event ScheduledTransactionExecuted(scheduleID: UInt64)
+var scheduledTransactionExecutedEventType = &cadence.EventType{
+	Location:            stdlib.FlowLocation{},
+	QualifiedIdentifier: "ScheduledTransactionExecuted",
+	Fields: []cadence.Field{
+		{Identifier: "scheduleID", Type: cadence.UInt64Type{}},
+	},
+}
+
+// scheduleTransactionDeclaration returns the declaration of the scheduleTransaction function,
+// bound to the environment of the transaction.
+func (e *hostEnv) scheduleTransactionDeclaration() runtime.ValueDeclaration {
+	return runtime.ValueDeclaration{
+		Name:           scheduleTransactionFunctionName,
+		Type:           scheduleTransactionFunctionType,
+		Kind:           common.DeclarationKindFunction,
+		IsConstant:     true,
+		ArgumentLabels: []string{"at", "script", "arguments", "payer", "gasLimit"},
+		Value: interpreter.NewHostFunctionValue(
+			func(invocation interpreter.Invocation) trampoline.Trampoline {
+				arguments := invocation.Arguments[2].(*interpreter.ArrayValue).Values
+
+				tx := flow.ScheduledTransaction{
+					Height:    uint64(invocation.Arguments[0].(interpreter.UInt64Value)),
+					Script:    []byte(invocation.Arguments[1].(*interpreter.StringValue).Str),
+					Arguments: make([][]byte, len(arguments)),
+					Payer:     flow.Address(invocation.Arguments[3].(interpreter.AuthAccountValue).Address),
+					GasLimit:  uint64(invocation.Arguments[4].(interpreter.UInt64Value)),
+				}
+				for i, argument := range arguments {
+					tx.Arguments[i] = []byte(argument.(*interpreter.StringValue).Str)
+				}
+
+				scheduleID, err := e.transactionEnv.ScheduleTransaction(tx)
+				if err != nil {
+					raiseError(err)
+				}
+				tx.ScheduleID = scheduleID
+
+				err = e.EmitEvent(cadence.NewEvent([]cadence.Value{
+					cadence.NewUInt64(scheduleID),
+					cadence.NewUInt64(tx.Height),
+					cadence.NewString(tx.TransactionBody().ID().String()),
+				}).WithType(transactionScheduledEventType))
+				if err != nil {
+					raiseError(err)
+				}
+
+				return trampoline.Done{Result: interpreter.UInt64Value(scheduleID)}
+			},
+		),
+	}
+}
+
+// raiseError aborts the invocation of a host function with the error. Errors of the VM, like invalid
+// arguments, are reported to the transaction as a Cadence error at the invocation, all other errors
+// abort the execution like the errors of the runtime interface.
+func raiseError(err error) {
+	var vmErr Error
+	if errors.As(err, &vmErr) {
+		panic(err)
+	}
+	panic(interpreter.ExternalError{Recovered: err})
+}
+
+// ScheduleTransaction schedules the transaction for a future block, and returns its schedule ID.
+// The storage of the transaction is charged to its payer until the transaction is executed.
+func (e *transactionEnv) ScheduleTransaction(tx flow.ScheduledTransaction) (uint64, error) {
+	if e.ctx.BlockHeader == nil {
+		return 0, &InvalidScheduledTransactionError{Reason: "scheduling transactions requires a block"}
+	}
+
+	if tx.Height <= e.ctx.BlockHeader.Height {
+		return 0, &InvalidScheduledTransactionError{Reason: fmt.Sprintf(
+			"cannot schedule transaction at height %d, which is not after the current height %d",
+			tx.Height,
+			e.ctx.BlockHeader.Height,
+		)}
+	}
+
+	if tx.Height-e.ctx.BlockHeader.Height > MaxScheduledTransactionHeightWindow {
+		return 0, &InvalidScheduledTransactionError{Reason: fmt.Sprintf(
+			"cannot schedule transaction at height %d, which is more than %d blocks after the current height %d",
+			tx.Height,
+			MaxScheduledTransactionHeightWindow,
+			e.ctx.BlockHeader.Height,
+		)}
+	}
+
+	if len(tx.Script) > MaxScheduledTransactionScriptSize {
+		return 0, &InvalidScheduledTransactionError{Reason: fmt.Sprintf(
+			"scheduled transaction script size %d exceeds the maximum of %d",
+			len(tx.Script),
+			MaxScheduledTransactionScriptSize,
+		)}
+	}
+
+	if tx.GasLimit > flow.DefaultMaxGasLimit {
+		return 0, &InvalidScheduledTransactionError{Reason: fmt.Sprintf(
+			"scheduled transaction gas limit %d exceeds the maximum of %d",
+			tx.GasLimit,
+			flow.DefaultMaxGasLimit,
+		)}
+	}
+
+	scheduled := state.NewScheduledTransactions(e.accounts, e.ctx.Chain.ServiceAddress())
+
+	count, err := scheduled.CountByHeight(tx.Height)
+	if err != nil {
+		return 0, err
+	}
+
+	if count >= MaxScheduledTransactionsPerBlock {
+		return 0, &InvalidScheduledTransactionError{Reason: fmt.Sprintf(
+			"cannot schedule more than %d transactions at height %d",
+			MaxScheduledTransactionsPerBlock,
+			tx.Height,
+		)}
+	}
+
+	return scheduled.Schedule(tx)
+}
+
+// ScheduledTransactions returns a procedure which takes the transactions scheduled for the given
+// height from the ledger.
+func ScheduledTransactions(height uint64) *ScheduledTransactionsProcedure {
+	return &ScheduledTransactionsProcedure{
+		Height: height,
+	}
+}
+
+// A ScheduledTransactionsProcedure takes the transactions scheduled for a height: it returns them
+// and removes them from the ledger. It does nothing if scheduled transactions are disabled.
+//
+// The returned transactions are executed in the system chunk of the block at the height, before the
+// system chunk transaction, in a context created with ScheduledTransactionContext.
+type ScheduledTransactionsProcedure struct {
+	Height       uint64
+	Transactions []*flow.TransactionBody
+}
+
+func (proc *ScheduledTransactionsProcedure) Run(vm *VirtualMachine, ctx Context, st *state.State) error {
+	if !ctx.ScheduledTransactionsEnabled {
+		return nil
+	}
+
+	scheduled := state.NewScheduledTransactions(state.NewAccounts(st), ctx.Chain.ServiceAddress())
+
+	transactions, err := scheduled.ByHeight(proc.Height)
+	if err != nil {
+		return err
+	}
+
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	err = scheduled.Remove(proc.Height)
+	if err != nil {
+		return err
+	}
+
+	proc.Transactions = make([]*flow.TransactionBody, len(transactions))
+	for i, tx := range transactions {
+		proc.Transactions[i] = tx.TransactionBody()
+	}
+
+	return nil
+}
+
+// ScheduledTransactionContext returns the context scheduled transactions are executed in.
+//
+// Scheduled transactions have no signatures, and their proposal key is not used, so they are
+// processed by the transaction processors of the parent context, except for the signature
+// verifier and the sequence number checker. Each transaction emits a ScheduledTransactionExecuted
+// event, even if it fails.
+func ScheduledTransactionContext(parent Context) Context {
+	processors := make([]TransactionProcessor, 0, len(parent.TransactionProcessors))
+	for _, p := range parent.TransactionProcessors {
+		switch p.(type) {
+		case *TransactionSignatureVerifier, *TransactionSequenceNumberChecker:
+			continue
+		}
+		processors = append(processors, p)
+	}
+
+	ctx := NewContextFromParent(parent, WithTransactionProcessors(processors...))
+	ctx.scheduledTransaction = true
+
+	return ctx
+}
+
+// scheduledTransactionExecutedEvent returns the ScheduledTransactionExecuted event of the scheduled
+// transaction, which follows the events emitted by the transaction itself.
+func scheduledTransactionExecutedEvent(proc *TransactionProcedure) (flow.Event, error) {
+	// the schedule ID is the sequence number of the proposal key of scheduled transactions
+	event := cadence.NewEvent([]cadence.Value{
+		cadence.NewUInt64(proc.Transaction.ProposalKey.SequenceNumber),
+	}).WithType(scheduledTransactionExecutedEventType)
+
+	payload, err := jsoncdc.Encode(event)
+	if err != nil {
+		return flow.Event{}, fmt.Errorf("failed to encode scheduled transaction executed event: %w", err)
+	}
+
+	return flow.Event{
+		Type:             flow.EventType(scheduledTransactionExecutedEventType.ID()),
+		TransactionID:    proc.ID,
+		TransactionIndex: proc.TxIndex,
+		EventIndex:       uint32(len(proc.Events)),
+		Payload:          payload,
+	}, nil
+}
@@ -0,0 +1,304 @@
+package fvm_test
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/onflow/cadence"
+	jsoncdc "github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/cadence/runtime"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/engine/execution/testutil"
+	"github.com/onflow/flow-go/fvm"
+	"github.com/onflow/flow-go/fvm/state"
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/utils/unittest"
+)
+
+func TestScheduledTransactions(t *testing.T) {
+	rt := runtime.NewInterpreterRuntime()
+
+	chain := flow.Testnet.Chain()
+
+	vm := fvm.New(rt)
+
+	scheduledScript := `transaction(value: Int) { prepare(payer: AuthAccount) { log(value) } }`
+
+	scheduleTransactionFor := func(payer flow.Address, height uint64, gasLimit uint64) *flow.TransactionBody {
+		return flow.NewTransactionBody().
+			SetScript([]byte(fmt.Sprintf(`
+				transaction {
+					prepare(signer: AuthAccount) {
+						let id = scheduleTransaction(
+							at: %d,
+							script: %s,
+							arguments: [%s],
+							payer: signer,
+							gasLimit: %d
+						)
+						log(id)
+					}
+				}
+			`,
+				height,
+				strconv.Quote(scheduledScript),
+				strconv.Quote(`{"type":"Int","value":"42"}`),
+				gasLimit,
+			))).
+			AddAuthorizer(payer)
+	}
+
+	scheduleTransaction := func(height uint64, gasLimit uint64) *flow.TransactionBody {
+		return scheduleTransactionFor(chain.ServiceAddress(), height, gasLimit)
+	}
+
+	header := unittest.BlockHeaderFixture()
+	header.Height = 10
+
+	newContext := func(enabled bool, height uint64) fvm.Context {
+		h := header
+		h.Height = height
+
+		return fvm.NewContext(
+			zerolog.Nop(),
+			fvm.WithChain(chain),
+			fvm.WithCadenceLogging(true),
+			fvm.WithScheduledTransactions(enabled),
+			fvm.WithBlockHeader(&h),
+		)
+	}
+
+	run := func(ctx fvm.Context, ledger state.Ledger, txBody *flow.TransactionBody, seqNum uint64) *fvm.TransactionProcedure {
+		err := testutil.SignTransactionAsServiceAccount(txBody, seqNum, chain)
+		require.NoError(t, err)
+
+		tx := fvm.Transaction(txBody, 0)
+		err = vm.Run(ctx, tx, ledger)
+		require.NoError(t, err)
+
+		return tx
+	}
+
+	t.Run("scheduled transactions are executed at their height", func(t *testing.T) {
+		ctx := newContext(true, 10)
+
+		ledger := testutil.RootBootstrappedLedger(vm, ctx)
+
+		var scheduledIDs []flow.Identifier
+
+		for i := 0; i < 2; i++ {
+			tx := run(ctx, ledger, scheduleTransaction(12, 100), uint64(i))
+			require.NoError(t, tx.Err)
+			assert.Equal(t, []string{strconv.Itoa(i)}, tx.Logs)
+
+			// the scheduling transaction reports the ID of the scheduled transaction
+			require.Len(t, tx.Events, 1)
+			assert.Equal(t, flow.EventTransactionScheduled, tx.Events[0].Type)
+			assert.Equal(t, tx.ID, tx.Events[0].TransactionID)
+
+			event, err := jsoncdc.Decode(tx.Events[0].Payload)
+			require.NoError(t, err)
+			fields := event.(cadence.Event).Fields
+			assert.Equal(t, cadence.NewUInt64(uint64(i)), fields[0])
+			assert.Equal(t, cadence.NewUInt64(12), fields[1])
+
+			scheduledID, err := flow.HexStringToIdentifier(string(fields[2].(cadence.String)))
+			require.NoError(t, err)
+			scheduledIDs = append(scheduledIDs, scheduledID)
+		}
+
+		// nothing is scheduled for the next height
+		scheduled := fvm.ScheduledTransactions(11)
+		err := vm.Run(newContext(true, 11), scheduled, ledger)
+		require.NoError(t, err)
+		assert.Empty(t, scheduled.Transactions)
+
+		blockCtx := newContext(true, 12)
+
+		scheduled = fvm.ScheduledTransactions(12)
+		err = vm.Run(blockCtx, scheduled, ledger)
+		require.NoError(t, err)
+		require.Len(t, scheduled.Transactions, 2)
+
+		for i, txBody := range scheduled.Transactions {
+			assert.Equal(t, scheduledIDs[i], txBody.ID())
+			assert.Equal(t, []byte(scheduledScript), txBody.Script)
+			assert.Equal(t, uint64(100), txBody.GasLimit)
+			assert.Equal(t, chain.ServiceAddress(), txBody.Payer)
+			assert.Equal(t, []flow.Address{chain.ServiceAddress()}, txBody.Authorizers)
+			assert.Equal(t, uint64(i), txBody.ProposalKey.SequenceNumber)
+
+			tx := fvm.Transaction(txBody, uint32(i))
+			err = vm.Run(fvm.ScheduledTransactionContext(blockCtx), tx, ledger)
+			require.NoError(t, err)
+			require.NoError(t, tx.Err)
+			assert.Equal(t, []string{"42"}, tx.Logs)
+
+			// the scheduled transaction reports its execution
+			require.Len(t, tx.Events, 1)
+			assert.Equal(t, flow.EventScheduledTransactionExecuted, tx.Events[0].Type)
+			assert.Equal(t, scheduledIDs[i], tx.Events[0].TransactionID)
+			assert.Equal(t, uint32(i), tx.Events[0].TransactionIndex)
+		}
+
+		// the transactions are removed once taken
+		scheduled = fvm.ScheduledTransactions(12)
+		err = vm.Run(blockCtx, scheduled, ledger)
+		require.NoError(t, err)
+		assert.Empty(t, scheduled.Transactions)
+	})
+
+	t.Run("scheduled transaction context skips signature and sequence number checks", func(t *testing.T) {
+		ctx := newContext(true, 10)
+
+		feeDeductor := fvm.NewTransactionFeeDeductor()
+		invocator := fvm.NewTransactionInvocator(zerolog.Nop())
+		storageLimiter := fvm.NewTransactionStorageLimiter()
+
+		parent := fvm.NewContextFromParent(
+			ctx,
+			fvm.WithTransactionProcessors(
+				fvm.NewTransactionSignatureVerifier(fvm.AccountKeyWeightThreshold),
+				fvm.NewTransactionSequenceNumberChecker(),
+				feeDeductor,
+				invocator,
+				storageLimiter,
+			),
+		)
+
+		scheduledCtx := fvm.ScheduledTransactionContext(parent)
+		assert.Equal(
+			t,
+			[]fvm.TransactionProcessor{feeDeductor, invocator, storageLimiter},
+			scheduledCtx.TransactionProcessors,
+		)
+
+		// the parent context is not changed
+		assert.Len(t, parent.TransactionProcessors, 5)
+	})
+
+	t.Run("failed scheduled transactions report their execution", func(t *testing.T) {
+		ctx := newContext(true, 12)
+
+		ledger := testutil.RootBootstrappedLedger(vm, ctx)
+
+		txBody := flow.ScheduledTransaction{
+			ScheduleID: 7,
+			Height:     12,
+			Script:     []byte(`transaction { prepare(payer: AuthAccount) { panic("failed") } }`),
+			Payer:      chain.ServiceAddress(),
+			GasLimit:   100,
+		}.TransactionBody()
+
+		tx := fvm.Transaction(txBody, 3)
+		err := vm.Run(fvm.ScheduledTransactionContext(ctx), tx, ledger)
+		require.NoError(t, err)
+		require.Error(t, tx.Err)
+
+		require.Len(t, tx.Events, 1)
+		assert.Equal(t, flow.EventScheduledTransactionExecuted, tx.Events[0].Type)
+		assert.Equal(t, txBody.ID(), tx.Events[0].TransactionID)
+		assert.Equal(t, uint32(3), tx.Events[0].TransactionIndex)
+
+		event, err := jsoncdc.Decode(tx.Events[0].Payload)
+		require.NoError(t, err)
+		assert.Equal(t, []cadence.Value{cadence.NewUInt64(7)}, event.(cadence.Event).Fields)
+
+		// other transactions don't
+		tx = run(ctx, ledger, flow.NewTransactionBody().SetScript([]byte(`transaction { }`)), 0)
+		require.NoError(t, tx.Err)
+		assert.Empty(t, tx.Events)
+	})
+
+	t.Run("transactions can only be scheduled for future heights", func(t *testing.T) {
+		ctx := newContext(true, 10)
+
+		ledger := testutil.RootBootstrappedLedger(vm, ctx)
+
+		tx := run(ctx, ledger, scheduleTransaction(10, 100), 0)
+		require.Error(t, tx.Err)
+		assert.Equal(t, uint32(fvm.ErrCodeInvalidScheduledTransaction), tx.Err.Code(), tx.Err.Error())
+	})
+
+	t.Run("transactions can only be scheduled within the height window", func(t *testing.T) {
+		ctx := newContext(true, 10)
+
+		ledger := testutil.RootBootstrappedLedger(vm, ctx)
+
+		tx := run(ctx, ledger, scheduleTransaction(10+fvm.MaxScheduledTransactionHeightWindow+1, 100), 0)
+		require.Error(t, tx.Err)
+		assert.Equal(t, uint32(fvm.ErrCodeInvalidScheduledTransaction), tx.Err.Code(), tx.Err.Error())
+
+		tx = run(ctx, ledger, scheduleTransaction(10+fvm.MaxScheduledTransactionHeightWindow, 100), 1)
+		assert.NoError(t, tx.Err)
+	})
+
+	t.Run("storage of scheduled transactions is charged to the payer", func(t *testing.T) {
+		ctx := newContext(true, 10)
+
+		ledger := testutil.RootBootstrappedLedger(vm, ctx)
+
+		privateKeys, err := testutil.GenerateAccountPrivateKeys(1)
+		require.NoError(t, err)
+		payers, err := testutil.CreateAccounts(vm, ledger, privateKeys, chain)
+		require.NoError(t, err)
+		payer := payers[0]
+
+		storageUsed := func(address flow.Address) uint64 {
+			used, err := state.NewAccounts(state.NewState(ledger)).GetStorageUsed(address)
+			require.NoError(t, err)
+			return used
+		}
+
+		payerUsed := storageUsed(payer)
+
+		txBody := scheduleTransactionFor(payer, 12, 100)
+		err = testutil.SignTransaction(txBody, payer, privateKeys[0], 0)
+		require.NoError(t, err)
+
+		tx := fvm.Transaction(txBody, 0)
+		err = vm.Run(ctx, tx, ledger)
+		require.NoError(t, err)
+		require.NoError(t, tx.Err)
+
+		// the payer stores the transaction
+		assert.Greater(t, storageUsed(payer)-payerUsed, uint64(len(scheduledScript)))
+
+		scheduled := fvm.ScheduledTransactions(12)
+		err = vm.Run(newContext(true, 12), scheduled, ledger)
+		require.NoError(t, err)
+		require.Len(t, scheduled.Transactions, 1)
+		assert.Equal(t, payer, scheduled.Transactions[0].Payer)
+
+		// the storage is released once the transaction is taken
+		assert.Equal(t, payerUsed, storageUsed(payer))
+	})
+
+	t.Run("scheduled transactions can't exceed the maximum gas limit", func(t *testing.T) {
+		ctx := newContext(true, 10)
+
+		ledger := testutil.RootBootstrappedLedger(vm, ctx)
+
+		tx := run(ctx, ledger, scheduleTransaction(11, flow.DefaultMaxGasLimit+1), 0)
+		require.Error(t, tx.Err)
+		assert.Equal(t, uint32(fvm.ErrCodeInvalidScheduledTransaction), tx.Err.Code(), tx.Err.Error())
+	})
+
+	t.Run("transactions can't be scheduled if disabled", func(t *testing.T) {
+		ctx := newContext(false, 10)
+
+		ledger := testutil.RootBootstrappedLedger(vm, ctx)
+
+		tx := run(ctx, ledger, scheduleTransaction(11, 100), 0)
+		assert.Error(t, tx.Err)
+
+		scheduled := fvm.ScheduledTransactions(11)
+		err := vm.Run(newContext(false, 11), scheduled, ledger)
+		require.NoError(t, err)
+		assert.Empty(t, scheduled.Transactions)
+	})
+}
@@ -72,14 +72,68 @@ func (i ScriptInvocator) Process(
 	proc *ScriptProcedure,
 	st *state.State,
 ) error {
+	if ctx.ExecutionProfiler != nil {
+		return i.processProfiled(vm, ctx, proc, st)
+	}
+
 	env, err := newEnvironment(ctx, vm, st)
 	if err != nil {
 		return err
 	}
 
+	return i.execute(vm.Runtime, env, proc)
+}
+
+// processProfiled executes the script and records its execution in the profiler of the context.
+//
+// Cadence only reports the executed statements to the coverage report of a runtime if it does not meter
+// computation. The script is therefore executed twice: first metered and without the profiler, which
+// ensures that it finishes within the computation limit, and then unmetered on a separate runtime which
+// reports to the profiler. Scripts failing in the first execution are not profiled.
+func (i ScriptInvocator) processProfiled(
+	vm *VirtualMachine,
+	ctx Context,
+	proc *ScriptProcedure,
+	st *state.State,
+) error {
+	profiler := ctx.ExecutionProfiler
+
+	meteredCtx := NewContextFromParent(ctx, WithExecutionProfiler(nil))
+	env, err := newEnvironment(meteredCtx, vm, st)
+	if err != nil {
+		return err
+	}
+
+	err = i.execute(vm.Runtime, env, proc)
+	if err != nil {
+		return err
+	}
+
+	// the changes of the first execution are discarded, while its reads remain cached by the state, so
+	// that they are not counted twice against the interaction limit
+	err = st.Rollback()
+	if err != nil {
+		return err
+	}
+
+	env, err = newEnvironment(ctx, vm, st)
+	if err != nil {
+		return err
+	}
+	env.meteringDisabled = true
+
+	rt := runtime.NewInterpreterRuntime()
+	rt.SetCoverageReport(profiler.coverage)
+
+	profiler.recordCode(common.ScriptLocation(proc.ID[:]), proc.Script)
+
+	return i.execute(rt, env, proc)
+}
+
+func (i ScriptInvocator) execute(rt runtime.Runtime, env *hostEnv, proc *ScriptProcedure) error {
 	location := common.ScriptLocation(proc.ID[:])
 
-	value, err := vm.Runtime.ExecuteScript(
+	value, err := rt.ExecuteScript(
 		runtime.Script{
 			Source:    proc.Script,
 			Arguments: proc.Arguments,
@@ -97,5 +151,7 @@ func (i ScriptInvocator) Process(
 	proc.Logs = env.getLogs()
 	proc.Events = env.Events()
 
+	env.cachePrograms()
+
 	return nil
 }
@@ -22,3 +22,16 @@ func SystemChunkTransaction(serviceAddress flow.Address) *flow.TransactionBody {
 	return flow.NewTransactionBody().
 		SetScript([]byte(fmt.Sprintf(systemChunkTransactionTemplate, serviceAddress)))
 }
+
+// SystemChunkContext returns the context the system chunk transaction is executed in.
+//
+// The system chunk transaction has no signatures and no payer, so it is only processed by the invocator,
+// and it can create accounts and deploy contracts.
+func SystemChunkContext(parent Context) Context {
+	return NewContextFromParent(
+		parent,
+		WithRestrictedAccountCreation(false),
+		WithRestrictedDeployment(false),
+		WithTransactionProcessors(NewTransactionInvocator(parent.Logger)),
+	)
+}
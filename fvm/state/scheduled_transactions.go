@@ -0,0 +1,183 @@
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/utils/slices"
+)
+
+const keyScheduledTransactionCount = "scheduled_transaction_count"
+
+func keyScheduledTransactions(height uint64) string {
+	return fmt.Sprintf("scheduled_transactions_%d", height)
+}
+
+func keyScheduledTransaction(scheduleID uint64) string {
+	return fmt.Sprintf("scheduled_transaction_%d", scheduleID)
+}
+
+// scheduledTransactionRef refers to a scheduled transaction stored by its payer.
+type scheduledTransactionRef struct {
+	Payer      flow.Address
+	ScheduleID uint64
+}
+
+// ScheduledTransactions stores the transactions scheduled for future blocks in controller registers,
+// which the Cadence runtime can't access.
+//
+// Each transaction is stored by its payer, so that its storage is charged to the payer until it is
+// executed. The service account only stores the schedule ID counter and, for each height, the payers
+// and schedule IDs of the transactions scheduled for it.
+type ScheduledTransactions struct {
+	accounts       *Accounts
+	serviceAddress flow.Address
+}
+
+func NewScheduledTransactions(accounts *Accounts, serviceAddress flow.Address) *ScheduledTransactions {
+	return &ScheduledTransactions{
+		accounts:       accounts,
+		serviceAddress: serviceAddress,
+	}
+}
+
+// CountByHeight returns the number of transactions scheduled for the given height.
+func (s *ScheduledTransactions) CountByHeight(height uint64) (int, error) {
+	refs, err := s.refsByHeight(height)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(refs), nil
+}
+
+// ByHeight returns the transactions scheduled for the given height, in the order they were scheduled.
+func (s *ScheduledTransactions) ByHeight(height uint64) ([]flow.ScheduledTransaction, error) {
+	refs, err := s.refsByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	transactions := make([]flow.ScheduledTransaction, len(refs))
+	for i, ref := range refs {
+		encoded, err := s.accounts.getValue(ref.Payer, true, keyScheduledTransaction(ref.ScheduleID))
+		if err != nil {
+			return nil, fmt.Errorf("cannot get scheduled transaction %d: %w", ref.ScheduleID, err)
+		}
+
+		err = cbor.NewDecoder(bytes.NewReader(encoded)).Decode(&transactions[i])
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode scheduled transaction %x: %w", encoded, err)
+		}
+	}
+
+	return transactions, nil
+}
+
+// Schedule assigns the next schedule ID to the transaction, stores it in the registers of its payer,
+// and adds it to the transactions scheduled for its height.
+func (s *ScheduledTransactions) Schedule(tx flow.ScheduledTransaction) (uint64, error) {
+	refs, err := s.refsByHeight(tx.Height)
+	if err != nil {
+		return 0, err
+	}
+
+	countBytes, err := s.accounts.getValue(s.serviceAddress, true, keyScheduledTransactionCount)
+	if err != nil {
+		return 0, fmt.Errorf("cannot get scheduled transaction count: %w", err)
+	}
+	count := binary.BigEndian.Uint64(slices.EnsureByteSliceSize(countBytes, 8))
+
+	tx.ScheduleID = count
+
+	var buf bytes.Buffer
+	err = cbor.NewEncoder(&buf).Encode(tx)
+	if err != nil {
+		return 0, fmt.Errorf("cannot encode scheduled transaction: %w", err)
+	}
+
+	err = s.accounts.setValue(tx.Payer, true, keyScheduledTransaction(tx.ScheduleID), buf.Bytes())
+	if err != nil {
+		return 0, fmt.Errorf("cannot set scheduled transaction: %w", err)
+	}
+
+	refs = append(refs, scheduledTransactionRef{Payer: tx.Payer, ScheduleID: tx.ScheduleID})
+
+	err = s.setRefsByHeight(tx.Height, refs)
+	if err != nil {
+		return 0, err
+	}
+
+	countBytes = make([]byte, 8)
+	binary.BigEndian.PutUint64(countBytes, count+1)
+
+	err = s.accounts.setValue(s.serviceAddress, true, keyScheduledTransactionCount, countBytes)
+	if err != nil {
+		return 0, fmt.Errorf("cannot set scheduled transaction count: %w", err)
+	}
+
+	return tx.ScheduleID, nil
+}
+
+// Remove removes the transactions scheduled for the given height, which releases their storage.
+func (s *ScheduledTransactions) Remove(height uint64) error {
+	refs, err := s.refsByHeight(height)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		err = s.accounts.setValue(ref.Payer, true, keyScheduledTransaction(ref.ScheduleID), nil)
+		if err != nil {
+			return fmt.Errorf("cannot remove scheduled transaction %d: %w", ref.ScheduleID, err)
+		}
+	}
+
+	return s.setRefsByHeight(height, nil)
+}
+
+func (s *ScheduledTransactions) refsByHeight(height uint64) ([]scheduledTransactionRef, error) {
+	encoded, err := s.accounts.getValue(s.serviceAddress, true, keyScheduledTransactions(height))
+	if err != nil {
+		return nil, fmt.Errorf("cannot get scheduled transactions: %w", err)
+	}
+
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+
+	var refs []scheduledTransactionRef
+	err = cbor.NewDecoder(bytes.NewReader(encoded)).Decode(&refs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode scheduled transactions %x: %w", encoded, err)
+	}
+
+	return refs, nil
+}
+
+func (s *ScheduledTransactions) setRefsByHeight(height uint64, refs []scheduledTransactionRef) error {
+	var encoded []byte
+	if len(refs) > 0 {
+		var buf bytes.Buffer
+		err := cbor.NewEncoder(&buf).Encode(refs)
+		if err != nil {
+			return fmt.Errorf("cannot encode scheduled transactions: %w", err)
+		}
+		encoded = buf.Bytes()
+	}
+
+	err := s.accounts.setValue(s.serviceAddress, true, keyScheduledTransactions(height), encoded)
+	if err != nil {
+		return fmt.Errorf("cannot set scheduled transactions: %w", err)
+	}
+
+	return nil
+}
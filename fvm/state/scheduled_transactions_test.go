@@ -0,0 +1,85 @@
+package state_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/fvm/state"
+	"github.com/onflow/flow-go/model/flow"
+)
+
+func TestScheduledTransactions(t *testing.T) {
+	ledger := state.NewMapLedger()
+	st := state.NewState(ledger)
+	accounts := state.NewAccounts(st)
+
+	serviceAddress := flow.HexToAddress("01")
+	err := accounts.Create(nil, serviceAddress)
+	require.NoError(t, err)
+
+	payer := flow.HexToAddress("02")
+	err = accounts.Create(nil, payer)
+	require.NoError(t, err)
+
+	scheduled := state.NewScheduledTransactions(accounts, serviceAddress)
+
+	transactions, err := scheduled.ByHeight(10)
+	require.NoError(t, err)
+	require.Empty(t, transactions)
+
+	tx := flow.ScheduledTransaction{
+		Height:    10,
+		Script:    []byte("transaction {}"),
+		Arguments: [][]byte{[]byte(`{"type":"Int","value":"1"}`)},
+		Payer:     payer,
+		GasLimit:  100,
+	}
+
+	// schedule IDs are assigned in order across heights
+	id, err := scheduled.Schedule(tx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), id)
+
+	other := tx
+	other.Height = 11
+	id, err = scheduled.Schedule(other)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), id)
+
+	id, err = scheduled.Schedule(tx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), id)
+
+	transactions, err = scheduled.ByHeight(10)
+	require.NoError(t, err)
+	require.Len(t, transactions, 2)
+
+	count, err := scheduled.CountByHeight(10)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	expected := tx
+	expected.ScheduleID = 0
+	require.Equal(t, expected, transactions[0])
+	require.Equal(t, uint64(2), transactions[1].ScheduleID)
+
+	// the storage used by the transactions is accounted to the payer
+	storageUsed, err := accounts.GetStorageUsed(payer)
+	require.NoError(t, err)
+
+	err = scheduled.Remove(10)
+	require.NoError(t, err)
+
+	transactions, err = scheduled.ByHeight(10)
+	require.NoError(t, err)
+	require.Empty(t, transactions)
+
+	storageUsedAfterRemoval, err := accounts.GetStorageUsed(payer)
+	require.NoError(t, err)
+	require.Less(t, storageUsedAfterRemoval, storageUsed)
+
+	transactions, err = scheduled.ByHeight(11)
+	require.NoError(t, err)
+	require.Len(t, transactions, 1)
+}
@@ -31,9 +31,8 @@ type TransactionProcedure struct {
 	TxIndex     uint32
 	Logs        []string
 	Events      []flow.Event
-	// TODO: report gas consumption: https://github.com/dapperlabs/flow-go/issues/4139
-	GasUsed uint64
-	Err     Error
+	GasUsed     uint64
+	Err         Error
 }
 
 func (proc *TransactionProcedure) Run(vm *VirtualMachine, ctx Context, st *state.State) error {
@@ -46,10 +45,20 @@ func (proc *TransactionProcedure) Run(vm *VirtualMachine, ctx Context, st *state
 
 		if vmErr != nil {
 			proc.Err = vmErr
-			return nil
+			break
 		}
 	}
 
+	// scheduled transactions report their execution even if they failed, so that they can be found
+	// by their event
+	if ctx.scheduledTransaction {
+		event, err := scheduledTransactionExecutedEvent(proc)
+		if err != nil {
+			return err
+		}
+		proc.Events = append(proc.Events, event)
+	}
+
 	return nil
 }
 
@@ -77,17 +86,25 @@ func (i *TransactionInvocator) Process(
 
 	location := common.TransactionLocation(proc.ID[:])
 
+	var predeclaredValues []runtime.ValueDeclaration
+	if ctx.ScheduledTransactionsEnabled {
+		predeclaredValues = append(predeclaredValues, env.scheduleTransactionDeclaration())
+	}
+
 	err = vm.Runtime.ExecuteTransaction(
 		runtime.Script{
 			Source:    proc.Transaction.Script,
 			Arguments: proc.Transaction.Arguments,
 		},
 		runtime.Context{
-			Interface: env,
-			Location:  location,
+			Interface:         env,
+			Location:          location,
+			PredeclaredValues: predeclaredValues,
 		},
 	)
 
+	proc.GasUsed = env.totalGasUsed
+
 	if err != nil {
 		i.safetyErrorCheck(err)
 		return err
@@ -95,6 +112,8 @@ func (i *TransactionInvocator) Process(
 
 	i.logger.Info().Str("txHash", proc.ID.String()).Msgf("(%d) ledger interactions used by transaction", st.InteractionUsed())
 
+	env.cachePrograms()
+
 	proc.Events = env.getEvents()
 	proc.Logs = env.getLogs()
 
@@ -17,7 +17,17 @@ func (d *TransactionFeeDeductor) Process(
 	proc *TransactionProcedure,
 	st *state.State,
 ) error {
-	return d.deductFees(vm, ctx, proc.Transaction, st)
+	err := d.deductFees(vm, ctx, proc.Transaction, st)
+	if err == nil {
+		return nil
+	}
+
+	vmErr, fatalErr := handleError(err)
+	if fatalErr != nil {
+		return fatalErr
+	}
+
+	return &FeeDeductionFailedError{Err: vmErr}
 }
 
 func (d *TransactionFeeDeductor) deductFees(
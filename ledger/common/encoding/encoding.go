@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common"
 	"github.com/onflow/flow-go/ledger/common/utils"
 )
 
@@ -15,6 +16,21 @@ import (
 // codes should be updated with backward compatibility if needed
 const Version = uint16(0)
 
+// ProofVersion captures the maximum version of the encoding of proofs (trie proofs, batch proofs and compact
+// batch proofs) that this code supports. Proofs are versioned separately from the other entities, which are
+// also stored in WAL segments and checkpoints, so that changes to the encoding of proofs leave the format
+// of the stored data unchanged.
+const ProofVersion = uint16(2)
+
+// versions of the encoding of proofs after the initial version 0, decoders support proofs of all versions up to ProofVersion
+const (
+	// versionCompactBatchProof introduces compactly encoded batch proofs (TypeCompactBatchProof)
+	versionCompactBatchProof = uint16(1)
+	// versionTrieHasher records the trie hasher in proofs, in the inclusion byte of trie proofs
+	// and in a separate byte of compact batch proofs
+	versionTrieHasher = uint16(2)
+)
+
 // Type capture the type of encoded entity (e.g. State, Key, Value, Path)
 type Type uint8
 
@@ -44,24 +60,37 @@ const (
 	TypeUpdate
 	// TypeTrieUpdate - type for trie update
 	TypeTrieUpdate
+	// TypeCompactBatchProof - type for BatchProofs encoded as a single proof tree
+	// (shared interim nodes are only included once)
+	TypeCompactBatchProof
 	// this is used to flag types from the future
 	typeUnsuported
 )
 
 func (e Type) String() string {
-	return [...]string{"Unknown", "State", "KeyPart", "Key", "Value", "Path", "Payload", "Proof", "BatchProof", "Query", "Update", "Trie Update"}[e]
+	return [...]string{"Unknown", "State", "KeyPart", "Key", "Value", "Path", "Payload", "Proof", "BatchProof", "Query", "Update", "Trie Update", "Compact BatchProof"}[e]
 }
 
 // CheckVersion extracts encoding bytes from a raw encoded message
 // checks it against the supported versions and returns the rest of rawInput (excluding encDecVersion bytes)
 func CheckVersion(rawInput []byte) (rest []byte, version uint16, err error) {
+	return checkVersion(rawInput, Version)
+}
+
+// checkProofVersion extracts encoding bytes from an encoded proof
+// checks it against the supported proof versions and returns the rest of rawInput (excluding encDecVersion bytes)
+func checkProofVersion(rawInput []byte) (rest []byte, version uint16, err error) {
+	return checkVersion(rawInput, ProofVersion)
+}
+
+func checkVersion(rawInput []byte, maxVersion uint16) (rest []byte, version uint16, err error) {
 	version, rest, err = utils.ReadUint16(rawInput)
 	if err != nil {
 		return rest, version, fmt.Errorf("error checking the encoding decoding version: %w", err)
 	}
 	// error on versions coming from future till a time-machine is invented
-	if version > Version {
-		return rest, version, fmt.Errorf("incompatible encoding decoding version (%d > %d): %w", version, Version, err)
+	if version > maxVersion {
+		return rest, version, fmt.Errorf("incompatible encoding decoding version (%d > %d): %w", version, maxVersion, err)
 	}
 	// return the rest of bytes
 	return rest, version, nil
@@ -545,13 +574,23 @@ func decodeTrieUpdate(inp []byte) (*ledger.TrieUpdate, error) {
 	return &ledger.TrieUpdate{RootHash: rh, Paths: paths, Payloads: payloads}, nil
 }
 
+// decodeTrieHasher checks that the encoded trie hasher is known to this code
+func decodeTrieHasher(b uint8) (ledger.TrieHasherType, error) {
+	hasher := ledger.TrieHasherType(b)
+	switch hasher {
+	case ledger.TrieHasherSHA3, ledger.TrieHasherBlake2b:
+		return hasher, nil
+	}
+	return 0, fmt.Errorf("unknown trie hasher %d", b)
+}
+
 // EncodeTrieProof encodes the content of a proof into a byte slice
 func EncodeTrieProof(p *ledger.TrieProof) []byte {
 	if p == nil {
 		return []byte{}
 	}
 	// encode version
-	buffer := utils.AppendUint16([]byte{}, Version)
+	buffer := utils.AppendUint16([]byte{}, ProofVersion)
 
 	// encode proof entity type
 	buffer = utils.AppendUint8(buffer, TypeProof)
@@ -563,12 +602,14 @@ func EncodeTrieProof(p *ledger.TrieProof) []byte {
 }
 
 func encodeTrieProof(p *ledger.TrieProof) []byte {
-	// first byte is reserved for inclusion flag
+	// first byte is reserved for inclusion flag and trie hasher
 	buffer := make([]byte, 1)
 	if p.Inclusion {
 		// set the first bit to 1 if it is an inclusion proof
 		buffer[0] |= 1 << 7
 	}
+	// the remaining 7 bits encode the trie hasher (since versionTrieHasher)
+	buffer[0] |= uint8(p.Hasher) & 0x7f
 
 	// steps are encoded as a single byte
 	buffer = utils.AppendUint8(buffer, p.Steps)
@@ -600,7 +641,7 @@ func encodeTrieProof(p *ledger.TrieProof) []byte {
 // DecodeTrieProof construct a proof from an encoded byte slice
 func DecodeTrieProof(encodedProof []byte) (*ledger.TrieProof, error) {
 	// check the enc dec version
-	rest, _, err := CheckVersion(encodedProof)
+	rest, version, err := checkProofVersion(encodedProof)
 	if err != nil {
 		return nil, fmt.Errorf("error decoding proof: %w", err)
 	}
@@ -609,10 +650,10 @@ func DecodeTrieProof(encodedProof []byte) (*ledger.TrieProof, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error decoding proof: %w", err)
 	}
-	return decodeTrieProof(rest)
+	return decodeTrieProof(rest, version)
 }
 
-func decodeTrieProof(inp []byte) (*ledger.TrieProof, error) {
+func decodeTrieProof(inp []byte, version uint16) (*ledger.TrieProof, error) {
 	pInst := ledger.NewTrieProof()
 
 	// Inclusion flag
@@ -622,6 +663,14 @@ func decodeTrieProof(inp []byte) (*ledger.TrieProof, error) {
 	}
 	pInst.Inclusion, _ = utils.IsBitSet(byteInclusion, 0)
 
+	// Trie hasher, proofs encoded before versionTrieHasher were generated from SHA3 tries
+	if version >= versionTrieHasher {
+		pInst.Hasher, err = decodeTrieHasher(byteInclusion[0] & 0x7f)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding proof: %w", err)
+		}
+	}
+
 	// read steps
 	steps, rest, err := utils.ReadUint8(rest)
 	if err != nil {
@@ -693,13 +742,29 @@ func decodeTrieProof(inp []byte) (*ledger.TrieProof, error) {
 	return pInst, nil
 }
 
-// EncodeTrieBatchProof encodes a batch proof into a byte slice
+// EncodeCompactTrieBatchProof encodes a batch proof compactly, if it can be merged into a compact batch
+// proof (see common.CompactTrieBatchProof), e.g. proofs generated by the complete ledger. All other batch
+// proofs are encoded like EncodeTrieBatchProof does.
+// Compact batch proofs can only be decoded by code which supports versionCompactBatchProof.
+func EncodeCompactTrieBatchProof(bp *ledger.TrieBatchProof) []byte {
+	if bp == nil {
+		return []byte{}
+	}
+	cbp, err := common.CompactTrieBatchProof(bp)
+	if err != nil {
+		return EncodeTrieBatchProof(bp)
+	}
+	return EncodeTrieCompactBatchProof(cbp)
+}
+
+// EncodeTrieBatchProof encodes a batch proof into a byte slice, as a list of independent proofs
 func EncodeTrieBatchProof(bp *ledger.TrieBatchProof) []byte {
 	if bp == nil {
 		return []byte{}
 	}
+
 	// encode version
-	buffer := utils.AppendUint16([]byte{}, Version)
+	buffer := utils.AppendUint16([]byte{}, ProofVersion)
 
 	// encode batch proof entity type
 	buffer = utils.AppendUint8(buffer, TypeBatchProof)
@@ -726,28 +791,58 @@ func encodeTrieBatchProof(bp *ledger.TrieBatchProof) []byte {
 	return buffer
 }
 
-// DecodeTrieBatchProof constructs a batch proof from an encoded byte slice
+// DecodeTrieBatchProof constructs a batch proof from an encoded byte slice.
+// Compactly encoded batch proofs are expanded into a separate proof for each path.
 func DecodeTrieBatchProof(encodedBatchProof []byte) (*ledger.TrieBatchProof, error) {
-	// check the enc dec version
-	rest, _, err := CheckVersion(encodedBatchProof)
+	rest, version, compact, err := checkBatchProofType(encodedBatchProof)
 	if err != nil {
 		return nil, fmt.Errorf("error decoding batch proof: %w", err)
 	}
-	// check the encoding type
-	rest, err = CheckType(rest, TypeBatchProof)
+
+	if !compact {
+		// decode the batch proof content
+		bp, err := decodeTrieBatchProof(rest, version)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding batch proof: %w", err)
+		}
+		return bp, nil
+	}
+
+	cbp, err := decodeTrieCompactBatchProof(rest, version)
 	if err != nil {
 		return nil, fmt.Errorf("error decoding batch proof: %w", err)
 	}
-
-	// decode the batch proof content
-	bp, err := decodeTrieBatchProof(rest)
+	bp, err := common.ExpandTrieCompactBatchProof(cbp)
 	if err != nil {
 		return nil, fmt.Errorf("error decoding batch proof: %w", err)
 	}
 	return bp, nil
 }
 
-func decodeTrieBatchProof(inp []byte) (*ledger.TrieBatchProof, error) {
+// checkBatchProofType checks the version and the type of an encoded batch proof,
+// which can be either a compact or a regular batch proof
+func checkBatchProofType(encodedBatchProof []byte) (rest []byte, version uint16, compact bool, err error) {
+	// check the enc dec version
+	rest, version, err = checkProofVersion(encodedBatchProof)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	// check the encoding type, compact batch proofs only exist since versionCompactBatchProof
+	compactRest, err := CheckType(rest, TypeCompactBatchProof)
+	if err == nil {
+		if version < versionCompactBatchProof {
+			return nil, 0, false, fmt.Errorf("compact batch proof with unsupported encoding version %d", version)
+		}
+		return compactRest, version, true, nil
+	}
+	rest, err = CheckType(rest, TypeBatchProof)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return rest, version, false, nil
+}
+
+func decodeTrieBatchProof(inp []byte, version uint16) (*ledger.TrieBatchProof, error) {
 	bp := ledger.NewTrieBatchProof()
 	// number of proofs
 	numOfProofs, rest, err := utils.ReadUint32(inp)
@@ -771,7 +866,7 @@ func decodeTrieBatchProof(inp []byte) (*ledger.TrieBatchProof, error) {
 		}
 
 		// decode encoded proof
-		proof, err := decodeTrieProof(encProof)
+		proof, err := decodeTrieProof(encProof, version)
 		if err != nil {
 			return nil, fmt.Errorf("error decoding batch proof (content): %w", err)
 		}
@@ -779,3 +874,191 @@ func decodeTrieBatchProof(inp []byte) (*ledger.TrieBatchProof, error) {
 	}
 	return bp, nil
 }
+
+// EncodeTrieCompactBatchProof encodes a compact batch proof into a byte slice
+func EncodeTrieCompactBatchProof(cbp *ledger.TrieCompactBatchProof) []byte {
+	if cbp == nil {
+		return []byte{}
+	}
+	// encode version
+	buffer := utils.AppendUint16([]byte{}, ProofVersion)
+
+	// encode compact batch proof entity type
+	buffer = utils.AppendUint8(buffer, TypeCompactBatchProof)
+	// encode compact batch proof content
+	buffer = append(buffer, encodeTrieCompactBatchProof(cbp)...)
+
+	return buffer
+}
+
+func encodeTrieCompactBatchProof(cbp *ledger.TrieCompactBatchProof) []byte {
+	buffer := make([]byte, 0)
+
+	// encode trie hasher
+	buffer = utils.AppendUint8(buffer, uint8(cbp.Hasher))
+
+	// encode path size and number of paths
+	pathSize := 0
+	if len(cbp.Paths) > 0 {
+		pathSize = cbp.Paths[0].Size()
+	}
+	buffer = utils.AppendUint16(buffer, uint16(pathSize))
+	buffer = utils.AppendUint32(buffer, uint32(len(cbp.Paths)))
+
+	// encode path, steps and payload of every proven leaf
+	for i, path := range cbp.Paths {
+		buffer = append(buffer, path...)
+		buffer = utils.AppendUint8(buffer, cbp.Steps[i])
+		buffer = utils.AppendLongData(buffer, encodePayload(cbp.Payloads[i]))
+	}
+
+	// encode flags
+	buffer = utils.AppendLongData(buffer, cbp.Flags)
+
+	// encode interims, which are all hashes of the same size
+	interimSize := 0
+	if len(cbp.Interims) > 0 {
+		interimSize = len(cbp.Interims[0])
+	}
+	buffer = utils.AppendUint32(buffer, uint32(len(cbp.Interims)))
+	buffer = utils.AppendUint16(buffer, uint16(interimSize))
+	for _, interim := range cbp.Interims {
+		buffer = append(buffer, interim...)
+	}
+
+	// encode indices of proven paths
+	buffer = utils.AppendUint32(buffer, uint32(len(cbp.ProofIndices)))
+	for _, index := range cbp.ProofIndices {
+		buffer = utils.AppendUint32(buffer, index)
+	}
+
+	return buffer
+}
+
+// DecodeTrieCompactBatchProof constructs a compact batch proof from an encoded byte slice.
+// Regular batch proofs are converted into compact batch proofs, which fails if the proofs can't be merged.
+func DecodeTrieCompactBatchProof(encodedBatchProof []byte) (*ledger.TrieCompactBatchProof, error) {
+	rest, version, compact, err := checkBatchProofType(encodedBatchProof)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding compact batch proof: %w", err)
+	}
+
+	if !compact {
+		bp, err := decodeTrieBatchProof(rest, version)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding compact batch proof: %w", err)
+		}
+		cbp, err := common.CompactTrieBatchProof(bp)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding compact batch proof: %w", err)
+		}
+		return cbp, nil
+	}
+
+	cbp, err := decodeTrieCompactBatchProof(rest, version)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding compact batch proof: %w", err)
+	}
+	return cbp, nil
+}
+
+func decodeTrieCompactBatchProof(inp []byte, version uint16) (*ledger.TrieCompactBatchProof, error) {
+	cbp := ledger.NewTrieCompactBatchProof()
+
+	// read trie hasher, proofs encoded before versionTrieHasher were generated from SHA3 tries
+	rest := inp
+	if version >= versionTrieHasher {
+		var hasher uint8
+		var err error
+		hasher, rest, err = utils.ReadUint8(rest)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding compact batch proof (content): %w", err)
+		}
+		cbp.Hasher, err = decodeTrieHasher(hasher)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding compact batch proof (content): %w", err)
+		}
+	}
+
+	// read path size and number of paths
+	pathSize, rest, err := utils.ReadUint16(rest)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding compact batch proof (content): %w", err)
+	}
+	numOfPaths, rest, err := utils.ReadUint32(rest)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding compact batch proof (content): %w", err)
+	}
+
+	// read path, steps and payload of every proven leaf
+	for i := 0; i < int(numOfPaths); i++ {
+		var path, encPayload []byte
+		var steps uint8
+		path, rest, err = utils.ReadSlice(rest, int(pathSize))
+		if err != nil {
+			return nil, fmt.Errorf("error decoding compact batch proof (content): %w", err)
+		}
+		steps, rest, err = utils.ReadUint8(rest)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding compact batch proof (content): %w", err)
+		}
+		encPayload, rest, err = utils.ReadLongData(rest)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding compact batch proof (content): %w", err)
+		}
+		payload, err := decodePayload(encPayload)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding compact batch proof (content): %w", err)
+		}
+		cbp.Paths = append(cbp.Paths, path)
+		cbp.Steps = append(cbp.Steps, steps)
+		cbp.Payloads = append(cbp.Payloads, payload)
+	}
+
+	// read flags
+	flags, rest, err := utils.ReadLongData(rest)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding compact batch proof (content): %w", err)
+	}
+	cbp.Flags = flags
+
+	// read interims
+	numOfInterims, rest, err := utils.ReadUint32(rest)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding compact batch proof (content): %w", err)
+	}
+	interimSize, rest, err := utils.ReadUint16(rest)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding compact batch proof (content): %w", err)
+	}
+	for i := 0; i < int(numOfInterims); i++ {
+		var interim []byte
+		interim, rest, err = utils.ReadSlice(rest, int(interimSize))
+		if err != nil {
+			return nil, fmt.Errorf("error decoding compact batch proof (content): %w", err)
+		}
+		cbp.Interims = append(cbp.Interims, interim)
+	}
+
+	// read indices of proven paths
+	numOfProofs, rest, err := utils.ReadUint32(rest)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding compact batch proof (content): %w", err)
+	}
+	for i := 0; i < int(numOfProofs); i++ {
+		var index uint32
+		index, rest, err = utils.ReadUint32(rest)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding compact batch proof (content): %w", err)
+		}
+		if index >= numOfPaths {
+			return nil, fmt.Errorf("error decoding compact batch proof (content): unknown path %d", index)
+		}
+		cbp.ProofIndices = append(cbp.ProofIndices, index)
+	}
+
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("error decoding compact batch proof (content): %d unexpected trailing bytes", len(rest))
+	}
+	return cbp, nil
+}
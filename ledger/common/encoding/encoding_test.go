@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common"
 	"github.com/onflow/flow-go/ledger/common/encoding"
 	"github.com/onflow/flow-go/ledger/common/utils"
 )
@@ -71,6 +72,26 @@ func Test_PayloadEncodingDecoding(t *testing.T) {
 	require.True(t, newp.Equals(p))
 }
 
+// Test_PayloadEncodingVersion tests that payloads, which are stored in WAL segments and checkpoints,
+// are still encoded with the initial encoding version, independent of the version of proofs
+func Test_PayloadEncodingVersion(t *testing.T) {
+	raw := []byte{
+		0, 0, // encoding version
+		6,          // payload type
+		0, 0, 0, 9, // key data len
+		0, 1, // number of key parts
+		0, 0, 0, 3, // key part data len
+		0, 0, 65, // key part (type 0, value "A")
+		0, 0, 0, 0, 0, 0, 0, 1, // value len
+		97, // value
+	}
+
+	payload, err := encoding.DecodePayload(raw)
+	require.NoError(t, err)
+	require.Equal(t, ledger.Value("a"), payload.Value)
+	require.Equal(t, raw, encoding.EncodePayload(payload))
+}
+
 // Test_ProofEncodingDecoding tests encoding decoding functionality of a proof
 func Test_TrieProofEncodingDecoding(t *testing.T) {
 	p, _ := utils.TrieProofFixture()
@@ -78,6 +99,29 @@ func Test_TrieProofEncodingDecoding(t *testing.T) {
 	newp, err := encoding.DecodeTrieProof(encoded)
 	require.NoError(t, err)
 	require.True(t, newp.Equals(p))
+	require.Equal(t, ledger.TrieHasherSHA3, newp.Hasher)
+
+	// the trie hasher is recorded next to the inclusion flag
+	p.Hasher = ledger.TrieHasherBlake2b
+	encoded = encoding.EncodeTrieProof(p)
+	newp, err = encoding.DecodeTrieProof(encoded)
+	require.NoError(t, err)
+	require.True(t, newp.Equals(p))
+	require.True(t, newp.Inclusion)
+	require.Equal(t, ledger.TrieHasherBlake2b, newp.Hasher)
+
+	// the trie hasher bits are ignored in proofs encoded before hashers were recorded
+	v1 := append(utils.AppendUint16([]byte{}, 1), encoded[2:]...)
+	newp, err = encoding.DecodeTrieProof(v1)
+	require.NoError(t, err)
+	require.True(t, newp.Inclusion)
+	require.Equal(t, ledger.TrieHasherSHA3, newp.Hasher)
+
+	// unknown trie hashers are rejected
+	unknown := append([]byte{}, encoded...)
+	unknown[3] = 1<<7 | 0x7f
+	_, err = encoding.DecodeTrieProof(unknown)
+	require.Error(t, err)
 }
 
 // Test_BatchProofEncodingDecoding tests encoding decoding functionality of a batch proof
@@ -89,6 +133,97 @@ func Test_BatchProofEncodingDecoding(t *testing.T) {
 	require.True(t, newbp.Equals(bp))
 }
 
+// Test_CompactBatchProofEncodingDecoding tests encoding decoding functionality of a compact batch proof
+func Test_CompactBatchProofEncodingDecoding(t *testing.T) {
+	bp, _ := utils.TrieBatchProofFixture()
+	cbp, err := common.CompactTrieBatchProof(bp)
+	require.NoError(t, err)
+
+	// batch proofs are only encoded compactly if requested
+	_, err = encoding.CheckType(encoding.EncodeTrieBatchProof(bp)[2:], encoding.TypeBatchProof)
+	require.NoError(t, err)
+
+	encoded := encoding.EncodeCompactTrieBatchProof(bp)
+	require.Equal(t, encoding.EncodeTrieCompactBatchProof(cbp), encoded)
+	_, err = encoding.CheckType(encoded[2:], encoding.TypeCompactBatchProof)
+	require.NoError(t, err)
+
+	newcbp, err := encoding.DecodeTrieCompactBatchProof(encoded)
+	require.NoError(t, err)
+	require.True(t, newcbp.Equals(cbp))
+
+	newbp, err := encoding.DecodeTrieBatchProof(encoded)
+	require.NoError(t, err)
+	require.True(t, newbp.Equals(bp))
+
+	// regular batch proofs of the initial encoding version are still decoded
+	legacy := utils.AppendUint16([]byte{}, 0)
+	legacy = utils.AppendUint8(legacy, encoding.TypeBatchProof)
+	legacy = utils.AppendUint32(legacy, uint32(len(bp.Proofs)))
+	for _, p := range bp.Proofs {
+		// strip version and type of the encoded proof
+		encP := encoding.EncodeTrieProof(p)[3:]
+		legacy = utils.AppendUint64(legacy, uint64(len(encP)))
+		legacy = append(legacy, encP...)
+	}
+	require.Less(t, len(encoded), len(legacy))
+
+	newbp, err = encoding.DecodeTrieBatchProof(legacy)
+	require.NoError(t, err)
+	require.True(t, newbp.Equals(bp))
+
+	newcbp, err = encoding.DecodeTrieCompactBatchProof(legacy)
+	require.NoError(t, err)
+	require.True(t, newcbp.Equals(cbp))
+
+	// compact batch proofs didn't exist in the initial encoding version
+	initial := append(utils.AppendUint16([]byte{}, 0), encoded[2:]...)
+	_, err = encoding.DecodeTrieBatchProof(initial)
+	require.Error(t, err)
+
+	// batch proofs which can't be merged are encoded as regular batch proofs
+	bp.Proofs[1].Inclusion = false
+	encoded = encoding.EncodeCompactTrieBatchProof(bp)
+	_, err = encoding.CheckType(encoded[2:], encoding.TypeBatchProof)
+	require.NoError(t, err)
+	newbp, err = encoding.DecodeTrieBatchProof(encoded)
+	require.NoError(t, err)
+	require.True(t, newbp.Equals(bp))
+
+	// the trie hasher is recorded
+	bp, _ = utils.TrieBatchProofFixture()
+	for _, p := range bp.Proofs {
+		p.Hasher = ledger.TrieHasherBlake2b
+	}
+	newcbp, err = encoding.DecodeTrieCompactBatchProof(encoding.EncodeCompactTrieBatchProof(bp))
+	require.NoError(t, err)
+	require.Equal(t, ledger.TrieHasherBlake2b, newcbp.Hasher)
+	newbp, err = common.ExpandTrieCompactBatchProof(newcbp)
+	require.NoError(t, err)
+	for _, p := range newbp.Proofs {
+		require.Equal(t, ledger.TrieHasherBlake2b, p.Hasher)
+	}
+
+	// compact batch proofs encoded before hashers were recorded have no trie hasher byte
+	encoded = encoding.EncodeTrieCompactBatchProof(cbp)
+	v1 := append(utils.AppendUint16([]byte{}, 1), encoded[2])
+	v1 = append(v1, encoded[4:]...)
+	newcbp, err = encoding.DecodeTrieCompactBatchProof(v1)
+	require.NoError(t, err)
+	require.True(t, newcbp.Equals(cbp))
+	require.Equal(t, ledger.TrieHasherSHA3, newcbp.Hasher)
+
+	// unknown trie hashers are rejected
+	unknown := append([]byte{}, encoded...)
+	unknown[3] = 0xff
+	_, err = encoding.DecodeTrieCompactBatchProof(unknown)
+	require.Error(t, err)
+
+	// truncated data
+	_, err = encoding.DecodeTrieCompactBatchProof(encoding.EncodeTrieCompactBatchProof(cbp)[:20])
+	require.Error(t, err)
+}
+
 // Test_TrieUpdateEncodingDecoding tests encoding decoding functionality of a trie update
 func Test_TrieUpdateEncodingDecoding(t *testing.T) {
 
@@ -1,96 +1,176 @@
 package common
 
 import (
+	"fmt"
+
+	"golang.org/x/crypto/blake2b"
+
 	"github.com/onflow/flow-go/crypto/hash"
 	"github.com/onflow/flow-go/ledger"
 	"github.com/onflow/flow-go/ledger/common/utils"
 )
 
-// default value and default hash value for a default node
-var emptySlice []byte
-var defaultLeafHash = HashLeaf([]byte("default:"), emptySlice)
-
-// we are currently supporting paths of a size up to 32 bytes. I.e. path length from the rootNode of a fully expanded tree to the leaf node is 256. A path of length k is comprised of k+1 vertices. Hence, we need 257 default hashes.
-var defaultHashes [257][]byte
-
-func init() {
-	// Creates the Default hashes from base to level height
-	defaultHashes[0] = defaultLeafHash
-	for i := 1; i < len(defaultHashes); i++ {
-		defaultHashes[i] = HashInterNode(defaultHashes[i-1], defaultHashes[i-1])
-	}
-}
-
-// GetDefaultHashes returns the default hashes of the SMT.
+// TrieHasher computes the hashes of the nodes of a trie.
 //
-// For each tree level N, there is a default hash equal to the chained
-// hashing of the default value N times.
-func GetDefaultHashes() [257][]byte {
-	return defaultHashes
+// All nodes of a trie (and all proofs generated from it) must be hashed with the same
+// TrieHasher. The default values and default hashes are specific to each TrieHasher.
+type TrieHasher interface {
+	// Type returns the type of the hasher, as recorded in proofs
+	Type() ledger.TrieHasherType
+	// HashLeaf generates hash value for leaf nodes.
+	// note that we don't include the keys here as they are already included in the path
+	HashLeaf(path []byte, value []byte) []byte
+	// HashInterNode generates hash value for intermediate nodes.
+	HashInterNode(hash1 []byte, hash2 []byte) []byte
+	// GetDefaultHashForHeight returns the default hashes of the SMT at a specified height.
+	GetDefaultHashForHeight(height int) []byte
+	// ComputeCompactValue computes the value for the node considering the sub tree to only include this value and default values.
+	ComputeCompactValue(path []byte, payload *ledger.Payload, nodeHeight int) []byte
 }
 
-// GetDefaultHashForHeight returns the default hashes of the SMT at a specified height.
-//
-// For each tree level N, there is a default hash equal to the chained
-// hashing of the default value N times.
-func GetDefaultHashForHeight(height int) []byte {
-	return defaultHashes[height]
-}
+// DefaultTrieHasher is the SHA3-256 TrieHasher, used unless specified otherwise
+var DefaultTrieHasher TrieHasher = sha3TrieHasher
 
-// HashLeaf generates hash value for leaf nodes (SHA3-256).
-// note that we don't include the keys here as they are already included in the path
-func HashLeaf(path []byte, value []byte) []byte {
+var sha3TrieHasher = newTrieHasher(ledger.TrieHasherSHA3, func(a []byte, b []byte) []byte {
 	hasher := hash.NewSHA3_256()
-	_, err := hasher.Write(path)
+	_, err := hasher.Write(a)
 	if err != nil {
 		panic(err)
 	}
-	_, err = hasher.Write(value)
+	_, err = hasher.Write(b)
 	if err != nil {
 		panic(err)
 	}
-
 	return hasher.SumHash()
-}
+})
 
-// HashInterNode generates hash value for intermediate nodes (SHA3-256).
-func HashInterNode(hash1 []byte, hash2 []byte) []byte {
-	hasher := hash.NewSHA3_256()
-	_, err := hasher.Write(hash1)
+var blake2bTrieHasher = newTrieHasher(ledger.TrieHasherBlake2b, func(a []byte, b []byte) []byte {
+	hasher, err := blake2b.New256(nil)
+	if err != nil { // this won't happen ever, as no key is used
+		panic(err)
+	}
+	_, err = hasher.Write(a)
 	if err != nil {
 		panic(err)
 	}
-	_, err = hasher.Write(hash2)
+	_, err = hasher.Write(b)
 	if err != nil {
 		panic(err)
 	}
-	return hasher.SumHash()
+	return hasher.Sum(nil)
+})
+
+// NewTrieHasher returns the TrieHasher of the given type
+func NewTrieHasher(t ledger.TrieHasherType) (TrieHasher, error) {
+	switch t {
+	case ledger.TrieHasherSHA3:
+		return sha3TrieHasher, nil
+	case ledger.TrieHasherBlake2b:
+		return blake2bTrieHasher, nil
+	}
+	return nil, fmt.Errorf("unsupported trie hasher: %s", t)
 }
 
-// ComputeCompactValue computes the value for the node considering the sub tree to only include this value and default values.
-func ComputeCompactValue(path []byte, payload *ledger.Payload, nodeHeight int) []byte {
+// SupportedTrieHashers returns all supported TrieHashers, starting with the DefaultTrieHasher
+func SupportedTrieHashers() []TrieHasher {
+	return []TrieHasher{sha3TrieHasher, blake2bTrieHasher}
+}
+
+// trieHasher implements TrieHasher for a given hash function
+type trieHasher struct {
+	hasherType ledger.TrieHasherType
+	hash       func(a []byte, b []byte) []byte // hash of the concatenation of a and b
+	// we are currently supporting paths of a size up to 32 bytes. I.e. path length from the rootNode of a fully expanded tree to the leaf node is 256. A path of length k is comprised of k+1 vertices. Hence, we need 257 default hashes.
+	defaultHashes [257][]byte
+}
+
+func newTrieHasher(hasherType ledger.TrieHasherType, hash func(a []byte, b []byte) []byte) *trieHasher {
+	h := &trieHasher{
+		hasherType: hasherType,
+		hash:       hash,
+	}
+	// Creates the Default hashes from base to level height
+	h.defaultHashes[0] = h.HashLeaf([]byte("default:"), emptySlice)
+	for i := 1; i < len(h.defaultHashes); i++ {
+		h.defaultHashes[i] = h.HashInterNode(h.defaultHashes[i-1], h.defaultHashes[i-1])
+	}
+	return h
+}
+
+func (h *trieHasher) Type() ledger.TrieHasherType {
+	return h.hasherType
+}
+
+func (h *trieHasher) HashLeaf(path []byte, value []byte) []byte {
+	return h.hash(path, value)
+}
+
+func (h *trieHasher) HashInterNode(hash1 []byte, hash2 []byte) []byte {
+	return h.hash(hash1, hash2)
+}
+
+func (h *trieHasher) GetDefaultHashForHeight(height int) []byte {
+	return h.defaultHashes[height]
+}
+
+func (h *trieHasher) ComputeCompactValue(path []byte, payload *ledger.Payload, nodeHeight int) []byte {
 	// if register is unallocated: return default hash
 	if len(payload.Value) == 0 {
-		return GetDefaultHashForHeight(nodeHeight)
+		return h.GetDefaultHashForHeight(nodeHeight)
 	}
 
 	// register is allocated
 	treeHeight := 8 * len(path)
 	// TODO Change this later to include the key as well
 	// for now is just the value to make it compatible with previous code
-	computedHash := HashLeaf(path, payload.Value) // we first compute the hash of the fully-expanded leaf
-	for h := 1; h <= nodeHeight; h++ {            // then, we hash our way upwards towards the root until we hit the specified nodeHeight
-		// h is the height of the node, whose hash we are computing in this iteration.
-		// The hash is computed from the node's children at height h-1.
-		bitIsSet, err := utils.IsBitSet(path, treeHeight-h)
+	computedHash := h.HashLeaf(path, payload.Value)   // we first compute the hash of the fully-expanded leaf
+	for height := 1; height <= nodeHeight; height++ { // then, we hash our way upwards towards the root until we hit the specified nodeHeight
+		// height is the height of the node, whose hash we are computing in this iteration.
+		// The hash is computed from the node's children at height-1.
+		bitIsSet, err := utils.IsBitSet(path, treeHeight-height)
 		if err != nil { // this won't happen ever
 			panic(err)
 		}
 		if bitIsSet { // right branching
-			computedHash = HashInterNode(GetDefaultHashForHeight(h-1), computedHash)
+			computedHash = h.HashInterNode(h.GetDefaultHashForHeight(height-1), computedHash)
 		} else { // left branching
-			computedHash = HashInterNode(computedHash, GetDefaultHashForHeight(h-1))
+			computedHash = h.HashInterNode(computedHash, h.GetDefaultHashForHeight(height-1))
 		}
 	}
 	return computedHash
 }
+
+// default value for a default node
+var emptySlice []byte
+
+// GetDefaultHashes returns the default hashes of the SMT (using the DefaultTrieHasher).
+//
+// For each tree level N, there is a default hash equal to the chained
+// hashing of the default value N times.
+func GetDefaultHashes() [257][]byte {
+	return sha3TrieHasher.defaultHashes
+}
+
+// GetDefaultHashForHeight returns the default hashes of the SMT at a specified height (SHA3-256).
+//
+// For each tree level N, there is a default hash equal to the chained
+// hashing of the default value N times.
+func GetDefaultHashForHeight(height int) []byte {
+	return sha3TrieHasher.GetDefaultHashForHeight(height)
+}
+
+// HashLeaf generates hash value for leaf nodes (SHA3-256).
+// note that we don't include the keys here as they are already included in the path
+func HashLeaf(path []byte, value []byte) []byte {
+	return sha3TrieHasher.HashLeaf(path, value)
+}
+
+// HashInterNode generates hash value for intermediate nodes (SHA3-256).
+func HashInterNode(hash1 []byte, hash2 []byte) []byte {
+	return sha3TrieHasher.HashInterNode(hash1, hash2)
+}
+
+// ComputeCompactValue computes the value for the node considering the sub tree to only include this value and default values (SHA3-256).
+func ComputeCompactValue(path []byte, payload *ledger.Payload, nodeHeight int) []byte {
+	return sha3TrieHasher.ComputeCompactValue(path, payload, nodeHeight)
+}
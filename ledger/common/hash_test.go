@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
 
 	"github.com/onflow/flow-go/ledger"
 	"github.com/onflow/flow-go/ledger/common"
@@ -39,3 +41,48 @@ func Test_ComputeCompactValue(t *testing.T) {
 	l3 := common.HashInterNode(common.GetDefaultHashForHeight(2), l2)
 	assert.Equal(t, l3, common.ComputeCompactValue(path, p, nodeHeight))
 }
+
+// Test_Blake2bTrieHasher tests the default hashes and compact values of the BLAKE2b trie hasher
+func Test_Blake2bTrieHasher(t *testing.T) {
+	hasher, err := common.NewTrieHasher(ledger.TrieHasherBlake2b)
+	require.NoError(t, err)
+	require.Equal(t, ledger.TrieHasherBlake2b, hasher.Type())
+
+	h, err := blake2b.New256(nil)
+	require.NoError(t, err)
+	_, err = h.Write([]byte("default:"))
+	require.NoError(t, err)
+	defaultLeafHash := h.Sum(nil)
+	assert.Equal(t, defaultLeafHash, hasher.GetDefaultHashForHeight(0))
+	assert.Equal(t, defaultLeafHash, hasher.HashLeaf([]byte("default:"), []byte{}))
+	assert.NotEqual(t, common.GetDefaultHashForHeight(0), hasher.GetDefaultHashForHeight(0))
+
+	l1 := hasher.HashInterNode(hasher.GetDefaultHashForHeight(0), hasher.GetDefaultHashForHeight(0))
+	assert.Equal(t, l1, hasher.GetDefaultHashForHeight(1))
+
+	kp1 := ledger.NewKeyPart(uint16(1), []byte("key part 1"))
+	k := ledger.NewKey([]ledger.KeyPart{kp1})
+	v := ledger.Value([]byte{'A'})
+	p := ledger.NewPayload(k, v)
+
+	// 00000101
+	path := utils.OneBytePath(5)
+	l0 := hasher.HashLeaf(path, v)
+	l1 = hasher.HashInterNode(hasher.GetDefaultHashForHeight(0), l0)
+	l2 := hasher.HashInterNode(l1, hasher.GetDefaultHashForHeight(1))
+	assert.Equal(t, l2, hasher.ComputeCompactValue(path, p, 2))
+}
+
+// Test_NewTrieHasher tests looking up trie hashers by type
+func Test_NewTrieHasher(t *testing.T) {
+	for _, hasher := range common.SupportedTrieHashers() {
+		h, err := common.NewTrieHasher(hasher.Type())
+		require.NoError(t, err)
+		require.Equal(t, hasher, h)
+	}
+	require.Equal(t, ledger.TrieHasherSHA3, common.DefaultTrieHasher.Type())
+	require.Equal(t, common.GetDefaultHashes()[5], common.DefaultTrieHasher.GetDefaultHashForHeight(5))
+
+	_, err := common.NewTrieHasher(ledger.TrieHasherType(42))
+	require.Error(t, err)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/onflow/flow-go/crypto/hash"
 	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common"
 )
 
 // PathByteSize captures number of bytes each path takes
@@ -14,6 +15,7 @@ const PathByteSize = 32
 
 // KeyToPath converts key into a path
 // version zero applies sha2-256 on value of the key parts (in order ignoring types)
+// version one and two apply sha3-256 on the canonical form of the key
 func KeyToPath(key ledger.Key, version uint8) (ledger.Path, error) {
 	switch version {
 	case 0:
@@ -29,7 +31,7 @@ func KeyToPath(key ledger.Key, version uint8) (ledger.Path, error) {
 			}
 			return ledger.Path(h.Sum(nil)), nil
 		}
-	case 1:
+	case 1, 2:
 		{
 			hasher := hash.NewSHA3_256()
 			_, err := hasher.Write(key.CanonicalForm())
@@ -42,6 +44,18 @@ func KeyToPath(key ledger.Key, version uint8) (ledger.Path, error) {
 	return nil, fmt.Errorf("unsupported key to path version")
 }
 
+// TrieHasher returns the hasher of the tries used with the given path finder version.
+// versions zero and one hash the trie with sha3-256, version two with blake2b-256
+func TrieHasher(version uint8) (common.TrieHasher, error) {
+	switch version {
+	case 0, 1:
+		return common.NewTrieHasher(ledger.TrieHasherSHA3)
+	case 2:
+		return common.NewTrieHasher(ledger.TrieHasherBlake2b)
+	}
+	return nil, fmt.Errorf("unsupported key to path version")
+}
+
 // KeysToPaths converts an slice of keys into a paths
 func KeysToPaths(keys []ledger.Key, version uint8) ([]ledger.Path, error) {
 	paths := make([]ledger.Path, 0)
@@ -50,3 +50,15 @@ func Test_KeyToPathV1(t *testing.T) {
 	expected := ledger.Path(hasher.SumHash())
 	require.True(t, path.Equals(expected))
 }
+
+// Test_TrieHasher tests the trie hasher of every path finder version
+func Test_TrieHasher(t *testing.T) {
+	for version, expected := range []ledger.TrieHasherType{ledger.TrieHasherSHA3, ledger.TrieHasherSHA3, ledger.TrieHasherBlake2b} {
+		hasher, err := pathfinder.TrieHasher(uint8(version))
+		require.NoError(t, err)
+		require.Equal(t, expected, hasher.Type())
+	}
+
+	_, err := pathfinder.TrieHasher(3)
+	require.Error(t, err)
+}
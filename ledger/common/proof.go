@@ -2,6 +2,8 @@ package common
 
 import (
 	"bytes"
+	"fmt"
+	"sort"
 
 	"github.com/onflow/flow-go/ledger"
 	"github.com/onflow/flow-go/ledger/common/utils"
@@ -10,17 +12,21 @@ import (
 // TODO move this to proof itself
 
 // VerifyTrieProof verifies the proof, by constructing all the
-// hash from the leaf to the root and comparing the rootHash
+// hash from the leaf to the root (using the proof's hasher) and comparing the rootHash
 func VerifyTrieProof(p *ledger.TrieProof, expectedState ledger.State) bool {
+	hasher, err := NewTrieHasher(p.Hasher)
+	if err != nil {
+		return false
+	}
 	treeHeight := 8 * len(p.Path)
 	leafHeight := treeHeight - int(p.Steps)             // p.Steps is the number of edges we are traversing until we hit the compactified leaf.
 	if !(0 <= leafHeight && leafHeight <= treeHeight) { // sanity check
 		return false
 	}
 	// We start with the leaf and hash our way upwards towards the root
-	proofIndex := len(p.Interims) - 1                              // the index of the last non-default value furthest down the tree (-1 if there is none)
-	computed := ComputeCompactValue(p.Path, p.Payload, leafHeight) // we first compute the hash of the fully-expanded leaf (at height 0)
-	for h := leafHeight + 1; h <= treeHeight; h++ {                // then, we hash our way upwards until we hit the root (at height `treeHeight`)
+	proofIndex := len(p.Interims) - 1                                     // the index of the last non-default value furthest down the tree (-1 if there is none)
+	computed := hasher.ComputeCompactValue(p.Path, p.Payload, leafHeight) // we first compute the hash of the fully-expanded leaf (at height 0)
+	for h := leafHeight + 1; h <= treeHeight; h++ {                       // then, we hash our way upwards until we hit the root (at height `treeHeight`)
 		// we are currently at a node n (initially the leaf). In this iteration, we want to compute the
 		// parent's hash. Here, h is the height of the parent, whose hash want to compute.
 		// The parent has two children: child n, whose hash we have already computed (aka `computed`);
@@ -38,7 +44,7 @@ func VerifyTrieProof(p *ledger.TrieProof, expectedState ledger.State) bool {
 			siblingHash = p.Interims[proofIndex]
 			proofIndex--
 		} else { // otherwise, siblingHash is a default hash
-			siblingHash = GetDefaultHashForHeight(h - 1)
+			siblingHash = hasher.GetDefaultHashForHeight(h - 1)
 		}
 
 		bitIsSet, err := utils.IsBitSet(p.Path, treeHeight-h)
@@ -47,9 +53,9 @@ func VerifyTrieProof(p *ledger.TrieProof, expectedState ledger.State) bool {
 		}
 		// hashing is order dependant
 		if bitIsSet { // we hash our way up to the parent along the parent's right branch
-			computed = HashInterNode(siblingHash, computed)
+			computed = hasher.HashInterNode(siblingHash, computed)
 		} else { // we hash our way up to the parent along the parent's left branch
-			computed = HashInterNode(computed, siblingHash)
+			computed = hasher.HashInterNode(computed, siblingHash)
 		}
 	}
 	return bytes.Equal(computed, expectedState) == p.Inclusion
@@ -65,3 +71,346 @@ func VerifyTrieBatchProof(bp *ledger.TrieBatchProof, expectedState ledger.State)
 	}
 	return true
 }
+
+// VerifyTrieCompactBatchProof verifies all the proofs inside the compact batch proof,
+// by computing the root hash of the proof tree. Every interior hash is computed only once.
+func VerifyTrieCompactBatchProof(bp *ledger.TrieCompactBatchProof, expectedState ledger.State) bool {
+	if len(bp.Paths) == 0 {
+		return len(bp.ProofIndices) == 0
+	}
+	for _, index := range bp.ProofIndices {
+		if int(index) >= len(bp.Paths) {
+			return false
+		}
+	}
+	w, err := newProofTreeWalker(bp)
+	if err != nil {
+		return false
+	}
+	computed, err := w.rootHash(nil)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(computed, expectedState)
+}
+
+// CompactTrieBatchProof converts a batch proof into a compact batch proof. Only inclusion proofs
+// (as generated by the complete ledger, including proofs of empty payloads for unallocated registers)
+// of paths with the same size and hasher can be merged. Proofs of the same path must be identical.
+func CompactTrieBatchProof(bp *ledger.TrieBatchProof) (*ledger.TrieCompactBatchProof, error) {
+	cbp := ledger.NewTrieCompactBatchProof()
+	if len(bp.Proofs) == 0 {
+		return cbp, nil
+	}
+	pathByteSize := len(bp.Proofs[0].Path)
+	cbp.Hasher = bp.Proofs[0].Hasher
+
+	// sort and deduplicate proofs by path
+	unique := make(map[string]*ledger.TrieProof)
+	for i, p := range bp.Proofs {
+		if !p.Inclusion {
+			return nil, fmt.Errorf("proof %d is not an inclusion proof", i)
+		}
+		if len(p.Path) != pathByteSize || pathByteSize == 0 {
+			return nil, fmt.Errorf("proof %d has a path of size %d, expected %d", i, len(p.Path), pathByteSize)
+		}
+		if p.Hasher != cbp.Hasher {
+			return nil, fmt.Errorf("proof %d has trie hasher %s, expected %s", i, p.Hasher, cbp.Hasher)
+		}
+		if int(p.Steps) >= 8*pathByteSize+1 {
+			return nil, fmt.Errorf("proof %d has %d steps for a path of size %d", i, p.Steps, pathByteSize)
+		}
+		if other, ok := unique[string(p.Path)]; ok {
+			if !other.Equals(p) {
+				return nil, fmt.Errorf("proofs for path %s are not identical", p.Path)
+			}
+			continue
+		}
+		unique[string(p.Path)] = p
+	}
+	proofs := make([]*ledger.TrieProof, 0, len(unique))
+	for _, p := range unique {
+		proofs = append(proofs, p)
+	}
+	sort.Slice(proofs, func(i, j int) bool {
+		return bytes.Compare(proofs[i].Path, proofs[j].Path) < 0
+	})
+
+	pathIndex := make(map[string]uint32, len(proofs))
+	for i, p := range proofs {
+		pathIndex[string(p.Path)] = uint32(i)
+		cbp.Paths = append(cbp.Paths, p.Path)
+		cbp.Payloads = append(cbp.Payloads, p.Payload)
+		cbp.Steps = append(cbp.Steps, p.Steps)
+	}
+	for _, p := range bp.Proofs {
+		cbp.ProofIndices = append(cbp.ProofIndices, pathIndex[string(p.Path)])
+	}
+
+	// sibling hashes of each proof by depth, nil for default hashes
+	siblings := make([][][]byte, len(proofs))
+	for i, p := range proofs {
+		siblings[i] = make([][]byte, p.Steps)
+		interimIndex := 0
+		for depth := 0; depth < int(p.Steps); depth++ {
+			flagIsSet, err := utils.IsBitSet(p.Flags, depth)
+			if err != nil {
+				return nil, fmt.Errorf("invalid flags of proof for path %s: %w", p.Path, err)
+			}
+			if !flagIsSet {
+				continue
+			}
+			if interimIndex >= len(p.Interims) {
+				return nil, fmt.Errorf("proof for path %s has too few interims", p.Path)
+			}
+			siblings[i][depth] = p.Interims[interimIndex]
+			interimIndex++
+		}
+		if interimIndex != len(p.Interims) {
+			return nil, fmt.Errorf("proof for path %s has too many interims", p.Path)
+		}
+	}
+
+	// walk the proof tree and collect the siblings of nodes with a single child
+	flagCount := 0
+	err := walkProofTree(cbp, func(lo, hi, depth int, branch bool) error {
+		if branch {
+			return nil
+		}
+		if flagCount%8 == 0 {
+			cbp.Flags = append(cbp.Flags, 0)
+		}
+		if sibling := siblings[lo][depth]; sibling != nil {
+			_ = utils.SetBit(cbp.Flags, flagCount)
+			cbp.Interims = append(cbp.Interims, sibling)
+		}
+		flagCount++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cbp, nil
+}
+
+// ExpandTrieCompactBatchProof converts a compact batch proof into a batch proof, which holds
+// a separate proof for each path, by recomputing the hashes of the subtries shared by paths.
+func ExpandTrieCompactBatchProof(cbp *ledger.TrieCompactBatchProof) (*ledger.TrieBatchProof, error) {
+	bp := ledger.NewTrieBatchProof()
+	if len(cbp.Paths) == 0 {
+		if len(cbp.ProofIndices) != 0 {
+			return nil, fmt.Errorf("compact batch proof has %d proofs, but no paths", len(cbp.ProofIndices))
+		}
+		return bp, nil
+	}
+
+	w, err := newProofTreeWalker(cbp)
+	if err != nil {
+		return nil, err
+	}
+	// sibling hashes of each proven path by depth
+	siblings := make([][][]byte, len(cbp.Paths))
+	for i, steps := range cbp.Steps {
+		siblings[i] = make([][]byte, steps)
+	}
+	_, err = w.rootHash(siblings)
+	if err != nil {
+		return nil, err
+	}
+
+	treeHeight := 8 * len(cbp.Paths[0])
+	proofs := make([]*ledger.TrieProof, len(cbp.Paths))
+	for i, path := range cbp.Paths {
+		p := ledger.NewTrieProof()
+		p.Path = path
+		p.Payload = cbp.Payloads[i]
+		p.Inclusion = true
+		p.Steps = cbp.Steps[i]
+		p.Hasher = cbp.Hasher
+		p.Flags = make([]byte, len(path))
+		for depth, sibling := range siblings[i] {
+			// in proofs, we only provide non-default value hashes
+			if bytes.Equal(sibling, w.hasher.GetDefaultHashForHeight(treeHeight-depth-1)) {
+				continue
+			}
+			_ = utils.SetBit(p.Flags, depth)
+			p.Interims = append(p.Interims, sibling)
+		}
+		proofs[i] = p
+	}
+
+	for _, index := range cbp.ProofIndices {
+		if int(index) >= len(proofs) {
+			return nil, fmt.Errorf("compact batch proof references unknown path %d", index)
+		}
+		bp.AppendProof(proofs[index])
+	}
+	return bp, nil
+}
+
+// walkProofTree walks the interior nodes of the proof tree of a compact batch proof in DFS pre-order.
+// For each node, fn is called with the range [lo, hi) of the proven paths in the node's subtrie,
+// the depth of the node, and whether both children of the node contain proven paths.
+func walkProofTree(cbp *ledger.TrieCompactBatchProof, fn func(lo, hi, depth int, branch bool) error) error {
+	if len(cbp.Paths) != len(cbp.Payloads) || len(cbp.Paths) != len(cbp.Steps) {
+		return fmt.Errorf("compact batch proof has %d paths, %d payloads and %d steps", len(cbp.Paths), len(cbp.Payloads), len(cbp.Steps))
+	}
+	pathByteSize := len(cbp.Paths[0])
+	for i, path := range cbp.Paths {
+		if len(path) != pathByteSize {
+			return fmt.Errorf("path %d has size %d, expected %d", i, len(path), pathByteSize)
+		}
+		if i > 0 && bytes.Compare(cbp.Paths[i-1], path) >= 0 {
+			return fmt.Errorf("paths are not sorted and unique")
+		}
+		if int(cbp.Steps[i]) > 8*pathByteSize {
+			return fmt.Errorf("path %d has %d steps, but the trie height is %d", i, cbp.Steps[i], 8*pathByteSize)
+		}
+	}
+
+	var walk func(lo, hi, depth int) error
+	walk = func(lo, hi, depth int) error {
+		if hi-lo == 1 && depth == int(cbp.Steps[lo]) {
+			// leaf
+			return nil
+		}
+		for i := lo; i < hi; i++ {
+			if int(cbp.Steps[i]) <= depth {
+				return fmt.Errorf("leaf of path %d at depth %d is not a leaf of the proof tree", i, cbp.Steps[i])
+			}
+		}
+		// paths are sorted, hence the paths with the bit at depth set are at the end
+		split := lo + sort.Search(hi-lo, func(i int) bool {
+			bit, _ := utils.IsBitSet(cbp.Paths[lo+i], depth)
+			return bit
+		})
+		branch := split != lo && split != hi
+		err := fn(lo, hi, depth, branch)
+		if err != nil {
+			return err
+		}
+		if split > lo {
+			err = walk(lo, split, depth+1)
+			if err != nil {
+				return err
+			}
+		}
+		if split < hi {
+			return walk(split, hi, depth+1)
+		}
+		return nil
+	}
+	return walk(0, len(cbp.Paths), 0)
+}
+
+// proofTreeWalker computes the hashes of the nodes of the proof tree of a compact batch proof
+type proofTreeWalker struct {
+	cbp          *ledger.TrieCompactBatchProof
+	hasher       TrieHasher
+	treeHeight   int
+	flagIndex    int
+	interimIndex int
+}
+
+func newProofTreeWalker(cbp *ledger.TrieCompactBatchProof) (*proofTreeWalker, error) {
+	if len(cbp.Paths) == 0 {
+		return nil, fmt.Errorf("compact batch proof has no paths")
+	}
+	hasher, err := NewTrieHasher(cbp.Hasher)
+	if err != nil {
+		return nil, err
+	}
+	return &proofTreeWalker{cbp: cbp, hasher: hasher, treeHeight: 8 * len(cbp.Paths[0])}, nil
+}
+
+// rootHash computes the root hash of the proof tree. If siblings is not nil,
+// the sibling hashes of each proven path are recorded by depth.
+func (w *proofTreeWalker) rootHash(siblings [][][]byte) ([]byte, error) {
+	// validate the proof tree structure before computing any hashes
+	err := walkProofTree(w.cbp, func(int, int, int, bool) error { return nil })
+	if err != nil {
+		return nil, err
+	}
+	computed, err := w.hash(0, len(w.cbp.Paths), 0, siblings)
+	if err != nil {
+		return nil, err
+	}
+	if len(w.cbp.Flags) != (w.flagIndex+7)/8 {
+		return nil, fmt.Errorf("compact batch proof has %d flag bytes, expected %d", len(w.cbp.Flags), (w.flagIndex+7)/8)
+	}
+	for i := w.flagIndex; i < 8*len(w.cbp.Flags); i++ {
+		if flagIsSet, _ := utils.IsBitSet(w.cbp.Flags, i); flagIsSet {
+			return nil, fmt.Errorf("compact batch proof has unused flags set")
+		}
+	}
+	if w.interimIndex != len(w.cbp.Interims) {
+		return nil, fmt.Errorf("compact batch proof has %d interims, but only %d are used", len(w.cbp.Interims), w.interimIndex)
+	}
+	return computed, nil
+}
+
+// hash computes the hash of the node at the given depth, whose subtrie holds the proven paths [lo, hi)
+func (w *proofTreeWalker) hash(lo, hi, depth int, siblings [][][]byte) ([]byte, error) {
+	cbp := w.cbp
+	height := w.treeHeight - depth
+	if hi-lo == 1 && depth == int(cbp.Steps[lo]) {
+		return w.hasher.ComputeCompactValue(cbp.Paths[lo], cbp.Payloads[lo], height), nil
+	}
+
+	split := lo + sort.Search(hi-lo, func(i int) bool {
+		bit, _ := utils.IsBitSet(cbp.Paths[lo+i], depth)
+		return bit
+	})
+
+	var left, right []byte
+	var err error
+	if split != lo && split != hi {
+		left, err = w.hash(lo, split, depth+1, siblings)
+		if err != nil {
+			return nil, err
+		}
+		right, err = w.hash(split, hi, depth+1, siblings)
+		if err != nil {
+			return nil, err
+		}
+		if siblings != nil {
+			for i := lo; i < split; i++ {
+				siblings[i][depth] = right
+			}
+			for i := split; i < hi; i++ {
+				siblings[i][depth] = left
+			}
+		}
+		return w.hasher.HashInterNode(left, right), nil
+	}
+
+	// node with a single child, the sibling hash is either default or included in the proof
+	flagIsSet, err := utils.IsBitSet(cbp.Flags, w.flagIndex)
+	if err != nil {
+		return nil, fmt.Errorf("compact batch proof has too few flags: %w", err)
+	}
+	w.flagIndex++
+	sibling := w.hasher.GetDefaultHashForHeight(height - 1)
+	if flagIsSet {
+		if w.interimIndex >= len(cbp.Interims) {
+			return nil, fmt.Errorf("compact batch proof has too few interims")
+		}
+		sibling = cbp.Interims[w.interimIndex]
+		w.interimIndex++
+	}
+
+	child, err := w.hash(lo, hi, depth+1, siblings)
+	if err != nil {
+		return nil, err
+	}
+	if siblings != nil {
+		for i := lo; i < hi; i++ {
+			siblings[i][depth] = sibling
+		}
+	}
+	if split == lo {
+		// all paths are in the right subtrie
+		return w.hasher.HashInterNode(sibling, child), nil
+	}
+	return w.hasher.HashInterNode(child, sibling), nil
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/onflow/flow-go/ledger"
 	"github.com/onflow/flow-go/ledger/common"
 	"github.com/onflow/flow-go/ledger/common/utils"
 )
@@ -20,3 +21,58 @@ func Test_TrieBatchProofVerify(t *testing.T) {
 	bp, sc := utils.TrieBatchProofFixture()
 	require.True(t, common.VerifyTrieBatchProof(bp, sc))
 }
+
+// Test_TrieCompactBatchProofVerify tests compact batch proof verification
+func Test_TrieCompactBatchProofVerify(t *testing.T) {
+	bp, sc := utils.TrieBatchProofFixture()
+	cbp, err := common.CompactTrieBatchProof(bp)
+	require.NoError(t, err)
+	require.True(t, common.VerifyTrieCompactBatchProof(cbp, sc))
+
+	// proofs are restored in the original order
+	expanded, err := common.ExpandTrieCompactBatchProof(cbp)
+	require.NoError(t, err)
+	require.True(t, expanded.Equals(bp))
+
+	t.Run("duplicated paths", func(t *testing.T) {
+		bp, sc := utils.TrieBatchProofFixture()
+		bp.Proofs = append(bp.Proofs, bp.Proofs[0])
+		cbp, err := common.CompactTrieBatchProof(bp)
+		require.NoError(t, err)
+		require.Len(t, cbp.Paths, 2)
+		require.Len(t, cbp.ProofIndices, 3)
+		require.True(t, common.VerifyTrieCompactBatchProof(cbp, sc))
+
+		expanded, err := common.ExpandTrieCompactBatchProof(cbp)
+		require.NoError(t, err)
+		require.True(t, expanded.Equals(bp))
+	})
+
+	t.Run("wrong state", func(t *testing.T) {
+		wrongState := make([]byte, len(sc))
+		copy(wrongState, sc)
+		wrongState[0] ^= 0x01
+		require.False(t, common.VerifyTrieCompactBatchProof(cbp, ledger.State(wrongState)))
+	})
+
+	t.Run("tampered interim", func(t *testing.T) {
+		cbp, err := common.CompactTrieBatchProof(bp)
+		require.NoError(t, err)
+		cbp.Interims[0][0] ^= 0x01
+		require.False(t, common.VerifyTrieCompactBatchProof(cbp, sc))
+	})
+
+	t.Run("tampered payload", func(t *testing.T) {
+		cbp, err := common.CompactTrieBatchProof(bp)
+		require.NoError(t, err)
+		cbp.Payloads[0] = utils.LightPayload8('B', 'B')
+		require.False(t, common.VerifyTrieCompactBatchProof(cbp, sc))
+	})
+
+	t.Run("unused interim", func(t *testing.T) {
+		cbp, err := common.CompactTrieBatchProof(bp)
+		require.NoError(t, err)
+		cbp.Interims = append(cbp.Interims, cbp.Interims[0])
+		require.False(t, common.VerifyTrieCompactBatchProof(cbp, sc))
+	})
+}
@@ -141,8 +141,7 @@ func ReadShortData(input []byte) (data []byte, rest []byte, err error) {
 	if err != nil {
 		return nil, rest, err
 	}
-	data = rest[:size]
-	return
+	return ReadSlice(rest, int(size))
 }
 
 // ReadLongData read data shorter than 32MB and return the rest of bytes
@@ -152,8 +151,7 @@ func ReadLongData(input []byte) (data []byte, rest []byte, err error) {
 	if err != nil {
 		return nil, rest, err
 	}
-	data = rest[:size]
-	return
+	return ReadSlice(rest, int(size))
 }
 
 // ReadShortDataFromReader reads data shorter than 16kB from reader
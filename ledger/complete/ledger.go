@@ -16,7 +16,7 @@ import (
 	"github.com/onflow/flow-go/ledger/common/encoding"
 	"github.com/onflow/flow-go/ledger/common/pathfinder"
 	"github.com/onflow/flow-go/ledger/complete/mtrie"
-	"github.com/onflow/flow-go/ledger/complete/mtrie/flattener"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/store"
 	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
 	"github.com/onflow/flow-go/ledger/complete/wal"
 	"github.com/onflow/flow-go/module"
@@ -34,42 +34,103 @@ const DefaultPathFinderVersion = 1
 // Ledger is fork-aware which means any update can be applied at any previous state which forms a tree of tries (forest).
 // The forest is in memory but all changes (e.g. register updates) are captured inside write-ahead-logs for crash recovery reasons.
 // In order to limit the memory usage and maintain the performance storage only keeps a limited number of
-// tries and purge the old ones (LRU-based); in other words, Ledger is not designed to be used
+// tries (optionally, within a memory budget) and purge the old ones (LRU-based); in other words, Ledger is not designed to be used
 // for archival usage but make it possible for other software components to reconstruct very old tries using write-ahead logs.
+// For archival usage, NewArchivalLedger creates a Ledger which keeps the evicted tries in a disk-backed node store,
+// so Get and Prove can be answered for any state ever produced, while memory usage stays bounded.
 type Ledger struct {
 	forest  *mtrie.Forest
+	archive *store.NodeStore // nil unless the ledger is in archival mode
 	wal     *wal.LedgerWAL
 	metrics module.LedgerMetrics
 	logger  zerolog.Logger
 	// disk size reading can be time consuming, so limit how often its read
 	diskUpdateLimiter *time.Ticker
 	pathFinderVersion uint8
+	compactProofs     bool // encode the proofs of Prove compactly
 }
 
 // NewLedger creates a new in-memory trie-backed ledger storage with persistence.
+// The tries are hashed with the hasher of the path finder version (see pathfinder.TrieHasher).
+// The forest options configure the eviction of tries from memory, see mtrie.WithMemoryBudget.
 func NewLedger(dbDir string,
 	capacity int,
 	metrics module.LedgerMetrics,
 	log zerolog.Logger,
 	reg prometheus.Registerer,
-	pathFinderVer uint8) (*Ledger, error) {
+	pathFinderVer uint8,
+	opts ...mtrie.ForestOption) (*Ledger, error) {
+
+	return newLedger(dbDir, nil, capacity, metrics, log, reg, pathFinderVer, opts...)
+}
+
+// NewArchivalLedger creates a new trie-backed ledger storage with persistence, which keeps
+// at most capacity tries in memory and all evicted tries in a node store under archiveDir.
+// archiveDir must not be the same directory as dbDir.
+func NewArchivalLedger(dbDir string,
+	archiveDir string,
+	capacity int,
+	metrics module.LedgerMetrics,
+	log zerolog.Logger,
+	reg prometheus.Registerer,
+	pathFinderVer uint8,
+	opts ...mtrie.ForestOption) (*Ledger, error) {
+
+	if filepath.Clean(archiveDir) == filepath.Clean(dbDir) {
+		return nil, fmt.Errorf("archive directory must differ from the ledger directory %s", dbDir)
+	}
+
+	archive, err := store.NewNodeStore(archiveDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create node store: %w", err)
+	}
+
+	l, err := newLedger(dbDir, archive, capacity, metrics, log, reg, pathFinderVer, opts...)
+	if err != nil {
+		_ = archive.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func newLedger(dbDir string,
+	archive *store.NodeStore,
+	capacity int,
+	metrics module.LedgerMetrics,
+	log zerolog.Logger,
+	reg prometheus.Registerer,
+	pathFinderVer uint8,
+	opts ...mtrie.ForestOption) (*Ledger, error) {
+
+	hasher, err := pathfinder.TrieHasher(pathFinderVer)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get trie hasher: %w", err)
+	}
+	logger := log.With().Str("ledger", "complete").Logger()
+	opts = append([]mtrie.ForestOption{mtrie.WithTrieHasher(hasher), mtrie.WithLogger(logger)}, opts...)
 
 	w, err := wal.NewWAL(nil, reg, dbDir, capacity, pathfinder.PathByteSize, wal.SegmentSize)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create LedgerWAL: %w", err)
 	}
 
-	forest, err := mtrie.NewForest(pathfinder.PathByteSize, dbDir, capacity, metrics, func(evictedTrie *trie.MTrie) error {
+	onTreeEvicted := func(evictedTrie *trie.MTrie) error {
 		return w.RecordDelete(evictedTrie.RootHash())
-	})
+	}
+
+	var forest *mtrie.Forest
+	if archive != nil {
+		forest, err = mtrie.NewArchivalForest(pathfinder.PathByteSize, dbDir, capacity, metrics, archive, onTreeEvicted, opts...)
+	} else {
+		forest, err = mtrie.NewForest(pathfinder.PathByteSize, dbDir, capacity, metrics, onTreeEvicted, opts...)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("cannot create forest: %w", err)
 	}
 
-	logger := log.With().Str("ledger", "complete").Logger()
-
 	storage := &Ledger{
 		forest:            forest,
+		archive:           archive,
 		wal:               w,
 		metrics:           metrics,
 		logger:            logger,
@@ -83,13 +144,14 @@ func NewLedger(dbDir string,
 
 	err = w.ReplayOnForest(forest)
 	if err != nil {
+		forest.Close()
 		return nil, fmt.Errorf("cannot restore LedgerWAL: %w", err)
 	}
 
-	w.UnpauseRecord()
+	// tries evicted during the replay are recorded as removed once archived
+	forest.Flush()
 
-	// TODO update to proper value once https://github.com/onflow/flow-go/pull/3720 is merged
-	metrics.ForestApproxMemorySize(0)
+	w.UnpauseRecord()
 
 	return storage, nil
 }
@@ -103,9 +165,13 @@ func (l *Ledger) Ready() <-chan struct{} {
 }
 
 // Done implements interface module.ReadyDoneAware
-// it closes all the open write-ahead log files.
+// it closes all the open write-ahead log files and the archive (if any).
 func (l *Ledger) Done() <-chan struct{} {
+	l.forest.Close()
 	_ = l.wal.Close()
+	if l.archive != nil {
+		_ = l.archive.Close()
+	}
 	done := make(chan struct{})
 	close(done)
 	return done
@@ -146,6 +212,37 @@ func (l *Ledger) Get(query *ledger.Query) (values []ledger.Value, err error) {
 	return values, err
 }
 
+// Iterate returns the keys and values of the registers at the given state in path order,
+// see ledger.IterationQuery. Registers with empty values are not returned.
+func (l *Ledger) Iterate(query *ledger.IterationQuery) (*ledger.IterationResult, error) {
+	start := time.Now()
+	trieIteration := &ledger.TrieIteration{
+		RootHash: ledger.RootHash(query.State()),
+		Start:    query.Start(),
+		Limit:    query.Limit(),
+		KeyParts: query.KeyParts(),
+	}
+	iterationResult, err := l.forest.Iterate(trieIteration)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ledger.IterationResult{
+		Keys:   make([]ledger.Key, 0, len(iterationResult.Payloads)),
+		Values: make([]ledger.Value, 0, len(iterationResult.Payloads)),
+		Next:   iterationResult.Next,
+	}
+	for _, payload := range iterationResult.Payloads {
+		result.Keys = append(result.Keys, payload.Key)
+		result.Values = append(result.Values, payload.Value)
+	}
+
+	l.metrics.ReadValuesNumber(uint64(len(result.Keys)))
+	l.metrics.ReadDuration(time.Since(start))
+
+	return result, nil
+}
+
 // Set updates the ledger given an update
 // it returns the state after update and errors (if any)
 func (l *Ledger) Set(update *ledger.Update) (newState ledger.State, err error) {
@@ -175,9 +272,6 @@ func (l *Ledger) Set(update *ledger.Update) (newState ledger.State, err error) {
 		return nil, fmt.Errorf("cannot update state: %w", err)
 	}
 
-	// TODO update to proper value once https://github.com/onflow/flow-go/pull/3720 is merged
-	l.metrics.ForestApproxMemorySize(0)
-
 	elapsed := time.Since(start)
 	l.metrics.UpdateDuration(elapsed)
 
@@ -218,7 +312,12 @@ func (l *Ledger) Prove(query *ledger.Query) (proof ledger.Proof, err error) {
 		return nil, fmt.Errorf("could not get proofs: %w", err)
 	}
 
-	proofToGo := encoding.EncodeTrieBatchProof(batchProof)
+	var proofToGo []byte
+	if l.compactProofs {
+		proofToGo = encoding.EncodeCompactTrieBatchProof(batchProof)
+	} else {
+		proofToGo = encoding.EncodeTrieBatchProof(batchProof)
+	}
 
 	if len(paths) > 0 {
 		l.metrics.ProofSize(uint32(len(proofToGo) / len(paths)))
@@ -227,9 +326,20 @@ func (l *Ledger) Prove(query *ledger.Query) (proof ledger.Proof, err error) {
 	return ledger.Proof(proofToGo), err
 }
 
+// EnableCompactProofs makes Prove encode proofs compactly, with the interim hashes shared between the
+// proven paths only included once. Compact proofs can only be decoded by code which supports them, so
+// they must only be enabled once all consumers of the proofs do. It must be called before the ledger is used.
+func (l *Ledger) EnableCompactProofs() {
+	l.compactProofs = true
+}
+
 // CloseStorage closes the DB
 func (l *Ledger) CloseStorage() {
+	l.forest.Close()
 	_ = l.wal.Close()
+	if l.archive != nil {
+		_ = l.archive.Close()
+	}
 }
 
 // MemSize return the amount of memory used by ledger
@@ -254,6 +364,7 @@ func (l *Ledger) Checkpointer() (*wal.Checkpointer, error) {
 	if err != nil {
 		return nil, fmt.Errorf("cannot create checkpointer for compactor: %w", err)
 	}
+	checkpointer.SetTrieHasher(l.forest.Hasher())
 	return checkpointer, nil
 }
 
@@ -314,7 +425,12 @@ func (l *Ledger) ExportCheckpointAt(state ledger.State,
 		return nil, fmt.Errorf("cannot export checkpoint, can't construct paths: %w", err)
 	}
 
-	emptyTrie, err := trie.NewEmptyMTrie(pathfinder.PathByteSize)
+	hasher, err := pathfinder.TrieHasher(targetPathFinderVersion)
+	if err != nil {
+		return nil, fmt.Errorf("cannot export checkpoint, can't get trie hasher: %w", err)
+	}
+
+	emptyTrie, err := trie.NewEmptyMTrieWithHasher(pathfinder.PathByteSize, hasher)
 	if err != nil {
 		return nil, fmt.Errorf("constructing empty trie failed: %w", err)
 	}
@@ -331,14 +447,9 @@ func (l *Ledger) ExportCheckpointAt(state ledger.State,
 		return nil, fmt.Errorf("failed to create a checkpoint writer: %w", err)
 	}
 
-	flatTrie, err := flattener.FlattenTrie(newTrie)
-	if err != nil {
-		return nil, fmt.Errorf("failed to flatten the trie: %w", err)
-	}
-
 	l.logger.Info().Msg("storing the checkpoint to the file")
 
-	err = wal.StoreCheckpoint(flatTrie.ToFlattenedForestWithASingleTrie(), writer)
+	err = wal.StoreCheckpointTries([]*trie.MTrie{newTrie}, writer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to store the checkpoint: %w", err)
 	}
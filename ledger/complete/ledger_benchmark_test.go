@@ -1,9 +1,12 @@
 package complete_test
 
 import (
+	"fmt"
+	"io"
 	"io/ioutil"
 	"math/rand"
 	"os"
+	"runtime"
 	"testing"
 	"time"
 
@@ -13,6 +16,8 @@ import (
 	"github.com/onflow/flow-go/ledger/common/encoding"
 	"github.com/onflow/flow-go/ledger/common/utils"
 	"github.com/onflow/flow-go/ledger/complete"
+	"github.com/onflow/flow-go/ledger/complete/mtrie"
+	"github.com/onflow/flow-go/ledger/complete/wal"
 	"github.com/onflow/flow-go/ledger/partial/ptrie"
 	"github.com/onflow/flow-go/module/metrics"
 )
@@ -125,3 +130,96 @@ func benchmarkStorage(steps int, b *testing.B) {
 	b.ReportMetric(float64(totalPTrieConstTimeMS/steps), "ptrie_const_time_(ms)")
 
 }
+
+// BenchmarkReplay benchmarks restoring the forest from a checkpoint followed
+// by WAL segments, both sequentially and concurrently
+func BenchmarkReplay(b *testing.B) { benchmarkReplay(100, b) }
+
+func benchmarkReplay(steps int, b *testing.B) {
+	pathByteSize := 32
+	numInsPerStep := 1000
+	keyNumberOfParts := 10
+	keyPartMinByteSize := 1
+	keyPartMaxByteSize := 100
+	valueMaxByteSize := 32
+	rand.Seed(time.Now().UnixNano())
+
+	dir, err := ioutil.TempDir("", "test-mtrie-")
+	defer os.RemoveAll(dir)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	led, err := complete.NewLedger(dir, steps+1, &metrics.NoopCollector{}, zerolog.Logger{}, nil, complete.DefaultPathFinderVersion)
+	if err != nil {
+		b.Fatal("can't create a new complete ledger")
+	}
+
+	state := led.InitialState()
+	for i := 0; i < steps; i++ {
+		keys := utils.RandomUniqueKeys(numInsPerStep, keyNumberOfParts, keyPartMinByteSize, keyPartMaxByteSize)
+		values := utils.RandomValues(numInsPerStep, 1, valueMaxByteSize)
+		update, err := ledger.NewUpdate(state, keys, values)
+		if err != nil {
+			b.Fatal(err)
+		}
+		state, err = led.Set(update)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	// checkpoint all but the last segment, which is replayed from the WAL
+	checkpointer, err := led.Checkpointer()
+	if err != nil {
+		b.Fatal(err)
+	}
+	_, to, err := checkpointer.NotCheckpointedSegments()
+	if err != nil {
+		b.Fatal(err)
+	}
+	if to < 1 {
+		b.Fatal("not enough segments to checkpoint")
+	}
+	err = checkpointer.Checkpoint(to-1, func() (io.WriteCloser, error) {
+		return checkpointer.CheckpointWriter(to - 1)
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	<-led.Done()
+
+	concurrencies := []int{1}
+	if runtime.NumCPU() > 1 {
+		concurrencies = append(concurrencies, runtime.NumCPU())
+	}
+	for _, concurrency := range concurrencies {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				w, err := wal.NewWAL(nil, nil, dir, steps+1, pathByteSize, wal.SegmentSize)
+				if err != nil {
+					b.Fatal(err)
+				}
+				w.SetConcurrency(concurrency)
+
+				forest, err := mtrie.NewForest(pathByteSize, dir, steps+1, &metrics.NoopCollector{}, nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				err = w.ReplayOnForest(forest)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if forest.Size() != steps+1 {
+					b.Fatalf("expected %d tries, got %d", steps+1, forest.Size())
+				}
+
+				err = w.Close()
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
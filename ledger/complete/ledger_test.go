@@ -114,6 +114,78 @@ func TestLedger_Get(t *testing.T) {
 	})
 }
 
+func TestLedger_Iterate(t *testing.T) {
+	unittest.RunWithTempDir(t, func(dbDir string) {
+		led, err := complete.NewLedger(dbDir, 100, &metrics.NoopCollector{}, zerolog.Logger{}, nil, complete.DefaultPathFinderVersion)
+		require.NoError(t, err)
+
+		// registers of two owners, and one unallocated register of the first owner
+		keys := make([]ledger.Key, 0)
+		values := make([]ledger.Value, 0)
+		expected := make(map[string]ledger.Value)
+		for i := 0; i < 20; i++ {
+			owner := fmt.Sprintf("owner%d", i%2)
+			key := ledger.NewKey([]ledger.KeyPart{
+				utils.KeyPartFixture(0, owner),
+				utils.KeyPartFixture(2, fmt.Sprintf("key%d", i)),
+			})
+			value := ledger.Value(fmt.Sprintf("value%d", i))
+			if i == 4 {
+				value = ledger.Value{}
+			} else {
+				expected[key.String()] = value
+			}
+			keys = append(keys, key)
+			values = append(values, value)
+		}
+		update, err := ledger.NewUpdate(led.InitialState(), keys, values)
+		require.NoError(t, err)
+		state, err := led.Set(update)
+		require.NoError(t, err)
+
+		iterate := func(limit int, keyParts []ledger.KeyPart) map[string]ledger.Value {
+			found := make(map[string]ledger.Value)
+			var start ledger.Path
+			for {
+				query, err := ledger.NewIterationQuery(state, start, limit, keyParts)
+				require.NoError(t, err)
+				result, err := led.Iterate(query)
+				require.NoError(t, err)
+				require.Len(t, result.Values, len(result.Keys))
+				require.LessOrEqual(t, len(result.Keys), limit)
+				for i, key := range result.Keys {
+					found[key.String()] = result.Values[i]
+				}
+				if result.Next == nil {
+					return found
+				}
+				require.Len(t, result.Keys, limit)
+				start = result.Next
+			}
+		}
+
+		t.Run("all registers, page by page", func(t *testing.T) {
+			found := iterate(3, nil)
+			assert.Equal(t, expected, found)
+		})
+
+		t.Run("registers of one owner", func(t *testing.T) {
+			found := iterate(4, []ledger.KeyPart{utils.KeyPartFixture(0, "owner1")})
+			assert.Len(t, found, 10)
+			for k, v := range found {
+				assert.Equal(t, expected[k], v)
+			}
+		})
+
+		t.Run("unknown state", func(t *testing.T) {
+			query, err := ledger.NewIterationQuery(ledger.State(unittest.StateCommitmentFixture()), nil, 0, nil)
+			require.NoError(t, err)
+			_, err = led.Iterate(query)
+			require.Error(t, err)
+		})
+	})
+}
+
 func TestLedger_Proof(t *testing.T) {
 	t.Run("empty query", func(t *testing.T) {
 		unittest.RunWithTempDir(t, func(dbDir string) {
@@ -181,6 +253,75 @@ func TestLedger_Proof(t *testing.T) {
 	})
 }
 
+// TestArchivalLedger verifies that an archival ledger answers Get and Prove for
+// states which were evicted from memory, including after a restart.
+func TestArchivalLedger(t *testing.T) {
+	numInsPerStep := 2
+	keyNumberOfParts := 3
+	keyPartMinByteSize := 1
+	keyPartMaxByteSize := 20
+	capacity := 2
+	steps := 10
+	metricsCollector := &metrics.NoopCollector{}
+	logger := zerolog.Logger{}
+
+	unittest.RunWithTempDir(t, func(dir string) {
+		unittest.RunWithTempDir(t, func(archiveDir string) {
+
+			_, err := complete.NewArchivalLedger(dir, dir, capacity, metricsCollector, logger, nil, complete.DefaultPathFinderVersion)
+			require.Error(t, err)
+
+			led, err := complete.NewArchivalLedger(dir, archiveDir, capacity, metricsCollector, logger, nil, complete.DefaultPathFinderVersion)
+			require.NoError(t, err)
+
+			state := led.InitialState()
+			states := make([]ledger.State, 0, steps)
+			keysByState := make([][]ledger.Key, 0, steps)
+			valuesByState := make([][]ledger.Value, 0, steps)
+			for i := 0; i < steps; i++ {
+				keys := utils.RandomUniqueKeys(numInsPerStep, keyNumberOfParts, keyPartMinByteSize, keyPartMaxByteSize)
+				values := utils.RandomValues(numInsPerStep, 1, 32)
+				update, err := ledger.NewUpdate(state, keys, values)
+				require.NoError(t, err)
+				state, err = led.Set(update)
+				require.NoError(t, err)
+
+				states = append(states, state)
+				keysByState = append(keysByState, keys)
+				valuesByState = append(valuesByState, values)
+			}
+			require.Equal(t, capacity, led.ForestSize())
+
+			checkStates := func(led *complete.Ledger) {
+				for i, state := range states {
+					query, err := ledger.NewQuery(state, keysByState[i])
+					require.NoError(t, err)
+
+					values, err := led.Get(query)
+					require.NoError(t, err)
+					for j, value := range values {
+						assert.True(t, valuesByState[i][j].Equals(value))
+					}
+
+					retProof, err := led.Prove(query)
+					require.NoError(t, err)
+					proof, err := encoding.DecodeTrieBatchProof(retProof)
+					require.NoError(t, err)
+					assert.True(t, common.VerifyTrieBatchProof(proof, state))
+				}
+			}
+
+			checkStates(led)
+			<-led.Done()
+
+			led2, err := complete.NewArchivalLedger(dir, archiveDir, capacity, metricsCollector, logger, nil, complete.DefaultPathFinderVersion)
+			require.NoError(t, err)
+			checkStates(led2)
+			<-led2.Done()
+		})
+	})
+}
+
 func Test_WAL(t *testing.T) {
 	numInsPerStep := 2
 	keyNumberOfParts := 10
@@ -0,0 +1,121 @@
+package mtrie
+
+import (
+	"sync"
+
+	"github.com/rs/zerolog"
+
+	"github.com/onflow/flow-go/ledger/complete/mtrie/store"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
+)
+
+// archiveQueueSize is the number of evicted tries which can wait to be archived.
+// Once the queue is full, evicting further tries blocks until the archive caught up.
+const archiveQueueSize = 16
+
+// archiver persists the tries evicted from an archival forest in the node store,
+// in the background. Tries remain available from memory until they are archived.
+type archiver struct {
+	storeTrie  func(tree *trie.MTrie) error // persists a trie in the node store
+	onArchived func(tree *trie.MTrie) error // called after a trie was archived, may be nil
+	log        zerolog.Logger
+
+	queue    chan *trie.MTrie
+	inFlight sync.WaitGroup // tries which are queued or being archived
+	quit     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+
+	mu      sync.RWMutex
+	pending map[string]*trie.MTrie // tries which are not archived yet, by root hash
+}
+
+func newArchiver(nodes *store.NodeStore, onArchived func(tree *trie.MTrie) error, log zerolog.Logger) *archiver {
+	a := &archiver{
+		storeTrie:  nodes.StoreTrie,
+		onArchived: onArchived,
+		log:        log,
+		queue:      make(chan *trie.MTrie, archiveQueueSize),
+		quit:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+		pending:    make(map[string]*trie.MTrie),
+	}
+	go a.loop()
+	return a
+}
+
+// archive queues the given trie for archiving. It blocks while the queue is full.
+func (a *archiver) archive(t *trie.MTrie) {
+	rootHash := t.StringRootHash()
+
+	a.mu.Lock()
+	a.pending[rootHash] = t
+	a.mu.Unlock()
+
+	a.inFlight.Add(1)
+	select {
+	case a.queue <- t:
+	case <-a.quit:
+		a.inFlight.Done()
+		a.log.Error().Str("root_hash", rootHash).Msg("cannot archive evicted trie, archiver is stopped")
+	}
+}
+
+// pendingTrie returns the trie with the given root hash, if it was evicted but not archived yet
+func (a *archiver) pendingTrie(rootHash string) (*trie.MTrie, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	t, ok := a.pending[rootHash]
+	return t, ok
+}
+
+// flush blocks until all queued tries are archived
+func (a *archiver) flush() {
+	a.inFlight.Wait()
+}
+
+// stop archives all queued tries and stops the archiver
+func (a *archiver) stop() {
+	a.stopOnce.Do(func() {
+		a.flush()
+		close(a.quit)
+	})
+	<-a.stopped
+}
+
+func (a *archiver) loop() {
+	defer close(a.stopped)
+	for {
+		select {
+		case t := <-a.queue:
+			a.store(t)
+		case <-a.quit:
+			return
+		}
+	}
+}
+
+func (a *archiver) store(t *trie.MTrie) {
+	defer a.inFlight.Done()
+	rootHash := t.StringRootHash()
+
+	err := a.storeTrie(t)
+	if err != nil {
+		// keep the trie in memory, so it remains available
+		a.log.Error().Err(err).Str("root_hash", rootHash).Msg("could not archive evicted trie")
+		return
+	}
+
+	a.mu.Lock()
+	if a.pending[rootHash] == t {
+		delete(a.pending, rootHash)
+	}
+	a.mu.Unlock()
+
+	if a.onArchived != nil {
+		err = a.onArchived(t)
+		if err != nil {
+			a.log.Error().Err(err).Str("root_hash", rootHash).Msg("could not handle archived trie")
+		}
+	}
+}
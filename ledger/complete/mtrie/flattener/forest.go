@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/hex"
 	"fmt"
+	"runtime"
 
 	"github.com/onflow/flow-go/ledger"
 	"github.com/onflow/flow-go/ledger/common/encoding"
@@ -29,8 +30,24 @@ type FlattenedForest struct {
 	Tries []*StorableTrie
 }
 
-// node2indexMap maps a node pointer to the node index in the serialization
-type node2indexMap map[*node.Node]uint64
+// NodeIndex maps a node pointer to the node index in the serialization.
+// By convention, the nil node has index 0. Hence, the next free index is the size of the map.
+type NodeIndex map[*node.Node]uint64
+
+// NewNodeIndex returns a NodeIndex only containing the nil node
+func NewNodeIndex() NodeIndex {
+	return NodeIndex{nil: 0}
+}
+
+// NewNodeIndexFromNodes returns a NodeIndex for nodes rebuilt from a sequence of StorableNodes,
+// i.e. nodes[0] is nil and nodes[i] is the node with index i.
+func NewNodeIndexFromNodes(nodes []*node.Node) NodeIndex {
+	index := make(NodeIndex, len(nodes))
+	for i, n := range nodes {
+		index[n] = uint64(i)
+	}
+	return index
+}
 
 // FlattenForest returns forest FlattenedForest, which contains all nodes and tries of the Forest.
 func FlattenForest(f *mtrie.Forest) (*FlattenedForest, error) {
@@ -43,8 +60,7 @@ func FlattenForest(f *mtrie.Forest) (*FlattenedForest, error) {
 	storableNodes := []*StorableNode{nil} // 0th element is nil
 
 	// assign unique value to every node
-	allNodes := make(node2indexMap)
-	allNodes[nil] = 0 // 0th element is nil
+	allNodes := NewNodeIndex() // 0th element is nil
 
 	counter := uint64(1) // start from 1, as 0 marks nil
 	for _, t := range tries {
@@ -76,7 +92,7 @@ func FlattenForest(f *mtrie.Forest) (*FlattenedForest, error) {
 	}, nil
 }
 
-func toStorableNode(node *node.Node, indexForNode node2indexMap) (*StorableNode, error) {
+func toStorableNode(node *node.Node, indexForNode NodeIndex) (*StorableNode, error) {
 	leftIndex, found := indexForNode[node.LeftChild()]
 	if !found {
 		return nil, fmt.Errorf("internal error: missing node with hash %s", hex.EncodeToString(node.LeftChild().Hash()))
@@ -99,7 +115,7 @@ func toStorableNode(node *node.Node, indexForNode node2indexMap) (*StorableNode,
 	return storableNode, nil
 }
 
-func toStorableTrie(mtrie *trie.MTrie, indexForNode node2indexMap) (*StorableTrie, error) {
+func toStorableTrie(mtrie *trie.MTrie, indexForNode NodeIndex) (*StorableTrie, error) {
 	rootIndex, found := indexForNode[mtrie.RootNode()]
 	if !found {
 		return nil, fmt.Errorf("internal error: missing node with hash %s", hex.EncodeToString(mtrie.RootNode().Hash()))
@@ -112,16 +128,24 @@ func toStorableTrie(mtrie *trie.MTrie, indexForNode node2indexMap) (*StorableTri
 	return storableTrie, nil
 }
 
-// RebuildTries construct a forest from a storable FlattenedForest
+// RebuildTries construct a forest from a storable FlattenedForest.
+// Independent subtries are rebuilt concurrently, see RebuildNodesParallel.
 func RebuildTries(flatForest *FlattenedForest) ([]*trie.MTrie, error) {
 	tries := make([]*trie.MTrie, 0, len(flatForest.Tries))
-	nodes, err := RebuildNodes(flatForest.Nodes)
+	rootIndices := make([]uint64, 0, len(flatForest.Tries))
+	for _, storableTrie := range flatForest.Tries {
+		rootIndices = append(rootIndices, storableTrie.RootIndex)
+	}
+	nodes, err := RebuildNodesParallel(flatForest.Nodes, rootIndices, runtime.NumCPU())
 	if err != nil {
 		return nil, fmt.Errorf("reconstructing nodes from storables failed: %w", err)
 	}
 
 	//restore tries
 	for _, storableTrie := range flatForest.Tries {
+		if storableTrie.RootIndex >= uint64(len(nodes)) {
+			return nil, fmt.Errorf("restoring trie failed: unknown root node %d", storableTrie.RootIndex)
+		}
 		mtrie, err := trie.NewMTrie(nodes[storableTrie.RootIndex])
 		if err != nil {
 			return nil, fmt.Errorf("restoring trie failed: %w", err)
@@ -138,27 +162,53 @@ func RebuildTries(flatForest *FlattenedForest) ([]*trie.MTrie, error) {
 // The sequence must obey the DESCENDANTS-FIRST-RELATIONSHIP
 func RebuildNodes(storableNodes []*StorableNode) ([]*node.Node, error) {
 	nodes := make([]*node.Node, 0, len(storableNodes))
-	for i, snode := range storableNodes {
+	for _, snode := range storableNodes {
 		if snode == nil {
 			nodes = append(nodes, nil)
 			continue
 		}
-		if (snode.LIndex >= uint64(i)) || (snode.RIndex >= uint64(i)) {
-			return nil, fmt.Errorf("sequence of StorableNodes does not satisfy Descendents-First-Relationship")
-		}
-
-		if len(snode.Path) > 0 {
-			path := ledger.Path(snode.Path)
-			payload, err := encoding.DecodePayload(snode.EncPayload)
-			if err != nil {
-				return nil, fmt.Errorf("failed to decode a payload for an storableNode %w", err)
-			}
-			node := node.NewNode(int(snode.Height), nodes[snode.LIndex], nodes[snode.RIndex], path, payload, snode.HashValue, snode.MaxDepth, snode.RegCount)
-			nodes = append(nodes, node)
-			continue
+		node, err := RebuildNode(snode, nodes)
+		if err != nil {
+			return nil, err
 		}
-		node := node.NewNode(int(snode.Height), nodes[snode.LIndex], nodes[snode.RIndex], nil, nil, snode.HashValue, snode.MaxDepth, snode.RegCount)
 		nodes = append(nodes, node)
 	}
 	return nodes, nil
 }
+
+// RebuildNode generates a Node from a StorableNode, given the previously rebuilt nodes
+// of the sequence (i.e. the StorableNode will get index len(nodes)).
+// The sequence must obey the DESCENDANTS-FIRST-RELATIONSHIP
+func RebuildNode(snode *StorableNode, nodes []*node.Node) (*node.Node, error) {
+	payload, err := decodeStorablePayload(snode)
+	if err != nil {
+		return nil, err
+	}
+	return rebuildNode(snode, payload, nodes, uint64(len(nodes)))
+}
+
+// rebuildNode generates the Node with index i from a StorableNode and its decoded payload.
+// Children are looked up in nodes, which must contain all nodes with indices smaller than i.
+func rebuildNode(snode *StorableNode, payload *ledger.Payload, nodes []*node.Node, i uint64) (*node.Node, error) {
+	if (snode.LIndex >= i) || (snode.RIndex >= i) {
+		return nil, fmt.Errorf("sequence of StorableNodes does not satisfy Descendents-First-Relationship")
+	}
+
+	if len(snode.Path) > 0 {
+		path := ledger.Path(snode.Path)
+		return node.NewNode(int(snode.Height), nodes[snode.LIndex], nodes[snode.RIndex], path, payload, snode.HashValue, snode.MaxDepth, snode.RegCount), nil
+	}
+	return node.NewNode(int(snode.Height), nodes[snode.LIndex], nodes[snode.RIndex], nil, nil, snode.HashValue, snode.MaxDepth, snode.RegCount), nil
+}
+
+// decodeStorablePayload decodes the payload of a StorableNode, or returns nil if the node has no path
+func decodeStorablePayload(snode *StorableNode) (*ledger.Payload, error) {
+	if len(snode.Path) == 0 {
+		return nil, nil
+	}
+	payload, err := encoding.DecodePayload(snode.EncPayload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode a payload for an storableNode %w", err)
+	}
+	return payload, nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common/encoding"
 	"github.com/onflow/flow-go/ledger/common/utils"
 	"github.com/onflow/flow-go/ledger/complete/mtrie"
 	"github.com/onflow/flow-go/ledger/complete/mtrie/flattener"
@@ -76,3 +77,81 @@ func TestForestStoreAndLoad(t *testing.T) {
 		require.True(t, retPayloads[i].Equals(newRetPayloads[i]))
 	}
 }
+
+func TestRebuildNodesParallel(t *testing.T) {
+	pathByteSize := 32
+	dir, err := ioutil.TempDir("", "test-mtrie-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	metricsCollector := &metrics.NoopCollector{}
+	mForest, err := mtrie.NewForest(pathByteSize, dir, 5, metricsCollector, nil)
+	require.NoError(t, err)
+	rootHash := mForest.GetEmptyRootHash()
+
+	// large enough tries for subtries to be rebuilt in separate goroutines
+	for i := 0; i < 3; i++ {
+		paths := utils.RandomPaths(10000, pathByteSize)
+		payloads := utils.RandomPayloads(10000, 1, 10)
+		update := &ledger.TrieUpdate{RootHash: rootHash, Paths: paths, Payloads: payloads}
+		rootHash, err = mForest.Update(update)
+		require.NoError(t, err)
+	}
+
+	forestSequencing, err := flattener.FlattenForest(mForest)
+	require.NoError(t, err)
+
+	rootIndices := make([]uint64, 0, len(forestSequencing.Tries))
+	for _, storableTrie := range forestSequencing.Tries {
+		rootIndices = append(rootIndices, storableTrie.RootIndex)
+	}
+
+	expected, err := flattener.RebuildNodes(forestSequencing.Nodes)
+	require.NoError(t, err)
+
+	nodes, err := flattener.RebuildNodesParallel(forestSequencing.Nodes, rootIndices, 4)
+	require.NoError(t, err)
+	require.Equal(t, len(expected), len(nodes))
+	for i := 1; i < len(expected); i++ {
+		require.Equal(t, expected[i].Hash(), nodes[i].Hash())
+		require.Equal(t, expected[i].Height(), nodes[i].Height())
+		require.Equal(t, expected[i].RegCount(), nodes[i].RegCount())
+	}
+}
+
+func TestRebuildNodesParallel_Fallback(t *testing.T) {
+	leaf := func(path uint8) *flattener.StorableNode {
+		return &flattener.StorableNode{
+			Height:     0,
+			Path:       utils.OneBytePath(path),
+			EncPayload: encoding.EncodePayload(utils.LightPayload8(path, path)),
+			HashValue:  []byte{path},
+		}
+	}
+
+	// the first leaf is not part of any trie, hence the nodes
+	// of the trie do not form a contiguous range of indices
+	storableNodes := []*flattener.StorableNode{
+		nil,
+		leaf(1),
+		leaf(2),
+		leaf(3),
+		{LIndex: 2, RIndex: 3, Height: 1, HashValue: []byte{4}},
+	}
+
+	expected, err := flattener.RebuildNodes(storableNodes)
+	require.NoError(t, err)
+
+	nodes, err := flattener.RebuildNodesParallel(storableNodes, []uint64{4}, 4)
+	require.NoError(t, err)
+	require.Equal(t, len(expected), len(nodes))
+	for i := 1; i < len(expected); i++ {
+		require.Equal(t, expected[i].Hash(), nodes[i].Hash())
+	}
+
+	// sequences violating the Descendents-First-Relationship are still rejected
+	storableNodes[4].RIndex = 5
+	storableNodes = append(storableNodes, leaf(5))
+	_, err = flattener.RebuildNodesParallel(storableNodes, []uint64{4}, 4)
+	require.Error(t, err)
+}
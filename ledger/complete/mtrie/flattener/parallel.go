@@ -0,0 +1,220 @@
+package flattener
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/node"
+)
+
+// minParallelSubtrieSize is the minimum number of nodes in a subtrie
+// for it to be rebuilt in a separate goroutine
+const minParallelSubtrieSize = 1 << 12
+
+// RebuildNodesParallel generates a list of Nodes from a sequence of StorableNodes, like RebuildNodes,
+// but rebuilds independent subtries concurrently using up to the given number of workers.
+//
+// rootIndices are the indices of the root nodes of the stored tries. The nodes of every trie, which
+// are not shared with previously stored tries, are expected in DFS post-order, as generated by
+// FlattenForest and StreamTries. In this order, the new nodes of any subtrie form a contiguous range
+// of indices, hence subtries can be rebuilt independently of each other. If the sequence does not have
+// this structure (while still obeying the DESCENDANTS-FIRST-RELATIONSHIP), it is rebuilt sequentially.
+func RebuildNodesParallel(storableNodes []*StorableNode, rootIndices []uint64, workers int) ([]*node.Node, error) {
+	if workers <= 1 {
+		return RebuildNodes(storableNodes)
+	}
+
+	r := &subtrieRebuilder{
+		storableNodes: storableNodes,
+		nodes:         make([]*node.Node, len(storableNodes)),
+		workers:       make(chan struct{}, workers-1),
+	}
+	ok, err := r.rebuildTries(rootIndices)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return RebuildNodes(storableNodes)
+	}
+	return r.nodes, nil
+}
+
+// RebuildNodeBatch rebuilds a batch of StorableNodes which directly follow the given, previously
+// rebuilt, nodes in the sequence and returns nodes extended by the rebuilt batch.
+// Payloads, whose decoding dominates the cost of rebuilding nodes, are decoded concurrently
+// using up to the given number of workers.
+// The sequence must obey the DESCENDANTS-FIRST-RELATIONSHIP
+func RebuildNodeBatch(batch []*StorableNode, nodes []*node.Node, workers int) ([]*node.Node, error) {
+	payloads, err := decodeStorablePayloads(batch, workers)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, snode := range batch {
+		n, err := rebuildNode(snode, payloads[i], nodes, uint64(len(nodes)))
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
+
+// decodeStorablePayloads decodes the payloads of the given StorableNodes
+// by splitting them into equally sized chunks, one per worker.
+func decodeStorablePayloads(batch []*StorableNode, workers int) ([]*ledger.Payload, error) {
+	payloads := make([]*ledger.Payload, len(batch))
+	if workers < 1 {
+		workers = 1
+	}
+	chunkSize := (len(batch) + workers - 1) / workers
+	if chunkSize == 0 {
+		return payloads, nil
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, workers)
+	for w := 0; w*chunkSize < len(batch); w++ {
+		from := w * chunkSize
+		to := from + chunkSize
+		if to > len(batch) {
+			to = len(batch)
+		}
+
+		wg.Add(1)
+		go func(w, from, to int) {
+			defer wg.Done()
+			for i := from; i < to; i++ {
+				payload, err := decodeStorablePayload(batch[i])
+				if err != nil {
+					errs[w] = err
+					return
+				}
+				payloads[i] = payload
+			}
+		}(w, from, to)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return payloads, nil
+}
+
+// subtrieRebuilder rebuilds the nodes of tries in parallel, by rebuilding
+// disjoint subtries in separate goroutines
+type subtrieRebuilder struct {
+	storableNodes []*StorableNode
+	nodes         []*node.Node
+	workers       chan struct{} // limits the number of additional goroutines
+}
+
+// rebuildTries rebuilds all nodes of the tries with the given root indices. It returns false
+// if the nodes are not in the order required for rebuilding subtries independently.
+func (r *subtrieRebuilder) rebuildTries(rootIndices []uint64) (bool, error) {
+	roots := make([]uint64, len(rootIndices))
+	copy(roots, rootIndices)
+	sort.Slice(roots, func(i, j int) bool {
+		return roots[i] < roots[j]
+	})
+
+	// nodes with an index up to built are rebuilt already, 0 is the nil node
+	built := uint64(0)
+	for _, root := range roots {
+		if root <= built {
+			// empty trie, or root shared with another trie
+			continue
+		}
+		if root >= uint64(len(r.storableNodes)) {
+			return false, nil
+		}
+		ok, err := r.rebuildSubtrie(built, built, root)
+		if !ok || err != nil {
+			return ok, err
+		}
+		built = root
+	}
+
+	// all nodes must be part of a trie
+	return built+1 == uint64(len(r.storableNodes)), nil
+}
+
+// rebuildSubtrie rebuilds the subtrie with the root node at index end, whose new nodes
+// have indices in (start, end]. Nodes with an index up to base are rebuilt already.
+// It returns false if the subtrie's nodes are not in DFS post-order.
+func (r *subtrieRebuilder) rebuildSubtrie(base, start, end uint64) (bool, error) {
+	snode := r.storableNodes[end]
+	if snode == nil {
+		return false, nil
+	}
+
+	// each child is either rebuilt already, or is the root of a contiguous range of new nodes,
+	// the ranges of the left and the right child directly following each other
+	var ranges [2][2]uint64
+	count := 0
+	next := start
+	for _, child := range []uint64{snode.LIndex, snode.RIndex} {
+		if child <= base {
+			continue
+		}
+		if child <= next || child >= end {
+			return false, nil
+		}
+		ranges[count] = [2]uint64{next, child}
+		count++
+		next = child
+	}
+	if next+1 != end {
+		return false, nil
+	}
+
+	var oks [2]bool
+	var errs [2]error
+	rebuildRange := func(i int) {
+		oks[i], errs[i] = r.rebuildSubtrie(base, ranges[i][0], ranges[i][1])
+	}
+
+	if count == 2 && ranges[0][1]-ranges[0][0] >= minParallelSubtrieSize && ranges[1][1]-ranges[1][0] >= minParallelSubtrieSize {
+		select {
+		case r.workers <- struct{}{}:
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-r.workers }()
+				rebuildRange(0)
+			}()
+			rebuildRange(1)
+			wg.Wait()
+		default:
+			rebuildRange(0)
+			rebuildRange(1)
+		}
+	} else {
+		for i := 0; i < count; i++ {
+			rebuildRange(i)
+		}
+	}
+
+	for i := 0; i < count; i++ {
+		if !oks[i] || errs[i] != nil {
+			return oks[i], errs[i]
+		}
+	}
+
+	payload, err := decodeStorablePayload(snode)
+	if err != nil {
+		return false, err
+	}
+	n, err := rebuildNode(snode, payload, r.nodes, end)
+	if err != nil {
+		return false, fmt.Errorf("failed to rebuild node %d: %w", end, err)
+	}
+	r.nodes[end] = n
+	return true, nil
+}
@@ -0,0 +1,63 @@
+package flattener
+
+import (
+	"fmt"
+
+	"github.com/onflow/flow-go/ledger/complete/mtrie/node"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
+)
+
+// StreamTries converts the given tries into StorableNodes and StorableTries one at a time,
+// without materializing a FlattenedForest in memory.
+//
+// Nodes which already have an index in indexForNode are considered to be stored already
+// (e.g. in a previous checkpoint) and are neither passed to nodeFn nor traversed. All other
+// nodes are assigned the next free index and passed to nodeFn in an order satisfying the
+// Descendents-First-Relationship. Once all nodes are streamed, trieFn is called for each trie.
+// On return, indexForNode contains the indices of all nodes of the given tries.
+func StreamTries(tries []*trie.MTrie, indexForNode NodeIndex, nodeFn func(*StorableNode) error, trieFn func(*StorableTrie) error) error {
+	for _, t := range tries {
+		err := streamSubtrie(t.RootNode(), indexForNode, nodeFn)
+		if err != nil {
+			return fmt.Errorf("failed to stream nodes of trie %s: %w", t.StringRootHash(), err)
+		}
+	}
+
+	for _, t := range tries {
+		storableTrie, err := toStorableTrie(t, indexForNode)
+		if err != nil {
+			return fmt.Errorf("failed to construct storable trie: %w", err)
+		}
+		err = trieFn(storableTrie)
+		if err != nil {
+			return fmt.Errorf("failed to stream trie %s: %w", t.StringRootHash(), err)
+		}
+	}
+	return nil
+}
+
+// streamSubtrie streams the nodes of the sub-trie in DFS post-order (children first).
+// As nodes are indexed after their children, an indexed node implies an indexed sub-trie,
+// which therefore can be skipped entirely.
+func streamSubtrie(n *node.Node, indexForNode NodeIndex, nodeFn func(*StorableNode) error) error {
+	if _, has := indexForNode[n]; has {
+		return nil
+	}
+
+	err := streamSubtrie(n.LeftChild(), indexForNode, nodeFn)
+	if err != nil {
+		return err
+	}
+	err = streamSubtrie(n.RightChild(), indexForNode, nodeFn)
+	if err != nil {
+		return err
+	}
+
+	storableNode, err := toStorableNode(n, indexForNode)
+	if err != nil {
+		return fmt.Errorf("failed to construct storable node: %w", err)
+	}
+	indexForNode[n] = uint64(len(indexForNode))
+
+	return nodeFn(storableNode)
+}
@@ -6,10 +6,15 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"sync/atomic"
 
 	lru "github.com/hashicorp/golang-lru"
+	"github.com/rs/zerolog"
 
 	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/node"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/store"
 	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
 	"github.com/onflow/flow-go/module"
 	"github.com/onflow/flow-go/utils/io"
@@ -24,18 +29,64 @@ import (
 // tries that are still needed. In fully matured Flow, we will have an
 // explicit eviction policy.
 //
+// Forest keeps track of the approximate memory used by its tries, where nodes shared
+// between tries are accounted for only once. Optionally (see WithMemoryBudget), the
+// Least Recently Used tries are evicted as long as the memory used exceeds a budget.
+//
+// In archival mode, evicted tries are persisted to a disk-backed node store in the
+// background, and remain in memory until they are archived. Reads and proofs for tries
+// which are no longer in memory are then served from the store, paging in only the
+// nodes along the requested paths.
+//
+// All tries of a Forest are hashed with the same hasher (see WithTrieHasher),
+// by default SHA3-256.
+//
 // TODO: Storage Eviction Policy for Forest
 //       For the execution node: we only evict on sealing a result.
 type Forest struct {
 	// tries stores all MTries in the forest. It is NOT a CACHE in the conventional sense:
-	// there is no mechanism to load a trie from disk in case of a cache miss. Missing a
-	// needed trie in the forest might cause a fatal application logic error.
+	// unless the forest is in archival mode, there is no mechanism to load a trie from disk
+	// in case of a cache miss. Missing a needed trie in the forest might cause a fatal
+	// application logic error.
 	tries          *lru.Cache
+	archive        *store.NodeStore // disk-backed store of evicted tries, nil if not in archival mode
+	archiver       *archiver        // archives evicted tries in the background, nil if not in archival mode
 	dir            string
 	forestCapacity int
 	onTreeEvicted  func(tree *trie.MTrie) error
 	pathByteSize   int // length [bytes] of register path
+	hasher         common.TrieHasher
 	metrics        module.LedgerMetrics
+	refs           *node.References // references to the nodes of all tries, for memory accounting
+	memorySize     uint64           // approximate memory [bytes] used by all tries (atomic)
+	memoryBudget   uint64           // memory [bytes] above which tries are evicted, 0 if unlimited
+	log            zerolog.Logger
+}
+
+// ForestOption configures optional properties of a Forest
+type ForestOption func(*Forest)
+
+// WithMemoryBudget makes the forest evict the Least Recently Used tries, as long as the approximate
+// memory used by all tries exceeds the given number of bytes. The most recently added trie is never
+// evicted. The budget applies in addition to the forest capacity; 0 disables the budget.
+func WithMemoryBudget(bytes uint64) ForestOption {
+	return func(f *Forest) {
+		f.memoryBudget = bytes
+	}
+}
+
+// WithLogger sets the logger of the forest, which reports the errors of handling evicted tries
+func WithLogger(log zerolog.Logger) ForestOption {
+	return func(f *Forest) {
+		f.log = log
+	}
+}
+
+// WithTrieHasher sets the hasher of the forest's tries, instead of the default hasher
+func WithTrieHasher(hasher common.TrieHasher) ForestOption {
+	return func(f *Forest) {
+		f.hasher = hasher
+	}
 }
 
 // NewForest returns a new instance of memory forest.
@@ -45,18 +96,62 @@ type Forest struct {
 // THIS IS A ROUGH HEURISTIC as it might evict tries that are still needed.
 // Make sure you chose a sufficiently large forestCapacity, such that, when reaching the capacity, the
 // Least Recently Used trie will never be needed again.
-func NewForest(pathByteSize int, trieStorageDir string, forestCapacity int, metrics module.LedgerMetrics, onTreeEvicted func(tree *trie.MTrie) error) (*Forest, error) {
+func NewForest(pathByteSize int, trieStorageDir string, forestCapacity int, metrics module.LedgerMetrics, onTreeEvicted func(tree *trie.MTrie) error, opts ...ForestOption) (*Forest, error) {
+	return newForest(pathByteSize, trieStorageDir, forestCapacity, metrics, nil, onTreeEvicted, opts...)
+}
+
+// NewArchivalForest returns a new instance of memory forest in archival mode.
+// At most forestCapacity tries are kept in memory; evicted tries are persisted in
+// the given node store and remain available for reads and proofs. onTreeEvicted is
+// called once an evicted trie is archived. Close must be called before closing the store.
+func NewArchivalForest(pathByteSize int, trieStorageDir string, forestCapacity int, metrics module.LedgerMetrics, archive *store.NodeStore, onTreeEvicted func(tree *trie.MTrie) error, opts ...ForestOption) (*Forest, error) {
+	if archive == nil {
+		return nil, errors.New("archival forest requires a node store")
+	}
+	return newForest(pathByteSize, trieStorageDir, forestCapacity, metrics, archive, onTreeEvicted, opts...)
+}
+
+func newForest(pathByteSize int, trieStorageDir string, forestCapacity int, metrics module.LedgerMetrics, archive *store.NodeStore, onTreeEvicted func(tree *trie.MTrie) error, opts ...ForestOption) (*Forest, error) {
+	// init Forest and add an empty trie
+	if pathByteSize < 1 {
+		return nil, errors.New("trie's path size [in bytes] must be positive")
+	}
+	forest := &Forest{
+		archive:        archive,
+		dir:            trieStorageDir,
+		forestCapacity: forestCapacity,
+		onTreeEvicted:  onTreeEvicted,
+		pathByteSize:   pathByteSize,
+		hasher:         common.DefaultTrieHasher,
+		metrics:        metrics,
+		refs:           node.NewReferences(),
+		log:            zerolog.Nop(),
+	}
+	for _, opt := range opts {
+		opt(forest)
+	}
+	if archive != nil {
+		forest.archiver = newArchiver(archive, onTreeEvicted, forest.log)
+	}
+
 	// init LRU cache as a SHORTCUT for a usage-related storage eviction policy
 	var cache *lru.Cache
 	var err error
-	if onTreeEvicted != nil {
+	if onTreeEvicted != nil || archive != nil {
 		cache, err = lru.NewWithEvict(forestCapacity, func(key interface{}, value interface{}) {
 			trie, ok := value.(*trie.MTrie)
 			if !ok {
 				panic(fmt.Sprintf("cache contains item of type %T", value))
 			}
-			//TODO Log error
-			_ = onTreeEvicted(trie)
+			if forest.archiver != nil {
+				// blocks while the archive is behind, so evicted tries can't pile up in memory
+				forest.archiver.archive(trie)
+				return
+			}
+			err := onTreeEvicted(trie)
+			if err != nil {
+				forest.log.Error().Err(err).Str("root_hash", trie.StringRootHash()).Msg("could not handle evicted trie")
+			}
 		})
 	} else {
 		cache, err = lru.New(forestCapacity)
@@ -64,21 +159,10 @@ func NewForest(pathByteSize int, trieStorageDir string, forestCapacity int, metr
 	if err != nil {
 		return nil, fmt.Errorf("cannot create forest cache: %w", err)
 	}
-
-	// init Forest and add an empty trie
-	if pathByteSize < 1 {
-		return nil, errors.New("trie's path size [in bytes] must be positive")
-	}
-	forest := &Forest{tries: cache,
-		dir:            trieStorageDir,
-		forestCapacity: forestCapacity,
-		onTreeEvicted:  onTreeEvicted,
-		pathByteSize:   pathByteSize,
-		metrics:        metrics,
-	}
+	forest.tries = cache
 
 	// add empty roothash
-	emptyTrie, err := trie.NewEmptyMTrie(pathByteSize)
+	emptyTrie, err := trie.NewEmptyMTrieWithHasher(pathByteSize, forest.hasher)
 	if err != nil {
 		return nil, fmt.Errorf("constructing empty trie for forest failed: %w", err)
 	}
@@ -99,7 +183,7 @@ func (f *Forest) Read(r *ledger.TrieRead) ([]*ledger.Payload, error) {
 	}
 
 	// lookup the trie by rootHash
-	trie, err := f.GetTrie(r.RootHash)
+	trie, err := f.getTrieForPaths(r.RootHash, r.Paths)
 	if err != nil {
 		return nil, err
 	}
@@ -149,6 +233,80 @@ func (f *Forest) Read(r *ledger.TrieRead) ([]*ledger.Payload, error) {
 	return orderedPayloads, nil
 }
 
+// Iterate returns the payloads of the trie with the given root hash in path order, starting
+// at the iteration's start path, up to the iteration's limit. Payloads with empty values are
+// skipped, as they are indistinguishable from unallocated registers, as well as payloads whose
+// keys don't contain all of the iteration's key parts. If the limit is reached before all payloads
+// were returned, the result's Next holds the path of the next payload.
+func (f *Forest) Iterate(it *ledger.TrieIteration) (*ledger.TrieIterationResult, error) {
+	if it.Limit < 0 {
+		return nil, fmt.Errorf("limit must not be negative, got %d", it.Limit)
+	}
+
+	// lookup the trie by rootHash
+	trie, err := f.GetTrie(it.RootHash)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ledger.TrieIterationResult{
+		Paths:    make([]ledger.Path, 0),
+		Payloads: make([]*ledger.Payload, 0),
+	}
+	totalPayloadSize := 0
+	err = trie.Iterate(it.Start, func(path ledger.Path, payload *ledger.Payload) bool {
+		if len(payload.Value) == 0 || !payload.Key.HasKeyParts(it.KeyParts) {
+			return true
+		}
+		if it.Limit > 0 && len(result.Payloads) == it.Limit {
+			result.Next = path.DeepCopy()
+			return false
+		}
+		result.Paths = append(result.Paths, path.DeepCopy())
+		result.Payloads = append(result.Payloads, payload.DeepCopy())
+		totalPayloadSize += payload.Size()
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot iterate over trie: %w", err)
+	}
+
+	f.metrics.ReadValuesSize(uint64(totalPayloadSize))
+
+	return result, nil
+}
+
+// Diff returns the changes of payloads from the trie with root hash a to the trie with root hash b,
+// in path order. Payloads with empty values are treated as unallocated registers, i.e. setting a
+// register's value to empty is reported as removal. Subtries shared by both tries are skipped.
+func (f *Forest) Diff(a, b ledger.RootHash) ([]*ledger.PayloadChange, error) {
+	trieA, err := f.GetTrie(a)
+	if err != nil {
+		return nil, err
+	}
+	trieB, err := f.GetTrie(b)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]*ledger.PayloadChange, 0)
+	err = trieA.Diff(trieB, func(path ledger.Path, payloadA, payloadB *ledger.Payload) bool {
+		change := &ledger.PayloadChange{Path: path.DeepCopy()}
+		if payloadA != nil {
+			change.Old = payloadA.DeepCopy()
+		}
+		if payloadB != nil {
+			change.New = payloadB.DeepCopy()
+		}
+		changes = append(changes, change)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot compare tries: %w", err)
+	}
+	return changes, nil
+}
+
 // Update updates the Values for the registers and returns rootHash and error (if any).
 // In case there are multiple updates to the same register, Update will persist the latest
 // written value.
@@ -203,10 +361,11 @@ func (f *Forest) Update(u *ledger.TrieUpdate) (ledger.RootHash, error) {
 	f.metrics.LatestTrieMaxDepth(uint64(newTrie.MaxDepth()))
 	f.metrics.LatestTrieMaxDepthDiff(uint64(newTrie.MaxDepth() - parentTrie.MaxDepth()))
 
-	err = f.AddTrie(newTrie)
+	allocated, err := f.addTrie(newTrie)
 	if err != nil {
 		return nil, fmt.Errorf("adding updated trie to forest failed: %w", err)
 	}
+	f.metrics.LatestTrieMemorySizeDiff(allocated)
 
 	return ledger.RootHash(newTrie.RootHash()), nil
 }
@@ -251,7 +410,7 @@ func (f *Forest) Proofs(r *ledger.TrieRead) (*ledger.TrieBatchProof, error) {
 
 	}
 
-	stateTrie, err := f.GetTrie(r.RootHash)
+	stateTrie, err := f.getTrieForPaths(r.RootHash, r.Paths)
 	if err != nil {
 		return nil, err
 	}
@@ -316,9 +475,64 @@ func (f *Forest) GetTrie(rootHash ledger.RootHash) (*trie.MTrie, error) {
 		return ent.(*trie.MTrie), nil
 	}
 
+	// if evicted, but not archived yet
+	if t, ok := f.pendingTrie(encRootHash); ok {
+		return t, nil
+	}
+
+	// if archived, page in the complete trie
+	if f.archive != nil {
+		t, err := f.archive.LoadTrie(rootHash)
+		if err == nil {
+			return t, nil
+		}
+		if !errors.Is(err, store.ErrTrieNotFound) {
+			return nil, fmt.Errorf("cannot load archived trie with the given rootHash [%v]: %w", encRootHash, err)
+		}
+	}
+
+	return nil, fmt.Errorf("trie with the given rootHash [%v] not found", encRootHash)
+}
+
+// getTrieForPaths returns the trie at specific rootHash, which can at least be
+// used for reading and proving the given paths. For tries which are not in memory
+// anymore, only the nodes needed for the given paths are loaded from the archive.
+func (f *Forest) getTrieForPaths(rootHash ledger.RootHash, paths []ledger.Path) (*trie.MTrie, error) {
+	encRootHash := hex.EncodeToString(rootHash)
+
+	// if in memory
+	if ent, ok := f.tries.Get(encRootHash); ok {
+		return ent.(*trie.MTrie), nil
+	}
+
+	// if evicted, but not archived yet
+	if t, ok := f.pendingTrie(encRootHash); ok {
+		return t, nil
+	}
+
+	// if archived, page in the nodes on the given paths
+	if f.archive != nil {
+		t, err := f.archive.LoadTrieForPaths(rootHash, paths)
+		if err == nil {
+			return t, nil
+		}
+		if !errors.Is(err, store.ErrTrieNotFound) {
+			return nil, fmt.Errorf("cannot load archived trie with the given rootHash [%v]: %w", encRootHash, err)
+		}
+	}
+
 	return nil, fmt.Errorf("trie with the given rootHash [%v] not found", encRootHash)
 }
 
+// pendingTrie returns the trie with the given root hash, if it was evicted from an
+// archival forest but is not archived yet
+func (f *Forest) pendingTrie(encRootHash string) (*trie.MTrie, bool) {
+	if f.archiver == nil {
+		return nil, false
+	}
+	return f.archiver.pendingTrie(encRootHash)
+}
+
 // GetTries returns list of currently cached tree root hashes
 func (f *Forest) GetTries() ([]*trie.MTrie, error) {
 	// ToDo needs concurrency safety
@@ -347,11 +561,21 @@ func (f *Forest) AddTries(newTries []*trie.MTrie) error {
 
 // AddTrie adds a trie to the forest
 func (f *Forest) AddTrie(newTrie *trie.MTrie) error {
+	_, err := f.addTrie(newTrie)
+	return err
+}
+
+// addTrie adds a trie to the forest and returns the approximate memory [bytes]
+// allocated by the nodes of the trie which are not shared with other tries in the forest
+func (f *Forest) addTrie(newTrie *trie.MTrie) (uint64, error) {
 	if newTrie == nil {
-		return nil
+		return 0, nil
 	}
 	if newTrie.PathLength() != f.pathByteSize {
-		return fmt.Errorf("forest has path length %d, but new trie has path length %d", f.pathByteSize, newTrie.PathLength())
+		return 0, fmt.Errorf("forest has path length %d, but new trie has path length %d", f.pathByteSize, newTrie.PathLength())
+	}
+	if newTrie.Hasher().Type() != f.hasher.Type() {
+		return 0, fmt.Errorf("forest has trie hasher %s, but new trie has trie hasher %s", f.hasher.Type(), newTrie.Hasher().Type())
 	}
 
 	// TODO: check Thread safety
@@ -360,27 +584,73 @@ func (f *Forest) AddTrie(newTrie *trie.MTrie) error {
 	if storedTrie, found := f.tries.Get(hashString); found {
 		foo := storedTrie.(*trie.MTrie)
 		if foo.Equals(newTrie) {
-			return nil
+			return 0, nil
 		}
-		return fmt.Errorf("forest already contains a tree with same root hash but other properties")
+		return 0, fmt.Errorf("forest already contains a tree with same root hash but other properties")
+	}
+
+	// retain the nodes before evicting tries, so nodes shared with an evicted trie are not released
+	allocated := f.refs.Retain(newTrie.RootNode())
+	atomic.AddUint64(&f.memorySize, allocated)
+
+	// evict the Least Recently Used trie explicitly (instead of by the cache), to release its memory
+	if f.tries.Len() >= f.forestCapacity {
+		f.removeOldestTrie()
 	}
 	f.tries.Add(hashString, newTrie)
+
+	// evict Least Recently Used tries until the forest fits into the memory budget
+	if f.memoryBudget > 0 {
+		for f.MemorySize() > f.memoryBudget && f.tries.Len() > 1 {
+			f.removeOldestTrie()
+		}
+	}
+
 	f.metrics.ForestNumberOfTrees(uint64(f.tries.Len()))
+	f.metrics.ForestApproxMemorySize(f.MemorySize())
 
-	return nil
+	return allocated, nil
+}
+
+// removeOldestTrie evicts the Least Recently Used trie and releases its memory
+func (f *Forest) removeOldestTrie() {
+	_, value, ok := f.tries.RemoveOldest()
+	if ok {
+		f.releaseMemory(value.(*trie.MTrie))
+	}
+}
+
+// releaseMemory releases the nodes of a trie removed from the forest
+func (f *Forest) releaseMemory(t *trie.MTrie) {
+	released := f.refs.Release(t.RootNode())
+	// subtract released from memorySize
+	atomic.AddUint64(&f.memorySize, ^(released - 1))
 }
 
-// RemoveTrie removes a trie to the forest
+// RemoveTrie removes a trie to the forest.
+// In archival mode, the trie remains available from the archive.
 func (f *Forest) RemoveTrie(rootHash []byte) {
 	// TODO remove from the file as well
 	encRootHash := hex.EncodeToString(rootHash)
+	// removing an entry from the cache triggers the eviction callback, which archives the trie
+	value, ok := f.tries.Peek(encRootHash)
+	if !ok {
+		return
+	}
 	f.tries.Remove(encRootHash)
+	f.releaseMemory(value.(*trie.MTrie))
 	f.metrics.ForestNumberOfTrees(uint64(f.tries.Len()))
+	f.metrics.ForestApproxMemorySize(f.MemorySize())
 }
 
 // GetEmptyRootHash returns the rootHash of empty Trie
 func (f *Forest) GetEmptyRootHash() []byte {
-	return trie.EmptyTrieRootHash(f.pathByteSize)
+	return trie.EmptyTrieRootHashWithHasher(f.pathByteSize, f.hasher)
+}
+
+// Hasher returns the hasher of the forest's tries
+func (f *Forest) Hasher() common.TrieHasher {
+	return f.hasher
 }
 
 // Size returns the number of active tries in this store
@@ -388,7 +658,47 @@ func (f *Forest) Size() int {
 	return f.tries.Len()
 }
 
-// DiskSize returns the disk size of the directory used by the forest (in bytes)
+// MemorySize returns the approximate memory [bytes] used by all tries in the forest,
+// nodes shared between tries are only accounted for once
+func (f *Forest) MemorySize() uint64 {
+	return atomic.LoadUint64(&f.memorySize)
+}
+
+// IsArchival returns true if the forest keeps evicted tries in a disk-backed node store
+func (f *Forest) IsArchival() bool {
+	return f.archive != nil
+}
+
+// Flush blocks until all tries evicted from an archival forest are archived.
+// It is a no-op if the forest is not in archival mode.
+func (f *Forest) Flush() {
+	if f.archiver != nil {
+		f.archiver.flush()
+	}
+}
+
+// Close archives all tries evicted from an archival forest and stops archiving.
+// No tries must be evicted from the forest afterwards. It is a no-op if the forest
+// is not in archival mode.
+func (f *Forest) Close() {
+	if f.archiver != nil {
+		f.archiver.stop()
+	}
+}
+
+// DiskSize returns the disk size of the directory used by the forest (in bytes),
+// including the disk size of the archive (if any)
 func (f *Forest) DiskSize() (uint64, error) {
-	return io.DirSize(f.dir)
+	size, err := io.DirSize(f.dir)
+	if err != nil {
+		return 0, err
+	}
+	if f.archive != nil {
+		archiveSize, err := f.archive.DiskSize()
+		if err != nil {
+			return 0, err
+		}
+		size += archiveSize
+	}
+	return size, nil
 }
@@ -15,6 +15,8 @@ import (
 	"github.com/onflow/flow-go/ledger/common"
 	"github.com/onflow/flow-go/ledger/common/encoding"
 	"github.com/onflow/flow-go/ledger/common/utils"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/node"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/store"
 	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
 	"github.com/onflow/flow-go/ledger/partial/ptrie"
 	"github.com/onflow/flow-go/module/metrics"
@@ -645,6 +647,59 @@ func TestReadOrder(t *testing.T) {
 	require.True(t, bytes.Equal(encoding.EncodePayload(retPayloads[0]), encoding.EncodePayload(payloads[1])))
 }
 
+// TestDiff tests that the changes between two tries of the forest are returned in path order
+func TestDiff(t *testing.T) {
+	pathByteSize := 2 // path size of 16 bits
+	dir, err := ioutil.TempDir("", "test-mtrie-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	forest, err := NewForest(pathByteSize, dir, 5, &metrics.NoopCollector{}, nil)
+	require.NoError(t, err)
+
+	p1 := pathByUint8s([]uint8{uint8(53), uint8(74)}, pathByteSize)
+	v1 := payloadBySlices([]byte{'A'}, []byte{'A'})
+
+	p2 := pathByUint8s([]uint8{uint8(116), uint8(129)}, pathByteSize)
+	v2 := payloadBySlices([]byte{'B'}, []byte{'B'})
+	v2Modified := payloadBySlices([]byte{'B'}, []byte{'C'})
+
+	p3 := pathByUint8s([]uint8{uint8(116), uint8(130)}, pathByteSize)
+	v3 := payloadBySlices([]byte{'D'}, []byte{'D'})
+
+	update := &ledger.TrieUpdate{RootHash: forest.GetEmptyRootHash(), Paths: []ledger.Path{p1, p2}, Payloads: []*ledger.Payload{v1, v2}}
+	rootA, err := forest.Update(update)
+	require.NoError(t, err)
+
+	update = &ledger.TrieUpdate{RootHash: rootA, Paths: []ledger.Path{p1, p2, p3}, Payloads: []*ledger.Payload{payloadBySlices([]byte{'A'}, nil), v2Modified, v3}}
+	rootB, err := forest.Update(update)
+	require.NoError(t, err)
+
+	changes, err := forest.Diff(rootA, rootB)
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+
+	require.Equal(t, p1, changes[0].Path)
+	require.True(t, changes[0].IsRemoved())
+	require.True(t, v1.Equals(changes[0].Old))
+
+	require.Equal(t, p2, changes[1].Path)
+	require.True(t, changes[1].IsModified())
+	require.True(t, v2.Equals(changes[1].Old))
+	require.True(t, v2Modified.Equals(changes[1].New))
+
+	require.Equal(t, p3, changes[2].Path)
+	require.True(t, changes[2].IsAdded())
+	require.True(t, v3.Equals(changes[2].New))
+
+	changes, err = forest.Diff(rootB, rootB)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	_, err = forest.Diff(rootA, ledger.RootHash(utils.RootHashFixture()))
+	require.Error(t, err)
+}
+
 // TestMixRead tests reading a mixture of set and unset registers.
 // We expect the default payload (nil) to be returned for unset registers.
 func TestMixRead(t *testing.T) {
@@ -986,6 +1041,151 @@ func TestRandomUpdateReadProof(t *testing.T) {
 	}
 }
 
+// TestArchivalForest verifies that tries evicted from an archival forest can still
+// be read and proven, and that further updates can be applied on top of them.
+func TestArchivalForest(t *testing.T) {
+	pathByteSize := 2 // path size of 16 bits
+	capacity := 3
+	steps := 10
+	dir, err := ioutil.TempDir("", "test-mtrie-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	archiveDir, err := ioutil.TempDir("", "test-mtrie-archive-")
+	require.NoError(t, err)
+	defer os.RemoveAll(archiveDir)
+
+	archive, err := store.NewNodeStore(archiveDir)
+	require.NoError(t, err)
+	defer archive.Close()
+
+	evicted := 0
+	forest, err := NewArchivalForest(pathByteSize, dir, capacity, &metrics.NoopCollector{}, archive, func(tree *trie.MTrie) error {
+		evicted++
+		return nil
+	})
+	require.NoError(t, err)
+	defer forest.Close()
+	require.True(t, forest.IsArchival())
+
+	roots := make([]ledger.RootHash, 0, steps)
+	pathsByRoot := make([][]ledger.Path, 0, steps)
+	payloadsByRoot := make([][]*ledger.Payload, 0, steps)
+
+	activeRoot := forest.GetEmptyRootHash()
+	for i := 0; i < steps; i++ {
+		paths := utils.RandomPaths(10, pathByteSize)
+		payloads := utils.RandomPayloads(len(paths), 2, 10)
+
+		update := &ledger.TrieUpdate{RootHash: activeRoot, Paths: paths, Payloads: payloads}
+		activeRoot, err = forest.Update(update)
+		require.NoError(t, err)
+
+		roots = append(roots, activeRoot)
+		pathsByRoot = append(pathsByRoot, paths)
+		payloadsByRoot = append(payloadsByRoot, payloads)
+	}
+	require.Equal(t, capacity, forest.Size())
+
+	// evicted tries are archived in the background
+	forest.Flush()
+	require.Equal(t, steps+1-capacity, evicted)
+
+	for i, root := range roots {
+		read := &ledger.TrieRead{RootHash: root, Paths: pathsByRoot[i]}
+		retPayloads, err := forest.Read(read)
+		require.NoError(t, err)
+		for j, v := range payloadsByRoot[i] {
+			require.True(t, bytes.Equal(encoding.EncodePayload(v), encoding.EncodePayload(retPayloads[j])))
+		}
+
+		// proofs for a mix of existing and non existing paths
+		proofPaths := append(utils.RandomPaths(5, pathByteSize), pathsByRoot[i]...)
+		batchProof, err := forest.Proofs(&ledger.TrieRead{RootHash: root, Paths: proofPaths})
+		require.NoError(t, err)
+		require.True(t, common.VerifyTrieBatchProof(batchProof, ledger.State(root)))
+	}
+
+	// update on top of the oldest (archived) trie
+	paths := utils.RandomPaths(5, pathByteSize)
+	payloads := utils.RandomPayloads(len(paths), 2, 10)
+	forkedRoot, err := forest.Update(&ledger.TrieUpdate{RootHash: roots[0], Paths: paths, Payloads: payloads})
+	require.NoError(t, err)
+
+	forkedTrie, err := forest.GetTrie(forkedRoot)
+	require.NoError(t, err)
+	require.True(t, forkedTrie.IsAValidTrie())
+
+	retPayloads, err := forest.Read(&ledger.TrieRead{RootHash: forkedRoot, Paths: pathsByRoot[0]})
+	require.NoError(t, err)
+	for j, p := range pathsByRoot[0] {
+		expected := payloadsByRoot[0][j]
+		for k := range paths {
+			if bytes.Equal(paths[k], p) {
+				expected = payloads[k]
+			}
+		}
+		require.True(t, bytes.Equal(encoding.EncodePayload(expected), encoding.EncodePayload(retPayloads[j])))
+	}
+}
+
+// TestArchivalForestArchiveFailure verifies that tries which could not be archived
+// remain available from memory, and are not reported as evicted.
+func TestArchivalForestArchiveFailure(t *testing.T) {
+	pathByteSize := 2 // path size of 16 bits
+	capacity := 2
+	steps := 5
+	dir, err := ioutil.TempDir("", "test-mtrie-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	archiveDir, err := ioutil.TempDir("", "test-mtrie-archive-")
+	require.NoError(t, err)
+	defer os.RemoveAll(archiveDir)
+
+	archive, err := store.NewNodeStore(archiveDir)
+	require.NoError(t, err)
+	defer archive.Close()
+
+	evicted := 0
+	forest, err := NewArchivalForest(pathByteSize, dir, capacity, &metrics.NoopCollector{}, archive, func(tree *trie.MTrie) error {
+		evicted++
+		return nil
+	})
+	require.NoError(t, err)
+	defer forest.Close()
+
+	forest.archiver.storeTrie = func(tree *trie.MTrie) error {
+		return fmt.Errorf("disk full")
+	}
+
+	roots := make([]ledger.RootHash, 0, steps)
+	pathsByRoot := make([][]ledger.Path, 0, steps)
+	payloadsByRoot := make([][]*ledger.Payload, 0, steps)
+
+	activeRoot := forest.GetEmptyRootHash()
+	for i := 0; i < steps; i++ {
+		paths := utils.RandomPaths(10, pathByteSize)
+		payloads := utils.RandomPayloads(len(paths), 2, 10)
+
+		activeRoot, err = forest.Update(&ledger.TrieUpdate{RootHash: activeRoot, Paths: paths, Payloads: payloads})
+		require.NoError(t, err)
+
+		roots = append(roots, activeRoot)
+		pathsByRoot = append(pathsByRoot, paths)
+		payloadsByRoot = append(payloadsByRoot, payloads)
+	}
+	forest.Flush()
+	require.Equal(t, capacity, forest.Size())
+	require.Equal(t, 0, evicted)
+
+	for i, root := range roots {
+		retPayloads, err := forest.Read(&ledger.TrieRead{RootHash: root, Paths: pathsByRoot[i]})
+		require.NoError(t, err)
+		for j, v := range payloadsByRoot[i] {
+			require.True(t, bytes.Equal(encoding.EncodePayload(v), encoding.EncodePayload(retPayloads[j])))
+		}
+	}
+}
+
 // TestProofGenerationInclusion tests that inclusion proofs generated by a Trie pass verification
 func TestProofGenerationInclusion(t *testing.T) {
 	pathByteSize := 2 // path size of 16 bits
@@ -1030,3 +1230,191 @@ func pathByUint8s(inputs []uint8, pathByteSize int) ledger.Path {
 	copy(b, inputs)
 	return ledger.Path([]byte(b))
 }
+
+// TestMemoryAccounting tests that the memory used by the forest accounts for every node of
+// the tries in the forest exactly once, as tries sharing nodes are added and removed
+func TestMemoryAccounting(t *testing.T) {
+	pathByteSize := 2 // path size of 16 bits
+	dir, err := ioutil.TempDir("", "test-mtrie-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	forest, err := NewForest(pathByteSize, dir, 100, &metrics.NoopCollector{}, nil)
+	require.NoError(t, err)
+
+	requireMemorySize := func(t *testing.T) {
+		tries, err := forest.GetTries()
+		require.NoError(t, err)
+		require.Equal(t, uniqueNodesMemorySize(tries), forest.MemorySize())
+	}
+	requireMemorySize(t)
+
+	paths := utils.RandomPaths(100, pathByteSize)
+	payloads := utils.RandomPayloads(len(paths), 2, 10)
+	baseRoot, err := forest.Update(&ledger.TrieUpdate{RootHash: forest.GetEmptyRootHash(), Paths: paths, Payloads: payloads})
+	require.NoError(t, err)
+	requireMemorySize(t)
+	baseSize := forest.MemorySize()
+
+	// fork the base trie, the forks share most nodes with the base trie
+	roots := make([]ledger.RootHash, 0)
+	for i := 0; i < 5; i++ {
+		paths := utils.RandomPaths(5, pathByteSize)
+		payloads := utils.RandomPayloads(len(paths), 2, 10)
+		root, err := forest.Update(&ledger.TrieUpdate{RootHash: baseRoot, Paths: paths, Payloads: payloads})
+		require.NoError(t, err)
+		requireMemorySize(t)
+		roots = append(roots, root)
+	}
+	require.Less(t, forest.MemorySize(), 2*baseSize)
+
+	// removing the base trie keeps nodes shared with the forks
+	forest.RemoveTrie(baseRoot)
+	requireMemorySize(t)
+	require.Greater(t, forest.MemorySize(), baseSize/2)
+
+	// adding a trie with the same root hash does not change the memory used
+	memorySize := forest.MemorySize()
+	forkedTrie, err := forest.GetTrie(roots[0])
+	require.NoError(t, err)
+	err = forest.AddTrie(forkedTrie)
+	require.NoError(t, err)
+	require.Equal(t, memorySize, forest.MemorySize())
+
+	for _, root := range roots {
+		forest.RemoveTrie(root)
+		requireMemorySize(t)
+	}
+
+	emptyTrie, err := trie.NewEmptyMTrie(pathByteSize)
+	require.NoError(t, err)
+	require.Equal(t, emptyTrie.RootNode().MemorySize(), forest.MemorySize())
+}
+
+// TestMemoryBudgetEviction tests that the Least Recently Used tries are evicted
+// to keep the memory used by the forest within the memory budget
+func TestMemoryBudgetEviction(t *testing.T) {
+	pathByteSize := 2 // path size of 16 bits
+	steps := 20
+	dir, err := ioutil.TempDir("", "test-mtrie-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// measure the memory used by a couple of tries, to set the budget
+	forest, err := NewForest(pathByteSize, dir, 100, &metrics.NoopCollector{}, nil)
+	require.NoError(t, err)
+	paths := utils.RandomPaths(50, pathByteSize)
+	payloads := utils.RandomPayloads(len(paths), 100, 101)
+	_, err = forest.Update(&ledger.TrieUpdate{RootHash: forest.GetEmptyRootHash(), Paths: paths, Payloads: payloads})
+	require.NoError(t, err)
+	budget := 3 * forest.MemorySize()
+
+	evicted := make([]ledger.RootHash, 0)
+	forest, err = NewForest(pathByteSize, dir, 100, &metrics.NoopCollector{}, func(tree *trie.MTrie) error {
+		evicted = append(evicted, tree.RootHash())
+		return nil
+	}, WithMemoryBudget(budget))
+	require.NoError(t, err)
+
+	// overwrite the same registers, so all tries have the same size
+	activeRoot := forest.GetEmptyRootHash()
+	roots := []ledger.RootHash{activeRoot}
+	for i := 0; i < steps; i++ {
+		payloads := utils.RandomPayloads(len(paths), 100, 101)
+		activeRoot, err = forest.Update(&ledger.TrieUpdate{RootHash: activeRoot, Paths: paths, Payloads: payloads})
+		require.NoError(t, err)
+		roots = append(roots, activeRoot)
+
+		require.LessOrEqual(t, forest.MemorySize(), budget)
+
+		// the latest trie is never evicted
+		_, err = forest.GetTrie(activeRoot)
+		require.NoError(t, err)
+	}
+
+	// the oldest tries are evicted first
+	require.Greater(t, len(evicted), 0)
+	require.Equal(t, roots[:len(evicted)], evicted)
+	require.Equal(t, len(roots)-len(evicted), forest.Size())
+
+	tries, err := forest.GetTries()
+	require.NoError(t, err)
+	require.Equal(t, uniqueNodesMemorySize(tries), forest.MemorySize())
+}
+
+// uniqueNodesMemorySize returns the memory size of all nodes of the given tries,
+// counting nodes with the same hash once
+func uniqueNodesMemorySize(tries []*trie.MTrie) uint64 {
+	seen := make(map[string]struct{})
+	size := uint64(0)
+	var walk func(n *node.Node)
+	walk = func(n *node.Node) {
+		if n == nil {
+			return
+		}
+		key := string(n.Hash())
+		if _, ok := seen[key]; ok {
+			return
+		}
+		seen[key] = struct{}{}
+		size += n.MemorySize()
+		walk(n.LeftChild())
+		walk(n.RightChild())
+	}
+	for _, t := range tries {
+		walk(t.RootNode())
+	}
+	return size
+}
+
+// TestTrieHasher tests that the tries of a forest and their proofs use the forest's trie hasher
+func TestTrieHasher(t *testing.T) {
+	pathByteSize := 2 // path size of 16 bits
+
+	dir, err := ioutil.TempDir("", "test-mtrie-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	hasher, err := common.NewTrieHasher(ledger.TrieHasherBlake2b)
+	require.NoError(t, err)
+	forest, err := NewForest(pathByteSize, dir, 5, &metrics.NoopCollector{}, nil, WithTrieHasher(hasher))
+	require.NoError(t, err)
+	defaultForest, err := NewForest(pathByteSize, dir, 5, &metrics.NoopCollector{}, nil)
+	require.NoError(t, err)
+	require.NotEqual(t, defaultForest.GetEmptyRootHash(), forest.GetEmptyRootHash())
+
+	paths := utils.RandomPaths(20, pathByteSize)
+	payloads := utils.RandomPayloads(20, 10, 20)
+	rootHash, err := forest.Update(&ledger.TrieUpdate{RootHash: forest.GetEmptyRootHash(), Paths: paths, Payloads: payloads})
+	require.NoError(t, err)
+	defaultRootHash, err := defaultForest.Update(&ledger.TrieUpdate{RootHash: defaultForest.GetEmptyRootHash(), Paths: paths, Payloads: payloads})
+	require.NoError(t, err)
+	require.NotEqual(t, defaultRootHash, rootHash)
+
+	updatedTrie, err := forest.GetTrie(rootHash)
+	require.NoError(t, err)
+	require.Equal(t, ledger.TrieHasherBlake2b, updatedTrie.Hasher().Type())
+	require.True(t, updatedTrie.IsAValidTrie())
+
+	// the hasher is detected when restoring a trie from its root node
+	restoredTrie, err := trie.NewMTrie(updatedTrie.RootNode())
+	require.NoError(t, err)
+	require.Equal(t, ledger.TrieHasherBlake2b, restoredTrie.Hasher().Type())
+
+	// proofs record and are verified with the hasher
+	batchProof, err := forest.Proofs(&ledger.TrieRead{RootHash: rootHash, Paths: paths})
+	require.NoError(t, err)
+	for _, p := range batchProof.Proofs {
+		require.Equal(t, ledger.TrieHasherBlake2b, p.Hasher)
+	}
+	require.True(t, common.VerifyTrieBatchProof(batchProof, ledger.State(rootHash)))
+	psmt, err := ptrie.NewPSMT(rootHash, pathByteSize, batchProof)
+	require.NoError(t, err)
+	require.Equal(t, []byte(rootHash), psmt.RootHash())
+
+	// tries hashed with another hasher are rejected
+	defaultTrie, err := defaultForest.GetTrie(defaultRootHash)
+	require.NoError(t, err)
+	err = forest.AddTrie(defaultTrie)
+	require.Error(t, err)
+}
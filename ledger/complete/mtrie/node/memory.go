@@ -0,0 +1,102 @@
+package node
+
+import (
+	"sync"
+	"unsafe"
+
+	"github.com/onflow/flow-go/ledger"
+)
+
+var (
+	nodeMemorySize    = uint64(unsafe.Sizeof(Node{}))
+	payloadMemorySize = uint64(unsafe.Sizeof(ledger.Payload{}))
+	keyPartMemorySize = uint64(unsafe.Sizeof(ledger.KeyPart{}))
+)
+
+// MemorySize returns the approximate number of bytes allocated by this node,
+// excluding its children.
+func (n *Node) MemorySize() uint64 {
+	size := nodeMemorySize + uint64(cap(n.hashValue)) + uint64(cap(n.path))
+	if n.payload != nil {
+		size += payloadMemorySize + uint64(cap(n.payload.Value))
+		for _, kp := range n.payload.Key.KeyParts {
+			size += keyPartMemorySize + uint64(cap(kp.Value))
+		}
+	}
+	return size
+}
+
+// References counts the references to nodes held by the tries of a forest, or by
+// parent nodes retained in the same forest. Nodes are immutable and may be shared
+// between tries and forests, hence the counts are kept in a table owned by the forest
+// rather than in the nodes themselves. References is safe for concurrent use.
+//
+// Nodes are identified by their hash, i.e. subtries with the same content are counted
+// as one, even if they are distinct objects. Hence all referenced nodes must be hashed
+// with the same hasher, and the table doesn't keep released nodes reachable.
+type References struct {
+	mu     sync.Mutex
+	counts map[string]uint32 // reference count by node hash
+}
+
+// NewReferences returns an empty reference table.
+func NewReferences() *References {
+	return &References{
+		counts: make(map[string]uint32),
+	}
+}
+
+// Retain records a reference to the given node. The first reference to a node retains
+// its children as well. It returns the approximate memory size of all nodes of the subtrie
+// which were not referenced before, i.e. nodes shared between tries are only accounted for once.
+func (r *References) Retain(n *Node) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.retain(n)
+}
+
+// Release removes a reference to the given node, see Retain. Releasing the last reference
+// to a node releases its children as well. It returns the approximate memory size of all
+// nodes of the subtrie which are no longer referenced. Releasing a node which is not
+// referenced is a no-op.
+func (r *References) Release(n *Node) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.release(n)
+}
+
+// Len returns the number of referenced nodes.
+func (r *References) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.counts)
+}
+
+func (r *References) retain(n *Node) uint64 {
+	if n == nil {
+		return 0
+	}
+	key := string(n.hashValue)
+	r.counts[key]++
+	if r.counts[key] != 1 {
+		return 0
+	}
+	return n.MemorySize() + r.retain(n.lChild) + r.retain(n.rChild)
+}
+
+func (r *References) release(n *Node) uint64 {
+	if n == nil {
+		return 0
+	}
+	key := string(n.hashValue)
+	count, ok := r.counts[key]
+	if !ok {
+		return 0
+	}
+	if count > 1 {
+		r.counts[key] = count - 1
+		return 0
+	}
+	delete(r.counts, key)
+	return n.MemorySize() + r.release(n.lChild) + r.release(n.rChild)
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common"
 	"github.com/onflow/flow-go/ledger/common/utils"
 	"github.com/onflow/flow-go/ledger/complete/mtrie/node"
 )
@@ -14,10 +16,10 @@ import (
 func Test_ProperLeaf(t *testing.T) {
 	path := utils.TwoBytesPath(56809)
 	payload := utils.LightPayload(56810, 59656)
-	n := node.NewLeaf(path, payload, 0)
+	n := node.NewLeaf(path, payload, 0, common.DefaultTrieHasher)
 	expectedRootHashHex := "aa7693d498e9a087b1cadf5bfe9a1ff07829badc1915c210e482f369f9a00a70"
 	require.Equal(t, expectedRootHashHex, hex.EncodeToString(n.Hash()))
-	require.True(t, n.VerifyCachedHash())
+	require.True(t, n.VerifyCachedHash(common.DefaultTrieHasher))
 }
 
 // Test_ProperLeaf verifies that the hash value of a compactified leaf (at height > 0) is computed correctly.
@@ -27,15 +29,15 @@ func Test_ProperLeaf(t *testing.T) {
 func Test_CompactifiedLeaf(t *testing.T) {
 	path := utils.TwoBytesPath(56809)
 	payload := utils.LightPayload(56810, 59656)
-	n := node.NewLeaf(path, payload, 1)
+	n := node.NewLeaf(path, payload, 1, common.DefaultTrieHasher)
 	expectedRootHashHex := "34ee03b8ca7d5cc8638d28b7cf2d70641efd5dfa428333863904a0fd19930700"
 	require.Equal(t, expectedRootHashHex, hex.EncodeToString(n.Hash()))
 
-	n = node.NewLeaf(path, payload, 9)
+	n = node.NewLeaf(path, payload, 9, common.DefaultTrieHasher)
 	expectedRootHashHex = "1e726af2a11191dfaf03de45408955a114817872dbf063d161c3669c530f26f5"
 	require.Equal(t, expectedRootHashHex, hex.EncodeToString(n.Hash()))
 
-	n = node.NewLeaf(path, payload, 16)
+	n = node.NewLeaf(path, payload, 16, common.DefaultTrieHasher)
 	expectedRootHashHex = "b44a9a00c182ba2203fca6886c4c99b854f9f8279a9978b180ad10e82362e412"
 	require.Equal(t, expectedRootHashHex, hex.EncodeToString(n.Hash()))
 }
@@ -43,15 +45,15 @@ func Test_CompactifiedLeaf(t *testing.T) {
 // Test_InterimNode verifies that the hash value of an interim node without children is computed correctly.
 // We test the hash at the lowest-possible height (0), at an interim height (9) and the max possible height (16)
 func Test_InterimNodeWithoutChildren(t *testing.T) {
-	n := node.NewInterimNode(0, nil, nil)
+	n := node.NewInterimNode(0, nil, nil, common.DefaultTrieHasher)
 	expectedRootHashHex := "18373b4b038cbbf37456c33941a7e346e752acd8fafa896933d4859002b62619"
 	require.Equal(t, expectedRootHashHex, hex.EncodeToString(n.Hash()))
 
-	n = node.NewInterimNode(9, nil, nil)
+	n = node.NewInterimNode(9, nil, nil, common.DefaultTrieHasher)
 	expectedRootHashHex = "a37f98dbac56e315fbd4b9f9bc85fbd1b138ed4ae453b128c22c99401495af6d"
 	require.Equal(t, expectedRootHashHex, hex.EncodeToString(n.Hash()))
 
-	n = node.NewInterimNode(16, nil, nil)
+	n = node.NewInterimNode(16, nil, nil, common.DefaultTrieHasher)
 	expectedRootHashHex = "6e24e2397f130d9d17bef32b19a77b8f5bcf03fb7e9e75fd89b8a455675d574a"
 	require.Equal(t, expectedRootHashHex, hex.EncodeToString(n.Hash()))
 }
@@ -61,13 +63,13 @@ func Test_InterimNodeWithoutChildren(t *testing.T) {
 func Test_InterimNodeWithOneChild(t *testing.T) {
 	path := utils.TwoBytesPath(56809)
 	payload := utils.LightPayload(56810, 59656)
-	c := node.NewLeaf(path, payload, 0)
+	c := node.NewLeaf(path, payload, 0, common.DefaultTrieHasher)
 
-	n := node.NewInterimNode(1, c, nil)
+	n := node.NewInterimNode(1, c, nil, common.DefaultTrieHasher)
 	expectedRootHashHex := "87768f75da797362be04fbe4d30291f94ed416cc5f336fb17dd430791f93a661"
 	require.Equal(t, expectedRootHashHex, hex.EncodeToString(n.Hash()))
 
-	n = node.NewInterimNode(1, nil, c)
+	n = node.NewInterimNode(1, nil, c, common.DefaultTrieHasher)
 	expectedRootHashHex = "34ee03b8ca7d5cc8638d28b7cf2d70641efd5dfa428333863904a0fd19930700"
 	require.Equal(t, expectedRootHashHex, hex.EncodeToString(n.Hash()))
 }
@@ -77,13 +79,13 @@ func Test_InterimNodeWithOneChild(t *testing.T) {
 func Test_InterimNodeWithBothChildren(t *testing.T) {
 	leftPath := utils.TwoBytesPath(56809)
 	leftPayload := utils.LightPayload(56810, 59656)
-	leftChild := node.NewLeaf(leftPath, leftPayload, 0)
+	leftChild := node.NewLeaf(leftPath, leftPayload, 0, common.DefaultTrieHasher)
 
 	rightPath := utils.TwoBytesPath(2)
 	rightPayload := utils.LightPayload(11, 22)
-	rightChild := node.NewLeaf(rightPath, rightPayload, 0)
+	rightChild := node.NewLeaf(rightPath, rightPayload, 0, common.DefaultTrieHasher)
 
-	n := node.NewInterimNode(1, leftChild, rightChild)
+	n := node.NewInterimNode(1, leftChild, rightChild, common.DefaultTrieHasher)
 	expectedRootHashHex := "77ae9ef2993849e70476c2dac2abc947cce92ca326fcafa74e912223a0b1a2ed"
 	require.Equal(t, expectedRootHashHex, hex.EncodeToString(n.Hash()))
 }
@@ -92,44 +94,102 @@ func Test_MaxDepth(t *testing.T) {
 	path := utils.TwoBytesPath(1)
 	payload := utils.LightPayload(2, 3)
 
-	n1 := node.NewLeaf(path, payload, 0)
-	n2 := node.NewLeaf(path, payload, 0)
-	n3 := node.NewLeaf(path, payload, 0)
+	n1 := node.NewLeaf(path, payload, 0, common.DefaultTrieHasher)
+	n2 := node.NewLeaf(path, payload, 0, common.DefaultTrieHasher)
+	n3 := node.NewLeaf(path, payload, 0, common.DefaultTrieHasher)
 
-	n4 := node.NewInterimNode(1, n1, n2)
-	n5 := node.NewInterimNode(1, n4, n3)
+	n4 := node.NewInterimNode(1, n1, n2, common.DefaultTrieHasher)
+	n5 := node.NewInterimNode(1, n4, n3, common.DefaultTrieHasher)
 	require.Equal(t, n5.MaxDepth(), uint16(2))
 }
 
 func Test_RegCount(t *testing.T) {
 	path := utils.TwoBytesPath(1)
 	payload := utils.LightPayload(2, 3)
-	n1 := node.NewLeaf(path, payload, 0)
-	n2 := node.NewLeaf(path, payload, 0)
-	n3 := node.NewLeaf(path, payload, 0)
+	n1 := node.NewLeaf(path, payload, 0, common.DefaultTrieHasher)
+	n2 := node.NewLeaf(path, payload, 0, common.DefaultTrieHasher)
+	n3 := node.NewLeaf(path, payload, 0, common.DefaultTrieHasher)
 
-	n4 := node.NewInterimNode(1, n1, n2)
-	n5 := node.NewInterimNode(1, n4, n3)
+	n4 := node.NewInterimNode(1, n1, n2, common.DefaultTrieHasher)
+	n5 := node.NewInterimNode(1, n4, n3, common.DefaultTrieHasher)
 	require.Equal(t, n5.RegCount(), uint64(3))
 }
 func Test_AllPayloads(t *testing.T) {
 	path := utils.TwoBytesPath(1)
 	payload := utils.LightPayload(2, 3)
-	n1 := node.NewLeaf(path, payload, 0)
-	n2 := node.NewLeaf(path, payload, 0)
-	n3 := node.NewLeaf(path, payload, 0)
-	n4 := node.NewInterimNode(1, n1, n2)
-	n5 := node.NewInterimNode(1, n4, n3)
+	n1 := node.NewLeaf(path, payload, 0, common.DefaultTrieHasher)
+	n2 := node.NewLeaf(path, payload, 0, common.DefaultTrieHasher)
+	n3 := node.NewLeaf(path, payload, 0, common.DefaultTrieHasher)
+	n4 := node.NewInterimNode(1, n1, n2, common.DefaultTrieHasher)
+	n5 := node.NewInterimNode(1, n4, n3, common.DefaultTrieHasher)
 	require.Equal(t, len(n5.AllPayloads()), 3)
 }
 
 func Test_VerifyCachedHash(t *testing.T) {
 	path := utils.TwoBytesPath(1)
 	payload := utils.LightPayload(2, 3)
-	n1 := node.NewLeaf(path, payload, 0)
-	n2 := node.NewLeaf(path, payload, 0)
-	n3 := node.NewLeaf(path, payload, 0)
-	n4 := node.NewInterimNode(1, n1, n2)
-	n5 := node.NewInterimNode(1, n4, n3)
-	require.True(t, n5.VerifyCachedHash())
+	n1 := node.NewLeaf(path, payload, 0, common.DefaultTrieHasher)
+	n2 := node.NewLeaf(path, payload, 0, common.DefaultTrieHasher)
+	n3 := node.NewLeaf(path, payload, 0, common.DefaultTrieHasher)
+	n4 := node.NewInterimNode(1, n1, n2, common.DefaultTrieHasher)
+	n5 := node.NewInterimNode(1, n4, n3, common.DefaultTrieHasher)
+	require.True(t, n5.VerifyCachedHash(common.DefaultTrieHasher))
+}
+
+// Test_DetectHasher verifies that the hasher of a node is detected from its cached hash
+func Test_DetectHasher(t *testing.T) {
+	path := utils.TwoBytesPath(1)
+	payload := utils.LightPayload(2, 3)
+	blake2b, err := common.NewTrieHasher(ledger.TrieHasherBlake2b)
+	require.NoError(t, err)
+
+	for _, hasher := range []common.TrieHasher{common.DefaultTrieHasher, blake2b} {
+		n1 := node.NewLeaf(path, payload, 0, hasher)
+		n2 := node.NewInterimNode(1, n1, nil, hasher)
+		require.True(t, n2.VerifyCachedHash(hasher))
+
+		detected, ok := n2.DetectHasher()
+		require.True(t, ok)
+		require.Equal(t, hasher.Type(), detected.Type())
+	}
+
+	n1 := node.NewLeaf(path, payload, 0, common.DefaultTrieHasher)
+	n2 := node.NewInterimNode(1, n1, nil, blake2b)
+	require.True(t, n2.VerifyOwnHash(blake2b))
+	require.False(t, n2.VerifyCachedHash(blake2b))
+	require.NotEqual(t, common.DefaultTrieHasher.GetDefaultHashForHeight(1), blake2b.GetDefaultHashForHeight(1))
+}
+
+// Test_References verifies that shared subtries are only accounted for once
+func Test_References(t *testing.T) {
+	payload := utils.LightPayload(2, 3)
+	n1 := node.NewLeaf(utils.TwoBytesPath(1), payload, 0, common.DefaultTrieHasher)
+	n2 := node.NewLeaf(utils.TwoBytesPath(2), payload, 0, common.DefaultTrieHasher)
+	n3 := node.NewLeaf(utils.TwoBytesPath(3), payload, 0, common.DefaultTrieHasher)
+	n4 := node.NewInterimNode(1, n1, n2, common.DefaultTrieHasher)
+	n5 := node.NewInterimNode(2, n4, n3, common.DefaultTrieHasher)
+	n6 := node.NewInterimNode(2, n4, nil, common.DefaultTrieHasher)
+
+	refs := node.NewReferences()
+	n4Size := n1.MemorySize() + n2.MemorySize() + n4.MemorySize()
+	require.Equal(t, n4Size+n3.MemorySize()+n5.MemorySize(), refs.Retain(n5))
+	// n4 is shared with n5
+	require.Equal(t, n6.MemorySize(), refs.Retain(n6))
+	require.Equal(t, 6, refs.Len())
+
+	// a distinct node with the same content is accounted for as the same node
+	n7 := node.NewLeaf(utils.TwoBytesPath(3), payload, 0, common.DefaultTrieHasher)
+	require.Equal(t, uint64(0), refs.Retain(n7))
+	require.Equal(t, uint64(0), refs.Release(n7))
+
+	// counts are kept per table, the nodes can be shared with another forest
+	other := node.NewReferences()
+	require.Equal(t, n4Size+n6.MemorySize(), other.Retain(n6))
+
+	require.Equal(t, n3.MemorySize()+n5.MemorySize(), refs.Release(n5))
+	require.Equal(t, n4Size+n6.MemorySize(), refs.Release(n6))
+	require.Equal(t, 0, refs.Len())
+	require.Equal(t, uint64(0), refs.Release(n6))
+
+	require.Equal(t, n4Size+n6.MemorySize(), other.Release(n6))
 }
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/onflow/flow-go/ledger/common/utils"
+)
+
+const encodingDecodingVersion = uint16(0)
+
+// storedNode is the on-disk representation of a trie node. In contrast to
+// the flattener's StorableNode, children are referenced by their hash rather
+// than by an index, which allows nodes shared by several tries to be stored
+// only once.
+type storedNode struct {
+	Height     uint16
+	LHash      []byte // hash of the left child, empty if there is no left child
+	RHash      []byte // hash of the right child, empty if there is no right child
+	MaxDepth   uint16
+	RegCount   uint64
+	Path       []byte // path (leaf nodes only)
+	EncPayload []byte // encoded payload (leaf nodes only)
+}
+
+// isLeaf returns true if the stored node is a leaf
+func (n *storedNode) isLeaf() bool {
+	return len(n.Path) > 0
+}
+
+// encodeStoredNode encodes a storedNode
+func encodeStoredNode(n *storedNode) []byte {
+	length := 2 + 2 + 2 + 8 + 2 + len(n.LHash) + 2 + len(n.RHash) + 2 + len(n.Path) + 4 + len(n.EncPayload)
+	buf := make([]byte, 0, length)
+
+	// 2-bytes encoding version
+	buf = utils.AppendUint16(buf, encodingDecodingVersion)
+
+	// 2-bytes Big Endian uint16 height
+	buf = utils.AppendUint16(buf, n.Height)
+
+	// 2-bytes Big Endian maxDepth
+	buf = utils.AppendUint16(buf, n.MaxDepth)
+
+	// 8-bytes Big Endian regCount
+	buf = utils.AppendUint64(buf, n.RegCount)
+
+	// 2-bytes Big Endian uint16 left child hash length and n-bytes left child hash
+	buf = utils.AppendShortData(buf, n.LHash)
+
+	// 2-bytes Big Endian uint16 right child hash length and n-bytes right child hash
+	buf = utils.AppendShortData(buf, n.RHash)
+
+	// 2-bytes Big Endian uint16 encoded path length and n-bytes encoded path
+	buf = utils.AppendShortData(buf, n.Path)
+
+	// 4-bytes Big Endian uint32 encoded payload length and n-bytes encoded payload
+	buf = utils.AppendLongData(buf, n.EncPayload)
+
+	return buf
+}
+
+// decodeStoredNode decodes a storedNode
+func decodeStoredNode(encoded []byte) (*storedNode, error) {
+	version, rest, err := utils.ReadUint16(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding stored node, cannot read version: %w", err)
+	}
+	if version > encodingDecodingVersion {
+		return nil, fmt.Errorf("error decoding stored node: unsuported version %d > %d", version, encodingDecodingVersion)
+	}
+
+	n := &storedNode{}
+
+	n.Height, rest, err = utils.ReadUint16(rest)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding stored node, cannot read height: %w", err)
+	}
+
+	n.MaxDepth, rest, err = utils.ReadUint16(rest)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding stored node, cannot read max depth: %w", err)
+	}
+
+	n.RegCount, rest, err = utils.ReadUint64(rest)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding stored node, cannot read reg count: %w", err)
+	}
+
+	n.LHash, rest, err = utils.ReadShortData(rest)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding stored node, cannot read left child hash: %w", err)
+	}
+
+	n.RHash, rest, err = utils.ReadShortData(rest)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding stored node, cannot read right child hash: %w", err)
+	}
+
+	n.Path, rest, err = utils.ReadShortData(rest)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding stored node, cannot read path: %w", err)
+	}
+
+	n.EncPayload, _, err = utils.ReadLongData(rest)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding stored node, cannot read payload: %w", err)
+	}
+
+	return n, nil
+}
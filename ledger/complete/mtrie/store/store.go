@@ -0,0 +1,280 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/dgraph-io/badger/v2"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common/encoding"
+	"github.com/onflow/flow-go/ledger/common/utils"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/node"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
+	"github.com/onflow/flow-go/utils/io"
+)
+
+// ErrTrieNotFound is returned when the store holds no trie with the requested root hash
+var ErrTrieNotFound = errors.New("trie not found in node store")
+
+// NodeStore is a disk-backed store for MTrie nodes. It is used by the Forest in
+// archival mode to keep the nodes of evicted tries, so that reads and proofs can
+// still be served for any trie which was ever part of the forest.
+//
+// Nodes are stored keyed by their hash, and reference their children by hash.
+// As tries share most of their sub-tries with their parents, each node is written
+// only once, no matter how many tries reference it. Nodes are written children-first,
+// hence a node being present in the store implies that its entire sub-trie is present
+// as well. This property allows skipping whole sub-tries when storing a trie.
+//
+// Tries are paged in on demand: for reads and proofs, only the nodes along the
+// requested paths are loaded. Siblings of the loaded nodes are represented by
+// hash-only nodes (nodes without children and payload but carrying the sibling's
+// hash), which is all that is needed for reading and generating proofs.
+type NodeStore struct {
+	db  *badger.DB
+	dir string
+}
+
+// NewNodeStore creates a new node store persisting nodes in the given directory
+func NewNodeStore(dir string) (*NodeStore, error) {
+	opts := badger.
+		DefaultOptions(dir).
+		WithKeepL0InMemory(false).
+		WithLogger(nil)
+
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not open node store: %w", err)
+	}
+
+	return &NodeStore{
+		db:  db,
+		dir: dir,
+	}, nil
+}
+
+// StoreTrie persists all nodes of the given trie which are not yet part of the store.
+// Storing a trie which is already fully stored is a cheap no-op.
+func (s *NodeStore) StoreTrie(t *trie.MTrie) error {
+	tx := s.db.NewTransaction(true)
+	defer func() {
+		tx.Discard()
+	}()
+
+	// set writes the node in the current transaction, and when the transaction
+	// becomes too big, commits it and continues in a fresh one. As nodes are written
+	// children-first, committed data always satisfies the store's invariant.
+	set := func(key []byte, value []byte) error {
+		err := tx.Set(key, value)
+		if errors.Is(err, badger.ErrTxnTooBig) {
+			err = tx.Commit()
+			if err != nil {
+				return fmt.Errorf("could not commit nodes: %w", err)
+			}
+			tx = s.db.NewTransaction(true)
+			err = tx.Set(key, value)
+		}
+		if err != nil {
+			return fmt.Errorf("could not store node: %w", err)
+		}
+		return nil
+	}
+
+	var storeSubtrie func(n *node.Node) error
+	storeSubtrie = func(n *node.Node) error {
+		if n == nil {
+			return nil
+		}
+
+		// if the node is already stored, so is its entire sub-trie
+		_, err := tx.Get(n.Hash())
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, badger.ErrKeyNotFound) {
+			return fmt.Errorf("could not check node: %w", err)
+		}
+
+		err = storeSubtrie(n.LeftChild())
+		if err != nil {
+			return err
+		}
+		err = storeSubtrie(n.RightChild())
+		if err != nil {
+			return err
+		}
+
+		return set(n.Hash(), encodeStoredNode(toStoredNode(n)))
+	}
+
+	err := storeSubtrie(t.RootNode())
+	if err != nil {
+		return fmt.Errorf("could not store trie %s: %w", t.StringRootHash(), err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("could not commit trie %s: %w", t.StringRootHash(), err)
+	}
+
+	return nil
+}
+
+// HasTrie returns true if the trie with the given root hash is stored
+func (s *NodeStore) HasTrie(rootHash ledger.RootHash) (bool, error) {
+	var found bool
+	err := s.db.View(func(tx *badger.Txn) error {
+		_, err := tx.Get(rootHash)
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("could not check trie: %w", err)
+	}
+	return found, nil
+}
+
+// LoadTrie loads the complete trie with the given root hash into memory.
+// CAUTION: depending on the size of the trie, this can consume a lot of memory;
+// use LoadTrieForPaths whenever only a subset of registers is needed.
+func (s *NodeStore) LoadTrie(rootHash ledger.RootHash) (*trie.MTrie, error) {
+	return s.loadTrie(rootHash, nil, true)
+}
+
+// LoadTrieForPaths loads the trie with the given root hash, only paging in the nodes
+// needed to read or prove the given paths. The returned trie MUST only be used for
+// reading or generating proofs for (a subset of) the given paths.
+func (s *NodeStore) LoadTrieForPaths(rootHash ledger.RootHash, paths []ledger.Path) (*trie.MTrie, error) {
+	// sort and deduplicate paths, without modifying the input
+	sortedPaths := make([]ledger.Path, 0, len(paths))
+	seen := make(map[string]struct{}, len(paths))
+	for _, path := range paths {
+		if _, ok := seen[string(path)]; ok {
+			continue
+		}
+		seen[string(path)] = struct{}{}
+		sortedPaths = append(sortedPaths, path)
+	}
+	sort.Slice(sortedPaths, func(i, j int) bool {
+		return bytes.Compare(sortedPaths[i], sortedPaths[j]) < 0
+	})
+
+	return s.loadTrie(rootHash, sortedPaths, false)
+}
+
+func (s *NodeStore) loadTrie(rootHash ledger.RootHash, sortedPaths []ledger.Path, full bool) (*trie.MTrie, error) {
+	var root *node.Node
+	err := s.db.View(func(tx *badger.Txn) error {
+		sn, err := retrieveNode(tx, rootHash)
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return ErrTrieNotFound
+		}
+		if err != nil {
+			return err
+		}
+		root, err = loadSubtrie(tx, int(sn.Height), rootHash, sn, sortedPaths, full)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not load trie %x: %w", rootHash, err)
+	}
+
+	return trie.NewMTrie(root)
+}
+
+// DiskSize returns the disk size of the directory used by the store (in bytes)
+func (s *NodeStore) DiskSize() (uint64, error) {
+	return io.DirSize(s.dir)
+}
+
+// Close closes the underlying database
+func (s *NodeStore) Close() error {
+	return s.db.Close()
+}
+
+// loadSubtrie reconstructs the sub-trie headed by the given stored node. Unless full is set,
+// only children on the given paths are loaded, all others are replaced with hash-only nodes.
+func loadSubtrie(tx *badger.Txn, treeHeight int, hash []byte, sn *storedNode, sortedPaths []ledger.Path, full bool) (*node.Node, error) {
+	if sn.isLeaf() || (len(sn.LHash) == 0 && len(sn.RHash) == 0) {
+		payload, err := encoding.DecodePayload(sn.EncPayload)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode payload of node %x: %w", hash, err)
+		}
+		return node.NewNode(int(sn.Height), nil, nil, sn.Path, payload, hash, sn.MaxDepth, sn.RegCount), nil
+	}
+
+	var lPaths, rPaths []ledger.Path
+	if !full {
+		var err error
+		lPaths, rPaths, err = utils.SplitSortedPaths(sortedPaths, treeHeight-int(sn.Height))
+		if err != nil {
+			return nil, fmt.Errorf("could not split paths: %w", err)
+		}
+	}
+
+	lChild, err := loadChild(tx, treeHeight, int(sn.Height)-1, sn.LHash, lPaths, full)
+	if err != nil {
+		return nil, err
+	}
+	rChild, err := loadChild(tx, treeHeight, int(sn.Height)-1, sn.RHash, rPaths, full)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.NewNode(int(sn.Height), lChild, rChild, nil, nil, hash, sn.MaxDepth, sn.RegCount), nil
+}
+
+func loadChild(tx *badger.Txn, treeHeight int, height int, hash []byte, sortedPaths []ledger.Path, full bool) (*node.Node, error) {
+	if len(hash) == 0 {
+		return nil, nil
+	}
+
+	// child is not on any of the requested paths, only its hash is needed
+	if !full && len(sortedPaths) == 0 {
+		return node.NewNode(height, nil, nil, nil, nil, hash, 0, 0), nil
+	}
+
+	sn, err := retrieveNode(tx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve node %x: %w", hash, err)
+	}
+	return loadSubtrie(tx, treeHeight, hash, sn, sortedPaths, full)
+}
+
+func retrieveNode(tx *badger.Txn, hash []byte) (*storedNode, error) {
+	item, err := tx.Get(hash)
+	if err != nil {
+		return nil, err
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not read node value: %w", err)
+	}
+	return decodeStoredNode(val)
+}
+
+func toStoredNode(n *node.Node) *storedNode {
+	sn := &storedNode{
+		Height:     uint16(n.Height()),
+		MaxDepth:   n.MaxDepth(),
+		RegCount:   n.RegCount(),
+		Path:       n.Path(),
+		EncPayload: encoding.EncodePayload(n.Payload()),
+	}
+	if lChild := n.LeftChild(); lChild != nil {
+		sn.LHash = lChild.Hash()
+	}
+	if rChild := n.RightChild(); rChild != nil {
+		sn.RHash = rChild.Hash()
+	}
+	return sn
+}
@@ -0,0 +1,160 @@
+package store_test
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common/utils"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/store"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
+	"github.com/onflow/flow-go/utils/unittest"
+)
+
+func randomTrie(t *testing.T, parent *trie.MTrie, n int) (*trie.MTrie, []ledger.Path, []ledger.Payload) {
+	paths := utils.RandomPaths(n, parent.PathLength())
+	sort.Slice(paths, func(i, j int) bool {
+		return bytes.Compare(paths[i], paths[j]) < 0
+	})
+	payloads := make([]ledger.Payload, 0, n)
+	for _, p := range utils.RandomPayloads(n, 1, 20) {
+		payloads = append(payloads, *p)
+	}
+	updated, err := trie.NewTrieWithUpdatedRegisters(parent, paths, payloads)
+	require.NoError(t, err)
+	return updated, paths, payloads
+}
+
+// TestNodeStore_StoreAndLoadTrie stores a trie and verifies that the
+// complete trie can be loaded back with identical content.
+func TestNodeStore_StoreAndLoadTrie(t *testing.T) {
+	unittest.RunWithTempDir(t, func(dir string) {
+		s, err := store.NewNodeStore(dir)
+		require.NoError(t, err)
+		defer s.Close()
+
+		emptyTrie, err := trie.NewEmptyMTrie(2)
+		require.NoError(t, err)
+		original, paths, payloads := randomTrie(t, emptyTrie, 100)
+
+		found, err := s.HasTrie(original.RootHash())
+		require.NoError(t, err)
+		require.False(t, found)
+
+		err = s.StoreTrie(original)
+		require.NoError(t, err)
+
+		found, err = s.HasTrie(original.RootHash())
+		require.NoError(t, err)
+		require.True(t, found)
+
+		loaded, err := s.LoadTrie(original.RootHash())
+		require.NoError(t, err)
+		require.True(t, original.Equals(loaded))
+		require.True(t, loaded.IsAValidTrie())
+		require.Equal(t, original.AllocatedRegCount(), loaded.AllocatedRegCount())
+
+		read, err := loaded.UnsafeRead(paths)
+		require.NoError(t, err)
+		for i := range paths {
+			require.True(t, payloads[i].Equals(read[i]))
+		}
+	})
+}
+
+// TestNodeStore_LoadTrieForPaths verifies that reads and proofs on a partially
+// loaded trie match the ones on the original trie.
+func TestNodeStore_LoadTrieForPaths(t *testing.T) {
+	unittest.RunWithTempDir(t, func(dir string) {
+		s, err := store.NewNodeStore(dir)
+		require.NoError(t, err)
+		defer s.Close()
+
+		emptyTrie, err := trie.NewEmptyMTrie(2)
+		require.NoError(t, err)
+		original, paths, _ := randomTrie(t, emptyTrie, 200)
+
+		err = s.StoreTrie(original)
+		require.NoError(t, err)
+
+		// a few existing and a non-existing path
+		queried := []ledger.Path{paths[3], paths[42], paths[150]}
+		queried = append(queried, utils.RandomPaths(1, 2)...)
+		sort.Slice(queried, func(i, j int) bool {
+			return bytes.Compare(queried[i], queried[j]) < 0
+		})
+
+		partial, err := s.LoadTrieForPaths(original.RootHash(), queried)
+		require.NoError(t, err)
+		require.True(t, original.Equals(partial))
+
+		expected, err := original.UnsafeRead(queried)
+		require.NoError(t, err)
+		read, err := partial.UnsafeRead(queried)
+		require.NoError(t, err)
+		for i := range queried {
+			require.Equal(t, expected[i], read[i])
+		}
+
+		expectedProofs := ledger.NewTrieBatchProofWithEmptyProofs(len(queried))
+		proofs := ledger.NewTrieBatchProofWithEmptyProofs(len(queried))
+		for i := range queried {
+			expectedProofs.Proofs[i].Flags = make([]byte, 2)
+			proofs.Proofs[i].Flags = make([]byte, 2)
+		}
+		err = original.UnsafeProofs(queried, expectedProofs.Proofs)
+		require.NoError(t, err)
+		err = partial.UnsafeProofs(queried, proofs.Proofs)
+		require.NoError(t, err)
+		for i := range queried {
+			require.Equal(t, expectedProofs.Proofs[i], proofs.Proofs[i])
+		}
+	})
+}
+
+// TestNodeStore_SharedNodes verifies that tries sharing sub-tries can be stored
+// and loaded independently of each other.
+func TestNodeStore_SharedNodes(t *testing.T) {
+	unittest.RunWithTempDir(t, func(dir string) {
+		s, err := store.NewNodeStore(dir)
+		require.NoError(t, err)
+		defer s.Close()
+
+		emptyTrie, err := trie.NewEmptyMTrie(2)
+		require.NoError(t, err)
+		parent, _, _ := randomTrie(t, emptyTrie, 100)
+		child, _, _ := randomTrie(t, parent, 10)
+
+		err = s.StoreTrie(parent)
+		require.NoError(t, err)
+
+		err = s.StoreTrie(child)
+		require.NoError(t, err)
+
+		// storing the same trie again is a no-op
+		err = s.StoreTrie(child)
+		require.NoError(t, err)
+
+		for _, original := range []*trie.MTrie{parent, child} {
+			loaded, err := s.LoadTrie(original.RootHash())
+			require.NoError(t, err)
+			require.True(t, original.Equals(loaded))
+			require.True(t, loaded.IsAValidTrie())
+		}
+	})
+}
+
+func TestNodeStore_TrieNotFound(t *testing.T) {
+	unittest.RunWithTempDir(t, func(dir string) {
+		s, err := store.NewNodeStore(dir)
+		require.NoError(t, err)
+		defer s.Close()
+
+		_, err = s.LoadTrie(utils.RootHashFixture())
+		require.True(t, errors.Is(err, store.ErrTrieNotFound))
+	})
+}
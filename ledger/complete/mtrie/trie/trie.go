@@ -36,27 +36,46 @@ import (
 //   * HEIGHT of a node v in a tree is the number of edges on the longest downward path
 //     between v and a tree leaf. The height of a tree is the heights of its root.
 //     The height of a Trie is always the height of the fully-expanded tree.
+//   * All nodes of a trie are hashed with the trie's hasher, which is
+//     inherited by the tries derived from it through updates.
 type MTrie struct {
 	root         *node.Node
 	height       int
 	pathByteSize int
+	hasher       common.TrieHasher
 }
 
-// NewEmptyMTrie returns an empty Mtrie (root is an empty node)
+// NewEmptyMTrie returns an empty Mtrie (root is an empty node) using the default hasher
 func NewEmptyMTrie(pathByteSize int) (*MTrie, error) {
+	return NewEmptyMTrieWithHasher(pathByteSize, common.DefaultTrieHasher)
+}
+
+// NewEmptyMTrieWithHasher returns an empty Mtrie (root is an empty node) using the given hasher
+func NewEmptyMTrieWithHasher(pathByteSize int, hasher common.TrieHasher) (*MTrie, error) {
 	if pathByteSize < 1 {
 		return nil, errors.New("trie's path size [in bytes] must be positive")
 	}
 	height := pathByteSize * 8
 	return &MTrie{
-		root:         node.NewEmptyTreeRoot(height),
+		root:         node.NewEmptyTreeRoot(height, hasher),
 		pathByteSize: pathByteSize,
 		height:       height,
+		hasher:       hasher,
 	}, nil
 }
 
-// NewMTrie returns a Mtrie given the root
+// NewMTrie returns a Mtrie given the root.
+// The trie's hasher is detected from the hash of the root node, see node.DetectHasher.
 func NewMTrie(root *node.Node) (*MTrie, error) {
+	hasher, ok := root.DetectHasher()
+	if !ok {
+		return nil, errors.New("hash of root node doesn't match any supported hasher")
+	}
+	return NewMTrieWithHasher(root, hasher)
+}
+
+// NewMTrieWithHasher returns a Mtrie given the root, whose nodes are hashed with the given hasher
+func NewMTrieWithHasher(root *node.Node, hasher common.TrieHasher) (*MTrie, error) {
 	if root.Height()%8 != 0 {
 		return nil, errors.New("height of root node must be integer-multiple of 8")
 	}
@@ -65,6 +84,7 @@ func NewMTrie(root *node.Node) (*MTrie, error) {
 		root:         root,
 		pathByteSize: pathByteSize,
 		height:       root.Height(),
+		hasher:       hasher,
 	}, nil
 }
 
@@ -88,6 +108,10 @@ func (mt *MTrie) AllocatedRegCount() uint64 { return mt.root.RegCount() }
 // Concurrency safe (as Tries are immutable structures by convention)
 func (mt *MTrie) MaxDepth() uint16 { return mt.root.MaxDepth() }
 
+// Hasher returns the hasher of the Trie's nodes
+// Concurrency safe (as Tries are immutable structures by convention)
+func (mt *MTrie) Hasher() common.TrieHasher { return mt.hasher }
+
 // RootNode returns the Trie's root Node
 // Concurrency safe (as Tries are immutable structures by convention)
 func (mt *MTrie) RootNode() *node.Node {
@@ -165,11 +189,11 @@ func (mt *MTrie) read(head *node.Node, paths []ledger.Path) ([]*ledger.Payload,
 // TODO: move consistency checks from MForest to here, to make API is safe and self-contained
 func NewTrieWithUpdatedRegisters(parentTrie *MTrie, updatedPaths []ledger.Path, updatedPayloads []ledger.Payload) (*MTrie, error) {
 	parentRoot := parentTrie.root
-	updatedRoot, err := update(parentTrie.height, parentRoot.Height(), parentRoot, updatedPaths, updatedPayloads)
+	updatedRoot, err := update(parentTrie.height, parentRoot.Height(), parentRoot, updatedPaths, updatedPayloads, parentTrie.hasher)
 	if err != nil {
 		return nil, fmt.Errorf("constructing updated trie failed: %w", err)
 	}
-	updatedTrie, err := NewMTrie(updatedRoot)
+	updatedTrie, err := NewMTrieWithHasher(updatedRoot, parentTrie.hasher)
 	if err != nil {
 		return nil, fmt.Errorf("constructing updated trie failed: %w", err)
 	}
@@ -183,9 +207,9 @@ func NewTrieWithUpdatedRegisters(parentTrie *MTrie, updatedPaths []ledger.Path,
 //     (excluding the bit at index headHeight)
 //   * keys are NOT duplicated
 // TODO: remove error return
-func update(treeHeight int, nodeHeight int, parentNode *node.Node, paths []ledger.Path, payloads []ledger.Payload) (*node.Node, error) {
+func update(treeHeight int, nodeHeight int, parentNode *node.Node, paths []ledger.Path, payloads []ledger.Payload, hasher common.TrieHasher) (*node.Node, error) {
 	if parentNode == nil { // parent Trie has no sub-trie for the set of paths => construct entire subtree
-		return constructSubtrie(treeHeight, nodeHeight, paths, payloads)
+		return constructSubtrie(treeHeight, nodeHeight, paths, payloads, hasher)
 	}
 
 	if len(paths) == 0 { // We are not changing any values in this sub-trie => return parent trie
@@ -207,7 +231,7 @@ func update(treeHeight int, nodeHeight int, parentNode *node.Node, paths []ledge
 			paths = append(paths, parentNode.Path())
 			payloads = append(payloads, *parentNode.Payload())
 		}
-		return constructSubtrie(treeHeight, nodeHeight, paths, payloads)
+		return constructSubtrie(treeHeight, nodeHeight, paths, payloads, hasher)
 	}
 
 	// Split payloads so we can update the trie in parallel
@@ -223,9 +247,9 @@ func update(treeHeight int, nodeHeight int, parentNode *node.Node, paths []ledge
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		lChild, lErr = update(treeHeight, nodeHeight-1, parentNode.LeftChild(), lpaths, lpayloads)
+		lChild, lErr = update(treeHeight, nodeHeight-1, parentNode.LeftChild(), lpaths, lpayloads, hasher)
 	}()
-	rChild, rErr = update(treeHeight, nodeHeight-1, parentNode.RightChild(), rpaths, rpayloads)
+	rChild, rErr = update(treeHeight, nodeHeight-1, parentNode.RightChild(), rpaths, rpayloads, hasher)
 	wg.Wait()
 	if lErr != nil || rErr != nil {
 		var merr *multierror.Error
@@ -240,7 +264,7 @@ func update(treeHeight int, nodeHeight int, parentNode *node.Node, paths []ledge
 		}
 	}
 
-	return node.NewInterimNode(nodeHeight, lChild, rChild), nil
+	return node.NewInterimNode(nodeHeight, lChild, rChild, hasher), nil
 }
 
 // constructSubtrie returns the head of a newly-constructed sub-trie for the specified key-value pairs.
@@ -251,14 +275,14 @@ func update(treeHeight int, nodeHeight int, parentNode *node.Node, paths []ledge
 //   * paths contains at least one element
 //   * paths are NOT duplicated
 // TODO: remove error return
-func constructSubtrie(treeHeight int, nodeHeight int, paths []ledger.Path, payloads []ledger.Payload) (*node.Node, error) {
+func constructSubtrie(treeHeight int, nodeHeight int, paths []ledger.Path, payloads []ledger.Payload, hasher common.TrieHasher) (*node.Node, error) {
 	// no inserts => default value, represented by nil node
 	if len(paths) == 0 {
 		return nil, nil
 	}
 	// If we are at a leaf node, we create the node
 	if len(paths) == 1 {
-		return node.NewLeaf(paths[0], &payloads[0], nodeHeight), nil
+		return node.NewLeaf(paths[0], &payloads[0], nodeHeight, hasher), nil
 	}
 	// from here on, we have: len(paths) > 1
 
@@ -279,9 +303,9 @@ func constructSubtrie(treeHeight int, nodeHeight int, paths []ledger.Path, paylo
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		lChild, lErr = constructSubtrie(treeHeight, nodeHeight-1, lpaths, lpayloads)
+		lChild, lErr = constructSubtrie(treeHeight, nodeHeight-1, lpaths, lpayloads, hasher)
 	}()
-	rChild, rErr = constructSubtrie(treeHeight, nodeHeight-1, rpaths, rpayloads)
+	rChild, rErr = constructSubtrie(treeHeight, nodeHeight-1, rpaths, rpayloads, hasher)
 	wg.Wait()
 	if lErr != nil || rErr != nil {
 		var merr *multierror.Error
@@ -296,12 +320,15 @@ func constructSubtrie(treeHeight int, nodeHeight int, paths []ledger.Path, paylo
 		}
 	}
 
-	return node.NewInterimNode(nodeHeight, lChild, rChild), nil
+	return node.NewInterimNode(nodeHeight, lChild, rChild, hasher), nil
 }
 
 // UnsafeProofs provides proofs for the given paths, this is called unsafe as
 // it requires the input paths to be sorted in advance.
 func (mt *MTrie) UnsafeProofs(paths []ledger.Path, proofs []*ledger.TrieProof) error {
+	for _, p := range proofs {
+		p.Hasher = mt.hasher.Type()
+	}
 	return mt.proofs(mt.root, paths, proofs)
 }
 
@@ -337,7 +364,7 @@ func (mt *MTrie) proofs(head *node.Node, paths []ledger.Path, proofs []*ledger.T
 	if len(lpaths) > 0 {
 		if rChild := head.RightChild(); rChild != nil {
 			nodeHash := rChild.Hash()
-			isDef := bytes.Equal(nodeHash, common.GetDefaultHashForHeight(rChild.Height()))
+			isDef := bytes.Equal(nodeHash, mt.hasher.GetDefaultHashForHeight(rChild.Height()))
 			if !isDef { // in proofs, we only provide non-default value hashes
 				for _, p := range lproofs {
 					err := utils.SetBit(p.Flags, mt.height-head.Height())
@@ -357,7 +384,7 @@ func (mt *MTrie) proofs(head *node.Node, paths []ledger.Path, proofs []*ledger.T
 	if len(rpaths) > 0 {
 		if lChild := head.LeftChild(); lChild != nil {
 			nodeHash := lChild.Hash()
-			isDef := bytes.Equal(nodeHash, common.GetDefaultHashForHeight(lChild.Height()))
+			isDef := bytes.Equal(nodeHash, mt.hasher.GetDefaultHashForHeight(lChild.Height()))
 			if !isDef { // in proofs, we only provide non-default value hashes
 				for _, p := range rproofs {
 					err := utils.SetBit(p.Flags, mt.height-head.Height())
@@ -425,8 +452,15 @@ func (mt *MTrie) dumpAsJSON(n *node.Node, encoder *json.Encoder) error {
 }
 
 // EmptyTrieRootHash returns the rootHash of an empty Trie for the specified path size [bytes]
+// using the default hasher
 func EmptyTrieRootHash(pathByteSize int) []byte {
-	return node.NewEmptyTreeRoot(8 * pathByteSize).Hash()
+	return EmptyTrieRootHashWithHasher(pathByteSize, common.DefaultTrieHasher)
+}
+
+// EmptyTrieRootHashWithHasher returns the rootHash of an empty Trie for the specified path size [bytes]
+// using the given hasher
+func EmptyTrieRootHashWithHasher(pathByteSize int, hasher common.TrieHasher) []byte {
+	return node.NewEmptyTreeRoot(8*pathByteSize, hasher).Hash()
 }
 
 // AllPayloads returns all payloads
@@ -434,8 +468,147 @@ func (mt *MTrie) AllPayloads() []ledger.Payload {
 	return mt.root.AllPayloads()
 }
 
+// Iterate calls fn for the path and payload of every leaf with a path greater than or
+// equal to start (nil for the smallest path), in increasing path order, until fn returns false.
+// Subtries containing only smaller paths are skipped without visiting their nodes.
+// Do NOT MODIFY the paths and payloads passed to fn!
+// Concurrency safe (as Tries are immutable structures by convention)
+func (mt *MTrie) Iterate(start ledger.Path, fn func(path ledger.Path, payload *ledger.Payload) bool) error {
+	if start != nil && len(start) != mt.pathByteSize {
+		return fmt.Errorf("start path size doesn't match the trie height: %x", len(start))
+	}
+	mt.iterate(mt.root, start, start != nil, fn)
+	return nil
+}
+
+// iterate walks the subtrie of head in path order and returns false if fn stopped the iteration.
+// If bounded is true, the path of head is a prefix of start, i.e. the subtrie may contain paths
+// smaller than start.
+func (mt *MTrie) iterate(head *node.Node, start ledger.Path, bounded bool, fn func(ledger.Path, *ledger.Payload) bool) bool {
+	if head == nil {
+		return true
+	}
+	if head.IsLeaf() {
+		if bounded && bytes.Compare(head.Path(), start) < 0 {
+			return true
+		}
+		return fn(head.Path(), head.Payload())
+	}
+
+	rightOnly := false
+	if bounded {
+		// the bit of start at the depth of head's children decides if start is in the right subtrie
+		bit, err := utils.IsBitSet(start, mt.height-head.Height())
+		if err != nil {
+			// can't happen as the path size was checked
+			return false
+		}
+		rightOnly = bit
+	}
+
+	if !rightOnly {
+		if !mt.iterate(head.LeftChild(), start, bounded, fn) {
+			return false
+		}
+		// all paths in the right subtrie are greater than start
+		bounded = false
+	}
+	return mt.iterate(head.RightChild(), start, bounded, fn)
+}
+
+// Diff calls fn for every path at which the payloads of mt and other differ, in increasing path order,
+// until fn returns false. payload is nil if the path is unallocated in mt, otherPayload is nil if the
+// path is unallocated in other. Payloads with empty values are treated as unallocated registers.
+// Subtries shared by both tries (i.e. with identical hashes) are skipped without visiting their nodes.
+// Do NOT MODIFY the paths and payloads passed to fn!
+// Concurrency safe (as Tries are immutable structures by convention)
+func (mt *MTrie) Diff(other *MTrie, fn func(path ledger.Path, payload, otherPayload *ledger.Payload) bool) error {
+	if mt.pathByteSize != other.pathByteSize {
+		return fmt.Errorf("path size of tries doesn't match: %d != %d", mt.pathByteSize, other.pathByteSize)
+	}
+	mt.diff(nonEmpty(mt.root), nonEmpty(other.root), mt.height, fn)
+	return nil
+}
+
+// diff compares the subtries a and b at the given height and returns false if fn stopped the comparison.
+// Compact leaves might be at a greater height, if the subtrie of the other trie has interim nodes.
+func (mt *MTrie) diff(a, b *node.Node, height int, fn func(ledger.Path, *ledger.Payload, *ledger.Payload) bool) bool {
+	if a != nil && b != nil && a.Height() == b.Height() && bytes.Equal(a.Hash(), b.Hash()) {
+		return true
+	}
+	if (a == nil || a.IsLeaf()) && (b == nil || b.IsLeaf()) {
+		return diffLeaves(a, b, fn)
+	}
+
+	aLeft, aRight := mt.children(a, height)
+	bLeft, bRight := mt.children(b, height)
+	if !mt.diff(aLeft, bLeft, height-1, fn) {
+		return false
+	}
+	return mt.diff(aRight, bRight, height-1, fn)
+}
+
+// children returns the children of the node at the given height. A compact leaf is
+// returned as the child on the side of its path, as if it was compacted one level lower.
+func (mt *MTrie) children(n *node.Node, height int) (*node.Node, *node.Node) {
+	if n == nil {
+		return nil, nil
+	}
+	if !n.IsLeaf() {
+		return n.LeftChild(), n.RightChild()
+	}
+	// height > 0 here, as an interim node of the other trie has the same height
+	bit, _ := utils.IsBitSet(n.Path(), mt.height-height)
+	if bit {
+		return nil, n
+	}
+	return n, nil
+}
+
+// diffLeaves compares two compact leaves (or unallocated subtries if nil)
+func diffLeaves(a, b *node.Node, fn func(ledger.Path, *ledger.Payload, *ledger.Payload) bool) bool {
+	aPayload, bPayload := allocatedPayload(a), allocatedPayload(b)
+	if a != nil && b != nil && bytes.Equal(a.Path(), b.Path()) {
+		if aPayload == nil && bPayload == nil {
+			return true
+		}
+		if aPayload != nil && bPayload != nil && aPayload.Equals(bPayload) {
+			return true
+		}
+		return fn(a.Path(), aPayload, bPayload)
+	}
+
+	// different paths, the payload of a was removed and the payload of b was added
+	if aPayload != nil && bPayload != nil && bytes.Compare(b.Path(), a.Path()) < 0 {
+		return fn(b.Path(), nil, bPayload) && fn(a.Path(), aPayload, nil)
+	}
+	if aPayload != nil && !fn(a.Path(), aPayload, nil) {
+		return false
+	}
+	if bPayload != nil {
+		return fn(b.Path(), nil, bPayload)
+	}
+	return true
+}
+
+// allocatedPayload returns the payload of a leaf, or nil if there is no leaf or its value is empty
+func allocatedPayload(n *node.Node) *ledger.Payload {
+	if n == nil || len(n.Payload().Value) == 0 {
+		return nil
+	}
+	return n.Payload()
+}
+
+// nonEmpty returns nil for the root node of an empty trie, or the given node otherwise
+func nonEmpty(n *node.Node) *node.Node {
+	if n.IsLeaf() || n.LeftChild() != nil || n.RightChild() != nil {
+		return n
+	}
+	return nil
+}
+
 // IsAValidTrie verifies the content of the trie for potential issues
 func (mt *MTrie) IsAValidTrie() bool {
 	// TODO add checks on the health of node max height ...
-	return mt.root.VerifyCachedHash()
+	return mt.root.VerifyCachedHash(mt.hasher)
 }
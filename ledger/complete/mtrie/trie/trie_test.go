@@ -240,3 +240,201 @@ func deduplicateWrites(paths []ledger.Path, payloads []ledger.Payload) ([]ledger
 	}
 	return dedupedPaths, dedupedPayloads
 }
+
+// Test_Iterate tests that iterating over a trie visits the leaves in path order,
+// starting at the given path, and stops when requested.
+func Test_Iterate(t *testing.T) {
+	emptyTrie, err := trie.NewEmptyMTrie(ReferenceImplPathByteSize)
+	require.NoError(t, err)
+
+	// allocate every third register
+	paths := make([]ledger.Path, 0)
+	payloads := make([]ledger.Payload, 0)
+	for i := 0; i < 65536; i += 3 {
+		paths = append(paths, utils.TwoBytesPath(uint16(i)))
+		payloads = append(payloads, *utils.LightPayload(uint16(i), uint16(i)))
+	}
+	updatedTrie, err := trie.NewTrieWithUpdatedRegisters(emptyTrie, paths, payloads)
+	require.NoError(t, err)
+
+	iterate := func(start ledger.Path, limit int) ([]ledger.Path, []ledger.Payload) {
+		visitedPaths := make([]ledger.Path, 0)
+		visitedPayloads := make([]ledger.Payload, 0)
+		err := updatedTrie.Iterate(start, func(path ledger.Path, payload *ledger.Payload) bool {
+			visitedPaths = append(visitedPaths, path)
+			visitedPayloads = append(visitedPayloads, *payload)
+			return len(visitedPaths) != limit
+		})
+		require.NoError(t, err)
+		return visitedPaths, visitedPayloads
+	}
+
+	t.Run("all leaves", func(t *testing.T) {
+		visitedPaths, visitedPayloads := iterate(nil, 0)
+		require.Equal(t, paths, visitedPaths)
+		require.Equal(t, payloads, visitedPayloads)
+	})
+
+	t.Run("start at allocated path", func(t *testing.T) {
+		visitedPaths, _ := iterate(utils.TwoBytesPath(30000), 0)
+		require.Equal(t, paths[10000:], visitedPaths)
+	})
+
+	t.Run("start at unallocated path", func(t *testing.T) {
+		visitedPaths, _ := iterate(utils.TwoBytesPath(30001), 0)
+		require.Equal(t, paths[10001:], visitedPaths)
+	})
+
+	t.Run("start at last path", func(t *testing.T) {
+		visitedPaths, _ := iterate(utils.TwoBytesPath(65535), 0)
+		require.Equal(t, paths[len(paths)-1:], visitedPaths)
+	})
+
+	t.Run("start after last path", func(t *testing.T) {
+		partialTrie, err := trie.NewTrieWithUpdatedRegisters(emptyTrie, paths[:100], payloads[:100])
+		require.NoError(t, err)
+		err = partialTrie.Iterate(utils.TwoBytesPath(298), func(ledger.Path, *ledger.Payload) bool {
+			require.Fail(t, "no leaves after start path")
+			return true
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("stop iteration", func(t *testing.T) {
+		visitedPaths, _ := iterate(utils.TwoBytesPath(1000), 10)
+		require.Equal(t, paths[334:344], visitedPaths)
+	})
+
+	t.Run("empty trie", func(t *testing.T) {
+		err := emptyTrie.Iterate(nil, func(ledger.Path, *ledger.Payload) bool {
+			require.Fail(t, "empty trie has no leaves")
+			return true
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("wrong start path size", func(t *testing.T) {
+		err := updatedTrie.Iterate(ledger.Path{0x01}, func(ledger.Path, *ledger.Payload) bool {
+			return true
+		})
+		require.Error(t, err)
+	})
+}
+
+// Test_Diff tests that the differences between two tries are found in path order,
+// by comparing them to the differences of all allocated registers.
+func Test_Diff(t *testing.T) {
+	emptyTrie, err := trie.NewEmptyMTrie(ReferenceImplPathByteSize)
+	require.NoError(t, err)
+
+	rng := &LinearCongruentialGenerator{seed: 0}
+	pathsA, payloadsA := deduplicateWrites(sampleRandomRegisterWrites(rng, 2000))
+	trieA, err := trie.NewTrieWithUpdatedRegisters(emptyTrie, pathsA, payloadsA)
+	require.NoError(t, err)
+
+	// add and modify registers, as well as set some existing registers to empty values
+	paths, payloads := sampleRandomRegisterWrites(rng, 300)
+	for i := 0; i < 50; i++ {
+		paths = append(paths, pathsA[i])
+		payloads = append(payloads, *ledger.NewPayload(payloadsA[i].Key, ledger.Value{}))
+	}
+	for i := 50; i < 100; i++ {
+		paths = append(paths, pathsA[i])
+		payloads = append(payloads, *ledger.NewPayload(payloadsA[i].Key, ledger.Value("modified")))
+	}
+	paths, payloads = deduplicateWrites(paths, payloads)
+	trieB, err := trie.NewTrieWithUpdatedRegisters(trieA, paths, payloads)
+	require.NoError(t, err)
+
+	allocated := func(mt *trie.MTrie) map[string]*ledger.Payload {
+		payloads := make(map[string]*ledger.Payload)
+		err := mt.Iterate(nil, func(path ledger.Path, payload *ledger.Payload) bool {
+			if len(payload.Value) > 0 {
+				payloads[string(path)] = payload
+			}
+			return true
+		})
+		require.NoError(t, err)
+		return payloads
+	}
+
+	diff := func(a, b *trie.MTrie) []*ledger.PayloadChange {
+		changes := make([]*ledger.PayloadChange, 0)
+		err := a.Diff(b, func(path ledger.Path, payloadA, payloadB *ledger.Payload) bool {
+			changes = append(changes, &ledger.PayloadChange{Path: path, Old: payloadA, New: payloadB})
+			return true
+		})
+		require.NoError(t, err)
+		return changes
+	}
+
+	expectedDiff := func(a, b *trie.MTrie) []*ledger.PayloadChange {
+		payloadsA, payloadsB := allocated(a), allocated(b)
+		changes := make([]*ledger.PayloadChange, 0)
+		for i := 0; i < 65536; i++ {
+			path := utils.TwoBytesPath(uint16(i))
+			payloadA, payloadB := payloadsA[string(path)], payloadsB[string(path)]
+			if payloadA == nil && payloadB == nil {
+				continue
+			}
+			if payloadA != nil && payloadA.Equals(payloadB) {
+				continue
+			}
+			changes = append(changes, &ledger.PayloadChange{Path: path, Old: payloadA, New: payloadB})
+		}
+		return changes
+	}
+
+	t.Run("updated trie", func(t *testing.T) {
+		changes := diff(trieA, trieB)
+		require.Equal(t, expectedDiff(trieA, trieB), changes)
+
+		added, removed, modified := 0, 0, 0
+		for _, change := range changes {
+			switch {
+			case change.IsAdded():
+				added++
+			case change.IsRemoved():
+				removed++
+			case change.IsModified():
+				modified++
+			}
+		}
+		require.NotZero(t, added)
+		require.NotZero(t, removed)
+		require.NotZero(t, modified)
+	})
+
+	t.Run("reversed", func(t *testing.T) {
+		require.Equal(t, expectedDiff(trieB, trieA), diff(trieB, trieA))
+	})
+
+	t.Run("empty trie", func(t *testing.T) {
+		require.Equal(t, expectedDiff(emptyTrie, trieA), diff(emptyTrie, trieA))
+		require.Equal(t, expectedDiff(trieA, emptyTrie), diff(trieA, emptyTrie))
+	})
+
+	t.Run("identical tries", func(t *testing.T) {
+		require.Empty(t, diff(trieA, trieA))
+	})
+
+	t.Run("single registers", func(t *testing.T) {
+		// compact leaves at the root's children compared to deeper leaves
+		trieC, err := trie.NewTrieWithUpdatedRegisters(emptyTrie, []ledger.Path{utils.TwoBytesPath(1)}, []ledger.Payload{*utils.LightPayload(1, 1)})
+		require.NoError(t, err)
+		trieD, err := trie.NewTrieWithUpdatedRegisters(emptyTrie, []ledger.Path{utils.TwoBytesPath(2)}, []ledger.Payload{*utils.LightPayload(2, 2)})
+		require.NoError(t, err)
+		require.Equal(t, expectedDiff(trieC, trieD), diff(trieC, trieD))
+		require.Equal(t, expectedDiff(trieD, trieC), diff(trieD, trieC))
+		require.Equal(t, expectedDiff(trieC, trieA), diff(trieC, trieA))
+	})
+
+	t.Run("wrong path size", func(t *testing.T) {
+		otherTrie, err := trie.NewEmptyMTrie(ReferenceImplPathByteSize + 1)
+		require.NoError(t, err)
+		err = trieA.Diff(otherTrie, func(ledger.Path, *ledger.Payload, *ledger.Payload) bool {
+			return true
+		})
+		require.Error(t, err)
+	})
+}
@@ -2,19 +2,26 @@ package wal
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"os"
 	"path"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/prometheus/tsdb/fileutil"
 
 	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common"
 	"github.com/onflow/flow-go/ledger/complete/mtrie"
 	"github.com/onflow/flow-go/ledger/complete/mtrie/flattener"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/node"
 	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
 	"github.com/onflow/flow-go/module/metrics"
 )
@@ -25,6 +32,36 @@ const MagicBytes uint16 = 0x2137
 const VersionV1 uint16 = 0x01
 const VersionV2 uint16 = 0x02
 
+// VersionV3 is the streaming checkpoint format. Nodes and tries are written and read
+// one at a time, and the file ends with a footer holding the number of nodes and tries
+// as well as a CRC32 checksum of the entire file. A V3 checkpoint is either a full
+// checkpoint, or a delta checkpoint which only contains the nodes added since the
+// checkpoint it is based on.
+//
+// Layout:
+//
+//	header: 2 bytes magic | 2 bytes version | 1 byte checkpoint type |
+//	        8 bytes base checkpoint number | 8 bytes number of nodes in the base checkpoints
+//	body:   sequence of records (1 byte tag | encoded node or trie), terminated by the end tag
+//	footer: 8 bytes number of nodes | 8 bytes number of tries | 4 bytes CRC32 (Castagnoli) checksum
+const VersionV3 uint16 = 0x03
+
+const (
+	checkpointTypeFull  uint8 = 0
+	checkpointTypeDelta uint8 = 1
+)
+
+const (
+	recordEnd  uint8 = 0
+	recordNode uint8 = 1
+	recordTrie uint8 = 2
+)
+
+const headerV3Size = 2 + 2 + 1 + 8 + 8
+const footerV3Size = 8 + 8 + 4
+
+var crc32Table = crc32.MakeTable(crc32.Castagnoli)
+
 const RootCheckpointFilename = "root.checkpoint"
 
 type Checkpointer struct {
@@ -32,6 +69,16 @@ type Checkpointer struct {
 	wal            *LedgerWAL
 	keyByteSize    int
 	forestCapacity int
+	maxDeltaChain  uint
+	hasher         common.TrieHasher
+}
+
+// checkpointHeader holds the information from the header of a checkpoint file
+type checkpointHeader struct {
+	version        uint16
+	delta          bool
+	baseCheckpoint int    // number of the checkpoint a delta checkpoint is based on
+	baseNodesCount uint64 // number of nodes in the checkpoints a delta checkpoint is based on
 }
 
 func NewCheckpointer(wal *LedgerWAL, keyByteSize int, forestCapacity int) *Checkpointer {
@@ -40,17 +87,32 @@ func NewCheckpointer(wal *LedgerWAL, keyByteSize int, forestCapacity int) *Check
 		wal:            wal,
 		keyByteSize:    keyByteSize,
 		forestCapacity: forestCapacity,
+		hasher:         common.DefaultTrieHasher,
 	}
 }
 
-// LatestCheckpoint returns number of latest checkpoint or -1 if there are no checkpoints
+// SetMaxDeltaChain enables delta checkpoints. Subsequent checkpoints only store the nodes
+// added since the previous checkpoint, until the previous checkpoint is based on maxDeltaChain
+// delta checkpoints, in which case a full checkpoint is created. 0 disables delta checkpoints.
+func (c *Checkpointer) SetMaxDeltaChain(maxDeltaChain uint) {
+	c.maxDeltaChain = maxDeltaChain
+}
+
+// SetTrieHasher sets the hasher of the checkpointed tries, which must match the hasher
+// of the tries in the WAL and previous checkpoints. By default, the default hasher is used.
+func (c *Checkpointer) SetTrieHasher(hasher common.TrieHasher) {
+	c.hasher = hasher
+}
+
+// LatestCheckpoint returns number of latest checkpoint or -1 if there are no checkpoints.
+// Delta checkpoints are only considered if all checkpoints they are based on are present.
 func (c *Checkpointer) LatestCheckpoint() (int, error) {
 
 	files, err := fileutil.ReadDir(c.dir)
 	if err != nil {
 		return -1, err
 	}
-	last := -1
+	checkpoints := make([]int, 0)
 	for _, fn := range files {
 		if !strings.HasPrefix(fn, checkpointFilenamePrefix) {
 			continue
@@ -61,10 +123,47 @@ func (c *Checkpointer) LatestCheckpoint() (int, error) {
 			continue
 		}
 
-		last = k
+		checkpoints = append(checkpoints, k)
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(checkpoints)))
+	for _, k := range checkpoints {
+		_, err := c.checkpointChain(k)
+		if errors.Is(err, os.ErrNotExist) {
+			// a checkpoint this delta checkpoint is based on is missing
+			continue
+		}
+		// other errors (e.g. a corrupted header) are reported when loading the checkpoint
+		return k, nil
+	}
+
+	return -1, nil
+}
+
+// checkpointChain returns the numbers of all checkpoints needed to load the given
+// checkpoint: a full checkpoint followed by the delta checkpoints based on it (if any).
+func (c *Checkpointer) checkpointChain(checkpoint int) ([]int, error) {
+	chain := []int{checkpoint}
+	for current := checkpoint; ; {
+		header, err := readCheckpointHeaderFile(path.Join(c.dir, NumberToFilename(current)))
+		if err != nil {
+			return nil, err
+		}
+		if !header.delta {
+			break
+		}
+		if header.baseCheckpoint >= current {
+			return nil, fmt.Errorf("delta checkpoint %d is based on a later checkpoint %d", current, header.baseCheckpoint)
+		}
+		current = header.baseCheckpoint
+		chain = append(chain, current)
 	}
 
-	return last, nil
+	// reverse, so the full checkpoint comes first
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain, nil
 }
 
 // NotCheckpointedSegments - returns numbers of segments which are not checkpointed yet,
@@ -104,7 +203,9 @@ func (c *Checkpointer) NotCheckpointedSegments() (from, to int, err error) {
 	return latestCheckpoint + 1, last, nil
 }
 
-// Checkpoint creates new checkpoint stopping at given segment
+// Checkpoint creates new checkpoint stopping at given segment.
+// If delta checkpoints are enabled, and the chain of the latest checkpoint is short enough,
+// the new checkpoint only stores the nodes added since the latest checkpoint.
 func (c *Checkpointer) Checkpoint(to int, targetWriter func() (io.WriteCloser, error)) error {
 
 	_, notCheckpointedTo, err := c.NotCheckpointedSegments()
@@ -127,39 +228,65 @@ func (c *Checkpointer) Checkpoint(to int, targetWriter func() (io.WriteCloser, e
 
 	forest, err := mtrie.NewForest(c.keyByteSize, c.dir, c.forestCapacity, &metrics.NoopCollector{}, func(evictedTrie *trie.MTrie) error {
 		return nil
-	})
+	}, mtrie.WithTrieHasher(c.hasher))
 	if err != nil {
 		return fmt.Errorf("cannot create Forest: %w", err)
 	}
 
-	err = c.wal.replay(0, to,
-		func(forestSequencing *flattener.FlattenedForest) error {
-			tries, err := flattener.RebuildTries(forestSequencing)
-			if err != nil {
-				return err
-			}
-			for _, t := range tries {
-				err := forest.AddTrie(t)
-				if err != nil {
-					return err
-				}
+	updateFn := func(update *ledger.TrieUpdate) error {
+		_, err := forest.Update(update)
+		return err
+	}
+	deleteFn := func(rootHash ledger.RootHash) error {
+		return nil
+	}
+
+	// header and node indices of the new checkpoint, if it is a delta checkpoint
+	var deltaHeader *checkpointHeader
+	var indexForNode flattener.NodeIndex
+
+	if latestCheckpoint == -1 {
+		err = c.wal.replay(0, to, forest.AddTries, updateFn, deleteFn, true)
+		if err != nil {
+			return fmt.Errorf("cannot replay WAL: %w", err)
+		}
+	} else {
+		chain, err := c.checkpointChain(latestCheckpoint)
+		if err != nil {
+			return fmt.Errorf("cannot resolve checkpoint %d: %w", latestCheckpoint, err)
+		}
+		tries, nodes, err := c.loadCheckpointChain(chain)
+		if err != nil {
+			return fmt.Errorf("cannot load checkpoint %d: %w", latestCheckpoint, err)
+		}
+		err = forest.AddTries(tries)
+		if err != nil {
+			return fmt.Errorf("cannot add tries of checkpoint %d: %w", latestCheckpoint, err)
+		}
+
+		// the new delta checkpoint would be based on len(chain)-1 deltas plus itself
+		if uint(len(chain)) <= c.maxDeltaChain {
+			deltaHeader = &checkpointHeader{
+				version:        VersionV3,
+				delta:          true,
+				baseCheckpoint: latestCheckpoint,
+				baseNodesCount: uint64(len(nodes) - 1), // -1 to account for 0 node meaning nil
 			}
-			return nil
-		},
-		func(update *ledger.TrieUpdate) error {
-			_, err := forest.Update(update)
-			return err
-		}, func(rootHash ledger.RootHash) error {
-			return nil
-		}, true)
+			indexForNode = flattener.NewNodeIndexFromNodes(nodes)
+		}
 
-	if err != nil {
-		return fmt.Errorf("cannot replay WAL: %w", err)
+		err = c.wal.replay(latestCheckpoint+1, to,
+			func(tries []*trie.MTrie) error {
+				return fmt.Errorf("unexpected checkpoint while replaying segments after checkpoint %d", latestCheckpoint)
+			}, updateFn, deleteFn, false)
+		if err != nil {
+			return fmt.Errorf("cannot replay WAL: %w", err)
+		}
 	}
 
-	forestSequencing, err := flattener.FlattenForest(forest)
+	tries, err := forest.GetTries()
 	if err != nil {
-		return fmt.Errorf("cannot get storables: %w", err)
+		return fmt.Errorf("cannot get tries: %w", err)
 	}
 
 	writer, err := targetWriter()
@@ -168,9 +295,10 @@ func (c *Checkpointer) Checkpoint(to int, targetWriter func() (io.WriteCloser, e
 	}
 	defer writer.Close()
 
-	err = StoreCheckpoint(forestSequencing, writer)
-
-	return err
+	if deltaHeader != nil {
+		return storeCheckpointV3(tries, indexForNode, deltaHeader, writer)
+	}
+	return StoreCheckpointTries(tries, writer)
 }
 
 func NumberToFilenamePart(n int) string {
@@ -233,6 +361,8 @@ func CreateCheckpointWriterForFile(filename string) (io.WriteCloser, error) {
 	}, nil
 }
 
+// StoreCheckpoint stores the flattened forest as a checkpoint in the non-streaming (V1) format.
+// Use StoreCheckpointTries to store tries without flattening the forest in memory first.
 func StoreCheckpoint(forestSequencing *flattener.FlattenedForest, writer io.WriteCloser) error {
 	storableNodes := forestSequencing.Nodes
 	storableTries := forestSequencing.Tries
@@ -268,6 +398,468 @@ func StoreCheckpoint(forestSequencing *flattener.FlattenedForest, writer io.Writ
 	return nil
 }
 
+// StoreCheckpointTries stores the given tries as a full checkpoint in the streaming (V3) format.
+// Nodes are encoded and written one at a time.
+func StoreCheckpointTries(tries []*trie.MTrie, writer io.Writer) error {
+	header := &checkpointHeader{
+		version: VersionV3,
+		delta:   false,
+	}
+	return storeCheckpointV3(tries, flattener.NewNodeIndex(), header, writer)
+}
+
+// storeCheckpointV3 writes a V3 checkpoint. Nodes which already have an index in indexForNode
+// are not written, for delta checkpoints these are the nodes of the base checkpoints.
+func storeCheckpointV3(tries []*trie.MTrie, indexForNode flattener.NodeIndex, header *checkpointHeader, writer io.Writer) error {
+	crc := crc32.New(crc32Table)
+	w := io.MultiWriter(writer, crc)
+
+	_, err := w.Write(encodeCheckpointHeaderV3(header))
+	if err != nil {
+		return fmt.Errorf("cannot write checkpoint header: %w", err)
+	}
+
+	nodesCount := uint64(0)
+	triesCount := uint64(0)
+	err = flattener.StreamTries(tries, indexForNode,
+		func(storableNode *flattener.StorableNode) error {
+			_, err := w.Write(append([]byte{recordNode}, flattener.EncodeStorableNode(storableNode)...))
+			if err != nil {
+				return fmt.Errorf("error while writing node date: %w", err)
+			}
+			nodesCount++
+			return nil
+		},
+		func(storableTrie *flattener.StorableTrie) error {
+			_, err := w.Write(append([]byte{recordTrie}, flattener.EncodeStorableTrie(storableTrie)...))
+			if err != nil {
+				return fmt.Errorf("error while writing trie date: %w", err)
+			}
+			triesCount++
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("cannot write checkpoint body: %w", err)
+	}
+
+	footer := make([]byte, 1+8+8)
+	footer[0] = recordEnd
+	pos := writeUint64(footer, 1, nodesCount)
+	writeUint64(footer, pos, triesCount)
+	_, err = w.Write(footer)
+	if err != nil {
+		return fmt.Errorf("cannot write checkpoint footer: %w", err)
+	}
+
+	checksum := make([]byte, 4)
+	binary.BigEndian.PutUint32(checksum, crc.Sum32())
+	_, err = writer.Write(checksum)
+	if err != nil {
+		return fmt.Errorf("cannot write checkpoint checksum: %w", err)
+	}
+
+	return nil
+}
+
+func encodeCheckpointHeaderV3(header *checkpointHeader) []byte {
+	buf := make([]byte, headerV3Size)
+	pos := writeUint16(buf, 0, MagicBytes)
+	pos = writeUint16(buf, pos, VersionV3)
+	if header.delta {
+		buf[pos] = checkpointTypeDelta
+	} else {
+		buf[pos] = checkpointTypeFull
+	}
+	pos = writeUint64(buf, pos+1, uint64(header.baseCheckpoint))
+	writeUint64(buf, pos, header.baseNodesCount)
+	return buf
+}
+
+// readCheckpointHeader reads the header of a checkpoint. For V1 and V2 checkpoints,
+// only the magic bytes and the version are consumed from the reader.
+func readCheckpointHeader(reader io.Reader) (*checkpointHeader, error) {
+	buf := make([]byte, headerV3Size)
+	_, err := io.ReadFull(reader, buf[:4])
+	if err != nil {
+		return nil, fmt.Errorf("cannot read header bytes: %w", err)
+	}
+
+	magicBytes, pos := readUint16(buf, 0)
+	version, _ := readUint16(buf, pos)
+
+	if magicBytes != MagicBytes {
+		return nil, fmt.Errorf("unknown file format. Magic constant %x does not match expected %x", magicBytes, MagicBytes)
+	}
+
+	header := &checkpointHeader{version: version}
+	switch version {
+	case VersionV1, VersionV2:
+		return header, nil
+	case VersionV3:
+	default:
+		return nil, fmt.Errorf("unsupported file version %x ", version)
+	}
+
+	_, err = io.ReadFull(reader, buf[4:])
+	if err != nil {
+		return nil, fmt.Errorf("cannot read header bytes: %w", err)
+	}
+	switch buf[4] {
+	case checkpointTypeFull:
+	case checkpointTypeDelta:
+		header.delta = true
+	default:
+		return nil, fmt.Errorf("unknown checkpoint type %d", buf[4])
+	}
+	baseCheckpoint, pos := readUint64(buf, 5)
+	header.baseCheckpoint = int(baseCheckpoint)
+	header.baseNodesCount, _ = readUint64(buf, pos)
+
+	return header, nil
+}
+
+func readCheckpointHeaderFile(filepath string) (*checkpointHeader, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open checkpoint file %s: %w", filepath, err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	header, err := readCheckpointHeader(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read header of checkpoint file %s: %w", filepath, err)
+	}
+	return header, nil
+}
+
+// readCheckpointV3Body reads the records and the footer of a V3 checkpoint following its header,
+// passing every node and trie to the given functions as soon as it is read.
+// The checksum covers the header as well, hence crc must already include the header bytes.
+func readCheckpointV3Body(reader byteReader, crc hashWriter,
+	nodeFn func(*flattener.StorableNode) error,
+	trieFn func(*flattener.StorableTrie) error) error {
+
+	r := io.TeeReader(reader, crc)
+	nodesCount := uint64(0)
+	triesCount := uint64(0)
+	tag := make([]byte, 1)
+
+	for {
+		_, err := io.ReadFull(r, tag)
+		if err != nil {
+			return fmt.Errorf("cannot read record tag: %w", err)
+		}
+
+		if tag[0] == recordEnd {
+			break
+		}
+
+		switch tag[0] {
+		case recordNode:
+			if triesCount > 0 {
+				return fmt.Errorf("unexpected node record after trie records")
+			}
+			storableNode, err := flattener.ReadStorableNode(r)
+			if err != nil {
+				return fmt.Errorf("cannot read storable node %d: %w", nodesCount+1, err)
+			}
+			err = nodeFn(storableNode)
+			if err != nil {
+				return fmt.Errorf("cannot process storable node %d: %w", nodesCount+1, err)
+			}
+			nodesCount++
+		case recordTrie:
+			storableTrie, err := flattener.ReadStorableTrie(r)
+			if err != nil {
+				return fmt.Errorf("cannot read storable trie %d: %w", triesCount, err)
+			}
+			err = trieFn(storableTrie)
+			if err != nil {
+				return fmt.Errorf("cannot process storable trie %d: %w", triesCount, err)
+			}
+			triesCount++
+		default:
+			return fmt.Errorf("unknown record tag %d", tag[0])
+		}
+	}
+
+	footer := make([]byte, footerV3Size)
+	_, err := io.ReadFull(r, footer[:16])
+	if err != nil {
+		return fmt.Errorf("cannot read footer: %w", err)
+	}
+	expectedChecksum := crc.Sum32()
+	_, err = io.ReadFull(reader, footer[16:])
+	if err != nil {
+		return fmt.Errorf("cannot read checksum: %w", err)
+	}
+
+	footerNodesCount, pos := readUint64(footer, 0)
+	footerTriesCount, pos := readUint64(footer, pos)
+	storedChecksum := binary.BigEndian.Uint32(footer[pos:])
+
+	if storedChecksum != expectedChecksum {
+		return fmt.Errorf("checksum mismatch: stored %x, computed %x", storedChecksum, expectedChecksum)
+	}
+	if footerNodesCount != nodesCount || footerTriesCount != triesCount {
+		return fmt.Errorf("footer expects %d nodes and %d tries, but found %d nodes and %d tries",
+			footerNodesCount, footerTriesCount, nodesCount, triesCount)
+	}
+
+	_, err = reader.ReadByte()
+	if err != io.EOF {
+		return fmt.Errorf("unexpected data after checkpoint footer")
+	}
+
+	return nil
+}
+
+// errReadingStopped is returned by the checkpoint reading goroutine when the consumer stopped early
+var errReadingStopped = errors.New("reading checkpoint stopped")
+
+// nodeBatchSize is the number of nodes passed at once from the reading goroutine to the consumer
+const nodeBatchSize = 1 << 14
+
+// checkpointRecords is either a batch of nodes or a trie, read from a V3 checkpoint
+type checkpointRecords struct {
+	nodes []*flattener.StorableNode
+	trie  *flattener.StorableTrie
+}
+
+// readCheckpointV3BodyPipelined reads the body of a V3 checkpoint like readCheckpointV3Body, but in a
+// separate goroutine. Nodes are passed to batchFn in batches, while the following nodes are being read.
+func readCheckpointV3BodyPipelined(reader byteReader, crc hashWriter,
+	batchFn func([]*flattener.StorableNode) error,
+	trieFn func(*flattener.StorableTrie) error) error {
+
+	records := make(chan checkpointRecords, 2)
+	quit := make(chan struct{})
+	readErr := make(chan error, 1)
+
+	go func() {
+		defer close(records)
+
+		send := func(r checkpointRecords) error {
+			select {
+			case records <- r:
+				return nil
+			case <-quit:
+				return errReadingStopped
+			}
+		}
+
+		batch := make([]*flattener.StorableNode, 0, nodeBatchSize)
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			err := send(checkpointRecords{nodes: batch})
+			batch = make([]*flattener.StorableNode, 0, nodeBatchSize)
+			return err
+		}
+
+		err := readCheckpointV3Body(reader, crc,
+			func(storableNode *flattener.StorableNode) error {
+				batch = append(batch, storableNode)
+				if len(batch) == nodeBatchSize {
+					return flush()
+				}
+				return nil
+			},
+			func(storableTrie *flattener.StorableTrie) error {
+				err := flush()
+				if err != nil {
+					return err
+				}
+				return send(checkpointRecords{trie: storableTrie})
+			})
+		if err == nil {
+			err = flush()
+		}
+		readErr <- err
+	}()
+
+	// stop and wait for the reading goroutine, as the reader must not be used after returning
+	defer func() {
+		close(quit)
+		for range records {
+		}
+	}()
+
+	for r := range records {
+		var err error
+		if r.trie != nil {
+			err = trieFn(r.trie)
+		} else {
+			err = batchFn(r.nodes)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return <-readErr
+}
+
+// byteReader is the reader a V3 checkpoint body is read from
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// hashWriter is the subset of hash.Hash32 needed for computing checkpoint checksums
+type hashWriter interface {
+	io.Writer
+	Sum32() uint32
+}
+
+// LoadCheckpointTries loads the tries stored in the given checkpoint. Delta checkpoints are
+// resolved by loading the checkpoints they are based on first.
+func (c *Checkpointer) LoadCheckpointTries(checkpoint int) ([]*trie.MTrie, error) {
+	chain, err := c.checkpointChain(checkpoint)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve checkpoint %d: %w", checkpoint, err)
+	}
+	tries, _, err := c.loadCheckpointChain(chain)
+	return tries, err
+}
+
+// LoadRootCheckpointTries loads the tries stored in the root checkpoint
+func (c *Checkpointer) LoadRootCheckpointTries() ([]*trie.MTrie, error) {
+	return LoadCheckpointTries(path.Join(c.dir, RootCheckpointFilename))
+}
+
+// loadCheckpointChain loads the given chain of checkpoints and returns the tries of the last
+// checkpoint, as well as all nodes of the chain indexed as in the checkpoint files.
+func (c *Checkpointer) loadCheckpointChain(chain []int) ([]*trie.MTrie, []*node.Node, error) {
+	nodes := []*node.Node{nil} // 0th element is nil
+	var tries []*trie.MTrie
+	var err error
+	for i, checkpoint := range chain {
+		tries, nodes, err = loadCheckpointFileTries(path.Join(c.dir, NumberToFilename(checkpoint)), nodes, i > 0, c.wal.concurrency)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot load checkpoint %d: %w", checkpoint, err)
+		}
+	}
+	return tries, nodes, nil
+}
+
+// LoadCheckpointTries loads the tries stored in a full checkpoint file.
+// For V3 checkpoints, nodes are decoded and rebuilt while reading the file.
+func LoadCheckpointTries(filepath string) ([]*trie.MTrie, error) {
+	tries, _, err := loadCheckpointFileTries(filepath, []*node.Node{nil}, false, runtime.NumCPU())
+	return tries, err
+}
+
+// loadCheckpointFileTries loads the tries from a checkpoint file. If delta is set, the file must be a
+// delta checkpoint based on the given nodes, otherwise it must be a full checkpoint.
+// Nodes are rebuilt concurrently by the given number of workers, 1 loads the checkpoint sequentially.
+func loadCheckpointFileTries(filepath string, nodes []*node.Node, delta bool, workers int) ([]*trie.MTrie, []*node.Node, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot open checkpoint file %s: %w", filepath, err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	reader := bufio.NewReader(file)
+	crc := crc32.New(crc32Table)
+
+	header, err := readCheckpointHeader(io.TeeReader(reader, crc))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if header.delta != delta {
+		return nil, nil, fmt.Errorf("checkpoint file %s: expected delta checkpoint %v, got %v", filepath, delta, header.delta)
+	}
+
+	if header.version != VersionV3 {
+		flattenedForest, err := readFlattenedForestV1(reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		rootIndices := make([]uint64, 0, len(flattenedForest.Tries))
+		for _, storableTrie := range flattenedForest.Tries {
+			rootIndices = append(rootIndices, storableTrie.RootIndex)
+		}
+		nodes, err := flattener.RebuildNodesParallel(flattenedForest.Nodes, rootIndices, workers)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reconstructing nodes from storables failed: %w", err)
+		}
+		tries := make([]*trie.MTrie, 0, len(flattenedForest.Tries))
+		for _, storableTrie := range flattenedForest.Tries {
+			t, err := rebuildTrie(storableTrie, nodes)
+			if err != nil {
+				return nil, nil, err
+			}
+			tries = append(tries, t)
+		}
+		return tries, nodes, nil
+	}
+
+	if !delta {
+		nodes = []*node.Node{nil}
+	} else if header.baseNodesCount != uint64(len(nodes)-1) {
+		return nil, nil, fmt.Errorf("delta checkpoint expects %d base nodes, but %d nodes were loaded", header.baseNodesCount, len(nodes)-1)
+	}
+
+	tries := make([]*trie.MTrie, 0)
+	trieFn := func(storableTrie *flattener.StorableTrie) error {
+		t, err := rebuildTrie(storableTrie, nodes)
+		if err != nil {
+			return err
+		}
+		tries = append(tries, t)
+		return nil
+	}
+
+	if workers > 1 {
+		err = readCheckpointV3BodyPipelined(reader, crc,
+			func(batch []*flattener.StorableNode) error {
+				rebuilt, err := flattener.RebuildNodeBatch(batch, nodes, workers)
+				if err != nil {
+					return err
+				}
+				nodes = rebuilt
+				return nil
+			},
+			trieFn)
+	} else {
+		err = readCheckpointV3Body(reader, crc,
+			func(storableNode *flattener.StorableNode) error {
+				n, err := flattener.RebuildNode(storableNode, nodes)
+				if err != nil {
+					return err
+				}
+				nodes = append(nodes, n)
+				return nil
+			},
+			trieFn)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read checkpoint file %s: %w", filepath, err)
+	}
+
+	return tries, nodes, nil
+}
+
+func rebuildTrie(storableTrie *flattener.StorableTrie, nodes []*node.Node) (*trie.MTrie, error) {
+	if storableTrie.RootIndex >= uint64(len(nodes)) {
+		return nil, fmt.Errorf("trie references unknown root node %d", storableTrie.RootIndex)
+	}
+	t, err := trie.NewMTrie(nodes[storableTrie.RootIndex])
+	if err != nil {
+		return nil, fmt.Errorf("restoring trie failed: %w", err)
+	}
+	if !bytes.Equal(storableTrie.RootHash, t.RootHash()) {
+		return nil, fmt.Errorf("restoring trie failed: roothash doesn't match")
+	}
+	return t, nil
+}
+
 func (c *Checkpointer) LoadCheckpoint(checkpoint int) (*flattener.FlattenedForest, error) {
 	filepath := path.Join(c.dir, NumberToFilename(checkpoint))
 	return LoadCheckpoint(filepath)
@@ -288,6 +880,9 @@ func (c *Checkpointer) HasRootCheckpoint() (bool, error) {
 	}
 }
 
+// LoadCheckpoint loads a full checkpoint file as a FlattenedForest.
+// CAUTION: this holds all storable nodes in memory, use LoadCheckpointTries
+// to rebuild the tries while reading the nodes.
 func LoadCheckpoint(filepath string) (*flattener.FlattenedForest, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
@@ -298,51 +893,106 @@ func LoadCheckpoint(filepath string) (*flattener.FlattenedForest, error) {
 	}()
 
 	reader := bufio.NewReader(file)
+	crc := crc32.New(crc32Table)
 
-	header := make([]byte, 4+8+2)
-
-	_, err = io.ReadFull(reader, header)
+	header, err := readCheckpointHeader(io.TeeReader(reader, crc))
 	if err != nil {
-		return nil, fmt.Errorf("cannot read header bytes: %w", err)
+		return nil, err
 	}
 
-	magicBytes, pos := readUint16(header, 0)
-	version, pos := readUint16(header, pos)
-	nodesCount, pos := readUint64(header, pos)
-	triesCount, _ := readUint16(header, pos)
+	if header.version != VersionV3 {
+		return readFlattenedForestV1(reader)
+	}
 
-	if magicBytes != MagicBytes {
-		return nil, fmt.Errorf("unknown file format. Magic constant %x does not match expected %x", magicBytes, MagicBytes)
+	if header.delta {
+		return nil, fmt.Errorf("checkpoint file %s is a delta checkpoint, load it with a Checkpointer", filepath)
 	}
-	if version != VersionV1 && version != VersionV2 {
-		return nil, fmt.Errorf("unsupported file version %x ", version)
+
+	nodes := []*flattener.StorableNode{nil} // 0th element is nil
+	tries := make([]*flattener.StorableTrie, 0)
+	err = readCheckpointV3Body(reader, crc,
+		func(storableNode *flattener.StorableNode) error {
+			nodes = append(nodes, storableNode)
+			return nil
+		},
+		func(storableTrie *flattener.StorableTrie) error {
+			tries = append(tries, storableTrie)
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("cannot read checkpoint file %s: %w", filepath, err)
+	}
+
+	return &flattener.FlattenedForest{
+		Nodes: nodes,
+		Tries: tries,
+	}, nil
+}
+
+// readCheckpointV1 reads a V1 or V2 checkpoint following the magic bytes and version,
+// passing every node and trie to the given functions as soon as it is read.
+func readCheckpointV1(reader io.Reader,
+	nodeFn func(*flattener.StorableNode) error,
+	trieFn func(*flattener.StorableTrie) error) error {
+
+	header := make([]byte, 8+2)
+
+	_, err := io.ReadFull(reader, header)
+	if err != nil {
+		return fmt.Errorf("cannot read header bytes: %w", err)
 	}
 
-	nodes := make([]*flattener.StorableNode, nodesCount+1) //+1 for 0 index meaning nil
-	tries := make([]*flattener.StorableTrie, triesCount)
+	nodesCount, pos := readUint64(header, 0)
+	triesCount, _ := readUint16(header, pos)
 
 	for i := uint64(1); i <= nodesCount; i++ {
 		storableNode, err := flattener.ReadStorableNode(reader)
 		if err != nil {
-			return nil, fmt.Errorf("cannot read storable node %d: %w", i, err)
+			return fmt.Errorf("cannot read storable node %d: %w", i, err)
+		}
+		err = nodeFn(storableNode)
+		if err != nil {
+			return fmt.Errorf("cannot process storable node %d: %w", i, err)
 		}
-		nodes[i] = storableNode
 	}
 
 	// TODO version ?
 	for i := uint16(0); i < triesCount; i++ {
 		storableTrie, err := flattener.ReadStorableTrie(reader)
 		if err != nil {
-			return nil, fmt.Errorf("cannot read storable trie %d: %w", i, err)
+			return fmt.Errorf("cannot read storable trie %d: %w", i, err)
 		}
-		tries[i] = storableTrie
+		err = trieFn(storableTrie)
+		if err != nil {
+			return fmt.Errorf("cannot process storable trie %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// readFlattenedForestV1 reads a V1 or V2 checkpoint following the magic bytes and version
+func readFlattenedForestV1(reader io.Reader) (*flattener.FlattenedForest, error) {
+	nodes := []*flattener.StorableNode{nil} // 0th element is nil
+	tries := make([]*flattener.StorableTrie, 0)
+
+	err := readCheckpointV1(reader,
+		func(storableNode *flattener.StorableNode) error {
+			nodes = append(nodes, storableNode)
+			return nil
+		},
+		func(storableTrie *flattener.StorableTrie) error {
+			tries = append(tries, storableTrie)
+			return nil
+		})
+	if err != nil {
+		return nil, err
 	}
 
 	return &flattener.FlattenedForest{
 		Nodes: nodes,
 		Tries: tries,
 	}, nil
-
 }
 
 func writeUint16(buffer []byte, location int, value uint16) int {
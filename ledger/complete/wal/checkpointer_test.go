@@ -3,6 +3,8 @@ package wal_test
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
 	"path"
 	"testing"
 	"time"
@@ -201,7 +203,7 @@ func Test_Checkpointing(t *testing.T) {
 			require.NoError(t, err)
 
 			err = wal2.Replay(
-				func(forestSequencing *flattener.FlattenedForest) error {
+				func(tries []*trie.MTrie) error {
 					return fmt.Errorf("I should fail as there should be no checkpoints")
 				},
 				func(update *ledger.TrieUpdate) error {
@@ -236,8 +238,8 @@ func Test_Checkpointing(t *testing.T) {
 			require.NoError(t, err)
 
 			err = wal3.Replay(
-				func(forestSequencing *flattener.FlattenedForest) error {
-					return loadIntoForest(f3, forestSequencing)
+				func(tries []*trie.MTrie) error {
+					return loadIntoForest(f3, tries)
 				},
 				func(update *ledger.TrieUpdate) error {
 					return fmt.Errorf("I should fail as there should be no updates")
@@ -324,8 +326,8 @@ func Test_Checkpointing(t *testing.T) {
 			updatesLeft := 1 // there should be only one update
 
 			err = wal5.Replay(
-				func(forestSequencing *flattener.FlattenedForest) error {
-					return loadIntoForest(f5, forestSequencing)
+				func(tries []*trie.MTrie) error {
+					return loadIntoForest(f5, tries)
 				},
 				func(update *ledger.TrieUpdate) error {
 					if updatesLeft == 0 {
@@ -366,11 +368,7 @@ func Test_Checkpointing(t *testing.T) {
 	})
 }
 
-func loadIntoForest(forest *mtrie.Forest, forestSequencing *flattener.FlattenedForest) error {
-	tries, err := flattener.RebuildTries(forestSequencing)
-	if err != nil {
-		return err
-	}
+func loadIntoForest(forest *mtrie.Forest, tries []*trie.MTrie) error {
 	for _, t := range tries {
 		err := forest.AddTrie(t)
 		if err != nil {
@@ -379,3 +377,317 @@ func loadIntoForest(forest *mtrie.Forest, forestSequencing *flattener.FlattenedF
 	}
 	return nil
 }
+
+// recordUpdates records n random updates in the WAL, applies them to the forest
+// and returns the root hash of the last updated trie
+func recordUpdates(t *testing.T, wal *realWAL.LedgerWAL, f *mtrie.Forest, rootHash ledger.State, n int) ledger.State {
+	for i := 0; i < n; i++ {
+		keys := utils.RandomUniqueKeys(numInsPerStep, keyNumberOfParts, 1600, 1600)
+		values := utils.RandomValues(numInsPerStep, 1, valueMaxByteSize)
+		update, err := ledger.NewUpdate(rootHash, keys, values)
+		require.NoError(t, err)
+
+		trieUpdate, err := pathfinder.UpdateToTrieUpdate(update, pathFinderVersion)
+		require.NoError(t, err)
+
+		err = wal.RecordUpdate(trieUpdate)
+		require.NoError(t, err)
+
+		newRootHash, err := f.Update(trieUpdate)
+		require.NoError(t, err)
+		rootHash = ledger.State(newRootHash)
+	}
+	return rootHash
+}
+
+// checkpointAll checkpoints all segments of the WAL and returns the number of the checkpoint
+func checkpointAll(t *testing.T, wal *realWAL.LedgerWAL, maxDeltaChain uint) int {
+	checkpointer, err := wal.NewCheckpointer()
+	require.NoError(t, err)
+	checkpointer.SetMaxDeltaChain(maxDeltaChain)
+
+	_, to, err := checkpointer.NotCheckpointedSegments()
+	require.NoError(t, err)
+
+	err = checkpointer.Checkpoint(to, func() (io.WriteCloser, error) {
+		return checkpointer.CheckpointWriter(to)
+	})
+	require.NoError(t, err)
+	return to
+}
+
+func requireSameTries(t *testing.T, expected []*trie.MTrie, actual []*trie.MTrie) {
+	require.Equal(t, len(expected), len(actual))
+	expectedRootHashes := make(map[string]struct{}, len(expected))
+	for _, tr := range expected {
+		expectedRootHashes[string(tr.RootHash())] = struct{}{}
+	}
+	for _, tr := range actual {
+		require.Contains(t, expectedRootHashes, string(tr.RootHash()))
+		require.True(t, tr.IsAValidTrie())
+	}
+}
+
+func Test_CheckpointV3(t *testing.T) {
+	unittest.RunWithTempDir(t, func(dir string) {
+
+		f, err := mtrie.NewForest(pathByteSize, dir, size*10, metricsCollector, func(tree *trie.MTrie) error { return nil })
+		require.NoError(t, err)
+
+		rootHash := ledger.State(f.GetEmptyRootHash())
+		for i := 0; i < 3; i++ {
+			keys := utils.RandomUniqueKeys(10, keyNumberOfParts, keyPartMinByteSize, keyPartMaxByteSize)
+			values := utils.RandomValues(10, 1, 100)
+			update, err := ledger.NewUpdate(rootHash, keys, values)
+			require.NoError(t, err)
+			trieUpdate, err := pathfinder.UpdateToTrieUpdate(update, pathFinderVersion)
+			require.NoError(t, err)
+			newRootHash, err := f.Update(trieUpdate)
+			require.NoError(t, err)
+			rootHash = ledger.State(newRootHash)
+		}
+		tries, err := f.GetTries()
+		require.NoError(t, err)
+
+		filePath := path.Join(dir, "checkpoint.v3")
+
+		t.Run("store and load", func(t *testing.T) {
+			writer, err := realWAL.CreateCheckpointWriterForFile(filePath)
+			require.NoError(t, err)
+			err = realWAL.StoreCheckpointTries(tries, writer)
+			require.NoError(t, err)
+			err = writer.Close()
+			require.NoError(t, err)
+
+			loadedTries, err := realWAL.LoadCheckpointTries(filePath)
+			require.NoError(t, err)
+			requireSameTries(t, tries, loadedTries)
+
+			flattenedForest, err := realWAL.LoadCheckpoint(filePath)
+			require.NoError(t, err)
+			rebuiltTries, err := flattener.RebuildTries(flattenedForest)
+			require.NoError(t, err)
+			requireSameTries(t, tries, rebuiltTries)
+		})
+
+		t.Run("load legacy checkpoint", func(t *testing.T) {
+			legacyFilePath := path.Join(dir, "checkpoint.v1")
+			flattenedForest, err := flattener.FlattenForest(f)
+			require.NoError(t, err)
+
+			writer, err := realWAL.CreateCheckpointWriterForFile(legacyFilePath)
+			require.NoError(t, err)
+			err = realWAL.StoreCheckpoint(flattenedForest, writer)
+			require.NoError(t, err)
+			err = writer.Close()
+			require.NoError(t, err)
+
+			loadedTries, err := realWAL.LoadCheckpointTries(legacyFilePath)
+			require.NoError(t, err)
+			requireSameTries(t, tries, loadedTries)
+		})
+
+		t.Run("detect corruption", func(t *testing.T) {
+			data, err := ioutil.ReadFile(filePath)
+			require.NoError(t, err)
+
+			// flip a bit in the middle of the body
+			corrupted := append([]byte{}, data...)
+			corrupted[len(corrupted)/2] ^= 0x01
+			corruptedFilePath := path.Join(dir, "checkpoint.corrupted")
+			err = ioutil.WriteFile(corruptedFilePath, corrupted, 0644)
+			require.NoError(t, err)
+
+			_, err = realWAL.LoadCheckpointTries(corruptedFilePath)
+			require.Error(t, err)
+
+			// cut off the footer
+			truncatedFilePath := path.Join(dir, "checkpoint.truncated")
+			err = ioutil.WriteFile(truncatedFilePath, data[:len(data)-10], 0644)
+			require.NoError(t, err)
+
+			_, err = realWAL.LoadCheckpointTries(truncatedFilePath)
+			require.Error(t, err)
+		})
+	})
+}
+
+func Test_DeltaCheckpoints(t *testing.T) {
+	unittest.RunWithTempDir(t, func(dir string) {
+
+		f, err := mtrie.NewForest(pathByteSize, dir, size*10, metricsCollector, func(tree *trie.MTrie) error { return nil })
+		require.NoError(t, err)
+		rootHash := ledger.State(f.GetEmptyRootHash())
+
+		var fullCheckpoint, deltaCheckpoint, nextFullCheckpoint int
+
+		t.Run("first checkpoint is a full checkpoint", func(t *testing.T) {
+			wal, err := realWAL.NewWAL(nil, nil, dir, size*10, pathByteSize, segmentSize)
+			require.NoError(t, err)
+			rootHash = recordUpdates(t, wal, f, rootHash, 3)
+
+			fullCheckpoint = checkpointAll(t, wal, 1)
+			err = wal.Close()
+			require.NoError(t, err)
+
+			_, err = realWAL.LoadCheckpoint(path.Join(dir, realWAL.NumberToFilename(fullCheckpoint)))
+			require.NoError(t, err)
+		})
+
+		t.Run("delta checkpoint", func(t *testing.T) {
+			wal, err := realWAL.NewWAL(nil, nil, dir, size*10, pathByteSize, segmentSize)
+			require.NoError(t, err)
+			rootHash = recordUpdates(t, wal, f, rootHash, 3)
+
+			deltaCheckpoint = checkpointAll(t, wal, 1)
+			require.Greater(t, deltaCheckpoint, fullCheckpoint)
+
+			// a delta checkpoint cannot be loaded on its own
+			_, err = realWAL.LoadCheckpoint(path.Join(dir, realWAL.NumberToFilename(deltaCheckpoint)))
+			require.Error(t, err)
+
+			checkpointer, err := wal.NewCheckpointer()
+			require.NoError(t, err)
+			loadedTries, err := checkpointer.LoadCheckpointTries(deltaCheckpoint)
+			require.NoError(t, err)
+
+			tries, err := f.GetTries()
+			require.NoError(t, err)
+			requireSameTries(t, tries, loadedTries)
+
+			err = wal.Close()
+			require.NoError(t, err)
+		})
+
+		t.Run("replay delta checkpoint", func(t *testing.T) {
+			wal, err := realWAL.NewWAL(nil, nil, dir, size*10, pathByteSize, segmentSize)
+			require.NoError(t, err)
+
+			f2, err := mtrie.NewForest(pathByteSize, dir, size*10, metricsCollector, func(tree *trie.MTrie) error { return nil })
+			require.NoError(t, err)
+			err = wal.ReplayOnForest(f2)
+			require.NoError(t, err)
+
+			tries, err := f.GetTries()
+			require.NoError(t, err)
+			replayedTries, err := f2.GetTries()
+			require.NoError(t, err)
+			requireSameTries(t, tries, replayedTries)
+
+			err = wal.Close()
+			require.NoError(t, err)
+		})
+
+		t.Run("full checkpoint when delta chain is too long", func(t *testing.T) {
+			wal, err := realWAL.NewWAL(nil, nil, dir, size*10, pathByteSize, segmentSize)
+			require.NoError(t, err)
+			rootHash = recordUpdates(t, wal, f, rootHash, 3)
+
+			nextFullCheckpoint = checkpointAll(t, wal, 1)
+			err = wal.Close()
+			require.NoError(t, err)
+
+			loadedTries, err := realWAL.LoadCheckpointTries(path.Join(dir, realWAL.NumberToFilename(nextFullCheckpoint)))
+			require.NoError(t, err)
+
+			tries, err := f.GetTries()
+			require.NoError(t, err)
+			requireSameTries(t, tries, loadedTries)
+		})
+
+		t.Run("delta checkpoint without base is skipped", func(t *testing.T) {
+			err := os.Remove(path.Join(dir, realWAL.NumberToFilename(fullCheckpoint)))
+			require.NoError(t, err)
+
+			wal, err := realWAL.NewWAL(nil, nil, dir, size*10, pathByteSize, segmentSize)
+			require.NoError(t, err)
+			checkpointer, err := wal.NewCheckpointer()
+			require.NoError(t, err)
+
+			latest, err := checkpointer.LatestCheckpoint()
+			require.NoError(t, err)
+			require.Equal(t, nextFullCheckpoint, latest)
+
+			err = os.Remove(path.Join(dir, realWAL.NumberToFilename(nextFullCheckpoint)))
+			require.NoError(t, err)
+
+			latest, err = checkpointer.LatestCheckpoint()
+			require.NoError(t, err)
+			require.Equal(t, -1, latest)
+
+			err = wal.Close()
+			require.NoError(t, err)
+		})
+	})
+}
+
+func Test_ReplayConcurrency(t *testing.T) {
+	unittest.RunWithTempDir(t, func(dir string) {
+
+		f, err := mtrie.NewForest(pathByteSize, dir, size*10, metricsCollector, func(tree *trie.MTrie) error { return nil })
+		require.NoError(t, err)
+		rootHash := ledger.State(f.GetEmptyRootHash())
+
+		// checkpointed updates, followed by updates only in the WAL
+		wal, err := realWAL.NewWAL(nil, nil, dir, size*10, pathByteSize, segmentSize)
+		require.NoError(t, err)
+		rootHash = recordUpdates(t, wal, f, rootHash, 5)
+		checkpointAll(t, wal, 0)
+		err = wal.Close()
+		require.NoError(t, err)
+
+		wal, err = realWAL.NewWAL(nil, nil, dir, size*10, pathByteSize, segmentSize)
+		require.NoError(t, err)
+		recordUpdates(t, wal, f, rootHash, 5)
+		err = wal.Close()
+		require.NoError(t, err)
+
+		expectedTries, err := f.GetTries()
+		require.NoError(t, err)
+
+		for _, concurrency := range []int{1, 4} {
+			t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+				wal, err := realWAL.NewWAL(nil, nil, dir, size*10, pathByteSize, segmentSize)
+				require.NoError(t, err)
+				wal.SetConcurrency(concurrency)
+
+				f2, err := mtrie.NewForest(pathByteSize, dir, size*10, metricsCollector, func(tree *trie.MTrie) error { return nil })
+				require.NoError(t, err)
+				err = wal.ReplayOnForest(f2)
+				require.NoError(t, err)
+
+				tries, err := f2.GetTries()
+				require.NoError(t, err)
+				requireSameTries(t, expectedTries, tries)
+
+				err = wal.Close()
+				require.NoError(t, err)
+			})
+		}
+
+		t.Run("update errors stop replaying", func(t *testing.T) {
+			wal, err := realWAL.NewWAL(nil, nil, dir, size*10, pathByteSize, segmentSize)
+			require.NoError(t, err)
+			wal.SetConcurrency(4)
+
+			updates := 0
+			err = wal.ReplayLogsOnly(
+				func(tries []*trie.MTrie) error {
+					return nil
+				},
+				func(update *ledger.TrieUpdate) error {
+					updates++
+					return fmt.Errorf("update failed")
+				},
+				func(rootHash ledger.RootHash) error {
+					return nil
+				},
+			)
+			require.Error(t, err)
+			require.Equal(t, 1, updates)
+
+			err = wal.Close()
+			require.NoError(t, err)
+		})
+	})
+}
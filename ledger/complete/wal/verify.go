@@ -0,0 +1,242 @@
+package wal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path"
+
+	prometheusWAL "github.com/prometheus/tsdb/wal"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/flattener"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/node"
+)
+
+// CheckpointCorruptionErr is returned when verifying a corrupted checkpoint file.
+// Offset is the position of the first corrupted record (node or trie) in the file.
+type CheckpointCorruptionErr struct {
+	File   string
+	Offset int64
+	Err    error
+}
+
+func (e *CheckpointCorruptionErr) Error() string {
+	return fmt.Sprintf("corruption in checkpoint %s at %d: %s", e.File, e.Offset, e.Err)
+}
+
+func (e *CheckpointCorruptionErr) Unwrap() error {
+	return e.Err
+}
+
+// VerifyCheckpoint verifies the integrity of the checkpoint with the given number, and of the
+// checkpoints it is based on in case of a delta checkpoint. All nodes are rebuilt and the hash
+// of every node is recomputed with the checkpointer's trie hasher. It returns the root hashes of
+// the tries stored in the checkpoint. Corruption is reported as *CheckpointCorruptionErr.
+func (c *Checkpointer) VerifyCheckpoint(checkpoint int) ([]ledger.RootHash, error) {
+	chain, err := c.checkpointChain(checkpoint)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve checkpoint %d: %w", checkpoint, err)
+	}
+
+	nodes := []*node.Node{nil} // 0th element is nil
+	var rootHashes []ledger.RootHash
+	for i, n := range chain {
+		rootHashes, nodes, err = verifyCheckpointFile(path.Join(c.dir, NumberToFilename(n)), nodes, i > 0, c.hasher)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rootHashes, nil
+}
+
+// VerifyRootCheckpoint verifies the integrity of the root checkpoint, see VerifyCheckpoint
+func (c *Checkpointer) VerifyRootCheckpoint() ([]ledger.RootHash, error) {
+	rootHashes, _, err := verifyCheckpointFile(path.Join(c.dir, RootCheckpointFilename), []*node.Node{nil}, false, c.hasher)
+	return rootHashes, err
+}
+
+// VerifyCheckpointFile verifies the integrity of a full checkpoint file, see VerifyCheckpoint.
+// The trie hasher is detected from the first node, and all nodes must be hashed with it.
+func VerifyCheckpointFile(filepath string) ([]ledger.RootHash, error) {
+	rootHashes, _, err := verifyCheckpointFile(filepath, []*node.Node{nil}, false, nil)
+	return rootHashes, err
+}
+
+// verifyCheckpointFile verifies the nodes of a checkpoint file with the given hasher,
+// or with the hasher detected from the first node if the hasher is nil
+func verifyCheckpointFile(filepath string, nodes []*node.Node, delta bool, hasher common.TrieHasher) ([]ledger.RootHash, []*node.Node, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot open checkpoint file %s: %w", filepath, err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	reader := &countingReader{reader: bufio.NewReader(file)}
+	crc := crc32.New(crc32Table)
+
+	// offset of the record currently being read
+	recordOffset := int64(0)
+	corruption := func(err error) error {
+		var cerr *CheckpointCorruptionErr
+		if errors.As(err, &cerr) {
+			return err
+		}
+		return &CheckpointCorruptionErr{File: filepath, Offset: recordOffset, Err: err}
+	}
+
+	header, err := readCheckpointHeader(io.TeeReader(reader, crc))
+	if err != nil {
+		return nil, nil, corruption(err)
+	}
+	if header.delta != delta {
+		return nil, nil, fmt.Errorf("checkpoint file %s: expected delta checkpoint %v, got %v", filepath, delta, header.delta)
+	}
+	if header.version == VersionV3 {
+		if !delta {
+			nodes = []*node.Node{nil}
+		} else if header.baseNodesCount != uint64(len(nodes)-1) {
+			return nil, nil, corruption(fmt.Errorf("delta checkpoint expects %d base nodes, but %d nodes were loaded", header.baseNodesCount, len(nodes)-1))
+		}
+	}
+
+	rootHashes := make([]ledger.RootHash, 0)
+	recordOffset = reader.offset
+	nodeFn := func(storableNode *flattener.StorableNode) error {
+		n, err := flattener.RebuildNode(storableNode, nodes)
+		if err != nil {
+			return corruption(fmt.Errorf("cannot rebuild node %d: %w", len(nodes), err))
+		}
+		if hasher == nil {
+			hasher, _ = n.DetectHasher()
+		}
+		if hasher == nil || !n.VerifyOwnHash(hasher) {
+			return corruption(fmt.Errorf("hash of node %d does not match its content", len(nodes)))
+		}
+		nodes = append(nodes, n)
+		recordOffset = reader.offset
+		return nil
+	}
+	trieFn := func(storableTrie *flattener.StorableTrie) error {
+		t, err := rebuildTrie(storableTrie, nodes)
+		if err != nil {
+			return corruption(err)
+		}
+		rootHashes = append(rootHashes, t.RootHash())
+		recordOffset = reader.offset
+		return nil
+	}
+
+	if header.version != VersionV3 {
+		nodes = []*node.Node{nil}
+		err = readCheckpointV1(reader, nodeFn, trieFn)
+		if err == nil {
+			recordOffset = reader.offset
+			_, err = reader.ReadByte()
+			if err != io.EOF {
+				return nil, nil, corruption(fmt.Errorf("unexpected data after last trie"))
+			}
+			err = nil
+		}
+	} else {
+		err = readCheckpointV3Body(reader, crc, nodeFn, trieFn)
+	}
+	if err != nil {
+		return nil, nil, corruption(err)
+	}
+
+	return rootHashes, nodes, nil
+}
+
+// countingReader counts the number of bytes read
+type countingReader struct {
+	reader *bufio.Reader
+	offset int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *countingReader) ReadByte() (byte, error) {
+	b, err := r.reader.ReadByte()
+	if err == nil {
+		r.offset++
+	}
+	return b, err
+}
+
+// Verify reads and decodes all records of the WAL. It returns the number of valid records and,
+// if a torn record, garbage or an undecodable record is found, the corruption error locating it.
+// Records following a corruption are not read.
+func (w *LedgerWAL) Verify() (int, *prometheusWAL.CorruptionErr, error) {
+	first, last, err := w.wal.Segments()
+	if err != nil {
+		return 0, nil, fmt.Errorf("cannot get range of segments: %w", err)
+	}
+	if first == -1 && last == -1 {
+		return 0, nil, nil
+	}
+
+	sr, err := prometheusWAL.NewSegmentsRangeReader(prometheusWAL.SegmentRange{
+		Dir:   w.wal.Dir(),
+		First: first,
+		Last:  last,
+	})
+	if err != nil {
+		return 0, nil, fmt.Errorf("cannot create segment reader: %w", err)
+	}
+	defer sr.Close()
+
+	reader := prometheusWAL.NewReader(sr)
+
+	records := 0
+	segment, offset := reader.Segment(), reader.Offset()
+	for reader.Next() {
+		_, _, _, err := Decode(reader.Record())
+		if err != nil {
+			start := offset
+			if reader.Segment() != segment {
+				start = 0
+			}
+			// the offset of the corruption is the end of the record, so truncating
+			// the WAL at the corruption keeps all records preceding this one
+			return records, &prometheusWAL.CorruptionErr{
+				Dir:     w.wal.Dir(),
+				Segment: reader.Segment(),
+				Offset:  reader.Offset(),
+				Err:     fmt.Errorf("cannot decode record starting at %d: %w", start, err),
+			}, nil
+		}
+		records++
+		segment, offset = reader.Segment(), reader.Offset()
+	}
+
+	err = reader.Err()
+	if err != nil {
+		var cerr *prometheusWAL.CorruptionErr
+		if errors.As(err, &cerr) {
+			return records, cerr, nil
+		}
+		return records, nil, fmt.Errorf("cannot read LedgerWAL: %w", err)
+	}
+	return records, nil, nil
+}
+
+// Truncate truncates the WAL to the last valid record preceding the given corruption,
+// as returned by Verify. All segments following the corrupted segment are deleted.
+func (w *LedgerWAL) Truncate(corruption *prometheusWAL.CorruptionErr) error {
+	err := w.wal.Repair(corruption)
+	if err != nil {
+		return fmt.Errorf("cannot truncate LedgerWAL: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package wal
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common/utils"
+	"github.com/onflow/flow-go/ledger/complete/mtrie"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/flattener"
+	"github.com/onflow/flow-go/module/metrics"
+	"github.com/onflow/flow-go/utils/unittest"
+)
+
+func Test_VerifyCheckpoint(t *testing.T) {
+	unittest.RunWithTempDir(t, func(dir string) {
+
+		f, err := mtrie.NewForest(4, dir, 10, &metrics.NoopCollector{}, nil)
+		require.NoError(t, err)
+		rootHash := f.GetEmptyRootHash()
+		for i := 0; i < 3; i++ {
+			update := &ledger.TrieUpdate{RootHash: rootHash, Paths: utils.RandomPaths(20, 4), Payloads: utils.RandomPayloads(20, 10, 20)}
+			rootHash, err = f.Update(update)
+			require.NoError(t, err)
+		}
+		tries, err := f.GetTries()
+		require.NoError(t, err)
+
+		t.Run("valid checkpoint", func(t *testing.T) {
+			filePath := path.Join(dir, "checkpoint.v3")
+			writer, err := CreateCheckpointWriterForFile(filePath)
+			require.NoError(t, err)
+			err = StoreCheckpointTries(tries, writer)
+			require.NoError(t, err)
+			err = writer.Close()
+			require.NoError(t, err)
+
+			rootHashes, err := VerifyCheckpointFile(filePath)
+			require.NoError(t, err)
+			require.Len(t, rootHashes, len(tries))
+			for i, tr := range tries {
+				require.Equal(t, ledger.RootHash(tr.RootHash()), rootHashes[i])
+			}
+		})
+
+		t.Run("corrupted node", func(t *testing.T) {
+			flattenedForest, err := flattener.FlattenForest(f)
+			require.NoError(t, err)
+
+			filePath := path.Join(dir, "checkpoint.v1")
+			writer, err := CreateCheckpointWriterForFile(filePath)
+			require.NoError(t, err)
+			err = StoreCheckpoint(flattenedForest, writer)
+			require.NoError(t, err)
+			err = writer.Close()
+			require.NoError(t, err)
+
+			// find the offset of the first leaf, and corrupt the last byte of its payload
+			offset := int64(4 + 8 + 2)
+			var corrupted int64
+			for i := 1; i < len(flattenedForest.Nodes); i++ {
+				storableNode := flattenedForest.Nodes[i]
+				encoded := flattener.EncodeStorableNode(storableNode)
+				if len(storableNode.Path) > 0 {
+					corrupted = offset + int64(len(encoded)-2-len(storableNode.HashValue)-1)
+					break
+				}
+				offset += int64(len(encoded))
+			}
+
+			data, err := ioutil.ReadFile(filePath)
+			require.NoError(t, err)
+			data[corrupted] ^= 0x01
+			err = ioutil.WriteFile(filePath, data, 0644)
+			require.NoError(t, err)
+
+			_, err = VerifyCheckpointFile(filePath)
+			var cerr *CheckpointCorruptionErr
+			require.True(t, errors.As(err, &cerr))
+			require.Equal(t, offset, cerr.Offset)
+		})
+	})
+}
+
+func Test_VerifyWAL(t *testing.T) {
+
+	recordUpdates := func(t *testing.T, wal *LedgerWAL, n int) {
+		for i := 0; i < n; i++ {
+			update := &ledger.TrieUpdate{RootHash: utils.RootHashFixture(), Paths: utils.RandomPaths(2, 4), Payloads: utils.RandomPayloads(2, 10, 20)}
+			err := wal.RecordUpdate(update)
+			require.NoError(t, err)
+		}
+	}
+
+	countUpdates := func(t *testing.T, dir string) int {
+		wal, err := NewWAL(nil, nil, dir, 10, 4, 32*1024)
+		require.NoError(t, err)
+		defer wal.Close()
+
+		records, corruption, err := wal.Verify()
+		require.NoError(t, err)
+		require.Nil(t, corruption)
+		return records
+	}
+
+	t.Run("valid WAL", func(t *testing.T) {
+		unittest.RunWithTempDir(t, func(dir string) {
+			wal, err := NewWAL(nil, nil, dir, 10, 4, 32*1024)
+			require.NoError(t, err)
+			recordUpdates(t, wal, 5)
+			err = wal.Close()
+			require.NoError(t, err)
+
+			require.Equal(t, 5, countUpdates(t, dir))
+		})
+	})
+
+	t.Run("undecodable record is truncated", func(t *testing.T) {
+		unittest.RunWithTempDir(t, func(dir string) {
+			wal, err := NewWAL(nil, nil, dir, 10, 4, 32*1024)
+			require.NoError(t, err)
+			recordUpdates(t, wal, 3)
+			err = wal.wal.Log([]byte{0xff, 0x00, 0x00, 0x00})
+			require.NoError(t, err)
+			recordUpdates(t, wal, 2)
+			err = wal.Close()
+			require.NoError(t, err)
+
+			wal, err = NewWAL(nil, nil, dir, 10, 4, 32*1024)
+			require.NoError(t, err)
+			records, corruption, err := wal.Verify()
+			require.NoError(t, err)
+			require.Equal(t, 3, records)
+			require.NotNil(t, corruption)
+			require.Equal(t, 0, corruption.Segment)
+
+			err = wal.Truncate(corruption)
+			require.NoError(t, err)
+			err = wal.Close()
+			require.NoError(t, err)
+
+			require.Equal(t, 3, countUpdates(t, dir))
+		})
+	})
+
+	t.Run("torn record is detected", func(t *testing.T) {
+		unittest.RunWithTempDir(t, func(dir string) {
+			wal, err := NewWAL(nil, nil, dir, 10, 4, 32*1024)
+			require.NoError(t, err)
+			recordUpdates(t, wal, 3)
+			err = wal.Close()
+			require.NoError(t, err)
+
+			// cut the segment in the middle of the last record, skipping the zero padding of the page
+			segment := path.Join(dir, "00000000")
+			data, err := ioutil.ReadFile(segment)
+			require.NoError(t, err)
+			end := len(data)
+			for end > 0 && data[end-1] == 0 {
+				end--
+			}
+			err = os.Truncate(segment, int64(end-5))
+			require.NoError(t, err)
+
+			wal, err = NewWAL(nil, nil, dir, 10, 4, 32*1024)
+			require.NoError(t, err)
+
+			records, corruption, err := wal.Verify()
+			require.NoError(t, err)
+			require.Equal(t, 2, records)
+			require.NotNil(t, corruption)
+
+			err = wal.Truncate(corruption)
+			require.NoError(t, err)
+			err = wal.Close()
+			require.NoError(t, err)
+
+			require.Equal(t, 2, countUpdates(t, dir))
+		})
+	})
+}
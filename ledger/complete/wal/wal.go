@@ -2,6 +2,8 @@ package wal
 
 import (
 	"fmt"
+	"runtime"
+	"sync"
 
 	"github.com/go-kit/kit/log"
 	"github.com/prometheus/client_golang/prometheus"
@@ -9,7 +11,7 @@ import (
 
 	"github.com/onflow/flow-go/ledger"
 	"github.com/onflow/flow-go/ledger/complete/mtrie"
-	"github.com/onflow/flow-go/ledger/complete/mtrie/flattener"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
 )
 
 const SegmentSize = 32 * 1024 * 1024
@@ -19,6 +21,7 @@ type LedgerWAL struct {
 	paused         bool
 	forestCapacity int
 	pathByteSize   int
+	concurrency    int
 }
 
 // TODO use real logger and metrics, but that would require passing them to Trie storage
@@ -32,9 +35,16 @@ func NewWAL(logger log.Logger, reg prometheus.Registerer, dir string, forestCapa
 		paused:         false,
 		forestCapacity: forestCapacity,
 		pathByteSize:   pathByteSize,
+		concurrency:    runtime.NumCPU(),
 	}, nil
 }
 
+// SetConcurrency sets the number of goroutines used for decoding WAL records and
+// rebuilding checkpoint nodes during replay. 1 replays the WAL sequentially.
+func (w *LedgerWAL) SetConcurrency(concurrency int) {
+	w.concurrency = concurrency
+}
+
 func (w *LedgerWAL) PauseRecord() {
 	w.paused = true
 }
@@ -75,12 +85,8 @@ func (w *LedgerWAL) RecordDelete(rootHash ledger.RootHash) error {
 
 func (w *LedgerWAL) ReplayOnForest(forest *mtrie.Forest) error {
 	return w.Replay(
-		func(forestSequencing *flattener.FlattenedForest) error {
-			rebuiltTries, err := flattener.RebuildTries(forestSequencing)
-			if err != nil {
-				return fmt.Errorf("rebuilding forest from sequenced nodes failed: %w", err)
-			}
-			err = forest.AddTries(rebuiltTries)
+		func(tries []*trie.MTrie) error {
+			err := forest.AddTries(tries)
 			if err != nil {
 				return fmt.Errorf("adding rebuilt tries to forest failed: %w", err)
 			}
@@ -98,7 +104,7 @@ func (w *LedgerWAL) ReplayOnForest(forest *mtrie.Forest) error {
 }
 
 func (w *LedgerWAL) Replay(
-	checkpointFn func(forestSequencing *flattener.FlattenedForest) error,
+	checkpointFn func(tries []*trie.MTrie) error,
 	updateFn func(update *ledger.TrieUpdate) error,
 	deleteFn func(ledger.RootHash) error,
 ) error {
@@ -110,7 +116,7 @@ func (w *LedgerWAL) Replay(
 }
 
 func (w *LedgerWAL) ReplayLogsOnly(
-	checkpointFn func(forestSequencing *flattener.FlattenedForest) error,
+	checkpointFn func(tries []*trie.MTrie) error,
 	updateFn func(update *ledger.TrieUpdate) error,
 	deleteFn func(rootHash ledger.RootHash) error,
 ) error {
@@ -123,7 +129,7 @@ func (w *LedgerWAL) ReplayLogsOnly(
 
 func (w *LedgerWAL) replay(
 	from, to int,
-	checkpointFn func(forestSequencing *flattener.FlattenedForest) error,
+	checkpointFn func(tries []*trie.MTrie) error,
 	updateFn func(update *ledger.TrieUpdate) error,
 	deleteFn func(rootHash ledger.RootHash) error,
 	useCheckpoints bool,
@@ -148,11 +154,11 @@ func (w *LedgerWAL) replay(
 		}
 
 		if latestCheckpoint != -1 && latestCheckpoint+1 >= from { //+1 to account for connected checkpoint and segments
-			forestSequencing, err := checkpointer.LoadCheckpoint(latestCheckpoint)
+			tries, err := checkpointer.LoadCheckpointTries(latestCheckpoint)
 			if err != nil {
 				return fmt.Errorf("cannot load checkpoint %d: %w", latestCheckpoint, err)
 			}
-			err = checkpointFn(forestSequencing)
+			err = checkpointFn(tries)
 			if err != nil {
 				return fmt.Errorf("error while handling checkpoint: %w", err)
 			}
@@ -174,11 +180,11 @@ func (w *LedgerWAL) replay(
 			return fmt.Errorf("cannot check root checkpoint existence: %w", err)
 		}
 		if hasRootCheckpoint {
-			flattenedForest, err := checkpointer.LoadRootCheckpoint()
+			tries, err := checkpointer.LoadRootCheckpointTries()
 			if err != nil {
 				return fmt.Errorf("cannot load root checkpoint: %w", err)
 			}
-			err = checkpointFn(flattenedForest)
+			err = checkpointFn(tries)
 			if err != nil {
 				return fmt.Errorf("error while handling root checkpoint: %w", err)
 			}
@@ -198,6 +204,17 @@ func (w *LedgerWAL) replay(
 
 	defer sr.Close()
 
+	if w.concurrency <= 1 {
+		return replayRecords(reader, updateFn, deleteFn)
+	}
+	return replayRecordsPipelined(reader, w.concurrency, updateFn, deleteFn)
+}
+
+func replayRecords(
+	reader *prometheusWAL.Reader,
+	updateFn func(update *ledger.TrieUpdate) error,
+	deleteFn func(rootHash ledger.RootHash) error,
+) error {
 	for reader.Next() {
 		record := reader.Record()
 		operation, rootHash, update, err := Decode(record)
@@ -205,17 +222,9 @@ func (w *LedgerWAL) replay(
 			return fmt.Errorf("cannot decode LedgerWAL record: %w", err)
 		}
 
-		switch operation {
-		case WALUpdate:
-			err = updateFn(update)
-			if err != nil {
-				return fmt.Errorf("error while processing LedgerWAL update: %w", err)
-			}
-		case WALDelete:
-			err = deleteFn(rootHash)
-			if err != nil {
-				return fmt.Errorf("error while processing LedgerWAL deletion: %w", err)
-			}
+		err = applyRecord(operation, rootHash, update, updateFn, deleteFn)
+		if err != nil {
+			return err
 		}
 
 		err = reader.Err()
@@ -226,6 +235,112 @@ func (w *LedgerWAL) replay(
 	return nil
 }
 
+// decodedRecord is a LedgerWAL record passing through the replay pipeline
+type decodedRecord struct {
+	data      []byte
+	operation WALOperation
+	rootHash  ledger.RootHash
+	update    *ledger.TrieUpdate
+	err       error
+	decoded   chan struct{} // closed once the record is decoded
+}
+
+// replayRecordsPipelined replays records like replayRecords, but decodes records while previous
+// records are applied. Records are read sequentially, decoded concurrently by the given number
+// of workers, and applied in their original order.
+func replayRecordsPipelined(
+	reader *prometheusWAL.Reader,
+	workers int,
+	updateFn func(update *ledger.TrieUpdate) error,
+	deleteFn func(rootHash ledger.RootHash) error,
+) error {
+	toDecode := make(chan *decodedRecord, workers)
+	toApply := make(chan *decodedRecord, 2*workers)
+	quit := make(chan struct{})
+	readErr := make(chan error, 1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for record := range toDecode {
+				record.operation, record.rootHash, record.update, record.err = Decode(record.data)
+				record.data = nil
+				close(record.decoded)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(toDecode)
+		defer close(toApply)
+		readErr <- func() error {
+			for reader.Next() {
+				// the reader reuses its buffer, hence the record has to be copied
+				data := make([]byte, len(reader.Record()))
+				copy(data, reader.Record())
+
+				record := &decodedRecord{data: data, decoded: make(chan struct{})}
+				select {
+				case toApply <- record:
+				case <-quit:
+					return nil
+				}
+				toDecode <- record
+
+				err := reader.Err()
+				if err != nil {
+					return fmt.Errorf("cannot read LedgerWAL: %w", err)
+				}
+			}
+			return nil
+		}()
+	}()
+
+	// stop reading, and wait for the reader and the workers to finish, as the
+	// reader must not be used anymore once replaying is done
+	defer func() {
+		close(quit)
+		wg.Wait()
+	}()
+
+	for record := range toApply {
+		<-record.decoded
+		if record.err != nil {
+			return fmt.Errorf("cannot decode LedgerWAL record: %w", record.err)
+		}
+
+		err := applyRecord(record.operation, record.rootHash, record.update, updateFn, deleteFn)
+		if err != nil {
+			return err
+		}
+	}
+	return <-readErr
+}
+
+func applyRecord(
+	operation WALOperation,
+	rootHash ledger.RootHash,
+	update *ledger.TrieUpdate,
+	updateFn func(update *ledger.TrieUpdate) error,
+	deleteFn func(rootHash ledger.RootHash) error,
+) error {
+	switch operation {
+	case WALUpdate:
+		err := updateFn(update)
+		if err != nil {
+			return fmt.Errorf("error while processing LedgerWAL update: %w", err)
+		}
+	case WALDelete:
+		err := deleteFn(rootHash)
+		if err != nil {
+			return fmt.Errorf("error while processing LedgerWAL deletion: %w", err)
+		}
+	}
+	return nil
+}
+
 // NewCheckpointer returns a Checkpointer for this WAL
 func (w *LedgerWAL) NewCheckpointer() (*Checkpointer, error) {
 	return NewCheckpointer(w, w.pathByteSize, w.forestCapacity), nil
@@ -30,6 +30,10 @@ type Ledger interface {
 
 	// Prove returns proofs for the given keys at specific state
 	Prove(query *Query) (proof Proof, err error)
+
+	// Iterate returns the keys and values of registers at specific state in path order,
+	// starting at the query's start path, up to the query's limit
+	Iterate(query *IterationQuery) (result *IterationResult, err error)
 }
 
 // Query holds all data needed for a ledger read or ledger proof
@@ -68,6 +72,54 @@ func (q *Query) SetState(s State) {
 	q.state = s
 }
 
+// IterationQuery holds all data needed for iterating over the registers of a ledger state.
+// Registers are returned in the order of their paths, which is not the order of their keys.
+type IterationQuery struct {
+	state    State
+	start    Path
+	limit    int
+	keyParts []KeyPart
+}
+
+// NewIterationQuery constructs a new ledger iteration query. Iteration starts at the given
+// path (nil for the first register) and returns at most limit registers (0 for no limit).
+// If keyParts are given, only registers whose keys contain all of these key parts are returned,
+// e.g. the key part of an owner to iterate over the registers of a single account.
+func NewIterationQuery(sc State, start Path, limit int, keyParts []KeyPart) (*IterationQuery, error) {
+	if limit < 0 {
+		return nil, fmt.Errorf("limit must not be negative, got %d", limit)
+	}
+	return &IterationQuery{state: sc, start: start, limit: limit, keyParts: keyParts}, nil
+}
+
+// State returns the state part of the query
+func (q *IterationQuery) State() State {
+	return q.state
+}
+
+// Start returns the path the iteration starts at, nil for the first register
+func (q *IterationQuery) Start() Path {
+	return q.start
+}
+
+// Limit returns the maximum number of registers returned, 0 for no limit
+func (q *IterationQuery) Limit() int {
+	return q.limit
+}
+
+// KeyParts returns the key parts all returned keys must contain
+func (q *IterationQuery) KeyParts() []KeyPart {
+	return q.keyParts
+}
+
+// IterationResult holds the registers returned by a ledger iteration.
+// If not all registers were returned, Next holds the start path of the next iteration.
+type IterationResult struct {
+	Keys   []Key
+	Values []Value
+	Next   Path
+}
+
 // Update holds all data needed for a ledger update
 type Update struct {
 	state  State
@@ -210,6 +262,23 @@ func (k *Key) Equals(other *Key) bool {
 	return true
 }
 
+// HasKeyParts returns true if the key contains all of the given key parts
+func (k *Key) HasKeyParts(keyParts []KeyPart) bool {
+	for i := range keyParts {
+		found := false
+		for j := range k.KeyParts {
+			if k.KeyParts[j].Equals(&keyParts[i]) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 // KeyPart is a typed part of a key
 type KeyPart struct {
 	Type  uint16
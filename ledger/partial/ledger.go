@@ -22,6 +22,8 @@ type Ledger struct {
 }
 
 // NewLedger creates a new in-memory trie-backed ledger storage with persistence.
+// The proof can be either a regular or a compact encoded batch proof. The proofs must use
+// the trie hasher of the path finder version (see pathfinder.TrieHasher).
 func NewLedger(proof ledger.Proof, s ledger.State, pathFinderVer uint8) (*Ledger, error) {
 
 	// Decode proof encodings
@@ -41,6 +43,14 @@ func NewLedger(proof ledger.Proof, s ledger.State, pathFinderVer uint8) (*Ledger
 		return nil, ledger.NewErrLedgerConstruction(err)
 	}
 
+	hasher, err := pathfinder.TrieHasher(pathFinderVer)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get trie hasher: %w", err)
+	}
+	if batchProof.Size() > 0 && psmt.Hasher().Type() != hasher.Type() {
+		return nil, ledger.NewErrLedgerConstruction(fmt.Errorf("proofs use trie hasher %s, but path finder version %d uses %s", psmt.Hasher().Type(), pathFinderVer, hasher.Type()))
+	}
+
 	return &Ledger{ptrie: psmt, proof: proof, state: s, pathFinderVersion: pathFinderVer}, nil
 }
 
@@ -97,6 +107,12 @@ func (l *Ledger) Get(query *ledger.Query) (values []ledger.Value, err error) {
 	return values, err
 }
 
+// Iterate is not supported by the partial ledger, as it only holds the registers
+// included in its proofs and can't tell which registers are missing in between
+func (l *Ledger) Iterate(_ *ledger.IterationQuery) (*ledger.IterationResult, error) {
+	return nil, fmt.Errorf("iteration is not supported by the partial ledger")
+}
+
 // Set updates the ledger given an update
 // it returns the state after update and errors (if any)
 func (l *Ledger) Set(update *ledger.Update) (newState ledger.State, err error) {
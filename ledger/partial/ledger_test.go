@@ -8,6 +8,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common"
+	"github.com/onflow/flow-go/ledger/common/encoding"
 	"github.com/onflow/flow-go/ledger/common/utils"
 	"github.com/onflow/flow-go/ledger/complete"
 	"github.com/onflow/flow-go/ledger/partial"
@@ -66,3 +68,104 @@ func TestFunctionalityWithCompleteTrie(t *testing.T) {
 
 	})
 }
+
+func TestCompactProofWithCompleteTrie(t *testing.T) {
+	unittest.RunWithTempDir(t, func(dbDir string) {
+
+		l, err := complete.NewLedger(dbDir, 100, &metrics.NoopCollector{}, zerolog.Logger{}, nil, complete.DefaultPathFinderVersion)
+		require.NoError(t, err)
+		l.EnableCompactProofs()
+
+		state := l.InitialState()
+		keys := utils.RandomUniqueKeys(60, 2, 2, 4)
+		values := utils.RandomValues(60, 1, 32)
+		update, err := ledger.NewUpdate(state, keys[0:50], values[0:50])
+		require.NoError(t, err)
+
+		newState, err := l.Set(update)
+		require.NoError(t, err)
+
+		// query allocated, unallocated and duplicated keys
+		queryKeys := append(keys[40:60], keys[45])
+		query, err := ledger.NewQuery(newState, queryKeys)
+		require.NoError(t, err)
+		proof, err := l.Prove(query)
+		require.NoError(t, err)
+
+		// the proof is encoded compactly
+		cbp, err := encoding.DecodeTrieCompactBatchProof(proof)
+		require.NoError(t, err)
+		require.True(t, common.VerifyTrieCompactBatchProof(cbp, newState))
+
+		bp, err := encoding.DecodeTrieBatchProof(proof)
+		require.NoError(t, err)
+		require.Len(t, bp.Proofs, len(queryKeys))
+		regularSize := 0
+		for _, p := range bp.Proofs {
+			regularSize += len(encoding.EncodeTrieProof(p))
+		}
+		require.Less(t, len(proof), regularSize)
+
+		pled, err := partial.NewLedger(proof, newState, partial.DefaultPathFinderVersion)
+		require.NoError(t, err)
+		assert.Equal(t, pled.InitialState(), newState)
+
+		retValues, err := pled.Get(query)
+		require.NoError(t, err)
+		expected, err := l.Get(query)
+		require.NoError(t, err)
+		require.Equal(t, expected, retValues)
+	})
+}
+
+func TestTrieHasherWithCompleteTrie(t *testing.T) {
+	unittest.RunWithTempDir(t, func(dbDir string) {
+
+		// path finder version 2 hashes the trie with blake2b
+		l, err := complete.NewLedger(dbDir, 100, &metrics.NoopCollector{}, zerolog.Logger{}, nil, 2)
+		require.NoError(t, err)
+
+		state := l.InitialState()
+		require.NotEqual(t, ledger.State(common.GetDefaultHashForHeight(8*len(state))), state)
+
+		keys := utils.RandomUniqueKeys(10, 2, 2, 4)
+		values := utils.RandomValues(10, 1, 32)
+		update, err := ledger.NewUpdate(state, keys[0:5], values[0:5])
+		require.NoError(t, err)
+		newState, err := l.Set(update)
+		require.NoError(t, err)
+
+		query, err := ledger.NewQuery(newState, keys)
+		require.NoError(t, err)
+		proof, err := l.Prove(query)
+		require.NoError(t, err)
+
+		bp, err := encoding.DecodeTrieBatchProof(proof)
+		require.NoError(t, err)
+		for _, p := range bp.Proofs {
+			require.Equal(t, ledger.TrieHasherBlake2b, p.Hasher)
+		}
+		require.True(t, common.VerifyTrieBatchProof(bp, newState))
+
+		pled, err := partial.NewLedger(proof, newState, 2)
+		require.NoError(t, err)
+
+		expected, err := l.Get(query)
+		require.NoError(t, err)
+		retValues, err := pled.Get(query)
+		require.NoError(t, err)
+		require.Equal(t, expected, retValues)
+
+		update, err = ledger.NewUpdate(newState, keys[5:], values[5:])
+		require.NoError(t, err)
+		expectedState, err := l.Set(update)
+		require.NoError(t, err)
+		partialState, err := pled.Set(update)
+		require.NoError(t, err)
+		require.Equal(t, expectedState, partialState)
+
+		// proofs must use the hasher of the path finder version
+		_, err = partial.NewLedger(proof, newState, partial.DefaultPathFinderVersion)
+		require.Error(t, err)
+	})
+}
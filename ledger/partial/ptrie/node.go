@@ -36,24 +36,24 @@ func newNode(hashValue []byte, height int) *node {
 }
 
 // ComputeValue recomputes value for this node in recursive manner
-func (n *node) HashValue() []byte {
+func (n *node) HashValue(hasher common.TrieHasher) []byte {
 	// leaf node
 	if n.lChild == nil && n.rChild == nil {
 		if n.hashValue != nil {
 			return n.hashValue
 		}
-		return common.GetDefaultHashForHeight(n.height)
+		return hasher.GetDefaultHashForHeight(n.height)
 	}
 	// otherwise compute
-	h1 := common.GetDefaultHashForHeight(n.height - 1)
+	h1 := hasher.GetDefaultHashForHeight(n.height - 1)
 	if n.lChild != nil {
-		h1 = n.lChild.HashValue()
+		h1 = n.lChild.HashValue(hasher)
 	}
-	h2 := common.GetDefaultHashForHeight(n.height - 1)
+	h2 := hasher.GetDefaultHashForHeight(n.height - 1)
 	if n.rChild != nil {
-		h2 = n.rChild.HashValue()
+		h2 = n.rChild.HashValue(hasher)
 	}
 	// For debugging purpose uncomment this
 	// n.value = HashInterNode(h1, h2)
-	return common.HashInterNode(h1, h2)
+	return hasher.HashInterNode(h1, h2)
 }
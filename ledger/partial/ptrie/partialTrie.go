@@ -19,10 +19,12 @@ import (
 //   * HEIGHT of a node v in a tree is the number of edges on the longest downward path
 //     between v and a tree leaf. The height of a tree is the heights of its root.
 //     The height of a Trie is always the height of the fully-expanded tree.
+//   * All nodes are hashed with the hasher recorded in the proofs the PSMT is built from.
 type PSMT struct {
 	root         *node // Root
 	pathByteSize int   // expected size [bytes] of path
 	pathLookUp   map[string]*node
+	hasher       common.TrieHasher
 }
 
 // PathSize returns the expected expected size [bytes] of path
@@ -32,7 +34,12 @@ func (p *PSMT) PathSize() int {
 
 // RootHash returns the rootNode hash value of the SMT
 func (p *PSMT) RootHash() []byte {
-	return p.root.HashValue()
+	return p.root.HashValue(p.hasher)
+}
+
+// Hasher returns the hasher of the SMT's nodes
+func (p *PSMT) Hasher() common.TrieHasher {
+	return p.hasher
 }
 
 // Get returns an slice of payloads (same order), an slice of failed paths and errors (if any)
@@ -69,16 +76,17 @@ func (p *PSMT) Update(paths []ledger.Path, payloads []*ledger.Payload) ([]byte,
 			failedKeys = append(failedKeys, payload.Key)
 			continue
 		}
-		node.hashValue = common.ComputeCompactValue(path, payload, node.height)
+		node.hashValue = p.hasher.ComputeCompactValue(path, payload, node.height)
 	}
 	if len(failedKeys) > 0 {
 		return nil, &ledger.ErrMissingKeys{Keys: failedKeys}
 	}
 	// after updating all the nodes, compute the value recursively only once
-	return p.root.HashValue(), nil
+	return p.root.HashValue(p.hasher), nil
 }
 
-// NewPSMT builds a Partial Sparse Merkle Tree (PMST) given a chunkdatapack registertouches
+// NewPSMT builds a Partial Sparse Merkle Tree (PMST) given a chunkdatapack registertouches.
+// All proofs must use the same trie hasher (the default hasher is used if there are no proofs).
 // TODO just accept batch proof as input
 func NewPSMT(
 	rootValue []byte, // rootHash
@@ -89,7 +97,15 @@ func NewPSMT(
 	if pathByteSize < 1 {
 		return nil, errors.New("trie's path size [in bytes] must be positive")
 	}
-	psmt := PSMT{newNode(nil, pathByteSize*8), pathByteSize, make(map[string]*node)}
+	hasher := common.DefaultTrieHasher
+	if batchProof.Size() > 0 {
+		var err error
+		hasher, err = common.NewTrieHasher(batchProof.Proofs[0].Hasher)
+		if err != nil {
+			return nil, err
+		}
+	}
+	psmt := PSMT{newNode(nil, pathByteSize*8), pathByteSize, make(map[string]*node), hasher}
 
 	paths := batchProof.Paths()
 	payloads := batchProof.Payloads()
@@ -111,6 +127,10 @@ func NewPSMT(
 		if len(path) != pathByteSize {
 			return nil, fmt.Errorf("path [%x] size (%d) doesn't match the expected value (%d)", path, len(path), pathByteSize)
 		}
+		// check trie hasher
+		if pr.Hasher != hasher.Type() {
+			return nil, fmt.Errorf("proof of path [%x] uses trie hasher %s, expected %s", path, pr.Hasher, hasher.Type())
+		}
 
 		// we keep track of our progress through proofs by proofIndex
 		prValueIndex := 0
@@ -122,7 +142,7 @@ func NewPSMT(
 		for j := 0; j < int(pr.Steps); j++ {
 			// if a flag (bit j in flags) is false, the value is a default value
 			// otherwise the value is stored in the proofs
-			v := common.GetDefaultHashForHeight(currentNode.height - 1)
+			v := hasher.GetDefaultHashForHeight(currentNode.height - 1)
 			flagIsSet, err := utils.IsBitSet(pr.Flags, j)
 			if err != nil {
 				return nil, err
@@ -166,7 +186,7 @@ func NewPSMT(
 		currentNode.path = path
 		// update node's hashvalue only for inclusion proofs (for others we assume default value)
 		if pr.Inclusion {
-			currentNode.hashValue = common.ComputeCompactValue(path, payload, currentNode.height)
+			currentNode.hashValue = hasher.ComputeCompactValue(path, payload, currentNode.height)
 		}
 		// keep a reference to this node by path (for update purpose)
 		psmt.pathLookUp[string(path)] = currentNode
@@ -174,8 +194,8 @@ func NewPSMT(
 	}
 
 	// check if the rootHash matches the root node's hash value of the partial trie
-	if !bytes.Equal(psmt.root.HashValue(), rootValue) {
-		return nil, fmt.Errorf("rootNode hash doesn't match the proofs expected [%x], got [%x]", psmt.root.HashValue(), rootValue)
+	if !bytes.Equal(psmt.RootHash(), rootValue) {
+		return nil, fmt.Errorf("rootNode hash doesn't match the proofs expected [%x], got [%x]", psmt.RootHash(), rootValue)
 	}
 	return &psmt, nil
 }
@@ -52,7 +52,7 @@ func TestPartialTrieEmptyTrie(t *testing.T) {
 		psmt, err := NewPSMT(rootHash, pathByteSize, bp)
 
 		require.NoError(t, err, "error building partial trie")
-		if !bytes.Equal(rootHash, psmt.root.HashValue()) {
+		if !bytes.Equal(rootHash, psmt.RootHash()) {
 			t.Fatal("rootNode hash doesn't match [before set]")
 		}
 		u := &ledger.TrieUpdate{RootHash: rootHash, Paths: paths, Payloads: payloads}
@@ -62,7 +62,7 @@ func TestPartialTrieEmptyTrie(t *testing.T) {
 		_, err = psmt.Update(paths, payloads)
 		require.NoError(t, err, "error updating psmt")
 
-		if !bytes.Equal(rootHash, psmt.root.HashValue()) {
+		if !bytes.Equal(rootHash, psmt.RootHash()) {
 			t.Fatal("rootNode hash doesn't match [after set]")
 		}
 
@@ -76,7 +76,7 @@ func TestPartialTrieEmptyTrie(t *testing.T) {
 		_, err = psmt.Update(paths, payloads)
 		require.NoError(t, err, "error updating psmt")
 
-		if !bytes.Equal(rootHash, psmt.root.HashValue()) {
+		if !bytes.Equal(rootHash, psmt.RootHash()) {
 			t.Fatal("rootNode hash doesn't match [after update]")
 		}
 
@@ -110,7 +110,7 @@ func TestPartialTrieLeafUpdates(t *testing.T) {
 		psmt, err := NewPSMT(rootHash, pathByteSize, bp)
 		require.NoError(t, err, "error building partial trie")
 
-		if !bytes.Equal(rootHash, psmt.root.HashValue()) {
+		if !bytes.Equal(rootHash, psmt.RootHash()) {
 			t.Fatal("rootNode hash doesn't match [before update]")
 		}
 
@@ -121,7 +121,7 @@ func TestPartialTrieLeafUpdates(t *testing.T) {
 		_, err = psmt.Update(paths, payloads)
 		require.NoError(t, err, "error updating psmt")
 
-		if !bytes.Equal(rootHash, psmt.root.HashValue()) {
+		if !bytes.Equal(rootHash, psmt.RootHash()) {
 			t.Fatal("rootNode hash doesn't match [after update]")
 		}
 	})
@@ -155,7 +155,7 @@ func TestPartialTrieMiddleBranching(t *testing.T) {
 		psmt, err := NewPSMT(rootHash, pathByteSize, bp)
 		require.NoError(t, err, "error building partial trie")
 
-		if !bytes.Equal(f.GetEmptyRootHash(), psmt.root.HashValue()) {
+		if !bytes.Equal(f.GetEmptyRootHash(), psmt.RootHash()) {
 			t.Fatal("rootNode hash doesn't match [before update]")
 		}
 		// first update
@@ -165,7 +165,7 @@ func TestPartialTrieMiddleBranching(t *testing.T) {
 		_, err = psmt.Update(paths, payloads)
 		require.NoError(t, err, "error updating psmt")
 
-		if !bytes.Equal(rootHash, psmt.root.HashValue()) {
+		if !bytes.Equal(rootHash, psmt.RootHash()) {
 			t.Fatal("rootNode hash doesn't match [before update]")
 		}
 
@@ -177,7 +177,7 @@ func TestPartialTrieMiddleBranching(t *testing.T) {
 		_, err = psmt.Update(paths, payloads)
 		require.NoError(t, err, "error updating psmt")
 
-		if !bytes.Equal(rootHash, psmt.root.HashValue()) {
+		if !bytes.Equal(rootHash, psmt.RootHash()) {
 			t.Fatal("rootNode hash doesn't match [after update]")
 		}
 	})
@@ -207,7 +207,7 @@ func TestPartialTrieRootUpdates(t *testing.T) {
 		psmt, err := NewPSMT(rootHash, pathByteSize, bp)
 		require.NoError(t, err, "error building partial trie")
 
-		if !bytes.Equal(rootHash, psmt.root.HashValue()) {
+		if !bytes.Equal(rootHash, psmt.RootHash()) {
 			t.Fatal("rootNode hash doesn't match [before update]")
 		}
 
@@ -263,7 +263,7 @@ func TestMixProof(t *testing.T) {
 		psmt, err := NewPSMT(rootHash, pathByteSize, bp)
 		require.NoError(t, err, "error building partial trie")
 
-		if !bytes.Equal(rootHash, psmt.root.HashValue()) {
+		if !bytes.Equal(rootHash, psmt.RootHash()) {
 			t.Fatal("rootNode hash doesn't match [before update]")
 		}
 
@@ -317,7 +317,7 @@ func TestRandomProofs(t *testing.T) {
 			psmt, err := NewPSMT(rootHash, pathByteSize, bp)
 			require.NoError(t, err, "error building partial trie")
 
-			if !bytes.Equal(rootHash, psmt.root.HashValue()) {
+			if !bytes.Equal(rootHash, psmt.RootHash()) {
 				t.Fatal("root hash doesn't match")
 			}
 
@@ -12,6 +12,44 @@ type TrieRead struct {
 	Paths    []Path
 }
 
+// TrieIteration captures a query iterating over the payloads of a trie in path order
+type TrieIteration struct {
+	RootHash RootHash
+	Start    Path      // smallest path to consider, nil to start with the smallest path of the trie
+	Limit    int       // maximum number of payloads returned, 0 for no limit
+	KeyParts []KeyPart // only payloads whose keys contain all of these key parts are returned
+}
+
+// TrieIterationResult holds the payloads returned by a trie iteration
+type TrieIterationResult struct {
+	Paths    []Path
+	Payloads []*Payload
+	Next     Path // path to start the next iteration with, nil if there are no more payloads
+}
+
+// PayloadChange describes how the payload stored at a path differs between two tries.
+// Old is nil for payloads added by the second trie, New is nil for payloads it removed.
+type PayloadChange struct {
+	Path Path
+	Old  *Payload
+	New  *Payload
+}
+
+// IsAdded returns true if the payload is only stored in the second trie
+func (c *PayloadChange) IsAdded() bool {
+	return c.Old == nil
+}
+
+// IsRemoved returns true if the payload is only stored in the first trie
+func (c *PayloadChange) IsRemoved() bool {
+	return c.New == nil
+}
+
+// IsModified returns true if the path stores different payloads in both tries
+func (c *PayloadChange) IsModified() bool {
+	return c.Old != nil && c.New != nil
+}
+
 // TrieUpdate holds all data for a trie update
 type TrieUpdate struct {
 	RootHash RootHash
@@ -177,16 +215,37 @@ func EmptyPayload() *Payload {
 	return &Payload{}
 }
 
+// TrieHasherType identifies the hash function used to compute the hashes of the trie nodes
+type TrieHasherType uint8
+
+const (
+	// TrieHasherSHA3 hashes trie nodes using SHA3-256 (default)
+	TrieHasherSHA3 TrieHasherType = iota
+	// TrieHasherBlake2b hashes trie nodes using BLAKE2b-256
+	TrieHasherBlake2b
+)
+
+func (t TrieHasherType) String() string {
+	switch t {
+	case TrieHasherSHA3:
+		return "SHA3-256"
+	case TrieHasherBlake2b:
+		return "BLAKE2b-256"
+	}
+	return fmt.Sprintf("unknown trie hasher (%d)", uint8(t))
+}
+
 // TrieProof includes all the information needed to walk
 // through a trie branch from an specific leaf node (key)
 // up to the root of the trie.
 type TrieProof struct {
-	Path      Path     // path
-	Payload   *Payload // payload
-	Interims  [][]byte // the non-default intermediate nodes in the proof
-	Inclusion bool     // flag indicating if this is an inclusion or exclusion
-	Flags     []byte   // The flags of the proofs (is set if an intermediate node has a non-default)
-	Steps     uint8    // number of steps for the proof (path len) // TODO: should this be a type allowing for larger values?
+	Path      Path           // path
+	Payload   *Payload       // payload
+	Interims  [][]byte       // the non-default intermediate nodes in the proof
+	Inclusion bool           // flag indicating if this is an inclusion or exclusion
+	Flags     []byte         // The flags of the proofs (is set if an intermediate node has a non-default)
+	Steps     uint8          // number of steps for the proof (path len) // TODO: should this be a type allowing for larger values?
+	Hasher    TrieHasherType // hash function of the trie the proof was generated from
 }
 
 // NewTrieProof creates a new instance of Trie Proof
@@ -253,6 +312,9 @@ func (p *TrieProof) Equals(o *TrieProof) bool {
 	if p.Steps != o.Steps {
 		return false
 	}
+	if p.Hasher != o.Hasher {
+		return false
+	}
 	return true
 }
 
@@ -338,3 +400,76 @@ func (bp *TrieBatchProof) Equals(o *TrieBatchProof) bool {
 	}
 	return true
 }
+
+// TrieCompactBatchProof is a batch proof, in which the inclusion proofs of all paths are merged
+// into a single proof tree, i.e. the union of the branches from the root to the proven leaves.
+// Sibling hashes shared by several paths, which are repeated in the proofs of a TrieBatchProof,
+// are included only once. Hashes of subtries containing proven paths are not included at all,
+// as they are recomputed from the proven leaves during verification.
+//
+// The proof tree is implied by the proven paths and the depths of their (compact) leaves:
+// walking the tree in DFS pre-order, every node on the branch of a single proven leaf holds a flag,
+// which is set if the hash of the node's sibling is non-default and included in Interims.
+type TrieCompactBatchProof struct {
+	Paths        []Path     // proven paths, sorted and unique
+	Payloads     []*Payload // payloads stored at the proven paths
+	Steps        []uint8    // depths of the leaves of the proven paths
+	Flags        []byte     // bit vector holding one flag per node with a single child in the proof tree
+	Interims     [][]byte   // non-default sibling hashes in DFS pre-order
+	ProofIndices []uint32   // index of the proven path of each proof in the original batch proof
+	Hasher       TrieHasherType
+}
+
+// NewTrieCompactBatchProof creates an empty compact batch proof
+func NewTrieCompactBatchProof() *TrieCompactBatchProof {
+	return &TrieCompactBatchProof{
+		Paths:        make([]Path, 0),
+		Payloads:     make([]*Payload, 0),
+		Steps:        make([]uint8, 0),
+		Flags:        make([]byte, 0),
+		Interims:     make([][]byte, 0),
+		ProofIndices: make([]uint32, 0),
+	}
+}
+
+// Size returns the number of proofs, including duplicated paths
+func (bp *TrieCompactBatchProof) Size() int {
+	return len(bp.ProofIndices)
+}
+
+// Equals compares this compact batch proof to another compact batch proof
+func (bp *TrieCompactBatchProof) Equals(o *TrieCompactBatchProof) bool {
+	if o == nil {
+		return false
+	}
+	if len(bp.Paths) != len(o.Paths) || len(bp.Payloads) != len(o.Payloads) || len(bp.Interims) != len(o.Interims) || len(bp.ProofIndices) != len(o.ProofIndices) {
+		return false
+	}
+	if bp.Hasher != o.Hasher {
+		return false
+	}
+	for i, path := range bp.Paths {
+		if !path.Equals(o.Paths[i]) {
+			return false
+		}
+	}
+	for i, payload := range bp.Payloads {
+		if !payload.Equals(o.Payloads[i]) {
+			return false
+		}
+	}
+	if !bytes.Equal(bp.Steps, o.Steps) || !bytes.Equal(bp.Flags, o.Flags) {
+		return false
+	}
+	for i, interim := range bp.Interims {
+		if !bytes.Equal(interim, o.Interims[i]) {
+			return false
+		}
+	}
+	for i, index := range bp.ProofIndices {
+		if index != o.ProofIndices[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -12,10 +12,12 @@ import (
 
 // List of built-in event types.
 const (
-	EventAccountCreated EventType = "flow.AccountCreated"
-	EventAccountUpdated EventType = "flow.AccountUpdated"
-	EventEpochSetup     EventType = "flow.EpochSetup"
-	EventEpochCommit    EventType = "flow.EpochCommit"
+	EventAccountCreated               EventType = "flow.AccountCreated"
+	EventAccountUpdated               EventType = "flow.AccountUpdated"
+	EventEpochSetup                   EventType = "flow.EpochSetup"
+	EventEpochCommit                  EventType = "flow.EpochCommit"
+	EventTransactionScheduled         EventType = "flow.TransactionScheduled"
+	EventScheduledTransactionExecuted EventType = "flow.ScheduledTransactionExecuted"
 )
 
 type EventType string
@@ -22,6 +22,12 @@ func NewRegisterID(owner, controller, key string) RegisterID {
 	}
 }
 
+// RegisterWrite identifies a block, which wrote a register when it was executed
+type RegisterWrite struct {
+	Height  uint64
+	BlockID Identifier
+}
+
 // RegisterValue (value part of Register)
 type RegisterValue = []byte
 
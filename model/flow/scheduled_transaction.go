@@ -0,0 +1,35 @@
+package flow
+
+// A ScheduledTransaction is a transaction registered by another transaction, which is executed in the
+// system chunk of the block at a future height.
+type ScheduledTransaction struct {
+	// ScheduleID is the unique number assigned to the transaction when it was scheduled.
+	ScheduleID uint64
+	// Height is the height of the block in whose system chunk the transaction is executed.
+	Height    uint64
+	Script    []byte
+	Arguments [][]byte
+	// Payer pays the fees of the transaction, and is its only authorizer.
+	Payer    Address
+	GasLimit uint64
+}
+
+// TransactionBody returns the body of the transaction executed for the schedule.
+//
+// The body has no signatures, as the payer authorized the transaction when it was scheduled. The
+// schedule ID is used as the sequence number of the proposal key, so that transactions scheduled
+// with the same script and arguments have different IDs.
+func (s ScheduledTransaction) TransactionBody() *TransactionBody {
+	tb := NewTransactionBody().
+		SetScript(s.Script).
+		SetGasLimit(s.GasLimit).
+		SetProposalKey(s.Payer, 0, s.ScheduleID).
+		SetPayer(s.Payer).
+		AddAuthorizer(s.Payer)
+
+	for _, argument := range s.Arguments {
+		tb.AddArgument(argument)
+	}
+
+	return tb
+}
@@ -11,6 +11,21 @@ type TransactionResult struct {
 	TransactionID Identifier
 	// ErrorMessage contains the error message of any error that may have occurred when the transaction was executed
 	ErrorMessage string
+	// ErrorCode is the code of the error the transaction failed with, 0 if it succeeded.
+	ErrorCode uint32
+}
+
+// StatusCode returns the status code of the transaction reported to clients: 0 if the transaction succeeded,
+// and otherwise the code of its error. Failed transactions whose results were recorded without an error code
+// report 1, the status code reported for all failed transactions before error codes were stored.
+func (t TransactionResult) StatusCode() uint32 {
+	if t.ErrorCode != 0 {
+		return t.ErrorCode
+	}
+	if t.ErrorMessage != "" {
+		return 1
+	}
+	return 0
 }
 
 // String returns the string representation of this error.
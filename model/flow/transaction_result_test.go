@@ -0,0 +1,26 @@
+package flow_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/onflow/flow-go/model/flow"
+)
+
+func TestTransactionResultStatusCode(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		result := flow.TransactionResult{}
+		assert.Equal(t, uint32(0), result.StatusCode())
+	})
+
+	t.Run("error with code", func(t *testing.T) {
+		result := flow.TransactionResult{ErrorMessage: "failed", ErrorCode: 105}
+		assert.Equal(t, uint32(105), result.StatusCode())
+	})
+
+	t.Run("error without code", func(t *testing.T) {
+		result := flow.TransactionResult{ErrorMessage: "failed"}
+		assert.Equal(t, uint32(1), result.StatusCode())
+	})
+}
@@ -0,0 +1,20 @@
+package flow
+
+// TransactionSimulation contains the artifacts generated by executing a transaction against the
+// execution state of a block, without committing any of its changes.
+type TransactionSimulation struct {
+	// TransactionID is the ID of the simulated transaction.
+	TransactionID Identifier
+	// BlockID is the ID of the block at whose execution state the transaction was simulated.
+	BlockID Identifier
+	Events  []Event
+	Logs    []string
+	// ComputationUsed is the computation used by the transaction.
+	ComputationUsed uint64
+	// RegisterUpdates are the changes the transaction would have made to the execution state.
+	RegisterUpdates []RegisterEntry
+	// ErrorCode is the code of the VM error the transaction failed with, 0 if it succeeded.
+	ErrorCode uint32
+	// ErrorMessage contains the error message of the VM error the transaction failed with.
+	ErrorMessage string
+}
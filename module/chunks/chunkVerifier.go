@@ -51,7 +51,7 @@ func (fcv *ChunkVerifier) Verify(vc *verification.VerifiableChunkData) ([]byte,
 		transactions = append(transactions, tx)
 	}
 
-	return fcv.verifyTransactions(vc.Chunk, vc.ChunkDataPack, vc.Result, vc.Header, transactions, vc.EndState)
+	return fcv.verifyTransactions(vc.Chunk, vc.ChunkDataPack, vc.Result, vc.Header, false, transactions, vc.EndState)
 }
 
 // SystemChunkVerify verifies a given VerifiableChunk corresponding to a system chunk.
@@ -69,13 +69,14 @@ func (fcv *ChunkVerifier) SystemChunkVerify(vc *verification.VerifiableChunkData
 	tx := fvm.Transaction(txBody, uint32(0))
 	transactions := []*fvm.TransactionProcedure{tx}
 
-	return fcv.verifyTransactions(vc.Chunk, vc.ChunkDataPack, vc.Result, vc.Header, transactions, vc.EndState)
+	return fcv.verifyTransactions(vc.Chunk, vc.ChunkDataPack, vc.Result, vc.Header, true, transactions, vc.EndState)
 }
 
 func (fcv *ChunkVerifier) verifyTransactions(chunk *flow.Chunk,
 	chunkDataPack *flow.ChunkDataPack,
 	result *flow.ExecutionResult,
 	header *flow.Header,
+	systemChunk bool,
 	transactions []*fvm.TransactionProcedure,
 	endState flow.StateCommitment) ([]byte, chmodels.ChunkFault, error) {
 
@@ -135,22 +136,36 @@ func (fcv *ChunkVerifier) verifyTransactions(chunk *flow.Chunk,
 
 	chunkView := delta.NewView(getRegister)
 
-	// executes all transactions in this chunk
-	for i, tx := range transactions {
-		txView := chunkView.NewChild()
-
-		// tx := fvm.Transaction(txBody, uint32(i))
+	// the transactions scheduled for the block are executed before the system chunk transaction
+	if systemChunk && blockCtx.ScheduledTransactionsEnabled {
+		scheduled := fvm.ScheduledTransactions(header.Height)
 
-		err := fcv.vm.Run(blockCtx, tx, txView)
+		err := fcv.vm.Run(blockCtx, scheduled, chunkView)
 		if err != nil {
-			// this covers unexpected and very rare cases (e.g. system memory issues...),
-			// so we shouldn't be here even if transaction naturally fails (e.g. permission, runtime ... )
-			return nil, nil, fmt.Errorf("failed to execute transaction: %d (%w)", i, err)
+			return nil, nil, fmt.Errorf("failed to get scheduled transactions: %w", err)
 		}
 
-		if tx.Err == nil {
-			// if tx is successful, we apply changes to the chunk view by merging the txView into chunk view
-			chunkView.MergeView(txView)
+		scheduledCtx := fvm.ScheduledTransactionContext(blockCtx)
+
+		for i, txBody := range scheduled.Transactions {
+			err := fcv.executeTransaction(scheduledCtx, fvm.Transaction(txBody, uint32(i)), chunkView)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to execute scheduled transaction: %d (%w)", i, err)
+			}
+		}
+	}
+
+	// the system chunk transaction is executed in the same context as by execution nodes
+	txCtx := blockCtx
+	if systemChunk {
+		txCtx = fvm.SystemChunkContext(blockCtx)
+	}
+
+	// executes all transactions in this chunk
+	for i, tx := range transactions {
+		err := fcv.executeTransaction(txCtx, tx, chunkView)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to execute transaction: %d (%w)", i, err)
 		}
 	}
 
@@ -199,3 +214,23 @@ func (fcv *ChunkVerifier) verifyTransactions(chunk *flow.Chunk,
 	}
 	return chunkView.SpockSecret(), nil, nil
 }
+
+// executeTransaction runs the transaction on a child of the chunk view, and applies its changes to the
+// chunk view if it succeeded.
+func (fcv *ChunkVerifier) executeTransaction(ctx fvm.Context, tx *fvm.TransactionProcedure, chunkView *delta.View) error {
+	txView := chunkView.NewChild()
+
+	err := fcv.vm.Run(ctx, tx, txView)
+	if err != nil {
+		// this covers unexpected and very rare cases (e.g. system memory issues...),
+		// so we shouldn't be here even if transaction naturally fails (e.g. permission, runtime ... )
+		return err
+	}
+
+	if tx.Err == nil {
+		// if tx is successful, we apply changes to the chunk view by merging the txView into chunk view
+		chunkView.MergeView(txView)
+	}
+
+	return nil
+}
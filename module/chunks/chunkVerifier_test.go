@@ -1,14 +1,21 @@
 package chunks_test
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/onflow/cadence/runtime"
 	"github.com/rs/zerolog"
 
+	"github.com/onflow/flow-go/engine/execution/computation/computer"
 	executionState "github.com/onflow/flow-go/engine/execution/state"
+	"github.com/onflow/flow-go/engine/execution/state/bootstrap"
+	"github.com/onflow/flow-go/engine/execution/state/delta"
+	"github.com/onflow/flow-go/engine/execution/testutil"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -22,6 +29,7 @@ import (
 	chunksmodels "github.com/onflow/flow-go/model/chunks"
 	"github.com/onflow/flow-go/model/flow"
 	"github.com/onflow/flow-go/module/chunks"
+	"github.com/onflow/flow-go/module/mempool/entity"
 	"github.com/onflow/flow-go/module/metrics"
 	"github.com/onflow/flow-go/utils/unittest"
 )
@@ -150,6 +158,155 @@ func (s *ChunkVerifierTestSuite) TestEmptyCollection() {
 	assert.NotNil(s.T(), spockSecret)
 }
 
+// TestSystemChunkWithScheduledTransactions tests that the system chunk of a block with scheduled transactions,
+// executed by the block computer, is verified with the same end state and SPoCK secret.
+func TestSystemChunkWithScheduledTransactions(t *testing.T) {
+	chain := flow.Testnet.Chain()
+	log := zerolog.Nop()
+
+	unittest.RunWithTempDir(t, func(dir string) {
+		led, err := completeLedger.NewLedger(dir, 100, &metrics.NoopCollector{}, log, nil, completeLedger.DefaultPathFinderVersion)
+		require.NoError(t, err)
+		defer led.Done()
+
+		startState, err := bootstrap.NewBootstrapper(log).BootstrapLedger(
+			led,
+			unittest.ServiceAccountPublicKey,
+			unittest.GenesisTokenSupply,
+			chain,
+		)
+		require.NoError(t, err)
+
+		vm := fvm.New(runtime.NewInterpreterRuntime())
+		vmCtx := fvm.NewContext(log, fvm.WithChain(chain), fvm.WithScheduledTransactions(true))
+
+		bc, err := computer.NewBlockComputer(vm, vmCtx, nil, nil, log, 1)
+		require.NoError(t, err)
+
+		// executes the block and commits its updates, returning the system chunk, its data pack and SPoCK secret
+		executeBlock := func(block *flow.Block, collections ...*entity.CompleteCollection) (*flow.Chunk, *flow.ChunkDataPack, []byte) {
+			completeCollections := make(map[flow.Identifier]*entity.CompleteCollection)
+			for _, collection := range collections {
+				completeCollections[collection.Guarantee.ID()] = collection
+			}
+
+			executableBlock := &entity.ExecutableBlock{
+				Block:               block,
+				CompleteCollections: completeCollections,
+				StartState:          startState,
+			}
+
+			view := delta.NewView(executionState.LedgerGetRegister(led, startState))
+
+			result, err := bc.ExecuteBlock(context.Background(), executableBlock, view)
+			require.NoError(t, err)
+			for _, txResult := range result.TransactionResult {
+				require.Empty(t, txResult.ErrorMessage)
+			}
+
+			// the system chunk only starts after the collections, so it is proven against the end state of the block
+			// if the block has no collections
+			systemChunk := result.StateSnapshots[len(result.StateSnapshots)-1]
+
+			query, err := ledger.NewQuery(startState, executionState.RegisterIDSToKeys(systemChunk.AllRegisters()))
+			require.NoError(t, err)
+			proof, err := led.Prove(query)
+			require.NoError(t, err)
+
+			ids, values := view.Delta().RegisterUpdates()
+			update, err := ledger.NewUpdate(
+				startState,
+				executionState.RegisterIDSToKeys(ids),
+				executionState.RegisterValuesToValues(values),
+			)
+			require.NoError(t, err)
+			endState, err := led.Set(update)
+			require.NoError(t, err)
+
+			chunk := &flow.Chunk{
+				ChunkBody: flow.ChunkBody{
+					CollectionIndex: uint(len(collections)),
+					StartState:      startState,
+					BlockID:         block.ID(),
+				},
+				Index:    uint64(len(collections)),
+				EndState: endState,
+			}
+
+			chunkDataPack := &flow.ChunkDataPack{
+				ChunkID:    chunk.ID(),
+				StartState: startState,
+				Proof:      proof,
+			}
+
+			startState = endState
+
+			return chunk, chunkDataPack, systemChunk.SpockSecret
+		}
+
+		// the first block schedules a transaction for the second block
+		scheduleTx := flow.NewTransactionBody().
+			SetScript([]byte(fmt.Sprintf(`
+				transaction {
+					prepare(signer: AuthAccount) {
+						scheduleTransaction(
+							at: 11,
+							script: %s,
+							arguments: [],
+							payer: signer,
+							gasLimit: 100
+						)
+					}
+				}
+			`, strconv.Quote(`transaction { prepare(payer: AuthAccount) { payer.save(42, to: /storage/answer) } }`)))).
+			AddAuthorizer(chain.ServiceAddress())
+		err = testutil.SignTransactionAsServiceAccount(scheduleTx, 0, chain)
+		require.NoError(t, err)
+
+		collection := flow.Collection{Transactions: []*flow.TransactionBody{scheduleTx}}
+		guarantee := collection.Guarantee()
+
+		header := unittest.BlockHeaderFixture()
+		header.Height = 10
+		block := &flow.Block{
+			Header:  &header,
+			Payload: &flow.Payload{Guarantees: []*flow.CollectionGuarantee{&guarantee}},
+		}
+
+		executeBlock(block, &entity.CompleteCollection{Guarantee: &guarantee, Transactions: collection.Transactions})
+
+		// the second block executes the scheduled transaction in its system chunk
+		header = unittest.BlockHeaderWithParentFixture(&header)
+		block = &flow.Block{
+			Header:  &header,
+			Payload: &flow.Payload{},
+		}
+
+		chunk, chunkDataPack, spockSecret := executeBlock(block)
+
+		result := &flow.ExecutionResult{
+			ExecutionResultBody: flow.ExecutionResultBody{
+				BlockID: block.ID(),
+				Chunks:  flow.ChunkList{chunk},
+			},
+		}
+
+		verifier := chunks.NewChunkVerifier(vm, vmCtx)
+
+		verifiedSpockSecret, chFault, err := verifier.SystemChunkVerify(&verification.VerifiableChunkData{
+			IsSystemChunk: true,
+			Chunk:         chunk,
+			Header:        block.Header,
+			Result:        result,
+			ChunkDataPack: chunkDataPack,
+			EndState:      chunk.EndState,
+		})
+		require.NoError(t, err)
+		require.Nil(t, chFault)
+		require.Equal(t, spockSecret, verifiedSpockSecret)
+	})
+}
+
 // GetBaselineVerifiableChunk returns a verifiable chunk and sets the script
 // of a transaction in the middle of the collection to some value to signal the
 // mocked vm on what to return as tx exec outcome.
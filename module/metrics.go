@@ -207,6 +207,10 @@ type LedgerMetrics interface {
 	// LatestTrieMaxDepthDiff records the difference between the max depth of the latest created trie and parent trie
 	LatestTrieMaxDepthDiff(number uint64)
 
+	// LatestTrieMemorySizeDiff records the approximate memory allocated by the latest created trie,
+	// excluding the nodes shared with other tries in the forest
+	LatestTrieMemorySizeDiff(bytes uint64)
+
 	// UpdateCount increase a counter of performed updates
 	UpdateCount()
 
@@ -315,6 +319,21 @@ type TransactionMetrics interface {
 	TransactionSubmissionFailed()
 }
 
+type ExecutionNodeQueryMetrics interface {
+	// ExecutionNodeQueryFailed should be called whenever a request to an execution node fails
+	ExecutionNodeQueryFailed(query string)
+
+	// ExecutionNodeResponseMismatch should be called whenever the responses of two execution nodes
+	// to the same request differ
+	ExecutionNodeResponseMismatch(query string)
+}
+
+type RateLimitMetrics interface {
+	// CallRateLimited should be called whenever a call to an API method is rejected, because the
+	// client exceeded one of its limits
+	CallRateLimited(method string, limit string)
+}
+
 type PingMetrics interface {
 	// NodeReachable tracks the node availability of the node and reports it as 1 if the node was successfully pinged, 0
 	// otherwise. The nodeInfo provides additional information about the node such as the name of the node operator
@@ -31,6 +31,7 @@ type ExecutionCollector struct {
 	latestTrieRegCountDiff           prometheus.Gauge
 	latestTrieMaxDepth               prometheus.Gauge
 	latestTrieMaxDepthDiff           prometheus.Gauge
+	latestTrieMemorySizeDiff         prometheus.Gauge
 	updated                          prometheus.Counter
 	proofSize                        prometheus.Gauge
 	updatedValuesNumber              prometheus.Counter
@@ -95,6 +96,13 @@ func NewExecutionCollector(tracer *trace.OpenTracer, registerer prometheus.Regis
 		Help:      "the difference between the max depth of the latest created trie and parent trie",
 	})
 
+	latestTrieMemorySizeDiff := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespaceExecution,
+		Subsystem: subsystemMTrie,
+		Name:      "latest_trie_memory_size_diff",
+		Help:      "approximate memory in bytes allocated by the latest created trie, excluding nodes shared with other tries",
+	})
+
 	updatedCount := prometheus.NewCounter(prometheus.CounterOpts{
 		Namespace: namespaceExecution,
 		Subsystem: subsystemMTrie,
@@ -217,6 +225,7 @@ func NewExecutionCollector(tracer *trace.OpenTracer, registerer prometheus.Regis
 	registerer.MustRegister(latestTrieRegCountDiff)
 	registerer.MustRegister(latestTrieMaxDepth)
 	registerer.MustRegister(latestTrieMaxDepthDiff)
+	registerer.MustRegister(latestTrieMemorySizeDiff)
 	registerer.MustRegister(updatedCount)
 	registerer.MustRegister(proofSize)
 	registerer.MustRegister(updatedValuesNumber)
@@ -243,6 +252,7 @@ func NewExecutionCollector(tracer *trace.OpenTracer, registerer prometheus.Regis
 		latestTrieRegCountDiff:     latestTrieRegCountDiff,
 		latestTrieMaxDepth:         latestTrieMaxDepth,
 		latestTrieMaxDepthDiff:     latestTrieMaxDepthDiff,
+		latestTrieMemorySizeDiff:   latestTrieMemorySizeDiff,
 		updated:                    updatedCount,
 		proofSize:                  proofSize,
 		updatedValuesNumber:        updatedValuesNumber,
@@ -394,6 +404,12 @@ func (ec *ExecutionCollector) LatestTrieMaxDepthDiff(number uint64) {
 	ec.latestTrieMaxDepthDiff.Set(float64(number))
 }
 
+// LatestTrieMemorySizeDiff records the approximate memory allocated by the latest created trie,
+// excluding the nodes shared with other tries in the forest
+func (ec *ExecutionCollector) LatestTrieMemorySizeDiff(bytes uint64) {
+	ec.latestTrieMemorySizeDiff.Set(float64(bytes))
+}
+
 // UpdateCount increase a counter of performed updates
 func (ec *ExecutionCollector) UpdateCount() {
 	ec.updated.Inc()
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type ExecutionNodeQueryCollector struct {
+	failed     *prometheus.CounterVec
+	mismatched *prometheus.CounterVec
+}
+
+func NewExecutionNodeQueryCollector() *ExecutionNodeQueryCollector {
+	ec := &ExecutionNodeQueryCollector{
+		failed: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name:      "failed_total",
+			Namespace: namespaceAccess,
+			Subsystem: subsystemExecutionNodeQuery,
+			Help:      "the number of failed requests to execution nodes",
+		}, []string{LabelQuery}),
+		mismatched: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name:      "response_mismatch_total",
+			Namespace: namespaceAccess,
+			Subsystem: subsystemExecutionNodeQuery,
+			Help:      "the number of responses of execution nodes which differ from the response of another execution node",
+		}, []string{LabelQuery}),
+	}
+	return ec
+}
+
+func (ec *ExecutionNodeQueryCollector) ExecutionNodeQueryFailed(query string) {
+	ec.failed.WithLabelValues(query).Inc()
+}
+
+func (ec *ExecutionNodeQueryCollector) ExecutionNodeResponseMismatch(query string) {
+	ec.mismatched.WithLabelValues(query).Inc()
+}
@@ -10,6 +10,9 @@ const (
 	LabelNodeRole = "noderole"
 	LabelNodeInfo = "nodeinfo"
 	LabelPriority = "priority"
+	LabelQuery    = "query"
+	LabelMethod   = "method"
+	LabelLimit    = "limit"
 )
 
 const (
@@ -64,6 +67,9 @@ const (
 	ResourceEpochSetup               = "epoch_setup"
 	ResourceEpochCommit              = "epoch_commit"
 	ResourceEpochStatus              = "epoch_status"
+	ResourceScriptResult             = "script_result" // access node, response cache
+	ResourceBlockEvents              = "block_events"  // access node, response cache
+	ResourceAccount                  = "account"       // access node, response cache
 )
 
 const (
@@ -31,6 +31,8 @@ const (
 const (
 	subsystemTransactionTiming     = "transaction_timing"
 	subsystemTransactionSubmission = "transaction_submission"
+	subsystemExecutionNodeQuery    = "execution_node_query"
+	subsystemRateLimit             = "rate_limit"
 )
 
 // Collection subsystem
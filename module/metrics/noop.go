@@ -89,6 +89,7 @@ func (nc *NoopCollector) LatestTrieRegCount(number uint64)
 func (nc *NoopCollector) LatestTrieRegCountDiff(number uint64)                                   {}
 func (nc *NoopCollector) LatestTrieMaxDepth(number uint64)                                       {}
 func (nc *NoopCollector) LatestTrieMaxDepthDiff(number uint64)                                   {}
+func (nc *NoopCollector) LatestTrieMemorySizeDiff(bytes uint64)                                  {}
 func (nc *NoopCollector) UpdateCount()                                                           {}
 func (nc *NoopCollector) ProofSize(bytes uint32)                                                 {}
 func (nc *NoopCollector) UpdateValuesNumber(number uint64)                                       {}
@@ -109,6 +110,9 @@ func (nc *NoopCollector) TransactionFinalized(txID flow.Identifier, when time.Ti
 func (nc *NoopCollector) TransactionExecuted(txID flow.Identifier, when time.Time)               {}
 func (nc *NoopCollector) TransactionExpired(txID flow.Identifier)                                {}
 func (nc *NoopCollector) TransactionSubmissionFailed()                                           {}
+func (nc *NoopCollector) ExecutionNodeQueryFailed(query string)                                  {}
+func (nc *NoopCollector) ExecutionNodeResponseMismatch(query string)                             {}
+func (nc *NoopCollector) CallRateLimited(method string, limit string)                            {}
 func (nc *NoopCollector) ChunkDataPackRequested()                                                {}
 func (nc *NoopCollector) ExecutionSync(syncing bool)                                             {}
 func (nc *NoopCollector) DiskSize(uint64)                                                        {}
@@ -0,0 +1,26 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type RateLimitCollector struct {
+	rejected *prometheus.CounterVec
+}
+
+func NewRateLimitCollector() *RateLimitCollector {
+	rc := &RateLimitCollector{
+		rejected: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name:      "rejected_calls_total",
+			Namespace: namespaceAccess,
+			Subsystem: subsystemRateLimit,
+			Help:      "the number of API calls rejected because the client exceeded its rate limit",
+		}, []string{LabelMethod, LabelLimit}),
+	}
+	return rc
+}
+
+func (rc *RateLimitCollector) CallRateLimited(method string, limit string) {
+	rc.rejected.WithLabelValues(method, limit).Inc()
+}
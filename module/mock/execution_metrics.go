@@ -89,6 +89,11 @@ func (_m *ExecutionMetrics) LatestTrieMaxDepthDiff(number uint64) {
 	_m.Called(number)
 }
 
+// LatestTrieMemorySizeDiff provides a mock function with given fields: bytes
+func (_m *ExecutionMetrics) LatestTrieMemorySizeDiff(bytes uint64) {
+	_m.Called(bytes)
+}
+
 // LatestTrieRegCount provides a mock function with given fields: number
 func (_m *ExecutionMetrics) LatestTrieRegCount(number uint64) {
 	_m.Called(number)
@@ -38,6 +38,11 @@ func (_m *LedgerMetrics) LatestTrieMaxDepthDiff(number uint64) {
 	_m.Called(number)
 }
 
+// LatestTrieMemorySizeDiff provides a mock function with given fields: bytes
+func (_m *LedgerMetrics) LatestTrieMemorySizeDiff(bytes uint64) {
+	_m.Called(bytes)
+}
+
 // LatestTrieRegCount provides a mock function with given fields: number
 func (_m *LedgerMetrics) LatestTrieRegCount(number uint64) {
 	_m.Called(number)
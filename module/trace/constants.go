@@ -94,6 +94,7 @@ const (
 	EXERetrieveStateDelta                 SpanName = "exe.state.retrieveStateDelta"
 	EXEUpdateHighestExecutedBlockIfHigher SpanName = "exe.state.updateHighestExecutedBlockIfHigher"
 	EXEGetHighestExecutedBlockID          SpanName = "exe.state.getHighestExecutedBlockID"
+	EXERegisterHistory                    SpanName = "exe.state.registerHistory"
 
 	// Verification node
 	//
@@ -120,7 +121,9 @@ const (
 
 // Tag names
 const (
-	EXEParseDurationTag     = "runtime.parseTransactionDuration"
-	EXECheckDurationTag     = "runtime.checkTransactionDuration"
-	EXEInterpretDurationTag = "runtime.interpretTransactionDuration"
+	EXEParseDurationTag      = "runtime.parseTransactionDuration"
+	EXECheckDurationTag      = "runtime.checkTransactionDuration"
+	EXEInterpretDurationTag  = "runtime.interpretTransactionDuration"
+	EXEProgramCacheHitsTag   = "runtime.programCacheHits"
+	EXEProgramCacheMissesTag = "runtime.programCacheMisses"
 )
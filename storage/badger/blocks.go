@@ -139,3 +139,37 @@ func (b *Blocks) GetLastFullBlockHeight() (uint64, error) {
 	}
 	return h, nil
 }
+
+// UpdateLastIndexedBlockHeight upsert (update or insert) the last indexed block height
+func (b *Blocks) UpdateLastIndexedBlockHeight(height uint64) error {
+	return operation.RetryOnConflict(b.db.Update, func(tx *badger.Txn) error {
+
+		// try to update
+		err := operation.UpdateLastIndexedBlockHeight(height)(tx)
+		if err == nil {
+			return nil
+		}
+
+		if !errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("could not update LastIndexedBlockHeight: %w", err)
+		}
+
+		// if key does not exist, try insert.
+		err = operation.InsertLastIndexedBlockHeight(height)(tx)
+		if err != nil {
+			return fmt.Errorf("could not insert LastIndexedBlockHeight: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// GetLastIndexedBlockHeight retrieves the last indexed block height
+func (b *Blocks) GetLastIndexedBlockHeight() (uint64, error) {
+	var h uint64
+	err := b.db.View(operation.RetrieveLastIndexedBlockHeight(&h))
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve LastIndexedBlockHeight: %w", err)
+	}
+	return h, nil
+}
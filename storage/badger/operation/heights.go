@@ -49,3 +49,15 @@ func UpdateLastCompleteBlockHeight(height uint64) func(*badger.Txn) error {
 func RetrieveLastCompleteBlockHeight(height *uint64) func(*badger.Txn) error {
 	return retrieve(makePrefix(codeLastCompleteBlockHeight), height)
 }
+
+func InsertLastIndexedBlockHeight(height uint64) func(*badger.Txn) error {
+	return insert(makePrefix(codeLastIndexedBlockHeight), height)
+}
+
+func UpdateLastIndexedBlockHeight(height uint64) func(*badger.Txn) error {
+	return update(makePrefix(codeLastIndexedBlockHeight), height)
+}
+
+func RetrieveLastIndexedBlockHeight(height *uint64) func(*badger.Txn) error {
+	return retrieve(makePrefix(codeLastIndexedBlockHeight), height)
+}
@@ -83,3 +83,27 @@ func TestLastCompleteBlockHeightInsertUpdateRetrieve(t *testing.T) {
 		assert.Equal(t, retrieved, height)
 	})
 }
+
+func TestLastIndexedBlockHeightInsertUpdateRetrieve(t *testing.T) {
+	unittest.RunWithBadgerDB(t, func(db *badger.DB) {
+		height := uint64(1337)
+
+		err := db.Update(InsertLastIndexedBlockHeight(height))
+		require.Nil(t, err)
+
+		var retrieved uint64
+		err = db.View(RetrieveLastIndexedBlockHeight(&retrieved))
+		require.Nil(t, err)
+
+		assert.Equal(t, retrieved, height)
+
+		height = 9999
+		err = db.Update(UpdateLastIndexedBlockHeight(height))
+		require.Nil(t, err)
+
+		err = db.View(RetrieveLastIndexedBlockHeight(&retrieved))
+		require.Nil(t, err)
+
+		assert.Equal(t, retrieved, height)
+	})
+}
@@ -12,7 +12,8 @@ import (
 const (
 
 	// codes for special database markers
-	codeMax = 1 // keeps track of the maximum key size
+	codeMax              = 1 // keeps track of the maximum key size
+	codeExecutorsIndexed = 2 // marks the receipts stored before codeBlockExecutors existed as indexed
 
 	// codes for views with special meaning
 	codeStartedView = 10 // latest view hotstuff started
@@ -25,6 +26,7 @@ const (
 	codeExecutedBlock           = 23 // latest executed block with max height
 	codeRootHeight              = 24 // the height of the first loaded block
 	codeLastCompleteBlockHeight = 25 // the height of the last block for which all collections were received
+	codeLastIndexedBlockHeight  = 26 // the height of the last block for which all events and transaction results were indexed
 
 	// codes for single entity storage
 	// 31 was used for identities before epochs
@@ -51,11 +53,15 @@ const (
 	codeBlockExecutionReceipt = 55 // index mapping block ID to execution receipt ID
 	codeBlockEpochStatus      = 56 // index mapping block ID to epoch status
 	codePayloadReceipts       = 57 // index mapping block ID  to payload receipts
+	codeBlockExecutors        = 58 // index mapping block ID to the executors of its execution receipts
 
 	// codes related to epoch information
 	codeEpochSetup  = 60 // EpochSetup service event, keyed by ID
 	codeEpochCommit = 61 // EpochCommit service event, keyed by ID
 
+	// codes for indexing the execution state
+	codeRegisterHistory = 70 // index mapping register ID to the blocks which wrote the register
+
 	// legacy codes (should be cleaned up)
 	codeChunkDataPack                = 100
 	codeCommit                       = 101
@@ -94,6 +100,8 @@ func b(v interface{}) []byte {
 		return b
 	case string:
 		return []byte(i)
+	case []byte:
+		return i
 	case flow.Role:
 		return []byte{byte(i)}
 	case flow.Identifier:
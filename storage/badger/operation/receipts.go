@@ -25,3 +25,48 @@ func IndexExecutionReceipt(blockID flow.Identifier, receiptID flow.Identifier) f
 func LookupExecutionReceipt(blockID flow.Identifier, receiptID *flow.Identifier) func(*badger.Txn) error {
 	return retrieve(makePrefix(codeBlockExecutionReceipt, blockID), receiptID)
 }
+
+// IndexExecutionReceiptExecutor indexes the executor of an execution receipt by the executed block ID
+func IndexExecutionReceiptExecutor(blockID flow.Identifier, executorID flow.Identifier) func(*badger.Txn) error {
+	return insert(makePrefix(codeBlockExecutors, blockID, executorID), executorID)
+}
+
+// LookupExecutionReceiptExecutors finds the executors of all indexed execution receipts for the block
+func LookupExecutionReceiptExecutors(blockID flow.Identifier, executorIDs *[]flow.Identifier) func(*badger.Txn) error {
+	return traverse(makePrefix(codeBlockExecutors, blockID), lookup(executorIDs))
+}
+
+// FindExecutionReceiptExecutors finds the executor and the result ID of all stored execution receipts.
+// Execution results are stored with the same code as receipt metas, they decode into metas without a
+// result ID and are skipped.
+func FindExecutionReceiptExecutors(found *[]flow.ExecutionReceiptMeta) func(*badger.Txn) error {
+	return traverse(makePrefix(codeExecutionReceiptMeta), func() (checkFunc, createFunc, handleFunc) {
+		check := func(key []byte) bool {
+			return true
+		}
+		var val flow.ExecutionReceiptMeta
+		create := func() interface{} {
+			val = flow.ExecutionReceiptMeta{}
+			return &val
+		}
+		handle := func() error {
+			if val.ResultID != flow.ZeroID {
+				*found = append(*found, flow.ExecutionReceiptMeta{ExecutorID: val.ExecutorID, ResultID: val.ResultID})
+			}
+			return nil
+		}
+		return check, create, handle
+	})
+}
+
+// InsertExecutorsIndexed marks the executors of the receipts stored before they were indexed by the
+// executed block as indexed.
+func InsertExecutorsIndexed() func(*badger.Txn) error {
+	return insert(makePrefix(codeExecutorsIndexed), true)
+}
+
+// RetrieveExecutorsIndexed retrieves whether the executors of the receipts stored before they were
+// indexed by the executed block are indexed.
+func RetrieveExecutorsIndexed(indexed *bool) func(*badger.Txn) error {
+	return retrieve(makePrefix(codeExecutorsIndexed), indexed)
+}
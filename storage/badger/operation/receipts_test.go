@@ -45,3 +45,47 @@ func TestReceipts_Index(t *testing.T) {
 		assert.Equal(t, expected, actual)
 	})
 }
+
+func TestReceipts_IndexExecutors(t *testing.T) {
+	unittest.RunWithBadgerDB(t, func(db *badger.DB) {
+		blockID := unittest.IdentifierFixture()
+		expected := unittest.IdentifierListFixture(3)
+
+		for _, executorID := range expected {
+			err := db.Update(IndexExecutionReceiptExecutor(blockID, executorID))
+			require.Nil(t, err)
+		}
+
+		// executors of other blocks are not included
+		err := db.Update(IndexExecutionReceiptExecutor(unittest.IdentifierFixture(), unittest.IdentifierFixture()))
+		require.Nil(t, err)
+
+		var actual []flow.Identifier
+		err = db.View(LookupExecutionReceiptExecutors(blockID, &actual))
+		require.Nil(t, err)
+
+		assert.ElementsMatch(t, expected, actual)
+	})
+}
+
+func TestReceipts_FindExecutors(t *testing.T) {
+	unittest.RunWithBadgerDB(t, func(db *badger.DB) {
+		receipt := unittest.ExecutionReceiptFixture()
+		meta := receipt.Meta()
+
+		err := db.Update(InsertExecutionReceiptMeta(receipt.ID(), meta))
+		require.Nil(t, err)
+
+		// results share the prefix of the receipt metas and are skipped
+		err = db.Update(InsertExecutionResult(&receipt.ExecutionResult))
+		require.Nil(t, err)
+
+		var actual []flow.ExecutionReceiptMeta
+		err = db.View(FindExecutionReceiptExecutors(&actual))
+		require.Nil(t, err)
+
+		require.Len(t, actual, 1)
+		assert.Equal(t, meta.ExecutorID, actual[0].ExecutorID)
+		assert.Equal(t, meta.ResultID, actual[0].ResultID)
+	})
+}
@@ -0,0 +1,46 @@
+package operation
+
+import (
+	"github.com/dgraph-io/badger/v2"
+
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// IndexRegisterWrite indexes that the register was written by the block with the given height and ID.
+func IndexRegisterWrite(registerID flow.RegisterID, write flow.RegisterWrite) func(*badger.Txn) error {
+	return insert(makePrefix(codeRegisterHistory, registerKey(registerID), write.Height, write.BlockID), write)
+}
+
+// LookupRegisterHistory retrieves the blocks which wrote the register, with heights in the
+// range [fromHeight, toHeight], ordered by height.
+func LookupRegisterHistory(registerID flow.RegisterID, fromHeight uint64, toHeight uint64, writes *[]flow.RegisterWrite) func(*badger.Txn) error {
+	*writes = make([]flow.RegisterWrite, 0)
+	iterationFunc := func() (checkFunc, createFunc, handleFunc) {
+		check := func(key []byte) bool {
+			return true
+		}
+		var val flow.RegisterWrite
+		create := func() interface{} {
+			return &val
+		}
+		handle := func() error {
+			*writes = append(*writes, val)
+			return nil
+		}
+		return check, create, handle
+	}
+	start := makePrefix(codeRegisterHistory, registerKey(registerID), fromHeight)
+	end := makePrefix(codeRegisterHistory, registerKey(registerID), toHeight)
+	return iterate(start, end, iterationFunc)
+}
+
+// registerKey encodes a register ID, such that no encoded register ID is a prefix of another one.
+// Each part is preceded by its length.
+func registerKey(registerID flow.RegisterID) []byte {
+	key := make([]byte, 0, 12+len(registerID.Owner)+len(registerID.Controller)+len(registerID.Key))
+	for _, part := range []string{registerID.Owner, registerID.Controller, registerID.Key} {
+		key = append(key, b(uint32(len(part)))...)
+		key = append(key, part...)
+	}
+	return key
+}
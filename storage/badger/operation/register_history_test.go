@@ -0,0 +1,58 @@
+package operation
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/utils/unittest"
+)
+
+func TestRegisterHistoryIndexLookup(t *testing.T) {
+	unittest.RunWithBadgerDB(t, func(db *badger.DB) {
+
+		register := flow.NewRegisterID("owner", "controller", "key")
+		// the encoding of this register starts with the encoding of register, if no lengths were encoded
+		other := flow.NewRegisterID("owner", "controller", "key2")
+
+		writes := []flow.RegisterWrite{
+			{Height: 5, BlockID: unittest.IdentifierFixture()},
+			{Height: 7, BlockID: unittest.IdentifierFixture()},
+			{Height: 300, BlockID: unittest.IdentifierFixture()},
+		}
+		for _, write := range writes {
+			err := db.Update(IndexRegisterWrite(register, write))
+			require.NoError(t, err)
+		}
+		err := db.Update(IndexRegisterWrite(other, flow.RegisterWrite{Height: 6, BlockID: unittest.IdentifierFixture()}))
+		require.NoError(t, err)
+
+		t.Run("indexing twice fails", func(t *testing.T) {
+			err := db.Update(IndexRegisterWrite(register, writes[0]))
+			require.Error(t, err)
+		})
+
+		t.Run("full range", func(t *testing.T) {
+			var actual []flow.RegisterWrite
+			err := db.View(LookupRegisterHistory(register, 0, 1000, &actual))
+			require.NoError(t, err)
+			require.Equal(t, writes, actual)
+		})
+
+		t.Run("range bounds are inclusive", func(t *testing.T) {
+			var actual []flow.RegisterWrite
+			err := db.View(LookupRegisterHistory(register, 7, 300, &actual))
+			require.NoError(t, err)
+			require.Equal(t, writes[1:], actual)
+		})
+
+		t.Run("no writes in range", func(t *testing.T) {
+			var actual []flow.RegisterWrite
+			err := db.View(LookupRegisterHistory(register, 8, 299, &actual))
+			require.NoError(t, err)
+			require.Empty(t, actual)
+		})
+	})
+}
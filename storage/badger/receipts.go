@@ -13,6 +13,9 @@ import (
 	"github.com/onflow/flow-go/storage/badger/operation"
 )
 
+// indexExecutorsBatchSize is the number of receipts whose executors IndexExecutors indexes per transaction
+const indexExecutorsBatchSize = 1000
+
 // ExecutionReceipts implements storage for execution receipts.
 type ExecutionReceipts struct {
 	db      *badger.DB
@@ -33,6 +36,11 @@ func NewExecutionReceipts(collector module.CacheMetrics, db *badger.DB, results
 			if err != nil {
 				return fmt.Errorf("could not store result: %w", err)
 			}
+			// index the executor of the receipt by the executed block
+			err = operation.SkipDuplicates(operation.IndexExecutionReceiptExecutor(receipt.ExecutionResult.BlockID, receipt.ExecutorID))(tx)
+			if err != nil {
+				return fmt.Errorf("could not index receipt executor: %w", err)
+			}
 			return nil
 		}
 	}
@@ -147,3 +155,64 @@ func (r *ExecutionReceipts) ByBlockID(blockID flow.Identifier) (*flow.ExecutionR
 	defer tx.Discard()
 	return r.byBlockID(blockID)(tx)
 }
+
+func (r *ExecutionReceipts) ExecutorsByBlockID(blockID flow.Identifier) ([]flow.Identifier, error) {
+	var executorIDs []flow.Identifier
+	err := r.db.View(operation.LookupExecutionReceiptExecutors(blockID, &executorIDs))
+	if err != nil {
+		return nil, fmt.Errorf("could not lookup receipt executors: %w", err)
+	}
+	return executorIDs, nil
+}
+
+// IndexExecutors indexes the executors of the receipts stored before the executors were indexed by the
+// executed block, so that ExecutorsByBlockID returns them. The stored receipts are only scanned once,
+// later calls return immediately.
+func (r *ExecutionReceipts) IndexExecutors() error {
+	var indexed bool
+	err := r.db.View(operation.RetrieveExecutorsIndexed(&indexed))
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("could not check whether executors are indexed: %w", err)
+	}
+	if indexed {
+		return nil
+	}
+
+	var metas []flow.ExecutionReceiptMeta
+	err = r.db.View(operation.FindExecutionReceiptExecutors(&metas))
+	if err != nil {
+		return fmt.Errorf("could not find receipts: %w", err)
+	}
+
+	// index the executors in batches, to keep the transactions small
+	for start := 0; start < len(metas); start += indexExecutorsBatchSize {
+		end := start + indexExecutorsBatchSize
+		if end > len(metas) {
+			end = len(metas)
+		}
+		err = operation.RetryOnConflict(r.db.Update, func(tx *badger.Txn) error {
+			for _, meta := range metas[start:end] {
+				var result flow.ExecutionResult
+				err := operation.RetrieveExecutionResult(meta.ResultID, &result)(tx)
+				if err != nil {
+					return fmt.Errorf("could not retrieve result %x: %w", meta.ResultID, err)
+				}
+				err = operation.SkipDuplicates(operation.IndexExecutionReceiptExecutor(result.BlockID, meta.ExecutorID))(tx)
+				if err != nil {
+					return fmt.Errorf("could not index receipt executor: %w", err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = r.db.Update(operation.InsertExecutorsIndexed())
+	if err != nil {
+		return fmt.Errorf("could not mark executors as indexed: %w", err)
+	}
+
+	return nil
+}
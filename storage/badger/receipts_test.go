@@ -7,9 +7,11 @@ import (
 	"github.com/dgraph-io/badger/v2"
 	"github.com/stretchr/testify/require"
 
+	"github.com/onflow/flow-go/model/flow"
 	"github.com/onflow/flow-go/module/metrics"
 	"github.com/onflow/flow-go/storage"
 	bstorage "github.com/onflow/flow-go/storage/badger"
+	"github.com/onflow/flow-go/storage/badger/operation"
 	"github.com/onflow/flow-go/utils/unittest"
 )
 
@@ -107,3 +109,77 @@ func TestReceiptStoreTwoDifferentReceiptsShouldOKIfResultAreSame(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestReceiptExecutorsByBlockID(t *testing.T) {
+	unittest.RunWithBadgerDB(t, func(db *badger.DB) {
+		metrics := metrics.NewNoopCollector()
+		results := bstorage.NewExecutionResults(metrics, db)
+		store := bstorage.NewExecutionReceipts(metrics, db, results)
+
+		blockID := unittest.IdentifierFixture()
+		executorIDs, err := store.ExecutorsByBlockID(blockID)
+		require.NoError(t, err)
+		require.Empty(t, executorIDs)
+
+		// the executors of all stored receipts for the block are indexed, once
+		receipt1 := unittest.ExecutionReceiptFixture()
+		receipt1.ExecutionResult.BlockID = blockID
+		receipt2 := unittest.ExecutionReceiptFixture()
+		receipt2.ExecutionResult.BlockID = blockID
+		for _, receipt := range []*flow.ExecutionReceipt{receipt1, receipt2, receipt1} {
+			err = store.Store(receipt)
+			require.NoError(t, err)
+		}
+
+		executorIDs, err = store.ExecutorsByBlockID(blockID)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []flow.Identifier{receipt1.ExecutorID, receipt2.ExecutorID}, executorIDs)
+	})
+}
+
+func TestReceiptIndexExecutors(t *testing.T) {
+	unittest.RunWithBadgerDB(t, func(db *badger.DB) {
+		metrics := metrics.NewNoopCollector()
+		results := bstorage.NewExecutionResults(metrics, db)
+		store := bstorage.NewExecutionReceipts(metrics, db, results)
+
+		// receipts stored before their executors were indexed
+		blockID := unittest.IdentifierFixture()
+		receipt1 := unittest.ExecutionReceiptFixture()
+		receipt1.ExecutionResult.BlockID = blockID
+		receipt2 := unittest.ExecutionReceiptFixture()
+		receipt2.ExecutionResult.BlockID = blockID
+		for _, receipt := range []*flow.ExecutionReceipt{receipt1, receipt2} {
+			err := db.Update(operation.InsertExecutionResult(&receipt.ExecutionResult))
+			require.NoError(t, err)
+			err = db.Update(operation.InsertExecutionReceiptMeta(receipt.ID(), receipt.Meta()))
+			require.NoError(t, err)
+		}
+
+		executorIDs, err := store.ExecutorsByBlockID(blockID)
+		require.NoError(t, err)
+		require.Empty(t, executorIDs)
+
+		err = store.IndexExecutors()
+		require.NoError(t, err)
+
+		executorIDs, err = store.ExecutorsByBlockID(blockID)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []flow.Identifier{receipt1.ExecutorID, receipt2.ExecutorID}, executorIDs)
+
+		// the receipts are only indexed once, receipts stored afterwards are indexed when stored
+		receipt3 := unittest.ExecutionReceiptFixture()
+		receipt3.ExecutionResult.BlockID = blockID
+		err = db.Update(operation.InsertExecutionResult(&receipt3.ExecutionResult))
+		require.NoError(t, err)
+		err = db.Update(operation.InsertExecutionReceiptMeta(receipt3.ID(), receipt3.Meta()))
+		require.NoError(t, err)
+
+		err = store.IndexExecutors()
+		require.NoError(t, err)
+
+		executorIDs, err = store.ExecutorsByBlockID(blockID)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []flow.Identifier{receipt1.ExecutorID, receipt2.ExecutorID}, executorIDs)
+	})
+}
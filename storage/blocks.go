@@ -39,4 +39,12 @@ type Blocks interface {
 
 	// GetLastFullBlockHeight retrieves the FullBlockHeight
 	GetLastFullBlockHeight() (height uint64, err error)
+
+	// UpdateLastIndexedBlockHeight records the height of the last sealed block whose events and
+	// transaction results have been indexed by the access node. Indexing resumes after it on restart.
+	UpdateLastIndexedBlockHeight(height uint64) error
+
+	// GetLastIndexedBlockHeight returns the height of the last sealed block whose events and transaction
+	// results have been indexed. It returns storage.ErrNotFound if no block has been indexed yet.
+	GetLastIndexedBlockHeight() (height uint64, err error)
 }
@@ -58,6 +58,29 @@ func (_m *ExecutionReceipts) ByID(resultID flow.Identifier) (*flow.ExecutionRece
 	return r0, r1
 }
 
+// ExecutorsByBlockID provides a mock function with given fields: blockID
+func (_m *ExecutionReceipts) ExecutorsByBlockID(blockID flow.Identifier) ([]flow.Identifier, error) {
+	ret := _m.Called(blockID)
+
+	var r0 []flow.Identifier
+	if rf, ok := ret.Get(0).(func(flow.Identifier) []flow.Identifier); ok {
+		r0 = rf(blockID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]flow.Identifier)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(flow.Identifier) error); ok {
+		r1 = rf(blockID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Index provides a mock function with given fields: blockID, resultID
 func (_m *ExecutionReceipts) Index(blockID flow.Identifier, resultID flow.Identifier) error {
 	ret := _m.Called(blockID, resultID)
@@ -94,6 +94,21 @@ func (mr *MockBlocksMockRecorder) GetLastFullBlockHeight() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastFullBlockHeight", reflect.TypeOf((*MockBlocks)(nil).GetLastFullBlockHeight))
 }
 
+// GetLastIndexedBlockHeight mocks base method
+func (m *MockBlocks) GetLastIndexedBlockHeight() (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastIndexedBlockHeight")
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastIndexedBlockHeight indicates an expected call of GetLastIndexedBlockHeight
+func (mr *MockBlocksMockRecorder) GetLastIndexedBlockHeight() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastIndexedBlockHeight", reflect.TypeOf((*MockBlocks)(nil).GetLastIndexedBlockHeight))
+}
+
 // IndexBlockForCollections mocks base method
 func (m *MockBlocks) IndexBlockForCollections(arg0 flow.Identifier, arg1 []flow.Identifier) error {
 	m.ctrl.T.Helper()
@@ -150,6 +165,20 @@ func (mr *MockBlocksMockRecorder) UpdateLastFullBlockHeight(arg0 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLastFullBlockHeight", reflect.TypeOf((*MockBlocks)(nil).UpdateLastFullBlockHeight), arg0)
 }
 
+// UpdateLastIndexedBlockHeight mocks base method
+func (m *MockBlocks) UpdateLastIndexedBlockHeight(arg0 uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLastIndexedBlockHeight", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLastIndexedBlockHeight indicates an expected call of UpdateLastIndexedBlockHeight
+func (mr *MockBlocksMockRecorder) UpdateLastIndexedBlockHeight(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLastIndexedBlockHeight", reflect.TypeOf((*MockBlocks)(nil).UpdateLastIndexedBlockHeight), arg0)
+}
+
 // MockPayloads is a mock of Payloads interface
 type MockPayloads struct {
 	ctrl     *gomock.Controller
@@ -19,4 +19,8 @@ type ExecutionReceipts interface {
 
 	// ByBlockID retrieves an execution receipt by block ID.
 	ByBlockID(blockID flow.Identifier) (*flow.ExecutionReceipt, error)
+
+	// ExecutorsByBlockID retrieves the IDs of the execution nodes which produced a stored
+	// execution receipt for the block.
+	ExecutorsByBlockID(blockID flow.Identifier) ([]flow.Identifier, error)
 }